@@ -27,6 +27,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/crash"
 	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
 	"sigs.k8s.io/external-dns/registry"
@@ -152,6 +153,39 @@ var (
 			Help:      "Number of DNS AAAA-records that exists both in source and registry.",
 		},
 	)
+	deletionThresholdExceededTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "deletion_threshold_exceeded_total",
+			Help:      "Number of reconcile loops aborted because the plan would delete more records than allowed.",
+		},
+	)
+	invariantViolationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "invariant_violations_total",
+			Help:      "Number of creates and updates skipped because they would break a DNS invariant.",
+		},
+		[]string{"reason"},
+	)
+	registryOwnershipConflictsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "registry",
+			Name:      "ownership_conflicts_total",
+			Help:      "Number of desired creates and updates skipped because the records are owned by another owner.",
+		},
+	)
+	privateRecordsPublished = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "private_records_published",
+			Help:      "Number of records classified as private which are published or about to be published in the managed zones.",
+		},
+	)
 )
 
 func init() {
@@ -170,6 +204,10 @@ func init() {
 	prometheus.MustRegister(sourceAAAARecords)
 	prometheus.MustRegister(verifiedARecords)
 	prometheus.MustRegister(verifiedAAAARecords)
+	prometheus.MustRegister(deletionThresholdExceededTotal)
+	prometheus.MustRegister(invariantViolationsTotal)
+	prometheus.MustRegister(privateRecordsPublished)
+	prometheus.MustRegister(registryOwnershipConflictsTotal)
 }
 
 // Controller is responsible for orchestrating the different components.
@@ -183,6 +221,8 @@ type Controller struct {
 	Registry registry.Registry
 	// The policy that defines which changes to DNS records are allowed
 	Policy plan.Policy
+	// Mutators adjust the desired records before the plan is calculated
+	Mutators []plan.Mutator
 	// The interval between individual synchronizations
 	Interval time.Duration
 	// The DomainFilter defines which DNS records to keep or exclude
@@ -199,6 +239,43 @@ type Controller struct {
 	ExcludeRecordTypes []string
 	// MinEventSyncInterval is used as window for batching events
 	MinEventSyncInterval time.Duration
+	// MaxDeletionsPerSync aborts a synchronization whose plan deletes more records than this. 0 disables the check.
+	MaxDeletionsPerSync int
+	// MaxDeletionPercentage aborts a synchronization whose plan deletes more than this percentage
+	// of the records owned by this instance. 0 disables the check.
+	MaxDeletionPercentage float64
+	// AdoptExistingRecords takes ownership of records without an owner which exactly match a desired endpoint
+	AdoptExistingRecords bool
+	// SharedOwnership shares the ownership of records owned by other owners which exactly match a desired endpoint
+	SharedOwnership bool
+	// CheckInvariants skips creates and updates which would break a DNS invariant
+	CheckInvariants bool
+	// CustomLabels are the labels set by the sources which the registry persists, whose changes update the records
+	CustomLabels []string
+	// CheckPrivateRecords reports records classified as private which are published in the managed zones,
+	// for instances managing public zones
+	CheckPrivateRecords bool
+	// EventRecorder reports the skipped changes and private records to the resources they originate from. nil disables it.
+	EventRecorder EventRecorder
+	// RecordEvents reports the created, updated and deleted records and the failed changes to the resources
+	// they originate from with the EventRecorder
+	RecordEvents bool
+	// PlanStore keeps the last applied changes so they can be rolled back. nil disables it.
+	PlanStore PlanStore
+	// DryRun tells the controller that the provider doesn't apply any changes, so none are stored in the PlanStore
+	DryRun bool
+	// SyncReporter receives the outcome of each synchronization. nil disables it.
+	SyncReporter SyncReporter
+	// PlanPreviewRefresh allows ServeHTTP to calculate a new plan on demand, which queries the DNS provider
+	PlanPreviewRefresh bool
+	// The runMutex serializes synchronizations and plan previews calculated on demand
+	runMutex sync.Mutex
+	// The lastPlan is the most recently calculated plan, served by ServeHTTP
+	lastPlan *PlanPreview
+	// The lastPlanChanges are the changes lastPlan is a copy of, which the registry may modify while applying them
+	lastPlanChanges *plan.Changes
+	// The lastPlanMutex is for atomic updating of lastPlan
+	lastPlanMutex sync.Mutex
 }
 
 // RunOnce runs a single iteration of a reconciliation loop.
@@ -209,67 +286,262 @@ func (c *Controller) RunOnce(ctx context.Context) error {
 	c.lastRunAt = time.Now()
 	c.runAtMutex.Unlock()
 
-	records, err := c.Registry.Records(ctx)
+	c.runMutex.Lock()
+	defer c.runMutex.Unlock()
+
+	records, plan, err := c.calculatePlan(ctx, true)
 	if err != nil {
-		registryErrorsTotal.Inc()
-		deprecatedRegistryErrors.Inc()
+		c.reportSync(ctx, nil, err)
 		return err
 	}
+	plan.Changes = c.filterChanges(plan.Changes)
+	c.addMaintenanceChanges(plan.Changes)
+	c.setLastPlan(plan.Changes, false)
+	c.reportViolations(ctx, plan.Violations)
+	registryOwnershipConflictsTotal.Add(float64(len(plan.Conflicts)))
+	if c.CheckPrivateRecords {
+		c.reportPrivateRecords(ctx, records, plan.Changes)
+	}
+	ctx = context.WithValue(ctx, provider.RecordsContextKey, records)
+
+	if plan.Changes.HasChanges() {
+		if err := c.checkDeletionThresholds(records, plan.Changes); err != nil {
+			deletionThresholdExceededTotal.Inc()
+			c.reportSync(ctx, plan, err)
+			return err
+		}
+
+		err = c.Registry.ApplyChanges(ctx, plan.Changes)
+		if err != nil {
+			registryErrorsTotal.Inc()
+			deprecatedRegistryErrors.Inc()
+			c.reportChanges(ctx, plan.Changes, err)
+			c.reportSync(ctx, plan, err)
+			return err
+		}
+		c.reportChanges(ctx, plan.Changes, nil)
+		c.setLastPlan(plan.Changes, true)
+		c.saveAppliedChanges(ctx, plan.Changes)
+	} else {
+		controllerNoChangesTotal.Inc()
+		log.Info("All records are already up to date")
+	}
+
+	lastSyncTimestamp.SetToCurrentTime()
+	c.reportSync(ctx, plan, nil)
 
-	registryEndpointsTotal.Set(float64(len(records)))
-	regARecords, regAAAARecords := countAddressRecords(records)
-	registryARecords.Set(float64(regARecords))
-	registryAAAARecords.Set(float64(regAAAARecords))
+	return nil
+}
+
+// calculatePlan fetches the current and the desired records and calculates the plan between them.
+// It returns the current records along with the calculated plan. The metrics of the registry and the
+// sources are only updated if sync is true, so a plan calculated for a preview doesn't show up as a synchronization.
+func (c *Controller) calculatePlan(ctx context.Context, sync bool) ([]*endpoint.Endpoint, *plan.Plan, error) {
+	records, err := c.Registry.Records(ctx)
+	if err != nil {
+		if sync {
+			registryErrorsTotal.Inc()
+			deprecatedRegistryErrors.Inc()
+		}
+		return nil, nil, err
+	}
+
+	if sync {
+		registryEndpointsTotal.Set(float64(len(records)))
+		regARecords, regAAAARecords := countAddressRecords(records)
+		registryARecords.Set(float64(regARecords))
+		registryAAAARecords.Set(float64(regAAAARecords))
+	}
 	ctx = context.WithValue(ctx, provider.RecordsContextKey, records)
+	ctx, report := source.WithFetchReport(ctx)
 
 	endpoints, err := c.Source.Endpoints(ctx)
 	if err != nil {
-		sourceErrorsTotal.Inc()
-		deprecatedSourceErrors.Inc()
-		return err
+		if sync {
+			sourceErrorsTotal.Inc()
+			deprecatedSourceErrors.Inc()
+		}
+		return nil, nil, err
+	}
+	if sync {
+		if failed := report.FailedSources(); failed > 0 {
+			sourceErrorsTotal.Add(float64(failed))
+			deprecatedSourceErrors.Add(float64(failed))
+		}
+		sourceEndpointsTotal.Set(float64(len(endpoints)))
+		srcARecords, srcAAAARecords := countAddressRecords(endpoints)
+		sourceARecords.Set(float64(srcARecords))
+		sourceAAAARecords.Set(float64(srcAAAARecords))
+		vARecords, vAAAARecords := countMatchingAddressRecords(endpoints, records)
+		verifiedARecords.Set(float64(vARecords))
+		verifiedAAAARecords.Set(float64(vAAAARecords))
 	}
-	sourceEndpointsTotal.Set(float64(len(endpoints)))
-	srcARecords, srcAAAARecords := countAddressRecords(endpoints)
-	sourceARecords.Set(float64(srcARecords))
-	sourceAAAARecords.Set(float64(srcAAAARecords))
-	vARecords, vAAAARecords := countMatchingAddressRecords(endpoints, records)
-	verifiedARecords.Set(float64(vARecords))
-	verifiedAAAARecords.Set(float64(vAAAARecords))
 	endpoints, err = c.Registry.AdjustEndpoints(endpoints)
 	if err != nil {
-		return fmt.Errorf("adjusting endpoints: %w", err)
+		return nil, nil, fmt.Errorf("adjusting endpoints: %w", err)
 	}
 	registryFilter := c.Registry.GetDomainFilter()
 
 	plan := &plan.Plan{
-		Policies:       []plan.Policy{c.Policy},
-		Current:        records,
-		Desired:        endpoints,
-		DomainFilter:   endpoint.MatchAllDomainFilters{c.DomainFilter, registryFilter},
-		ManagedRecords: c.ManagedRecordTypes,
-		ExcludeRecords: c.ExcludeRecordTypes,
-		OwnerID:        c.Registry.OwnerID(),
+		Policies:        []plan.Policy{c.Policy},
+		Mutators:        c.Mutators,
+		Current:         records,
+		Desired:         endpoints,
+		DomainFilter:    endpoint.MatchAllDomainFilters{c.DomainFilter, registryFilter},
+		ManagedRecords:  c.ManagedRecordTypes,
+		ExcludeRecords:  c.ExcludeRecordTypes,
+		OwnerID:         c.Registry.OwnerID(),
+		AdoptExisting:   c.AdoptExistingRecords,
+		SharedOwnership: c.SharedOwnership,
+		CheckInvariants: c.CheckInvariants,
+		CustomLabels:    c.CustomLabels,
 	}
-
 	plan = plan.Calculate()
 
-	if plan.Changes.HasChanges() {
-		err = c.Registry.ApplyChanges(ctx, plan.Changes)
-		if err != nil {
-			registryErrorsTotal.Inc()
-			deprecatedRegistryErrors.Inc()
-			return err
+	// the endpoints of failed sources are missing, so their records would be deleted, or updated to the
+	// endpoints of other sources publishing the same hostnames, e.g. with a lower priority
+	if failed := report.FailedSources(); failed > 0 && (len(plan.Changes.Delete) > 0 || len(plan.Changes.UpdateNew) > 0) {
+		log.Warnf("Skipping %d deletions and %d updates since the endpoints of %d sources are missing", len(plan.Changes.Delete), len(plan.Changes.UpdateNew), failed)
+		plan.Changes.Delete = nil
+		plan.Changes.UpdateOld = nil
+		plan.Changes.UpdateNew = nil
+	}
+
+	return records, plan, nil
+}
+
+// filterChanges drops the changes the registry holds back, e.g. the target changes of flapping records, so they
+// are neither applied nor reported as applied.
+func (c *Controller) filterChanges(changes *plan.Changes) *plan.Changes {
+	filter, ok := c.Registry.(registry.ChangeFilter)
+	if !ok {
+		return changes
+	}
+	return filter.FilterChanges(changes)
+}
+
+// addMaintenanceChanges adds the changes maintaining the registry, e.g. its heartbeats, to the changes of the plan
+// after applying the policy to them, so they are applied like any other change.
+func (c *Controller) addMaintenanceChanges(changes *plan.Changes) {
+	maintainer, ok := c.Registry.(registry.Maintainer)
+	if !ok {
+		return
+	}
+	maintenance := maintainer.MaintenanceChanges()
+	if c.Policy != nil {
+		maintenance = c.Policy.Apply(maintenance)
+	}
+	changes.Create = append(changes.Create, maintenance.Create...)
+	changes.UpdateOld = append(changes.UpdateOld, maintenance.UpdateOld...)
+	changes.UpdateNew = append(changes.UpdateNew, maintenance.UpdateNew...)
+	changes.Delete = append(changes.Delete, maintenance.Delete...)
+}
+
+// reportViolations logs the changes skipped by the invariant checks and records them as events.
+func (c *Controller) reportViolations(ctx context.Context, violations []plan.Violation) {
+	for _, v := range violations {
+		invariantViolationsTotal.WithLabelValues(v.Reason).Inc()
+		log.Warnf("Skipping %s", v)
+		if c.EventRecorder != nil {
+			c.EventRecorder.Warn(ctx, v.Endpoint, v.Reason, v.Message)
 		}
-	} else {
-		controllerNoChangesTotal.Inc()
-		log.Info("All records are already up to date")
 	}
+}
 
-	lastSyncTimestamp.SetToCurrentTime()
+// RollbackLast reverts the changes of the last applied plan stored in the PlanStore.
+// The inverse changes are stored afterwards, so a second rollback restores the original state.
+func (c *Controller) RollbackLast(ctx context.Context) error {
+	if c.PlanStore == nil {
+		return errors.New("rollback requires a plan store")
+	}
+
+	last, err := c.PlanStore.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("loading last applied plan: %w", err)
+	}
+
+	changes := last.Inverse()
+	if !changes.HasChanges() {
+		log.Info("Last applied plan has no changes to roll back")
+		return nil
+	}
+
+	records, err := c.Registry.Records(ctx)
+	if err != nil {
+		registryErrorsTotal.Inc()
+		deprecatedRegistryErrors.Inc()
+		return err
+	}
+	ctx = context.WithValue(ctx, provider.RecordsContextKey, records)
+
+	if err := c.checkDeletionThresholds(records, changes); err != nil {
+		deletionThresholdExceededTotal.Inc()
+		return err
+	}
+
+	log.Infof("Rolling back last applied plan: %d creates, %d updates, %d deletes", len(changes.Create), len(changes.UpdateNew), len(changes.Delete))
+	if err := c.Registry.ApplyChanges(ctx, changes); err != nil {
+		registryErrorsTotal.Inc()
+		deprecatedRegistryErrors.Inc()
+		return err
+	}
+	c.saveAppliedChanges(ctx, changes)
+
+	return nil
+}
+
+// saveAppliedChanges stores the changes in the PlanStore, if any. Failures are only logged since
+// the changes have already been applied. Nothing is stored in dry-run mode, where nothing has been applied.
+func (c *Controller) saveAppliedChanges(ctx context.Context, changes *plan.Changes) {
+	if c.PlanStore == nil || c.DryRun {
+		return
+	}
+	if err := c.PlanStore.Save(ctx, changes); err != nil {
+		log.Errorf("Failed to store applied plan, it can't be rolled back: %v", err)
+	}
+}
+
+// checkDeletionThresholds protects a zone from being wiped, e.g. by a source returning no
+// endpoints during an outage, by refusing plans that delete more records than configured.
+func (c *Controller) checkDeletionThresholds(records []*endpoint.Endpoint, changes *plan.Changes) error {
+	deletions := len(changes.Delete)
+	if deletions == 0 {
+		return nil
+	}
+
+	if c.MaxDeletionsPerSync > 0 && deletions > c.MaxDeletionsPerSync {
+		return provider.NewSoftError(fmt.Errorf("plan deletes %d records, which exceeds the maximum of %d deletions per sync, not applying changes", deletions, c.MaxDeletionsPerSync))
+	}
+
+	if c.MaxDeletionPercentage > 0 {
+		owned := c.countOwnedRecords(records)
+		if owned > 0 {
+			percentage := float64(deletions) * 100 / float64(owned)
+			if percentage > c.MaxDeletionPercentage {
+				return provider.NewSoftError(fmt.Errorf("plan deletes %d of %d owned records (%.1f%%), which exceeds the maximum of %.1f%%, not applying changes", deletions, owned, percentage, c.MaxDeletionPercentage))
+			}
+		}
+	}
 
 	return nil
 }
 
+// countOwnedRecords counts the managed records owned by the registry owner.
+// Without an owner ID all managed records are considered owned.
+func (c *Controller) countOwnedRecords(records []*endpoint.Endpoint) int {
+	ownerID := c.Registry.OwnerID()
+	count := 0
+	for _, record := range records {
+		if !plan.IsManagedRecord(record.RecordType, c.ManagedRecordTypes, c.ExcludeRecordTypes) {
+			continue
+		}
+		if ownerID == "" || record.IsOwnedBy(ownerID) {
+			count++
+		}
+	}
+	return count
+}
+
 func earliest(r time.Time, times ...time.Time) time.Time {
 	for _, t := range times {
 		if t.Before(r) {
@@ -358,7 +630,8 @@ func (c *Controller) Run(ctx context.Context) {
 	for {
 		if c.ShouldRunOnce(time.Now()) {
 			if err := c.RunOnce(ctx); err != nil {
-				if errors.Is(err, provider.SoftError) {
+				var crashErr *crash.Error
+				if errors.Is(err, provider.SoftError) || errors.As(err, &crashErr) {
 					log.Errorf("Failed to do run once: %v", err)
 				} else {
 					log.Fatalf("Failed to do run once: %v", err)
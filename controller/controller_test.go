@@ -26,6 +26,8 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/client-go/kubernetes/fake"
 
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/internal/testutils"
@@ -33,6 +35,7 @@ import (
 	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
 	"sigs.k8s.io/external-dns/registry"
+	"sigs.k8s.io/external-dns/source"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -739,3 +742,216 @@ func TestAAAARecords(t *testing.T) {
 	assert.Equal(t, math.Float64bits(2), valueFromMetric(sourceAAAARecords))
 	assert.Equal(t, math.Float64bits(1), valueFromMetric(registryAAAARecords))
 }
+
+func TestRunOnceSkipsDeletesWithFailedSources(t *testing.T) {
+	failing := new(testutils.MockSource)
+	failing.On("Endpoints").Return(nil, errors.New("some error"))
+	working := new(testutils.MockSource)
+	working.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("create-record.used.tld", endpoint.RecordTypeA, "1.2.3.4"),
+	}, nil)
+
+	dnsProvider := newMockProvider([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("delete-record.used.tld", endpoint.RecordTypeA, "4.3.2.1"),
+	}, &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("create-record.used.tld", endpoint.RecordTypeA, "1.2.3.4")},
+	})
+	r, err := registry.NewNoopRegistry(dnsProvider)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             source.NewMultiSource([]source.Source{failing, working}, nil, nil, 0, source.SourceFailurePolicySkipDeletes),
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+	}
+
+	before := testutil.ToFloat64(sourceErrorsTotal)
+	assert.NoError(t, ctrl.RunOnce(context.Background()))
+	assert.Equal(t, before+1, testutil.ToFloat64(sourceErrorsTotal))
+}
+
+func TestRunOnceKeepsHostnamesOfFailedPrioritySource(t *testing.T) {
+	// the hostname is published by the failing source with the higher priority
+	failing := new(testutils.MockSource)
+	failing.On("Endpoints").Return(nil, errors.New("some error"))
+	lower := new(testutils.MockSource)
+	lower.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("shared.used.tld", endpoint.RecordTypeA, "5.6.7.8"),
+		endpoint.NewEndpoint("create-record.used.tld", endpoint.RecordTypeA, "1.2.3.4"),
+	}, nil)
+
+	dnsProvider := &filteredMockProvider{
+		RecordsStore: []*endpoint.Endpoint{endpoint.NewEndpoint("shared.used.tld", endpoint.RecordTypeA, "4.3.2.1")},
+	}
+	r, err := registry.NewNoopRegistry(dnsProvider)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             source.NewMultiSource([]source.Source{failing, lower}, []int{0, 1}, nil, 0, source.SourceFailurePolicySkipDeletes),
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+	}
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	require.Len(t, dnsProvider.ApplyChangesCalls, 1)
+	changes := dnsProvider.ApplyChangesCalls[0]
+	require.Len(t, changes.Create, 1)
+	assert.Equal(t, "create-record.used.tld", changes.Create[0].DNSName)
+	assert.Empty(t, changes.UpdateNew)
+	assert.Empty(t, changes.Delete)
+}
+
+func TestRunOnceDeletionThresholds(t *testing.T) {
+	for _, tc := range []struct {
+		title                 string
+		maxDeletionsPerSync   int
+		maxDeletionPercentage float64
+		aborted               bool
+	}{
+		{title: "thresholds disabled"},
+		{title: "deletions below maximum", maxDeletionsPerSync: 2},
+		{title: "deletions above maximum", maxDeletionsPerSync: 1, aborted: true},
+		{title: "percentage below maximum", maxDeletionPercentage: 50},
+		{title: "percentage above maximum", maxDeletionPercentage: 49, aborted: true},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			source := getTestSource()
+			cfg := getTestConfig()
+			dnsProvider := getTestProvider()
+
+			r, err := registry.NewNoopRegistry(dnsProvider)
+			require.NoError(t, err)
+
+			ctrl := &Controller{
+				Source:                source,
+				Registry:              r,
+				Policy:                &plan.SyncPolicy{},
+				ManagedRecordTypes:    cfg.ManagedDNSRecordTypes,
+				MaxDeletionsPerSync:   tc.maxDeletionsPerSync,
+				MaxDeletionPercentage: tc.maxDeletionPercentage,
+			}
+
+			before := testutil.ToFloat64(deletionThresholdExceededTotal)
+			err = ctrl.RunOnce(context.Background())
+			if tc.aborted {
+				assert.ErrorIs(t, err, provider.SoftError)
+				assert.Equal(t, before+1, testutil.ToFloat64(deletionThresholdExceededTotal))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, before, testutil.ToFloat64(deletionThresholdExceededTotal))
+			}
+		})
+	}
+}
+
+// maintainingRegistry is a registry returning fixed maintenance changes.
+type maintainingRegistry struct {
+	registry.Registry
+	changes *plan.Changes
+}
+
+func (r *maintainingRegistry) MaintenanceChanges() *plan.Changes {
+	return &plan.Changes{Create: r.changes.Create, Delete: r.changes.Delete}
+}
+
+func TestRunOnceMaintenanceChanges(t *testing.T) {
+	heartbeat := endpoint.NewEndpoint("owner.heartbeat.example.org", endpoint.RecordTypeTXT, "heartbeat")
+	stale := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpoint("b.example.org", endpoint.RecordTypeA, "1.2.3.5"),
+	}
+	for _, tc := range []struct {
+		title               string
+		policy              plan.Policy
+		maxDeletionsPerSync int
+		expected            *plan.Changes
+	}{
+		{
+			title:    "sync policy",
+			policy:   &plan.SyncPolicy{},
+			expected: &plan.Changes{Create: []*endpoint.Endpoint{heartbeat}, Delete: stale},
+		},
+		{
+			title:    "upsert-only policy",
+			policy:   &plan.UpsertOnlyPolicy{},
+			expected: &plan.Changes{Create: []*endpoint.Endpoint{heartbeat}},
+		},
+		{
+			title:               "deletion threshold",
+			policy:              &plan.SyncPolicy{},
+			maxDeletionsPerSync: 1,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			source := new(testutils.MockSource)
+			source.On("Endpoints").Return([]*endpoint.Endpoint{}, nil)
+			dnsProvider := &filteredMockProvider{}
+			noop, err := registry.NewNoopRegistry(dnsProvider)
+			require.NoError(t, err)
+
+			ctrl := &Controller{
+				Source:              source,
+				Registry:            &maintainingRegistry{Registry: noop, changes: &plan.Changes{Create: []*endpoint.Endpoint{heartbeat}, Delete: stale}},
+				Policy:              tc.policy,
+				ManagedRecordTypes:  []string{endpoint.RecordTypeA, endpoint.RecordTypeTXT},
+				MaxDeletionsPerSync: tc.maxDeletionsPerSync,
+			}
+
+			err = ctrl.RunOnce(context.Background())
+			if tc.expected == nil {
+				assert.ErrorIs(t, err, provider.SoftError)
+				assert.Empty(t, dnsProvider.ApplyChangesCalls)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, dnsProvider.ApplyChangesCalls, 1)
+			assert.Equal(t, tc.expected.Create, dnsProvider.ApplyChangesCalls[0].Create)
+			assert.Equal(t, tc.expected.Delete, dnsProvider.ApplyChangesCalls[0].Delete)
+		})
+	}
+}
+
+// filteringRegistry is a registry holding back all updates.
+type filteringRegistry struct {
+	registry.Registry
+}
+
+func (r *filteringRegistry) FilterChanges(changes *plan.Changes) *plan.Changes {
+	return &plan.Changes{Create: changes.Create, Delete: changes.Delete}
+}
+
+func TestRunOnceFilteredChanges(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "1.2.3.5"),
+		endpoint.NewEndpoint("b.example.org", endpoint.RecordTypeA, "1.2.3.6"),
+	}, nil)
+	dnsProvider := &filteredMockProvider{
+		RecordsStore: []*endpoint.Endpoint{endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+	}
+	noop, err := registry.NewNoopRegistry(dnsProvider)
+	require.NoError(t, err)
+	store := NewConfigMapPlanStore(fake.NewSimpleClientset(), "default", "external-dns-last-plan")
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           &filteringRegistry{Registry: noop},
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		PlanStore:          store,
+	}
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+
+	// the update held back by the registry is neither applied nor reported as applied
+	require.Len(t, dnsProvider.ApplyChangesCalls, 1)
+	assert.Empty(t, dnsProvider.ApplyChangesCalls[0].UpdateNew)
+	assert.Len(t, dnsProvider.ApplyChangesCalls[0].Create, 1)
+	assert.True(t, ctrl.lastPlan.Applied)
+	assert.Empty(t, ctrl.lastPlan.Changes.UpdateNew)
+	last, err := store.Load(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, last.UpdateNew)
+	assert.Len(t, last.Create, 1)
+}
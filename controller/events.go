@@ -0,0 +1,202 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// eventInterval is the interval after which an event with the same reason and message is recorded again.
+const eventInterval = time.Hour
+
+// resourceKinds maps the kinds used in the resource label of endpoints to Kubernetes kinds.
+var resourceKinds = map[string]string{
+	"service":        "Service",
+	"ingress":        "Ingress",
+	"crd":            "DNSEndpoint",
+	"gateway":        "Gateway",
+	"virtualservice": "VirtualService",
+	"route":          "Route",
+	"node":           "Node",
+	"pod":            "Pod",
+}
+
+// Reasons of the events recorded for the changes applied to the records.
+const (
+	reasonRecordCreated     = "RecordCreated"
+	reasonRecordUpdated     = "RecordUpdated"
+	reasonRecordDeleted     = "RecordDeleted"
+	reasonRecordApplyFailed = "RecordApplyFailed"
+)
+
+// reasonInvalidEndpoint is the reason of the warnings recorded for the endpoints skipped by the sources.
+const reasonInvalidEndpoint = "InvalidEndpoint"
+
+// EventRecorder reports problems with endpoints and the outcome of their changes to the resources they originate from.
+type EventRecorder interface {
+	Warn(ctx context.Context, ep *endpoint.Endpoint, reason, message string)
+	Normal(ctx context.Context, ep *endpoint.Endpoint, reason, message string)
+}
+
+// kubeEventRecorder records Kubernetes events on the resources named by the resource label of
+// the endpoints, so they show up next to the resource the problem has to be fixed in.
+type kubeEventRecorder struct {
+	client kubernetes.Interface
+	now    func() time.Time
+
+	// recorded holds the time an event has last been recorded, so the same problem found on every
+	// synchronization doesn't flood the API server
+	recorded      map[string]time.Time
+	recordedMutex sync.Mutex
+}
+
+// NewKubeEventRecorder returns an EventRecorder creating Kubernetes events.
+func NewKubeEventRecorder(client kubernetes.Interface) EventRecorder {
+	return &kubeEventRecorder{
+		client:   client,
+		now:      time.Now,
+		recorded: map[string]time.Time{},
+	}
+}
+
+// Warn records a warning event on the resource of the endpoint. Endpoints without a resource are ignored.
+func (r *kubeEventRecorder) Warn(ctx context.Context, ep *endpoint.Endpoint, reason, message string) {
+	r.record(ctx, ep, corev1.EventTypeWarning, reason, message)
+}
+
+// Normal records a normal event on the resource of the endpoint. Endpoints without a resource are ignored.
+func (r *kubeEventRecorder) Normal(ctx context.Context, ep *endpoint.Endpoint, reason, message string) {
+	r.record(ctx, ep, corev1.EventTypeNormal, reason, message)
+}
+
+// record creates an event of the given type on the resource of the endpoint.
+func (r *kubeEventRecorder) record(ctx context.Context, ep *endpoint.Endpoint, eventType, reason, message string) {
+	resource := ep.Labels[endpoint.ResourceLabelKey]
+	kind, namespace, name, ok := parseResource(resource)
+	if !ok {
+		log.Debugf("Not recording event %s for %s without resource", reason, ep.DNSName)
+		return
+	}
+	if !r.due(resource + " " + reason + " " + message) {
+		return
+	}
+
+	t := r.now()
+	now := metav1.NewTime(t)
+	eventNamespace := namespace
+	if eventNamespace == "" {
+		eventNamespace = metav1.NamespaceDefault
+	}
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			// named like the events of the client-go event recorder
+			Name:      fmt.Sprintf("%v.%x", name, t.UnixNano()),
+			Namespace: eventNamespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:      kind,
+			Namespace: namespace,
+			Name:      name,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: "external-dns"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err := r.client.CoreV1().Events(eventNamespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		log.Warnf("Failed to record event %s for %s: %v", reason, resource, err)
+	}
+}
+
+// due returns true if the event with the given key hasn't been recorded within the event interval.
+func (r *kubeEventRecorder) due(key string) bool {
+	r.recordedMutex.Lock()
+	defer r.recordedMutex.Unlock()
+
+	now := r.now()
+	if last, ok := r.recorded[key]; ok && now.Sub(last) < eventInterval {
+		return false
+	}
+	r.recorded[key] = now
+	return true
+}
+
+// parseResource splits a resource label of the form <kind>/<namespace>/<name>, or <kind>/<name>
+// for cluster scoped resources like nodes.
+func parseResource(resource string) (kind, namespace, name string, ok bool) {
+	parts := strings.Split(resource, "/")
+	if len(parts) == 2 {
+		parts = []string{parts[0], "", parts[1]}
+	}
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return "", "", "", false
+	}
+	kind = parts[0]
+	if k, known := resourceKinds[strings.ToLower(kind)]; known {
+		kind = k
+	}
+	return kind, parts[1], parts[2], true
+}
+
+// reportChanges records an event for every record created, updated or deleted by the changes on the
+// resource it originates from, or a warning for every changed record if applying the changes failed.
+func (c *Controller) reportChanges(ctx context.Context, changes *plan.Changes, err error) {
+	if c.EventRecorder == nil || !c.RecordEvents {
+		return
+	}
+	if err != nil {
+		for _, ep := range slices.Concat(changes.Create, changes.UpdateNew, changes.Delete) {
+			c.EventRecorder.Warn(ctx, ep, reasonRecordApplyFailed, fmt.Sprintf("Failed to apply the changes of %s record %s: %v", ep.RecordType, ep.DNSName, err))
+		}
+		return
+	}
+	for _, ep := range changes.Create {
+		c.EventRecorder.Normal(ctx, ep, reasonRecordCreated, fmt.Sprintf("Created %s record %s with targets %s", ep.RecordType, ep.DNSName, strings.Join(ep.Targets, ", ")))
+	}
+	for _, ep := range changes.UpdateNew {
+		c.EventRecorder.Normal(ctx, ep, reasonRecordUpdated, fmt.Sprintf("Updated %s record %s to targets %s", ep.RecordType, ep.DNSName, strings.Join(ep.Targets, ", ")))
+	}
+	for _, ep := range changes.Delete {
+		c.EventRecorder.Normal(ctx, ep, reasonRecordDeleted, fmt.Sprintf("Deleted %s record %s", ep.RecordType, ep.DNSName))
+	}
+}
+
+// ReportInvalidEndpoint records a warning event on the resource of an endpoint skipped by a source because it
+// is invalid, see source.SetInvalidEndpointHandler. Endpoints without a resource are only logged by the source.
+func (c *Controller) ReportInvalidEndpoint(resource string, err error) {
+	if c.EventRecorder == nil || resource == "" {
+		return
+	}
+	ep := &endpoint.Endpoint{Labels: endpoint.Labels{endpoint.ResourceLabelKey: resource}}
+	c.EventRecorder.Warn(context.Background(), ep, reasonInvalidEndpoint, fmt.Sprintf("Skipping invalid endpoint: %v", err))
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+type recordedEvent struct {
+	dnsName, reason, message string
+}
+
+// fakeEventRecorder keeps the recorded events in memory.
+type fakeEventRecorder struct {
+	events []recordedEvent
+}
+
+func (r *fakeEventRecorder) Warn(ctx context.Context, ep *endpoint.Endpoint, reason, message string) {
+	r.events = append(r.events, recordedEvent{ep.DNSName, reason, message})
+}
+
+func (r *fakeEventRecorder) Normal(ctx context.Context, ep *endpoint.Endpoint, reason, message string) {
+	r.events = append(r.events, recordedEvent{ep.DNSName, reason, message})
+}
+
+func TestKubeEventRecorder(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	now := time.Unix(1717430400, 0)
+	recorder := NewKubeEventRecorder(client).(*kubeEventRecorder)
+	recorder.now = func() time.Time { return now }
+
+	ep := endpoint.NewEndpoint("_sip._tcp.example.com", endpoint.RecordTypeSRV, "10 5 5060 192.0.2.1")
+	ep.Labels[endpoint.ResourceLabelKey] = "service/default/sip"
+	recorder.Warn(ctx, ep, plan.ViolationSRVTargetNotHostname, "target 192.0.2.1 is an IP address, not a hostname")
+
+	events, err := client.CoreV1().Events("default").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1)
+	event := events.Items[0]
+	assert.Equal(t, corev1.ObjectReference{Kind: "Service", Namespace: "default", Name: "sip"}, event.InvolvedObject)
+	assert.Equal(t, corev1.EventTypeWarning, event.Type)
+	assert.Equal(t, plan.ViolationSRVTargetNotHostname, event.Reason)
+
+	// the same event is recorded again only after the event interval
+	recorder.Warn(ctx, ep, plan.ViolationSRVTargetNotHostname, "target 192.0.2.1 is an IP address, not a hostname")
+	now = now.Add(eventInterval)
+	recorder.Warn(ctx, ep, plan.ViolationSRVTargetNotHostname, "target 192.0.2.1 is an IP address, not a hostname")
+	events, err = client.CoreV1().Events("default").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, events.Items, 2)
+
+	// endpoints without a resource are ignored
+	recorder.Warn(ctx, endpoint.NewEndpoint("static.example.com", endpoint.RecordTypeCNAME, "example.com"), plan.ViolationCNAMEConflict, "conflict")
+	events, err = client.CoreV1().Events("").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, events.Items, 2)
+}
+
+func TestKubeEventRecorderNormal(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+
+	ep := endpoint.NewEndpoint("app.example.com", endpoint.RecordTypeA, "192.0.2.1")
+	ep.Labels[endpoint.ResourceLabelKey] = "ingress/apps/app"
+	NewKubeEventRecorder(client).Normal(ctx, ep, reasonRecordCreated, "Created A record app.example.com with targets 192.0.2.1")
+
+	events, err := client.CoreV1().Events("apps").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1)
+	event := events.Items[0]
+	assert.Equal(t, corev1.ObjectReference{Kind: "Ingress", Namespace: "apps", Name: "app"}, event.InvolvedObject)
+	assert.Equal(t, corev1.EventTypeNormal, event.Type)
+	assert.Equal(t, reasonRecordCreated, event.Reason)
+}
+
+func TestParseResource(t *testing.T) {
+	for _, tc := range []struct {
+		resource              string
+		kind, namespace, name string
+		ok                    bool
+	}{
+		{resource: "service/default/sip", kind: "Service", namespace: "default", name: "sip", ok: true},
+		{resource: "pod/kube-system/coredns-0", kind: "Pod", namespace: "kube-system", name: "coredns-0", ok: true},
+		{resource: "node/worker-1", kind: "Node", name: "worker-1", ok: true},
+		{resource: "Unknown/default/thing", kind: "Unknown", namespace: "default", name: "thing", ok: true},
+		{resource: ""},
+		{resource: "node"},
+		{resource: "service/default/"},
+		{resource: "a/b/c/d"},
+	} {
+		t.Run(tc.resource, func(t *testing.T) {
+			kind, namespace, name, ok := parseResource(tc.resource)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.kind, kind)
+			assert.Equal(t, tc.namespace, namespace)
+			assert.Equal(t, tc.name, name)
+		})
+	}
+}
+
+func TestReportInvalidEndpoint(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	ctrl := &Controller{EventRecorder: NewKubeEventRecorder(client)}
+
+	ctrl.ReportInvalidEndpoint("ingress/apps/app", errors.New(`target "lb" of A record "app.example.com" is invalid: not an IPv4 address`))
+	// endpoints without a resource are only logged
+	ctrl.ReportInvalidEndpoint("", errors.New("invalid"))
+
+	events, err := client.CoreV1().Events("").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1)
+	event := events.Items[0]
+	assert.Equal(t, corev1.ObjectReference{Kind: "Ingress", Namespace: "apps", Name: "app"}, event.InvolvedObject)
+	assert.Equal(t, corev1.EventTypeWarning, event.Type)
+	assert.Equal(t, reasonInvalidEndpoint, event.Reason)
+	assert.Contains(t, event.Message, "not an IPv4 address")
+}
+
+func TestRunOnceReportsViolations(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("valid.example.com", endpoint.RecordTypeA, "192.0.2.1"),
+		endpoint.NewEndpoint("_sip._tcp.example.com", endpoint.RecordTypeSRV, "10 5 5060 192.0.2.1"),
+	}, nil)
+	r, err := registry.NewNoopRegistry(newMockProvider(nil, &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("valid.example.com", endpoint.RecordTypeA, "192.0.2.1")},
+	}))
+	require.NoError(t, err)
+
+	recorder := &fakeEventRecorder{}
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA, endpoint.RecordTypeSRV},
+		CheckInvariants:    true,
+		EventRecorder:      recorder,
+	}
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	assert.Equal(t, []recordedEvent{{
+		dnsName: "_sip._tcp.example.com",
+		reason:  plan.ViolationSRVTargetNotHostname,
+		message: "target 192.0.2.1 is an IP address, not a hostname",
+	}}, recorder.events)
+}
+
+func TestRunOnceReportsChanges(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("create-record.example.com", endpoint.RecordTypeA, "192.0.2.1"),
+		endpoint.NewEndpoint("update-record.example.com", endpoint.RecordTypeA, "192.0.2.2", "192.0.2.3"),
+	}, nil)
+	records := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("update-record.example.com", endpoint.RecordTypeA, "192.0.2.4"),
+		endpoint.NewEndpoint("delete-record.example.com", endpoint.RecordTypeA, "192.0.2.5"),
+	}
+	changes := &plan.Changes{
+		Create:    []*endpoint.Endpoint{endpoint.NewEndpoint("create-record.example.com", endpoint.RecordTypeA, "192.0.2.1")},
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpoint("update-record.example.com", endpoint.RecordTypeA, "192.0.2.4")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpoint("update-record.example.com", endpoint.RecordTypeA, "192.0.2.2", "192.0.2.3")},
+		Delete:    []*endpoint.Endpoint{endpoint.NewEndpoint("delete-record.example.com", endpoint.RecordTypeA, "192.0.2.5")},
+	}
+
+	t.Run("applied", func(t *testing.T) {
+		r, err := registry.NewNoopRegistry(newMockProvider(records, changes))
+		require.NoError(t, err)
+
+		recorder := &fakeEventRecorder{}
+		ctrl := &Controller{
+			Source:             source,
+			Registry:           r,
+			Policy:             &plan.SyncPolicy{},
+			ManagedRecordTypes: []string{endpoint.RecordTypeA},
+			EventRecorder:      recorder,
+			RecordEvents:       true,
+		}
+
+		require.NoError(t, ctrl.RunOnce(context.Background()))
+		assert.Equal(t, []recordedEvent{
+			{dnsName: "create-record.example.com", reason: reasonRecordCreated, message: "Created A record create-record.example.com with targets 192.0.2.1"},
+			{dnsName: "update-record.example.com", reason: reasonRecordUpdated, message: "Updated A record update-record.example.com to targets 192.0.2.2, 192.0.2.3"},
+			{dnsName: "delete-record.example.com", reason: reasonRecordDeleted, message: "Deleted A record delete-record.example.com"},
+		}, recorder.events)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		// the provider expects other changes and fails to apply them
+		r, err := registry.NewNoopRegistry(newMockProvider(records, &plan.Changes{}))
+		require.NoError(t, err)
+
+		recorder := &fakeEventRecorder{}
+		ctrl := &Controller{
+			Source:             source,
+			Registry:           r,
+			Policy:             &plan.SyncPolicy{},
+			ManagedRecordTypes: []string{endpoint.RecordTypeA},
+			EventRecorder:      recorder,
+			RecordEvents:       true,
+		}
+
+		require.Error(t, ctrl.RunOnce(context.Background()))
+		require.Len(t, recorder.events, 3)
+		for _, event := range recorder.events {
+			assert.Equal(t, reasonRecordApplyFailed, event.reason)
+			assert.Contains(t, event.message, "number of records is wrong")
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		r, err := registry.NewNoopRegistry(newMockProvider(records, changes))
+		require.NoError(t, err)
+
+		recorder := &fakeEventRecorder{}
+		ctrl := &Controller{
+			Source:             source,
+			Registry:           r,
+			Policy:             &plan.SyncPolicy{},
+			ManagedRecordTypes: []string{endpoint.RecordTypeA},
+			EventRecorder:      recorder,
+		}
+
+		require.NoError(t, ctrl.RunOnce(context.Background()))
+		assert.Empty(t, recorder.events)
+	})
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// PlanPreview describes a calculated plan as served by the plan preview endpoint.
+type PlanPreview struct {
+	// CalculatedAt is the time the plan was calculated at
+	CalculatedAt time.Time `json:"calculatedAt"`
+	// Applied is true once the changes have been applied successfully
+	Applied bool `json:"applied"`
+	// Changes are the changes of the plan
+	Changes *plan.Changes `json:"changes"`
+}
+
+// setLastPlan stores a copy of the changes, since the registry modifies the labels of the endpoints while
+// applying them, concurrently to ServeHTTP encoding them.
+func (c *Controller) setLastPlan(changes *plan.Changes, applied bool) {
+	c.lastPlanMutex.Lock()
+	defer c.lastPlanMutex.Unlock()
+	if applied && c.lastPlan != nil && c.lastPlanChanges == changes {
+		c.lastPlan.Applied = true
+		return
+	}
+	c.lastPlanChanges = changes
+	c.lastPlan = &PlanPreview{
+		CalculatedAt: time.Now(),
+		Applied:      applied,
+		Changes:      copyChanges(changes),
+	}
+}
+
+// copyChanges returns a deep copy of the changes.
+func copyChanges(changes *plan.Changes) *plan.Changes {
+	copyEndpoints := func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+		if endpoints == nil {
+			return nil
+		}
+		copied := make([]*endpoint.Endpoint, len(endpoints))
+		for i, ep := range endpoints {
+			copied[i] = ep.DeepCopy()
+		}
+		return copied
+	}
+	return &plan.Changes{
+		Create:    copyEndpoints(changes.Create),
+		UpdateOld: copyEndpoints(changes.UpdateOld),
+		UpdateNew: copyEndpoints(changes.UpdateNew),
+		Delete:    copyEndpoints(changes.Delete),
+	}
+}
+
+// LastPlan returns the most recently calculated plan, or nil if no plan has been calculated yet.
+func (c *Controller) LastPlan() *PlanPreview {
+	c.lastPlanMutex.Lock()
+	defer c.lastPlanMutex.Unlock()
+	if c.lastPlan == nil {
+		return nil
+	}
+	preview := *c.lastPlan
+	return &preview
+}
+
+// PreviewPlan calculates a plan from the current state of the source and the registry without applying it.
+// Unlike a synchronization, it leaves the metrics and the last plan untouched.
+func (c *Controller) PreviewPlan(r *http.Request) (*PlanPreview, error) {
+	c.runMutex.Lock()
+	defer c.runMutex.Unlock()
+
+	_, plan, err := c.calculatePlan(r.Context(), false)
+	if err != nil {
+		return nil, err
+	}
+	return &PlanPreview{CalculatedAt: time.Now(), Changes: plan.Changes}, nil
+}
+
+// ServeHTTP serves the most recently calculated plan as JSON. With the query parameter refresh=true
+// a new plan is calculated instead, which queries the DNS provider, if PlanPreviewRefresh allows it.
+func (c *Controller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	refresh, err := strconv.ParseBool(r.URL.Query().Get("refresh"))
+	if err != nil && r.URL.Query().Has("refresh") {
+		http.Error(w, "invalid value of refresh", http.StatusBadRequest)
+		return
+	}
+
+	if refresh && !c.PlanPreviewRefresh {
+		http.Error(w, "refreshing the plan is disabled", http.StatusForbidden)
+		return
+	}
+
+	var preview *PlanPreview
+	if refresh {
+		preview, err = c.PreviewPlan(r)
+		if err != nil {
+			log.Errorf("Failed to calculate plan preview: %v", err)
+			http.Error(w, "failed to calculate plan", http.StatusInternalServerError)
+			return
+		}
+	} else if preview = c.LastPlan(); preview == nil {
+		http.Error(w, "no plan has been calculated yet", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(preview); err != nil {
+		log.Errorf("Failed to write plan preview: %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+func servePlan(ctrl *Controller, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	ctrl.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestServePlan(t *testing.T) {
+	cfg := getTestConfig()
+	r, err := registry.NewNoopRegistry(getTestProvider())
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             getTestSource(),
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: cfg.ManagedDNSRecordTypes,
+	}
+
+	assert.Equal(t, http.StatusNotFound, servePlan(ctrl, http.MethodGet, "/plan").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, servePlan(ctrl, http.MethodPost, "/plan").Code)
+	assert.Equal(t, http.StatusForbidden, servePlan(ctrl, http.MethodGet, "/plan?refresh=true").Code)
+	ctrl.PlanPreviewRefresh = true
+	assert.Equal(t, http.StatusBadRequest, servePlan(ctrl, http.MethodGet, "/plan?refresh=maybe").Code)
+
+	// a refreshed plan is calculated but not applied, and isn't reported as a synchronization
+	lastReconcileTimestamp.Set(0)
+	registryEndpointsTotal.Set(0)
+	sourceEndpointsTotal.Set(0)
+	w := servePlan(ctrl, http.MethodGet, "/plan?refresh=true")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var preview PlanPreview
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+	assert.False(t, preview.Applied)
+	assert.Len(t, preview.Changes.Create, 2)
+	assert.Len(t, preview.Changes.UpdateNew, 2)
+	assert.Len(t, preview.Changes.Delete, 2)
+	assert.Nil(t, ctrl.LastPlan())
+	assert.Zero(t, testutil.ToFloat64(lastReconcileTimestamp))
+	assert.Zero(t, testutil.ToFloat64(registryEndpointsTotal))
+	assert.Zero(t, testutil.ToFloat64(sourceEndpointsTotal))
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+
+	w = servePlan(ctrl, http.MethodGet, "/plan")
+	require.Equal(t, http.StatusOK, w.Code)
+	preview = PlanPreview{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preview))
+	assert.True(t, preview.Applied)
+	assert.False(t, preview.CalculatedAt.IsZero())
+	assert.Len(t, preview.Changes.Create, 2)
+	assert.Len(t, preview.Changes.Delete, 2)
+}
+
+func TestLastPlanNotApplied(t *testing.T) {
+	cfg := getTestConfig()
+	r, err := registry.NewNoopRegistry(getTestProvider())
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:              getTestSource(),
+		Registry:            r,
+		Policy:              &plan.SyncPolicy{},
+		ManagedRecordTypes:  cfg.ManagedDNSRecordTypes,
+		MaxDeletionsPerSync: 1,
+	}
+
+	// the plan exceeding the deletion threshold is kept as pending
+	require.Error(t, ctrl.RunOnce(context.Background()))
+	preview := ctrl.LastPlan()
+	require.NotNil(t, preview)
+	assert.False(t, preview.Applied)
+	assert.Len(t, preview.Changes.Delete, 2)
+}
+
+func TestLastPlanIsCopied(t *testing.T) {
+	ep := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	changes := &plan.Changes{Create: []*endpoint.Endpoint{ep}}
+
+	ctrl := &Controller{}
+	ctrl.setLastPlan(changes, false)
+	// the registry sets the owner while applying the changes
+	ep.Labels[endpoint.OwnerLabelKey] = "default"
+	ctrl.setLastPlan(changes, true)
+
+	preview := ctrl.LastPlan()
+	require.NotNil(t, preview)
+	assert.True(t, preview.Applied)
+	require.Len(t, preview.Changes.Create, 1)
+	assert.NotSame(t, ep, preview.Changes.Create[0])
+	assert.Empty(t, preview.Changes.Create[0].Labels[endpoint.OwnerLabelKey])
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+const lastPlanKey = "changes.json"
+
+// ErrNoLastPlan is returned by a PlanStore which has not stored any plan yet.
+var ErrNoLastPlan = errors.New("no applied plan has been stored")
+
+// PlanStore persists the changes of the last successfully applied plan, so they can be rolled back.
+type PlanStore interface {
+	Save(ctx context.Context, changes *plan.Changes) error
+	Load(ctx context.Context) (*plan.Changes, error)
+}
+
+// ConfigMapPlanStore stores the last applied changes as JSON in a ConfigMap.
+type ConfigMapPlanStore struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// NewConfigMapPlanStore returns a PlanStore using the ConfigMap with the given name and namespace.
+// The ConfigMap is created on the first save if it doesn't exist.
+func NewConfigMapPlanStore(client kubernetes.Interface, namespace, name string) *ConfigMapPlanStore {
+	return &ConfigMapPlanStore{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+// Save replaces the stored changes with the given ones.
+func (s *ConfigMapPlanStore) Save(ctx context.Context, changes *plan.Changes) error {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal applied changes: %w", err)
+	}
+
+	configMaps := s.client.CoreV1().ConfigMaps(s.namespace)
+	cm, err := configMaps.Get(ctx, s.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: s.namespace,
+				Name:      s.name,
+			},
+			Data: map[string]string{lastPlanKey: string(data)},
+		}
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[lastPlanKey] = string(data)
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
+
+// Load returns the stored changes or ErrNoLastPlan if there are none.
+func (s *ConfigMapPlanStore) Load(ctx context.Context) (*plan.Changes, error) {
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, ErrNoLastPlan
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := cm.Data[lastPlanKey]
+	if !ok {
+		return nil, ErrNoLastPlan
+	}
+
+	changes := &plan.Changes{}
+	if err := json.Unmarshal([]byte(data), changes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal applied changes from configmap %s/%s: %w", s.namespace, s.name, err)
+	}
+	// empty labels are omitted when marshaling, but registries expect them to be initialized
+	for _, endpoints := range [][]*endpoint.Endpoint{changes.Create, changes.UpdateOld, changes.UpdateNew, changes.Delete} {
+		for _, ep := range endpoints {
+			if ep.Labels == nil {
+				ep.Labels = endpoint.NewLabels()
+			}
+		}
+	}
+	return changes, nil
+}
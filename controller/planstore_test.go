@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+func TestConfigMapPlanStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewConfigMapPlanStore(fake.NewSimpleClientset(), "default", "external-dns-last-plan")
+
+	_, err := store.Load(ctx)
+	assert.ErrorIs(t, err, ErrNoLastPlan)
+
+	first := &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+	}
+	require.NoError(t, store.Save(ctx, first))
+
+	loaded, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, first, loaded)
+
+	second := &plan.Changes{
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeCNAME, "lb.example.org")},
+	}
+	require.NoError(t, store.Save(ctx, second))
+
+	loaded, err = store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, second, loaded)
+}
+
+func TestRollbackLast(t *testing.T) {
+	ctx := context.Background()
+	cfg := getTestConfig()
+	store := NewConfigMapPlanStore(fake.NewSimpleClientset(), "default", "external-dns-last-plan")
+
+	dnsProvider := getTestProvider()
+	r, err := registry.NewNoopRegistry(dnsProvider)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             getTestSource(),
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: cfg.ManagedDNSRecordTypes,
+		PlanStore:          store,
+	}
+	require.NoError(t, ctrl.RunOnce(ctx))
+
+	applied := dnsProvider.(*mockProvider)
+	rollbackProvider := newMockProvider(applied.RecordsStore, applied.ExpectChanges.Inverse())
+	ctrl.Registry, err = registry.NewNoopRegistry(rollbackProvider)
+	require.NoError(t, err)
+	require.NoError(t, ctrl.RollbackLast(ctx))
+
+	// the rollback itself is stored, so rolling back again restores the applied plan
+	last, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Len(t, last.Create, len(applied.ExpectChanges.Delete))
+	assert.Len(t, last.Delete, len(applied.ExpectChanges.Create))
+}
+
+func TestRunOnceDryRunDoesNotStorePlan(t *testing.T) {
+	ctx := context.Background()
+	cfg := getTestConfig()
+	store := NewConfigMapPlanStore(fake.NewSimpleClientset(), "default", "external-dns-last-plan")
+
+	r, err := registry.NewNoopRegistry(getTestProvider())
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             getTestSource(),
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: cfg.ManagedDNSRecordTypes,
+		PlanStore:          store,
+		DryRun:             true,
+	}
+	require.NoError(t, ctrl.RunOnce(ctx))
+
+	// the provider didn't apply the changes, so there is nothing to roll back
+	_, err = store.Load(ctx)
+	assert.ErrorIs(t, err, ErrNoLastPlan)
+}
+
+func TestRollbackLastWithoutPlanStore(t *testing.T) {
+	ctrl := &Controller{}
+	assert.Error(t, ctrl.RollbackLast(context.Background()))
+}
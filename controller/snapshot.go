@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// registrySnapshotVersion is the version of the snapshots written by ExportRegistry
+const registrySnapshotVersion = 1
+
+// RegistrySnapshot holds the records managed by ExternalDNS instances along with their registry labels,
+// e.g. owner and resource, so they can be restored after the loss of a zone.
+type RegistrySnapshot struct {
+	Version int                  `json:"version"`
+	Records []*endpoint.Endpoint `json:"records"`
+}
+
+// ExportRegistry writes a snapshot of the records with an owner in the registry as JSON. Records of
+// all owners are exported, so the snapshot of one instance can restore the records of every instance
+// sharing the zone.
+func (c *Controller) ExportRegistry(ctx context.Context, w io.Writer) error {
+	records, err := c.Registry.Records(ctx)
+	if err != nil {
+		registryErrorsTotal.Inc()
+		deprecatedRegistryErrors.Inc()
+		return err
+	}
+
+	domainFilter := endpoint.MatchAllDomainFilters{c.DomainFilter, c.Registry.GetDomainFilter()}
+	snapshot := RegistrySnapshot{Version: registrySnapshotVersion, Records: []*endpoint.Endpoint{}}
+	for _, record := range records {
+		if record.Labels[endpoint.OwnerLabelKey] == "" || !domainFilter.Match(record.DNSName) {
+			continue
+		}
+		snapshot.Records = append(snapshot.Records, record)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(snapshot); err != nil {
+		return fmt.Errorf("failed to write registry snapshot: %w", err)
+	}
+	log.Infof("Exported %d records from the registry", len(snapshot.Records))
+	return nil
+}
+
+// ImportRegistry restores the records of this owner from a snapshot written by ExportRegistry. Missing records
+// are created along with their registry labels. Records which exist without an owner, e.g. restored from a backup
+// of the zone without their ownership records, get their labels back if the registry supports adoptions. Records
+// with an owner are left alone, as are the records of other owners in the snapshot.
+func (c *Controller) ImportRegistry(ctx context.Context, r io.Reader) error {
+	var snapshot RegistrySnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return fmt.Errorf("failed to read registry snapshot: %w", err)
+	}
+	if snapshot.Version != registrySnapshotVersion {
+		return fmt.Errorf("unsupported registry snapshot version %d", snapshot.Version)
+	}
+
+	records, err := c.Registry.Records(ctx)
+	if err != nil {
+		registryErrorsTotal.Inc()
+		deprecatedRegistryErrors.Inc()
+		return err
+	}
+	ctx = context.WithValue(ctx, provider.RecordsContextKey, records)
+
+	current := make(map[endpoint.EndpointKey]*endpoint.Endpoint, len(records))
+	for _, record := range records {
+		current[record.Key()] = record
+	}
+
+	domainFilter := endpoint.MatchAllDomainFilters{c.DomainFilter, c.Registry.GetDomainFilter()}
+	ownerID := c.Registry.OwnerID()
+	changes := &plan.Changes{}
+	skipped := 0
+	for _, record := range snapshot.Records {
+		if record.Labels == nil || !domainFilter.Match(record.DNSName) || !plan.IsManagedRecord(record.RecordType, c.ManagedRecordTypes, c.ExcludeRecordTypes) {
+			continue
+		}
+		if record.Labels[endpoint.OwnerLabelKey] != ownerID && !slices.Contains(record.Labels.Owners(), ownerID) {
+			skipped++
+			continue
+		}
+		record.Labels[endpoint.OwnerLabelKey] = ownerID
+
+		existing, ok := current[record.Key()]
+		switch {
+		case !ok:
+			changes.Create = append(changes.Create, record)
+		case existing.Labels[endpoint.OwnerLabelKey] == "":
+			changes.UpdateOld = append(changes.UpdateOld, existing)
+			changes.UpdateNew = append(changes.UpdateNew, record)
+		default:
+			log.Debugf("Skipping import of %s %s which already has the owner %q", record.RecordType, record.DNSName, existing.Labels[endpoint.OwnerLabelKey])
+		}
+	}
+	if skipped > 0 {
+		log.Infof("Skipping import of %d records of other owners", skipped)
+	}
+
+	if !changes.HasChanges() {
+		log.Info("Registry snapshot has no records to import")
+		return nil
+	}
+
+	log.Infof("Importing registry snapshot: %d creates, %d updates", len(changes.Create), len(changes.UpdateNew))
+	if err := c.Registry.ApplyChanges(ctx, changes); err != nil {
+		registryErrorsTotal.Inc()
+		deprecatedRegistryErrors.Inc()
+		return err
+	}
+	c.saveAppliedChanges(ctx, changes)
+
+	return nil
+}
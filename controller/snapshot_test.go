@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+func TestExportImportRegistry(t *testing.T) {
+	ctx := context.Background()
+	zone := "example.org"
+	managedRecordTypes := []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}
+
+	newController := func(p *inmemory.InMemoryProvider, ownerID string) *Controller {
+		r, err := registry.NewTXTRegistry(p, "", "", ownerID, 0, "", managedRecordTypes, nil, false, nil)
+		require.NoError(t, err)
+		return &Controller{Registry: r, ManagedRecordTypes: managedRecordTypes}
+	}
+	newRecord := func(dnsName, target, owner string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint(dnsName, endpoint.RecordTypeA, target)
+		ep.Labels[endpoint.OwnerLabelKey] = owner
+		ep.Labels[endpoint.ResourceLabelKey] = "ingress/default/" + strings.Split(dnsName, ".")[0]
+		return ep
+	}
+
+	lost := inmemory.NewInMemoryProvider()
+	require.NoError(t, lost.CreateZone(zone))
+	require.NoError(t, newController(lost, "owner").Registry.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newRecord("created.example.org", "1.2.3.4", ""),
+			newRecord("restored.example.org", "5.6.7.8", ""),
+		},
+	}))
+	require.NoError(t, newController(lost, "other").Registry.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newRecord("other.example.org", "9.9.9.9", ""),
+		},
+	}))
+	require.NoError(t, lost.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("unmanaged.example.org", endpoint.RecordTypeA, "1.1.1.1")},
+	}))
+
+	var snapshot bytes.Buffer
+	require.NoError(t, newController(lost, "owner").ExportRegistry(ctx, &snapshot))
+
+	// the zone is recreated with one record restored from a backup of the zone, without its TXT records
+	recreated := inmemory.NewInMemoryProvider()
+	require.NoError(t, recreated.CreateZone(zone))
+	require.NoError(t, recreated.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("restored.example.org", endpoint.RecordTypeA, "5.6.7.8")},
+	}))
+
+	ctrl := newController(recreated, "owner")
+	require.NoError(t, ctrl.ImportRegistry(ctx, bytes.NewReader(snapshot.Bytes())))
+
+	records, err := ctrl.Registry.Records(ctx)
+	require.NoError(t, err)
+	restored := map[string]string{}
+	for _, record := range records {
+		restored[record.DNSName] = record.Labels[endpoint.OwnerLabelKey] + " " + record.Labels[endpoint.ResourceLabelKey]
+	}
+	assert.Equal(t, map[string]string{
+		"created.example.org":  "owner ingress/default/created",
+		"restored.example.org": "owner ingress/default/restored",
+	}, restored)
+
+	// importing again changes nothing
+	require.NoError(t, ctrl.ImportRegistry(ctx, bytes.NewReader(snapshot.Bytes())))
+	again, err := ctrl.Registry.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, again, len(records))
+}
+
+func TestImportRegistryInvalidSnapshot(t *testing.T) {
+	ctrl := &Controller{}
+	assert.Error(t, ctrl.ImportRegistry(context.Background(), strings.NewReader("{")))
+	assert.Error(t, ctrl.ImportRegistry(context.Background(), strings.NewReader(`{"version":2,"records":[]}`)))
+}
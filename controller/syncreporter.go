@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// SyncReporter receives the outcome of each synchronization, e.g. to write it back to the resources
+// the endpoints originate from.
+type SyncReporter interface {
+	// ReportSync is called with the desired endpoints, the desired endpoints whose changes were skipped
+	// and the error the synchronization failed with, if any. The desired endpoints are nil when the
+	// synchronization failed before they were known.
+	ReportSync(ctx context.Context, desired, skipped []*endpoint.Endpoint, err error)
+}
+
+// reportSync passes the outcome of a synchronization to the SyncReporter, if any.
+func (c *Controller) reportSync(ctx context.Context, p *plan.Plan, err error) {
+	if c.SyncReporter == nil {
+		return
+	}
+	if p == nil {
+		c.SyncReporter.ReportSync(ctx, nil, nil, err)
+		return
+	}
+	c.SyncReporter.ReportSync(ctx, p.Desired, skippedEndpoints(p), err)
+}
+
+// skippedEndpoints returns the desired endpoints which the plan didn't apply: the ones violating an
+// invariant, the ones waiting for the records they depend on and the ones owned by other owners.
+func skippedEndpoints(p *plan.Plan) []*endpoint.Endpoint {
+	var skipped []*endpoint.Endpoint
+	for _, v := range p.Violations {
+		skipped = append(skipped, v.Endpoint)
+	}
+	skipped = append(skipped, p.Deferred...)
+
+	conflicts := make(map[endpoint.EndpointKey]bool, len(p.Conflicts))
+	for _, ep := range p.Conflicts {
+		conflicts[ep.Key()] = true
+	}
+	for _, ep := range p.Desired {
+		if conflicts[ep.Key()] {
+			skipped = append(skipped, ep)
+		}
+	}
+	return skipped
+}
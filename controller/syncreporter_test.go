@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+type fakeSyncReporter struct {
+	calls   int
+	desired []*endpoint.Endpoint
+	skipped []*endpoint.Endpoint
+	err     error
+}
+
+func (r *fakeSyncReporter) ReportSync(_ context.Context, desired, skipped []*endpoint.Endpoint, err error) {
+	r.calls++
+	r.desired, r.skipped, r.err = desired, skipped, err
+}
+
+func TestRunOnceReportsSync(t *testing.T) {
+	r, err := registry.NewNoopRegistry(getTestProvider())
+	require.NoError(t, err)
+	reporter := &fakeSyncReporter{}
+	ctrl := &Controller{
+		Source:             getTestSource(),
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: getTestConfig().ManagedDNSRecordTypes,
+		SyncReporter:       reporter,
+	}
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	assert.Equal(t, 1, reporter.calls)
+	assert.Len(t, reporter.desired, 4)
+	assert.Empty(t, reporter.skipped)
+	assert.NoError(t, reporter.err)
+}
+
+func TestRunOnceReportsSyncError(t *testing.T) {
+	failing := new(testutils.MockSource)
+	failing.On("Endpoints").Return(nil, errors.New("some error"))
+	r, err := registry.NewNoopRegistry(getTestProvider())
+	require.NoError(t, err)
+	reporter := &fakeSyncReporter{}
+	ctrl := &Controller{
+		Source:       failing,
+		Registry:     r,
+		Policy:       &plan.SyncPolicy{},
+		SyncReporter: reporter,
+	}
+
+	require.Error(t, ctrl.RunOnce(context.Background()))
+	assert.Equal(t, 1, reporter.calls)
+	assert.Nil(t, reporter.desired)
+	assert.EqualError(t, reporter.err, "some error")
+}
+
+func TestSkippedEndpoints(t *testing.T) {
+	violating := endpoint.NewEndpoint("violating.example.org", endpoint.RecordTypeCNAME, "example.org")
+	deferred := endpoint.NewEndpoint("deferred.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	conflicting := endpoint.NewEndpoint("conflicting.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	applied := endpoint.NewEndpoint("applied.example.org", endpoint.RecordTypeA, "1.2.3.4")
+
+	p := &plan.Plan{
+		Desired:    []*endpoint.Endpoint{violating, deferred, conflicting, applied},
+		Violations: []plan.Violation{{Endpoint: violating}},
+		Deferred:   []*endpoint.Endpoint{deferred},
+		Conflicts:  []*endpoint.Endpoint{endpoint.NewEndpoint("conflicting.example.org", endpoint.RecordTypeA, "5.6.7.8")},
+	}
+
+	assert.Equal(t, []*endpoint.Endpoint{violating, deferred, conflicting}, skippedEndpoints(p))
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+	"sigs.k8s.io/external-dns/registry"
+	"sigs.k8s.io/external-dns/source"
+)
+
+// VerifiedRecord is a record with an inconsistent ownership found by VerifyRegistry.
+type VerifiedRecord struct {
+	DNSName       string `json:"dnsName"`
+	RecordType    string `json:"recordType,omitempty"`
+	SetIdentifier string `json:"setIdentifier,omitempty"`
+	Resource      string `json:"resource,omitempty"`
+}
+
+// RegistryVerification holds the inconsistencies between the registry, the records of the provider and the
+// endpoints desired by the sources found by VerifyRegistry.
+type RegistryVerification struct {
+	OwnerID string `json:"ownerID"`
+	// OrphanedEntries are the ownership entries of this owner without a record, as far as the registry reports them
+	OrphanedEntries []VerifiedRecord `json:"orphanedEntries"`
+	// UnownedRecords are the records of managed types desired by the sources which have no owner
+	UnownedRecords []VerifiedRecord `json:"unownedRecords"`
+	// MissingResources are the records of this owner whose resource doesn't desire any record anymore
+	MissingResources []VerifiedRecord `json:"missingResources"`
+}
+
+// Problems returns the number of inconsistencies.
+func (v *RegistryVerification) Problems() int {
+	return len(v.OrphanedEntries) + len(v.UnownedRecords) + len(v.MissingResources)
+}
+
+// VerifyRegistry cross-checks the registry, the records of the provider and the endpoints desired by the
+// sources without changing anything, writes the inconsistencies as JSON and fails if there are any.
+func (c *Controller) VerifyRegistry(ctx context.Context, w io.Writer) error {
+	records, err := c.Registry.Records(ctx)
+	if err != nil {
+		registryErrorsTotal.Inc()
+		deprecatedRegistryErrors.Inc()
+		return err
+	}
+	ctx = context.WithValue(ctx, provider.RecordsContextKey, records)
+	ctx, report := source.WithFetchReport(ctx)
+
+	endpoints, err := c.Source.Endpoints(ctx)
+	if err != nil {
+		sourceErrorsTotal.Inc()
+		deprecatedSourceErrors.Inc()
+		return err
+	}
+	if failed := report.FailedSources(); failed > 0 {
+		// the records of the failed sources would be reported as missing resources
+		return fmt.Errorf("cannot verify the registry, %d sources failed", failed)
+	}
+
+	verification := c.verifyRegistry(records, endpoints)
+	for _, r := range verification.OrphanedEntries {
+		log.Warnf("Ownership entry of %s %s has no record", r.RecordType, r.DNSName)
+	}
+	for _, r := range verification.UnownedRecords {
+		log.Warnf("Record %s %s desired by %q has no owner", r.RecordType, r.DNSName, r.Resource)
+	}
+	for _, r := range verification.MissingResources {
+		log.Warnf("Record %s %s is owned for %q, which doesn't desire it anymore", r.RecordType, r.DNSName, r.Resource)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(verification); err != nil {
+		return fmt.Errorf("failed to write registry verification: %w", err)
+	}
+	if problems := verification.Problems(); problems > 0 {
+		return fmt.Errorf("registry verification found %d problems", problems)
+	}
+	log.Info("Registry verification found no problems")
+	return nil
+}
+
+func (c *Controller) verifyRegistry(records, endpoints []*endpoint.Endpoint) *RegistryVerification {
+	ownerID := c.Registry.OwnerID()
+	domainFilter := endpoint.MatchAllDomainFilters{c.DomainFilter, c.Registry.GetDomainFilter()}
+	verification := &RegistryVerification{
+		OwnerID:          ownerID,
+		OrphanedEntries:  []VerifiedRecord{},
+		UnownedRecords:   []VerifiedRecord{},
+		MissingResources: []VerifiedRecord{},
+	}
+
+	if reporter, ok := c.Registry.(registry.OrphanReporter); ok {
+		for _, key := range reporter.OrphanedEntries() {
+			verification.OrphanedEntries = append(verification.OrphanedEntries, VerifiedRecord{
+				DNSName:       key.DNSName,
+				RecordType:    key.RecordType,
+				SetIdentifier: key.SetIdentifier,
+			})
+		}
+	}
+
+	desired := make(map[endpoint.EndpointKey]*endpoint.Endpoint, len(endpoints))
+	resources := map[string]bool{}
+	for _, ep := range endpoints {
+		desired[ep.Key()] = ep
+		resources[ep.Labels[endpoint.ResourceLabelKey]] = true
+	}
+
+	for _, r := range records {
+		if !domainFilter.Match(r.DNSName) || !plan.IsManagedRecord(r.RecordType, c.ManagedRecordTypes, c.ExcludeRecordTypes) {
+			continue
+		}
+		owner := r.Labels[endpoint.OwnerLabelKey]
+		if ep, ok := desired[r.Key()]; ok && owner == "" {
+			verification.UnownedRecords = append(verification.UnownedRecords, newVerifiedRecord(r, ep.Labels[endpoint.ResourceLabelKey]))
+		}
+		if resource := r.Labels[endpoint.ResourceLabelKey]; r.IsOwnedBy(ownerID) && resource != "" && !resources[resource] {
+			verification.MissingResources = append(verification.MissingResources, newVerifiedRecord(r, resource))
+		}
+	}
+	return verification
+}
+
+func newVerifiedRecord(r *endpoint.Endpoint, resource string) VerifiedRecord {
+	return VerifiedRecord{
+		DNSName:       r.DNSName,
+		RecordType:    r.RecordType,
+		SetIdentifier: r.SetIdentifier,
+		Resource:      resource,
+	}
+}
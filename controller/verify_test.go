@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+func TestVerifyRegistry(t *testing.T) {
+	ctx := context.Background()
+	managedRecordTypes := []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}
+	newRecord := func(dnsName, target, resource string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint(dnsName, endpoint.RecordTypeA, target)
+		ep.Labels[endpoint.ResourceLabelKey] = resource
+		return ep
+	}
+
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone("example.org"))
+	r, err := registry.NewTXTRegistry(p, "", "", "owner", 0, "", managedRecordTypes, nil, false, nil)
+	require.NoError(t, err)
+	orphan := newRecord("orphan.example.org", "3.3.3.3", "ingress/default/orphan")
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newRecord("kept.example.org", "1.1.1.1", "ingress/default/kept"),
+			newRecord("gone.example.org", "2.2.2.2", "ingress/default/gone"),
+			orphan,
+		},
+	}))
+	// records changed by hand
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("unowned.example.org", endpoint.RecordTypeA, "4.4.4.4")},
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("orphan.example.org", endpoint.RecordTypeA, "3.3.3.3")},
+	}))
+
+	src := new(testutils.MockSource)
+	src.On("Endpoints").Return([]*endpoint.Endpoint{
+		newRecord("kept.example.org", "1.1.1.1", "ingress/default/kept"),
+		newRecord("unowned.example.org", "4.4.4.4", "ingress/default/unowned"),
+	}, nil)
+	ctrl := &Controller{Source: src, Registry: r, ManagedRecordTypes: managedRecordTypes}
+
+	var out bytes.Buffer
+	require.EqualError(t, ctrl.VerifyRegistry(ctx, &out), "registry verification found 4 problems")
+
+	var verification RegistryVerification
+	require.NoError(t, json.Unmarshal(out.Bytes(), &verification))
+	assert.Equal(t, RegistryVerification{
+		OwnerID: "owner",
+		OrphanedEntries: []VerifiedRecord{
+			{DNSName: "orphan.example.org"},
+			{DNSName: "orphan.example.org", RecordType: endpoint.RecordTypeA},
+		},
+		UnownedRecords: []VerifiedRecord{
+			{DNSName: "unowned.example.org", RecordType: endpoint.RecordTypeA, Resource: "ingress/default/unowned"},
+		},
+		MissingResources: []VerifiedRecord{
+			{DNSName: "gone.example.org", RecordType: endpoint.RecordTypeA, Resource: "ingress/default/gone"},
+		},
+	}, verification)
+}
+
+func TestVerifyRegistryConsistent(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone("example.org"))
+	r, err := registry.NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA}, nil, false, nil)
+	require.NoError(t, err)
+	ep := endpoint.NewEndpoint("kept.example.org", endpoint.RecordTypeA, "1.1.1.1")
+	ep.Labels[endpoint.ResourceLabelKey] = "ingress/default/kept"
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{ep.DeepCopy()}}))
+
+	src := new(testutils.MockSource)
+	src.On("Endpoints").Return([]*endpoint.Endpoint{ep}, nil)
+	ctrl := &Controller{Source: src, Registry: r, ManagedRecordTypes: []string{endpoint.RecordTypeA}}
+
+	var out bytes.Buffer
+	require.NoError(t, ctrl.VerifyRegistry(ctx, &out))
+	assert.Contains(t, out.String(), `"orphanedEntries": []`)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// reasonPrivateRecordPublished is the reason of the events recorded for private records in the managed zones.
+const reasonPrivateRecordPublished = "PrivateRecordPublished"
+
+// reportPrivateRecords logs the records classified as private which are published in the managed
+// zones or about to be, and records them as events. It detects a split brain between the instances
+// managing the public and the private zones of the same domains, e.g. a resource picked up by both.
+func (c *Controller) reportPrivateRecords(ctx context.Context, records []*endpoint.Endpoint, changes *plan.Changes) {
+	private := privateRecords(records, changes)
+	privateRecordsPublished.Set(float64(len(private)))
+	for _, ep := range private {
+		owner := ep.Labels[endpoint.OwnerLabelKey]
+		if owner == "" {
+			owner = "unknown"
+		}
+		message := fmt.Sprintf("%s %s is classified as private but published in a public zone by owner %s", ep.RecordType, ep.DNSName, owner)
+		log.Warn(message)
+		if c.EventRecorder != nil {
+			c.EventRecorder.Warn(ctx, ep, reasonPrivateRecordPublished, message)
+		}
+	}
+}
+
+// privateRecords returns the records classified as private among the current records which are not
+// deleted by the changes and the records created or updated by them.
+func privateRecords(records []*endpoint.Endpoint, changes *plan.Changes) []*endpoint.Endpoint {
+	deleted := map[endpoint.EndpointKey]bool{}
+	for _, ep := range changes.Delete {
+		deleted[ep.Key()] = true
+	}
+	for _, ep := range changes.UpdateOld {
+		deleted[ep.Key()] = true
+	}
+
+	var private []*endpoint.Endpoint
+	seen := map[endpoint.EndpointKey]bool{}
+	add := func(ep *endpoint.Endpoint) {
+		if ep.Labels[endpoint.VisibilityLabelKey] != endpoint.VisibilityPrivate || seen[ep.Key()] {
+			return
+		}
+		seen[ep.Key()] = true
+		private = append(private, ep)
+	}
+	for _, ep := range changes.Create {
+		add(ep)
+	}
+	for _, ep := range changes.UpdateNew {
+		add(ep)
+	}
+	for _, ep := range records {
+		if !deleted[ep.Key()] {
+			add(ep)
+		}
+	}
+	return private
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+func newPrivateEndpoint(dnsName, target string) *endpoint.Endpoint {
+	ep := endpoint.NewEndpoint(dnsName, endpoint.RecordTypeA, target)
+	ep.Labels[endpoint.VisibilityLabelKey] = endpoint.VisibilityPrivate
+	return ep
+}
+
+func TestPrivateRecords(t *testing.T) {
+	leaked := newPrivateEndpoint("leaked.example.com", "10.0.0.1")
+	deleted := newPrivateEndpoint("deleted.example.com", "10.0.0.2")
+	updated := newPrivateEndpoint("updated.example.com", "10.0.0.3")
+	created := newPrivateEndpoint("created.example.com", "10.0.0.4")
+	updatedNew := newPrivateEndpoint("updated.example.com", "10.0.0.5")
+	public := endpoint.NewEndpoint("public.example.com", endpoint.RecordTypeA, "192.0.2.1")
+
+	private := privateRecords([]*endpoint.Endpoint{leaked, deleted, updated, public}, &plan.Changes{
+		Create:    []*endpoint.Endpoint{created, public},
+		UpdateOld: []*endpoint.Endpoint{updated},
+		UpdateNew: []*endpoint.Endpoint{updatedNew},
+		Delete:    []*endpoint.Endpoint{deleted},
+	})
+	assert.Equal(t, []*endpoint.Endpoint{created, updatedNew, leaked}, private)
+}
+
+func TestRunOnceReportsPrivateRecords(t *testing.T) {
+	leaked := newPrivateEndpoint("leaked.example.com", "10.0.0.1")
+	leaked.Labels[endpoint.OwnerLabelKey] = "private-instance"
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		newPrivateEndpoint("private.example.com", "10.0.0.2"),
+		endpoint.NewEndpoint("public.example.com", endpoint.RecordTypeA, "192.0.2.1"),
+	}, nil)
+	r, err := registry.NewNoopRegistry(newMockProvider([]*endpoint.Endpoint{leaked}, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newPrivateEndpoint("private.example.com", "10.0.0.2"),
+			endpoint.NewEndpoint("public.example.com", endpoint.RecordTypeA, "192.0.2.1"),
+		},
+	}))
+	require.NoError(t, err)
+
+	recorder := &fakeEventRecorder{}
+	ctrl := &Controller{
+		Source:              source,
+		Registry:            r,
+		Policy:              &plan.UpsertOnlyPolicy{},
+		ManagedRecordTypes:  []string{endpoint.RecordTypeA},
+		CheckPrivateRecords: true,
+		EventRecorder:       recorder,
+	}
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	assert.Equal(t, []recordedEvent{
+		{
+			dnsName: "private.example.com",
+			reason:  reasonPrivateRecordPublished,
+			message: "A private.example.com is classified as private but published in a public zone by owner unknown",
+		},
+		{
+			dnsName: "leaked.example.com",
+			reason:  reasonPrivateRecordPublished,
+			message: "A leaked.example.com is classified as private but published in a public zone by owner private-instance",
+		},
+	}, recorder.events)
+	assert.InDelta(t, 2, testutil.ToFloat64(privateRecordsPublished), 0)
+}
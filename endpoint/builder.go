@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Builder assembles an Endpoint step by step. Every setter validates its input and records
+// any problem instead of failing right away, so sources can chain calls and inspect all
+// validation errors at once via Errors or Build.
+//
+//	ep, err := endpoint.NewBuilder("foo.example.org", endpoint.RecordTypeA).
+//		WithTargets("10.0.0.1", "10.0.0.2").
+//		WithTTL(300).
+//		WithResource("service/default/foo").
+//		Build()
+type Builder struct {
+	ep   *Endpoint
+	errs []error
+}
+
+// NewBuilder returns a Builder for an endpoint with the given DNS name and record type.
+// A trailing dot of the DNS name is removed.
+func NewBuilder(dnsName, recordType string) *Builder {
+	b := &Builder{
+		ep: &Endpoint{
+			DNSName:    strings.TrimSuffix(dnsName, "."),
+			RecordType: recordType,
+			Targets:    Targets{},
+			Labels:     NewLabels(),
+		},
+	}
+	b.errs = append(b.errs, validateDNSName(dnsName)...)
+	return b
+}
+
+// WithTargets adds the given targets to the endpoint. Trailing dots are removed from the targets of the
+// record types holding hostnames, like CNAME, MX or SRV, and each target is validated against the record type of the endpoint and normalized, e.g.
+// IPv4-mapped IPv6 addresses of A records are unmapped.
+func (b *Builder) WithTargets(targets ...string) *Builder {
+	for _, target := range targets {
+		if isNameValued(b.ep.RecordType) {
+			target = strings.TrimSuffix(target, ".")
+		}
+		if err := validateTarget(b.ep.DNSName, b.ep.RecordType, target); err != nil {
+			b.errs = append(b.errs, err)
+			continue
+		}
+		b.ep.Targets = append(b.ep.Targets, normalizeTarget(b.ep.RecordType, target))
+	}
+	return b
+}
+
+// WithTTL sets the TTL of the endpoint. Negative values are rejected.
+func (b *Builder) WithTTL(ttl TTL) *Builder {
+	if ttl < 0 {
+		b.errs = append(b.errs, fmt.Errorf("TTL %d of %q must not be negative", ttl, b.ep.DNSName))
+		return b
+	}
+	b.ep.RecordTTL = ttl
+	return b
+}
+
+// WithSetIdentifier sets the set identifier of the endpoint.
+func (b *Builder) WithSetIdentifier(setIdentifier string) *Builder {
+	b.ep.SetIdentifier = setIdentifier
+	return b
+}
+
+// WithLabel sets a label of the endpoint.
+func (b *Builder) WithLabel(key, value string) *Builder {
+	b.ep.Labels[key] = value
+	return b
+}
+
+// WithResource records the Kubernetes resource the endpoint originates from.
+// Empty values are ignored.
+func (b *Builder) WithResource(resource string) *Builder {
+	if resource != "" {
+		b.ep.Labels[ResourceLabelKey] = resource
+	}
+	return b
+}
+
+// WithProviderSpecific attaches a provider specific property to the endpoint.
+func (b *Builder) WithProviderSpecific(key, value string) *Builder {
+	b.ep.SetProviderSpecificProperty(key, value)
+	return b
+}
+
+// WithProviderSpecificProperties attaches all the given provider specific properties to the endpoint.
+func (b *Builder) WithProviderSpecificProperties(properties ProviderSpecific) *Builder {
+	for _, property := range properties {
+		b.ep.SetProviderSpecificProperty(property.Name, property.Value)
+	}
+	return b
+}
+
+// Errors returns all validation errors collected so far.
+func (b *Builder) Errors() []error {
+	return b.errs
+}
+
+// Build returns the assembled endpoint. If any validation failed, the endpoint is nil
+// and the returned error joins all collected validation errors.
+func (b *Builder) Build() (*Endpoint, error) {
+	errs := b.errs
+	if len(b.ep.Targets) == 0 {
+		errs = append(errs, fmt.Errorf("%s record %q has no valid targets", b.ep.RecordType, b.ep.DNSName))
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return b.ep, nil
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	ep, err := NewBuilder("foo.example.org.", RecordTypeA).
+		WithTargets("10.0.0.1", "10.0.0.2").
+		WithTTL(300).
+		WithSetIdentifier("eu").
+		WithResource("service/default/foo").
+		WithLabel("team", "dns").
+		WithProviderSpecific("alias", "false").
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, "foo.example.org", ep.DNSName)
+	assert.Equal(t, RecordTypeA, ep.RecordType)
+	assert.Equal(t, Targets{"10.0.0.1", "10.0.0.2"}, ep.Targets)
+	assert.Equal(t, TTL(300), ep.RecordTTL)
+	assert.Equal(t, "eu", ep.SetIdentifier)
+	assert.Equal(t, "service/default/foo", ep.Labels[ResourceLabelKey])
+	assert.Equal(t, "dns", ep.Labels["team"])
+	v, ok := ep.GetProviderSpecificProperty("alias")
+	assert.True(t, ok)
+	assert.Equal(t, "false", v)
+}
+
+func TestBuilderTargetValidation(t *testing.T) {
+	for _, tc := range []struct {
+		recordType string
+		target     string
+		valid      bool
+	}{
+		{RecordTypeA, "10.0.0.1", true},
+		{RecordTypeA, "2001:db8::1", false},
+		{RecordTypeA, "lb.example.org", false},
+		{RecordTypeAAAA, "2001:db8::1", true},
+		{RecordTypeAAAA, "10.0.0.1", false},
+		{RecordTypeA, "::ffff:10.0.0.1", true},
+		{RecordTypeAAAA, "::ffff:10.0.0.1", true},
+		{RecordTypeCNAME, "lb.example.org.", true},
+		{RecordTypeCNAME, "10.0.0.1", false},
+		{RecordTypeCNAME, "", false},
+		{RecordTypeMX, "10 mail.example.org", true},
+		{RecordTypeMX, "mail.example.org", false},
+		{RecordTypeMX, "100000 mail.example.org", false},
+		{RecordTypeSRV, "0 50 5060 sip.example.org", true},
+		{RecordTypeSRV, "0 50 sip.example.org", false},
+		{RecordTypeSRV, "0 50 port sip.example.org", false},
+		{RecordTypeNAPTR, `10 50 "S" "SIP+D2U" "" _sip._udp.example.org.`, true},
+		{RecordTypeNAPTR, `100 10 "U" "E2U+sip" "!^.*$!sip:info@example.org!" .`, true},
+		{RecordTypeNAPTR, `100 10 "U" "E2U+sip" "!^.*$!sip:\"info\" x@example.org!" .`, true},
+		{RecordTypeNAPTR, `10 50 S SIP+D2U "" _sip._udp.example.org.`, false},
+		{RecordTypeNAPTR, `10 50 "S" "SIP+D2U" "" 192.0.2.1.`, false},
+		{RecordTypeNAPTR, `10 50 "S" "SIP+D2U" "" _sip._udp.example.org`, false},
+		{RecordTypeNAPTR, `10 order "S" "SIP+D2U" "" _sip._udp.example.org.`, false},
+		{RecordTypeNAPTR, `10 50 "S" "SIP+D2U" " _sip._udp.example.org.`, false},
+		{RecordTypeTXT, "v=spf1 -all", true},
+	} {
+		t.Run(tc.recordType+"/"+tc.target, func(t *testing.T) {
+			b := NewBuilder("foo.example.org", tc.recordType).WithTargets(tc.target)
+			if tc.valid {
+				assert.Empty(t, b.Errors())
+				return
+			}
+			require.Len(t, b.Errors(), 1)
+			var targetErr *InvalidTargetError
+			assert.True(t, errors.As(b.Errors()[0], &targetErr))
+			assert.Equal(t, tc.recordType, targetErr.RecordType)
+		})
+	}
+}
+
+func TestBuilderUnmapsIPv4MappedTargets(t *testing.T) {
+	ep, err := NewBuilder("foo.example.org", RecordTypeA).WithTargets("::ffff:10.0.0.1", "10.0.0.2").Build()
+	require.NoError(t, err)
+	assert.Equal(t, Targets{"10.0.0.1", "10.0.0.2"}, ep.Targets)
+
+	ep, err = NewValidatedEndpoint("foo.example.org", RecordTypeA, 0, "::ffff:10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, Targets{"10.0.0.1"}, ep.Targets)
+
+	ep, err = NewBuilder("foo.example.org", RecordTypeAAAA).WithTargets("::ffff:10.0.0.1").Build()
+	require.NoError(t, err)
+	assert.Equal(t, Targets{"::ffff:10.0.0.1"}, ep.Targets)
+}
+
+func TestBuilderTrimsHostnameTargets(t *testing.T) {
+	for _, tc := range []struct {
+		recordType string
+		target     string
+		expected   string
+	}{
+		{RecordTypeCNAME, "lb.example.org.", "lb.example.org"},
+		{RecordTypeMX, "10 mail.example.org.", "10 mail.example.org"},
+		{RecordTypeSRV, "0 50 5060 sip.example.org.", "0 50 5060 sip.example.org"},
+		{RecordTypeNAPTR, `10 50 "S" "SIP+D2U" "" _sip._udp.example.org.`, `10 50 "S" "SIP+D2U" "" _sip._udp.example.org.`},
+		{RecordTypeTXT, "see example.org.", "see example.org."},
+	} {
+		t.Run(tc.recordType, func(t *testing.T) {
+			ep, err := NewBuilder("foo.example.org", tc.recordType).WithTargets(tc.target).Build()
+			require.NoError(t, err)
+			assert.Equal(t, Targets{tc.expected}, ep.Targets)
+		})
+	}
+
+	// the trailing dot of an address is an error rather than a hostname to trim
+	assert.Len(t, NewBuilder("foo.example.org", RecordTypeA).WithTargets("10.0.0.1.").Errors(), 1)
+}
+
+func TestBuilderAccumulatesErrors(t *testing.T) {
+	b := NewBuilder(strings.Repeat("x", 64)+".example.org", RecordTypeA).
+		WithTargets("10.0.0.1", "not-an-ip", "also-not-an-ip").
+		WithTTL(-1)
+
+	assert.Len(t, b.Errors(), 4)
+
+	var labelErr *InvalidLabelError
+	assert.True(t, errors.As(b.Errors()[0], &labelErr))
+	assert.Equal(t, strings.Repeat("x", 64), labelErr.Label)
+
+	ep, err := b.Build()
+	assert.Nil(t, ep)
+	assert.Error(t, err)
+	assert.True(t, errors.As(err, &labelErr))
+}
+
+func TestBuilderWithoutTargets(t *testing.T) {
+	ep, err := NewBuilder("foo.example.org", RecordTypeCNAME).Build()
+	assert.Nil(t, ep)
+	assert.ErrorContains(t, err, "no valid targets")
+}
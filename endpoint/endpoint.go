@@ -17,6 +17,7 @@ limitations under the License.
 package endpoint
 
 import (
+	"errors"
 	"fmt"
 	"net/netip"
 	"sort"
@@ -46,6 +47,8 @@ const (
 	RecordTypeMX = "MX"
 	// RecordTypeNAPTR is a RecordType enum value
 	RecordTypeNAPTR = "NAPTR"
+	// RecordTypeHTTPS is a RecordType enum value
+	RecordTypeHTTPS = "HTTPS"
 )
 
 // TTL is a structure defining the TTL of a DNS record
@@ -195,6 +198,67 @@ type ProviderSpecificProperty struct {
 // ProviderSpecific holds configuration which is specific to individual DNS providers
 type ProviderSpecific []ProviderSpecificProperty
 
+// The provider-specific properties of the routing policies, set by the sources on records with the same name and type
+// but different set identifiers. Providers supporting a routing policy translate its property to their own property.
+const (
+	// ProviderSpecificWeight is the weight of a record among the records of weighted routing.
+	ProviderSpecificWeight = "weight"
+	// ProviderSpecificGeolocation is the location of the clients answered with a record, see ParseGeolocation.
+	ProviderSpecificGeolocation = "geolocation"
+	// ProviderSpecificLatencyRegion is the cloud region of the targets of a record, for latency-based routing.
+	ProviderSpecificLatencyRegion = "latency-region"
+	// ProviderSpecificFailover is the failover role of a record, FailoverPrimary or FailoverSecondary.
+	ProviderSpecificFailover = "failover"
+)
+
+// The failover roles of the ProviderSpecificFailover property.
+const (
+	FailoverPrimary   = "PRIMARY"
+	FailoverSecondary = "SECONDARY"
+)
+
+// IsRoutingPolicyProperty returns true if the provider-specific property with the given name sets a routing policy.
+func IsRoutingPolicyProperty(name string) bool {
+	switch name {
+	case ProviderSpecificWeight, ProviderSpecificGeolocation, ProviderSpecificLatencyRegion, ProviderSpecificFailover:
+		return true
+	}
+	return false
+}
+
+// Geolocation is the location of the clients of a geolocation routing policy. Only one of Continent and Country
+// is set, Subdivision only together with Country.
+type Geolocation struct {
+	Continent   string
+	Country     string
+	Subdivision string
+}
+
+// ParseGeolocation parses the value of the ProviderSpecificGeolocation property, which is either "continent:<code>"
+// with a two-letter continent code, e.g. "continent:EU", or "country:<code>" with an ISO 3166-1 alpha-2 country code
+// optionally followed by an ISO 3166-2 subdivision code, e.g. "country:DE" or "country:US-CA".
+// The default location "*" matches the clients of all other locations.
+func ParseGeolocation(value string) (Geolocation, error) {
+	value = strings.TrimSpace(value)
+	if value == "*" {
+		return Geolocation{Country: "*"}, nil
+	}
+	kind, code, _ := strings.Cut(value, ":")
+	code = strings.ToUpper(strings.TrimSpace(code))
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "continent":
+		if len(code) == 2 {
+			return Geolocation{Continent: code}, nil
+		}
+	case "country":
+		country, subdivision, _ := strings.Cut(code, "-")
+		if len(country) == 2 {
+			return Geolocation{Country: country, Subdivision: subdivision}, nil
+		}
+	}
+	return Geolocation{}, fmt.Errorf("invalid geolocation %q: the format must be 'continent:<code>', 'country:<code>' or 'country:<code>-<subdivision>'", value)
+}
+
 // EndpointKey is the type of a map key for separating endpoints or targets.
 type EndpointKey struct {
 	DNSName       string
@@ -227,18 +291,58 @@ func NewEndpoint(dnsName, recordType string, targets ...string) *Endpoint {
 	return NewEndpointWithTTL(dnsName, recordType, TTL(0), targets...)
 }
 
-// NewEndpointWithTTL initialization method to be used to create an endpoint with a TTL struct
+// NewEndpointWithTTL initialization method to be used to create an endpoint with a TTL struct.
+// It returns nil if the DNS name is invalid, use NewValidatedEndpoint to find out why.
 func NewEndpointWithTTL(dnsName, recordType string, ttl TTL, targets ...string) *Endpoint {
-	cleanTargets := make([]string, len(targets))
-	for idx, target := range targets {
-		cleanTargets[idx] = strings.TrimSuffix(target, ".")
+	if errs := validateDNSName(dnsName); len(errs) > 0 {
+		log.Errorf("%v. Cannot create endpoint", errors.Join(errs...))
+		return nil
 	}
+	return newEndpoint(dnsName, recordType, ttl, targets...)
+}
 
-	for _, label := range strings.Split(dnsName, ".") {
-		if len(label) > 63 {
-			log.Errorf("label %s in %s is longer than 63 characters. Cannot create endpoint", label, dnsName)
-			return nil
+// NewValidatedEndpoint creates an endpoint like NewEndpointWithTTL, but additionally validates
+// the targets against the record type and normalizes them, e.g. IPv4-mapped addresses of A records. Instead
+// of returning nil, it returns an error listing every invalid label and target, see InvalidLabelError and
+// InvalidTargetError.
+func NewValidatedEndpoint(dnsName, recordType string, ttl TTL, targets ...string) (*Endpoint, error) {
+	errs := validateDNSName(dnsName)
+	ep := newEndpoint(dnsName, recordType, ttl, targets...)
+	for i, target := range ep.Targets {
+		if err := validateTarget(ep.DNSName, recordType, target); err != nil {
+			errs = append(errs, err)
+			continue
 		}
+		ep.Targets[i] = normalizeTarget(recordType, target)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return ep, nil
+}
+
+// trimTarget removes the trailing dot of a target, except for NAPTR records, whose replacement is a fully
+// qualified name, or "." for none, and TXT records, whose contents are kept as is.
+func trimTarget(recordType, target string) string {
+	if recordType == RecordTypeNAPTR || recordType == RecordTypeTXT {
+		return target
+	}
+	return strings.TrimSuffix(target, ".")
+}
+
+// isNameValued returns true for the record types whose targets are, or end with, a hostname.
+func isNameValued(recordType string) bool {
+	switch recordType {
+	case RecordTypeCNAME, RecordTypeNS, RecordTypePTR, RecordTypeMX, RecordTypeSRV:
+		return true
+	}
+	return false
+}
+
+func newEndpoint(dnsName, recordType string, ttl TTL, targets ...string) *Endpoint {
+	cleanTargets := make([]string, len(targets))
+	for idx, target := range targets {
+		cleanTargets[idx] = trimTarget(recordType, target)
 	}
 
 	return &Endpoint{
@@ -338,6 +442,29 @@ func FilterEndpointsByOwnerID(ownerID string, eps []*Endpoint) []*Endpoint {
 // DNSEndpointSpec defines the desired state of DNSEndpoint
 type DNSEndpointSpec struct {
 	Endpoints []*Endpoint `json:"endpoints,omitempty"`
+	// Schedules replace the targets of endpoints during recurring time windows
+	// +optional
+	Schedules []*TargetSchedule `json:"schedules,omitempty"`
+}
+
+// TargetSchedule replaces the targets of an endpoint of the same DNSEndpoint while a recurring window is active
+type TargetSchedule struct {
+	// The hostname of the endpoint whose targets are replaced
+	DNSName string `json:"dnsName"`
+	// RecordType of the endpoint whose targets are replaced
+	RecordType string `json:"recordType"`
+	// SetIdentifier of the endpoint whose targets are replaced
+	// +optional
+	SetIdentifier string `json:"setIdentifier,omitempty"`
+	// Schedule in the cron format (minute hour day-of-month month day-of-week) at which the window starts
+	Schedule string `json:"schedule"`
+	// Duration of the window, e.g. 8h
+	Duration metav1.Duration `json:"duration"`
+	// TimeZone the schedule is evaluated in, e.g. Europe/Berlin. Defaults to UTC
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+	// The targets the DNS record points to while the window is active
+	Targets Targets `json:"targets"`
 }
 
 // DNSEndpointStatus defines the observed state of DNSEndpoint
@@ -345,6 +472,29 @@ type DNSEndpointStatus struct {
 	// The generation observed by the external-dns controller.
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions of the last synchronization, a Synced condition tells whether the records reached the provider
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// The publication state of each endpoint
+	// +optional
+	Endpoints []EndpointStatus `json:"endpoints,omitempty"`
+}
+
+// EndpointStatus defines the publication state of an endpoint of a DNSEndpoint
+type EndpointStatus struct {
+	// The hostname of the endpoint
+	DNSName string `json:"dnsName"`
+	// RecordType of the endpoint
+	RecordType string `json:"recordType"`
+	// SetIdentifier of the endpoint
+	// +optional
+	SetIdentifier string `json:"setIdentifier,omitempty"`
+	// The last generation of the DNSEndpoint whose version of the endpoint was applied to the provider
+	// +optional
+	AppliedGeneration int64 `json:"appliedGeneration,omitempty"`
+	// Why the endpoint was not applied in the last synchronization
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // +genclient
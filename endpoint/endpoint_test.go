@@ -17,7 +17,9 @@ limitations under the License.
 package endpoint
 
 import (
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -36,6 +38,43 @@ func TestNewEndpoint(t *testing.T) {
 	}
 }
 
+func TestNewValidatedEndpoint(t *testing.T) {
+	e, err := NewValidatedEndpoint("example.org.", RecordTypeA, 300, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.DNSName != "example.org" || e.Targets[0] != "10.0.0.1" || e.RecordTTL != 300 {
+		t.Error("endpoint is not initialized correctly")
+	}
+
+	longLabel := strings.Repeat("x", 64)
+	e, err = NewValidatedEndpoint(longLabel+".example.org", RecordTypeA, 0, "10.0.0.1")
+	var labelErr *InvalidLabelError
+	if e != nil || !errors.As(err, &labelErr) || labelErr.Label != longLabel {
+		t.Errorf("expected invalid label error for %q, got %v", longLabel, err)
+	}
+
+	e, err = NewValidatedEndpoint("example.org", RecordTypeA, 0, "10.0.0.1", "lb.example.org.")
+	var targetErr *InvalidTargetError
+	if e != nil || !errors.As(err, &targetErr) || targetErr.Target != "lb.example.org" {
+		t.Errorf("expected invalid target error for lb.example.org, got %v", err)
+	}
+
+	if _, err = NewValidatedEndpoint("example.org", RecordTypeTXT, 0, "v=spf1 -all"); err != nil {
+		t.Errorf("unexpected error for a TXT record: %v", err)
+	}
+	e, err = NewValidatedEndpoint("example.org", RecordTypeTXT, 0, "v=spf1\n-all")
+	if e != nil || !errors.As(err, &targetErr) {
+		t.Errorf("expected invalid target error for a TXT value with a newline, got %v", err)
+	}
+}
+
+func TestNewEndpointWithTTLInvalidName(t *testing.T) {
+	if e := NewEndpointWithTTL(strings.Repeat("x", 64)+".example.org", RecordTypeA, 0, "10.0.0.1"); e != nil {
+		t.Errorf("expected nil endpoint, got %v", e)
+	}
+}
+
 func TestTargetsSame(t *testing.T) {
 	tests := []Targets{
 		{""},
@@ -441,3 +480,44 @@ func TestDuplicatedEndpointsWithOverlappingZones(t *testing.T) {
 		})
 	}
 }
+
+func TestParseGeolocation(t *testing.T) {
+	for _, tt := range []struct {
+		value    string
+		expected Geolocation
+		err      bool
+	}{
+		{value: "continent:EU", expected: Geolocation{Continent: "EU"}},
+		{value: "country:de", expected: Geolocation{Country: "DE"}},
+		{value: " Country:US-CA ", expected: Geolocation{Country: "US", Subdivision: "CA"}},
+		{value: "*", expected: Geolocation{Country: "*"}},
+		{value: "europe", err: true},
+		{value: "continent:Europe", err: true},
+		{value: "region:EU", err: true},
+	} {
+		location, err := ParseGeolocation(tt.value)
+		if tt.err {
+			if err == nil {
+				t.Errorf("expected an error for %q", tt.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", tt.value, err)
+		}
+		if location != tt.expected {
+			t.Errorf("expected %+v for %q, got %+v", tt.expected, tt.value, location)
+		}
+	}
+}
+
+func TestIsRoutingPolicyProperty(t *testing.T) {
+	for _, name := range []string{ProviderSpecificWeight, ProviderSpecificGeolocation, ProviderSpecificLatencyRegion, ProviderSpecificFailover} {
+		if !IsRoutingPolicyProperty(name) {
+			t.Errorf("%s is a routing policy property", name)
+		}
+	}
+	if IsRoutingPolicyProperty("aws/weight") {
+		t.Error("aws/weight is not a generic routing policy property")
+	}
+}
@@ -19,10 +19,14 @@ package endpoint
 import (
 	log "github.com/sirupsen/logrus"
 
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // ErrInvalidHeritage is returned when heritage was not found, or different heritage is found
@@ -44,14 +48,115 @@ const (
 	// DualstackLabelKey is the name of the label that identifies dualstack endpoints
 	DualstackLabelKey = "dualstack"
 
+	// DescriptionLabelKey is the name of the label that holds a human-readable description of the endpoint.
+	// Providers supporting record comments publish it, all others only keep it in the registry.
+	DescriptionLabelKey = "description"
+
+	// ResyncLabelKey is the name of the label that holds the resync token of the endpoint. A changed token forces
+	// the plan to update the record even if it looks up to date.
+	ResyncLabelKey = "resync"
+
+	// ZoneIDLabelKey is the name of the label that pins the endpoint to the hosted zone with the given ID. Providers
+	// supporting it skip the longest-suffix matching of zones, e.g. to choose between public and private zones of the same domain.
+	ZoneIDLabelKey = "zone-id"
+
+	// VisibilityLabelKey is the name of the label that classifies the endpoint as intended for public or private zones only
+	VisibilityLabelKey = "visibility"
+	// VisibilityPrivate is the value of the visibility label of endpoints which must not be published in public zones
+	VisibilityPrivate = "private"
+
+	// LeaseLabelKey is the name of the label that holds the time the ownership of the endpoint expires unless the
+	// owner renews it, in RFC 3339 format.
+	LeaseLabelKey = "lease"
+
+	// OwnersLabelKey is the name of the label that holds the owners sharing the ownership of the endpoint, separated
+	// by ownersSeparator. The endpoint is only deleted once it isn't owned by any of them anymore.
+	OwnersLabelKey = "owners"
+
+	// HandoffLabelKey is the name of the label that holds the resource label of the k8s resource the endpoint takes
+	// over the DNS name from, e.g. while moving the record from an Ingress to a DNSEndpoint.
+	HandoffLabelKey = "handoff-from"
+
+	// DependsOnLabelKey is the name of the label that holds the DNS names, separated by spaces, of the records which
+	// have to exist before the endpoint is created, e.g. the A record a CNAME points to.
+	DependsOnLabelKey = "depends-on"
+
+	// RecordMetadataLabelKey is the name of the label that holds the serialized labels of the endpoint as stored by
+	// providers with native record metadata, e.g. record comments or tags, for the metadata registry.
+	RecordMetadataLabelKey = "record-metadata"
+
 	// txtEncryptionNonce label for keep same nonce for same txt records, for prevent different result of encryption for same txt record, it can cause issues for some providers
 	txtEncryptionNonce = "txt-encryption-nonce"
+
+	// txtFormat label for keep the format the labels have been read in, so the TXT record can be regenerated as it is
+	txtFormat = "txt-format"
+
+	// txtDecryptionKey label for keep the index of the decryption key the labels have been decrypted with, if it isn't the encryption key
+	txtDecryptionKey = "txt-decryption-key"
+
+	// txtCompressed label for keep whether the labels have been read from a compressed payload
+	txtCompressed = "txt-compressed"
 )
 
+// txtCompressedPrefix prefixes the base64 encoded gzip of compressed TXT record payloads
+const txtCompressedPrefix = "gzip:"
+
+// maxTXTStringLength is the maximum length of a single string of a TXT record
+const maxTXTStringLength = 255
+
+// ownersSeparator separates the owners in the owners label, commas and equal signs are taken by the TXT record payload
+const ownersSeparator = ";"
+
+// Formats of the TXT record payload.
+const (
+	// TXTFormatV2 is the heritage=external-dns,external-dns/<key>=<value> payload
+	TXTFormatV2 = "v2"
+	// TXTFormatV3 is the versioned JSON payload
+	TXTFormatV3 = "v3"
+)
+
+// jsonLabels is the versioned JSON payload of TXT records in the v3 format. Fields unknown to
+// the parser are ignored, so later versions can add fields without breaking earlier readers.
+type jsonLabels struct {
+	Heritage string            `json:"heritage"`
+	Version  int               `json:"version"`
+	Owner    string            `json:"owner,omitempty"`
+	Resource string            `json:"resource,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
 // Labels store metadata related to the endpoint
 // it is then stored in a persistent storage via serialization
 type Labels map[string]string
 
+// builtinLabelKeys are the labels set and interpreted by ExternalDNS itself
+var builtinLabelKeys = map[string]bool{
+	OwnerLabelKey:          true,
+	ResourceLabelKey:       true,
+	OwnedRecordLabelKey:    true,
+	AWSSDDescriptionLabel:  true,
+	DualstackLabelKey:      true,
+	DescriptionLabelKey:    true,
+	ResyncLabelKey:         true,
+	ZoneIDLabelKey:         true,
+	VisibilityLabelKey:     true,
+	LeaseLabelKey:          true,
+	OwnersLabelKey:         true,
+	HandoffLabelKey:        true,
+	DependsOnLabelKey:      true,
+	RecordMetadataLabelKey: true,
+	txtEncryptionNonce:     true,
+	txtFormat:              true,
+	txtDecryptionKey:       true,
+	txtCompressed:          true,
+}
+
+// IsBuiltinLabelKey reports whether the label is set and interpreted by ExternalDNS itself, as opposed to the
+// custom labels which sources set for downstream tooling.
+func IsBuiltinLabelKey(key string) bool {
+	return builtinLabelKeys[key]
+}
+
 // NewLabels returns empty Labels
 func NewLabels() Labels {
 	return map[string]string{}
@@ -61,6 +166,17 @@ func NewLabels() Labels {
 // if heritage set to another value is found then error is returned
 // no heritage automatically assumes is not owned by external-dns and returns invalidHeritage error
 func NewLabelsFromStringPlain(labelText string) (Labels, error) {
+	if text, ok := compressedLabelText(labelText); ok {
+		labels, err := NewLabelsFromStringPlain(text)
+		if err == nil {
+			labels[txtCompressed] = "true"
+		}
+		return labels, err
+	}
+	if text, ok := jsonLabelText(labelText); ok {
+		return newLabelsFromJSON(text)
+	}
+
 	endpointLabels := map[string]string{}
 	labelText = strings.Trim(labelText, "\"") // drop quotes
 	tokens := strings.Split(labelText, ",")
@@ -90,8 +206,78 @@ func NewLabelsFromStringPlain(labelText string) (Labels, error) {
 	return endpointLabels, nil
 }
 
+// jsonLabelText returns the JSON of a v3 payload. Depending on the provider, the payload is read
+// back quoted with escaped inner quotes, unquoted with escaped inner quotes or unquoted as is.
+func jsonLabelText(labelText string) (string, bool) {
+	if unquoted, err := strconv.Unquote(labelText); err == nil {
+		labelText = unquoted
+	} else {
+		labelText = strings.Trim(labelText, "\"")
+	}
+	if !strings.HasPrefix(labelText, "{") {
+		return "", false
+	}
+	if strings.HasPrefix(labelText, `{\"`) {
+		if unquoted, err := strconv.Unquote(`"` + labelText + `"`); err == nil {
+			labelText = unquoted
+		}
+	}
+	return labelText, true
+}
+
+// compressedLabelText returns the decompressed payload of a compressed payload.
+func compressedLabelText(labelText string) (string, bool) {
+	encoded, ok := strings.CutPrefix(strings.Trim(labelText, "\""), txtCompressedPrefix)
+	if !ok {
+		return "", false
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false
+	}
+	data, err = decompressData(data)
+	if err != nil {
+		log.Debugf("Failed to decompress the TXT record payload %#v. Got error %#v.", labelText, err)
+		return "", false
+	}
+	return string(data), true
+}
+
+func newLabelsFromJSON(text string) (Labels, error) {
+	var payload jsonLabels
+	if err := json.Unmarshal([]byte(text), &payload); err != nil {
+		return nil, ErrInvalidHeritage
+	}
+	if payload.Heritage != heritage || payload.Version < 3 {
+		return nil, ErrInvalidHeritage
+	}
+
+	endpointLabels := make(map[string]string, len(payload.Metadata)+3)
+	for key, value := range payload.Metadata {
+		endpointLabels[key] = value
+	}
+	if payload.Owner != "" {
+		endpointLabels[OwnerLabelKey] = payload.Owner
+	}
+	if payload.Resource != "" {
+		endpointLabels[ResourceLabelKey] = payload.Resource
+	}
+	endpointLabels[txtFormat] = TXTFormatV3
+	return endpointLabels, nil
+}
+
 func NewLabelsFromString(labelText string, aesKey []byte) (Labels, error) {
-	if len(aesKey) != 0 {
+	return NewLabelsFromStringWithKeys(labelText, [][]byte{aesKey})
+}
+
+// NewLabelsFromStringWithKeys same to NewLabelsFromString, but tries to decrypt the labels with each
+// of the given keys in order. The first key is the encryption key, the index of any other key the
+// labels have been decrypted with is kept, see DecryptionKey.
+func NewLabelsFromStringWithKeys(labelText string, aesKeys [][]byte) (Labels, error) {
+	for i, aesKey := range aesKeys {
+		if len(aesKey) == 0 {
+			continue
+		}
 		decryptedText, encryptionNonce, err := DecryptText(strings.Trim(labelText, "\""), aesKey)
 		//in case if we have decryption error, just try process original text
 		//decryption errors should be ignored here, because we can already have plain-text labels in registry
@@ -99,6 +285,9 @@ func NewLabelsFromString(labelText string, aesKey []byte) (Labels, error) {
 			labels, err := NewLabelsFromStringPlain(decryptedText)
 			if err == nil {
 				labels[txtEncryptionNonce] = encryptionNonce
+				if i > 0 {
+					labels[txtDecryptionKey] = strconv.Itoa(i)
+				}
 			}
 
 			return labels, err
@@ -119,7 +308,7 @@ func (l Labels) SerializePlain(withQuotes bool) string {
 	sort.Strings(keys) // sort for consistency
 
 	for _, key := range keys {
-		if key == txtEncryptionNonce {
+		if key == txtEncryptionNonce || key == txtFormat || key == txtDecryptionKey || key == txtCompressed {
 			continue
 		}
 		tokens = append(tokens, fmt.Sprintf("%s/%s=%s", heritage, key, l[key]))
@@ -135,7 +324,191 @@ func (l Labels) Serialize(withQuotes bool, txtEncryptEnabled bool, aesKey []byte
 	if !txtEncryptEnabled {
 		return l.SerializePlain(withQuotes)
 	}
+	return l.encrypt(l.SerializePlain(false), withQuotes, aesKey)
+}
 
+// SerializeJSONPlain transforms endpoints labels into the versioned JSON payload of the v3 format
+// withQuotes adds additional quotes and escapes the quotes of the payload
+func (l Labels) SerializeJSONPlain(withQuotes bool) string {
+	payload := jsonLabels{
+		Heritage: heritage,
+		Version:  3,
+		Owner:    l[OwnerLabelKey],
+		Resource: l[ResourceLabelKey],
+	}
+	for key, value := range l {
+		switch key {
+		case OwnerLabelKey, ResourceLabelKey, txtEncryptionNonce, txtFormat, txtDecryptionKey, txtCompressed:
+			continue
+		}
+		if payload.Metadata == nil {
+			payload.Metadata = map[string]string{}
+		}
+		payload.Metadata[key] = value
+	}
+	// map keys are sorted when marshaling, so the payload is consistent
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Fatalf("Failed to marshal the labels %#v. Got error %#v.", l, err)
+	}
+	if withQuotes {
+		return strconv.Quote(string(data))
+	}
+	return string(data)
+}
+
+// SerializeJSON same to SerializeJSONPlain, but encrypt data, if encryption enabled
+func (l Labels) SerializeJSON(withQuotes bool, txtEncryptEnabled bool, aesKey []byte) string {
+	if !txtEncryptEnabled {
+		return l.SerializeJSONPlain(withQuotes)
+	}
+	return l.encrypt(l.SerializeJSONPlain(false), withQuotes, aesKey)
+}
+
+// SerializeCompressed same to SerializePlain or SerializeJSONPlain depending on the format, but compresses the payload
+// with gzip and base64 encodes it, for labels exceeding the length of TXT records. Encrypted payloads are compressed
+// already, so this is only used without encryption.
+func (l Labels) SerializeCompressed(format string, withQuotes bool) string {
+	text := l.SerializePlain(false)
+	if format == TXTFormatV3 {
+		text = l.SerializeJSONPlain(false)
+	}
+	data, err := compressData([]byte(text))
+	if err != nil {
+		log.Fatalf("Failed to compress the labels %#v. Got error %#v.", l, err)
+	}
+	text = txtCompressedPrefix + base64.StdEncoding.EncodeToString(data)
+	if withQuotes {
+		return fmt.Sprintf("\"%s\"", text)
+	}
+	return text
+}
+
+// SplitTXTStrings splits a quoted TXT record payload longer than the limit of a single TXT string into several
+// quoted strings separated by spaces, e.g. "abc" "def", which providers store as a single TXT record.
+// Payloads which aren't quoted or fit into a single string are returned as they are.
+func SplitTXTStrings(payload string) string {
+	text, err := strconv.Unquote(payload)
+	if err != nil || len(text) <= maxTXTStringLength {
+		return payload
+	}
+	var parts []string
+	for len(text) > maxTXTStringLength {
+		cut := maxTXTStringLength
+		// don't split multi-byte characters
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		parts = append(parts, strconv.Quote(text[:cut]))
+		text = text[cut:]
+	}
+	parts = append(parts, strconv.Quote(text))
+	return strings.Join(parts, " ")
+}
+
+// JoinTXTStrings joins the strings of a TXT record split by SplitTXTStrings, or by the provider, into a single quoted
+// payload. Targets which aren't made of several quoted strings are returned as they are.
+func JoinTXTStrings(target string) string {
+	var joined strings.Builder
+	rest := target
+	parts := 0
+	for rest != "" {
+		if rest[0] != '"' {
+			return target
+		}
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return target
+		}
+		text, err := strconv.Unquote(quoted)
+		if err != nil {
+			return target
+		}
+		joined.WriteString(text)
+		parts++
+		rest = strings.TrimLeft(rest[len(quoted):], " ")
+	}
+	if parts < 2 {
+		return target
+	}
+	return strconv.Quote(joined.String())
+}
+
+// TXTCompressed reports whether the labels have been read from a compressed payload.
+func (l Labels) TXTCompressed() bool {
+	return l[txtCompressed] == "true"
+}
+
+// Owners returns the owners sharing the ownership of the endpoint in sorted order, nil if the ownership isn't shared.
+func (l Labels) Owners() []string {
+	if l[OwnersLabelKey] == "" {
+		return nil
+	}
+	return strings.Split(l[OwnersLabelKey], ownersSeparator)
+}
+
+// DependsOn returns the DNS names of the records the endpoint depends on.
+func (l Labels) DependsOn() []string {
+	return strings.Fields(l[DependsOnLabelKey])
+}
+
+// SetOwners sets the owners sharing the ownership of the endpoint. Duplicates and empty owners are dropped,
+// the label is removed if less than two owners remain.
+func (l Labels) SetOwners(owners []string) {
+	set := map[string]struct{}{}
+	for _, owner := range owners {
+		if owner != "" {
+			set[owner] = struct{}{}
+		}
+	}
+	if len(set) < 2 {
+		delete(l, OwnersLabelKey)
+		return
+	}
+	sorted := make([]string, 0, len(set))
+	for owner := range set {
+		sorted = append(sorted, owner)
+	}
+	sort.Strings(sorted)
+	l[OwnersLabelKey] = strings.Join(sorted, ownersSeparator)
+}
+
+// WithoutTXTMetadata returns a copy of the labels without the metadata about the TXT record they have been read
+// from, e.g. its format and encryption nonce, so they can be stored elsewhere.
+func (l Labels) WithoutTXTMetadata() Labels {
+	labels := make(Labels, len(l))
+	for key, value := range l {
+		switch key {
+		case txtEncryptionNonce, txtFormat, txtDecryptionKey, txtCompressed:
+			continue
+		}
+		labels[key] = value
+	}
+	return labels
+}
+
+// TXTFormat returns the format of the TXT record the labels have been read from, TXTFormatV2 if
+// they haven't been read from a v3 payload.
+func (l Labels) TXTFormat() string {
+	if l[txtFormat] == TXTFormatV3 {
+		return TXTFormatV3
+	}
+	return TXTFormatV2
+}
+
+// DecryptionKey returns the key of the given keys the labels have been decrypted with, the first
+// key if they haven't been decrypted with another one.
+func (l Labels) DecryptionKey(aesKeys [][]byte) []byte {
+	if i, err := strconv.Atoi(l[txtDecryptionKey]); err == nil && i > 0 && i < len(aesKeys) {
+		return aesKeys[i]
+	}
+	if len(aesKeys) == 0 {
+		return nil
+	}
+	return aesKeys[0]
+}
+
+func (l Labels) encrypt(text string, withQuotes bool, aesKey []byte) string {
 	var encryptionNonce []byte
 	if extractedNonce, nonceExists := l[txtEncryptionNonce]; nonceExists {
 		encryptionNonce = []byte(extractedNonce)
@@ -148,7 +521,6 @@ func (l Labels) Serialize(withQuotes bool, txtEncryptEnabled bool, aesKey []byte
 		l[txtEncryptionNonce] = string(encryptionNonce)
 	}
 
-	text := l.SerializePlain(false)
 	log.Debugf("Encrypt the serialized text %#v before returning it.", text)
 	var err error
 	text, err = EncryptText(text, aesKey, encryptionNonce)
@@ -18,6 +18,8 @@ package endpoint
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -121,6 +123,154 @@ func (suite *LabelsSuite) TestDeserialize() {
 	suite.Nil(multipleHeritage, "if error should return nil")
 }
 
+func (suite *LabelsSuite) TestDeserializeWithKeys() {
+	oldKey := []byte("0123456789abcdef0123456789abcdef")
+	keys := [][]byte{suite.aesKey, oldKey}
+
+	foo, err := NewLabelsFromStringWithKeys(suite.fooAsTextEncrypted, keys)
+	suite.NoError(err, "should succeed for valid label text")
+	suite.Equal("foo-owner", foo[OwnerLabelKey])
+	suite.Equal(suite.aesKey, foo.DecryptionKey(keys), "should be decrypted with the encryption key")
+
+	encrypted := suite.foo.Serialize(false, true, oldKey)
+	foo, err = NewLabelsFromStringWithKeys(encrypted, keys)
+	suite.NoError(err, "should decrypt with a previous key")
+	suite.Equal("foo-owner", foo[OwnerLabelKey])
+	suite.Equal(oldKey, foo.DecryptionKey(keys), "should be decrypted with the previous key")
+	suite.Equal(encrypted, foo.Serialize(false, true, foo.DecryptionKey(keys)), "should serialize to the same text")
+	suite.Equal(suite.fooAsText, foo.SerializePlain(false), "should not serialize the decryption key")
+
+	_, err = NewLabelsFromStringWithKeys(encrypted, [][]byte{suite.aesKey})
+	suite.Equal(ErrInvalidHeritage, err, "should fail without the previous key")
+}
+
+func (suite *LabelsSuite) TestSerializeJSON() {
+	json := `{"heritage":"external-dns","version":3,"owner":"bar-owner","resource":"bar-resource","metadata":{"new-key":"bar-new-key"}}`
+	suite.Equal(json, suite.barTextAsMap.SerializeJSONPlain(false), "should serialize as JSON")
+	suite.Equal(`"{\"heritage\":\"external-dns\",\"version\":3,\"owner\":\"bar-owner\",\"resource\":\"bar-resource\",\"metadata\":{\"new-key\":\"bar-new-key\"}}"`, suite.barTextAsMap.SerializeJSON(true, false, nil), "should serialize as quoted JSON")
+	suite.NotEqual(json, suite.barTextAsMap.SerializeJSON(false, true, suite.aesKey), "should serialize as JSON and encrypt")
+}
+
+func (suite *LabelsSuite) TestDeserializeJSON() {
+	expected := Labels{
+		"owner":      "bar-owner",
+		"resource":   "bar-resource",
+		"new-key":    "bar-new-key",
+		"txt-format": TXTFormatV3,
+	}
+	for _, text := range []string{
+		suite.barTextAsMap.SerializeJSONPlain(false),
+		suite.barTextAsMap.SerializeJSONPlain(true),
+		// read back without the quotes but with escaped inner quotes
+		strings.Trim(suite.barTextAsMap.SerializeJSONPlain(true), `"`),
+		// later versions may add fields
+		`{"heritage":"external-dns","version":4,"owner":"bar-owner","resource":"bar-resource","metadata":{"new-key":"bar-new-key"},"expires":"never"}`,
+	} {
+		labels, err := NewLabelsFromStringPlain(text)
+		suite.NoError(err, text)
+		suite.Equal(expected, labels, text)
+		suite.Equal(TXTFormatV3, labels.TXTFormat())
+	}
+
+	labels, err := NewLabelsFromString(suite.barTextAsMap.SerializeJSON(true, true, suite.aesKey), suite.aesKey)
+	suite.NoError(err, "should decrypt JSON")
+	suite.Equal("bar-owner", labels[OwnerLabelKey])
+	suite.Equal(TXTFormatV3, labels.TXTFormat())
+
+	// the format isn't serialized
+	suite.Equal(suite.barTextAsMap.SerializeJSONPlain(false), labels.SerializeJSONPlain(false))
+	labels, err = NewLabelsFromStringPlain(suite.fooAsText)
+	suite.NoError(err)
+	suite.Equal(TXTFormatV2, labels.TXTFormat())
+
+	for _, text := range []string{
+		`{"heritage":"mate","version":3,"owner":"bar-owner"}`,
+		`{"heritage":"external-dns","owner":"bar-owner"}`,
+		`{"heritage":"external-dns",`,
+	} {
+		_, err := NewLabelsFromStringPlain(text)
+		suite.Equal(ErrInvalidHeritage, err, text)
+	}
+}
+
+func (suite *LabelsSuite) TestOwners() {
+	labels := NewLabels()
+	suite.Nil(labels.Owners())
+
+	labels.SetOwners([]string{"cluster-b", "", "cluster-a", "cluster-b"})
+	suite.Equal("cluster-a;cluster-b", labels[OwnersLabelKey])
+	suite.Equal([]string{"cluster-a", "cluster-b"}, labels.Owners())
+
+	// the owners survive the TXT record payload
+	parsed, err := NewLabelsFromStringPlain(labels.SerializePlain(true))
+	suite.NoError(err)
+	suite.Equal([]string{"cluster-a", "cluster-b"}, parsed.Owners())
+
+	labels.SetOwners([]string{"cluster-a"})
+	suite.NotContains(labels, OwnersLabelKey)
+	suite.Nil(labels.Owners())
+}
+
+func (suite *LabelsSuite) TestIsBuiltinLabelKey() {
+	suite.True(IsBuiltinLabelKey(OwnerLabelKey))
+	suite.True(IsBuiltinLabelKey(DescriptionLabelKey))
+	suite.True(IsBuiltinLabelKey(txtFormat))
+	suite.False(IsBuiltinLabelKey("team"))
+}
+
+func (suite *LabelsSuite) TestSerializeCompressed() {
+	labels := Labels{OwnerLabelKey: "foo-owner", DescriptionLabelKey: strings.Repeat("description ", 40)}
+	for _, format := range []string{TXTFormatV2, TXTFormatV3} {
+		payload := labels.SerializeCompressed(format, true)
+		suite.True(strings.HasPrefix(payload, `"gzip:`))
+		suite.Less(len(payload), 255)
+
+		parsed, err := NewLabelsFromStringPlain(payload)
+		suite.NoError(err)
+		suite.True(parsed.TXTCompressed())
+		suite.Equal(format, parsed.TXTFormat())
+		suite.Equal(labels, parsed.WithoutTXTMetadata())
+		// the payload is regenerated as it is
+		suite.Equal(payload, parsed.SerializeCompressed(parsed.TXTFormat(), true))
+	}
+
+	_, err := NewLabelsFromStringPlain(`"gzip:invalid"`)
+	suite.Equal(ErrInvalidHeritage, err)
+}
+
+func (suite *LabelsSuite) TestSplitTXTStrings() {
+	suite.Equal(suite.fooAsTextWithQuotes, SplitTXTStrings(suite.fooAsTextWithQuotes))
+	suite.Equal(suite.fooAsText, SplitTXTStrings(suite.fooAsText))
+	suite.Equal(suite.fooAsTextWithQuotes, JoinTXTStrings(suite.fooAsTextWithQuotes))
+
+	for _, labels := range []Labels{
+		{OwnerLabelKey: "foo-owner", DescriptionLabelKey: strings.Repeat("a", 600)},
+		{OwnerLabelKey: "foo-owner", DescriptionLabelKey: strings.Repeat("é", 300)},
+	} {
+		for _, payload := range []string{labels.SerializePlain(true), labels.SerializeJSONPlain(true)} {
+			split := SplitTXTStrings(payload)
+			suite.Contains(split, `" "`)
+			for rest := split; rest != ""; {
+				quoted, err := strconv.QuotedPrefix(rest)
+				suite.Require().NoError(err)
+				part, err := strconv.Unquote(quoted)
+				suite.Require().NoError(err)
+				suite.LessOrEqual(len(part), 255)
+				rest = strings.TrimPrefix(rest[len(quoted):], " ")
+			}
+
+			parsed, err := NewLabelsFromStringPlain(JoinTXTStrings(split))
+			suite.NoError(err)
+			suite.Equal(labels, parsed.WithoutTXTMetadata())
+		}
+	}
+
+	// the strings of other tools are kept as they are
+	suite.Equal(`"v=spf1 -all"`, JoinTXTStrings(`"v=spf1 -all"`))
+	suite.Equal(`v=spf1 "a" "b"`, JoinTXTStrings(`v=spf1 "a" "b"`))
+	suite.Equal(`"abcdef"`, JoinTXTStrings(`"abc" "def"`))
+}
+
 func TestLabels(t *testing.T) {
 	suite.Run(t, new(LabelsSuite))
 }
@@ -0,0 +1,208 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxLabelLength is the maximum length of a single label of a DNS name, see RFC 1035 2.3.4
+	maxLabelLength = 63
+)
+
+// InvalidLabelError is returned when a DNS name contains a label that cannot be published.
+type InvalidLabelError struct {
+	DNSName string
+	Label   string
+	Reason  string
+}
+
+func (e *InvalidLabelError) Error() string {
+	return fmt.Sprintf("label %q in %q is invalid: %s", e.Label, e.DNSName, e.Reason)
+}
+
+// InvalidTargetError is returned when a target is not valid for the record type of an endpoint.
+type InvalidTargetError struct {
+	DNSName    string
+	RecordType string
+	Target     string
+	Reason     string
+}
+
+func (e *InvalidTargetError) Error() string {
+	return fmt.Sprintf("target %q of %s record %q is invalid: %s", e.Target, e.RecordType, e.DNSName, e.Reason)
+}
+
+// validateDNSName checks that every label of a DNS name can be published.
+func validateDNSName(dnsName string) []error {
+	var errs []error
+	for _, label := range strings.Split(strings.TrimSuffix(dnsName, "."), ".") {
+		if len(label) > maxLabelLength {
+			errs = append(errs, &InvalidLabelError{
+				DNSName: dnsName,
+				Label:   label,
+				Reason:  fmt.Sprintf("longer than %d characters", maxLabelLength),
+			})
+		}
+	}
+	return errs
+}
+
+// validateTarget checks that a target is well-formed for the given record type.
+// Record types without a known target format are accepted as is.
+func validateTarget(dnsName, recordType, target string) error {
+	invalid := func(reason string) error {
+		return &InvalidTargetError{DNSName: dnsName, RecordType: recordType, Target: target, Reason: reason}
+	}
+
+	if strings.TrimSpace(target) == "" {
+		return invalid("target is empty")
+	}
+
+	switch recordType {
+	case RecordTypeA:
+		ip, err := netip.ParseAddr(target)
+		if err != nil || !ip.Unmap().Is4() {
+			return invalid("not an IPv4 address")
+		}
+		warnIPv4Mapped(dnsName, recordType, ip)
+	case RecordTypeAAAA:
+		ip, err := netip.ParseAddr(target)
+		if err != nil || !ip.Is6() {
+			return invalid("not an IPv6 address")
+		}
+		warnIPv4Mapped(dnsName, recordType, ip)
+	case RecordTypeCNAME, RecordTypeNS, RecordTypePTR:
+		if _, err := netip.ParseAddr(target); err == nil {
+			return invalid("must be a hostname, not an IP address")
+		}
+	case RecordTypeMX:
+		fields := strings.Fields(target)
+		if len(fields) != 2 {
+			return invalid("must have the format '<preference> <host>'")
+		}
+		if _, err := strconv.ParseUint(fields[0], 10, 16); err != nil {
+			return invalid("preference must be a number between 0 and 65535")
+		}
+	case RecordTypeSRV:
+		fields := strings.Fields(target)
+		if len(fields) != 4 {
+			return invalid("must have the format '<priority> <weight> <port> <target>'")
+		}
+		for _, field := range fields[:3] {
+			if _, err := strconv.ParseUint(field, 10, 16); err != nil {
+				return invalid("priority, weight and port must be numbers between 0 and 65535")
+			}
+		}
+	case RecordTypeNAPTR:
+		fields, ok := naptrFields(target)
+		if !ok || len(fields) != 6 {
+			return invalid(`must have the format '<order> <preference> "<flags>" "<service>" "<regexp>" <replacement>'`)
+		}
+		for _, field := range fields[:2] {
+			if _, err := strconv.ParseUint(field, 10, 16); err != nil {
+				return invalid("order and preference must be numbers between 0 and 65535")
+			}
+		}
+		for _, field := range fields[2:5] {
+			if len(field) < 2 || field[0] != '"' || field[len(field)-1] != '"' {
+				return invalid("flags, service and regexp must be quoted")
+			}
+		}
+		if !strings.HasSuffix(fields[5], ".") {
+			return invalid(`replacement must be a fully qualified name ending with a dot, or "." for none`)
+		}
+		if _, err := netip.ParseAddr(strings.TrimSuffix(fields[5], ".")); err == nil {
+			return invalid("replacement must be a hostname, not an IP address")
+		}
+	case RecordTypeTXT:
+		if strings.ContainsFunc(target, unicode.IsControl) {
+			return invalid("must not contain control characters")
+		}
+	}
+	return nil
+}
+
+// normalizeTarget returns a valid target in the form it is published in: the IPv4-mapped IPv6 addresses of
+// A records, e.g. ::ffff:192.0.2.1, are unmapped.
+func normalizeTarget(recordType, target string) string {
+	if recordType != RecordTypeA {
+		return target
+	}
+	if ip, err := netip.ParseAddr(target); err == nil && ip.Is4In6() {
+		return ip.Unmap().String()
+	}
+	return target
+}
+
+// warnedIPv4Mapped holds the endpoints already warned about by warnIPv4Mapped, so the warning isn't repeated
+// on every synchronization.
+var warnedIPv4Mapped sync.Map
+
+// warnIPv4Mapped warns once per endpoint about an IPv4-mapped IPv6 address, e.g. ::ffff:192.0.2.1, which is
+// most likely a mistake. It is accepted nonetheless, since rejecting it would delete the records already
+// published with it.
+func warnIPv4Mapped(dnsName, recordType string, ip netip.Addr) {
+	if !ip.Is4In6() {
+		return
+	}
+	if _, warned := warnedIPv4Mapped.LoadOrStore(dnsName+" "+recordType+" "+ip.String(), true); warned {
+		return
+	}
+	if recordType == RecordTypeA {
+		log.Warnf("Target %q of A record %q is an IPv4-mapped IPv6 address, publishing %s instead", ip, dnsName, ip.Unmap())
+		return
+	}
+	log.Warnf("Target %q of %s record %q is an IPv4-mapped IPv6 address, publish %s in an A record instead", ip, recordType, dnsName, ip.Unmap())
+}
+
+// naptrFields splits the target of a NAPTR record into its fields, keeping the quoted strings, which may contain
+// blanks and escaped quotes, together with their quotes. It returns false for an unterminated quoted string.
+func naptrFields(target string) ([]string, bool) {
+	var fields []string
+	var field strings.Builder
+	quoted, escaped := false, false
+	for _, r := range target {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case !quoted && unicode.IsSpace(r):
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+			continue
+		}
+		field.WriteRune(r)
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+	return fields, !quoted
+}
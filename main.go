@@ -22,6 +22,8 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
@@ -39,6 +41,7 @@ import (
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns/validation"
+	"sigs.k8s.io/external-dns/pkg/benchmark"
 	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
 	"sigs.k8s.io/external-dns/provider/akamai"
@@ -74,6 +77,7 @@ import (
 	webhookapi "sigs.k8s.io/external-dns/provider/webhook/api"
 	"sigs.k8s.io/external-dns/registry"
 	"sigs.k8s.io/external-dns/source"
+	"sigs.k8s.io/external-dns/source/annotations"
 )
 
 func main() {
@@ -107,11 +111,21 @@ func main() {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	if cfg.Benchmark {
+		if err := runBenchmark(ctx, cfg); err != nil {
+			log.Fatal(err)
+		}
+
+		os.Exit(0)
+	}
+
 	go serveMetrics(cfg.MetricsAddress)
 	go handleSigterm(cancel)
 
 	// error is explicitly ignored because the filter is already validated in validation.ValidateConfig
 	labelSelector, _ := labels.Parse(cfg.LabelFilter)
+	nodeLabelSelector, _ := labels.Parse(cfg.NodeFilterLabels)
+	crdLabelSelector, _ := labels.Parse(cfg.CRDSourceLabelFilter)
 
 	// Create a source.Config from the flags passed by the user.
 	sourceCfg := &source.Config{
@@ -126,13 +140,18 @@ func main() {
 		IgnoreIngressRulesSpec:         cfg.IgnoreIngressRulesSpec,
 		GatewayNamespace:               cfg.GatewayNamespace,
 		GatewayLabelFilter:             cfg.GatewayLabelFilter,
+		GatewayListenerPortRecords:     cfg.GatewayListenerPortRecords,
 		Compatibility:                  cfg.Compatibility,
 		PublishInternal:                cfg.PublishInternal,
 		PublishHostIP:                  cfg.PublishHostIP,
 		AlwaysPublishNotReadyAddresses: cfg.AlwaysPublishNotReadyAddresses,
 		ConnectorServer:                cfg.ConnectorSourceServer,
+		WebhookSourceURL:               cfg.WebhookSourceURL,
+		HTTPSourceURL:                  cfg.HTTPSourceURL,
+		HTTPSourceHeaders:              cfg.HTTPSourceHeaders,
 		CRDSourceAPIVersion:            cfg.CRDSourceAPIVersion,
 		CRDSourceKind:                  cfg.CRDSourceKind,
+		CRDSourceLabelFilter:           crdLabelSelector,
 		KubeConfig:                     cfg.KubeConfig,
 		APIServerURL:                   cfg.APIServerURL,
 		ServiceTypeFilter:              cfg.ServiceTypeFilter,
@@ -148,10 +167,23 @@ func main() {
 		ResolveLoadBalancerHostname:    cfg.ResolveServiceLoadBalancerHostname,
 		TraefikDisableLegacy:           cfg.TraefikDisableLegacy,
 		TraefikDisableNew:              cfg.TraefikDisableNew,
+		CacheEndpoints:                 cfg.CacheSourceEndpoints,
+		NodeLabelFilter:                nodeLabelSelector,
+		NodeFQDNTemplate:               cfg.NodeFQDNTemplate,
+		NodeExcludeNotReady:            cfg.NodeExcludeNotReady,
+		NodeAddressTypes:               cfg.NodeAddressTypes,
+		PodExcludeNotReady:             cfg.PodExcludeNotReady,
+		PodSRVRecords:                  cfg.PodSRVRecords,
+		ServiceFlaggerAware:            cfg.ServiceFlaggerAware,
+		ServiceFlaggerPublishCanary:    cfg.ServiceFlaggerPublishCanary,
+		NamespaceDefaultAnnotations:    cfg.NamespaceDefaultAnnotations,
 	}
 
+	annotations.SetAliases(cfg.AnnotationAliases)
+	annotations.SetCustomLabels(cfg.RegistryLabels)
+
 	// Lookup all the selected sources by names and pass them the desired configuration.
-	sources, err := source.ByNames(ctx, &source.SingletonClientGenerator{
+	clientGenerator := &source.SingletonClientGenerator{
 		KubeConfig:   cfg.KubeConfig,
 		APIServerURL: cfg.APIServerURL,
 		// If update events are enabled, disable timeout.
@@ -161,18 +193,51 @@ func main() {
 			}
 			return cfg.RequestTimeout
 		}(),
-	}, cfg.Sources, sourceCfg)
+	}
+	sources, err := source.ByNames(ctx, clientGenerator, cfg.Sources, sourceCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	staticEndpoints, err := source.ParseStaticEndpoints(cfg.StaticEndpoints)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if cfg.StaticEndpointsFile != "" {
+		fileEndpoints, err := source.LoadStaticEndpoints(cfg.StaticEndpointsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		staticEndpoints = append(staticEndpoints, fileEndpoints...)
+	}
+	sourcePriorities := source.SourcePriorities(cfg.Sources, cfg.SourcePriority)
+	if len(staticEndpoints) > 0 {
+		sources = append(sources, source.NewStaticSource(staticEndpoints))
+		if sourcePriorities != nil {
+			sourcePriorities = append(sourcePriorities, len(cfg.SourcePriority))
+		}
+	}
 
 	// Filter targets
 	targetFilter := endpoint.NewTargetNetFilterWithExclusions(cfg.TargetNetFilter, cfg.ExcludeTargetNets)
 
-	// Combine multiple sources into a single, deduplicated source.
-	endpointsSource := source.NewDedupSource(source.NewMultiSource(sources, sourceCfg.DefaultTargets))
-	endpointsSource = source.NewNAT64Source(endpointsSource, cfg.NAT64Networks)
-	endpointsSource = source.NewTargetFilterSource(endpointsSource, targetFilter)
+	stages := source.DefaultTransformerStages
+	if cfg.TransformerConfig != "" {
+		stages, err = source.LoadTransformerStages(cfg.TransformerConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	log.Infof("Endpoint transformer pipeline: %s", strings.Join(source.TransformerStageNames(stages), ", "))
+
+	// Combine multiple sources into a single source and pass its endpoints through the transformer pipeline.
+	endpointsSource, err := source.NewTransformerPipeline(source.NewMultiSource(sources, sourcePriorities, sourceCfg.DefaultTargets, cfg.SourceTimeout, cfg.SourceFailurePolicy), stages, &source.TransformerConfig{
+		NAT64Networks: cfg.NAT64Networks,
+		TargetFilter:  targetFilter,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// RegexDomainFilter overrides DomainFilter
 	var domainFilter endpoint.DomainFilter
@@ -234,7 +299,7 @@ func main() {
 			log.Infof("Registry \"%s\" cannot be used with AWS Cloud Map. Switching to \"aws-sd\".", cfg.Registry)
 			cfg.Registry = "aws-sd"
 		}
-		p, err = awssd.NewAWSSDProvider(domainFilter, cfg.AWSZoneType, cfg.DryRun, cfg.AWSSDServiceCleanup, cfg.TXTOwnerID, cfg.AWSSDCreateTag, sd.NewFromConfig(aws.CreateDefaultV2Config(cfg)))
+		p, err = awssd.NewAWSSDProvider(domainFilter, cfg.AWSZoneType, cfg.DryRun, cfg.AWSSDServiceCleanup, cfg.TXTOwnerID, cfg.AWSSDCreateTag, sd.NewFromConfig(aws.CreateServiceDiscoveryV2Config(cfg)))
 	case "azure-dns", "azure":
 		p, err = azure.NewAzureProvider(cfg.AzureConfigFile, domainFilter, zoneNameFilter, zoneIDFilter, cfg.AzureSubscriptionID, cfg.AzureResourceGroup, cfg.AzureUserAssignedIdentityClientID, cfg.AzureActiveDirectoryAuthorityHost, cfg.AzureZonesCacheDuration, cfg.DryRun)
 	case "azure-private-dns":
@@ -244,7 +309,7 @@ func main() {
 	case "civo":
 		p, err = civo.NewCivoProvider(domainFilter, cfg.DryRun)
 	case "cloudflare":
-		p, err = cloudflare.NewCloudFlareProvider(domainFilter, zoneIDFilter, cfg.CloudflareProxied, cfg.DryRun, cfg.CloudflareDNSRecordsPerPage, cfg.CloudflareRegionKey)
+		p, err = cloudflare.NewCloudFlareProvider(domainFilter, zoneIDFilter, cfg.CloudflareProxied, cfg.DryRun, cfg.CloudflareDNSRecordsPerPage, cfg.CloudflareRegionKey, cfg.CloudflareCommentMaxLength)
 	case "google":
 		p, err = google.NewGoogleProvider(ctx, cfg.GoogleProject, domainFilter, zoneIDFilter, cfg.GoogleBatchChangeSize, cfg.GoogleBatchChangeInterval, cfg.GoogleZoneVisibility, cfg.DryRun)
 	case "digitalocean":
@@ -365,6 +430,17 @@ func main() {
 		os.Exit(0)
 	}
 
+	// A panic of the provider fails the synchronization instead of terminating ExternalDNS.
+	p = provider.NewRecoveringProvider(p)
+
+	if cfg.ProviderTrailingDots || !cfg.ProviderPreserveCase {
+		p = provider.NewCanonicalProvider(p, cfg.ProviderTrailingDots, cfg.ProviderPreserveCase)
+	}
+
+	if cfg.FaultInjectionErrorRate > 0 || cfg.FaultInjectionPartialRate > 0 || cfg.FaultInjectionLatency > 0 {
+		p = provider.NewFaultProvider(p, cfg.FaultInjectionErrorRate, cfg.FaultInjectionPartialRate, cfg.FaultInjectionLatency)
+	}
+
 	if cfg.ProviderCacheTime > 0 {
 		p = provider.NewCachedProvider(
 			p,
@@ -372,46 +448,146 @@ func main() {
 		)
 	}
 
-	var r registry.Registry
-	switch cfg.Registry {
-	case "dynamodb":
-		var dynamodbOpts []func(*dynamodb.Options)
-		if cfg.AWSDynamoDBRegion != "" {
-			dynamodbOpts = []func(*dynamodb.Options){
-				func(opts *dynamodb.Options) {
-					opts.Region = cfg.AWSDynamoDBRegion
-				},
-			}
+	if cfg.MigrateRegistry {
+		if err := migrateRegistry(ctx, cfg, p, clientGenerator); err != nil {
+			log.Fatal(err)
 		}
-		r, err = registry.NewDynamoDBRegistry(p, cfg.TXTOwnerID, dynamodb.NewFromConfig(aws.CreateDefaultV2Config(cfg), dynamodbOpts...), cfg.AWSDynamoDBTable, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTWildcardReplacement, cfg.ManagedDNSRecordTypes, cfg.ExcludeDNSRecordTypes, []byte(cfg.TXTEncryptAESKey), cfg.TXTCacheInterval)
-	case "noop":
-		r, err = registry.NewNoopRegistry(p)
-	case "txt":
-		r, err = registry.NewTXTRegistry(p, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTOwnerID, cfg.TXTCacheInterval, cfg.TXTWildcardReplacement, cfg.ManagedDNSRecordTypes, cfg.ExcludeDNSRecordTypes, cfg.TXTEncryptEnabled, []byte(cfg.TXTEncryptAESKey))
-	case "aws-sd":
-		r, err = registry.NewAWSSDRegistry(p, cfg.TXTOwnerID)
-	default:
-		log.Fatalf("unknown registry: %s", cfg.Registry)
+
+		os.Exit(0)
 	}
 
+	r, err := newRegistry(ctx, cfg, cfg.Registry, p, clientGenerator)
+	if txtRegistry, ok := r.(*registry.TXTRegistry); ok && err == nil && len(cfg.TXTHeartbeatDomains) > 0 {
+		r, err = registry.NewHeartbeatRegistry(txtRegistry, cfg.TXTHeartbeatDomains, externaldns.Version, cfg.TXTHeartbeatInterval, cfg.TXTHeartbeatFreshness, cfg.TXTHeartbeatCleanup)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if invalidator, ok := r.(registry.CacheInvalidator); ok && cfg.TXTCacheInterval > 0 {
+		http.Handle("/registry/cache/flush", &registry.CacheFlushHandler{Registry: invalidator})
+	}
+
+	if cfg.SecondaryRegistry != "" {
+		secondary, err := newRegistry(ctx, cfg, cfg.SecondaryRegistry, registry.NewOwnershipProvider(p), clientGenerator)
+		if err != nil {
+			log.Fatal(err)
+		}
+		r, err = registry.NewDualRegistry(r, secondary, cfg.DryRun)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if cfg.MaxTargetChangesPerHour > 0 {
+		r = registry.NewDampingRegistry(r, cfg.MaxTargetChangesPerHour)
+	}
+
+	if cfg.RegistryAudit {
+		r = registry.NewAuditRegistry(r, cfg.RegistryAuditReport)
+	}
+
 	policy, exists := plan.Policies[cfg.Policy]
 	if !exists {
 		log.Fatalf("unknown policy: %s", cfg.Policy)
 	}
+	if len(cfg.PolicyPerType) > 0 {
+		// the policy names are already validated in validation.ValidateConfig
+		policies := make(map[string]plan.Policy, len(cfg.PolicyPerType))
+		for recordType, name := range cfg.PolicyPerType {
+			policies[recordType] = plan.Policies[name]
+		}
+		policy = plan.NewPerRecordTypePolicy(policy, policies)
+	}
+
+	mutators := make([]plan.Mutator, 0, len(cfg.PlanMutators))
+	for _, name := range cfg.PlanMutators {
+		mutators = append(mutators, plan.Mutators[name])
+	}
 
 	ctrl := controller.Controller{
-		Source:               endpointsSource,
-		Registry:             r,
-		Policy:               policy,
-		Interval:             cfg.Interval,
-		DomainFilter:         domainFilter,
-		ManagedRecordTypes:   cfg.ManagedDNSRecordTypes,
-		ExcludeRecordTypes:   cfg.ExcludeDNSRecordTypes,
-		MinEventSyncInterval: cfg.MinEventSyncInterval,
+		Source:                endpointsSource,
+		Registry:              r,
+		Policy:                policy,
+		Mutators:              mutators,
+		Interval:              cfg.Interval,
+		DomainFilter:          domainFilter,
+		ManagedRecordTypes:    cfg.ManagedDNSRecordTypes,
+		ExcludeRecordTypes:    cfg.ExcludeDNSRecordTypes,
+		MinEventSyncInterval:  cfg.MinEventSyncInterval,
+		MaxDeletionsPerSync:   cfg.MaxDeletionsPerSync,
+		MaxDeletionPercentage: cfg.MaxDeletionPercentage,
+		AdoptExistingRecords:  cfg.AdoptExistingRecords,
+		SharedOwnership:       cfg.SharedOwnership,
+		CheckInvariants:       cfg.CheckDNSInvariants,
+		CustomLabels:          cfg.RegistryLabels,
+		CheckPrivateRecords:   cfg.CheckPrivateRecords,
+		RecordEvents:          cfg.RecordEvents,
+		DryRun:                cfg.DryRun,
+	}
+
+	if cfg.CheckDNSInvariants || cfg.CheckPrivateRecords || cfg.RecordEvents {
+		kubeClient, err := clientGenerator.KubeClient()
+		if err != nil {
+			log.Fatal(err)
+		}
+		ctrl.EventRecorder = controller.NewKubeEventRecorder(kubeClient)
+		source.SetInvalidEndpointHandler(ctrl.ReportInvalidEndpoint)
+	}
+
+	if cfg.CRDSourceStatus && slices.Contains(cfg.Sources, "crd") {
+		reporter, err := source.BuildCRDStatusReporter(clientGenerator, sourceCfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ctrl.SyncReporter = reporter
+	}
+
+	if cfg.LastPlanConfigMap != "" {
+		kubeClient, err := clientGenerator.KubeClient()
+		if err != nil {
+			log.Fatal(err)
+		}
+		// the format is already validated in validation.ValidateConfig
+		namespace, name, _ := strings.Cut(cfg.LastPlanConfigMap, "/")
+		ctrl.PlanStore = controller.NewConfigMapPlanStore(kubeClient, namespace, name)
+	}
+
+	if cfg.PlanPreview {
+		ctrl.PlanPreviewRefresh = cfg.PlanPreviewRefresh
+		http.Handle("/plan", &ctrl)
+	}
+
+	if cfg.RollbackLast {
+		if err := ctrl.RollbackLast(ctx); err != nil {
+			log.Fatal(err)
+		}
+
+		os.Exit(0)
+	}
+
+	if cfg.ExportRegistry != "" {
+		if err := exportRegistry(ctx, &ctrl, cfg.ExportRegistry); err != nil {
+			log.Fatal(err)
+		}
+
+		os.Exit(0)
+	}
+
+	if cfg.ImportRegistry != "" {
+		if err := importRegistry(ctx, &ctrl, cfg.ImportRegistry); err != nil {
+			log.Fatal(err)
+		}
+
+		os.Exit(0)
+	}
+
+	if cfg.VerifyRegistry {
+		if err := ctrl.VerifyRegistry(ctx, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+
+		os.Exit(0)
 	}
 
 	if cfg.Once {
@@ -434,6 +610,163 @@ func main() {
 	ctrl.Run(ctx)
 }
 
+// newRegistry returns the registry with the given name using the provider.
+func newRegistry(ctx context.Context, cfg *externaldns.Config, name string, p provider.Provider, clientGenerator source.ClientGenerator) (registry.Registry, error) {
+	var r registry.Registry
+	var err error
+	switch name {
+	case "dynamodb":
+		var dynamodbOpts []func(*dynamodb.Options)
+		if cfg.AWSDynamoDBRegion != "" {
+			dynamodbOpts = []func(*dynamodb.Options){
+				func(opts *dynamodb.Options) {
+					opts.Region = cfg.AWSDynamoDBRegion
+				},
+			}
+		}
+		r, err = registry.NewDynamoDBRegistry(p, cfg.TXTOwnerID, dynamodb.NewFromConfig(aws.CreateDefaultV2Config(cfg), dynamodbOpts...), cfg.AWSDynamoDBTable, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTWildcardReplacement, cfg.ManagedDNSRecordTypes, cfg.ExcludeDNSRecordTypes, []byte(cfg.TXTEncryptAESKey), cfg.TXTCacheInterval)
+	case "configmap":
+		kubeClient, kubeErr := clientGenerator.KubeClient()
+		if kubeErr != nil {
+			return nil, kubeErr
+		}
+		// the format is already validated in validation.ValidateConfig
+		namespace, name, _ := strings.Cut(cfg.ConfigMapRegistry, "/")
+		r, err = registry.NewConfigMapRegistry(p, cfg.TXTOwnerID, kubeClient, namespace, name, cfg.TXTCacheInterval)
+	case "consul":
+		r, err = registry.NewConsulRegistry(p, cfg.TXTOwnerID, cfg.ConsulAddress, cfg.ConsulToken, cfg.ConsulRegistryPrefix, cfg.TXTCacheInterval)
+	case "etcd":
+		r, err = registry.NewEtcdRegistry(p, cfg.TXTOwnerID, cfg.EtcdRegistryEndpoints, cfg.EtcdRegistryUsername, cfg.EtcdRegistryPassword, cfg.EtcdRegistryPrefix, cfg.EtcdRegistryLockTTL, cfg.TXTCacheInterval)
+	case "sql":
+		r, err = registry.NewSQLRegistry(ctx, p, cfg.TXTOwnerID, cfg.SQLRegistryDSN, cfg.SQLRegistryTable, cfg.TXTCacheInterval)
+	case "webhook":
+		r, err = registry.NewWebhookRegistry(p, cfg.TXTOwnerID, cfg.WebhookRegistryURL, cfg.WebhookRegistryTimeout, cfg.TXTCacheInterval)
+	case "noop":
+		r, err = registry.NewNoopRegistry(p)
+	case "txt":
+		var txtRegistry *registry.TXTRegistry
+		txtRegistry, err = registry.NewTXTRegistry(p, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTOwnerID, cfg.TXTCacheInterval, cfg.TXTWildcardReplacement, cfg.ManagedDNSRecordTypes, cfg.ExcludeDNSRecordTypes, cfg.TXTEncryptEnabled, []byte(cfg.TXTEncryptAESKey))
+		if err == nil {
+			err = txtRegistry.SetFormat(cfg.TXTFormat)
+			txtRegistry.SetCompression(cfg.TXTCompress)
+		}
+		if err == nil && len(cfg.TXTDecryptAESKeys) > 0 {
+			decryptionKeys := make([][]byte, 0, len(cfg.TXTDecryptAESKeys))
+			for _, key := range cfg.TXTDecryptAESKeys {
+				decryptionKeys = append(decryptionKeys, []byte(key))
+			}
+			err = txtRegistry.SetDecryptionKeys(decryptionKeys)
+		}
+		if err == nil {
+			err = txtRegistry.SetOwnershipLease(cfg.TXTOwnershipLease)
+		}
+		if err == nil {
+			txtRegistry.SetOwnerlessRecordTypes(cfg.TXTOwnerlessRecordTypes)
+			txtRegistry.SetRegistryZone(cfg.TXTRegistryZone)
+			err = txtRegistry.SetOwnerIDMap(cfg.TXTOwnerIDMap)
+		}
+		r = txtRegistry
+	case "metadata":
+		if !provider.SupportsRecordMetadata(p) {
+			log.Warnf("Provider %s doesn't support record metadata, falling back to the txt registry", cfg.Provider)
+			return newRegistry(ctx, cfg, "txt", p, clientGenerator)
+		}
+		r, err = registry.NewMetadataRegistry(p, cfg.TXTOwnerID)
+	case "aws-sd":
+		r, err = registry.NewAWSSDRegistry(p, cfg.TXTOwnerID)
+	default:
+		return nil, fmt.Errorf("unknown registry: %s", name)
+	}
+	return r, err
+}
+
+// migrateRegistry copies the ownership of the records from the registry given by --from to the one given by --to.
+func migrateRegistry(ctx context.Context, cfg *externaldns.Config, p provider.Provider, clientGenerator source.ClientGenerator) error {
+	// the verification has to read the registries, not their caches
+	migrateCfg := *cfg
+	migrateCfg.TXTCacheInterval = 0
+
+	from, err := newRegistry(ctx, &migrateCfg, cfg.MigrateRegistryFrom, p, clientGenerator)
+	if err != nil {
+		return err
+	}
+	to, err := newRegistry(ctx, &migrateCfg, cfg.MigrateRegistryTo, registry.NewOwnershipProvider(p), clientGenerator)
+	if err != nil {
+		return err
+	}
+	return registry.MigrateRegistry(ctx, from, to)
+}
+
+// runBenchmark synchronizes synthetic records with an in-memory provider, prints the report and compares
+// it with the baseline, if any.
+func runBenchmark(ctx context.Context, cfg *externaldns.Config) error {
+	report, err := benchmark.Run(ctx, benchmark.Config{
+		Records:    cfg.BenchmarkRecords,
+		Iterations: cfg.BenchmarkIterations,
+		Churn:      cfg.BenchmarkChurn,
+		OwnerID:    cfg.TXTOwnerID,
+	})
+	if err != nil {
+		return err
+	}
+	if err := report.WriteTable(os.Stdout); err != nil {
+		return err
+	}
+	if cfg.BenchmarkOutput != "" {
+		f, err := os.Create(cfg.BenchmarkOutput)
+		if err != nil {
+			return err
+		}
+		if err := report.WriteJSON(f); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	if cfg.BenchmarkBaseline == "" {
+		return nil
+	}
+	f, err := os.Open(cfg.BenchmarkBaseline)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	baseline, err := benchmark.ReadReport(f)
+	if err != nil {
+		return err
+	}
+	if err := report.Compare(baseline, cfg.BenchmarkTolerance); err != nil {
+		return fmt.Errorf("performance regression against %s: %w", cfg.BenchmarkBaseline, err)
+	}
+	log.Infof("No performance regression against %s", cfg.BenchmarkBaseline)
+	return nil
+}
+
+// exportRegistry writes a snapshot of the registry to the file with the given name.
+func exportRegistry(ctx context.Context, ctrl *controller.Controller, name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := ctrl.ExportRegistry(ctx, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// importRegistry restores the registry from the snapshot in the file with the given name.
+func importRegistry(ctx context.Context, ctrl *controller.Controller, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return ctrl.ImportRegistry(ctx, f)
+}
+
 func handleSigterm(cancel func()) {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGTERM)
@@ -449,6 +782,7 @@ func serveMetrics(address string) {
 	})
 
 	http.Handle("/metrics", promhttp.Handler())
+	http.HandleFunc("/schema", source.ServeAnnotationSchema)
 
 	log.Fatal(http.ListenAndServe(address, nil))
 }
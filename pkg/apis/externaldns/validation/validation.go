@@ -19,12 +19,32 @@ package validation
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 
+	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
+	"sigs.k8s.io/external-dns/plan"
 )
 
+// knownRecordTypes are the record types a policy can be set for with --policy-per-type.
+var knownRecordTypes = []string{
+	endpoint.RecordTypeA,
+	endpoint.RecordTypeAAAA,
+	endpoint.RecordTypeCNAME,
+	endpoint.RecordTypeTXT,
+	endpoint.RecordTypeSRV,
+	endpoint.RecordTypeNS,
+	endpoint.RecordTypePTR,
+	endpoint.RecordTypeMX,
+	endpoint.RecordTypeNAPTR,
+	endpoint.RecordTypeHTTPS,
+}
+
 // ValidateConfig performs validation on the Config object
 func ValidateConfig(cfg *externaldns.Config) error {
 	// TODO: Should probably return field.ErrorList
@@ -37,6 +57,14 @@ func ValidateConfig(cfg *externaldns.Config) error {
 	if cfg.Provider == "" {
 		return errors.New("no provider specified")
 	}
+	if slices.Contains(cfg.Sources, "http") && cfg.HTTPSourceURL == "" {
+		return errors.New("no URL specified for the http source, set --http-source-url")
+	}
+	for _, name := range cfg.SourcePriority {
+		if !slices.Contains(cfg.Sources, name) {
+			return fmt.Errorf("--source-priority lists %q, which is not a source", name)
+		}
+	}
 
 	// Azure provider specific validations
 	if cfg.Provider == "azure" {
@@ -85,13 +113,221 @@ func ValidateConfig(cfg *externaldns.Config) error {
 		return errors.New("FQDN Template must be set if ignoring annotations")
 	}
 
+	if cfg.SourceTimeout < 0 {
+		return errors.New("--source-timeout cannot be negative")
+	}
+
+	if cfg.MaxDeletionsPerSync < 0 {
+		return errors.New("--max-deletions-per-sync cannot be negative")
+	}
+
+	if cfg.MaxDeletionPercentage < 0 || cfg.MaxDeletionPercentage > 100 {
+		return errors.New("--max-deletion-percentage must be between 0 and 100")
+	}
+
+	if cfg.FaultInjectionErrorRate < 0 || cfg.FaultInjectionErrorRate > 100 {
+		return errors.New("--fault-injection-error-rate must be between 0 and 100")
+	}
+
+	if cfg.FaultInjectionPartialRate < 0 || cfg.FaultInjectionPartialRate > 100 {
+		return errors.New("--fault-injection-partial-rate must be between 0 and 100")
+	}
+
+	if cfg.FaultInjectionLatency < 0 {
+		return errors.New("--fault-injection-latency cannot be negative")
+	}
+
+	if cfg.RegistryAudit && !cfg.DryRun {
+		return errors.New("--registry-audit requires --dry-run")
+	}
+	if cfg.RegistryAuditReport != "" && !cfg.RegistryAudit {
+		return errors.New("--registry-audit-report requires --registry-audit")
+	}
+
+	if cfg.MaxTargetChangesPerHour < 0 {
+		return errors.New("--max-target-changes-per-hour cannot be negative")
+	}
+
+	policyRecordTypes := map[string]bool{}
+	for recordType, policy := range cfg.PolicyPerType {
+		upper := strings.ToUpper(recordType)
+		if !slices.Contains(knownRecordTypes, upper) {
+			return fmt.Errorf("unknown record type %q in --policy-per-type, options: %s", recordType, strings.Join(knownRecordTypes, ", "))
+		}
+		if policyRecordTypes[upper] {
+			return fmt.Errorf("record type %s is given more than once in --policy-per-type", upper)
+		}
+		policyRecordTypes[upper] = true
+		if _, ok := plan.Policies[policy]; !ok {
+			return fmt.Errorf("unknown policy %q for record type %s", policy, recordType)
+		}
+	}
+
+	for _, name := range cfg.PlanMutators {
+		if _, ok := plan.Mutators[name]; !ok {
+			return fmt.Errorf("unknown plan mutator: %s", name)
+		}
+	}
+
+	if cfg.LastPlanConfigMap != "" {
+		if parts := strings.Split(cfg.LastPlanConfigMap, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return errors.New("--last-plan-configmap must be in the format <namespace>/<name>")
+		}
+	}
+
+	if cfg.Registry == "configmap" {
+		if parts := strings.Split(cfg.ConfigMapRegistry, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return errors.New("--configmap-registry must be in the format <namespace>/<name>")
+		}
+	}
+
+	if cfg.Registry == "consul" && strings.Trim(cfg.ConsulRegistryPrefix, "/") == "" {
+		return errors.New("--consul-registry-prefix cannot be empty")
+	}
+	if cfg.Registry == "etcd" {
+		if strings.Trim(cfg.EtcdRegistryPrefix, "/") == "" {
+			return errors.New("--etcd-registry-prefix cannot be empty")
+		}
+		if cfg.EtcdRegistryLockTTL < time.Second {
+			return errors.New("--etcd-registry-lock-ttl must be at least 1s")
+		}
+	}
+	if cfg.Registry == "sql" {
+		if cfg.SQLRegistryDSN == "" {
+			return errors.New("--sql-registry-dsn must be specified when using the sql registry")
+		}
+		if cfg.SQLRegistryTable == "" {
+			return errors.New("--sql-registry-table cannot be empty")
+		}
+	}
+	if cfg.Registry == "webhook" && cfg.WebhookRegistryURL == "" {
+		return errors.New("--webhook-registry-url must be specified when using the webhook registry")
+	}
+
+	if cfg.RollbackLast && cfg.LastPlanConfigMap == "" {
+		return errors.New("--rollback-last requires --last-plan-configmap")
+	}
+
+	if cfg.ExportRegistry != "" && (cfg.ImportRegistry != "" || cfg.RollbackLast) {
+		return errors.New("--export-registry cannot be combined with --import-registry or --rollback-last")
+	}
+	if cfg.ImportRegistry != "" && cfg.RollbackLast {
+		return errors.New("--import-registry cannot be combined with --rollback-last")
+	}
+
+	if cfg.MigrateRegistry {
+		if cfg.MigrateRegistryFrom == cfg.MigrateRegistryTo {
+			return errors.New("migrate-registry requires --from and --to to be different registries")
+		}
+		if (cfg.MigrateRegistryFrom == "sql" || cfg.MigrateRegistryTo == "sql") && cfg.SQLRegistryDSN == "" {
+			return errors.New("--sql-registry-dsn must be specified when migrating from or to the sql registry")
+		}
+		if (cfg.MigrateRegistryFrom == "webhook" || cfg.MigrateRegistryTo == "webhook") && cfg.WebhookRegistryURL == "" {
+			return errors.New("--webhook-registry-url must be specified when migrating from or to the webhook registry")
+		}
+	}
+
+	if cfg.SecondaryRegistry != "" {
+		if !slices.Contains(externaldns.MigratableRegistries, cfg.Registry) {
+			return fmt.Errorf("--secondary-registry requires --registry to be one of %s", strings.Join(externaldns.MigratableRegistries, ", "))
+		}
+		if cfg.SecondaryRegistry == cfg.Registry {
+			return errors.New("--secondary-registry must be different from --registry")
+		}
+		if cfg.SecondaryRegistry == "sql" && cfg.SQLRegistryDSN == "" {
+			return errors.New("--sql-registry-dsn must be specified when using the sql registry as secondary registry")
+		}
+		if cfg.SecondaryRegistry == "webhook" && cfg.WebhookRegistryURL == "" {
+			return errors.New("--webhook-registry-url must be specified when using the webhook registry as secondary registry")
+		}
+	}
+
+	if cfg.Benchmark {
+		if cfg.BenchmarkRecords <= 0 || cfg.BenchmarkIterations < 0 {
+			return errors.New("benchmark requires a positive --records and a non-negative --iterations")
+		}
+		if cfg.BenchmarkChurn < 0 || cfg.BenchmarkChurn > 1 {
+			return errors.New("benchmark requires --churn to be between 0 and 1")
+		}
+		if cfg.BenchmarkTolerance < 0 {
+			return errors.New("benchmark requires a non-negative --tolerance")
+		}
+	}
+
 	if len(cfg.TXTPrefix) > 0 && len(cfg.TXTSuffix) > 0 {
 		return errors.New("txt-prefix and txt-suffix are mutual exclusive")
 	}
 
+	if cfg.AdoptExistingRecords && cfg.Registry != "txt" {
+		return errors.New("--adopt-existing-records requires --registry=txt")
+	}
+
+	if cfg.SharedOwnership && cfg.Registry != "txt" {
+		return errors.New("--shared-ownership requires --registry=txt")
+	}
+
+	for _, label := range cfg.RegistryLabels {
+		if endpoint.IsBuiltinLabelKey(label) {
+			return fmt.Errorf("--registry-label %q is a label of ExternalDNS itself", label)
+		}
+		// the label is set with the annotation external-dns.alpha.kubernetes.io/label-<name>
+		if errs := validation.IsDNS1123Label("label-" + label); len(errs) > 0 {
+			return fmt.Errorf("--registry-label %q is not a valid label name: %s", label, strings.Join(errs, ", "))
+		}
+	}
+
+	if len(cfg.TXTHeartbeatDomains) > 0 {
+		if cfg.Registry != "txt" {
+			return errors.New("--txt-heartbeat-domain requires --registry=txt")
+		}
+		if errs := validation.IsDNS1123Label(cfg.TXTOwnerID); len(errs) > 0 {
+			return fmt.Errorf("--txt-heartbeat-domain requires --txt-owner-id to be a valid DNS label: %s", strings.Join(errs, ", "))
+		}
+		if cfg.TXTHeartbeatInterval <= 0 || cfg.TXTHeartbeatFreshness <= cfg.TXTHeartbeatInterval {
+			return errors.New("--txt-heartbeat-interval must be positive and shorter than --txt-heartbeat-freshness")
+		}
+	}
+
+	if cfg.TXTOwnershipLease < 0 {
+		return errors.New("--txt-ownership-lease cannot be negative")
+	}
+	if cfg.TXTOwnershipLease > 0 {
+		if cfg.Registry != "txt" {
+			return errors.New("--txt-ownership-lease requires --registry=txt")
+		}
+		// leases are renewed once half of them has passed, which has to happen before they expire
+		if cfg.TXTOwnershipLease <= 2*cfg.Interval {
+			return errors.New("--txt-ownership-lease must be longer than twice the --interval")
+		}
+	}
+
+	if len(cfg.TXTOwnerlessRecordTypes) > 0 && cfg.Registry != "txt" {
+		return errors.New("--txt-ownerless-record-type requires --registry=txt")
+	}
+	for _, recordType := range cfg.TXTOwnerlessRecordTypes {
+		if !plan.IsManagedRecord(recordType, cfg.ManagedDNSRecordTypes, cfg.ExcludeDNSRecordTypes) {
+			return fmt.Errorf("--txt-ownerless-record-type %q must be one of the --managed-record-types", recordType)
+		}
+	}
+
+	if len(cfg.TXTOwnerIDMap) > 0 && cfg.Registry != "txt" {
+		return errors.New("--txt-owner-id-map requires --registry=txt")
+	}
+	for domain, ownerID := range cfg.TXTOwnerIDMap {
+		if domain == "" || ownerID == "" {
+			return fmt.Errorf("--txt-owner-id-map %s=%s must map a domain to an owner id", domain, ownerID)
+		}
+	}
+
 	_, err := labels.Parse(cfg.LabelFilter)
 	if err != nil {
 		return errors.New("--label-filter does not specify a valid label selector")
 	}
+	if _, err := labels.Parse(cfg.NodeFilterLabels); err != nil {
+		return errors.New("--node-filter-labels does not specify a valid label selector")
+	}
+	if _, err := labels.Parse(cfg.CRDSourceLabelFilter); err != nil {
+		return errors.New("--crd-source-label-filter does not specify a valid label selector")
+	}
 	return nil
 }
@@ -18,6 +18,7 @@ package validation
 
 import (
 	"testing"
+	"time"
 
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
 
@@ -50,6 +51,18 @@ func TestValidateFlags(t *testing.T) {
 	cfg = newValidConfig(t)
 	cfg.Provider = ""
 	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.Sources = []string{"http"}
+	assert.Error(t, ValidateConfig(cfg))
+	cfg.HTTPSourceURL = "https://registry.example.org/endpoints"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.SourcePriority = []string{"crd"}
+	assert.Error(t, ValidateConfig(cfg))
+	cfg.Sources = append(cfg.Sources, "crd")
+	assert.NoError(t, ValidateConfig(cfg))
 }
 
 func newValidConfig(t *testing.T) *externaldns.Config {
@@ -72,6 +85,366 @@ func TestValidateBadIgnoreHostnameAnnotationsConfig(t *testing.T) {
 	assert.Error(t, ValidateConfig(cfg))
 }
 
+func TestValidateBadDeletionThresholdConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.MaxDeletionsPerSync = -1
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.MaxDeletionPercentage = 101
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.MaxTargetChangesPerHour = -1
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.MaxDeletionsPerSync = 10
+	cfg.MaxDeletionPercentage = 25
+	cfg.MaxTargetChangesPerHour = 6
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateSourceTimeout(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.SourceTimeout = -time.Second
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.SourceTimeout = 30 * time.Second
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateFaultInjectionConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.FaultInjectionErrorRate = 101
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.FaultInjectionPartialRate = -1
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.FaultInjectionLatency = -time.Second
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.FaultInjectionErrorRate = 10
+	cfg.FaultInjectionPartialRate = 5
+	cfg.FaultInjectionLatency = time.Second
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateRollbackConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.RollbackLast = true
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.LastPlanConfigMap = "external-dns-last-plan"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.LastPlanConfigMap = "kube-system/external-dns-last-plan"
+	cfg.RollbackLast = true
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateRegistrySnapshotConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.ExportRegistry = "registry.json"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.ImportRegistry = "registry.json"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.ImportRegistry = "registry.json"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.LastPlanConfigMap = "kube-system/external-dns-last-plan"
+	cfg.RollbackLast = true
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateMigrateRegistryConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.MigrateRegistry = true
+	cfg.MigrateRegistryFrom = "txt"
+	cfg.MigrateRegistryTo = "dynamodb"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.MigrateRegistryTo = "txt"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.MigrateRegistryTo = "sql"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.SQLRegistryDSN = "postgres://localhost/external_dns"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateBenchmarkConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Benchmark = true
+	cfg.BenchmarkRecords = 1000
+	cfg.BenchmarkChurn = 0.01
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.BenchmarkRecords = 0
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.BenchmarkRecords = 1000
+	cfg.BenchmarkChurn = 1.5
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.BenchmarkChurn = 0.01
+	cfg.BenchmarkTolerance = -1
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateRegistryLabels(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.RegistryLabels = []string{"team", "cost-center"}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.RegistryLabels = []string{"team", "owner"}
+	assert.ErrorContains(t, ValidateConfig(cfg), "label of ExternalDNS itself")
+
+	cfg.RegistryLabels = []string{"Team"}
+	assert.ErrorContains(t, ValidateConfig(cfg), "not a valid label name")
+
+	cfg.RegistryLabels = []string{"team,environment"}
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateSecondaryRegistry(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "txt"
+	cfg.SecondaryRegistry = "dynamodb"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.SecondaryRegistry = "txt"
+	assert.ErrorContains(t, ValidateConfig(cfg), "must be different")
+
+	cfg.Registry = "noop"
+	cfg.SecondaryRegistry = "dynamodb"
+	assert.ErrorContains(t, ValidateConfig(cfg), "requires --registry")
+
+	cfg.Registry = "txt"
+	cfg.SecondaryRegistry = "sql"
+	assert.ErrorContains(t, ValidateConfig(cfg), "--sql-registry-dsn")
+}
+
+func TestValidateConfigMapRegistry(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "configmap"
+	cfg.ConfigMapRegistry = "external-dns-registry"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.Registry = "configmap"
+	cfg.ConfigMapRegistry = "kube-system/external-dns-registry"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateConsulRegistry(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "consul"
+	cfg.ConsulRegistryPrefix = "/"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.Registry = "consul"
+	cfg.ConsulRegistryPrefix = "external-dns/registry"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateEtcdRegistry(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "etcd"
+	cfg.EtcdRegistryPrefix = "/"
+	cfg.EtcdRegistryLockTTL = time.Minute
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.Registry = "etcd"
+	cfg.EtcdRegistryPrefix = "/external-dns/registry"
+	cfg.EtcdRegistryLockTTL = 500 * time.Millisecond
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.Registry = "etcd"
+	cfg.EtcdRegistryPrefix = "/external-dns/registry"
+	cfg.EtcdRegistryLockTTL = time.Minute
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateSQLRegistry(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "sql"
+	cfg.SQLRegistryTable = "external_dns_records"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.Registry = "sql"
+	cfg.SQLRegistryDSN = "postgres://localhost/dns"
+	cfg.SQLRegistryTable = ""
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.Registry = "sql"
+	cfg.SQLRegistryDSN = "postgres://localhost/dns"
+	cfg.SQLRegistryTable = "external_dns_records"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateWebhookRegistry(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "webhook"
+	assert.ErrorContains(t, ValidateConfig(cfg), "--webhook-registry-url")
+
+	cfg = newValidConfig(t)
+	cfg.Registry = "webhook"
+	cfg.WebhookRegistryURL = "http://localhost:8080"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateTXTHeartbeat(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "txt"
+	cfg.TXTOwnerID = "default"
+	cfg.TXTHeartbeatDomains = []string{"heartbeat.example.org"}
+	cfg.TXTHeartbeatInterval = time.Minute
+	cfg.TXTHeartbeatFreshness = time.Hour
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.TXTOwnerID = "my_cluster"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TXTOwnerID = "default"
+	cfg.TXTHeartbeatInterval = 0
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TXTHeartbeatInterval = 2 * time.Hour
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TXTHeartbeatInterval = time.Minute
+	cfg.Registry = "noop"
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateTXTOwnershipLease(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "txt"
+	cfg.Interval = time.Minute
+	cfg.TXTOwnershipLease = time.Hour
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.TXTOwnershipLease = -time.Hour
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TXTOwnershipLease = 2 * time.Minute
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TXTOwnershipLease = time.Hour
+	cfg.Registry = "noop"
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateTXTOwnerlessRecordTypes(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "txt"
+	cfg.ManagedDNSRecordTypes = []string{"A", "TXT"}
+	cfg.TXTOwnerlessRecordTypes = []string{"TXT"}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.TXTOwnerlessRecordTypes = []string{"PTR"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TXTOwnerlessRecordTypes = []string{"TXT"}
+	cfg.Registry = "noop"
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateTXTOwnerIDMap(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "txt"
+	cfg.TXTOwnerIDMap = map[string]string{"prod.example.com": "prod-cluster"}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.TXTOwnerIDMap = map[string]string{"prod.example.com": ""}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TXTOwnerIDMap = map[string]string{"prod.example.com": "prod-cluster"}
+	cfg.Registry = "noop"
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateRegistryAudit(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.RegistryAudit = true
+	cfg.RegistryAuditReport = "/tmp/audit.json"
+	cfg.DryRun = true
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.DryRun = false
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.DryRun = true
+	cfg.RegistryAudit = false
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidatePolicyPerType(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.PolicyPerType = map[string]string{"NS": "create-only", "MX": "upsert-only"}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.PolicyPerType = map[string]string{"mx": "never-touch"}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.PolicyPerType = map[string]string{"NS": "never"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.PolicyPerType = map[string]string{"": "create-only"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.PolicyPerType = map[string]string{"FOO": "create-only"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.PolicyPerType = map[string]string{"mx": "create-only", "MX": "sync"}
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateAdoptExistingRecords(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "txt"
+	cfg.AdoptExistingRecords = true
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.Registry = "noop"
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateSharedOwnership(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "txt"
+	cfg.SharedOwnership = true
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.Registry = "noop"
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidatePlanMutators(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.PlanMutators = []string{"lowercase-names"}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.PlanMutators = []string{"lowercase-names", "unknown"}
+	assert.Error(t, ValidateConfig(cfg))
+}
+
 func TestValidateBadRfc2136Config(t *testing.T) {
 	cfg := externaldns.NewConfig()
 
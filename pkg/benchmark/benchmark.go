@@ -0,0 +1,265 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package benchmark measures the throughput and the allocations of the synchronization of a large number of
+// synthetic records through the source, the TXT registry, the plan and the in-memory provider, so users can
+// size their instances and changes can be checked for performance regressions.
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+const zone = "benchmark.example.org"
+
+// The phases of a synchronization which are measured
+const (
+	PhaseInitialSync = "initial-sync"
+	PhaseSource      = "source"
+	PhaseRecords     = "records"
+	PhasePlan        = "plan"
+	PhaseApply       = "apply"
+)
+
+// Config is the configuration of a benchmark run.
+type Config struct {
+	// Records is the number of records of the source.
+	Records int
+	// Iterations is the number of synchronizations measured after the initial one.
+	Iterations int
+	// Churn is the fraction of the records whose targets change before every synchronization.
+	Churn float64
+	// OwnerID is the owner of the records in the TXT registry.
+	OwnerID string
+}
+
+// PhaseResult is the average cost of one run of a phase.
+type PhaseResult struct {
+	Name             string        `json:"name"`
+	Runs             int           `json:"runs"`
+	Duration         time.Duration `json:"durationNanoseconds"`
+	RecordsPerSecond float64       `json:"recordsPerSecond"`
+	BytesPerRun      uint64        `json:"bytesPerRun"`
+	AllocsPerRun     uint64        `json:"allocsPerRun"`
+}
+
+// Report is the result of a benchmark run.
+type Report struct {
+	Records    int           `json:"records"`
+	Iterations int           `json:"iterations"`
+	Churn      float64       `json:"churn"`
+	Phases     []PhaseResult `json:"phases"`
+}
+
+// Run creates the records of the synthetic source in an empty in-memory provider and then synchronizes
+// them the given number of times, changing some of them every time.
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	if cfg.Records <= 0 {
+		return nil, errors.New("the number of records must be positive")
+	}
+	if cfg.Churn < 0 || cfg.Churn > 1 {
+		return nil, fmt.Errorf("churn %v must be between 0 and 1", cfg.Churn)
+	}
+
+	p := inmemory.NewInMemoryProvider()
+	if err := p.CreateZone(zone); err != nil {
+		return nil, err
+	}
+	r, err := registry.NewTXTRegistry(p, "", "", cfg.OwnerID, 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, nil, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	s := &syncer{
+		source:   newSyntheticSource(zone, cfg.Records, cfg.Churn),
+		registry: r,
+		phases:   map[string]*PhaseResult{},
+	}
+
+	if err := s.measure(ctx, PhaseInitialSync, s.sync); err != nil {
+		return nil, err
+	}
+	for i := 0; i < cfg.Iterations; i++ {
+		s.source.advance()
+		if err := s.sync(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &Report{Records: cfg.Records, Iterations: cfg.Iterations, Churn: cfg.Churn}
+	for _, name := range []string{PhaseInitialSync, PhaseSource, PhaseRecords, PhasePlan, PhaseApply} {
+		phase, ok := s.phases[name]
+		if !ok {
+			continue
+		}
+		phase.Duration /= time.Duration(phase.Runs)
+		phase.BytesPerRun /= uint64(phase.Runs)
+		phase.AllocsPerRun /= uint64(phase.Runs)
+		if phase.Duration > 0 {
+			phase.RecordsPerSecond = float64(cfg.Records) / phase.Duration.Seconds()
+		}
+		report.Phases = append(report.Phases, *phase)
+	}
+	return report, nil
+}
+
+// syncer runs the synchronizations like the controller does and sums up the costs of the phases.
+type syncer struct {
+	source   *syntheticSource
+	registry registry.Registry
+	phases   map[string]*PhaseResult
+	// measuring is set while a whole synchronization is measured, whose phases aren't measured separately
+	measuring bool
+}
+
+func (s *syncer) sync(ctx context.Context) error {
+	var desired, current []*endpoint.Endpoint
+	err := s.measure(ctx, PhaseSource, func(ctx context.Context) error {
+		endpoints, err := s.source.Endpoints(ctx)
+		if err != nil {
+			return err
+		}
+		desired, err = s.registry.AdjustEndpoints(endpoints)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	err = s.measure(ctx, PhaseRecords, func(ctx context.Context) error {
+		current, err = s.registry.Records(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	var changes *plan.Changes
+	_ = s.measure(ctx, PhasePlan, func(_ context.Context) error {
+		p := &plan.Plan{
+			Policies:       []plan.Policy{&plan.SyncPolicy{}},
+			Current:        current,
+			Desired:        desired,
+			DomainFilter:   endpoint.MatchAllDomainFilters{s.registry.GetDomainFilter()},
+			ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+			OwnerID:        s.registry.OwnerID(),
+		}
+		changes = p.Calculate().Changes
+		return nil
+	})
+	return s.measure(ctx, PhaseApply, func(ctx context.Context) error {
+		if !changes.HasChanges() {
+			return nil
+		}
+		return s.registry.ApplyChanges(ctx, changes)
+	})
+}
+
+// measure runs the phase and adds its duration and allocations to the totals of the phase.
+func (s *syncer) measure(ctx context.Context, name string, f func(ctx context.Context) error) error {
+	if s.measuring {
+		return f(ctx)
+	}
+	s.measuring = true
+	defer func() { s.measuring = false }()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	if err := f(ctx); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	phase, ok := s.phases[name]
+	if !ok {
+		phase = &PhaseResult{Name: name}
+		s.phases[name] = phase
+	}
+	phase.Runs++
+	phase.Duration += elapsed
+	phase.BytesPerRun += after.TotalAlloc - before.TotalAlloc
+	phase.AllocsPerRun += after.Mallocs - before.Mallocs
+	return nil
+}
+
+// Compare returns an error listing the phases which took longer or allocated more than the same phases of
+// the baseline, allowing for the given fraction of tolerance. Reports of different numbers of records
+// can't be compared.
+func (r *Report) Compare(baseline *Report, tolerance float64) error {
+	if r.Records != baseline.Records {
+		return fmt.Errorf("the report of %d records can't be compared with the baseline of %d records", r.Records, baseline.Records)
+	}
+	phases := make(map[string]PhaseResult, len(baseline.Phases))
+	for _, phase := range baseline.Phases {
+		phases[phase.Name] = phase
+	}
+	var regressions []error
+	for _, phase := range r.Phases {
+		base, ok := phases[phase.Name]
+		if !ok {
+			continue
+		}
+		if exceeds(float64(phase.Duration), float64(base.Duration), tolerance) {
+			regressions = append(regressions, fmt.Errorf("%s took %s instead of %s", phase.Name, phase.Duration, base.Duration))
+		}
+		if exceeds(float64(phase.AllocsPerRun), float64(base.AllocsPerRun), tolerance) {
+			regressions = append(regressions, fmt.Errorf("%s made %d allocations instead of %d", phase.Name, phase.AllocsPerRun, base.AllocsPerRun))
+		}
+	}
+	return errors.Join(regressions...)
+}
+
+func exceeds(value, baseline, tolerance float64) bool {
+	return value > baseline*(1+tolerance)
+}
+
+// WriteTable writes the report as a table for humans.
+func (r *Report) WriteTable(w io.Writer) error {
+	fmt.Fprintf(w, "%d records, %d iterations, %.2f%% churn\n", r.Records, r.Iterations, r.Churn*100)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "PHASE\tRUNS\tDURATION\tRECORDS/S\tBYTES/RUN\tALLOCS/RUN\t")
+	for _, phase := range r.Phases {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%.0f\t%d\t%d\t\n", phase.Name, phase.Runs, phase.Duration.Round(time.Microsecond), phase.RecordsPerSecond, phase.BytesPerRun, phase.AllocsPerRun)
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes the report as JSON, which ReadReport reads as a baseline.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// ReadReport reads a report written by WriteJSON.
+func ReadReport(rd io.Reader) (*Report, error) {
+	var report Report
+	if err := json.NewDecoder(rd).Decode(&report); err != nil {
+		return nil, fmt.Errorf("reading the benchmark report: %w", err)
+	}
+	return &report, nil
+}
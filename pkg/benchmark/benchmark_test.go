@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	report, err := Run(context.Background(), Config{Records: 200, Iterations: 3, Churn: 0.1, OwnerID: "benchmark"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 200, report.Records)
+	var names []string
+	for _, phase := range report.Phases {
+		names = append(names, phase.Name)
+		if phase.Name == PhaseInitialSync {
+			assert.Equal(t, 1, phase.Runs)
+		} else {
+			assert.Equal(t, 3, phase.Runs, phase.Name)
+		}
+		assert.Positive(t, phase.AllocsPerRun, phase.Name)
+	}
+	assert.Equal(t, []string{PhaseInitialSync, PhaseSource, PhaseRecords, PhasePlan, PhaseApply}, names)
+}
+
+func TestRunInvalidConfig(t *testing.T) {
+	_, err := Run(context.Background(), Config{Records: 0, OwnerID: "benchmark"})
+	assert.Error(t, err)
+	_, err = Run(context.Background(), Config{Records: 10, Churn: 2, OwnerID: "benchmark"})
+	assert.Error(t, err)
+	_, err = Run(context.Background(), Config{Records: 10})
+	assert.Error(t, err)
+}
+
+func TestSyntheticSourceAdvance(t *testing.T) {
+	s := newSyntheticSource(zone, 10, 0.3)
+	before, err := s.Endpoints(context.Background())
+	require.NoError(t, err)
+	s.advance()
+	s.advance()
+	s.advance()
+	s.advance()
+	after, err := s.Endpoints(context.Background())
+	require.NoError(t, err)
+
+	changed := 0
+	for i := range before {
+		assert.Equal(t, before[i].DNSName, after[i].DNSName)
+		if !before[i].Targets.Same(after[i].Targets) {
+			changed++
+		}
+	}
+	// 12 changes wrap around to the first records
+	assert.Equal(t, 10, changed)
+	assert.Equal(t, uint8(2), s.versions[0])
+	assert.Equal(t, uint8(1), s.versions[9])
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &Report{Records: 100, Phases: []PhaseResult{
+		{Name: PhasePlan, Duration: 10 * time.Millisecond, AllocsPerRun: 1000},
+		{Name: PhaseApply, Duration: 10 * time.Millisecond, AllocsPerRun: 1000},
+	}}
+
+	report := &Report{Records: 100, Phases: []PhaseResult{
+		{Name: PhasePlan, Duration: 11 * time.Millisecond, AllocsPerRun: 1100},
+		{Name: PhaseApply, Duration: 9 * time.Millisecond, AllocsPerRun: 900},
+		{Name: PhaseSource, Duration: time.Second, AllocsPerRun: 1000000},
+	}}
+	assert.NoError(t, report.Compare(baseline, 0.2))
+
+	report.Phases[0].Duration = 13 * time.Millisecond
+	report.Phases[1].AllocsPerRun = 1300
+	err := report.Compare(baseline, 0.2)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "plan took 13ms instead of 10ms")
+	assert.Contains(t, err.Error(), "apply made 1300 allocations instead of 1000")
+
+	report.Records = 1000
+	assert.ErrorContains(t, report.Compare(baseline, 0.2), "can't be compared")
+}
+
+func TestReportJSON(t *testing.T) {
+	report := &Report{Records: 100, Iterations: 2, Churn: 0.01, Phases: []PhaseResult{
+		{Name: PhasePlan, Runs: 2, Duration: 10 * time.Millisecond, RecordsPerSecond: 10000, BytesPerRun: 2048, AllocsPerRun: 1000},
+	}}
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buf))
+	read, err := ReadReport(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, report, read)
+
+	_, err = ReadReport(bytes.NewBufferString("not json"))
+	assert.Error(t, err)
+}
+
+func TestWriteTable(t *testing.T) {
+	report := &Report{Records: 100, Iterations: 2, Churn: 0.01, Phases: []PhaseResult{
+		{Name: PhasePlan, Runs: 2, Duration: 10 * time.Millisecond, RecordsPerSecond: 10000, BytesPerRun: 2048, AllocsPerRun: 1000},
+	}}
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteTable(&buf))
+	assert.Contains(t, buf.String(), "100 records, 2 iterations, 1.00% churn")
+	assert.Regexp(t, `plan +2 +10ms +10000 +2048 +1000`, buf.String())
+}
+
+func benchmarkSync(b *testing.B, records int) {
+	for i := 0; i < b.N; i++ {
+		if _, err := Run(context.Background(), Config{Records: records, Iterations: 1, Churn: 0.01, OwnerID: "benchmark"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSync1k(b *testing.B)  { benchmarkSync(b, 1000) }
+func BenchmarkSync10k(b *testing.B) { benchmarkSync(b, 10000) }
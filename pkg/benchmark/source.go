@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// syntheticSource is a source of a fixed number of A records. Every call of advance changes the targets of
+// the next records in turn, so consecutive synchronizations update a steady fraction of the records.
+type syntheticSource struct {
+	zone     string
+	versions []uint8
+	changes  int
+	next     int
+}
+
+func newSyntheticSource(zone string, records int, churn float64) *syntheticSource {
+	return &syntheticSource{
+		zone:     zone,
+		versions: make([]uint8, records),
+		changes:  int(float64(records) * churn),
+	}
+}
+
+// Endpoints returns new endpoints on every call, like the sources reading Kubernetes resources.
+func (s *syntheticSource) Endpoints(_ context.Context) ([]*endpoint.Endpoint, error) {
+	endpoints := make([]*endpoint.Endpoint, 0, len(s.versions))
+	for i, version := range s.versions {
+		name := fmt.Sprintf("record-%d.%s", i, s.zone)
+		target := fmt.Sprintf("10.%d.%d.%d", version, i>>8&0xff, i&0xff)
+		ep := endpoint.NewEndpointWithTTL(name, endpoint.RecordTypeA, 300, target)
+		ep.Labels[endpoint.ResourceLabelKey] = fmt.Sprintf("service/benchmark/record-%d", i)
+		endpoints = append(endpoints, ep)
+	}
+	return endpoints, nil
+}
+
+func (s *syntheticSource) AddEventHandler(_ context.Context, _ func()) {}
+
+// advance changes the targets of the next records.
+func (s *syntheticSource) advance() {
+	for i := 0; i < s.changes; i++ {
+		s.versions[s.next]++
+		s.next = (s.next + 1) % len(s.versions)
+	}
+}
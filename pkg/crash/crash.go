@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package crash converts panics of sources and providers into errors, so a single broken
+// component can't take down the controller.
+package crash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var panicsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "external_dns",
+		Subsystem: "controller",
+		Name:      "recovered_panics_total",
+		Help:      "Number of panics of sources and providers recovered, by component and hash of the stack.",
+	},
+	[]string{"component", "stack_hash"},
+)
+
+func init() {
+	prometheus.MustRegister(panicsTotal)
+}
+
+// Error is the error a recovered panic is converted to.
+type Error struct {
+	// Component is the source or provider which panicked.
+	Component string
+	// Value is the value passed to panic.
+	Value any
+	// StackHash identifies the code path of the panic, it is the same for every panic at the same place.
+	StackHash string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s panicked: %v (stack %s)", e.Component, e.Value, e.StackHash)
+}
+
+// Recover converts a panic of the calling function into an *Error stored in err. It has to be deferred:
+//
+//	defer crash.Recover("provider", &err)
+//
+// Panics of goroutines started by the calling function can't be recovered.
+func Recover(component string, err *error) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	crashErr := &Error{Component: component, Value: value, StackHash: stackHash()}
+	panicsTotal.WithLabelValues(component, crashErr.StackHash).Inc()
+	log.Errorf("Recovered from panic: %v\n%s", crashErr, debug.Stack())
+	*err = crashErr
+}
+
+// stackHash returns a short hash of the functions and lines of the stack, without the arguments and addresses,
+// which differ between panics at the same place.
+func stackHash() string {
+	pcs := make([]uintptr, 64)
+	// skip runtime.Callers, stackHash and Recover
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	h := sha256.New()
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(h, "%s:%d\n", frame.Function, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crash
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func panicking(value any) (err error) {
+	defer Recover("test", &err)
+	if value != nil {
+		panic(value)
+	}
+	return errors.New("returned")
+}
+
+func TestRecover(t *testing.T) {
+	err := panicking(nil)
+	assert.EqualError(t, err, "returned")
+
+	var errs []error
+	for _, value := range []string{"boom", "bang"} {
+		errs = append(errs, panicking(value))
+	}
+	var first, second *Error
+	require.ErrorAs(t, errs[0], &first)
+	assert.Equal(t, "test", first.Component)
+	assert.Equal(t, "boom", first.Value)
+	assert.Len(t, first.StackHash, 12)
+
+	// the same place panicking with another value has the same hash
+	require.ErrorAs(t, errs[1], &second)
+	assert.Equal(t, "bang", second.Value)
+	assert.Equal(t, first.StackHash, second.StackHash)
+	assert.Equal(t, 2.0, testutil.ToFloat64(panicsTotal.WithLabelValues("test", first.StackHash)))
+}
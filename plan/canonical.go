@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// canonicalTarget returns the form of a target used when comparing desired and current records.
+// Providers may return host names with a different case or with a trailing dot and IPv6
+// addresses in another notation than the sources, which must not be mistaken for a change.
+// The endpoints themselves are never modified, providers still receive the targets as they are.
+func canonicalTarget(recordType, target string) string {
+	switch recordType {
+	case endpoint.RecordTypeA, endpoint.RecordTypeAAAA:
+		if ip, err := netip.ParseAddr(target); err == nil {
+			return ip.String()
+		}
+	case endpoint.RecordTypeCNAME, endpoint.RecordTypeNS, endpoint.RecordTypePTR:
+		return canonicalHostname(target)
+	case endpoint.RecordTypeMX:
+		// <preference> <host>
+		if fields := strings.Fields(target); len(fields) == 2 {
+			return fields[0] + " " + canonicalHostname(fields[1])
+		}
+	case endpoint.RecordTypeSRV:
+		// <priority> <weight> <port> <host>
+		if fields := strings.Fields(target); len(fields) == 4 {
+			fields[3] = canonicalHostname(fields[3])
+			return strings.Join(fields, " ")
+		}
+	}
+	// other targets, e.g. of TXT records, have always been compared case-insensitively
+	return strings.ToLower(target)
+}
+
+func canonicalHostname(hostname string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(hostname)), ".")
+}
+
+// canonicalTargets returns the sorted canonical forms of the targets.
+func canonicalTargets(recordType string, targets endpoint.Targets) []string {
+	canonical := make([]string, len(targets))
+	for i, target := range targets {
+		canonical[i] = canonicalTarget(recordType, target)
+	}
+	sort.Strings(canonical)
+	return canonical
+}
+
+// sameTargets reports whether both lists contain the same targets once canonicalized.
+func sameTargets(recordType string, t, o endpoint.Targets) bool {
+	if len(t) != len(o) {
+		return false
+	}
+	if len(t) == 1 {
+		return t[0] == o[0] || canonicalTarget(recordType, t[0]) == canonicalTarget(recordType, o[0])
+	}
+	ct, co := canonicalTargets(recordType, t), canonicalTargets(recordType, o)
+	for i := range ct {
+		if ct[i] != co[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestCanonicalTarget(t *testing.T) {
+	for _, tc := range []struct {
+		recordType string
+		target     string
+		expected   string
+	}{
+		{endpoint.RecordTypeA, "10.0.0.1", "10.0.0.1"},
+		{endpoint.RecordTypeAAAA, "2001:DB8:0:0:0:0:0:1", "2001:db8::1"},
+		{endpoint.RecordTypeCNAME, "LB.Example.org.", "lb.example.org"},
+		{endpoint.RecordTypeNS, "ns1.example.org.", "ns1.example.org"},
+		{endpoint.RecordTypePTR, "Host.example.org.", "host.example.org"},
+		{endpoint.RecordTypeMX, "10 Mail.example.org.", "10 mail.example.org"},
+		{endpoint.RecordTypeSRV, "0 50 5060 SIP.example.org.", "0 50 5060 sip.example.org"},
+		{endpoint.RecordTypeTXT, "Heritage=External-DNS", "heritage=external-dns"},
+	} {
+		t.Run(tc.recordType+"/"+tc.target, func(t *testing.T) {
+			assert.Equal(t, tc.expected, canonicalTarget(tc.recordType, tc.target))
+		})
+	}
+}
+
+// TestCalculateCanonicalizesProviderRecords makes sure records returned by providers in a
+// different, but equivalent, form than the desired ones don't cause updates on every sync.
+func TestCalculateCanonicalizesProviderRecords(t *testing.T) {
+	for _, tc := range []struct {
+		title   string
+		current *endpoint.Endpoint
+		desired *endpoint.Endpoint
+	}{
+		{
+			title:   "fully qualified names and targets",
+			current: &endpoint.Endpoint{DNSName: "foo.example.org.", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"lb.example.org."}, Labels: endpoint.Labels{}},
+			desired: endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "lb.example.org"),
+		},
+		{
+			title:   "upper case names and targets",
+			current: &endpoint.Endpoint{DNSName: "Foo.Example.org", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"LB.example.org"}, Labels: endpoint.Labels{}},
+			desired: endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "lb.example.org"),
+		},
+		{
+			title:   "multiple targets sorted differently because of their case",
+			current: &endpoint.Endpoint{DNSName: "foo.example.org", RecordType: endpoint.RecordTypeNS, Targets: endpoint.Targets{"NS2.example.org", "ns1.example.org"}, Labels: endpoint.Labels{}},
+			desired: endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeNS, "ns1.example.org", "ns2.example.org"),
+		},
+		{
+			title:   "expanded IPv6 notation",
+			current: endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeAAAA, "2001:0db8:0000:0000:0000:0000:0000:0001"),
+			desired: endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeAAAA, "2001:db8::1"),
+		},
+		{
+			title:   "fully qualified MX hosts",
+			current: &endpoint.Endpoint{DNSName: "example.org", RecordType: endpoint.RecordTypeMX, Targets: endpoint.Targets{"10 Mail.example.org."}, Labels: endpoint.Labels{}},
+			desired: endpoint.NewEndpoint("example.org", endpoint.RecordTypeMX, "10 mail.example.org"),
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			p := &Plan{
+				Policies:       []Policy{&SyncPolicy{}},
+				Current:        []*endpoint.Endpoint{tc.current},
+				Desired:        []*endpoint.Endpoint{tc.desired},
+				ManagedRecords: []string{endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME, endpoint.RecordTypeNS, endpoint.RecordTypeMX},
+			}
+			changes := p.Calculate().Changes
+			assert.False(t, changes.HasChanges(), "unexpected changes: %+v", changes)
+		})
+	}
+}
+
+func TestCalculateDetectsCanonicalTargetChange(t *testing.T) {
+	p := &Plan{
+		Policies:       []Policy{&SyncPolicy{}},
+		Current:        []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "LB1.example.org.")},
+		Desired:        []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "lb2.example.org")},
+		ManagedRecords: []string{endpoint.RecordTypeCNAME},
+	}
+	changes := p.Calculate().Changes
+	assert.Len(t, changes.UpdateNew, 1)
+	// providers receive the records unmodified
+	assert.Equal(t, endpoint.Targets{"LB1.example.org"}, changes.UpdateOld[0].Targets)
+}
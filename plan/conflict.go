@@ -44,21 +44,33 @@ func (s PerResource) ResolveCreate(candidates []*endpoint.Endpoint) *endpoint.En
 			min = ep
 		}
 	}
+	logConflicts(min, candidates)
 	return min
 }
 
 // ResolveUpdate is invoked when dns name is already owned by "current" endpoint
 // ResolveUpdate uses "current" record as base and updates it accordingly with new version of same resource
-// if it doesn't exist then pick min
+// unless another resource takes the dns name over from it, if neither exists then pick min
 func (s PerResource) ResolveUpdate(current *endpoint.Endpoint, candidates []*endpoint.Endpoint) *endpoint.Endpoint {
 	currentResource := current.Labels[endpoint.ResourceLabelKey] // resource which has already acquired the DNS
 	// TODO: sort candidates only needed because we can still have two endpoints from same resource here. We sort for consistency
 	// TODO: remove once single endpoint can have multiple targets
-	sort.SliceStable(candidates, func(i, j int) bool {
-		return s.less(candidates[i], candidates[j])
-	})
+	if len(candidates) > 1 {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return s.less(candidates[i], candidates[j])
+		})
+	}
+	if currentResource != "" {
+		for _, ep := range candidates {
+			if ep.Labels[endpoint.HandoffLabelKey] == currentResource && ep.Labels[endpoint.ResourceLabelKey] != currentResource {
+				logConflicts(ep, candidates)
+				return ep
+			}
+		}
+	}
 	for _, ep := range candidates {
 		if ep.Labels[endpoint.ResourceLabelKey] == currentResource {
+			logConflicts(ep, candidates)
 			return ep
 		}
 	}
@@ -117,6 +129,27 @@ func (s PerResource) ResolveRecordTypes(key planKey, row *planTableRow) map[stri
 	return row.records
 }
 
+// logConflicts reports the candidates of other resources losing the dns name to the chosen one
+func logConflicts(chosen *endpoint.Endpoint, candidates []*endpoint.Endpoint) {
+	if chosen == nil || log.GetLevel() < log.DebugLevel {
+		return
+	}
+	resource := chosen.Labels[endpoint.ResourceLabelKey]
+	for _, ep := range candidates {
+		if other := ep.Labels[endpoint.ResourceLabelKey]; other != resource {
+			log.Debugf("Domain %s %s is claimed by %s, ignoring conflicting claim of %s", chosen.DNSName, chosen.RecordType, resourceOrUnknown(resource), resourceOrUnknown(other))
+		}
+	}
+}
+
+// resourceOrUnknown returns the resource for log messages about endpoints without a resource label
+func resourceOrUnknown(resource string) string {
+	if resource == "" {
+		return "an unknown resource"
+	}
+	return resource
+}
+
 // less returns true if endpoint x is less than y
 func (s PerResource) less(x, y *endpoint.Endpoint) bool {
 	return x.Targets.IsLess(y.Targets)
@@ -137,6 +137,13 @@ func (suite *ResolverSuite) TestStrictResolver() {
 	}
 	suite.Equal(newFooV1Cname, suite.perResource.ResolveUpdate(suite.fooV1Cname, []*endpoint.Endpoint{suite.fooA5, suite.fooV2Cname, newFooV1Cname}), "should actually pick same resource with updates")
 
+	// another resource takes the dns name over from the current one
+	fooV2Handoff := suite.fooV2Cname.DeepCopy()
+	fooV2Handoff.Labels[endpoint.HandoffLabelKey] = suite.fooV1Cname.Labels[endpoint.ResourceLabelKey]
+	suite.Equal(fooV2Handoff, suite.perResource.ResolveUpdate(suite.fooV1Cname, []*endpoint.Endpoint{suite.fooV1Cname, fooV2Handoff}), "should pick resource taking over")
+	suite.Equal(suite.bar127A, suite.perResource.ResolveUpdate(suite.bar127A, []*endpoint.Endpoint{fooV2Handoff, suite.bar127A}), "should ignore handoff from other resource")
+	suite.Equal(suite.bar127A, suite.perResource.ResolveUpdate(suite.legacyBar192A, []*endpoint.Endpoint{fooV2Handoff, suite.bar127A}), "should ignore handoff for legacy record")
+
 	// legacy record's resource value will not match any candidates resource label
 	// therefore pick minimum again
 	suite.Equal(suite.bar127A, suite.perResource.ResolveUpdate(suite.legacyBar192A, []*endpoint.Endpoint{suite.bar127A, suite.bar192A}), " legacy record's resource value will not match, should pick minimum")
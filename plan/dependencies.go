@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// deferDependentCreates holds back the creates of records depending on records which don't exist yet, see
+// endpoint.DependsOnLabelKey. Records depending on records created by the same changes are created in a later
+// synchronization, once their dependencies exist, since providers don't guarantee the order of the changes.
+func deferDependentCreates(current []*endpoint.Endpoint, changes *Changes) (*Changes, []*endpoint.Endpoint) {
+	existing := make(map[string]struct{}, len(current))
+	for _, record := range current {
+		existing[normalizeDependency(record.DNSName)] = struct{}{}
+	}
+
+	var creates, deferred []*endpoint.Endpoint
+	for _, create := range changes.Create {
+		if missing := missingDependency(create, existing); missing != "" {
+			log.Infof("Deferring the creation of %s %s until %s exists", create.RecordType, create.DNSName, missing)
+			deferred = append(deferred, create)
+			continue
+		}
+		creates = append(creates, create)
+	}
+	changes.Create = creates
+	return changes, deferred
+}
+
+// missingDependency returns the first DNS name the record depends on which doesn't exist, if any.
+func missingDependency(record *endpoint.Endpoint, existing map[string]struct{}) string {
+	for _, name := range record.Labels.DependsOn() {
+		if _, ok := existing[normalizeDependency(name)]; !ok {
+			return name
+		}
+	}
+	return ""
+}
+
+func normalizeDependency(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
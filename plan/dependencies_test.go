@@ -0,0 +1,53 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestDeferDependentCreates(t *testing.T) {
+	target := endpoint.NewEndpoint("lb.example.com", endpoint.RecordTypeA, "1.2.3.4")
+	alias := endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeCNAME, "lb.example.com")
+	alias.Labels[endpoint.DependsOnLabelKey] = "lb.example.com"
+	independent := endpoint.NewEndpoint("api.example.com", endpoint.RecordTypeA, "5.6.7.8")
+
+	// the CNAME waits for its target created by the same changes
+	p := &Plan{
+		Policies:       []Policy{&SyncPolicy{}},
+		Desired:        []*endpoint.Endpoint{target, alias, independent},
+		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+	}
+	first := p.Calculate()
+	assert.ElementsMatch(t, []*endpoint.Endpoint{target, independent}, first.Changes.Create)
+	require.Len(t, first.Deferred, 1)
+	assert.Equal(t, "www.example.com", first.Deferred[0].DNSName)
+
+	// the CNAME is created once its target exists
+	p.Current = []*endpoint.Endpoint{
+		endpoint.NewEndpoint("LB.example.com.", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpoint("api.example.com", endpoint.RecordTypeA, "5.6.7.8"),
+	}
+	second := p.Calculate()
+	assert.Equal(t, []*endpoint.Endpoint{alias}, second.Changes.Create)
+	assert.Empty(t, second.Deferred)
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// Reasons of invariant violations.
+const (
+	// ViolationCNAMEConflict is a CNAME sharing its name with records of other types
+	ViolationCNAMEConflict = "CNAMEConflict"
+	// ViolationTargetIsCNAME is an MX or NS record pointing at a CNAME
+	ViolationTargetIsCNAME = "TargetIsCNAME"
+	// ViolationSRVTargetNotHostname is an SRV record pointing at an IP address
+	ViolationSRVTargetNotHostname = "SRVTargetNotHostname"
+)
+
+// Violation is a create or update which has been skipped because the resulting records would
+// break a DNS invariant.
+type Violation struct {
+	// Endpoint is the record which would have been created or updated
+	Endpoint *endpoint.Endpoint
+	// Reason is one of the Violation constants
+	Reason string
+	// Message describes the violation
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s %s: %s", v.Endpoint.RecordType, v.Endpoint.DNSName, v.Message)
+}
+
+// zoneState holds the records expected after applying changes.
+type zoneState struct {
+	// records by normalized DNS name
+	records map[string][]*endpoint.Endpoint
+	// pointers are the MX and NS records by the normalized host names they point at
+	pointers map[string]*endpoint.Endpoint
+}
+
+func recordKey(e *endpoint.Endpoint) string {
+	return normalizeDNSName(e.DNSName) + " " + e.RecordType + " " + e.SetIdentifier
+}
+
+// newZoneState returns the records resulting from applying changes to current.
+func newZoneState(current []*endpoint.Endpoint, changes *Changes) zoneState {
+	removed := map[string]bool{}
+	for _, e := range changes.Delete {
+		removed[recordKey(e)] = true
+	}
+	for _, e := range changes.UpdateOld {
+		removed[recordKey(e)] = true
+	}
+
+	state := zoneState{records: map[string][]*endpoint.Endpoint{}, pointers: map[string]*endpoint.Endpoint{}}
+	add := func(e *endpoint.Endpoint) {
+		name := normalizeDNSName(e.DNSName)
+		state.records[name] = append(state.records[name], e)
+		for _, host := range hostTargets(e) {
+			state.pointers[normalizeDNSName(host)] = e
+		}
+	}
+	for _, e := range current {
+		if !removed[recordKey(e)] {
+			add(e)
+		}
+	}
+	for _, e := range changes.Create {
+		add(e)
+	}
+	for _, e := range changes.UpdateNew {
+		add(e)
+	}
+	return state
+}
+
+func (s zoneState) hasCNAME(name string) bool {
+	for _, e := range s.records[normalizeDNSName(name)] {
+		if e.RecordType == endpoint.RecordTypeCNAME {
+			return true
+		}
+	}
+	return false
+}
+
+// hostTargets returns the host names the targets of an MX or NS record point at.
+func hostTargets(e *endpoint.Endpoint) []string {
+	hosts := []string{}
+	for _, target := range e.Targets {
+		switch e.RecordType {
+		case endpoint.RecordTypeMX:
+			if fields := strings.Fields(target); len(fields) == 2 {
+				hosts = append(hosts, fields[1])
+			}
+		case endpoint.RecordTypeNS:
+			hosts = append(hosts, target)
+		}
+	}
+	return hosts
+}
+
+// violation returns the first invariant e breaks in the state, if any.
+func (s zoneState) violation(e *endpoint.Endpoint) *Violation {
+	name := normalizeDNSName(e.DNSName)
+	for _, other := range s.records[name] {
+		if other == e || other.RecordType == e.RecordType {
+			continue
+		}
+		if e.RecordType == endpoint.RecordTypeCNAME || other.RecordType == endpoint.RecordTypeCNAME {
+			return &Violation{e, ViolationCNAMEConflict, fmt.Sprintf("a CNAME can't coexist with the %s record of the same name", other.RecordType)}
+		}
+	}
+
+	switch e.RecordType {
+	case endpoint.RecordTypeMX, endpoint.RecordTypeNS:
+		for _, host := range hostTargets(e) {
+			if s.hasCNAME(host) {
+				return &Violation{e, ViolationTargetIsCNAME, fmt.Sprintf("target %s is a CNAME", host)}
+			}
+		}
+	case endpoint.RecordTypeCNAME:
+		if pointer, ok := s.pointers[name]; ok {
+			return &Violation{e, ViolationTargetIsCNAME, fmt.Sprintf("the %s record %s points at the name", pointer.RecordType, pointer.DNSName)}
+		}
+	case endpoint.RecordTypeSRV:
+		for _, target := range e.Targets {
+			fields := strings.Fields(target)
+			if len(fields) != 4 {
+				continue
+			}
+			if _, err := netip.ParseAddr(strings.TrimSuffix(fields[3], ".")); err == nil {
+				return &Violation{e, ViolationSRVTargetNotHostname, fmt.Sprintf("target %s is an IP address, not a hostname", fields[3])}
+			}
+		}
+	}
+	return nil
+}
+
+// checkInvariants removes the creates and updates from changes which break a DNS invariant in the
+// records resulting from applying the changes to current. Deletions never break an invariant.
+func checkInvariants(current []*endpoint.Endpoint, changes *Changes) (*Changes, []Violation) {
+	state := newZoneState(current, changes)
+	violations := []Violation{}
+
+	checked := &Changes{
+		Create:    []*endpoint.Endpoint{},
+		UpdateOld: []*endpoint.Endpoint{},
+		UpdateNew: []*endpoint.Endpoint{},
+		Delete:    changes.Delete,
+	}
+	for _, e := range changes.Create {
+		if v := state.violation(e); v != nil {
+			violations = append(violations, *v)
+			continue
+		}
+		checked.Create = append(checked.Create, e)
+	}
+
+	if len(changes.UpdateOld) != len(changes.UpdateNew) {
+		checked.UpdateOld = changes.UpdateOld
+		checked.UpdateNew = changes.UpdateNew
+		return checked, violations
+	}
+	for i, e := range changes.UpdateNew {
+		if v := state.violation(e); v != nil {
+			violations = append(violations, *v)
+			continue
+		}
+		checked.UpdateOld = append(checked.UpdateOld, changes.UpdateOld[i])
+		checked.UpdateNew = append(checked.UpdateNew, e)
+	}
+	return checked, violations
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestCheckInvariants(t *testing.T) {
+	allTypes := []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME, endpoint.RecordTypeMX, endpoint.RecordTypeNS, endpoint.RecordTypeSRV, endpoint.RecordTypeTXT}
+
+	for _, tc := range []struct {
+		title    string
+		managed  []string
+		current  []*endpoint.Endpoint
+		desired  []*endpoint.Endpoint
+		reason   string
+		creates  int
+		violated string
+	}{
+		{
+			title:    "CNAME alongside a record of an unmanaged type",
+			managed:  []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+			current:  []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeMX, "10 mail.example.com")},
+			desired:  []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeCNAME, "lb.example.com")},
+			reason:   ViolationCNAMEConflict,
+			violated: "foo.example.com",
+		},
+		{
+			title: "MX pointing at a CNAME",
+			desired: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("example.com", endpoint.RecordTypeMX, "10 mail.example.com"),
+				endpoint.NewEndpoint("mail.example.com", endpoint.RecordTypeCNAME, "mx.provider.com"),
+			},
+			reason:   ViolationTargetIsCNAME,
+			creates:  0,
+			violated: "example.com",
+		},
+		{
+			title:   "CNAME at the target of an existing NS record",
+			current: []*endpoint.Endpoint{endpoint.NewEndpoint("sub.example.com", endpoint.RecordTypeNS, "ns1.example.com")},
+			desired: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("sub.example.com", endpoint.RecordTypeNS, "ns1.example.com"),
+				endpoint.NewEndpoint("ns1.example.com", endpoint.RecordTypeCNAME, "ns.provider.com"),
+			},
+			reason:   ViolationTargetIsCNAME,
+			violated: "ns1.example.com",
+		},
+		{
+			title:    "SRV pointing at an IP address",
+			desired:  []*endpoint.Endpoint{endpoint.NewEndpoint("_sip._tcp.example.com", endpoint.RecordTypeSRV, "10 5 5060 192.0.2.1")},
+			reason:   ViolationSRVTargetNotHostname,
+			violated: "_sip._tcp.example.com",
+		},
+		{
+			title: "valid records",
+			desired: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("example.com", endpoint.RecordTypeMX, "10 mail.example.com"),
+				endpoint.NewEndpoint("mail.example.com", endpoint.RecordTypeA, "192.0.2.1"),
+				endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeCNAME, "example.com"),
+				endpoint.NewEndpoint("_sip._tcp.example.com", endpoint.RecordTypeSRV, "10 5 5060 sip.example.com"),
+			},
+			creates: 4,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			managed := tc.managed
+			if managed == nil {
+				managed = allTypes
+			}
+			p := &Plan{
+				Policies:        []Policy{&SyncPolicy{}},
+				Current:         tc.current,
+				Desired:         tc.desired,
+				ManagedRecords:  managed,
+				CheckInvariants: true,
+			}
+			calculated := p.Calculate()
+
+			if tc.reason == "" {
+				assert.Empty(t, calculated.Violations)
+				assert.Len(t, calculated.Changes.Create, tc.creates)
+				return
+			}
+			violated := []string{}
+			for _, v := range calculated.Violations {
+				assert.Equal(t, tc.reason, v.Reason)
+				violated = append(violated, v.Endpoint.DNSName)
+			}
+			assert.Contains(t, violated, tc.violated)
+			for _, e := range calculated.Changes.Create {
+				assert.NotEqual(t, tc.violated, e.DNSName, "violating %s record is created", e.RecordType)
+			}
+		})
+	}
+}
+
+func TestCheckInvariantsAllowsTypeMigration(t *testing.T) {
+	current := endpoint.NewEndpoint("svc.example.com", endpoint.RecordTypeCNAME, "lb.example.com")
+	p := &Plan{
+		Policies:        []Policy{&SyncPolicy{}},
+		Current:         []*endpoint.Endpoint{current},
+		Desired:         []*endpoint.Endpoint{endpoint.NewEndpoint("svc.example.com", endpoint.RecordTypeA, "192.0.2.1")},
+		ManagedRecords:  []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+		CheckInvariants: true,
+	}
+	calculated := p.Calculate()
+
+	assert.Empty(t, calculated.Violations)
+	assert.Len(t, calculated.Changes.Create, 1)
+	assert.Equal(t, []*endpoint.Endpoint{current}, calculated.Changes.Delete)
+}
+
+func TestCheckInvariantsDisabled(t *testing.T) {
+	p := &Plan{
+		Policies:       []Policy{&SyncPolicy{}},
+		Desired:        []*endpoint.Endpoint{endpoint.NewEndpoint("_sip._tcp.example.com", endpoint.RecordTypeSRV, "10 5 5060 192.0.2.1")},
+		ManagedRecords: []string{endpoint.RecordTypeSRV},
+	}
+	calculated := p.Calculate()
+
+	assert.Empty(t, calculated.Violations)
+	assert.Len(t, calculated.Changes.Create, 1)
+}
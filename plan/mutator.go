@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// Mutator adjusts the desired records of a plan before the changes are calculated, e.g. to enforce
+// naming conventions, raise TTLs to a minimum or drop records pointing to forbidden targets.
+// Endpoints which are not returned are not desired, so existing records are deleted.
+type Mutator interface {
+	Mutate(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint
+}
+
+// MutatorFunc allows to use an ordinary function as Mutator.
+type MutatorFunc func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint
+
+// Mutate calls f(endpoints).
+func (f MutatorFunc) Mutate(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	return f(endpoints)
+}
+
+// Mutators is a registry of available mutators. They are enabled by name with --plan-mutator.
+var Mutators = map[string]Mutator{
+	"lowercase-names": MutatorFunc(lowercaseNames),
+}
+
+// RegisterMutator makes a mutator available under the given name. It panics if the name is
+// already taken, so it's meant to be called from init functions.
+func RegisterMutator(name string, m Mutator) {
+	if _, exists := Mutators[name]; exists {
+		panic(fmt.Sprintf("plan mutator %q is already registered", name))
+	}
+	Mutators[name] = m
+}
+
+// lowercaseNames converts all DNS names to lower case.
+func lowercaseNames(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	for _, ep := range endpoints {
+		ep.DNSName = strings.ToLower(ep.DNSName)
+	}
+	return endpoints
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// minTTL raises the TTL of all records to at least ttl.
+func minTTL(ttl endpoint.TTL) Mutator {
+	return MutatorFunc(func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+		for _, ep := range endpoints {
+			if ep.RecordTTL < ttl {
+				ep.RecordTTL = ttl
+			}
+		}
+		return endpoints
+	})
+}
+
+// blockTarget drops all records pointing to target.
+func blockTarget(target string) Mutator {
+	return MutatorFunc(func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+		allowed := []*endpoint.Endpoint{}
+		for _, ep := range endpoints {
+			if !ep.Targets.Same(endpoint.Targets{target}) {
+				allowed = append(allowed, ep)
+			}
+		}
+		return allowed
+	})
+}
+
+func TestPlanMutators(t *testing.T) {
+	current := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("update.example.com", endpoint.RecordTypeA, 600, "1.1.1.1"),
+		endpoint.NewEndpointWithTTL("blocked-update.example.com", endpoint.RecordTypeA, 600, "1.1.1.1"),
+		endpoint.NewEndpoint("delete.example.com", endpoint.RecordTypeA, "1.1.1.1"),
+	}
+	desired := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("Create.example.com", endpoint.RecordTypeA, 30, "2.2.2.2"),
+		endpoint.NewEndpointWithTTL("blocked-create.example.com", endpoint.RecordTypeA, 600, "6.6.6.6"),
+		endpoint.NewEndpointWithTTL("update.example.com", endpoint.RecordTypeA, 600, "3.3.3.3"),
+		endpoint.NewEndpointWithTTL("blocked-update.example.com", endpoint.RecordTypeA, 600, "6.6.6.6"),
+	}
+
+	p := &Plan{
+		Policies:       []Policy{&SyncPolicy{}},
+		Mutators:       []Mutator{Mutators["lowercase-names"], minTTL(300), blockTarget("6.6.6.6")},
+		Current:        current,
+		Desired:        desired,
+		ManagedRecords: []string{endpoint.RecordTypeA},
+	}
+	changes := p.Calculate().Changes
+
+	require.Len(t, changes.Create, 1)
+	assert.Equal(t, "create.example.com", changes.Create[0].DNSName)
+	assert.Equal(t, endpoint.TTL(300), changes.Create[0].RecordTTL)
+
+	require.Len(t, changes.UpdateNew, 1)
+	require.Len(t, changes.UpdateOld, 1)
+	assert.Equal(t, "update.example.com", changes.UpdateOld[0].DNSName)
+	assert.Equal(t, endpoint.Targets{"3.3.3.3"}, changes.UpdateNew[0].Targets)
+
+	// blocked records aren't desired anymore
+	assert.ElementsMatch(t, []string{"delete.example.com", "blocked-update.example.com"}, []string{changes.Delete[0].DNSName, changes.Delete[1].DNSName})
+}
+
+func TestMutatorSplittingUpdate(t *testing.T) {
+	split := MutatorFunc(func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+		result := []*endpoint.Endpoint{}
+		for _, ep := range endpoints {
+			result = append(result, ep, endpoint.NewEndpoint("copy."+ep.DNSName, ep.RecordType, ep.Targets...))
+		}
+		return result
+	})
+
+	changes := (&Plan{
+		Policies:       []Policy{&SyncPolicy{}},
+		Mutators:       []Mutator{split},
+		Current:        []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeA, "1.1.1.1")},
+		Desired:        []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeA, "2.2.2.2")},
+		ManagedRecords: []string{endpoint.RecordTypeA},
+	}).Calculate().Changes
+
+	require.Len(t, changes.UpdateNew, 1)
+	assert.Equal(t, "foo.example.com", changes.UpdateNew[0].DNSName)
+	require.Len(t, changes.Create, 1)
+	assert.Equal(t, "copy.foo.example.com", changes.Create[0].DNSName)
+}
+
+func TestMutatorsConverge(t *testing.T) {
+	current := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("ttl.example.com", endpoint.RecordTypeA, 300, "1.1.1.1"),
+		endpoint.NewEndpointWithTTL("Name.example.com", endpoint.RecordTypeA, 300, "1.1.1.1"),
+	}
+	desired := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("ttl.example.com", endpoint.RecordTypeA, 60, "1.1.1.1"),
+		endpoint.NewEndpointWithTTL("NAME.example.com", endpoint.RecordTypeA, 300, "2.2.2.2"),
+	}
+
+	changes := (&Plan{
+		Policies:       []Policy{&SyncPolicy{}},
+		Mutators:       []Mutator{Mutators["lowercase-names"], minTTL(300)},
+		Current:        current,
+		Desired:        desired,
+		ManagedRecords: []string{endpoint.RecordTypeA},
+	}).Calculate().Changes
+
+	// the raised TTL matches the current one, so only the changed targets are updated
+	assert.Empty(t, changes.Create)
+	assert.Empty(t, changes.Delete)
+	require.Len(t, changes.UpdateNew, 1)
+	require.Len(t, changes.UpdateOld, 1)
+	assert.Equal(t, changes.UpdateOld[0].Key(), changes.UpdateNew[0].Key())
+	assert.Equal(t, endpoint.Targets{"2.2.2.2"}, changes.UpdateNew[0].Targets)
+}
+
+func TestRegisterMutator(t *testing.T) {
+	noop := MutatorFunc(func(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint { return endpoints })
+	RegisterMutator("test-noop", noop)
+	defer delete(Mutators, "test-noop")
+
+	assert.Contains(t, Mutators, "test-noop")
+	assert.Panics(t, func() { RegisterMutator("test-noop", noop) })
+}
@@ -18,6 +18,7 @@ package plan
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/google/go-cmp/cmp"
@@ -38,6 +39,8 @@ type Plan struct {
 	Desired []*endpoint.Endpoint
 	// Policies under which the desired changes are calculated
 	Policies []Policy
+	// Mutators adjust the desired records before the changes are calculated
+	Mutators []Mutator
 	// List of changes necessary to move towards desired state
 	// Populated after calling Calculate()
 	Changes *Changes
@@ -49,6 +52,24 @@ type Plan struct {
 	ExcludeRecords []string
 	// OwnerID of records to manage
 	OwnerID string
+	// AdoptExisting takes ownership of records without an owner which exactly match a desired endpoint
+	AdoptExisting bool
+	// SharedOwnership joins the owners of records owned by other owners which exactly match a desired endpoint
+	SharedOwnership bool
+	// CheckInvariants skips creates and updates which would break a DNS invariant, e.g. a CNAME
+	// sharing its name with other records
+	CheckInvariants bool
+	// CustomLabels are the labels of the desired endpoints persisted in the registry, whose changes update the records
+	CustomLabels []string
+	// Violations are the changes skipped by the invariant checks
+	// Populated after calling Calculate()
+	Violations []Violation
+	// Deferred are the records whose creation waits for the records they depend on
+	// Populated after calling Calculate()
+	Deferred []*endpoint.Endpoint
+	// Conflicts are the current records owned by other owners which stand in the way of desired changes
+	// Populated after calling Calculate()
+	Conflicts []*endpoint.Endpoint
 }
 
 // Changes holds lists of actions to be executed by dns providers
@@ -63,6 +84,43 @@ type Changes struct {
 	Delete []*endpoint.Endpoint
 }
 
+// TargetUpdate describes an update in terms of the targets that have to be added to and
+// removed from the current record. Providers that support incremental RRset edits can use
+// it to apply minimal updates instead of replacing the whole record set.
+type TargetUpdate struct {
+	// Old is the current version of the record
+	Old *endpoint.Endpoint
+	// New is the desired version of the record
+	New *endpoint.Endpoint
+	// Added are the targets present in New but not in Old
+	Added endpoint.Targets
+	// Removed are the targets present in Old but not in New
+	Removed endpoint.Targets
+}
+
+// TargetsOnly returns true if the update only changes the set of targets, i.e. both versions
+// share the same TTL, so that adding and removing single targets is sufficient to apply it.
+// Updates without added or removed targets, e.g. resyncs or changes of the case of a target,
+// return false, as there would be nothing to send.
+func (u *TargetUpdate) TargetsOnly() bool {
+	if len(u.Added) == 0 && len(u.Removed) == 0 {
+		return false
+	}
+	return u.Old.RecordTTL == u.New.RecordTTL || !u.New.RecordTTL.IsConfigured()
+}
+
+// TypeMigration describes records which are replaced by records of another type with the same
+// name, e.g. a CNAME replaced by an A record when a load balancer switches from a hostname to
+// an IP address. As a CNAME can't coexist with other records of the same name, most providers
+// reject the creation while the old records exist. Providers which apply changes one at a time
+// must therefore delete the old records first, others should apply both in one transaction.
+type TypeMigration struct {
+	// Delete are the records of the old type
+	Delete []*endpoint.Endpoint
+	// Create are the records of the new type
+	Create []*endpoint.Endpoint
+}
+
 // planKey is a key for a row in `planTable`.
 type planKey struct {
 	dnsName       string
@@ -92,8 +150,9 @@ type planTable struct {
 	resolver ConflictResolver
 }
 
-func newPlanTable() planTable { // TODO: make resolver configurable
-	return planTable{map[planKey]*planTableRow{}, PerResource{}}
+// newPlanTable returns a planTable with room for the given number of rows.
+func newPlanTable(size int) planTable { // TODO: make resolver configurable
+	return planTable{make(map[planKey]*planTableRow, size), PerResource{}}
 }
 
 // planTableRow represents a set of current and desired domain resource records.
@@ -124,34 +183,145 @@ func (t planTableRow) String() string {
 }
 
 func (t planTable) addCurrent(e *endpoint.Endpoint) {
-	key := t.newPlanKey(e)
-	t.rows[key].current = append(t.rows[key].current, e)
-	t.rows[key].records[e.RecordType].current = e
+	row, records := t.lookup(e)
+	row.current = append(row.current, e)
+	records.current = e
 }
 
 func (t planTable) addCandidate(e *endpoint.Endpoint) {
-	key := t.newPlanKey(e)
-	t.rows[key].candidates = append(t.rows[key].candidates, e)
-	t.rows[key].records[e.RecordType].candidates = append(t.rows[key].records[e.RecordType].candidates, e)
+	row, records := t.lookup(e)
+	row.candidates = append(row.candidates, e)
+	records.candidates = append(records.candidates, e)
 }
 
-func (t *planTable) newPlanKey(e *endpoint.Endpoint) planKey {
+// lookup returns the row of the endpoint and the records of its type, creating both if needed.
+func (t planTable) lookup(e *endpoint.Endpoint) (*planTableRow, *domainEndpoints) {
 	key := planKey{
 		dnsName:       normalizeDNSName(e.DNSName),
 		setIdentifier: e.SetIdentifier,
 	}
 
-	if _, ok := t.rows[key]; !ok {
-		t.rows[key] = &planTableRow{
-			records: make(map[string]*domainEndpoints),
+	row, ok := t.rows[key]
+	if !ok {
+		row = &planTableRow{
+			records: make(map[string]*domainEndpoints, 1),
+		}
+		t.rows[key] = row
+	}
+
+	records, ok := row.records[e.RecordType]
+	if !ok {
+		records = &domainEndpoints{}
+		row.records[e.RecordType] = records
+	}
+
+	return row, records
+}
+
+// TargetUpdates pairs UpdateOld and UpdateNew and computes the targets added and removed by
+// each update. UpdateOld and UpdateNew are expected to be index aligned, as produced by
+// Calculate. It returns nil if both lists differ in length.
+func (c *Changes) TargetUpdates() []*TargetUpdate {
+	if len(c.UpdateOld) != len(c.UpdateNew) {
+		return nil
+	}
+
+	updates := make([]*TargetUpdate, 0, len(c.UpdateNew))
+	for i, desired := range c.UpdateNew {
+		current := c.UpdateOld[i]
+		updates = append(updates, &TargetUpdate{
+			Old:     current,
+			New:     desired,
+			Added:   missingTargets(current.RecordType, desired.Targets, current.Targets),
+			Removed: missingTargets(current.RecordType, current.Targets, desired.Targets),
+		})
+	}
+	return updates
+}
+
+// missingTargets returns the targets of t which are not part of o.
+func missingTargets(recordType string, t, o endpoint.Targets) endpoint.Targets {
+	others := make(map[string]bool, len(o))
+	for _, other := range o {
+		others[canonicalTarget(recordType, other)] = true
+	}
+
+	missing := endpoint.Targets{}
+	for _, target := range t {
+		if !others[canonicalTarget(recordType, target)] {
+			missing = append(missing, target)
+		}
+	}
+	return missing
+}
+
+// TypeMigrations returns the deletions and creations of each DNS name which replace a CNAME by
+// records of other types or vice versa, sorted by DNS name. The records are part of Delete and
+// Create as well.
+func (c *Changes) TypeMigrations() []*TypeMigration {
+	migrations := map[planKey]*TypeMigration{}
+	key := func(e *endpoint.Endpoint) planKey {
+		return planKey{dnsName: normalizeDNSName(e.DNSName), setIdentifier: e.SetIdentifier}
+	}
+	for _, e := range c.Delete {
+		k := key(e)
+		if migrations[k] == nil {
+			migrations[k] = &TypeMigration{}
+		}
+		migrations[k].Delete = append(migrations[k].Delete, e)
+	}
+	for _, e := range c.Create {
+		if m, ok := migrations[key(e)]; ok {
+			m.Create = append(m.Create, e)
 		}
 	}
 
-	if _, ok := t.rows[key].records[e.RecordType]; !ok {
-		t.rows[key].records[e.RecordType] = &domainEndpoints{}
+	keys := make([]planKey, 0, len(migrations))
+	for k, m := range migrations {
+		if m.changesCNAME() {
+			keys = append(keys, k)
+		}
 	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].dnsName != keys[j].dnsName {
+			return keys[i].dnsName < keys[j].dnsName
+		}
+		return keys[i].setIdentifier < keys[j].setIdentifier
+	})
 
-	return key
+	result := make([]*TypeMigration, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, migrations[k])
+	}
+	return result
+}
+
+// changesCNAME returns true if a CNAME is replaced by other record types or vice versa.
+func (m *TypeMigration) changesCNAME() bool {
+	recordTypes := func(endpoints []*endpoint.Endpoint) (cname, other bool) {
+		for _, e := range endpoints {
+			if e.RecordType == endpoint.RecordTypeCNAME {
+				cname = true
+			} else if e.RecordType != endpoint.RecordTypeTXT {
+				other = true
+			}
+		}
+		return cname, other
+	}
+	deleteCNAME, deleteOther := recordTypes(m.Delete)
+	createCNAME, createOther := recordTypes(m.Create)
+	return deleteCNAME && createOther || deleteOther && createCNAME
+}
+
+// Inverse returns the changes which revert c: created records are deleted, deleted records
+// are created again and updates are swapped.
+func (c *Changes) Inverse() *Changes {
+	return &Changes{
+		Create:    c.Delete,
+		UpdateOld: c.UpdateNew,
+		UpdateNew: c.UpdateOld,
+		Delete:    c.Create,
+	}
 }
 
 func (c *Changes) HasChanges() bool {
@@ -165,7 +335,7 @@ func (c *Changes) HasChanges() bool {
 // state. It then passes those changes to the current policy for further
 // processing. It returns a copy of Plan with the changes populated.
 func (p *Plan) Calculate() *Plan {
-	t := newPlanTable()
+	t := newPlanTable(max(len(p.Current), len(p.Desired)))
 
 	if p.DomainFilter == nil {
 		p.DomainFilter = endpoint.MatchAllDomainFilters(nil)
@@ -174,11 +344,20 @@ func (p *Plan) Calculate() *Plan {
 	for _, current := range filterRecordsForPlan(p.Current, p.DomainFilter, p.ManagedRecords, p.ExcludeRecords) {
 		t.addCurrent(current)
 	}
-	for _, desired := range filterRecordsForPlan(p.Desired, p.DomainFilter, p.ManagedRecords, p.ExcludeRecords) {
+	// the mutators adjust the desired records before the diff, so the records converge to the mutated ones
+	desired := filterRecordsForPlan(p.Desired, p.DomainFilter, p.ManagedRecords, p.ExcludeRecords)
+	for _, m := range p.Mutators {
+		desired = m.Mutate(desired)
+	}
+	for _, desired := range desired {
 		t.addCandidate(desired)
 	}
 
 	changes := &Changes{}
+	// adopted holds the records without an owner this external dns takes ownership of
+	adopted := &Changes{}
+	// conflicts holds the records of other owners which block creates and updates
+	var conflicts []*endpoint.Endpoint
 
 	for key, row := range t.rows {
 		// dns name not taken
@@ -221,10 +400,23 @@ func (p *Plan) Calculate() *Plan {
 				if records.current != nil && len(records.candidates) > 0 {
 					update := t.resolver.ResolveUpdate(records.current, records.candidates)
 
-					if shouldUpdateTTL(update, records.current) || targetChanged(update, records.current) || p.shouldUpdateProviderSpecific(update, records.current) {
+					if shouldUpdateTTL(update, records.current) || targetChanged(update, records.current) || p.shouldUpdateProviderSpecific(update, records.current) || shouldResync(update, records.current) || descriptionChanged(update, records.current) || shouldHandoff(update, records.current) || p.customLabelsChanged(update, records.current) {
 						inheritOwner(records.current, update)
+						// the update replaces the current record, so it keeps its name, which may differ in case
+						update.DNSName = records.current.DNSName
 						changes.UpdateNew = append(changes.UpdateNew, update)
 						changes.UpdateOld = append(changes.UpdateOld, records.current)
+					} else if p.shouldAdopt(records.current) {
+						if update.Labels == nil {
+							update.Labels = map[string]string{}
+						}
+						update.Labels[endpoint.OwnerLabelKey] = p.OwnerID
+						adopted.UpdateNew = append(adopted.UpdateNew, update)
+						adopted.UpdateOld = append(adopted.UpdateOld, records.current)
+					} else if p.shouldJoin(records.current) {
+						joinOwners(records.current, update, p.OwnerID)
+						adopted.UpdateNew = append(adopted.UpdateNew, update)
+						adopted.UpdateOld = append(adopted.UpdateOld, records.current)
 					}
 				}
 			}
@@ -240,9 +432,12 @@ func (p *Plan) Calculate() *Plan {
 
 				if ownersMatch {
 					changes.Create = append(changes.Create, creates...)
-				} else if log.GetLevel() == log.DebugLevel {
+				} else {
+					conflicts = append(conflicts, ownedByOthers(p.OwnerID, row.current)...)
+				}
+				if !ownersMatch && log.GetLevel() == log.DebugLevel {
 					for _, current := range row.current {
-						log.Debugf(`Skipping endpoint %v because owner id does not match for one or more items to create, found: "%s", required: "%s"`, current, current.Labels[endpoint.OwnerLabelKey], p.OwnerID)
+						log.Debugf(`Skipping endpoint %v because owner id does not match for one or more items to create, found: "%s" (%s), required: "%s"`, current, current.Labels[endpoint.OwnerLabelKey], resourceOrUnknown(current.Labels[endpoint.ResourceLabelKey]), p.OwnerID)
 					}
 				}
 			}
@@ -251,26 +446,61 @@ func (p *Plan) Calculate() *Plan {
 
 	for _, pol := range p.Policies {
 		changes = pol.Apply(changes)
+		adopted = pol.Apply(adopted)
 	}
 
 	// filter out updates this external dns does not have ownership claim over
 	if p.OwnerID != "" {
+		conflicts = append(conflicts, ownedByOthers(p.OwnerID, changes.UpdateOld)...)
 		changes.Delete = endpoint.FilterEndpointsByOwnerID(p.OwnerID, changes.Delete)
 		changes.Delete = endpoint.RemoveDuplicates(changes.Delete)
 		changes.UpdateOld = endpoint.FilterEndpointsByOwnerID(p.OwnerID, changes.UpdateOld)
 		changes.UpdateNew = endpoint.FilterEndpointsByOwnerID(p.OwnerID, changes.UpdateNew)
 	}
 
+	// adoptions and joins update records without an owner or owned by other owners, so they are added after the owner filter
+	changes.UpdateOld = append(changes.UpdateOld, adopted.UpdateOld...)
+	changes.UpdateNew = append(changes.UpdateNew, adopted.UpdateNew...)
+
+	changes, deferred := deferDependentCreates(p.Current, changes)
+
+	var violations []Violation
+	if p.CheckInvariants {
+		// all records matter for the invariants, not only the managed ones
+		current := []*endpoint.Endpoint{}
+		for _, record := range p.Current {
+			if p.DomainFilter.Match(record.DNSName) {
+				current = append(current, record)
+			}
+		}
+		changes, violations = checkInvariants(current, changes)
+	}
+
 	plan := &Plan{
 		Current:        p.Current,
 		Desired:        p.Desired,
 		Changes:        changes,
 		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME},
+		Violations:     violations,
+		Deferred:       deferred,
+		Conflicts:      conflicts,
 	}
 
 	return plan
 }
 
+// ownedByOthers returns the records owned by owners other than the given one. Records without an owner
+// aren't in conflict, they are merely not managed.
+func ownedByOthers(ownerID string, records []*endpoint.Endpoint) []*endpoint.Endpoint {
+	var owned []*endpoint.Endpoint
+	for _, r := range records {
+		if r.Labels[endpoint.OwnerLabelKey] != "" && !r.IsOwnedBy(ownerID) {
+			owned = append(owned, r)
+		}
+	}
+	return owned
+}
+
 func inheritOwner(from, to *endpoint.Endpoint) {
 	if to.Labels == nil {
 		to.Labels = map[string]string{}
@@ -279,10 +509,24 @@ func inheritOwner(from, to *endpoint.Endpoint) {
 		from.Labels = map[string]string{}
 	}
 	to.Labels[endpoint.OwnerLabelKey] = from.Labels[endpoint.OwnerLabelKey]
+	if owners, ok := from.Labels[endpoint.OwnersLabelKey]; ok {
+		to.Labels[endpoint.OwnersLabelKey] = owners
+	}
+}
+
+// joinOwners adds the owner ID to the owners sharing the ownership of the current record.
+func joinOwners(current, desired *endpoint.Endpoint, ownerID string) {
+	if desired.Labels == nil {
+		desired.Labels = map[string]string{}
+	}
+	owners := append(current.Labels.Owners(), current.Labels[endpoint.OwnerLabelKey], ownerID)
+	desired.Labels[endpoint.OwnerLabelKey] = ownerID
+	desired.Labels.SetOwners(owners)
+	log.Infof("Sharing the ownership of %s %s owned by %q", desired.RecordType, desired.DNSName, current.Labels[endpoint.OwnerLabelKey])
 }
 
 func targetChanged(desired, current *endpoint.Endpoint) bool {
-	return !desired.Targets.Same(current.Targets)
+	return !sameTargets(current.RecordType, desired.Targets, current.Targets)
 }
 
 func shouldUpdateTTL(desired, current *endpoint.Endpoint) bool {
@@ -292,6 +536,70 @@ func shouldUpdateTTL(desired, current *endpoint.Endpoint) bool {
 	return desired.RecordTTL != current.RecordTTL
 }
 
+// shouldResync reports whether the resync token of the desired endpoint differs from the one stored in the
+// registry. Records the registry doesn't know an owner of are never resynced, as their labels are not
+// persisted and the token would differ on every run.
+func shouldResync(desired, current *endpoint.Endpoint) bool {
+	token := desired.Labels[endpoint.ResyncLabelKey]
+	if token == "" || current.Labels[endpoint.OwnerLabelKey] == "" {
+		return false
+	}
+	return token != current.Labels[endpoint.ResyncLabelKey]
+}
+
+// descriptionChanged reports whether the description of the desired endpoint differs from the one stored in the
+// registry or published as the comment of the record. Like the resync token, the description of records without an
+// owner is not persisted. A removed description doesn't update the record, so comments set by hand are kept.
+func descriptionChanged(desired, current *endpoint.Endpoint) bool {
+	description := desired.Labels[endpoint.DescriptionLabelKey]
+	if description == "" || current.Labels[endpoint.OwnerLabelKey] == "" {
+		return false
+	}
+	return description != current.Labels[endpoint.DescriptionLabelKey]
+}
+
+// customLabelsChanged reports whether a custom label of the desired endpoint differs from the one stored in the
+// registry. Like the resync token, the labels of records without an owner are not persisted.
+func (p *Plan) customLabelsChanged(desired, current *endpoint.Endpoint) bool {
+	if current.Labels[endpoint.OwnerLabelKey] == "" {
+		return false
+	}
+	for _, key := range p.CustomLabels {
+		if desired.Labels[key] != current.Labels[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldHandoff reports whether the desired endpoint takes the record over from the resource stored in the
+// registry. The update only rewrites the resource label in the registry, the record itself is left as it is
+// unless something else changed too.
+func shouldHandoff(desired, current *endpoint.Endpoint) bool {
+	from := desired.Labels[endpoint.HandoffLabelKey]
+	if from == "" || current.Labels[endpoint.OwnerLabelKey] == "" {
+		return false
+	}
+	if from != current.Labels[endpoint.ResourceLabelKey] || from == desired.Labels[endpoint.ResourceLabelKey] {
+		return false
+	}
+	log.Infof("Handing %s %s over from %s to %s", desired.RecordType, desired.DNSName, from, desired.Labels[endpoint.ResourceLabelKey])
+	return true
+}
+
+// shouldAdopt reports whether a record without an owner is adopted. The registry recognizes an adoption by
+// the update of a record without an owner to one owned by this external dns.
+func (p *Plan) shouldAdopt(current *endpoint.Endpoint) bool {
+	return p.AdoptExisting && p.OwnerID != "" && current.Labels[endpoint.OwnerLabelKey] == ""
+}
+
+// shouldJoin reports whether this external dns joins the owners of a record owned by another owner. The
+// registry recognizes a join by the update of a record owned by another owner to one owned by this external dns.
+func (p *Plan) shouldJoin(current *endpoint.Endpoint) bool {
+	owner := current.Labels[endpoint.OwnerLabelKey]
+	return p.SharedOwnership && p.OwnerID != "" && owner != "" && owner != p.OwnerID
+}
+
 func (p *Plan) shouldUpdateProviderSpecific(desired, current *endpoint.Endpoint) bool {
 	desiredProperties := map[string]endpoint.ProviderSpecificProperty{}
 
@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"fmt"
+	"testing"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// generatePlanRecords returns n current records owned by "owner" and the desired records, of which
+// every hundredth has a different target, is missing or is new.
+func generatePlanRecords(n int) (current, desired []*endpoint.Endpoint) {
+	current = make([]*endpoint.Endpoint, 0, n)
+	desired = make([]*endpoint.Endpoint, 0, n)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("record-%d.example.org", i)
+		target := fmt.Sprintf("10.%d.%d.%d", i>>16&0xff, i>>8&0xff, i&0xff)
+
+		c := endpoint.NewEndpoint(name, endpoint.RecordTypeA, target)
+		c.Labels[endpoint.OwnerLabelKey] = "owner"
+		c.Labels[endpoint.ResourceLabelKey] = "service/default/" + name
+		current = append(current, c)
+
+		switch i % 100 {
+		case 0:
+			target = fmt.Sprintf("192.168.%d.%d", i>>8&0xff, i&0xff)
+		case 1:
+			continue
+		case 2:
+			name = fmt.Sprintf("new-record-%d.example.org", i)
+		}
+		d := endpoint.NewEndpoint(name, endpoint.RecordTypeA, target)
+		d.Labels[endpoint.ResourceLabelKey] = "service/default/" + name
+		desired = append(desired, d)
+	}
+	return current, desired
+}
+
+func benchmarkCalculate(b *testing.B, n int) {
+	current, desired := generatePlanRecords(n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := &Plan{
+			Policies:       []Policy{&SyncPolicy{}},
+			Current:        current,
+			Desired:        desired,
+			ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+			OwnerID:        "owner",
+		}
+		p.Calculate()
+	}
+}
+
+func BenchmarkCalculate1k(b *testing.B)   { benchmarkCalculate(b, 1000) }
+func BenchmarkCalculate10k(b *testing.B)  { benchmarkCalculate(b, 10000) }
+func BenchmarkCalculate100k(b *testing.B) { benchmarkCalculate(b, 100000) }
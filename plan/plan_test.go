@@ -355,6 +355,375 @@ func (suite *PlanTestSuite) TestSyncSecondRoundWithProviderSpecificChange() {
 	validateEntries(suite.T(), changes.Delete, expectedDelete)
 }
 
+func (suite *PlanTestSuite) TestSyncSecondRoundWithResyncToken() {
+	newRecord := func(owner, token string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint("foo", endpoint.RecordTypeA, "1.2.3.4")
+		if owner != "" {
+			ep.Labels[endpoint.OwnerLabelKey] = owner
+		}
+		if token != "" {
+			ep.Labels[endpoint.ResyncLabelKey] = token
+		}
+		return ep
+	}
+
+	for _, tc := range []struct {
+		title   string
+		current *endpoint.Endpoint
+		desired *endpoint.Endpoint
+		update  bool
+	}{
+		{"new token", newRecord("owner", ""), newRecord("", "1"), true},
+		{"changed token", newRecord("owner", "1"), newRecord("", "2"), true},
+		{"unchanged token", newRecord("owner", "1"), newRecord("", "1"), false},
+		{"removed token", newRecord("owner", "1"), newRecord("", ""), false},
+		{"no owner in the registry", newRecord("", ""), newRecord("", "1"), false},
+	} {
+		suite.Run(tc.title, func() {
+			p := &Plan{
+				Policies:       []Policy{&SyncPolicy{}},
+				Current:        []*endpoint.Endpoint{tc.current},
+				Desired:        []*endpoint.Endpoint{tc.desired},
+				ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+			}
+
+			changes := p.Calculate().Changes
+			if tc.update {
+				suite.Len(changes.UpdateNew, 1)
+				suite.Equal(tc.desired.Labels[endpoint.ResyncLabelKey], changes.UpdateNew[0].Labels[endpoint.ResyncLabelKey])
+				suite.Equal([]*endpoint.Endpoint{tc.current}, changes.UpdateOld)
+			} else {
+				suite.Empty(changes.UpdateNew)
+				suite.Empty(changes.UpdateOld)
+			}
+			suite.Empty(changes.Create)
+			suite.Empty(changes.Delete)
+		})
+	}
+}
+
+func (suite *PlanTestSuite) TestSyncSecondRoundWithDescription() {
+	newRecord := func(owner, description string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint("foo", endpoint.RecordTypeA, "1.2.3.4")
+		if owner != "" {
+			ep.Labels[endpoint.OwnerLabelKey] = owner
+		}
+		if description != "" {
+			ep.Labels[endpoint.DescriptionLabelKey] = description
+		}
+		return ep
+	}
+
+	for _, tc := range []struct {
+		title   string
+		current *endpoint.Endpoint
+		desired *endpoint.Endpoint
+		update  bool
+	}{
+		{"new description", newRecord("owner", ""), newRecord("", "team a"), true},
+		{"changed description", newRecord("owner", "team a"), newRecord("", "team b"), true},
+		{"unchanged description", newRecord("owner", "team a"), newRecord("", "team a"), false},
+		{"removed description", newRecord("owner", "set by hand"), newRecord("", ""), false},
+		{"no owner in the registry", newRecord("", ""), newRecord("", "team a"), false},
+	} {
+		suite.Run(tc.title, func() {
+			p := &Plan{
+				Policies:       []Policy{&SyncPolicy{}},
+				Current:        []*endpoint.Endpoint{tc.current},
+				Desired:        []*endpoint.Endpoint{tc.desired},
+				ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+			}
+
+			changes := p.Calculate().Changes
+			if tc.update {
+				suite.Len(changes.UpdateNew, 1)
+				suite.Equal(tc.desired.Labels[endpoint.DescriptionLabelKey], changes.UpdateNew[0].Labels[endpoint.DescriptionLabelKey])
+			} else {
+				suite.Empty(changes.UpdateNew)
+			}
+			suite.Empty(changes.Create)
+			suite.Empty(changes.Delete)
+		})
+	}
+}
+
+func (suite *PlanTestSuite) TestSyncSecondRoundWithCustomLabels() {
+	newRecord := func(owner, team string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint("foo", endpoint.RecordTypeA, "1.2.3.4")
+		if owner != "" {
+			ep.Labels[endpoint.OwnerLabelKey] = owner
+		}
+		if team != "" {
+			ep.Labels["team"] = team
+		}
+		ep.Labels["environment"] = owner
+		return ep
+	}
+
+	for _, tc := range []struct {
+		title   string
+		current *endpoint.Endpoint
+		desired *endpoint.Endpoint
+		update  bool
+	}{
+		{"new label", newRecord("owner", ""), newRecord("", "payments"), true},
+		{"changed label", newRecord("owner", "payments"), newRecord("", "checkout"), true},
+		{"unchanged label", newRecord("owner", "payments"), newRecord("", "payments"), false},
+		{"removed label", newRecord("owner", "payments"), newRecord("", ""), true},
+		{"no owner in the registry", newRecord("", ""), newRecord("", "payments"), false},
+	} {
+		suite.Run(tc.title, func() {
+			p := &Plan{
+				Policies:       []Policy{&SyncPolicy{}},
+				Current:        []*endpoint.Endpoint{tc.current},
+				Desired:        []*endpoint.Endpoint{tc.desired},
+				ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+				CustomLabels:   []string{"team"},
+			}
+
+			changes := p.Calculate().Changes
+			if tc.update {
+				suite.Len(changes.UpdateNew, 1)
+				suite.Equal(tc.desired.Labels["team"], changes.UpdateNew[0].Labels["team"])
+				suite.Equal([]*endpoint.Endpoint{tc.current}, changes.UpdateOld)
+			} else {
+				suite.Empty(changes.UpdateNew)
+				suite.Empty(changes.UpdateOld)
+			}
+			suite.Empty(changes.Create)
+			suite.Empty(changes.Delete)
+		})
+	}
+}
+
+func (suite *PlanTestSuite) TestSyncSecondRoundWithHandoff() {
+	newRecord := func(owner, resource, from string, targets ...string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint("foo", endpoint.RecordTypeA, targets...)
+		if owner != "" {
+			ep.Labels[endpoint.OwnerLabelKey] = owner
+		}
+		ep.Labels[endpoint.ResourceLabelKey] = resource
+		if from != "" {
+			ep.Labels[endpoint.HandoffLabelKey] = from
+		}
+		return ep
+	}
+
+	for _, tc := range []struct {
+		title   string
+		current *endpoint.Endpoint
+		desired []*endpoint.Endpoint
+		update  string
+	}{
+		{
+			"handoff",
+			newRecord("owner", "ingress/default/foo", "", "1.2.3.4"),
+			[]*endpoint.Endpoint{
+				newRecord("", "ingress/default/foo", "", "1.2.3.4"),
+				newRecord("", "crd/default/foo", "ingress/default/foo", "1.2.3.4"),
+			},
+			"crd/default/foo",
+		},
+		{
+			"handoff with changed targets",
+			newRecord("owner", "ingress/default/foo", "", "1.2.3.4"),
+			[]*endpoint.Endpoint{newRecord("", "crd/default/foo", "ingress/default/foo", "5.6.7.8")},
+			"crd/default/foo",
+		},
+		{
+			"completed handoff",
+			newRecord("owner", "crd/default/foo", "ingress/default/foo", "1.2.3.4"),
+			[]*endpoint.Endpoint{
+				newRecord("", "ingress/default/foo", "", "1.2.3.4"),
+				newRecord("", "crd/default/foo", "ingress/default/foo", "1.2.3.4"),
+			},
+			"",
+		},
+		{
+			"handoff from other resource",
+			newRecord("owner", "ingress/default/foo", "", "1.2.3.4"),
+			[]*endpoint.Endpoint{
+				newRecord("", "ingress/default/foo", "", "1.2.3.4"),
+				newRecord("", "crd/default/foo", "ingress/default/bar", "1.2.3.4"),
+			},
+			"",
+		},
+		{
+			"no owner in the registry",
+			newRecord("", "ingress/default/foo", "", "1.2.3.4"),
+			[]*endpoint.Endpoint{newRecord("", "crd/default/foo", "ingress/default/foo", "1.2.3.4")},
+			"",
+		},
+	} {
+		suite.Run(tc.title, func() {
+			p := &Plan{
+				Policies:       []Policy{&SyncPolicy{}},
+				Current:        []*endpoint.Endpoint{tc.current},
+				Desired:        tc.desired,
+				ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+			}
+
+			changes := p.Calculate().Changes
+			if tc.update != "" {
+				suite.Len(changes.UpdateNew, 1)
+				suite.Equal(tc.update, changes.UpdateNew[0].Labels[endpoint.ResourceLabelKey])
+				suite.Equal(tc.current.Labels[endpoint.OwnerLabelKey], changes.UpdateNew[0].Labels[endpoint.OwnerLabelKey])
+				suite.Equal([]*endpoint.Endpoint{tc.current}, changes.UpdateOld)
+			} else {
+				suite.Empty(changes.UpdateNew)
+				suite.Empty(changes.UpdateOld)
+			}
+			suite.Empty(changes.Create)
+			suite.Empty(changes.Delete)
+		})
+	}
+}
+
+func (suite *PlanTestSuite) TestAdoptExistingRecords() {
+	newRecord := func(owner string, targets ...string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint("foo", endpoint.RecordTypeA, targets...)
+		if owner != "" {
+			ep.Labels[endpoint.OwnerLabelKey] = owner
+		}
+		return ep
+	}
+
+	for _, tc := range []struct {
+		title    string
+		policy   Policy
+		current  *endpoint.Endpoint
+		desired  *endpoint.Endpoint
+		adopted  bool
+		modified bool
+	}{
+		{"exact match without owner", &SyncPolicy{}, newRecord("", "1.2.3.4"), newRecord("", "1.2.3.4"), true, false},
+		{"different targets without owner", &SyncPolicy{}, newRecord("", "1.2.3.4"), newRecord("", "8.8.8.8"), false, false},
+		{"owned by another owner", &SyncPolicy{}, newRecord("other", "1.2.3.4"), newRecord("", "1.2.3.4"), false, false},
+		{"owned record", &SyncPolicy{}, newRecord("owner", "1.2.3.4"), newRecord("", "8.8.8.8"), false, true},
+		{"create-only policy", &CreateOnlyPolicy{}, newRecord("", "1.2.3.4"), newRecord("", "1.2.3.4"), false, false},
+	} {
+		suite.Run(tc.title, func() {
+			p := &Plan{
+				Policies:       []Policy{tc.policy},
+				Current:        []*endpoint.Endpoint{tc.current},
+				Desired:        []*endpoint.Endpoint{tc.desired},
+				ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+				OwnerID:        "owner",
+				AdoptExisting:  true,
+			}
+
+			changes := p.Calculate().Changes
+			if tc.adopted || tc.modified {
+				suite.Require().Len(changes.UpdateNew, 1)
+				suite.Equal("owner", changes.UpdateNew[0].Labels[endpoint.OwnerLabelKey])
+				suite.Equal([]*endpoint.Endpoint{tc.current}, changes.UpdateOld)
+			} else {
+				suite.Empty(changes.UpdateNew)
+				suite.Empty(changes.UpdateOld)
+			}
+			suite.Empty(changes.Create)
+			suite.Empty(changes.Delete)
+		})
+	}
+}
+
+func (suite *PlanTestSuite) TestAdoptExistingRecordsDisabled() {
+	current := endpoint.NewEndpoint("foo", endpoint.RecordTypeA, "1.2.3.4")
+	desired := endpoint.NewEndpoint("foo", endpoint.RecordTypeA, "1.2.3.4")
+
+	p := &Plan{
+		Policies:       []Policy{&SyncPolicy{}},
+		Current:        []*endpoint.Endpoint{current},
+		Desired:        []*endpoint.Endpoint{desired},
+		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+		OwnerID:        "owner",
+	}
+
+	suite.False(p.Calculate().Changes.HasChanges())
+}
+
+func (suite *PlanTestSuite) TestConflicts() {
+	newRecord := func(name, recordType, owner, target string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint(name, recordType, target)
+		if owner != "" {
+			ep.Labels[endpoint.OwnerLabelKey] = owner
+		}
+		return ep
+	}
+	otherUpdate := newRecord("update", endpoint.RecordTypeA, "other", "1.2.3.4")
+	otherCreate := newRecord("create", endpoint.RecordTypeA, "other", "1.2.3.4")
+
+	p := &Plan{
+		Policies: []Policy{&SyncPolicy{}},
+		Current: []*endpoint.Endpoint{
+			otherUpdate,
+			otherCreate,
+			newRecord("unowned", endpoint.RecordTypeA, "", "1.2.3.4"),
+			newRecord("owned", endpoint.RecordTypeA, "owner", "1.2.3.4"),
+		},
+		Desired: []*endpoint.Endpoint{
+			newRecord("update", endpoint.RecordTypeA, "", "8.8.8.8"),
+			newRecord("create", endpoint.RecordTypeAAAA, "", "2001:db8::1"),
+			newRecord("unowned", endpoint.RecordTypeA, "", "8.8.8.8"),
+			newRecord("owned", endpoint.RecordTypeA, "", "8.8.8.8"),
+		},
+		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA},
+		OwnerID:        "owner",
+	}
+
+	plan := p.Calculate()
+	suite.ElementsMatch([]*endpoint.Endpoint{otherUpdate, otherCreate}, plan.Conflicts)
+	suite.Len(plan.Changes.UpdateNew, 1)
+}
+
+func (suite *PlanTestSuite) TestSharedOwnership() {
+	newRecord := func(owner string, targets ...string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint("foo", endpoint.RecordTypeA, targets...)
+		if owner != "" {
+			ep.Labels[endpoint.OwnerLabelKey] = owner
+		}
+		return ep
+	}
+	shared := newRecord("cluster-a", "1.2.3.4")
+	shared.Labels[endpoint.OwnersLabelKey] = "cluster-a;cluster-c"
+
+	for _, tc := range []struct {
+		title   string
+		current *endpoint.Endpoint
+		desired *endpoint.Endpoint
+		owners  string
+	}{
+		{"exact match owned by another owner", newRecord("cluster-a", "1.2.3.4"), newRecord("", "1.2.3.4"), "cluster-a;cluster-b"},
+		{"exact match with shared ownership", shared, newRecord("", "1.2.3.4"), "cluster-a;cluster-b;cluster-c"},
+		{"different targets owned by another owner", newRecord("cluster-a", "1.2.3.4"), newRecord("", "8.8.8.8"), ""},
+		{"without owner", newRecord("", "1.2.3.4"), newRecord("", "1.2.3.4"), ""},
+	} {
+		suite.Run(tc.title, func() {
+			p := &Plan{
+				Policies:        []Policy{&SyncPolicy{}},
+				Current:         []*endpoint.Endpoint{tc.current},
+				Desired:         []*endpoint.Endpoint{tc.desired},
+				ManagedRecords:  []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+				OwnerID:         "cluster-b",
+				SharedOwnership: true,
+			}
+
+			changes := p.Calculate().Changes
+			if tc.owners != "" {
+				suite.Require().Len(changes.UpdateNew, 1)
+				suite.Equal("cluster-b", changes.UpdateNew[0].Labels[endpoint.OwnerLabelKey])
+				suite.Equal(tc.owners, changes.UpdateNew[0].Labels[endpoint.OwnersLabelKey])
+				suite.Equal([]*endpoint.Endpoint{tc.current}, changes.UpdateOld)
+			} else {
+				suite.Empty(changes.UpdateNew)
+				suite.Empty(changes.UpdateOld)
+			}
+			suite.Empty(changes.Create)
+			suite.Empty(changes.Delete)
+		})
+	}
+}
+
 func (suite *PlanTestSuite) TestSyncSecondRoundWithProviderSpecificNoChange() {
 	current := []*endpoint.Endpoint{suite.bar127AWithProviderSpecificTrue}
 	desired := []*endpoint.Endpoint{suite.bar127AWithProviderSpecificTrue}
@@ -1102,3 +1471,118 @@ func TestShouldUpdateProviderSpecific(tt *testing.T) {
 		})
 	}
 }
+
+func TestTargetUpdates(t *testing.T) {
+	current := &endpoint.Endpoint{
+		DNSName:    "headless.example.com",
+		RecordType: endpoint.RecordTypeA,
+		Targets:    endpoint.Targets{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+		RecordTTL:  300,
+	}
+	desired := &endpoint.Endpoint{
+		DNSName:    "headless.example.com",
+		RecordType: endpoint.RecordTypeA,
+		Targets:    endpoint.Targets{"10.0.0.1", "10.0.0.3", "10.0.0.4"},
+		RecordTTL:  300,
+	}
+	changes := &Changes{
+		UpdateOld: []*endpoint.Endpoint{current},
+		UpdateNew: []*endpoint.Endpoint{desired},
+	}
+
+	updates := changes.TargetUpdates()
+	assert.Len(t, updates, 1)
+	assert.Same(t, current, updates[0].Old)
+	assert.Same(t, desired, updates[0].New)
+	assert.Equal(t, endpoint.Targets{"10.0.0.4"}, updates[0].Added)
+	assert.Equal(t, endpoint.Targets{"10.0.0.2"}, updates[0].Removed)
+	assert.True(t, updates[0].TargetsOnly())
+
+	desired.RecordTTL = 600
+	assert.False(t, changes.TargetUpdates()[0].TargetsOnly())
+
+	// an update without a target delta, e.g. a resync, has to replace the record
+	desired.RecordTTL = 300
+	desired.Targets = current.Targets
+	assert.False(t, changes.TargetUpdates()[0].TargetsOnly())
+}
+
+func TestTypeMigrations(t *testing.T) {
+	cname := endpoint.NewEndpoint("svc.example.com", endpoint.RecordTypeCNAME, "lb.example.com")
+	a := endpoint.NewEndpoint("svc.example.com", endpoint.RecordTypeA, "10.0.0.1")
+	aaaa := endpoint.NewEndpoint("svc.example.com", endpoint.RecordTypeAAAA, "2001:db8::1")
+	otherA := endpoint.NewEndpoint("other.example.com", endpoint.RecordTypeA, "10.0.0.2")
+	otherAAAA := endpoint.NewEndpoint("other.example.com", endpoint.RecordTypeAAAA, "2001:db8::2")
+	backCNAME := endpoint.NewEndpoint("back.example.com", endpoint.RecordTypeCNAME, "lb.example.com")
+	backA := endpoint.NewEndpoint("back.example.com", endpoint.RecordTypeA, "10.0.0.3")
+
+	changes := &Changes{
+		Create: []*endpoint.Endpoint{a, aaaa, otherAAAA, backCNAME},
+		Delete: []*endpoint.Endpoint{cname, otherA, backA},
+	}
+
+	// other.example.com only changes between A and AAAA, which can coexist
+	assert.Equal(t, []*TypeMigration{
+		{Delete: []*endpoint.Endpoint{backA}, Create: []*endpoint.Endpoint{backCNAME}},
+		{Delete: []*endpoint.Endpoint{cname}, Create: []*endpoint.Endpoint{a, aaaa}},
+	}, changes.TypeMigrations())
+}
+
+func (suite *PlanTestSuite) TestCNAMEToARecordIsTypeMigration() {
+	current := endpoint.NewEndpoint("svc.example.com", endpoint.RecordTypeCNAME, "lb.example.com")
+	current.Labels[endpoint.OwnerLabelKey] = "owner"
+	desired := endpoint.NewEndpoint("svc.example.com", endpoint.RecordTypeA, "10.0.0.1")
+
+	p := &Plan{
+		Policies:       []Policy{&SyncPolicy{}},
+		Current:        []*endpoint.Endpoint{current},
+		Desired:        []*endpoint.Endpoint{desired},
+		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+		OwnerID:        "owner",
+	}
+
+	migrations := p.Calculate().Changes.TypeMigrations()
+	suite.Require().Len(migrations, 1)
+	suite.Equal([]*endpoint.Endpoint{current}, migrations[0].Delete)
+	suite.Equal([]*endpoint.Endpoint{desired}, migrations[0].Create)
+}
+
+func TestTargetUpdatesIPv6Normalization(t *testing.T) {
+	changes := &Changes{
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeAAAA, "2001:db8:0:0:0:0:0:1")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeAAAA, "2001:db8::1", "2001:db8::2")},
+	}
+
+	updates := changes.TargetUpdates()
+	assert.Len(t, updates, 1)
+	assert.Equal(t, endpoint.Targets{"2001:db8::2"}, updates[0].Added)
+	assert.Empty(t, updates[0].Removed)
+}
+
+func TestTargetUpdatesMismatch(t *testing.T) {
+	changes := &Changes{
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeA, "1.2.3.4")},
+	}
+	assert.Nil(t, changes.TargetUpdates())
+}
+
+func TestChangesInverse(t *testing.T) {
+	created := endpoint.NewEndpoint("new.example.com", endpoint.RecordTypeA, "1.2.3.4")
+	deleted := endpoint.NewEndpoint("old.example.com", endpoint.RecordTypeA, "5.6.7.8")
+	current := endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeA, "1.1.1.1")
+	desired := endpoint.NewEndpoint("foo.example.com", endpoint.RecordTypeA, "2.2.2.2")
+
+	changes := &Changes{
+		Create:    []*endpoint.Endpoint{created},
+		UpdateOld: []*endpoint.Endpoint{current},
+		UpdateNew: []*endpoint.Endpoint{desired},
+		Delete:    []*endpoint.Endpoint{deleted},
+	}
+
+	inverse := changes.Inverse()
+	assert.Equal(t, []*endpoint.Endpoint{deleted}, inverse.Create)
+	assert.Equal(t, []*endpoint.Endpoint{desired}, inverse.UpdateOld)
+	assert.Equal(t, []*endpoint.Endpoint{current}, inverse.UpdateNew)
+	assert.Equal(t, []*endpoint.Endpoint{created}, inverse.Delete)
+	assert.Equal(t, changes, inverse.Inverse())
+}
@@ -16,6 +16,11 @@ limitations under the License.
 
 package plan
 
+import (
+	"sort"
+	"strings"
+)
+
 // Policy allows to apply different rules to a set of changes.
 type Policy interface {
 	Apply(changes *Changes) *Changes
@@ -26,6 +31,7 @@ var Policies = map[string]Policy{
 	"sync":        &SyncPolicy{},
 	"upsert-only": &UpsertOnlyPolicy{},
 	"create-only": &CreateOnlyPolicy{},
+	"never-touch": &NeverTouchPolicy{},
 }
 
 // SyncPolicy allows for full synchronization of DNS records.
@@ -57,3 +63,81 @@ func (p *CreateOnlyPolicy) Apply(changes *Changes) *Changes {
 		Create: changes.Create,
 	}
 }
+
+// NeverTouchPolicy allows no changes at all, e.g. for record types managed by other infrastructure.
+type NeverTouchPolicy struct{}
+
+// Apply applies the never-touch policy which strips out all changes.
+func (p *NeverTouchPolicy) Apply(changes *Changes) *Changes {
+	return &Changes{}
+}
+
+// PerRecordTypePolicy applies the policy configured for a record type to its changes and the
+// default policy to the changes of all other record types.
+type PerRecordTypePolicy struct {
+	Default  Policy
+	Policies map[string]Policy
+}
+
+// NewPerRecordTypePolicy returns a PerRecordTypePolicy applying the given policies by record type, which are
+// matched case-insensitively, and the default policy to all other record types.
+func NewPerRecordTypePolicy(defaultPolicy Policy, policies map[string]Policy) *PerRecordTypePolicy {
+	byType := make(map[string]Policy, len(policies))
+	for recordType, policy := range policies {
+		byType[strings.ToUpper(recordType)] = policy
+	}
+	return &PerRecordTypePolicy{Default: defaultPolicy, Policies: byType}
+}
+
+// Apply splits the changes by record type, applies the matching policy to each part and merges the results.
+func (p *PerRecordTypePolicy) Apply(changes *Changes) *Changes {
+	split := map[string]*Changes{}
+	part := func(recordType string) *Changes {
+		if _, ok := p.Policies[recordType]; !ok {
+			recordType = ""
+		}
+		if _, ok := split[recordType]; !ok {
+			split[recordType] = &Changes{}
+		}
+		return split[recordType]
+	}
+
+	for _, ep := range changes.Create {
+		c := part(ep.RecordType)
+		c.Create = append(c.Create, ep)
+	}
+	for _, ep := range changes.UpdateOld {
+		c := part(ep.RecordType)
+		c.UpdateOld = append(c.UpdateOld, ep)
+	}
+	for _, ep := range changes.UpdateNew {
+		c := part(ep.RecordType)
+		c.UpdateNew = append(c.UpdateNew, ep)
+	}
+	for _, ep := range changes.Delete {
+		c := part(ep.RecordType)
+		c.Delete = append(c.Delete, ep)
+	}
+
+	recordTypes := make([]string, 0, len(split))
+	for recordType := range split {
+		recordTypes = append(recordTypes, recordType)
+	}
+	// keep the order of the changes stable between runs
+	sort.Strings(recordTypes)
+
+	result := &Changes{}
+	for _, recordType := range recordTypes {
+		c := split[recordType]
+		policy, ok := p.Policies[recordType]
+		if !ok {
+			policy = p.Default
+		}
+		c = policy.Apply(c)
+		result.Create = append(result.Create, c.Create...)
+		result.UpdateOld = append(result.UpdateOld, c.UpdateOld...)
+		result.UpdateNew = append(result.UpdateNew, c.UpdateNew...)
+		result.Delete = append(result.Delete, c.Delete...)
+	}
+	return result
+}
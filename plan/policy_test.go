@@ -70,11 +70,58 @@ func TestApply(t *testing.T) {
 	}
 }
 
+// TestPerRecordTypePolicy tests that the policy of a record type applies to its changes only.
+func TestPerRecordTypePolicy(t *testing.T) {
+	empty := []*endpoint.Endpoint{}
+	aV1 := []*endpoint.Endpoint{endpoint.NewEndpoint("foo", endpoint.RecordTypeA, "1.2.3.4")}
+	aV2 := []*endpoint.Endpoint{endpoint.NewEndpoint("foo", endpoint.RecordTypeA, "1.2.3.5")}
+	aOld := []*endpoint.Endpoint{endpoint.NewEndpoint("bar", endpoint.RecordTypeA, "1.2.3.6")}
+	nsNew := []*endpoint.Endpoint{endpoint.NewEndpoint("sub", endpoint.RecordTypeNS, "ns1.example.org")}
+	nsV1 := []*endpoint.Endpoint{endpoint.NewEndpoint("zone", endpoint.RecordTypeNS, "ns1.example.org")}
+	nsV2 := []*endpoint.Endpoint{endpoint.NewEndpoint("zone", endpoint.RecordTypeNS, "ns2.example.org")}
+	nsOld := []*endpoint.Endpoint{endpoint.NewEndpoint("old", endpoint.RecordTypeNS, "ns1.example.org")}
+	mxOld := []*endpoint.Endpoint{endpoint.NewEndpoint("mail", endpoint.RecordTypeMX, "10 mx.example.org")}
+
+	policy := &PerRecordTypePolicy{
+		Default: &SyncPolicy{},
+		Policies: map[string]Policy{
+			endpoint.RecordTypeNS: &CreateOnlyPolicy{},
+			endpoint.RecordTypeMX: &UpsertOnlyPolicy{},
+		},
+	}
+	changes := policy.Apply(&Changes{
+		Create:    nsNew,
+		UpdateOld: append(append([]*endpoint.Endpoint{}, aV1...), nsV1...),
+		UpdateNew: append(append([]*endpoint.Endpoint{}, aV2...), nsV2...),
+		Delete:    append(append(append([]*endpoint.Endpoint{}, aOld...), nsOld...), mxOld...),
+	})
+
+	validateEntries(t, changes.Create, nsNew)
+	validateEntries(t, changes.UpdateOld, aV1)
+	validateEntries(t, changes.UpdateNew, aV2)
+	validateEntries(t, changes.Delete, aOld)
+
+	// without any record type policy the default applies to everything
+	changes = (&PerRecordTypePolicy{Default: &UpsertOnlyPolicy{}}).Apply(&Changes{Create: aV1, Delete: nsOld})
+	validateEntries(t, changes.Create, aV1)
+	validateEntries(t, changes.Delete, empty)
+
+	// the record types are matched case-insensitively
+	policy = NewPerRecordTypePolicy(&SyncPolicy{}, map[string]Policy{"mx": &CreateOnlyPolicy{}, "ns": &NeverTouchPolicy{}})
+	changes = policy.Apply(&Changes{
+		Create: nsNew,
+		Delete: append(append([]*endpoint.Endpoint{}, aOld...), mxOld...),
+	})
+	validateEntries(t, changes.Create, empty)
+	validateEntries(t, changes.Delete, aOld)
+}
+
 // TestPolicies tests that policies are correctly registered.
 func TestPolicies(t *testing.T) {
 	validatePolicy(t, Policies["sync"], &SyncPolicy{})
 	validatePolicy(t, Policies["upsert-only"], &UpsertOnlyPolicy{})
 	validatePolicy(t, Policies["create-only"], &CreateOnlyPolicy{})
+	validatePolicy(t, Policies["never-touch"], &NeverTouchPolicy{})
 }
 
 // validatePolicy validates that a given policy is of the given type.
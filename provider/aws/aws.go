@@ -212,8 +212,10 @@ type Route53API interface {
 type Route53Change struct {
 	route53types.Change
 	OwnedRecord string
-	sizeBytes   int
-	sizeValues  int
+	// ZoneID is the hosted zone the change is pinned to, empty for the best matching zones
+	ZoneID     string
+	sizeBytes  int
+	sizeValues int
 }
 
 type Route53Changes []*Route53Change
@@ -589,6 +591,11 @@ func (p *AWSProvider) createUpdateChanges(newEndpoints, oldEndpoints []*endpoint
 	return combined
 }
 
+// SupportsMultiStringTXT reports that Route53 stores TXT values made of several quoted strings as they are.
+func (p *AWSProvider) SupportsMultiStringTXT() bool {
+	return true
+}
+
 // GetDomainFilter generates a filter to exclude any domain that is not controlled by the provider
 func (p *AWSProvider) GetDomainFilter() endpoint.DomainFilterInterface {
 	zones, err := p.Zones(context.Background())
@@ -671,7 +678,10 @@ func (p *AWSProvider) submitChanges(ctx context.Context, changes Route53Changes,
 				successfulChanges := 0
 
 				client := p.clients[zones[z].profile]
-				if _, err := client.ChangeResourceRecordSets(ctx, params); err != nil {
+				start := time.Now()
+				_, err := client.ChangeResourceRecordSets(ctx, params)
+				provider.ObserveApplyChanges(*zones[z].zone.Name, "ChangeResourceRecordSets", len(b), start)
+				if err != nil {
 					log.Errorf("Failure in zone %s when submitting change batch: %v", *zones[z].zone.Name, err)
 
 					changesByOwnership := groupChangesByNameAndOwnershipRelation(b)
@@ -686,7 +696,10 @@ func (p *AWSProvider) submitChanges(ctx context.Context, changes Route53Changes,
 							params.ChangeBatch = &route53types.ChangeBatch{
 								Changes: changes.Route53Changes(),
 							}
-							if _, err := client.ChangeResourceRecordSets(ctx, params); err != nil {
+							start := time.Now()
+							_, err := client.ChangeResourceRecordSets(ctx, params)
+							provider.ObserveApplyChanges(*zones[z].zone.Name, "ChangeResourceRecordSets", len(changes), start)
+							if err != nil {
 								failedUpdate = true
 								log.Errorf("Failed submitting change (error: %v), it will be retried in a separate change batch in the next iteration", err)
 								p.failedChangesQueue[z] = append(p.failedChangesQueue[z], changes...)
@@ -745,6 +758,55 @@ func (p *AWSProvider) newChanges(action route53types.ChangeAction, endpoints []*
 	return changes
 }
 
+// adjustRoutingPolicy translates the generic routing policy properties of the sources to the Route53 properties.
+// A routing policy set with the Route53 properties takes precedence.
+func adjustRoutingPolicy(ep *endpoint.Endpoint) {
+	routingPolicySet := false
+	for _, prop := range ep.ProviderSpecific {
+		switch prop.Name {
+		case providerSpecificWeight, providerSpecificRegion, providerSpecificFailover, providerSpecificGeolocationContinentCode,
+			providerSpecificGeolocationCountryCode, providerSpecificGeolocationSubdivisionCode:
+			routingPolicySet = true
+		}
+	}
+
+	for _, property := range []string{endpoint.ProviderSpecificWeight, endpoint.ProviderSpecificLatencyRegion,
+		endpoint.ProviderSpecificFailover, endpoint.ProviderSpecificGeolocation} {
+		value, ok := ep.GetProviderSpecificProperty(property)
+		if !ok {
+			continue
+		}
+		ep.DeleteProviderSpecificProperty(property)
+		if routingPolicySet {
+			continue
+		}
+
+		switch property {
+		case endpoint.ProviderSpecificWeight:
+			ep.SetProviderSpecificProperty(providerSpecificWeight, value)
+		case endpoint.ProviderSpecificLatencyRegion:
+			ep.SetProviderSpecificProperty(providerSpecificRegion, value)
+		case endpoint.ProviderSpecificFailover:
+			ep.SetProviderSpecificProperty(providerSpecificFailover, value)
+		case endpoint.ProviderSpecificGeolocation:
+			location, err := endpoint.ParseGeolocation(value)
+			if err != nil {
+				log.Warnf("Ignoring the geolocation of %s: %v", ep.DNSName, err)
+				continue
+			}
+			if location.Continent != "" {
+				ep.SetProviderSpecificProperty(providerSpecificGeolocationContinentCode, location.Continent)
+			} else {
+				ep.SetProviderSpecificProperty(providerSpecificGeolocationCountryCode, location.Country)
+				if location.Subdivision != "" {
+					ep.SetProviderSpecificProperty(providerSpecificGeolocationSubdivisionCode, location.Subdivision)
+				}
+			}
+		}
+		routingPolicySet = true
+	}
+}
+
 // AdjustEndpoints modifies the provided endpoints (coming from various sources) to match
 // the endpoints that the provider returns in `Records` so that the change plan will not have
 // unneeded (potentially failing) changes.
@@ -754,6 +816,8 @@ func (p *AWSProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoi
 	for _, ep := range endpoints {
 		alias := false
 
+		adjustRoutingPolicy(ep)
+
 		if aliasString, ok := ep.GetProviderSpecificProperty(providerSpecificAlias); ok {
 			alias = aliasString == "true"
 			if alias {
@@ -899,6 +963,10 @@ func (p *AWSProvider) newChange(action route53types.ChangeAction, ep *endpoint.E
 		change.OwnedRecord = ownedRecord
 	}
 
+	if zoneID, ok := ep.Labels[endpoint.ZoneIDLabelKey]; ok {
+		change.ZoneID = zoneID
+	}
+
 	return change, dualstack
 }
 
@@ -1060,26 +1128,24 @@ func changesByZone(zones map[string]*profiledZone, changeSet Route53Changes) map
 	for _, c := range changeSet {
 		hostname := provider.EnsureTrailingDot(*c.ResourceRecordSet.Name)
 
+		if c.ZoneID != "" {
+			pinned := pinnedZone(c.ZoneID, hostname, zones)
+			if pinned == nil {
+				log.Warnf("Skipping record %s because its hosted zone %s is not a managed hosted zone matching the record DNS Name", *c.ResourceRecordSet.Name, c.ZoneID)
+				continue
+			}
+			changes[*pinned.zone.Id] = append(changes[*pinned.zone.Id], sameZoneAliasChange(c, pinned))
+			log.Debugf("Adding %s to pinned zone %s [Id: %s]", hostname, *pinned.zone.Name, *pinned.zone.Id)
+			continue
+		}
+
 		zones := suitableZones(hostname, zones)
 		if len(zones) == 0 {
 			log.Debugf("Skipping record %s because no hosted zone matching record DNS Name was detected", *c.ResourceRecordSet.Name)
 			continue
 		}
 		for _, z := range zones {
-			if c.ResourceRecordSet.AliasTarget != nil && *c.ResourceRecordSet.AliasTarget.HostedZoneId == sameZoneAlias {
-				// alias record is to be created; target needs to be in the same zone as endpoint
-				// if it's not, this will fail
-				rrset := *c.ResourceRecordSet
-				aliasTarget := *rrset.AliasTarget
-				aliasTarget.HostedZoneId = aws.String(cleanZoneID(*z.zone.Id))
-				rrset.AliasTarget = &aliasTarget
-				c = &Route53Change{
-					Change: route53types.Change{
-						Action:            c.Action,
-						ResourceRecordSet: &rrset,
-					},
-				}
-			}
+			c = sameZoneAliasChange(c, z)
 			changes[*z.zone.Id] = append(changes[*z.zone.Id], c)
 			log.Debugf("Adding %s to zone %s [Id: %s]", hostname, *z.zone.Name, *z.zone.Id)
 		}
@@ -1123,6 +1189,40 @@ func suitableZones(hostname string, zones map[string]*profiledZone) []*profiledZ
 	return matchingZones
 }
 
+// sameZoneAliasChange returns the change with the target of an alias record to be created in the
+// same zone set to the given zone.
+func sameZoneAliasChange(c *Route53Change, z *profiledZone) *Route53Change {
+	if c.ResourceRecordSet.AliasTarget == nil || *c.ResourceRecordSet.AliasTarget.HostedZoneId != sameZoneAlias {
+		return c
+	}
+	// alias record is to be created; target needs to be in the same zone as endpoint
+	// if it's not, this will fail
+	rrset := *c.ResourceRecordSet
+	aliasTarget := *rrset.AliasTarget
+	aliasTarget.HostedZoneId = aws.String(cleanZoneID(*z.zone.Id))
+	rrset.AliasTarget = &aliasTarget
+	return &Route53Change{
+		Change: route53types.Change{
+			Action:            c.Action,
+			ResourceRecordSet: &rrset,
+		},
+	}
+}
+
+// pinnedZone returns the zone with the given ID if the hostname is in it. The pinned zone overrides
+// the selection of the best matching public zone and of all private zones.
+func pinnedZone(zoneID, hostname string, zones map[string]*profiledZone) *profiledZone {
+	for _, z := range zones {
+		if cleanZoneID(*z.zone.Id) != cleanZoneID(zoneID) {
+			continue
+		}
+		if *z.zone.Name == hostname || strings.HasSuffix(hostname, "."+*z.zone.Name) {
+			return z
+		}
+	}
+	return nil
+}
+
 // useAlias determines if AWS ALIAS should be used.
 func useAlias(ep *endpoint.Endpoint, preferCNAME bool) bool {
 	if preferCNAME {
@@ -568,6 +568,14 @@ func TestAWSAdjustEndpoints(t *testing.T) {
 		endpoint.NewEndpoint("cname-test-elb-no-alias.zone-2.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeCNAME, "foo.eu-central-1.elb.amazonaws.com").WithProviderSpecific(providerSpecificAlias, "false"),
 		endpoint.NewEndpoint("cname-test-elb-no-eth.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeCNAME, "foo.eu-central-1.elb.amazonaws.com").WithProviderSpecific(providerSpecificEvaluateTargetHealth, "false"), // eth = evaluate target health
 		endpoint.NewEndpoint("cname-test-elb-alias.zone-2.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeCNAME, "foo.eu-central-1.elb.amazonaws.com").WithProviderSpecific(providerSpecificAlias, "true").WithProviderSpecific(providerSpecificEvaluateTargetHealth, "true"),
+		endpoint.NewEndpoint("weighted-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "8.8.8.8").WithSetIdentifier("blue").WithProviderSpecific(endpoint.ProviderSpecificWeight, "20"),
+		endpoint.NewEndpoint("weighted-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "8.8.4.4").WithSetIdentifier("green").WithProviderSpecific(endpoint.ProviderSpecificWeight, "20").WithProviderSpecific(providerSpecificWeight, "80"),
+		endpoint.NewEndpoint("latency-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "8.8.8.8").WithSetIdentifier("eu").WithProviderSpecific(endpoint.ProviderSpecificLatencyRegion, "eu-west-1"),
+		endpoint.NewEndpoint("failover-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "8.8.8.8").WithSetIdentifier("primary").WithProviderSpecific(endpoint.ProviderSpecificFailover, "PRIMARY"),
+		endpoint.NewEndpoint("geolocation-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "8.8.8.8").WithSetIdentifier("eu").WithProviderSpecific(endpoint.ProviderSpecificGeolocation, "continent:EU"),
+		endpoint.NewEndpoint("geolocation-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "8.8.4.4").WithSetIdentifier("ca").WithProviderSpecific(endpoint.ProviderSpecificGeolocation, "country:us-ca"),
+		endpoint.NewEndpoint("geolocation-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "1.1.1.1").WithSetIdentifier("invalid").WithProviderSpecific(endpoint.ProviderSpecificGeolocation, "europe"),
+		endpoint.NewEndpoint("geolocation-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "1.0.0.1").WithSetIdentifier("aws").WithProviderSpecific(endpoint.ProviderSpecificGeolocation, "country:DE").WithProviderSpecific(providerSpecificGeolocationCountryCode, "FR"),
 	}
 
 	records, err := provider.AdjustEndpoints(records)
@@ -581,6 +589,14 @@ func TestAWSAdjustEndpoints(t *testing.T) {
 		endpoint.NewEndpoint("cname-test-elb-no-alias.zone-2.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeCNAME, "foo.eu-central-1.elb.amazonaws.com").WithProviderSpecific(providerSpecificAlias, "false"),
 		endpoint.NewEndpoint("cname-test-elb-no-eth.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "foo.eu-central-1.elb.amazonaws.com").WithProviderSpecific(providerSpecificAlias, "true").WithProviderSpecific(providerSpecificEvaluateTargetHealth, "false"), // eth = evaluate target health
 		endpoint.NewEndpoint("cname-test-elb-alias.zone-2.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "foo.eu-central-1.elb.amazonaws.com").WithProviderSpecific(providerSpecificAlias, "true").WithProviderSpecific(providerSpecificEvaluateTargetHealth, "true"),
+		endpoint.NewEndpoint("weighted-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "8.8.8.8").WithSetIdentifier("blue").WithProviderSpecific(providerSpecificWeight, "20"),
+		endpoint.NewEndpoint("weighted-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "8.8.4.4").WithSetIdentifier("green").WithProviderSpecific(providerSpecificWeight, "80"),
+		endpoint.NewEndpoint("latency-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "8.8.8.8").WithSetIdentifier("eu").WithProviderSpecific(providerSpecificRegion, "eu-west-1"),
+		endpoint.NewEndpoint("failover-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "8.8.8.8").WithSetIdentifier("primary").WithProviderSpecific(providerSpecificFailover, "PRIMARY"),
+		endpoint.NewEndpoint("geolocation-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "8.8.8.8").WithSetIdentifier("eu").WithProviderSpecific(providerSpecificGeolocationContinentCode, "EU"),
+		endpoint.NewEndpoint("geolocation-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "8.8.4.4").WithSetIdentifier("ca").WithProviderSpecific(providerSpecificGeolocationCountryCode, "US").WithProviderSpecific(providerSpecificGeolocationSubdivisionCode, "CA"),
+		{DNSName: "geolocation-test.zone-1.ext-dns-test-2.teapot.zalan.do", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.1"}, SetIdentifier: "invalid", ProviderSpecific: endpoint.ProviderSpecific{}},
+		endpoint.NewEndpoint("geolocation-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "1.0.0.1").WithSetIdentifier("aws").WithProviderSpecific(providerSpecificGeolocationCountryCode, "FR"),
 	})
 }
 
@@ -1211,6 +1227,87 @@ func TestAWSChangesByZones(t *testing.T) {
 	})
 }
 
+func TestAWSChangesByZonesPinned(t *testing.T) {
+	zones := map[string]*profiledZone{
+		"/hostedzone/bar-example-org": {
+			profile: defaultAWSProfile,
+			zone: &route53types.HostedZone{
+				Id:   aws.String("/hostedzone/bar-example-org"),
+				Name: aws.String("bar.example.org."),
+			},
+		},
+		"/hostedzone/bar-example-org-private": {
+			profile: defaultAWSProfile,
+			zone: &route53types.HostedZone{
+				Id:     aws.String("/hostedzone/bar-example-org-private"),
+				Name:   aws.String("bar.example.org."),
+				Config: &route53types.HostedZoneConfig{PrivateZone: true},
+			},
+		},
+		"/hostedzone/baz-example-org": {
+			profile: defaultAWSProfile,
+			zone: &route53types.HostedZone{
+				Id:   aws.String("/hostedzone/baz-example-org"),
+				Name: aws.String("baz.example.org."),
+			},
+		},
+	}
+
+	private := endpoint.NewEndpoint("private.bar.example.org", endpoint.RecordTypeA, "10.0.0.1")
+	private.Labels[endpoint.ZoneIDLabelKey] = "bar-example-org-private"
+	public := endpoint.NewEndpoint("public.bar.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	public.Labels[endpoint.ZoneIDLabelKey] = "/hostedzone/bar-example-org"
+	// the pinned zone doesn't contain the record
+	mismatch := endpoint.NewEndpoint("mismatch.bar.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	mismatch.Labels[endpoint.ZoneIDLabelKey] = "baz-example-org"
+	both := endpoint.NewEndpoint("both.bar.example.org", endpoint.RecordTypeA, "1.2.3.4")
+
+	p := &AWSProvider{}
+	changes := p.newChanges(route53types.ChangeActionCreate, []*endpoint.Endpoint{private, public, mismatch, both})
+	assert.Equal(t, "bar-example-org-private", changes[0].ZoneID)
+
+	changesByZone := changesByZone(zones, changes)
+	require.Len(t, changesByZone, 2)
+
+	validateAWSChangeRecords(t, changesByZone["/hostedzone/bar-example-org"], Route53Changes{
+		{
+			Change: route53types.Change{
+				Action: route53types.ChangeActionCreate,
+				ResourceRecordSet: &route53types.ResourceRecordSet{
+					Name: aws.String("public.bar.example.org"), Type: route53types.RRTypeA,
+				},
+			},
+		},
+		{
+			Change: route53types.Change{
+				Action: route53types.ChangeActionCreate,
+				ResourceRecordSet: &route53types.ResourceRecordSet{
+					Name: aws.String("both.bar.example.org"), Type: route53types.RRTypeA,
+				},
+			},
+		},
+	})
+
+	validateAWSChangeRecords(t, changesByZone["/hostedzone/bar-example-org-private"], Route53Changes{
+		{
+			Change: route53types.Change{
+				Action: route53types.ChangeActionCreate,
+				ResourceRecordSet: &route53types.ResourceRecordSet{
+					Name: aws.String("private.bar.example.org"), Type: route53types.RRTypeA,
+				},
+			},
+		},
+		{
+			Change: route53types.Change{
+				Action: route53types.ChangeActionCreate,
+				ResourceRecordSet: &route53types.ResourceRecordSet{
+					Name: aws.String("both.bar.example.org"), Type: route53types.RRTypeA,
+				},
+			},
+		},
+	})
+}
+
 func TestAWSsubmitChanges(t *testing.T) {
 	provider, _ := newAWSProvider(t, endpoint.NewDomainFilter([]string{"ext-dns-test-2.teapot.zalan.do."}), provider.NewZoneIDFilter([]string{}), provider.NewZoneTypeFilter(""), defaultEvaluateTargetHealth, false, nil)
 	const subnets = 16
@@ -2111,3 +2208,54 @@ func TestConvertOctalToAscii(t *testing.T) {
 		})
 	}
 }
+
+func TestAWSMultiStringTXT(t *testing.T) {
+	provider, _ := newAWSProvider(t, endpoint.NewDomainFilter([]string{"ext-dns-test-2.teapot.zalan.do."}), provider.NewZoneIDFilter([]string{}), provider.NewZoneTypeFilter(""), false, false, []route53types.ResourceRecordSet{})
+	assert.True(t, provider.SupportsMultiStringTXT())
+
+	// the strings of a TXT value are stored and returned as they are
+	target := `"` + strings.Repeat("a", 255) + `" "b"`
+	ctx := context.Background()
+	require.NoError(t, provider.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("txt.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeTXT, target)},
+	}))
+	records, err := provider.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, endpoint.Targets{target}, records[0].Targets)
+}
+
+func TestAWSRecordsCanonicalized(t *testing.T) {
+	// Route 53 returns fully qualified names and keeps the case of the targets, which must not be mistaken for a change
+	provider, _ := newAWSProvider(t, endpoint.NewDomainFilter([]string{"ext-dns-test-2.teapot.zalan.do."}), provider.NewZoneIDFilter([]string{}), provider.NewZoneTypeFilter(""), false, false, []route53types.ResourceRecordSet{
+		{
+			Name:            aws.String("cname-test.zone-1.ext-dns-test-2.teapot.zalan.do."),
+			Type:            route53types.RRTypeCname,
+			TTL:             aws.Int64(recordTTL),
+			ResourceRecords: []route53types.ResourceRecord{{Value: aws.String("LB.Example.com.")}},
+		},
+		{
+			Name:            aws.String("mx-test.zone-1.ext-dns-test-2.teapot.zalan.do."),
+			Type:            route53types.RRTypeMx,
+			TTL:             aws.Int64(recordTTL),
+			ResourceRecords: []route53types.ResourceRecord{{Value: aws.String("10 Mail.Example.com.")}},
+		},
+	})
+	ctx := context.Background()
+	records, err := provider.Records(ctx)
+	require.NoError(t, err)
+
+	desired, err := provider.AdjustEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("cname-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeCNAME, "lb.example.com"),
+		endpoint.NewEndpoint("mx-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeMX, "10 mail.example.com"),
+	})
+	require.NoError(t, err)
+
+	changes := (&plan.Plan{
+		Policies:       []plan.Policy{&plan.SyncPolicy{}},
+		Current:        records,
+		Desired:        desired,
+		ManagedRecords: []string{endpoint.RecordTypeCNAME, endpoint.RecordTypeMX},
+	}).Calculate().Changes
+	assert.False(t, changes.HasChanges(), "unexpected changes: %+v", changes)
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 
 	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
@@ -27,6 +28,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	stscredsv2 "github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
 	"github.com/linki/instrumented_http"
 	"github.com/sirupsen/logrus"
 
@@ -35,18 +37,41 @@ import (
 
 // AWSSessionConfig contains configuration to create a new AWS provider.
 type AWSSessionConfig struct {
-	AssumeRole           string
-	AssumeRoleExternalID string
-	APIRetries           int
-	Profile              string
+	AssumeRole            string
+	AssumeRoleExternalID  string
+	AssumeRoleSessionTags map[string]string
+	APIRetries            int
+	Profile               string
 }
 
 func CreateDefaultV2Config(cfg *externaldns.Config) awsv2.Config {
 	result, err := newV2Config(
 		AWSSessionConfig{
-			AssumeRole:           cfg.AWSAssumeRole,
-			AssumeRoleExternalID: cfg.AWSAssumeRoleExternalID,
-			APIRetries:           cfg.AWSAPIRetries,
+			AssumeRole:            cfg.AWSAssumeRole,
+			AssumeRoleExternalID:  cfg.AWSAssumeRoleExternalID,
+			AssumeRoleSessionTags: cfg.AWSAssumeRoleSessionTags,
+			APIRetries:            cfg.AWSAPIRetries,
+		},
+	)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	return result
+}
+
+// CreateServiceDiscoveryV2Config returns the config of the AWS Cloud Map clients. It assumes the role given by
+// --aws-sd-assume-role instead of the one given by --aws-assume-role, if any, so the namespaces may be in another
+// account than the hosted zones.
+func CreateServiceDiscoveryV2Config(cfg *externaldns.Config) awsv2.Config {
+	if cfg.AWSSDAssumeRole == "" {
+		return CreateDefaultV2Config(cfg)
+	}
+	result, err := newV2Config(
+		AWSSessionConfig{
+			AssumeRole:            cfg.AWSSDAssumeRole,
+			AssumeRoleExternalID:  cfg.AWSSDAssumeRoleExternalID,
+			AssumeRoleSessionTags: cfg.AWSAssumeRoleSessionTags,
+			APIRetries:            cfg.AWSAPIRetries,
 		},
 	)
 	if err != nil {
@@ -64,10 +89,11 @@ func CreateV2Configs(cfg *externaldns.Config) map[string]awsv2.Config {
 		for _, profile := range cfg.AWSProfiles {
 			cfg, err := newV2Config(
 				AWSSessionConfig{
-					AssumeRole:           cfg.AWSAssumeRole,
-					AssumeRoleExternalID: cfg.AWSAssumeRoleExternalID,
-					APIRetries:           cfg.AWSAPIRetries,
-					Profile:              profile,
+					AssumeRole:            cfg.AWSAssumeRole,
+					AssumeRoleExternalID:  cfg.AWSAssumeRoleExternalID,
+					AssumeRoleSessionTags: cfg.AWSAssumeRoleSessionTags,
+					APIRetries:            cfg.AWSAPIRetries,
+					Profile:               profile,
 				},
 			)
 			if err != nil {
@@ -100,21 +126,40 @@ func newV2Config(awsConfig AWSSessionConfig) (awsv2.Config, error) {
 
 	if awsConfig.AssumeRole != "" {
 		stsSvc := sts.NewFromConfig(cfg)
-		var assumeRoleOpts []func(*stscredsv2.AssumeRoleOptions)
 		if awsConfig.AssumeRoleExternalID != "" {
 			logrus.Infof("Assuming role %s with external id", awsConfig.AssumeRole)
 			logrus.Debugf("External id: %s", awsConfig.AssumeRoleExternalID)
-			assumeRoleOpts = []func(*stscredsv2.AssumeRoleOptions){
-				func(opts *stscredsv2.AssumeRoleOptions) {
-					opts.ExternalID = &awsConfig.AssumeRoleExternalID
-				},
-			}
 		} else {
 			logrus.Infof("Assuming role: %s", awsConfig.AssumeRole)
 		}
-		creds := stscredsv2.NewAssumeRoleProvider(stsSvc, awsConfig.AssumeRole, assumeRoleOpts...)
+		creds := stscredsv2.NewAssumeRoleProvider(stsSvc, awsConfig.AssumeRole, assumeRoleOptions(awsConfig)...)
 		cfg.Credentials = awsv2.NewCredentialsCache(creds)
 	}
 
 	return cfg, nil
 }
+
+// assumeRoleOptions returns the options of assuming the role, with the external ID and the session tags, if any.
+func assumeRoleOptions(awsConfig AWSSessionConfig) []func(*stscredsv2.AssumeRoleOptions) {
+	var opts []func(*stscredsv2.AssumeRoleOptions)
+	if awsConfig.AssumeRoleExternalID != "" {
+		opts = append(opts, func(opts *stscredsv2.AssumeRoleOptions) {
+			opts.ExternalID = &awsConfig.AssumeRoleExternalID
+		})
+	}
+	if len(awsConfig.AssumeRoleSessionTags) > 0 {
+		keys := make([]string, 0, len(awsConfig.AssumeRoleSessionTags))
+		for key := range awsConfig.AssumeRoleSessionTags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		tags := make([]ststypes.Tag, 0, len(keys))
+		for _, key := range keys {
+			tags = append(tags, ststypes.Tag{Key: awsv2.String(key), Value: awsv2.String(awsConfig.AssumeRoleSessionTags[key])})
+		}
+		opts = append(opts, func(opts *stscredsv2.AssumeRoleOptions) {
+			opts.Tags = tags
+		})
+	}
+	return opts
+}
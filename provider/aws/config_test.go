@@ -21,6 +21,9 @@ import (
 	"os"
 	"testing"
 
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	stscredsv2 "github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -64,6 +67,30 @@ func Test_newV2Config(t *testing.T) {
 	})
 }
 
+func Test_assumeRoleOptions(t *testing.T) {
+	apply := func(awsConfig AWSSessionConfig) stscredsv2.AssumeRoleOptions {
+		var opts stscredsv2.AssumeRoleOptions
+		for _, opt := range assumeRoleOptions(awsConfig) {
+			opt(&opts)
+		}
+		return opts
+	}
+
+	assert.Equal(t, stscredsv2.AssumeRoleOptions{}, apply(AWSSessionConfig{AssumeRole: "role"}))
+
+	opts := apply(AWSSessionConfig{
+		AssumeRole:            "role",
+		AssumeRoleExternalID:  "external-id",
+		AssumeRoleSessionTags: map[string]string{"team": "networking", "cluster": "workload-1"},
+	})
+	assert.Equal(t, awsv2.String("external-id"), opts.ExternalID)
+	// the tags are sorted, so the sessions are the same for the same flags
+	assert.Equal(t, []ststypes.Tag{
+		{Key: awsv2.String("cluster"), Value: awsv2.String("workload-1")},
+		{Key: awsv2.String("team"), Value: awsv2.String("networking")},
+	}, opts.Tags)
+}
+
 func prepareCredentialsFile(t *testing.T) (*os.File, error) {
 	credsFile, err := os.CreateTemp("", "aws-*.creds")
 	require.NoError(t, err)
@@ -36,6 +36,8 @@ import (
 
 const (
 	azureRecordTTL = 300
+	// azureRecordMetadataKey is the key of the record set metadata holding the labels of the metadata registry
+	azureRecordMetadataKey = "externaldns"
 )
 
 // ZonesClient is an interface of dns.ZoneClient that can be stubbed for testing.
@@ -140,6 +142,9 @@ func (p *AzureProvider) Records(ctx context.Context) (endpoints []*endpoint.Endp
 					ttl = endpoint.TTL(*recordSet.Properties.TTL)
 				}
 				ep := endpoint.NewEndpointWithTTL(name, recordType, ttl, targets...)
+				if metadata := recordSet.Properties.Metadata[azureRecordMetadataKey]; metadata != nil {
+					ep.Labels[endpoint.RecordMetadataLabelKey] = *metadata
+				}
 				log.Debugf(
 					"Found %s record for '%s' with target '%s'.",
 					ep.RecordType,
@@ -153,6 +158,11 @@ func (p *AzureProvider) Records(ctx context.Context) (endpoints []*endpoint.Endp
 	return endpoints, nil
 }
 
+// SupportsRecordMetadata reports that the labels of records are stored in the metadata of the record sets.
+func (p *AzureProvider) SupportsRecordMetadata() bool {
+	return true
+}
+
 // ApplyChanges applies the given changes.
 //
 // Returns nil if the operation was successful or an error if the operation failed.
@@ -299,6 +309,9 @@ func (p *AzureProvider) updateRecords(ctx context.Context, updated azureChangeMa
 
 			recordSet, err := p.newRecordSet(ep)
 			if err == nil {
+				if metadata, ok := ep.Labels[endpoint.RecordMetadataLabelKey]; ok {
+					recordSet.Properties.Metadata = map[string]*string{azureRecordMetadataKey: to.Ptr(metadata)}
+				}
 				_, err = p.recordSetsClient.CreateOrUpdate(
 					ctx,
 					p.resourceGroup,
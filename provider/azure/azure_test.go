@@ -24,6 +24,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	dns "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/internal/testutils"
@@ -106,15 +107,16 @@ func (client *mockRecordSetsClient) CreateOrUpdate(ctx context.Context, resource
 	if parameters.Properties.TTL != nil {
 		ttl = endpoint.TTL(*parameters.Properties.TTL)
 	}
-	client.updatedEndpoints = append(
-		client.updatedEndpoints,
-		endpoint.NewEndpointWithTTL(
-			formatAzureDNSName(relativeRecordSetName, zoneName),
-			string(recordType),
-			ttl,
-			extractAzureTargets(&parameters)...,
-		),
+	ep := endpoint.NewEndpointWithTTL(
+		formatAzureDNSName(relativeRecordSetName, zoneName),
+		string(recordType),
+		ttl,
+		extractAzureTargets(&parameters)...,
 	)
+	if metadata := parameters.Properties.Metadata[azureRecordMetadataKey]; metadata != nil {
+		ep.Labels[endpoint.RecordMetadataLabelKey] = *metadata
+	}
+	client.updatedEndpoints = append(client.updatedEndpoints, ep)
 	return dns.RecordSetsClientCreateOrUpdateResponse{}, nil
 }
 
@@ -327,6 +329,31 @@ func TestAzureMultiRecord(t *testing.T) {
 	validateAzureEndpoints(t, actual, expected)
 }
 
+func TestAzureRecordMetadata(t *testing.T) {
+	metadata := "heritage=external-dns,external-dns/owner=default"
+	recordSet := createMockRecordSet("nginx", endpoint.RecordTypeA, "123.123.123.123")
+	recordSet.Properties.Metadata = map[string]*string{azureRecordMetadataKey: to.Ptr(metadata)}
+	provider, err := newMockedAzureProvider(endpoint.NewDomainFilter([]string{"example.com"}), endpoint.NewDomainFilter([]string{}), provider.NewZoneIDFilter([]string{""}), false, "k8s", "", "",
+		[]*dns.Zone{
+			createMockZone("example.com", "/dnszones/example.com"),
+		},
+		[]*dns.RecordSet{recordSet})
+	require.NoError(t, err)
+	assert.True(t, provider.SupportsRecordMetadata())
+
+	records, err := provider.Records(context.Background())
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, metadata, records[0].Labels[endpoint.RecordMetadataLabelKey])
+
+	created := endpoint.NewEndpoint("new.example.com", endpoint.RecordTypeA, "1.2.3.4")
+	created.Labels[endpoint.RecordMetadataLabelKey] = metadata
+	require.NoError(t, provider.ApplyChanges(context.Background(), &plan.Changes{Create: []*endpoint.Endpoint{created}}))
+	updated := provider.recordSetsClient.(*mockRecordSetsClient).updatedEndpoints
+	require.Len(t, updated, 1)
+	assert.Equal(t, metadata, updated[0].Labels[endpoint.RecordMetadataLabelKey])
+}
+
 func TestAzureApplyChanges(t *testing.T) {
 	recordsClient := mockRecordSetsClient{}
 
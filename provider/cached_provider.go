@@ -95,6 +95,21 @@ func (c *CachedProvider) ApplyChanges(ctx context.Context, changes *plan.Changes
 	return c.Provider.ApplyChanges(ctx, changes)
 }
 
+// SupportsRecordMetadata reports whether the cached provider supports record metadata.
+func (c *CachedProvider) SupportsRecordMetadata() bool {
+	return SupportsRecordMetadata(c.Provider)
+}
+
+// MaxRecordMetadataLength returns the maximum length of the metadata of a record of the cached provider.
+func (c *CachedProvider) MaxRecordMetadataLength() int {
+	return MaxRecordMetadataLength(c.Provider)
+}
+
+// SupportsMultiStringTXT reports whether the cached provider supports TXT records made of several strings.
+func (c *CachedProvider) SupportsMultiStringTXT() bool {
+	return SupportsMultiStringTXT(c.Provider)
+}
+
 func (c *CachedProvider) Reset() {
 	c.cache = nil
 	c.lastRead = time.Time{}
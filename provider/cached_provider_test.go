@@ -204,3 +204,33 @@ func TestCachedProviderForcesCacheRefreshOnUpdate(t *testing.T) {
 		})
 	})
 }
+
+// metadataProvider is a provider supporting record metadata
+type metadataProvider struct {
+	testProviderFunc
+}
+
+func (p *metadataProvider) SupportsRecordMetadata() bool {
+	return true
+}
+
+func TestCachedProviderSupportsRecordMetadata(t *testing.T) {
+	assert.False(t, SupportsRecordMetadata(NewCachedProvider(newTestProviderFunc(t), time.Minute)))
+	assert.True(t, SupportsRecordMetadata(NewCachedProvider(&metadataProvider{}, time.Minute)))
+	assert.True(t, SupportsRecordMetadata(NewFaultProvider(NewCachedProvider(&metadataProvider{}, time.Minute), 0, 0, 0)))
+}
+
+// multiStringTXTProvider is a provider supporting TXT records made of several strings
+type multiStringTXTProvider struct {
+	testProviderFunc
+}
+
+func (p *multiStringTXTProvider) SupportsMultiStringTXT() bool {
+	return true
+}
+
+func TestCachedProviderSupportsMultiStringTXT(t *testing.T) {
+	assert.False(t, SupportsMultiStringTXT(NewCachedProvider(newTestProviderFunc(t), time.Minute)))
+	assert.True(t, SupportsMultiStringTXT(NewCachedProvider(&multiStringTXTProvider{}, time.Minute)))
+	assert.True(t, SupportsMultiStringTXT(NewFaultProvider(NewCachedProvider(&multiStringTXTProvider{}, time.Minute), 0, 0, 0)))
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// CanonicalProvider sends the DNS names and the host name targets of the records to a provider in the form
+// strict backends expect, so the providers don't need to convert them themselves. The records of the changes
+// are copied, the caller's records are never modified.
+type CanonicalProvider struct {
+	Provider
+	// TrailingDots sends the names and host name targets fully qualified, with a trailing dot. The trailing dots
+	// of the names of the records returned by the provider are removed again.
+	TrailingDots bool
+	// PreserveCase sends the names and host name targets in their original case instead of in lower case.
+	PreserveCase bool
+}
+
+// NewCanonicalProvider returns a CanonicalProvider sending the records to the given provider in canonical form.
+func NewCanonicalProvider(provider Provider, trailingDots, preserveCase bool) *CanonicalProvider {
+	return &CanonicalProvider{
+		Provider:     provider,
+		TrailingDots: trailingDots,
+		PreserveCase: preserveCase,
+	}
+}
+
+func (c *CanonicalProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	records, err := c.Provider.Records(ctx)
+	if err != nil || !c.TrailingDots {
+		return records, err
+	}
+	for _, r := range records {
+		r.DNSName = strings.TrimSuffix(r.DNSName, ".")
+	}
+	return records, nil
+}
+
+func (c *CanonicalProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	return c.Provider.ApplyChanges(ctx, &plan.Changes{
+		Create:    c.canonicalEndpoints(changes.Create),
+		UpdateOld: c.canonicalEndpoints(changes.UpdateOld),
+		UpdateNew: c.canonicalEndpoints(changes.UpdateNew),
+		Delete:    c.canonicalEndpoints(changes.Delete),
+	})
+}
+
+// SupportsRecordMetadata reports whether the wrapped provider supports record metadata.
+func (c *CanonicalProvider) SupportsRecordMetadata() bool {
+	return SupportsRecordMetadata(c.Provider)
+}
+
+// MaxRecordMetadataLength returns the maximum length of the metadata of a record of the wrapped provider.
+func (c *CanonicalProvider) MaxRecordMetadataLength() int {
+	return MaxRecordMetadataLength(c.Provider)
+}
+
+// SupportsMultiStringTXT reports whether the wrapped provider supports TXT records made of several strings.
+func (c *CanonicalProvider) SupportsMultiStringTXT() bool {
+	return SupportsMultiStringTXT(c.Provider)
+}
+
+// canonicalEndpoints returns copies of the endpoints with the names and host name targets in canonical form.
+func (c *CanonicalProvider) canonicalEndpoints(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	if endpoints == nil {
+		return nil
+	}
+	canonical := make([]*endpoint.Endpoint, len(endpoints))
+	for i, ep := range endpoints {
+		ep = ep.DeepCopy()
+		ep.DNSName = c.canonicalName(ep.DNSName)
+		for j, target := range ep.Targets {
+			ep.Targets[j] = c.canonicalTarget(ep.RecordType, target)
+		}
+		canonical[i] = ep
+	}
+	return canonical
+}
+
+// canonicalTarget returns the target with its host name, if the record type has one, in canonical form.
+func (c *CanonicalProvider) canonicalTarget(recordType, target string) string {
+	switch recordType {
+	case endpoint.RecordTypeCNAME, endpoint.RecordTypeNS, endpoint.RecordTypePTR:
+		return c.canonicalName(target)
+	case endpoint.RecordTypeMX:
+		// <preference> <host>
+		if fields := strings.Fields(target); len(fields) == 2 {
+			return fields[0] + " " + c.canonicalName(fields[1])
+		}
+	case endpoint.RecordTypeSRV:
+		// <priority> <weight> <port> <host>
+		if fields := strings.Fields(target); len(fields) == 4 {
+			fields[3] = c.canonicalName(fields[3])
+			return strings.Join(fields, " ")
+		}
+	}
+	return target
+}
+
+func (c *CanonicalProvider) canonicalName(name string) string {
+	if !c.PreserveCase {
+		name = strings.ToLower(name)
+	}
+	if c.TrailingDots {
+		if !strings.HasSuffix(name, ".") {
+			name += "."
+		}
+	} else if name != "." {
+		name = strings.TrimSuffix(name, ".")
+	}
+	return name
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// newRecord returns an endpoint with the name and targets as given, unlike endpoint.NewEndpoint, which removes
+// the trailing dots.
+func newRecord(name, recordType string, targets ...string) *endpoint.Endpoint {
+	return &endpoint.Endpoint{DNSName: name, RecordType: recordType, Targets: targets, Labels: endpoint.NewLabels()}
+}
+
+func TestCanonicalProviderApplyChanges(t *testing.T) {
+	newChanges := func() *plan.Changes {
+		return &plan.Changes{
+			Create: []*endpoint.Endpoint{
+				newRecord("Www.Example.org", endpoint.RecordTypeCNAME, "LB.Example.net."),
+				newRecord("Mail.Example.org", endpoint.RecordTypeMX, "10 MX.Example.org"),
+				newRecord("_sip._tcp.Example.org", endpoint.RecordTypeSRV, "10 50 5060 SIP.Example.org", "0 0 0 ."),
+				newRecord("Txt.Example.org", endpoint.RecordTypeTXT, "Some Text"),
+			},
+			UpdateOld: []*endpoint.Endpoint{newRecord("Api.Example.org", endpoint.RecordTypeA, "1.2.3.4")},
+			UpdateNew: []*endpoint.Endpoint{newRecord("Api.Example.org", endpoint.RecordTypeA, "5.6.7.8")},
+			Delete:    []*endpoint.Endpoint{newRecord("Old.Example.org", endpoint.RecordTypeNS, "NS1.Example.org")},
+		}
+	}
+
+	for _, tc := range []struct {
+		name         string
+		trailingDots bool
+		preserveCase bool
+		expected     *plan.Changes
+	}{
+		{
+			name:         "trailing dots and original case",
+			trailingDots: true,
+			preserveCase: true,
+			expected: &plan.Changes{
+				Create: []*endpoint.Endpoint{
+					newRecord("Www.Example.org.", endpoint.RecordTypeCNAME, "LB.Example.net."),
+					newRecord("Mail.Example.org.", endpoint.RecordTypeMX, "10 MX.Example.org."),
+					newRecord("_sip._tcp.Example.org.", endpoint.RecordTypeSRV, "10 50 5060 SIP.Example.org.", "0 0 0 ."),
+					newRecord("Txt.Example.org.", endpoint.RecordTypeTXT, "Some Text"),
+				},
+				UpdateOld: []*endpoint.Endpoint{newRecord("Api.Example.org.", endpoint.RecordTypeA, "1.2.3.4")},
+				UpdateNew: []*endpoint.Endpoint{newRecord("Api.Example.org.", endpoint.RecordTypeA, "5.6.7.8")},
+				Delete:    []*endpoint.Endpoint{newRecord("Old.Example.org.", endpoint.RecordTypeNS, "NS1.Example.org.")},
+			},
+		},
+		{
+			name: "no trailing dots and lower case",
+			expected: &plan.Changes{
+				Create: []*endpoint.Endpoint{
+					newRecord("www.example.org", endpoint.RecordTypeCNAME, "lb.example.net"),
+					newRecord("mail.example.org", endpoint.RecordTypeMX, "10 mx.example.org"),
+					newRecord("_sip._tcp.example.org", endpoint.RecordTypeSRV, "10 50 5060 sip.example.org", "0 0 0 ."),
+					newRecord("txt.example.org", endpoint.RecordTypeTXT, "Some Text"),
+				},
+				UpdateOld: []*endpoint.Endpoint{newRecord("api.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+				UpdateNew: []*endpoint.Endpoint{newRecord("api.example.org", endpoint.RecordTypeA, "5.6.7.8")},
+				Delete:    []*endpoint.Endpoint{newRecord("old.example.org", endpoint.RecordTypeNS, "ns1.example.org")},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var applied *plan.Changes
+			c := NewCanonicalProvider(&testProviderFunc{
+				applyChanges: func(ctx context.Context, changes *plan.Changes) error {
+					applied = changes
+					return nil
+				},
+			}, tc.trailingDots, tc.preserveCase)
+
+			changes := newChanges()
+			require.NoError(t, c.ApplyChanges(context.Background(), changes))
+			assert.Equal(t, tc.expected, applied)
+			assert.Equal(t, newChanges(), changes, "the changes of the caller are modified")
+		})
+	}
+}
+
+func TestCanonicalProviderRecords(t *testing.T) {
+	records := func(ctx context.Context) ([]*endpoint.Endpoint, error) {
+		return []*endpoint.Endpoint{
+			{DNSName: "Www.Example.org.", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"lb.example.net."}},
+		}, nil
+	}
+
+	c := NewCanonicalProvider(&testProviderFunc{records: records}, true, true)
+	endpoints, err := c.Records(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Www.Example.org", endpoints[0].DNSName)
+	assert.Equal(t, endpoint.Targets{"lb.example.net."}, endpoints[0].Targets)
+
+	c = NewCanonicalProvider(&testProviderFunc{records: records}, false, false)
+	endpoints, err = c.Records(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Www.Example.org.", endpoints[0].DNSName)
+}
@@ -134,6 +134,8 @@ type CloudFlareProvider struct {
 	DryRun            bool
 	DNSRecordsPerPage int
 	RegionKey         string
+	// CommentMaxLength is the maximum length of record comments, which depends on the plan of the account
+	CommentMaxLength int
 }
 
 // cloudFlareChange differentiates between ChangActions
@@ -148,15 +150,21 @@ type RecordParamsTypes interface {
 	cloudflare.UpdateDNSRecordParams | cloudflare.CreateDNSRecordParams
 }
 
-// updateDNSRecordParam is a function that returns the appropriate Record Param based on the cloudFlareChange passed in
+// updateDNSRecordParam is a function that returns the appropriate Record Param based on the cloudFlareChange passed in.
+// The comment is only sent if the endpoint has a description or metadata, since an empty comment clears the comment
+// of the record, e.g. one set by hand.
 func updateDNSRecordParam(cfc cloudFlareChange) cloudflare.UpdateDNSRecordParams {
-	return cloudflare.UpdateDNSRecordParams{
+	params := cloudflare.UpdateDNSRecordParams{
 		Name:    cfc.ResourceRecord.Name,
 		TTL:     cfc.ResourceRecord.TTL,
 		Proxied: cfc.ResourceRecord.Proxied,
 		Type:    cfc.ResourceRecord.Type,
 		Content: cfc.ResourceRecord.Content,
 	}
+	if cfc.ResourceRecord.Comment != "" {
+		params.Comment = &cfc.ResourceRecord.Comment
+	}
+	return params
 }
 
 // updateDataLocalizationRegionalHostnameParams is a function that returns the appropriate RegionalHostname Param based on the cloudFlareChange passed in
@@ -175,11 +183,12 @@ func getCreateDNSRecordParam(cfc cloudFlareChange) cloudflare.CreateDNSRecordPar
 		Proxied: cfc.ResourceRecord.Proxied,
 		Type:    cfc.ResourceRecord.Type,
 		Content: cfc.ResourceRecord.Content,
+		Comment: cfc.ResourceRecord.Comment,
 	}
 }
 
 // NewCloudFlareProvider initializes a new CloudFlare DNS based Provider.
-func NewCloudFlareProvider(domainFilter endpoint.DomainFilter, zoneIDFilter provider.ZoneIDFilter, proxiedByDefault bool, dryRun bool, dnsRecordsPerPage int, regionKey string) (*CloudFlareProvider, error) {
+func NewCloudFlareProvider(domainFilter endpoint.DomainFilter, zoneIDFilter provider.ZoneIDFilter, proxiedByDefault bool, dryRun bool, dnsRecordsPerPage int, regionKey string, commentMaxLength int) (*CloudFlareProvider, error) {
 	// initialize via chosen auth method and returns new API object
 	var (
 		config *cloudflare.API
@@ -210,6 +219,7 @@ func NewCloudFlareProvider(domainFilter endpoint.DomainFilter, zoneIDFilter prov
 		DryRun:            dryRun,
 		DNSRecordsPerPage: dnsRecordsPerPage,
 		RegionKey:         regionKey,
+		CommentMaxLength:  commentMaxLength,
 	}
 	return provider, nil
 }
@@ -290,6 +300,17 @@ func (p *CloudFlareProvider) Records(ctx context.Context) ([]*endpoint.Endpoint,
 func (p *CloudFlareProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
 	cloudflareChanges := []*cloudFlareChange{}
 
+	// records replaced by records of another type have to be deleted before the new ones can be created
+	migrated := map[*endpoint.Endpoint]bool{}
+	for _, migration := range changes.TypeMigrations() {
+		for _, endpoint := range migration.Delete {
+			migrated[endpoint] = true
+			for _, target := range endpoint.Targets {
+				cloudflareChanges = append(cloudflareChanges, p.newCloudFlareChange(cloudFlareDelete, endpoint, target))
+			}
+		}
+	}
+
 	for _, endpoint := range changes.Create {
 		for _, target := range endpoint.Targets {
 			cloudflareChanges = append(cloudflareChanges, p.newCloudFlareChange(cloudFlareCreate, endpoint, target))
@@ -315,6 +336,9 @@ func (p *CloudFlareProvider) ApplyChanges(ctx context.Context, changes *plan.Cha
 	}
 
 	for _, endpoint := range changes.Delete {
+		if migrated[endpoint] {
+			continue
+		}
 		for _, target := range endpoint.Targets {
 			cloudflareChanges = append(cloudflareChanges, p.newCloudFlareChange(cloudFlareDelete, endpoint, target))
 		}
@@ -337,6 +361,10 @@ func (p *CloudFlareProvider) submitChanges(ctx context.Context, changes []*cloud
 	}
 	// separate into per-zone change sets to be passed to the API.
 	changesByZone := p.changesByZone(zones, changes)
+	zoneNames := make(map[string]string, len(zones))
+	for _, z := range zones {
+		zoneNames[z.ID] = z.Name
+	}
 
 	var failedZones []string
 	for zoneID, changes := range changesByZone {
@@ -371,7 +399,9 @@ func (p *CloudFlareProvider) submitChanges(ctx context.Context, changes []*cloud
 				recordParam := updateDNSRecordParam(*change)
 				regionalHostnameParam := updateDataLocalizationRegionalHostnameParams(*change)
 				recordParam.ID = recordID
+				start := time.Now()
 				err := p.Client.UpdateDNSRecord(ctx, resourceContainer, recordParam)
+				provider.ObserveApplyChanges(zoneNames[zoneID], "UpdateDNSRecord", 1, start)
 				if err != nil {
 					failedChange = true
 					log.WithFields(logFields).Errorf("failed to update record: %v", err)
@@ -387,14 +417,18 @@ func (p *CloudFlareProvider) submitChanges(ctx context.Context, changes []*cloud
 					log.WithFields(logFields).Errorf("failed to find previous record: %v", change.ResourceRecord)
 					continue
 				}
+				start := time.Now()
 				err := p.Client.DeleteDNSRecord(ctx, resourceContainer, recordID)
+				provider.ObserveApplyChanges(zoneNames[zoneID], "DeleteDNSRecord", 1, start)
 				if err != nil {
 					failedChange = true
 					log.WithFields(logFields).Errorf("failed to delete record: %v", err)
 				}
 			} else if change.Action == cloudFlareCreate {
 				recordParam := getCreateDNSRecordParam(*change)
+				start := time.Now()
 				_, err := p.Client.CreateDNSRecord(ctx, resourceContainer, recordParam)
+				provider.ObserveApplyChanges(zoneNames[zoneID], "CreateDNSRecord", 1, start)
 				if err != nil {
 					failedChange = true
 					log.WithFields(logFields).Errorf("failed to create record: %v", err)
@@ -414,6 +448,16 @@ func (p *CloudFlareProvider) submitChanges(ctx context.Context, changes []*cloud
 	return nil
 }
 
+// SupportsRecordMetadata reports that the labels of records are stored in the comments of the records.
+func (p *CloudFlareProvider) SupportsRecordMetadata() bool {
+	return true
+}
+
+// MaxRecordMetadataLength returns the maximum length of record comments.
+func (p *CloudFlareProvider) MaxRecordMetadataLength() int {
+	return p.CommentMaxLength
+}
+
 // AdjustEndpoints modifies the endpoints as needed by the specific provider
 func (p *CloudFlareProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
 	adjustedEndpoints := []*endpoint.Endpoint{}
@@ -460,28 +504,34 @@ func (p *CloudFlareProvider) getRecordID(records []cloudflare.DNSRecord, record
 	return ""
 }
 
-func (p *CloudFlareProvider) newCloudFlareChange(action string, endpoint *endpoint.Endpoint, target string) *cloudFlareChange {
+func (p *CloudFlareProvider) newCloudFlareChange(action string, ep *endpoint.Endpoint, target string) *cloudFlareChange {
 	ttl := defaultCloudFlareRecordTTL
-	proxied := shouldBeProxied(endpoint, p.proxiedByDefault)
+	proxied := shouldBeProxied(ep, p.proxiedByDefault)
 
-	if endpoint.RecordTTL.IsConfigured() {
-		ttl = int(endpoint.RecordTTL)
+	if ep.RecordTTL.IsConfigured() {
+		ttl = int(ep.RecordTTL)
+	}
+	// the labels of the metadata registry take the place of the description, which is one of them
+	comment := ep.Labels[endpoint.DescriptionLabelKey]
+	if metadata, ok := ep.Labels[endpoint.RecordMetadataLabelKey]; ok {
+		comment = metadata
 	}
 	dt := time.Now()
 	return &cloudFlareChange{
 		Action: action,
 		ResourceRecord: cloudflare.DNSRecord{
-			Name:    endpoint.DNSName,
+			Name:    ep.DNSName,
 			TTL:     ttl,
 			Proxied: &proxied,
-			Type:    endpoint.RecordType,
+			Type:    ep.RecordType,
 			Content: target,
+			Comment: comment,
 			Meta: map[string]interface{}{
 				"region": p.RegionKey,
 			},
 		},
 		RegionalHostname: cloudflare.RegionalHostname{
-			Hostname:  endpoint.DNSName,
+			Hostname:  ep.DNSName,
 			RegionKey: p.RegionKey,
 			CreatedOn: &dt,
 		},
@@ -561,14 +611,17 @@ func groupByNameAndType(records []cloudflare.DNSRecord) []*endpoint.Endpoint {
 		for i, record := range records {
 			targets[i] = record.Content
 		}
-		endpoints = append(endpoints,
-			endpoint.NewEndpointWithTTL(
-				records[0].Name,
-				records[0].Type,
-				endpoint.TTL(records[0].TTL),
-				targets...).
-				WithProviderSpecific(source.CloudflareProxiedKey, strconv.FormatBool(*records[0].Proxied)),
-		)
+		ep := endpoint.NewEndpointWithTTL(
+			records[0].Name,
+			records[0].Type,
+			endpoint.TTL(records[0].TTL),
+			targets...).
+			WithProviderSpecific(source.CloudflareProxiedKey, strconv.FormatBool(*records[0].Proxied))
+		if records[0].Comment != "" {
+			ep.Labels[endpoint.DescriptionLabelKey] = records[0].Comment
+			ep.Labels[endpoint.RecordMetadataLabelKey] = records[0].Comment
+		}
+		endpoints = append(endpoints, ep)
 	}
 
 	return endpoints
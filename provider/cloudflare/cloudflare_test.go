@@ -115,15 +115,20 @@ func getDNSRecordFromRecordParams(rp any) cloudflare.DNSRecord {
 			Proxied: params.Proxied,
 			Type:    params.Type,
 			Content: params.Content,
+			Comment: params.Comment,
 		}
 	case cloudflare.UpdateDNSRecordParams:
-		return cloudflare.DNSRecord{
+		record := cloudflare.DNSRecord{
 			Name:    params.Name,
 			TTL:     params.TTL,
 			Proxied: params.Proxied,
 			Type:    params.Type,
 			Content: params.Content,
 		}
+		if params.Comment != nil {
+			record.Comment = *params.Comment
+		}
+		return record
 	default:
 		return cloudflare.DNSRecord{}
 	}
@@ -197,7 +202,12 @@ func (m *mockCloudFlareClient) UpdateDNSRecord(ctx context.Context, rc *cloudfla
 		RecordData: recordData,
 	})
 	if zone, ok := m.Records[rc.Identifier]; ok {
-		if _, ok := zone[rp.ID]; ok {
+		if record, ok := zone[rp.ID]; ok {
+			// like the API, a missing comment keeps the comment of the record
+			if rp.Comment == nil {
+				recordData.Comment = record.Comment
+			}
+			recordData.ID = record.ID
 			zone[rp.ID] = recordData
 		}
 	}
@@ -445,6 +455,76 @@ func TestCloudflareCustomTTL(t *testing.T) {
 	)
 }
 
+func TestCloudflareDescription(t *testing.T) {
+	endpoints := []*endpoint.Endpoint{
+		{
+			RecordType: "A",
+			DNSName:    "described.bar.com",
+			Targets:    endpoint.Targets{"127.0.0.1"},
+			Labels: endpoint.Labels{
+				endpoint.DescriptionLabelKey: "service default/web",
+			},
+		},
+	}
+
+	AssertActions(t, &CloudFlareProvider{}, endpoints, []MockAction{
+		{
+			Name:   "Create",
+			ZoneId: "001",
+			RecordData: cloudflare.DNSRecord{
+				Type:    "A",
+				Name:    "described.bar.com",
+				Content: "127.0.0.1",
+				TTL:     1,
+				Proxied: proxyDisabled,
+				Comment: "service default/web",
+			},
+		},
+	},
+		[]string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+	)
+
+	grouped := groupByNameAndType([]cloudflare.DNSRecord{
+		{Name: "described.bar.com", Type: "A", Content: "127.0.0.1", TTL: 1, Proxied: proxyDisabled, Comment: "service default/web"},
+	})
+	assert.Len(t, grouped, 1)
+	assert.Equal(t, "service default/web", grouped[0].Labels[endpoint.DescriptionLabelKey])
+	assert.Equal(t, "service default/web", grouped[0].Labels[endpoint.RecordMetadataLabelKey])
+}
+
+func TestCloudflareRecordMetadata(t *testing.T) {
+	metadata := "heritage=external-dns,external-dns/owner=default"
+	endpoints := []*endpoint.Endpoint{
+		{
+			RecordType: "A",
+			DNSName:    "owned.bar.com",
+			Targets:    endpoint.Targets{"127.0.0.1"},
+			Labels: endpoint.Labels{
+				endpoint.DescriptionLabelKey:    "service default/web",
+				endpoint.RecordMetadataLabelKey: metadata,
+			},
+		},
+	}
+
+	assert.True(t, (&CloudFlareProvider{}).SupportsRecordMetadata())
+	AssertActions(t, &CloudFlareProvider{}, endpoints, []MockAction{
+		{
+			Name:   "Create",
+			ZoneId: "001",
+			RecordData: cloudflare.DNSRecord{
+				Type:    "A",
+				Name:    "owned.bar.com",
+				Content: "127.0.0.1",
+				TTL:     1,
+				Proxied: proxyDisabled,
+				Comment: metadata,
+			},
+		},
+	},
+		[]string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+	)
+}
+
 func TestCloudflareProxiedDefault(t *testing.T) {
 	endpoints := []*endpoint.Endpoint{
 		{
@@ -719,7 +799,8 @@ func TestCloudflareProvider(t *testing.T) {
 		false,
 		true,
 		5000,
-		"")
+		"",
+		100)
 	if err != nil {
 		t.Errorf("should not fail, %s", err)
 	}
@@ -736,7 +817,8 @@ func TestCloudflareProvider(t *testing.T) {
 		false,
 		true,
 		5000,
-		"")
+		"",
+		100)
 	if err != nil {
 		t.Errorf("should not fail, %s", err)
 	}
@@ -750,7 +832,8 @@ func TestCloudflareProvider(t *testing.T) {
 		false,
 		true,
 		5000,
-		"")
+		"",
+		100)
 	if err != nil {
 		t.Errorf("should not fail, %s", err)
 	}
@@ -763,7 +846,8 @@ func TestCloudflareProvider(t *testing.T) {
 		false,
 		true,
 		5000,
-		"")
+		"",
+		100)
 	if err == nil {
 		t.Errorf("expected to fail")
 	}
@@ -834,6 +918,79 @@ func TestCloudflareApplyChanges(t *testing.T) {
 	}
 }
 
+func TestCloudflareApplyChangesKeepsComment(t *testing.T) {
+	client := NewMockCloudFlareClientWithRecords(map[string][]cloudflare.DNSRecord{
+		"001": {{
+			ID:      "1234567890",
+			Name:    "commented.bar.com",
+			Type:    endpoint.RecordTypeA,
+			TTL:     120,
+			Content: "1.2.3.4",
+			Comment: "set by hand",
+		}},
+	})
+	provider := &CloudFlareProvider{
+		Client: client,
+	}
+	changes := &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("commented.bar.com", endpoint.RecordTypeA, 120, "1.2.3.4")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("commented.bar.com", endpoint.RecordTypeA, 300, "1.2.3.4")},
+	}
+	assert.NoError(t, provider.ApplyChanges(context.Background(), changes))
+
+	record := client.Records["001"]["1234567890"]
+	assert.Equal(t, 300, record.TTL)
+	assert.Equal(t, "set by hand", record.Comment)
+
+	// a description replaces the comment
+	changes.UpdateNew[0].Labels[endpoint.DescriptionLabelKey] = "service default/web"
+	assert.NoError(t, provider.ApplyChanges(context.Background(), changes))
+	assert.Equal(t, "service default/web", client.Records["001"]["1234567890"].Comment)
+}
+
+func TestCloudflareApplyChangesTypeMigration(t *testing.T) {
+	client := NewMockCloudFlareClientWithRecords(map[string][]cloudflare.DNSRecord{
+		"001": {{
+			ID:      "1234567890",
+			Name:    "migrate.bar.com",
+			Type:    endpoint.RecordTypeCNAME,
+			TTL:     1,
+			Content: "lb.example.com",
+		}},
+	})
+	provider := &CloudFlareProvider{
+		Client: client,
+	}
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("migrate.bar.com", endpoint.RecordTypeA, "1.2.3.4")},
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("migrate.bar.com", endpoint.RecordTypeCNAME, "lb.example.com")},
+	}
+	err := provider.ApplyChanges(context.Background(), changes)
+	if err != nil {
+		t.Errorf("should not fail, %s", err)
+	}
+
+	// the CNAME is deleted before the A record is created
+	td.Cmp(t, client.Actions, []MockAction{
+		{
+			Name:     "Delete",
+			ZoneId:   "001",
+			RecordId: "1234567890",
+		},
+		{
+			Name:   "Create",
+			ZoneId: "001",
+			RecordData: cloudflare.DNSRecord{
+				Name:    "migrate.bar.com",
+				Type:    endpoint.RecordTypeA,
+				Content: "1.2.3.4",
+				TTL:     1,
+				Proxied: proxyDisabled,
+			},
+		},
+	})
+}
+
 func TestCloudflareApplyChangesError(t *testing.T) {
 	changes := &plan.Changes{}
 	client := NewMockCloudFlareClient()
@@ -1384,7 +1541,7 @@ func TestCustomTTLWithEnabledProxyNotChanged(t *testing.T) {
 func TestCloudFlareProvider_Region(t *testing.T) {
 	_ = os.Setenv("CF_API_TOKEN", "abc123def")
 	_ = os.Setenv("CF_API_EMAIL", "test@test.com")
-	provider, err := NewCloudFlareProvider(endpoint.NewDomainFilter([]string{"example.com"}), provider.ZoneIDFilter{}, true, false, 50, "us")
+	provider, err := NewCloudFlareProvider(endpoint.NewDomainFilter([]string{"example.com"}), provider.ZoneIDFilter{}, true, false, 50, "us", 100)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1415,7 +1572,7 @@ func TestCloudFlareProvider_updateDataLocalizationRegionalHostnameParams(t *test
 func TestCloudFlareProvider_newCloudFlareChange(t *testing.T) {
 	_ = os.Setenv("CF_API_KEY", "xxxxxxxxxxxxxxxxx")
 	_ = os.Setenv("CF_API_EMAIL", "test@test.com")
-	provider, err := NewCloudFlareProvider(endpoint.NewDomainFilter([]string{"example.com"}), provider.ZoneIDFilter{}, true, false, 50, "us")
+	provider, err := NewCloudFlareProvider(endpoint.NewDomainFilter([]string{"example.com"}), provider.ZoneIDFilter{}, true, false, 50, "us", 100)
 	if err != nil {
 		t.Fatal(err)
 	}
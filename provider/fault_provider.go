@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// ErrInjectedFault is the error returned by the FaultProvider for the calls it fails.
+var ErrInjectedFault = errors.New("injected fault")
+
+// FaultProvider injects faults into the calls to a provider, for validating alerting and the
+// retry behavior of the controller in staging environments. Injected errors are soft errors.
+type FaultProvider struct {
+	Provider
+	// ErrorRate is the percentage of Records and ApplyChanges calls failing without calling the provider
+	ErrorRate float64
+	// PartialRate is the percentage of ApplyChanges calls applying only some of the changes before failing
+	PartialRate float64
+	// Latency is the maximum random delay added to Records and ApplyChanges calls
+	Latency time.Duration
+
+	random func() float64
+}
+
+// NewFaultProvider returns a FaultProvider injecting faults into the calls to the given provider.
+func NewFaultProvider(provider Provider, errorRate, partialRate float64, latency time.Duration) *FaultProvider {
+	log.Warnf("Injecting faults into provider calls (errors: %.1f%%, partial failures: %.1f%%, latency: up to %s), do not use in production", errorRate, partialRate, latency)
+	return &FaultProvider{
+		Provider:    provider,
+		ErrorRate:   errorRate,
+		PartialRate: partialRate,
+		Latency:     latency,
+		random:      rand.Float64,
+	}
+}
+
+func (f *FaultProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	if err := f.delay(ctx); err != nil {
+		return nil, err
+	}
+	if f.happens(f.ErrorRate) {
+		log.Warn("Injecting error into provider Records")
+		return nil, NewSoftError(ErrInjectedFault)
+	}
+	return f.Provider.Records(ctx)
+}
+
+func (f *FaultProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	if err := f.delay(ctx); err != nil {
+		return err
+	}
+	if f.happens(f.ErrorRate) {
+		log.Warn("Injecting error into provider ApplyChanges")
+		return NewSoftError(ErrInjectedFault)
+	}
+	if f.happens(f.PartialRate) {
+		partial := f.partialChanges(changes)
+		log.Warnf("Injecting partial failure into provider ApplyChanges, applying %d of %d creates, %d of %d updates and %d of %d deletes",
+			len(partial.Create), len(changes.Create), len(partial.UpdateNew), len(changes.UpdateNew), len(partial.Delete), len(changes.Delete))
+		if err := f.Provider.ApplyChanges(ctx, partial); err != nil {
+			return err
+		}
+		return NewSoftError(ErrInjectedFault)
+	}
+	return f.Provider.ApplyChanges(ctx, changes)
+}
+
+// SupportsRecordMetadata reports whether the provider faults are injected into supports record metadata.
+func (f *FaultProvider) SupportsRecordMetadata() bool {
+	return SupportsRecordMetadata(f.Provider)
+}
+
+// MaxRecordMetadataLength returns the maximum length of the metadata of a record of the provider faults are injected into.
+func (f *FaultProvider) MaxRecordMetadataLength() int {
+	return MaxRecordMetadataLength(f.Provider)
+}
+
+// SupportsMultiStringTXT reports whether the provider faults are injected into supports TXT records made of several strings.
+func (f *FaultProvider) SupportsMultiStringTXT() bool {
+	return SupportsMultiStringTXT(f.Provider)
+}
+
+// partialChanges returns about half of the changes, keeping updates in pairs.
+func (f *FaultProvider) partialChanges(changes *plan.Changes) *plan.Changes {
+	partial := &plan.Changes{}
+	for _, e := range changes.Create {
+		if f.happens(50) {
+			partial.Create = append(partial.Create, e)
+		}
+	}
+	if len(changes.UpdateOld) == len(changes.UpdateNew) {
+		for i := range changes.UpdateNew {
+			if f.happens(50) {
+				partial.UpdateOld = append(partial.UpdateOld, changes.UpdateOld[i])
+				partial.UpdateNew = append(partial.UpdateNew, changes.UpdateNew[i])
+			}
+		}
+	}
+	for _, e := range changes.Delete {
+		if f.happens(50) {
+			partial.Delete = append(partial.Delete, e)
+		}
+	}
+	return partial
+}
+
+// happens returns true with the given percentage of probability.
+func (f *FaultProvider) happens(percentage float64) bool {
+	return percentage > 0 && f.random()*100 < percentage
+}
+
+func (f *FaultProvider) delay(ctx context.Context) error {
+	if f.Latency <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(time.Duration(f.random() * float64(f.Latency))):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
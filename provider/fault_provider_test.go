@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// sequence returns a random function returning the given values in turn.
+func sequence(values ...float64) func() float64 {
+	return func() float64 {
+		v := values[0]
+		values = values[1:]
+		return v
+	}
+}
+
+func TestFaultProviderRecords(t *testing.T) {
+	calls := 0
+	f := NewFaultProvider(&testProviderFunc{
+		records: func(ctx context.Context) ([]*endpoint.Endpoint, error) {
+			calls++
+			return []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")}, nil
+		},
+	}, 20, 0, 0)
+
+	f.random = sequence(0.1)
+	_, err := f.Records(context.Background())
+	assert.ErrorIs(t, err, SoftError)
+	assert.ErrorIs(t, err, ErrInjectedFault)
+	assert.Equal(t, 0, calls)
+
+	f.random = sequence(0.5)
+	records, err := f.Records(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, 1, calls)
+}
+
+func TestFaultProviderApplyChanges(t *testing.T) {
+	var applied *plan.Changes
+	f := NewFaultProvider(&testProviderFunc{
+		applyChanges: func(ctx context.Context, changes *plan.Changes) error {
+			applied = changes
+			return nil
+		},
+	}, 10, 30, 0)
+	changes := &plan.Changes{
+		Create:    []*endpoint.Endpoint{endpoint.NewEndpoint("create-1.example.org", endpoint.RecordTypeA, "1.2.3.4"), endpoint.NewEndpoint("create-2.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpoint("update.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpoint("update.example.org", endpoint.RecordTypeA, "5.6.7.8")},
+		Delete:    []*endpoint.Endpoint{endpoint.NewEndpoint("delete.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+	}
+
+	// error
+	f.random = sequence(0.05)
+	assert.ErrorIs(t, f.ApplyChanges(context.Background(), changes), ErrInjectedFault)
+	assert.Nil(t, applied)
+
+	// partial failure applying the first create and the update
+	f.random = sequence(0.5, 0.2, 0.1, 0.9, 0.3, 0.7)
+	err := f.ApplyChanges(context.Background(), changes)
+	assert.ErrorIs(t, err, SoftError)
+	assert.ErrorIs(t, err, ErrInjectedFault)
+	assert.Equal(t, &plan.Changes{
+		Create:    changes.Create[:1],
+		UpdateOld: changes.UpdateOld,
+		UpdateNew: changes.UpdateNew,
+	}, applied)
+
+	// success
+	f.random = sequence(0.5, 0.5)
+	require.NoError(t, f.ApplyChanges(context.Background(), changes))
+	assert.Equal(t, changes, applied)
+}
+
+func TestFaultProviderLatency(t *testing.T) {
+	f := NewFaultProvider(&testProviderFunc{
+		records: func(ctx context.Context) ([]*endpoint.Endpoint, error) {
+			return nil, nil
+		},
+	}, 0, 0, time.Hour)
+	f.random = sequence(0.5)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := f.Records(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
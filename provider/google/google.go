@@ -259,6 +259,11 @@ func (p *GoogleProvider) SupportedRecordType(recordType string) bool {
 	}
 }
 
+// SupportsMultiStringTXT reports that Cloud DNS stores TXT rrdatas made of several quoted strings as they are.
+func (p *GoogleProvider) SupportsMultiStringTXT() bool {
+	return true
+}
+
 // newFilteredRecords returns a collection of RecordSets based on the given endpoints and domainFilter.
 func (p *GoogleProvider) newFilteredRecords(endpoints []*endpoint.Endpoint) []*dns.ResourceRecordSet {
 	records := []*dns.ResourceRecordSet{}
@@ -301,7 +306,10 @@ func (p *GoogleProvider) submitChange(ctx context.Context, change *dns.Change) e
 				continue
 			}
 
-			if _, err := p.changesClient.Create(p.project, zone, c).Do(); err != nil {
+			start := time.Now()
+			_, err := p.changesClient.Create(p.project, zone, c).Do()
+			provider.ObserveApplyChanges(zones[zone].DnsName, "changes.create", len(c.Additions)+len(c.Deletions), start)
+			if err != nil {
 				return provider.NewSoftError(fmt.Errorf("failed to create changes: %w", err))
 			}
 
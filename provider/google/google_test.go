@@ -817,7 +817,7 @@ func clearGoogleRecords(t *testing.T, provider *GoogleProvider, zone string) {
 	provider.resourceRecordSetsClient.List(provider.project, zone).Pages(context.Background(), func(resp *dns.ResourceRecordSetsListResponse) error {
 		for _, r := range resp.Rrsets {
 			switch r.Type {
-			case endpoint.RecordTypeA, endpoint.RecordTypeCNAME:
+			case endpoint.RecordTypeA, endpoint.RecordTypeCNAME, endpoint.RecordTypeTXT:
 				recordSets = append(recordSets, r)
 			}
 		}
@@ -835,3 +835,38 @@ func clearGoogleRecords(t *testing.T, provider *GoogleProvider, zone string) {
 func validateEndpoints(t *testing.T, endpoints []*endpoint.Endpoint, expected []*endpoint.Endpoint) {
 	assert.True(t, testutils.SameEndpoints(endpoints, expected), "actual and expected endpoints don't match. %s:%s", endpoints, expected)
 }
+
+func TestGoogleMultiStringTXT(t *testing.T) {
+	// the strings of a TXT rrdata are stored and returned as they are
+	originalEndpoints := []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("txt.zone-1.ext-dns-test-2.gcp.zalan.do", endpoint.RecordTypeTXT, endpoint.TTL(300), `"`+strings.Repeat("a", 255)+`" "b"`),
+	}
+	provider := newGoogleProvider(t, endpoint.NewDomainFilter([]string{"ext-dns-test-2.gcp.zalan.do."}), provider.NewZoneIDFilter([]string{""}), false, originalEndpoints, nil, nil)
+	assert.True(t, provider.SupportsMultiStringTXT())
+
+	records, err := provider.Records(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, records, originalEndpoints)
+}
+
+func TestGoogleRecordsCanonicalized(t *testing.T) {
+	// Cloud DNS returns fully qualified names and targets, which must not be mistaken for a change when their case differs
+	provider := newGoogleProvider(t, endpoint.NewDomainFilter([]string{"ext-dns-test-2.gcp.zalan.do."}), provider.NewZoneIDFilter([]string{""}), false, []*endpoint.Endpoint{}, nil, nil)
+	_, err := provider.changesClient.Create(provider.project, "zone-1-ext-dns-test-2-gcp-zalan-do", &dns.Change{
+		Additions: []*dns.ResourceRecordSet{
+			{Name: "Cname-Test.zone-1.ext-dns-test-2.gcp.zalan.do.", Type: endpoint.RecordTypeCNAME, Ttl: 300, Rrdatas: []string{"LB.Example.com."}},
+		},
+	}).Do()
+	require.NoError(t, err)
+
+	records, err := provider.Records(context.Background())
+	require.NoError(t, err)
+
+	changes := (&plan.Plan{
+		Policies:       []plan.Policy{&plan.SyncPolicy{}},
+		Current:        records,
+		Desired:        []*endpoint.Endpoint{endpoint.NewEndpoint("cname-test.zone-1.ext-dns-test-2.gcp.zalan.do", endpoint.RecordTypeCNAME, "lb.example.com")},
+		ManagedRecords: []string{endpoint.RecordTypeCNAME},
+	}).Calculate().Changes
+	assert.False(t, changes.HasChanges(), "unexpected changes: %+v", changes)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	applyChangesDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "external_dns",
+			Subsystem: "provider",
+			Name:      "apply_changes_duration_seconds",
+			Help:      "Duration of the provider API calls applying changes to a zone.",
+			Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10),
+		},
+		[]string{"zone", "operation"},
+	)
+	applyChangesBatchSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "external_dns",
+			Subsystem: "provider",
+			Name:      "apply_changes_batch_size",
+			Help:      "Number of record changes submitted by the provider API calls applying changes to a zone.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 11),
+		},
+		[]string{"zone", "operation"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(applyChangesDuration)
+	prometheus.MustRegister(applyChangesBatchSize)
+}
+
+// ObserveApplyChanges records the duration since start and the number of record changes of a
+// provider API call applying changes to a zone. The operation is the name of the API call, e.g.
+// ChangeResourceRecordSets. Failed calls are recorded as well.
+func ObserveApplyChanges(zone, operation string, batchSize int, start time.Time) {
+	zone = strings.TrimSuffix(zone, ".")
+	applyChangesDuration.WithLabelValues(zone, operation).Observe(time.Since(start).Seconds())
+	applyChangesBatchSize.WithLabelValues(zone, operation).Observe(float64(batchSize))
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveApplyChanges(t *testing.T) {
+	applyChangesDuration.Reset()
+	applyChangesBatchSize.Reset()
+	ObserveApplyChanges("example.org.", "ChangeResourceRecordSets", 3, time.Now())
+
+	assert.Equal(t, 1, testutil.CollectAndCount(applyChangesDuration))
+	assert.NoError(t, testutil.CollectAndCompare(applyChangesBatchSize, strings.NewReader(`
+# HELP external_dns_provider_apply_changes_batch_size Number of record changes submitted by the provider API calls applying changes to a zone.
+# TYPE external_dns_provider_apply_changes_batch_size histogram
+external_dns_provider_apply_changes_batch_size_bucket{operation="ChangeResourceRecordSets",zone="example.org",le="1"} 0
+external_dns_provider_apply_changes_batch_size_bucket{operation="ChangeResourceRecordSets",zone="example.org",le="2"} 0
+external_dns_provider_apply_changes_batch_size_bucket{operation="ChangeResourceRecordSets",zone="example.org",le="4"} 1
+external_dns_provider_apply_changes_batch_size_bucket{operation="ChangeResourceRecordSets",zone="example.org",le="8"} 1
+external_dns_provider_apply_changes_batch_size_bucket{operation="ChangeResourceRecordSets",zone="example.org",le="16"} 1
+external_dns_provider_apply_changes_batch_size_bucket{operation="ChangeResourceRecordSets",zone="example.org",le="32"} 1
+external_dns_provider_apply_changes_batch_size_bucket{operation="ChangeResourceRecordSets",zone="example.org",le="64"} 1
+external_dns_provider_apply_changes_batch_size_bucket{operation="ChangeResourceRecordSets",zone="example.org",le="128"} 1
+external_dns_provider_apply_changes_batch_size_bucket{operation="ChangeResourceRecordSets",zone="example.org",le="256"} 1
+external_dns_provider_apply_changes_batch_size_bucket{operation="ChangeResourceRecordSets",zone="example.org",le="512"} 1
+external_dns_provider_apply_changes_batch_size_bucket{operation="ChangeResourceRecordSets",zone="example.org",le="1024"} 1
+external_dns_provider_apply_changes_batch_size_bucket{operation="ChangeResourceRecordSets",zone="example.org",le="+Inf"} 1
+external_dns_provider_apply_changes_batch_size_sum{operation="ChangeResourceRecordSets",zone="example.org"} 3
+external_dns_provider_apply_changes_batch_size_count{operation="ChangeResourceRecordSets",zone="example.org"} 1
+`)))
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
 )
 
@@ -880,6 +881,36 @@ func (suite *NewPDNSProviderTestSuite) TestPDNSRRSetToEndpoints() {
 	assert.Equal(suite.T(), endpointsDisabledRecord, eps)
 }
 
+func (suite *NewPDNSProviderTestSuite) TestPDNSRRSetCanonicalized() {
+	// PowerDNS returns fully qualified names and targets, which must not be mistaken for a change when their case differs
+	p := &PDNSProvider{
+		client: &PDNSAPIClientStub{},
+	}
+
+	var current []*endpoint.Endpoint
+	for _, rr := range []pgo.RrSet{
+		{Name: "Cname.Example.com.", Type_: "CNAME", Ttl: 300, Records: []pgo.Record{{Content: "LB.Example.org."}}},
+		{Name: "alias.example.com.", Type_: "ALIAS", Ttl: 300, Records: []pgo.Record{{Content: "Elb.Example.org."}}},
+		{Name: "example.com.", Type_: "MX", Ttl: 300, Records: []pgo.Record{{Content: "10 Mail.Example.com."}}},
+	} {
+		eps, err := p.convertRRSetToEndpoints(rr)
+		assert.Nil(suite.T(), err)
+		current = append(current, eps...)
+	}
+
+	changes := (&plan.Plan{
+		Policies: []plan.Policy{&plan.SyncPolicy{}},
+		Current:  current,
+		Desired: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("cname.example.com", endpoint.RecordTypeCNAME, "lb.example.org"),
+			endpoint.NewEndpoint("alias.example.com", endpoint.RecordTypeCNAME, "elb.example.org"),
+			endpoint.NewEndpoint("example.com", endpoint.RecordTypeMX, "10 mail.example.com"),
+		},
+		ManagedRecords: []string{endpoint.RecordTypeCNAME, endpoint.RecordTypeMX},
+	}).Calculate().Changes
+	assert.False(suite.T(), changes.HasChanges(), "unexpected changes: %+v", changes)
+}
+
 func (suite *NewPDNSProviderTestSuite) TestPDNSRecords() {
 	// Function definition: Records() (endpoints []*endpoint.Endpoint, _ error)
 
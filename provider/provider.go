@@ -51,6 +51,50 @@ type Provider interface {
 	GetDomainFilter() endpoint.DomainFilterInterface
 }
 
+// RecordMetadataProvider is implemented by providers which can store the registry labels of records natively,
+// e.g. in record comments or tags, so the registry doesn't need TXT records.
+type RecordMetadataProvider interface {
+	// SupportsRecordMetadata reports whether the provider stores the endpoint.RecordMetadataLabelKey label of
+	// created and updated endpoints with the records and returns it along with the records.
+	SupportsRecordMetadata() bool
+}
+
+// SupportsRecordMetadata reports whether the provider is a RecordMetadataProvider supporting record metadata.
+func SupportsRecordMetadata(p Provider) bool {
+	mp, ok := p.(RecordMetadataProvider)
+	return ok && mp.SupportsRecordMetadata()
+}
+
+// RecordMetadataLimiter is implemented by RecordMetadataProviders which limit the length of the metadata of a record,
+// e.g. the length of Cloudflare record comments.
+type RecordMetadataLimiter interface {
+	// MaxRecordMetadataLength returns the maximum length of the metadata of a record, 0 if it isn't limited.
+	MaxRecordMetadataLength() int
+}
+
+// MaxRecordMetadataLength returns the maximum length of the metadata the provider stores with a record, 0 if it
+// isn't limited.
+func MaxRecordMetadataLength(p Provider) int {
+	if ml, ok := p.(RecordMetadataLimiter); ok {
+		return ml.MaxRecordMetadataLength()
+	}
+	return 0
+}
+
+// MultiStringTXTProvider is implemented by providers which store a TXT record made of several quoted strings, e.g.
+// "abc" "def", as a single record and return it the same way, so TXT contents may exceed the length of a single string.
+type MultiStringTXTProvider interface {
+	// SupportsMultiStringTXT reports whether the provider stores TXT targets made of several quoted strings.
+	SupportsMultiStringTXT() bool
+}
+
+// SupportsMultiStringTXT reports whether the provider is a MultiStringTXTProvider supporting TXT records made of
+// several strings.
+func SupportsMultiStringTXT(p Provider) bool {
+	mp, ok := p.(MultiStringTXTProvider)
+	return ok && mp.SupportsMultiStringTXT()
+}
+
 type BaseProvider struct{}
 
 func (b BaseProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/crash"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// RecoveringProvider converts panics of the calls to a provider into a *crash.Error, so a panicking provider SDK
+// fails the synchronization instead of terminating ExternalDNS.
+type RecoveringProvider struct {
+	Provider
+}
+
+// NewRecoveringProvider returns a RecoveringProvider recovering from the panics of the given provider.
+func NewRecoveringProvider(provider Provider) *RecoveringProvider {
+	return &RecoveringProvider{Provider: provider}
+}
+
+func (r *RecoveringProvider) Records(ctx context.Context) (records []*endpoint.Endpoint, err error) {
+	defer crash.Recover(r.component(), &err)
+	return r.Provider.Records(ctx)
+}
+
+func (r *RecoveringProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) (err error) {
+	defer crash.Recover(r.component(), &err)
+	return r.Provider.ApplyChanges(ctx, changes)
+}
+
+func (r *RecoveringProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) (adjusted []*endpoint.Endpoint, err error) {
+	defer crash.Recover(r.component(), &err)
+	return r.Provider.AdjustEndpoints(endpoints)
+}
+
+// SupportsRecordMetadata reports whether the wrapped provider supports record metadata.
+func (r *RecoveringProvider) SupportsRecordMetadata() bool {
+	return SupportsRecordMetadata(r.Provider)
+}
+
+// MaxRecordMetadataLength returns the maximum length of the metadata of a record of the wrapped provider.
+func (r *RecoveringProvider) MaxRecordMetadataLength() int {
+	return MaxRecordMetadataLength(r.Provider)
+}
+
+// SupportsMultiStringTXT reports whether the wrapped provider supports TXT records made of several strings.
+func (r *RecoveringProvider) SupportsMultiStringTXT() bool {
+	return SupportsMultiStringTXT(r.Provider)
+}
+
+func (r *RecoveringProvider) component() string {
+	return fmt.Sprintf("provider %T", r.Provider)
+}
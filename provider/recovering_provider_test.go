@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/crash"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+func TestRecoveringProvider(t *testing.T) {
+	r := NewRecoveringProvider(&testProviderFunc{
+		records: func(ctx context.Context) ([]*endpoint.Endpoint, error) {
+			panic("records bug")
+		},
+		applyChanges: func(ctx context.Context, changes *plan.Changes) error {
+			var changed *endpoint.Endpoint
+			changed.DNSName = "nil pointer"
+			return nil
+		},
+		adjustEndpoints: func(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+			return endpoints, errors.New("adjust failed")
+		},
+	})
+
+	var crashErr *crash.Error
+	_, err := r.Records(context.Background())
+	require.ErrorAs(t, err, &crashErr)
+	assert.Equal(t, "provider *provider.testProviderFunc", crashErr.Component)
+	assert.Equal(t, "records bug", crashErr.Value)
+
+	err = r.ApplyChanges(context.Background(), &plan.Changes{})
+	require.ErrorAs(t, err, &crashErr)
+	assert.ErrorContains(t, err, "nil pointer dereference")
+
+	// errors without a panic are passed through
+	_, err = r.AdjustEndpoints(nil)
+	assert.EqualError(t, err, "adjust failed")
+}
@@ -312,6 +312,11 @@ func (r rfc2136Provider) GenerateReverseRecord(ip string, hostname string) []*en
 func (r rfc2136Provider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
 	log.Debugf("ApplyChanges (Create: %d, UpdateOld: %d, UpdateNew: %d, Delete: %d)", len(changes.Create), len(changes.UpdateOld), len(changes.UpdateNew), len(changes.Delete))
 
+	updates := changes.TargetUpdates()
+	if len(changes.UpdateNew) > 0 && updates == nil {
+		return fmt.Errorf("RFC2136 got %d old and %d new records to update", len(changes.UpdateOld), len(changes.UpdateNew))
+	}
+
 	var errors []error
 
 	for c, chunk := range chunkBy(changes.Create, r.batchChangeSize) {
@@ -372,9 +377,24 @@ func (r rfc2136Provider) ApplyChanges(ctx context.Context, changes *plan.Changes
 			r.krb5Realm = strings.ToUpper(zone)
 			m[zone].SetUpdate(zone)
 
-			r.UpdateRecord(m[zone], changes.UpdateOld[i], ep)
+			update := updates[c*r.batchChangeSize+i]
+			if update.TargetsOnly() {
+				// only send the targets that actually changed instead of replacing the whole RRset
+				r.UpdateRecordTargets(m[zone], update)
+				if r.createPTR && (ep.RecordType == "A" || ep.RecordType == "AAAA") {
+					for _, target := range update.Removed {
+						r.RemoveReverseRecord(target, ep.DNSName)
+					}
+					for _, target := range update.Added {
+						r.AddReverseRecord(target, ep.DNSName)
+					}
+				}
+				continue
+			}
+
+			r.UpdateRecord(m[zone], update.Old, ep)
 			if r.createPTR && (ep.RecordType == "A" || ep.RecordType == "AAAA") {
-				r.RemoveReverseRecord(changes.UpdateOld[i].Targets[0], ep.DNSName)
+				r.RemoveReverseRecord(update.Old.Targets[0], ep.DNSName)
 				r.AddReverseRecord(ep.Targets[0], ep.DNSName)
 			}
 		}
@@ -444,6 +464,25 @@ func (r rfc2136Provider) UpdateRecord(m *dns.Msg, oldEp *endpoint.Endpoint, newE
 	return r.AddRecord(m, newEp)
 }
 
+// UpdateRecordTargets applies an update by only removing and adding the targets which changed.
+func (r rfc2136Provider) UpdateRecordTargets(m *dns.Msg, update *plan.TargetUpdate) error {
+	if len(update.Removed) > 0 {
+		removed := *update.Old
+		removed.Targets = update.Removed
+		if err := r.RemoveRecord(m, &removed); err != nil {
+			return err
+		}
+	}
+
+	if len(update.Added) > 0 {
+		added := *update.New
+		added.Targets = update.Added
+		return r.AddRecord(m, &added)
+	}
+
+	return nil
+}
+
 func (r rfc2136Provider) AddRecord(m *dns.Msg, ep *endpoint.Endpoint) error {
 	log.Debugf("AddRecord.ep=%s", ep)
 
@@ -405,6 +405,34 @@ func TestRfc2136GetRecords(t *testing.T) {
 	assert.True(t, contains(recs, "v2.foo.com"))
 }
 
+func TestRfc2136GetRecordsCanonicalized(t *testing.T) {
+	// zone transfers return fully qualified names and targets keeping their case, which must not be mistaken for a change
+	stub := newStub()
+	err := stub.setOutput([]string{
+		"Cname.Foo.com. 3600 IN CNAME LB.Example.org.",
+		"sub.foo.com. 3600 IN NS NS1.Foo.com.",
+		"sub.foo.com. 3600 IN NS ns2.foo.com.",
+	})
+	assert.NoError(t, err)
+
+	provider, err := createRfc2136StubProvider(stub)
+	assert.NoError(t, err)
+
+	recs, err := provider.Records(context.Background())
+	assert.NoError(t, err)
+
+	changes := (&plan.Plan{
+		Policies: []plan.Policy{&plan.SyncPolicy{}},
+		Current:  recs,
+		Desired: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("cname.foo.com", endpoint.RecordTypeCNAME, "lb.example.org"),
+			endpoint.NewEndpoint("sub.foo.com", endpoint.RecordTypeNS, "ns2.foo.com", "ns1.foo.com"),
+		},
+		ManagedRecords: []string{endpoint.RecordTypeCNAME, endpoint.RecordTypeNS},
+	}).Calculate().Changes
+	assert.False(t, changes.HasChanges(), "unexpected changes: %+v", changes)
+}
+
 // Make sure the test version of SendMessage raises an error
 // if a zone update ever contains records outside of it's zone
 // as the TestRfc2136ApplyChanges tests all assume this
@@ -747,6 +775,109 @@ func TestRfc2136ApplyChangesWithUpdate(t *testing.T) {
 	assert.True(t, strings.Contains(stub.updateMsgs[1].String(), "boom"))
 }
 
+func TestRfc2136ApplyChangesWithIncrementalUpdate(t *testing.T) {
+	stub := newStub()
+
+	provider, err := createRfc2136StubProvider(stub)
+	assert.NoError(t, err)
+
+	p := &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{
+			{
+				DNSName:    "v1.foo.com",
+				RecordType: "A",
+				Targets:    []string{"1.2.3.4", "1.2.3.5", "1.2.3.6"},
+				RecordTTL:  endpoint.TTL(400),
+			},
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			{
+				DNSName:    "v1.foo.com",
+				RecordType: "A",
+				Targets:    []string{"1.2.3.4", "1.2.3.6", "1.2.3.7"},
+				RecordTTL:  endpoint.TTL(400),
+			},
+		},
+	}
+
+	err = provider.ApplyChanges(context.Background(), p)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(stub.createMsgs))
+	assert.Equal(t, 1, len(stub.updateMsgs))
+
+	update := extractUpdateSectionFromMessage(stub.updateMsgs[0])
+	var rrs []string
+	for _, line := range update {
+		if strings.TrimSpace(line) != "" {
+			rrs = append(rrs, line)
+		}
+	}
+	assert.Len(t, rrs, 2)
+	assert.True(t, strings.Contains(stub.updateMsgs[0].String(), "NONE\tA\t1.2.3.5"))
+	assert.True(t, strings.Contains(stub.updateMsgs[0].String(), "IN\tA\t1.2.3.7"))
+	assert.False(t, strings.Contains(stub.updateMsgs[0].String(), "1.2.3.4"))
+	assert.False(t, strings.Contains(stub.updateMsgs[0].String(), "1.2.3.6"))
+}
+
+func TestRfc2136ApplyChangesWithIdenticalTargets(t *testing.T) {
+	stub := newStub()
+
+	provider, err := createRfc2136StubProvider(stub)
+	assert.NoError(t, err)
+
+	// e.g. a resync, which has to rewrite the record although no target changed
+	p := &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{
+			{
+				DNSName:    "v1.foo.com",
+				RecordType: "A",
+				Targets:    []string{"1.2.3.4", "1.2.3.5"},
+				RecordTTL:  endpoint.TTL(400),
+			},
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			{
+				DNSName:    "v1.foo.com",
+				RecordType: "A",
+				Targets:    []string{"1.2.3.4", "1.2.3.5"},
+				RecordTTL:  endpoint.TTL(400),
+				Labels:     endpoint.Labels{endpoint.ResyncLabelKey: "1"},
+			},
+		},
+	}
+
+	err = provider.ApplyChanges(context.Background(), p)
+	assert.NoError(t, err)
+
+	// the whole RRset is replaced, the stub records the message once per removed target
+	assert.Equal(t, 2, len(stub.updateMsgs))
+	assert.True(t, strings.Contains(stub.updateMsgs[0].String(), "NONE\tA\t1.2.3.4"))
+	assert.True(t, strings.Contains(stub.updateMsgs[0].String(), "IN\tA\t1.2.3.4"))
+	assert.True(t, strings.Contains(stub.updateMsgs[0].String(), "IN\tA\t1.2.3.5"))
+}
+
+func TestRfc2136ApplyChangesWithMismatchedUpdates(t *testing.T) {
+	stub := newStub()
+
+	provider, err := createRfc2136StubProvider(stub)
+	assert.NoError(t, err)
+
+	p := &plan.Changes{
+		UpdateNew: []*endpoint.Endpoint{
+			{
+				DNSName:    "v1.foo.com",
+				RecordType: "A",
+				Targets:    []string{"1.2.3.4"},
+			},
+		},
+	}
+
+	err = provider.ApplyChanges(context.Background(), p)
+	assert.Error(t, err)
+	assert.Empty(t, stub.updateMsgs)
+}
+
 func TestChunkBy(t *testing.T) {
 	var records []*endpoint.Endpoint
 
@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// Ownership actions reported by the AuditRegistry
+const (
+	// AuditActionClaim is the creation of a record owned by this instance
+	AuditActionClaim = "claim"
+	// AuditActionAdopt is the adoption of an existing record without an owner
+	AuditActionAdopt = "adopt"
+	// AuditActionJoin is the joining of the owners sharing the ownership of a record of another owner
+	AuditActionJoin = "join"
+	// AuditActionTakeover is the takeover of a record owned by another owner, e.g. whose ownership lease expired
+	AuditActionTakeover = "takeover"
+	// AuditActionRelease is the deletion of a record owned by this instance
+	AuditActionRelease = "release"
+)
+
+var auditedActionsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "external_dns",
+		Subsystem: "registry",
+		Name:      "audited_actions_total",
+		Help:      "Number of ownership actions reported instead of applied by the audit mode of the registry.",
+	},
+	[]string{"action"},
+)
+
+func init() {
+	prometheus.MustRegister(auditedActionsTotal)
+}
+
+// AuditAction is an ownership action the registry would have applied.
+type AuditAction struct {
+	Action        string `json:"action"`
+	DNSName       string `json:"dnsName"`
+	RecordType    string `json:"recordType"`
+	SetIdentifier string `json:"setIdentifier,omitempty"`
+	Owner         string `json:"owner,omitempty"`
+	PreviousOwner string `json:"previousOwner,omitempty"`
+	Resource      string `json:"resource,omitempty"`
+}
+
+// AuditReport holds the ownership actions of the last synchronization.
+type AuditReport struct {
+	OwnerID string        `json:"ownerID"`
+	Actions []AuditAction `json:"actions"`
+}
+
+// AuditRegistry wraps a registry and reports the ownership actions of the changes instead of applying them.
+// Neither the records nor the ownership entries of the wrapped registry, e.g. TXT records or DynamoDB items,
+// are written, so the ownership ExternalDNS would claim in a shared zone can be audited before enabling it.
+type AuditRegistry struct {
+	Registry
+	reportPath string
+}
+
+// NewAuditRegistry returns an AuditRegistry wrapping the registry. The report of each synchronization is
+// written to reportPath as JSON, unless it is empty.
+func NewAuditRegistry(registry Registry, reportPath string) *AuditRegistry {
+	return &AuditRegistry{
+		Registry:   registry,
+		reportPath: reportPath,
+	}
+}
+
+// ApplyChanges reports the ownership actions of the changes without propagating them to the wrapped registry.
+func (r *AuditRegistry) ApplyChanges(_ context.Context, changes *plan.Changes) error {
+	report := AuditReport{OwnerID: r.OwnerID(), Actions: r.auditActions(changes)}
+	for _, action := range report.Actions {
+		log.Infof("Audit: would %s %s %s (owner %q, previous owner %q, resource %q)",
+			action.Action, action.RecordType, action.DNSName, action.Owner, action.PreviousOwner, action.Resource)
+		auditedActionsTotal.WithLabelValues(action.Action).Inc()
+	}
+
+	if r.reportPath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the audit report: %w", err)
+	}
+	if err := os.WriteFile(r.reportPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write the audit report: %w", err)
+	}
+	return nil
+}
+
+// auditActions returns the ownership actions of the changes. Updates which don't change the owner of a record
+// aren't ownership actions.
+func (r *AuditRegistry) auditActions(changes *plan.Changes) []AuditAction {
+	ownerID := r.OwnerID()
+	actions := []AuditAction{}
+	for _, ep := range changes.Create {
+		actions = append(actions, newAuditAction(AuditActionClaim, ep, ownerID, ""))
+	}
+	for i, updateNew := range changes.UpdateNew {
+		if i >= len(changes.UpdateOld) {
+			break
+		}
+		previous := changes.UpdateOld[i].Labels[endpoint.OwnerLabelKey]
+		switch {
+		case previous == "":
+			actions = append(actions, newAuditAction(AuditActionAdopt, updateNew, ownerID, ""))
+		case previous == ownerID:
+			continue
+		case slices.Contains(updateNew.Labels.Owners(), ownerID):
+			actions = append(actions, newAuditAction(AuditActionJoin, updateNew, ownerID, previous))
+		case updateNew.Labels[endpoint.OwnerLabelKey] == ownerID:
+			actions = append(actions, newAuditAction(AuditActionTakeover, updateNew, ownerID, previous))
+		}
+	}
+	for _, ep := range changes.Delete {
+		actions = append(actions, newAuditAction(AuditActionRelease, ep, "", ep.Labels[endpoint.OwnerLabelKey]))
+	}
+	return actions
+}
+
+func newAuditAction(action string, ep *endpoint.Endpoint, owner, previousOwner string) AuditAction {
+	return AuditAction{
+		Action:        action,
+		DNSName:       ep.DNSName,
+		RecordType:    ep.RecordType,
+		SetIdentifier: ep.SetIdentifier,
+		Owner:         owner,
+		PreviousOwner: previousOwner,
+		Resource:      ep.Labels[endpoint.ResourceLabelKey],
+	}
+}
+
+// OrphanedEntries returns the orphaned entries of the wrapped registry, if it reports them.
+func (r *AuditRegistry) OrphanedEntries() []endpoint.EndpointKey {
+	return orphanedEntries(r.Registry)
+}
+
+// MaintenanceChanges returns the maintenance changes of the wrapped registry, if it maintains records of its own.
+func (r *AuditRegistry) MaintenanceChanges() *plan.Changes {
+	return maintenanceChanges(r.Registry)
+}
+
+// FilterChanges returns the changes the wrapped registry allows to apply, if it holds back any.
+func (r *AuditRegistry) FilterChanges(changes *plan.Changes) *plan.Changes {
+	return filterChanges(r.Registry, changes)
+}
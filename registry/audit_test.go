@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+func TestAuditRegistry(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("existing.test-zone.example.org", endpoint.RecordTypeA, "1.1.1.1")},
+	}))
+	txt, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{}, []string{}, false, nil)
+	require.NoError(t, err)
+
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+	r := NewAuditRegistry(txt, reportPath)
+
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{newEndpointWithOwnerResource("new.test-zone.example.org", "2.2.2.2", endpoint.RecordTypeA, "", "ingress/default/new")},
+		UpdateOld: []*endpoint.Endpoint{
+			newEndpointWithOwner("existing.test-zone.example.org", "1.1.1.1", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("owned.test-zone.example.org", "3.3.3.3", endpoint.RecordTypeA, "owner"),
+			newEndpointWithOwner("expired.test-zone.example.org", "4.4.4.4", endpoint.RecordTypeA, "other"),
+			newEndpointWithOwner("shared.test-zone.example.org", "5.5.5.5", endpoint.RecordTypeA, "other"),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			newEndpointWithOwner("existing.test-zone.example.org", "1.1.1.1", endpoint.RecordTypeA, "owner"),
+			newEndpointWithOwner("owned.test-zone.example.org", "3.3.3.4", endpoint.RecordTypeA, "owner"),
+			newEndpointWithOwner("expired.test-zone.example.org", "4.4.4.4", endpoint.RecordTypeA, "owner"),
+			newEndpointWithOwnerAndLabels("shared.test-zone.example.org", "5.5.5.5", endpoint.RecordTypeA, "other", endpoint.Labels{endpoint.OwnersLabelKey: "other;owner"}),
+		},
+		Delete: []*endpoint.Endpoint{newEndpointWithOwner("old.test-zone.example.org", "6.6.6.6", endpoint.RecordTypeA, "owner")},
+	}
+	require.NoError(t, r.ApplyChanges(ctx, changes))
+
+	// nothing is written
+	records, err := p.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	data, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	var report AuditReport
+	require.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, AuditReport{
+		OwnerID: "owner",
+		Actions: []AuditAction{
+			{Action: AuditActionClaim, DNSName: "new.test-zone.example.org", RecordType: endpoint.RecordTypeA, Owner: "owner", Resource: "ingress/default/new"},
+			{Action: AuditActionAdopt, DNSName: "existing.test-zone.example.org", RecordType: endpoint.RecordTypeA, Owner: "owner"},
+			{Action: AuditActionTakeover, DNSName: "expired.test-zone.example.org", RecordType: endpoint.RecordTypeA, Owner: "owner", PreviousOwner: "other"},
+			{Action: AuditActionJoin, DNSName: "shared.test-zone.example.org", RecordType: endpoint.RecordTypeA, Owner: "owner", PreviousOwner: "other"},
+			{Action: AuditActionRelease, DNSName: "old.test-zone.example.org", RecordType: endpoint.RecordTypeA, PreviousOwner: "owner"},
+		},
+	}, report)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CacheInvalidator is implemented by registries caching the records read from the provider.
+type CacheInvalidator interface {
+	// InvalidateCache makes the next call of Records read the records from the provider.
+	InvalidateCache()
+}
+
+// CacheFlushHandler is an http.Handler invalidating the records cache of a registry on POST requests,
+// e.g. after the records have been changed outside of ExternalDNS.
+type CacheFlushHandler struct {
+	Registry CacheInvalidator
+}
+
+func (h *CacheFlushHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.Registry.InvalidateCache()
+	log.Info("Invalidated the records cache of the registry")
+	w.WriteHeader(http.StatusNoContent)
+}
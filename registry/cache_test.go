@@ -0,0 +1,48 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingInvalidator struct {
+	invalidations int
+}
+
+func (c *countingInvalidator) InvalidateCache() {
+	c.invalidations++
+}
+
+func TestCacheFlushHandler(t *testing.T) {
+	invalidator := &countingInvalidator{}
+	handler := &CacheFlushHandler{Registry: invalidator}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/registry/cache/flush", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, 0, invalidator.invalidations)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/registry/cache/flush", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, 1, invalidator.invalidations)
+}
@@ -0,0 +1,369 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+const configMapRecordsKey = "records.json"
+
+// configMapRecord is the ownership of a single DNS record as stored in the ConfigMap.
+type configMapRecord struct {
+	DNSName       string          `json:"dnsName"`
+	RecordType    string          `json:"recordType"`
+	SetIdentifier string          `json:"setIdentifier,omitempty"`
+	Labels        endpoint.Labels `json:"labels"`
+}
+
+// ConfigMapRegistry implements registry interface with ownership implemented via a Kubernetes ConfigMap.
+// The ConfigMap may be shared by many owners; concurrent writes are detected by the resource version.
+type ConfigMapRegistry struct {
+	provider provider.Provider
+	ownerID  string // refers to the owner id of the current instance
+
+	client    kubernetes.Interface
+	namespace string
+	name      string
+
+	// the ConfigMap as last read or written, nil if it doesn't exist yet
+	configMap *corev1.ConfigMap
+	// cache the labels of all owners stored in the ConfigMap
+	labels         map[endpoint.EndpointKey]endpoint.Labels
+	orphanedLabels sets.Set[endpoint.EndpointKey]
+
+	// cache the records in memory and update on an interval instead.
+	recordsCache            []*endpoint.Endpoint
+	recordsCacheRefreshTime time.Time
+	cacheInterval           time.Duration
+}
+
+// NewConfigMapRegistry returns a new ConfigMapRegistry object.
+func NewConfigMapRegistry(provider provider.Provider, ownerID string, client kubernetes.Interface, namespace, name string, cacheInterval time.Duration) (*ConfigMapRegistry, error) {
+	if ownerID == "" {
+		return nil, errors.New("owner id cannot be empty")
+	}
+	if namespace == "" || name == "" {
+		return nil, errors.New("configmap namespace and name cannot be empty")
+	}
+
+	return &ConfigMapRegistry{
+		provider:      provider,
+		ownerID:       ownerID,
+		client:        client,
+		namespace:     namespace,
+		name:          name,
+		cacheInterval: cacheInterval,
+	}, nil
+}
+
+func (im *ConfigMapRegistry) GetDomainFilter() endpoint.DomainFilterInterface {
+	return im.provider.GetDomainFilter()
+}
+
+func (im *ConfigMapRegistry) OwnerID() string {
+	return im.ownerID
+}
+
+// OrphanedEntries returns the keys of the owned entries whose records didn't exist on the last read.
+func (im *ConfigMapRegistry) OrphanedEntries() []endpoint.EndpointKey {
+	return sortedKeys(im.orphanedLabels)
+}
+
+// Records returns the current records from the registry.
+func (im *ConfigMapRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	// If we have the zones cached AND we have refreshed the cache since the
+	// last given interval, then just use the cached results.
+	if im.recordsCache != nil && time.Since(im.recordsCacheRefreshTime) < im.cacheInterval {
+		log.Debug("Using cached records.")
+		countCacheLookup("configmap", true)
+		return im.recordsCache, nil
+	}
+	if im.cacheInterval > 0 {
+		countCacheLookup("configmap", false)
+	}
+
+	if im.labels == nil {
+		if err := im.readLabels(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	records, err := im.provider.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orphanedLabels := sets.New[endpoint.EndpointKey]()
+	for key, labels := range im.labels {
+		if labels[endpoint.OwnerLabelKey] == im.ownerID {
+			orphanedLabels.Insert(key)
+		}
+	}
+	for _, record := range records {
+		key := record.Key()
+		if labels := im.labels[key]; labels != nil {
+			record.Labels = maps.Clone(labels)
+			orphanedLabels.Delete(key)
+		} else {
+			record.Labels = endpoint.NewLabels()
+		}
+	}
+	im.orphanedLabels = orphanedLabels
+
+	// Update the cache.
+	if im.cacheInterval > 0 {
+		im.recordsCache = records
+		im.recordsCacheRefreshTime = time.Now()
+	}
+
+	return records, nil
+}
+
+// ApplyChanges records the ownership of created and updated records in the ConfigMap, updates the
+// DNS provider and then releases the ownership of deleted records.
+func (im *ConfigMapRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	timer := startLabelPersistenceTimer("configmap")
+	defer timer.observe()
+
+	filteredChanges := &plan.Changes{
+		Create:    make([]*endpoint.Endpoint, 0, len(changes.Create)),
+		UpdateNew: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateNew),
+		UpdateOld: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateOld),
+		Delete:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete),
+	}
+	if im.labels == nil {
+		if err := im.readLabels(ctx); err != nil {
+			return err
+		}
+	}
+
+	changed := false
+	for _, r := range changes.Create {
+		key := r.Key()
+		if owner := im.labels[key][endpoint.OwnerLabelKey]; owner != "" && owner != im.ownerID {
+			// We lost a race with a different owner or another owner has an orphaned ownership record.
+			log.Infof("Skipping endpoint %v because owner does not match", r)
+			continue
+		}
+		if r.Labels == nil {
+			r.Labels = make(map[string]string)
+		}
+		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
+		filteredChanges.Create = append(filteredChanges.Create, r)
+
+		im.orphanedLabels.Delete(key)
+		changed = im.setLabels(key, r.Labels) || changed
+		if im.cacheInterval > 0 {
+			im.addToCache(r)
+		}
+	}
+
+	for _, r := range filteredChanges.UpdateOld {
+		// remove old version of record from cache
+		if im.cacheInterval > 0 {
+			im.removeFromCache(r)
+		}
+	}
+
+	for _, r := range filteredChanges.UpdateNew {
+		changed = im.setLabels(r.Key(), r.Labels) || changed
+		// add new version of record to caches
+		if im.cacheInterval > 0 {
+			im.addToCache(r)
+		}
+	}
+
+	if changed {
+		if err := im.writeLabels(ctx); err != nil {
+			im.reset()
+			return err
+		}
+	}
+
+	// When caching is enabled, disable the provider from using the cache.
+	if im.cacheInterval > 0 {
+		ctx = context.WithValue(ctx, provider.RecordsContextKey, nil)
+	}
+	if err := timer.applyChanges(ctx, im.provider, filteredChanges); err != nil {
+		im.reset()
+		return err
+	}
+
+	changed = false
+	for _, r := range filteredChanges.Delete {
+		if _, ok := im.labels[r.Key()]; ok {
+			delete(im.labels, r.Key())
+			changed = true
+		}
+		if im.cacheInterval > 0 {
+			im.removeFromCache(r)
+		}
+	}
+	for r := range im.orphanedLabels {
+		delete(im.labels, r)
+		changed = true
+	}
+	im.orphanedLabels = nil
+	if !changed {
+		return nil
+	}
+	if err := im.writeLabels(ctx); err != nil {
+		im.reset()
+		return err
+	}
+	return nil
+}
+
+// AdjustEndpoints modifies the endpoints as needed by the specific provider.
+func (im *ConfigMapRegistry) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	return im.provider.AdjustEndpoints(endpoints)
+}
+
+// setLabels stores the labels of a record and returns whether they changed.
+func (im *ConfigMapRegistry) setLabels(key endpoint.EndpointKey, labels endpoint.Labels) bool {
+	old, exists := im.labels[key]
+	im.labels[key] = maps.Clone(labels)
+	return !exists || !maps.Equal(old, labels)
+}
+
+// reset drops all cached state, so the next synchronization reads the ConfigMap again.
+func (im *ConfigMapRegistry) reset() {
+	im.recordsCache = nil
+	im.labels = nil
+	im.configMap = nil
+	im.orphanedLabels = nil
+}
+
+func (im *ConfigMapRegistry) readLabels(ctx context.Context) error {
+	cm, err := im.client.CoreV1().ConfigMaps(im.namespace).Get(ctx, im.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		im.configMap = nil
+		im.labels = map[endpoint.EndpointKey]endpoint.Labels{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading configmap %s/%s: %w", im.namespace, im.name, err)
+	}
+
+	labels := map[endpoint.EndpointKey]endpoint.Labels{}
+	if data, ok := cm.Data[configMapRecordsKey]; ok {
+		var records []configMapRecord
+		if err := json.Unmarshal([]byte(data), &records); err != nil {
+			return fmt.Errorf("unmarshalling records of configmap %s/%s: %w", im.namespace, im.name, err)
+		}
+		for _, r := range records {
+			if r.Labels == nil {
+				r.Labels = endpoint.NewLabels()
+			}
+			labels[endpoint.EndpointKey{DNSName: r.DNSName, RecordType: r.RecordType, SetIdentifier: r.SetIdentifier}] = r.Labels
+		}
+	}
+
+	im.configMap = cm
+	im.labels = labels
+	return nil
+}
+
+// writeLabels stores the labels of all owners in the ConfigMap. The update fails if another owner
+// changed the ConfigMap since it was read.
+func (im *ConfigMapRegistry) writeLabels(ctx context.Context) error {
+	records := make([]configMapRecord, 0, len(im.labels))
+	for key, labels := range im.labels {
+		records = append(records, configMapRecord{
+			DNSName:       key.DNSName,
+			RecordType:    key.RecordType,
+			SetIdentifier: key.SetIdentifier,
+			Labels:        labels,
+		})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].DNSName != records[j].DNSName {
+			return records[i].DNSName < records[j].DNSName
+		}
+		if records[i].RecordType != records[j].RecordType {
+			return records[i].RecordType < records[j].RecordType
+		}
+		return records[i].SetIdentifier < records[j].SetIdentifier
+	})
+	data, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("marshalling records of configmap %s/%s: %w", im.namespace, im.name, err)
+	}
+
+	configMaps := im.client.CoreV1().ConfigMaps(im.namespace)
+	var cm *corev1.ConfigMap
+	if im.configMap == nil {
+		cm, err = configMaps.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: im.namespace,
+				Name:      im.name,
+			},
+			Data: map[string]string{configMapRecordsKey: string(data)},
+		}, metav1.CreateOptions{})
+	} else {
+		cm = im.configMap.DeepCopy()
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[configMapRecordsKey] = string(data)
+		cm, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("writing configmap %s/%s: %w", im.namespace, im.name, err)
+	}
+	log.Infof("Updated ownership of %d records in configmap %s/%s", len(records), im.namespace, im.name)
+	im.configMap = cm
+	return nil
+}
+
+func (im *ConfigMapRegistry) addToCache(ep *endpoint.Endpoint) {
+	if im.recordsCache != nil {
+		im.recordsCache = append(im.recordsCache, ep)
+	}
+}
+
+func (im *ConfigMapRegistry) removeFromCache(ep *endpoint.Endpoint) {
+	if im.recordsCache == nil || ep == nil {
+		return
+	}
+
+	for i, e := range im.recordsCache {
+		if e.DNSName == ep.DNSName && e.RecordType == ep.RecordType && e.SetIdentifier == ep.SetIdentifier && e.Targets.Same(ep.Targets) {
+			// We found a match; delete the endpoint from the cache.
+			im.recordsCache = append(im.recordsCache[:i], im.recordsCache[i+1:]...)
+			return
+		}
+	}
+}
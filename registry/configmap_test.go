@@ -0,0 +1,293 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+func TestConfigMapRegistryNew(t *testing.T) {
+	p := inmemory.NewInMemoryProvider()
+	client := fake.NewSimpleClientset()
+
+	_, err := NewConfigMapRegistry(p, "test-owner", client, "default", "external-dns-registry", time.Hour)
+	require.NoError(t, err)
+
+	_, err = NewConfigMapRegistry(p, "", client, "default", "external-dns-registry", time.Hour)
+	require.EqualError(t, err, "owner id cannot be empty")
+
+	_, err = NewConfigMapRegistry(p, "test-owner", client, "default", "", time.Hour)
+	require.EqualError(t, err, "configmap namespace and name cannot be empty")
+}
+
+func TestConfigMapRegistryRecords(t *testing.T) {
+	client := newConfigMapRegistryClient(t, []configMapRecord{
+		{
+			DNSName:    "bar.test-zone.example.org",
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+			},
+		},
+		{
+			DNSName:       "baz.test-zone.example.org",
+			RecordType:    endpoint.RecordTypeA,
+			SetIdentifier: "set-1",
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey: "other-owner",
+			},
+		},
+	})
+	r, err := NewConfigMapRegistry(newConfigMapRegistryProvider(t), "test-owner", client, "default", "external-dns-registry", time.Hour)
+	require.NoError(t, err)
+
+	records, err := r.Records(context.Background())
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		{
+			DNSName:    "foo.test-zone.example.org",
+			Targets:    endpoint.Targets{"foo.loadbalancer.com"},
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey: "",
+			},
+		},
+		{
+			DNSName:    "bar.test-zone.example.org",
+			Targets:    endpoint.Targets{"my-domain.com"},
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+			},
+		},
+	}), "actual: %v", records)
+
+	// the ConfigMap is only read again after a failure
+	require.NoError(t, client.CoreV1().ConfigMaps("default").Delete(context.Background(), "external-dns-registry", metav1.DeleteOptions{}))
+	r.recordsCache = nil
+	records, err = r.Records(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+	for _, record := range records {
+		if record.DNSName == "bar.test-zone.example.org" {
+			assert.Equal(t, "test-owner", record.Labels[endpoint.OwnerLabelKey])
+		}
+	}
+}
+
+func TestConfigMapRegistryRecordsWithoutConfigMap(t *testing.T) {
+	r, err := NewConfigMapRegistry(newConfigMapRegistryProvider(t), "test-owner", fake.NewSimpleClientset(), "default", "external-dns-registry", 0)
+	require.NoError(t, err)
+
+	records, err := r.Records(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+	for _, record := range records {
+		assert.Equal(t, "", record.Labels[endpoint.OwnerLabelKey])
+	}
+}
+
+func TestConfigMapRegistryApplyChanges(t *testing.T) {
+	client := newConfigMapRegistryClient(t, []configMapRecord{
+		{
+			DNSName:    "bar.test-zone.example.org",
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+			},
+		},
+		{
+			DNSName:    "new.test-zone.example.org",
+			RecordType: endpoint.RecordTypeA,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey: "other-owner",
+			},
+		},
+		{
+			// the record has been deleted from the zone
+			DNSName:    "orphan.test-zone.example.org",
+			RecordType: endpoint.RecordTypeA,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey: "test-owner",
+			},
+		},
+	})
+	p := newConfigMapRegistryProvider(t)
+	r, err := NewConfigMapRegistry(p, "test-owner", client, "default", "external-dns-registry", time.Hour)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []endpoint.EndpointKey{{DNSName: "orphan.test-zone.example.org", RecordType: endpoint.RecordTypeA}}, r.OrphanedEntries())
+
+	var bar *endpoint.Endpoint
+	for _, record := range records {
+		if record.DNSName == "bar.test-zone.example.org" {
+			bar = record
+		}
+	}
+	require.NotNil(t, bar)
+	updated := bar.DeepCopy()
+	updated.Targets = endpoint.Targets{"other-domain.com"}
+	updated.Labels[endpoint.ResourceLabelKey] = "ingress/default/other-ingress"
+	qux := endpoint.NewEndpoint("qux.test-zone.example.org", endpoint.RecordTypeA, "3.3.3.3")
+	qux.Labels[endpoint.ResourceLabelKey] = "ingress/default/qux"
+
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			qux,
+			// owned by another instance
+			endpoint.NewEndpoint("new.test-zone.example.org", endpoint.RecordTypeA, "4.4.4.4"),
+		},
+		UpdateOld: []*endpoint.Endpoint{bar},
+		UpdateNew: []*endpoint.Endpoint{updated},
+	}))
+
+	assert.Equal(t, []configMapRecord{
+		{
+			DNSName:    "bar.test-zone.example.org",
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/other-ingress",
+			},
+		},
+		{
+			DNSName:    "new.test-zone.example.org",
+			RecordType: endpoint.RecordTypeA,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey: "other-owner",
+			},
+		},
+		{
+			DNSName:    "qux.test-zone.example.org",
+			RecordType: endpoint.RecordTypeA,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/qux",
+			},
+		},
+	}, readConfigMapRecords(t, client))
+
+	zoneRecords, err := p.Records(ctx)
+	require.NoError(t, err)
+	names := []string{}
+	for _, record := range zoneRecords {
+		names = append(names, record.DNSName)
+	}
+	assert.Contains(t, names, "qux.test-zone.example.org")
+	assert.NotContains(t, names, "new.test-zone.example.org")
+
+	// the cache contains the applied changes
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	// deletions release the ownership
+	qux = nil
+	for _, record := range records {
+		if record.DNSName == "qux.test-zone.example.org" {
+			qux = record
+		}
+	}
+	require.NotNil(t, qux)
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Delete: []*endpoint.Endpoint{qux},
+	}))
+	stored := readConfigMapRecords(t, client)
+	assert.Len(t, stored, 2)
+	for _, record := range stored {
+		assert.NotEqual(t, "qux.test-zone.example.org", record.DNSName)
+	}
+}
+
+func TestConfigMapRegistryApplyChangesCreatesConfigMap(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	r, err := NewConfigMapRegistry(newConfigMapRegistryProvider(t), "test-owner", client, "default", "external-dns-registry", 0)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = r.Records(ctx)
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("qux.test-zone.example.org", endpoint.RecordTypeA, "3.3.3.3"),
+		},
+	}))
+
+	assert.Equal(t, []configMapRecord{
+		{
+			DNSName:    "qux.test-zone.example.org",
+			RecordType: endpoint.RecordTypeA,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey: "test-owner",
+			},
+		},
+	}, readConfigMapRecords(t, client))
+}
+
+func newConfigMapRegistryProvider(t *testing.T) provider.Provider {
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+	require.NoError(t, p.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("foo.test-zone.example.org", endpoint.RecordTypeCNAME, "foo.loadbalancer.com"),
+			endpoint.NewEndpoint("bar.test-zone.example.org", endpoint.RecordTypeCNAME, "my-domain.com"),
+		},
+	}))
+	return p
+}
+
+func newConfigMapRegistryClient(t *testing.T, records []configMapRecord) kubernetes.Interface {
+	data, err := json.Marshal(records)
+	require.NoError(t, err)
+	return fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "external-dns-registry",
+		},
+		Data: map[string]string{configMapRecordsKey: string(data)},
+	})
+}
+
+func readConfigMapRecords(t *testing.T, client kubernetes.Interface) []configMapRecord {
+	cm, err := client.CoreV1().ConfigMaps("default").Get(context.Background(), "external-dns-registry", metav1.GetOptions{})
+	require.NoError(t, err)
+	var records []configMapRecord
+	require.NoError(t, json.Unmarshal([]byte(cm.Data[configMapRecordsKey]), &records))
+	return records
+}
@@ -0,0 +1,431 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// consulRecord is the ownership of a single DNS record as stored in a Consul KV entry.
+type consulRecord struct {
+	DNSName       string          `json:"dnsName"`
+	RecordType    string          `json:"recordType"`
+	SetIdentifier string          `json:"setIdentifier,omitempty"`
+	Labels        endpoint.Labels `json:"labels"`
+}
+
+// consulEntry is the ownership of a record along with the index of the KV entry it has been read from.
+type consulEntry struct {
+	labels      endpoint.Labels
+	modifyIndex uint64
+}
+
+// ConsulRegistry implements registry interface with ownership implemented via Consul KV entries.
+// Every record has its own entry below the prefix, entries are written with check-and-set, so an
+// owner can't overwrite the ownership claimed by another owner since the entries were read.
+type ConsulRegistry struct {
+	provider provider.Provider
+	ownerID  string // refers to the owner id of the current instance
+
+	client *consulClient
+	prefix string
+
+	// the entries of all owners as last read, nil if they have to be read again
+	entries        map[endpoint.EndpointKey]consulEntry
+	orphanedLabels map[endpoint.EndpointKey]consulEntry
+
+	// cache the records in memory and update on an interval instead.
+	recordsCache            []*endpoint.Endpoint
+	recordsCacheRefreshTime time.Time
+	cacheInterval           time.Duration
+}
+
+// NewConsulRegistry returns a new ConsulRegistry object.
+func NewConsulRegistry(provider provider.Provider, ownerID, address, token, prefix string, cacheInterval time.Duration) (*ConsulRegistry, error) {
+	if ownerID == "" {
+		return nil, errors.New("owner id cannot be empty")
+	}
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return nil, errors.New("consul prefix cannot be empty")
+	}
+	if _, err := url.Parse(address); err != nil || address == "" {
+		return nil, fmt.Errorf("invalid consul address %q", address)
+	}
+
+	return &ConsulRegistry{
+		provider: provider,
+		ownerID:  ownerID,
+		client: &consulClient{
+			address: strings.TrimSuffix(address, "/"),
+			token:   token,
+			client:  http.DefaultClient,
+		},
+		prefix:        prefix,
+		cacheInterval: cacheInterval,
+	}, nil
+}
+
+func (im *ConsulRegistry) GetDomainFilter() endpoint.DomainFilterInterface {
+	return im.provider.GetDomainFilter()
+}
+
+func (im *ConsulRegistry) OwnerID() string {
+	return im.ownerID
+}
+
+// OrphanedEntries returns the keys of the owned entries whose records didn't exist on the last read.
+func (im *ConsulRegistry) OrphanedEntries() []endpoint.EndpointKey {
+	return sortedKeys(im.orphanedLabels)
+}
+
+// Records returns the current records from the registry.
+func (im *ConsulRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	// If we have the zones cached AND we have refreshed the cache since the
+	// last given interval, then just use the cached results.
+	if im.recordsCache != nil && time.Since(im.recordsCacheRefreshTime) < im.cacheInterval {
+		log.Debug("Using cached records.")
+		countCacheLookup("consul", true)
+		return im.recordsCache, nil
+	}
+	if im.cacheInterval > 0 {
+		countCacheLookup("consul", false)
+	}
+
+	if err := im.readEntries(ctx); err != nil {
+		return nil, err
+	}
+
+	records, err := im.provider.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orphanedLabels := map[endpoint.EndpointKey]consulEntry{}
+	for key, entry := range im.entries {
+		if entry.labels[endpoint.OwnerLabelKey] == im.ownerID {
+			orphanedLabels[key] = entry
+		}
+	}
+	for _, record := range records {
+		key := record.Key()
+		if entry, ok := im.entries[key]; ok {
+			record.Labels = maps.Clone(entry.labels)
+			delete(orphanedLabels, key)
+		} else {
+			record.Labels = endpoint.NewLabels()
+		}
+	}
+	im.orphanedLabels = orphanedLabels
+
+	// Update the cache.
+	if im.cacheInterval > 0 {
+		im.recordsCache = records
+		im.recordsCacheRefreshTime = time.Now()
+	}
+
+	return records, nil
+}
+
+// ApplyChanges claims the ownership of created and updated records in Consul, updates the DNS
+// provider and then releases the ownership of deleted records.
+func (im *ConsulRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	timer := startLabelPersistenceTimer("consul")
+	defer timer.observe()
+
+	filteredChanges := &plan.Changes{
+		Create:    make([]*endpoint.Endpoint, 0, len(changes.Create)),
+		UpdateNew: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateNew),
+		UpdateOld: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateOld),
+		Delete:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete),
+	}
+	if im.entries == nil {
+		if err := im.readEntries(ctx); err != nil {
+			return err
+		}
+	}
+	// the indexes of written entries are unknown, so the entries are read again on the next change
+	defer im.reset(false)
+
+	for _, r := range changes.Create {
+		key := r.Key()
+		entry, exists := im.entries[key]
+		if owner := entry.labels[endpoint.OwnerLabelKey]; owner != "" && owner != im.ownerID {
+			// Another owner has an orphaned ownership record.
+			log.Infof("Skipping endpoint %v because owner does not match", r)
+			continue
+		}
+		if r.Labels == nil {
+			r.Labels = make(map[string]string)
+		}
+		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
+
+		if !exists || !maps.Equal(entry.labels, r.Labels) {
+			ok, err := im.client.put(ctx, im.entryKey(key), consulRecordOf(key, r.Labels), entry.modifyIndex)
+			if err != nil {
+				im.reset(true)
+				return err
+			}
+			if !ok {
+				// We lost a race with a different owner.
+				log.Infof("Skipping endpoint %v because its ownership has been claimed concurrently", r)
+				continue
+			}
+		}
+		filteredChanges.Create = append(filteredChanges.Create, r)
+		delete(im.orphanedLabels, key)
+		if im.cacheInterval > 0 {
+			im.addToCache(r)
+		}
+	}
+
+	for _, r := range filteredChanges.UpdateOld {
+		// remove old version of record from cache
+		if im.cacheInterval > 0 {
+			im.removeFromCache(r)
+		}
+	}
+
+	for _, r := range filteredChanges.UpdateNew {
+		key := r.Key()
+		entry, exists := im.entries[key]
+		if !exists || !maps.Equal(entry.labels, r.Labels) {
+			ok, err := im.client.put(ctx, im.entryKey(key), consulRecordOf(key, r.Labels), entry.modifyIndex)
+			if err == nil && !ok {
+				err = provider.NewSoftError(fmt.Errorf("consul entry %s has been modified concurrently", im.entryKey(key)))
+			}
+			if err != nil {
+				im.reset(true)
+				return err
+			}
+		}
+		// add new version of record to caches
+		if im.cacheInterval > 0 {
+			im.addToCache(r)
+		}
+	}
+
+	// When caching is enabled, disable the provider from using the cache.
+	if im.cacheInterval > 0 {
+		ctx = context.WithValue(ctx, provider.RecordsContextKey, nil)
+	}
+	if err := timer.applyChanges(ctx, im.provider, filteredChanges); err != nil {
+		im.reset(true)
+		return err
+	}
+
+	releases := map[endpoint.EndpointKey]consulEntry{}
+	for _, r := range filteredChanges.Delete {
+		if entry, ok := im.entries[r.Key()]; ok {
+			releases[r.Key()] = entry
+		}
+		if im.cacheInterval > 0 {
+			im.removeFromCache(r)
+		}
+	}
+	maps.Copy(releases, im.orphanedLabels)
+	for key, entry := range releases {
+		ok, err := im.client.delete(ctx, im.entryKey(key), entry.modifyIndex)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			log.Warnf("Not releasing the ownership of %s %s because consul entry %s has been modified concurrently", key.RecordType, key.DNSName, im.entryKey(key))
+		}
+	}
+	return nil
+}
+
+// AdjustEndpoints modifies the endpoints as needed by the specific provider.
+func (im *ConsulRegistry) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	return im.provider.AdjustEndpoints(endpoints)
+}
+
+// reset drops the entries, and the cached records if they may be out of date, so the next
+// synchronization reads them again.
+func (im *ConsulRegistry) reset(records bool) {
+	im.entries = nil
+	im.orphanedLabels = nil
+	if records {
+		im.recordsCache = nil
+	}
+}
+
+// entryKey returns the key of the KV entry of a record, <prefix>/<dns name>/<record type>[/<set identifier>].
+func (im *ConsulRegistry) entryKey(key endpoint.EndpointKey) string {
+	entryKey := im.prefix + "/" + url.PathEscape(key.DNSName) + "/" + url.PathEscape(key.RecordType)
+	if key.SetIdentifier != "" {
+		entryKey += "/" + url.PathEscape(key.SetIdentifier)
+	}
+	return entryKey
+}
+
+func (im *ConsulRegistry) readEntries(ctx context.Context) error {
+	pairs, err := im.client.list(ctx, im.prefix+"/")
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[endpoint.EndpointKey]consulEntry, len(pairs))
+	for _, pair := range pairs {
+		var r consulRecord
+		if err := json.Unmarshal(pair.Value, &r); err != nil {
+			log.Warnf("Skipping consul entry %s with invalid value: %v", pair.Key, err)
+			continue
+		}
+		if r.Labels == nil {
+			r.Labels = endpoint.NewLabels()
+		}
+		key := endpoint.EndpointKey{DNSName: r.DNSName, RecordType: r.RecordType, SetIdentifier: r.SetIdentifier}
+		entries[key] = consulEntry{labels: r.Labels, modifyIndex: pair.ModifyIndex}
+	}
+	im.entries = entries
+	return nil
+}
+
+func consulRecordOf(key endpoint.EndpointKey, labels endpoint.Labels) consulRecord {
+	return consulRecord{
+		DNSName:       key.DNSName,
+		RecordType:    key.RecordType,
+		SetIdentifier: key.SetIdentifier,
+		Labels:        labels,
+	}
+}
+
+func (im *ConsulRegistry) addToCache(ep *endpoint.Endpoint) {
+	if im.recordsCache != nil {
+		im.recordsCache = append(im.recordsCache, ep)
+	}
+}
+
+func (im *ConsulRegistry) removeFromCache(ep *endpoint.Endpoint) {
+	if im.recordsCache == nil || ep == nil {
+		return
+	}
+
+	for i, e := range im.recordsCache {
+		if e.DNSName == ep.DNSName && e.RecordType == ep.RecordType && e.SetIdentifier == ep.SetIdentifier && e.Targets.Same(ep.Targets) {
+			// We found a match; delete the endpoint from the cache.
+			im.recordsCache = append(im.recordsCache[:i], im.recordsCache[i+1:]...)
+			return
+		}
+	}
+}
+
+// consulKVPair is a KV entry as returned by the Consul KV API.
+type consulKVPair struct {
+	Key         string
+	Value       []byte
+	ModifyIndex uint64
+}
+
+// consulClient is a minimal client of the Consul KV HTTP API.
+type consulClient struct {
+	address string
+	token   string
+	client  *http.Client
+}
+
+// list returns the entries below the prefix.
+func (c *consulClient) list(ctx context.Context, prefix string) ([]consulKVPair, error) {
+	body, status, err := c.do(ctx, http.MethodGet, prefix, url.Values{"recurse": {"true"}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	var pairs []consulKVPair
+	if err := json.Unmarshal(body, &pairs); err != nil {
+		return nil, fmt.Errorf("unmarshalling consul entries below %s: %w", prefix, err)
+	}
+	return pairs, nil
+}
+
+// put writes the entry if it hasn't been modified since the given index, 0 writes the entry only
+// if it doesn't exist. It returns false if the entry has been modified.
+func (c *consulClient) put(ctx context.Context, key string, record consulRecord, modifyIndex uint64) (bool, error) {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return false, fmt.Errorf("marshalling consul entry %s: %w", key, err)
+	}
+	return c.cas(ctx, http.MethodPut, key, modifyIndex, value)
+}
+
+// delete deletes the entry if it hasn't been modified since the given index. It returns false if
+// the entry has been modified.
+func (c *consulClient) delete(ctx context.Context, key string, modifyIndex uint64) (bool, error) {
+	return c.cas(ctx, http.MethodDelete, key, modifyIndex, nil)
+}
+
+func (c *consulClient) cas(ctx context.Context, method, key string, modifyIndex uint64, value []byte) (bool, error) {
+	body, _, err := c.do(ctx, method, key, url.Values{"cas": {strconv.FormatUint(modifyIndex, 10)}}, value)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(body)) == "true", nil
+}
+
+func (c *consulClient) do(ctx context.Context, method, key string, query url.Values, value []byte) ([]byte, int, error) {
+	u := c.address + "/v1/kv/" + key + "?" + query.Encode()
+	var reqBody io.Reader
+	if value != nil {
+		reqBody = bytes.NewReader(value)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return nil, 0, err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, provider.NewSoftError(fmt.Errorf("consul request %s %s: %w", method, key, err))
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, provider.NewSoftError(fmt.Errorf("reading consul response %s %s: %w", method, key, err))
+	}
+	if resp.StatusCode != http.StatusOK && !(method == http.MethodGet && resp.StatusCode == http.StatusNotFound) {
+		err := fmt.Errorf("consul request %s %s failed with status %d: %s", method, key, resp.StatusCode, strings.TrimSpace(string(body)))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			err = provider.NewSoftError(err)
+		}
+		return nil, 0, err
+	}
+	return body, resp.StatusCode, nil
+}
@@ -0,0 +1,345 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+func TestConsulRegistryNew(t *testing.T) {
+	p := newConfigMapRegistryProvider(t)
+
+	_, err := NewConsulRegistry(p, "test-owner", "http://127.0.0.1:8500", "", "external-dns/registry", time.Hour)
+	require.NoError(t, err)
+
+	_, err = NewConsulRegistry(p, "", "http://127.0.0.1:8500", "", "external-dns/registry", time.Hour)
+	require.EqualError(t, err, "owner id cannot be empty")
+
+	_, err = NewConsulRegistry(p, "test-owner", "http://127.0.0.1:8500", "", "/", time.Hour)
+	require.EqualError(t, err, "consul prefix cannot be empty")
+
+	_, err = NewConsulRegistry(p, "test-owner", "", "", "external-dns/registry", time.Hour)
+	require.EqualError(t, err, `invalid consul address ""`)
+}
+
+func TestConsulRegistryRecords(t *testing.T) {
+	kv := newFakeConsulKV(t, "secret")
+	kv.set(t, "external-dns/registry/bar.test-zone.example.org/CNAME", consulRecord{
+		DNSName:    "bar.test-zone.example.org",
+		RecordType: endpoint.RecordTypeCNAME,
+		Labels: endpoint.Labels{
+			endpoint.OwnerLabelKey:    "test-owner",
+			endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+		},
+	})
+	kv.set(t, "other-prefix/foo.test-zone.example.org/CNAME", consulRecord{
+		DNSName:    "foo.test-zone.example.org",
+		RecordType: endpoint.RecordTypeCNAME,
+		Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "test-owner"},
+	})
+	r, err := NewConsulRegistry(newConfigMapRegistryProvider(t), "test-owner", kv.server.URL, "secret", "/external-dns/registry/", 0)
+	require.NoError(t, err)
+
+	records, err := r.Records(context.Background())
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		{
+			DNSName:    "foo.test-zone.example.org",
+			Targets:    endpoint.Targets{"foo.loadbalancer.com"},
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey: "",
+			},
+		},
+		{
+			DNSName:    "bar.test-zone.example.org",
+			Targets:    endpoint.Targets{"my-domain.com"},
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+			},
+		},
+	}), "actual: %v", records)
+
+	// the token is required
+	r, err = NewConsulRegistry(newConfigMapRegistryProvider(t), "test-owner", kv.server.URL, "", "external-dns/registry", 0)
+	require.NoError(t, err)
+	_, err = r.Records(context.Background())
+	require.ErrorContains(t, err, "status 403")
+}
+
+func TestConsulRegistryApplyChanges(t *testing.T) {
+	kv := newFakeConsulKV(t, "")
+	kv.set(t, "external-dns/registry/bar.test-zone.example.org/CNAME", consulRecord{
+		DNSName:    "bar.test-zone.example.org",
+		RecordType: endpoint.RecordTypeCNAME,
+		Labels: endpoint.Labels{
+			endpoint.OwnerLabelKey:    "test-owner",
+			endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+		},
+	})
+	kv.set(t, "external-dns/registry/new.test-zone.example.org/A", consulRecord{
+		DNSName:    "new.test-zone.example.org",
+		RecordType: endpoint.RecordTypeA,
+		Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "other-owner"},
+	})
+	// the record has been deleted from the zone
+	kv.set(t, "external-dns/registry/orphan.test-zone.example.org/A", consulRecord{
+		DNSName:    "orphan.test-zone.example.org",
+		RecordType: endpoint.RecordTypeA,
+		Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "test-owner"},
+	})
+	p := newConfigMapRegistryProvider(t)
+	r, err := NewConsulRegistry(p, "test-owner", kv.server.URL, "", "external-dns/registry", time.Hour)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+
+	var bar *endpoint.Endpoint
+	for _, record := range records {
+		if record.DNSName == "bar.test-zone.example.org" {
+			bar = record
+		}
+	}
+	require.NotNil(t, bar)
+	updated := bar.DeepCopy()
+	updated.Targets = endpoint.Targets{"other-domain.com"}
+	updated.Labels[endpoint.ResourceLabelKey] = "ingress/default/other-ingress"
+	qux := endpoint.NewEndpoint("qux.test-zone.example.org", endpoint.RecordTypeA, "3.3.3.3").WithSetIdentifier("set/1")
+	qux.Labels[endpoint.ResourceLabelKey] = "ingress/default/qux"
+
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			qux,
+			// owned by another instance
+			endpoint.NewEndpoint("new.test-zone.example.org", endpoint.RecordTypeA, "4.4.4.4"),
+		},
+		UpdateOld: []*endpoint.Endpoint{bar},
+		UpdateNew: []*endpoint.Endpoint{updated},
+	}))
+
+	assert.Equal(t, map[string]consulRecord{
+		"external-dns/registry/bar.test-zone.example.org/CNAME": {
+			DNSName:    "bar.test-zone.example.org",
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/other-ingress",
+			},
+		},
+		"external-dns/registry/new.test-zone.example.org/A": {
+			DNSName:    "new.test-zone.example.org",
+			RecordType: endpoint.RecordTypeA,
+			Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "other-owner"},
+		},
+		"external-dns/registry/qux.test-zone.example.org/A/set%2F1": {
+			DNSName:       "qux.test-zone.example.org",
+			RecordType:    endpoint.RecordTypeA,
+			SetIdentifier: "set/1",
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/qux",
+			},
+		},
+	}, kv.records(t))
+
+	zoneRecords, err := p.Records(ctx)
+	require.NoError(t, err)
+	names := []string{}
+	for _, record := range zoneRecords {
+		names = append(names, record.DNSName)
+	}
+	assert.Contains(t, names, "qux.test-zone.example.org")
+	assert.NotContains(t, names, "new.test-zone.example.org")
+
+	// the cache contains the applied changes
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	// deletions release the ownership
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Delete: []*endpoint.Endpoint{qux},
+	}))
+	assert.Len(t, kv.records(t), 2)
+	assert.NotContains(t, kv.records(t), "external-dns/registry/qux.test-zone.example.org/A/set%2F1")
+}
+
+func TestConsulRegistryApplyChangesConcurrentModification(t *testing.T) {
+	kv := newFakeConsulKV(t, "")
+	kv.set(t, "external-dns/registry/bar.test-zone.example.org/CNAME", consulRecord{
+		DNSName:    "bar.test-zone.example.org",
+		RecordType: endpoint.RecordTypeCNAME,
+		Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "test-owner"},
+	})
+	p := newConfigMapRegistryProvider(t)
+	r, err := NewConsulRegistry(p, "test-owner", kv.server.URL, "", "external-dns/registry", 0)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+
+	// another instance claims the records after they have been read
+	kv.set(t, "external-dns/registry/qux.test-zone.example.org/A", consulRecord{
+		DNSName:    "qux.test-zone.example.org",
+		RecordType: endpoint.RecordTypeA,
+		Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "other-owner"},
+	})
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("qux.test-zone.example.org", endpoint.RecordTypeA, "3.3.3.3"),
+		},
+	}))
+	assert.Equal(t, "other-owner", kv.records(t)["external-dns/registry/qux.test-zone.example.org/A"].Labels[endpoint.OwnerLabelKey])
+	zoneRecords, err := p.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, zoneRecords, 2)
+
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	var bar *endpoint.Endpoint
+	for _, record := range records {
+		if record.DNSName == "bar.test-zone.example.org" {
+			bar = record
+		}
+	}
+	require.NotNil(t, bar)
+	kv.set(t, "external-dns/registry/bar.test-zone.example.org/CNAME", consulRecord{
+		DNSName:    "bar.test-zone.example.org",
+		RecordType: endpoint.RecordTypeCNAME,
+		Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "other-owner"},
+	})
+	updated := bar.DeepCopy()
+	updated.Labels[endpoint.ResourceLabelKey] = "ingress/default/other-ingress"
+	err = r.ApplyChanges(ctx, &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{bar},
+		UpdateNew: []*endpoint.Endpoint{updated},
+	})
+	require.ErrorIs(t, err, provider.SoftError)
+	assert.Equal(t, "other-owner", kv.records(t)["external-dns/registry/bar.test-zone.example.org/CNAME"].Labels[endpoint.OwnerLabelKey])
+}
+
+// fakeConsulKV implements the parts of the Consul KV HTTP API used by the ConsulRegistry.
+type fakeConsulKV struct {
+	server *httptest.Server
+	token  string
+
+	mutex   sync.Mutex
+	index   uint64
+	entries map[string]consulKVPair
+}
+
+func newFakeConsulKV(t *testing.T, token string) *fakeConsulKV {
+	kv := &fakeConsulKV{token: token, entries: map[string]consulKVPair{}}
+	kv.server = httptest.NewServer(http.HandlerFunc(kv.serveHTTP))
+	t.Cleanup(kv.server.Close)
+	return kv
+}
+
+func (kv *fakeConsulKV) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Header.Get("X-Consul-Token") != kv.token {
+		http.Error(w, "Permission denied", http.StatusForbidden)
+		return
+	}
+	key := strings.TrimPrefix(req.URL.EscapedPath(), "/v1/kv/")
+
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+
+	cas := func() bool {
+		index, err := strconv.ParseUint(req.URL.Query().Get("cas"), 10, 64)
+		return err == nil && index == kv.entries[key].ModifyIndex
+	}
+	switch req.Method {
+	case http.MethodGet:
+		pairs := []consulKVPair{}
+		for k, pair := range kv.entries {
+			if strings.HasPrefix(k, key) {
+				pairs = append(pairs, pair)
+			}
+		}
+		if len(pairs) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+		_ = json.NewEncoder(w).Encode(pairs)
+	case http.MethodPut:
+		value, _ := io.ReadAll(req.Body)
+		if !cas() {
+			_, _ = w.Write([]byte("false"))
+			return
+		}
+		kv.index++
+		kv.entries[key] = consulKVPair{Key: key, Value: value, ModifyIndex: kv.index}
+		_, _ = w.Write([]byte("true"))
+	case http.MethodDelete:
+		if !cas() {
+			_, _ = w.Write([]byte("false"))
+			return
+		}
+		delete(kv.entries, key)
+		_, _ = w.Write([]byte("true"))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (kv *fakeConsulKV) set(t *testing.T, key string, record consulRecord) {
+	value, err := json.Marshal(record)
+	require.NoError(t, err)
+
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+	kv.index++
+	kv.entries[key] = consulKVPair{Key: key, Value: value, ModifyIndex: kv.index}
+}
+
+func (kv *fakeConsulKV) records(t *testing.T) map[string]consulRecord {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+
+	records := map[string]consulRecord{}
+	for key, pair := range kv.entries {
+		var record consulRecord
+		require.NoError(t, json.Unmarshal(pair.Value, &record))
+		records[key] = record
+	}
+	return records
+}
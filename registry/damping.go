@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// dampingWindow is the period over which the target changes of a record are counted.
+const dampingWindow = time.Hour
+
+var dampedUpdatesTotal = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "external_dns",
+		Subsystem: "registry",
+		Name:      "damped_updates_total",
+		Help:      "Number of updates held back because the record changed its targets too often.",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(dampedUpdatesTotal)
+}
+
+// DampingRegistry wraps a registry and holds back target changes of records which flap, e.g.
+// because two controllers fight over a name or a load balancer keeps changing its addresses.
+// Once a record changed its targets maxChanges times within an hour, further target changes
+// are dropped from the plan by FilterChanges and the record keeps its current value until the
+// older changes age out.
+type DampingRegistry struct {
+	Registry
+	maxChanges int
+	now        func() time.Time
+
+	mutex   sync.Mutex
+	history map[endpoint.EndpointKey][]time.Time
+}
+
+// NewDampingRegistry returns a DampingRegistry allowing maxChanges target changes per record and hour.
+func NewDampingRegistry(registry Registry, maxChanges int) *DampingRegistry {
+	return &DampingRegistry{
+		Registry:   registry,
+		maxChanges: maxChanges,
+		now:        time.Now,
+		history:    map[endpoint.EndpointKey][]time.Time{},
+	}
+}
+
+// FilterChanges drops the target changes of flapping records.
+func (r *DampingRegistry) FilterChanges(changes *plan.Changes) *plan.Changes {
+	updates := changes.TargetUpdates()
+	if updates == nil {
+		return changes
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.now()
+	filtered := &plan.Changes{
+		Create: changes.Create,
+		Delete: changes.Delete,
+	}
+	for _, update := range updates {
+		if len(update.Added) > 0 || len(update.Removed) > 0 {
+			if count := r.recentChanges(update.New.Key(), now); count >= r.maxChanges {
+				log.Warnf("Record %s %s changed its targets %d times within the last %s, holding its current targets %v instead of %v",
+					update.New.DNSName, update.New.RecordType, count, dampingWindow, update.Old.Targets, update.New.Targets)
+				dampedUpdatesTotal.Inc()
+				continue
+			}
+		}
+		filtered.UpdateOld = append(filtered.UpdateOld, update.Old)
+		filtered.UpdateNew = append(filtered.UpdateNew, update.New)
+	}
+	return filtered
+}
+
+// ApplyChanges propagates the changes to the wrapped registry and counts the target changes of the applied
+// updates. The changes are expected to be filtered with FilterChanges.
+func (r *DampingRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	if err := r.Registry.ApplyChanges(ctx, changes); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.now()
+	for _, update := range changes.TargetUpdates() {
+		if len(update.Added) > 0 || len(update.Removed) > 0 {
+			key := update.New.Key()
+			r.recentChanges(key, now)
+			r.history[key] = append(r.history[key], now)
+		}
+	}
+	return nil
+}
+
+// recentChanges returns the number of target changes of the record within the damping window
+// and forgets about older ones.
+func (r *DampingRegistry) recentChanges(key endpoint.EndpointKey, now time.Time) int {
+	changes := r.history[key]
+	i := 0
+	for i < len(changes) && now.Sub(changes[i]) >= dampingWindow {
+		i++
+	}
+	if i == len(changes) {
+		delete(r.history, key)
+		return 0
+	}
+	r.history[key] = changes[i:]
+	return len(changes) - i
+}
+
+// OrphanedEntries returns the orphaned entries of the wrapped registry, if it reports them.
+func (r *DampingRegistry) OrphanedEntries() []endpoint.EndpointKey {
+	return orphanedEntries(r.Registry)
+}
+
+// MaintenanceChanges returns the maintenance changes of the wrapped registry, if it maintains records of its own.
+func (r *DampingRegistry) MaintenanceChanges() *plan.Changes {
+	return maintenanceChanges(r.Registry)
+}
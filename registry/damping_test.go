@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+var (
+	_ Registry     = &DampingRegistry{}
+	_ ChangeFilter = &DampingRegistry{}
+)
+
+func TestDampingRegistry(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone("org")
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("flapping.example.org", endpoint.RecordTypeCNAME, "lb-0.example.com"),
+			endpoint.NewEndpoint("stable.example.org", endpoint.RecordTypeCNAME, "lb.example.com"),
+		},
+	}))
+
+	noop, err := NewNoopRegistry(p)
+	require.NoError(t, err)
+	r := NewDampingRegistry(noop, 2)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	targets := func(name string) endpoint.Targets {
+		records, err := r.Records(ctx)
+		require.NoError(t, err)
+		for _, record := range records {
+			if record.DNSName == name {
+				return record.Targets
+			}
+		}
+		return nil
+	}
+	apply := func(changes *plan.Changes) *plan.Changes {
+		filtered := r.FilterChanges(changes)
+		require.NoError(t, r.ApplyChanges(ctx, filtered))
+		return filtered
+	}
+	flip := func(target string) *plan.Changes {
+		current := endpoint.NewEndpoint("flapping.example.org", endpoint.RecordTypeCNAME, targets("flapping.example.org")...)
+		desired := endpoint.NewEndpoint("flapping.example.org", endpoint.RecordTypeCNAME, target)
+		return apply(&plan.Changes{
+			UpdateOld: []*endpoint.Endpoint{current},
+			UpdateNew: []*endpoint.Endpoint{desired},
+		})
+	}
+
+	flip("lb-1.example.com")
+	now = now.Add(10 * time.Minute)
+	flip("lb-2.example.com")
+	assert.Equal(t, endpoint.Targets{"lb-2.example.com"}, targets("flapping.example.org"))
+
+	// the third change within an hour is held back
+	before := testutil.ToFloat64(dampedUpdatesTotal)
+	now = now.Add(10 * time.Minute)
+	assert.Empty(t, flip("lb-3.example.com").UpdateNew)
+	assert.Equal(t, endpoint.Targets{"lb-2.example.com"}, targets("flapping.example.org"))
+	assert.Equal(t, before+1, testutil.ToFloat64(dampedUpdatesTotal))
+
+	// other records are not affected
+	apply(&plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpoint("stable.example.org", endpoint.RecordTypeCNAME, "lb.example.com")},
+		UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpoint("stable.example.org", endpoint.RecordTypeCNAME, "new-lb.example.com")},
+	})
+	assert.Equal(t, endpoint.Targets{"new-lb.example.com"}, targets("stable.example.org"))
+
+	// once the first change is older than an hour, the record may change again
+	now = now.Add(45 * time.Minute)
+	flip("lb-3.example.com")
+	assert.Equal(t, endpoint.Targets{"lb-3.example.com"}, targets("flapping.example.org"))
+}
+
+func TestDampingRegistryIgnoresTTLChanges(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone("org")
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "lb.example.com")},
+	}))
+
+	noop, err := NewNoopRegistry(p)
+	require.NoError(t, err)
+	r := NewDampingRegistry(noop, 1)
+
+	for ttl := endpoint.TTL(60); ttl <= 180; ttl += 60 {
+		changes := r.FilterChanges(&plan.Changes{
+			UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "lb.example.com")},
+			UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeCNAME, ttl, "lb.example.com")},
+		})
+		require.Len(t, changes.UpdateNew, 1)
+		require.NoError(t, r.ApplyChanges(ctx, changes))
+	}
+	assert.Empty(t, r.history)
+}
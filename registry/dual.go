@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// DualRegistry migrates the ownership of the records from one registry to another while ExternalDNS keeps running.
+// The ownership is read from the primary registry, the changes are applied to both registries. The secondary
+// registry has to use a provider returned by NewOwnershipProvider, so the records themselves are changed once.
+// Whenever changes are applied, the ownership of the records which the secondary registry doesn't have yet is
+// copied to it first, so it is fully populated after a while, which is reported by
+// external_dns_registry_dual_secondary_missing_records, and can replace the primary registry. In dry-run mode the
+// secondary registry is never written.
+type DualRegistry struct {
+	primary   Registry
+	secondary Registry
+	dryRun    bool
+
+	// records are the records last read from the primary registry
+	records []*endpoint.Endpoint
+}
+
+// NewDualRegistry returns a DualRegistry reading from the primary registry and writing to both registries, unless
+// dryRun is set.
+func NewDualRegistry(primary, secondary Registry, dryRun bool) (*DualRegistry, error) {
+	if primary.OwnerID() != secondary.OwnerID() {
+		return nil, fmt.Errorf("owner %q of the primary registry differs from owner %q of the secondary registry", primary.OwnerID(), secondary.OwnerID())
+	}
+	return &DualRegistry{primary: primary, secondary: secondary, dryRun: dryRun}, nil
+}
+
+func (d *DualRegistry) GetDomainFilter() endpoint.DomainFilterInterface {
+	return d.primary.GetDomainFilter()
+}
+
+func (d *DualRegistry) OwnerID() string {
+	return d.primary.OwnerID()
+}
+
+// Records returns the records of the primary registry. They are kept to copy their ownership to the secondary
+// registry on the next ApplyChanges.
+func (d *DualRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	records, err := d.primary.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d.records = records
+	return records, nil
+}
+
+// ApplyChanges copies the ownership of the records last read which is missing in the secondary registry, applies
+// the changes with the primary registry and then, if successful, with the secondary registry. Failures of the
+// secondary registry are logged, they never fail the synchronization.
+func (d *DualRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	if d.dryRun {
+		log.Debug("Not writing the secondary registry in dry-run mode")
+		return d.primary.ApplyChanges(ctx, changes)
+	}
+
+	// the ownership of the records is copied before the changes, which may update or delete these records
+	if err := d.backfill(ctx, d.records); err != nil {
+		log.Errorf("Failed to copy the ownership of the records to the secondary registry: %v", err)
+	}
+
+	// the registries add their own labels and records to the changes
+	secondaryChanges := &plan.Changes{
+		Create:    copyEndpoints(changes.Create),
+		UpdateOld: copyEndpoints(changes.UpdateOld),
+		UpdateNew: copyEndpoints(changes.UpdateNew),
+		Delete:    copyEndpoints(changes.Delete),
+	}
+	if err := d.primary.ApplyChanges(ctx, changes); err != nil {
+		return err
+	}
+	if err := d.secondary.ApplyChanges(ctx, secondaryChanges); err != nil {
+		dualSecondaryWriteFailuresTotal.Inc()
+		log.Errorf("Failed to apply the changes to the secondary registry: %v", err)
+	}
+	return nil
+}
+
+func (d *DualRegistry) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	return d.primary.AdjustEndpoints(endpoints)
+}
+
+// backfill copies the ownership of the records owned by us to the secondary registry unless it has it already.
+func (d *DualRegistry) backfill(ctx context.Context, records []*endpoint.Endpoint) error {
+	existing, err := d.secondary.Records(ctx)
+	if err != nil {
+		return fmt.Errorf("reading the secondary registry: %w", err)
+	}
+	changes, _ := ownershipChanges(records, existing, d.OwnerID())
+	dualSecondaryMissingRecords.Set(float64(len(changes.Create)))
+	if !changes.HasChanges() {
+		return nil
+	}
+	log.Infof("Copying the ownership of %d records to the secondary registry", len(changes.Create))
+	if err := d.secondary.ApplyChanges(ctx, changes); err != nil {
+		dualSecondaryWriteFailuresTotal.Inc()
+		return fmt.Errorf("writing the secondary registry: %w", err)
+	}
+	return nil
+}
+
+func copyEndpoints(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	if endpoints == nil {
+		return nil
+	}
+	copied := make([]*endpoint.Endpoint, len(endpoints))
+	for i, ep := range endpoints {
+		copied[i] = ep.DeepCopy()
+	}
+	return copied
+}
+
+// OrphanedEntries returns the orphaned entries of the primary registry, if it reports them.
+func (d *DualRegistry) OrphanedEntries() []endpoint.EndpointKey {
+	return orphanedEntries(d.primary)
+}
+
+// MaintenanceChanges returns the maintenance changes of the primary registry, if it maintains records of its own.
+func (d *DualRegistry) MaintenanceChanges() *plan.Changes {
+	return maintenanceChanges(d.primary)
+}
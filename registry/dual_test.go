@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+func TestDualRegistry(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+
+	primary, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, nil, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, primary.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner")},
+	}))
+
+	client := fake.NewSimpleClientset()
+	secondary, err := NewConfigMapRegistry(NewOwnershipProvider(p), "owner", client, "default", "external-dns-registry", 0)
+	require.NoError(t, err)
+	r, err := NewDualRegistry(primary, secondary, false)
+	require.NoError(t, err)
+
+	// reading never writes the secondary registry
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+	assertNoConfigMap(t, client)
+
+	// the ownership of the existing record is copied when changes are applied
+	baz := newEndpointWithOwner("baz.test-zone.example.org", "1.2.3.5", endpoint.RecordTypeA, "")
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{baz}}))
+	assert.Equal(t, 1.0, testutil.ToFloat64(dualSecondaryMissingRecords))
+	assert.Equal(t, []configMapRecord{
+		{DNSName: "baz.test-zone.example.org", RecordType: endpoint.RecordTypeA, Labels: endpoint.Labels{endpoint.OwnerLabelKey: "owner"}},
+		{DNSName: "foo.test-zone.example.org", RecordType: endpoint.RecordTypeA, Labels: endpoint.Labels{endpoint.OwnerLabelKey: "owner"}},
+	}, readConfigMapRecords(t, client))
+
+	// changes are applied to both registries, the records themselves only once
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	bar := newEndpointWithOwner("bar.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, "")
+	bar.Labels[endpoint.ResourceLabelKey] = "ingress/default/bar"
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{bar},
+		Delete: records,
+	}))
+	assert.Equal(t, 0.0, testutil.ToFloat64(dualSecondaryMissingRecords))
+	assert.Equal(t, []configMapRecord{
+		{DNSName: "bar.test-zone.example.org", RecordType: endpoint.RecordTypeA, Labels: endpoint.Labels{endpoint.OwnerLabelKey: "owner", endpoint.ResourceLabelKey: "ingress/default/bar"}},
+	}, readConfigMapRecords(t, client))
+
+	all, err := p.Records(ctx)
+	require.NoError(t, err)
+	var names []string
+	for _, record := range all {
+		names = append(names, record.DNSName+" "+record.RecordType)
+	}
+	assert.ElementsMatch(t, []string{
+		"bar.test-zone.example.org A",
+		"bar.test-zone.example.org TXT",
+		"a-bar.test-zone.example.org TXT",
+	}, names)
+}
+
+func TestDualRegistryDifferentOwners(t *testing.T) {
+	p := newConfigMapRegistryProvider(t)
+	primary, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, nil, false, nil)
+	require.NoError(t, err)
+	secondary, err := NewConfigMapRegistry(NewOwnershipProvider(p), "other", fake.NewSimpleClientset(), "default", "external-dns-registry", 0)
+	require.NoError(t, err)
+
+	_, err = NewDualRegistry(primary, secondary, false)
+	assert.Error(t, err)
+}
+
+func TestDualRegistryDryRun(t *testing.T) {
+	ctx := context.Background()
+	p := newConfigMapRegistryProvider(t)
+	primary, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, nil, false, nil)
+	require.NoError(t, err)
+	client := fake.NewSimpleClientset()
+	secondary, err := NewConfigMapRegistry(NewOwnershipProvider(p), "owner", client, "default", "external-dns-registry", 0)
+	require.NoError(t, err)
+	r, err := NewDualRegistry(primary, secondary, true)
+	require.NoError(t, err)
+
+	_, err = r.Records(ctx)
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{newEndpointWithOwner("new.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "")},
+	}))
+	assertNoConfigMap(t, client)
+}
+
+// failingRegistry is a registry whose reads and writes fail.
+type failingRegistry struct {
+	Registry
+}
+
+func (failingRegistry) Records(context.Context) ([]*endpoint.Endpoint, error) {
+	return nil, errors.New("records failed")
+}
+
+func (failingRegistry) ApplyChanges(context.Context, *plan.Changes) error {
+	return errors.New("apply failed")
+}
+
+func TestDualRegistrySecondaryFailure(t *testing.T) {
+	ctx := context.Background()
+	p := newConfigMapRegistryProvider(t)
+	primary, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, nil, false, nil)
+	require.NoError(t, err)
+	noop, err := NewNoopRegistry(p)
+	require.NoError(t, err)
+	r := &DualRegistry{primary: primary, secondary: failingRegistry{Registry: noop}}
+
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	failures := testutil.ToFloat64(dualSecondaryWriteFailuresTotal)
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{newEndpointWithOwner("new.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "")},
+	}))
+	assert.Equal(t, failures+1, testutil.ToFloat64(dualSecondaryWriteFailuresTotal))
+}
+
+// assertNoConfigMap asserts that the ConfigMap registry has never been written.
+func assertNoConfigMap(t *testing.T, client kubernetes.Interface) {
+	_, err := client.CoreV1().ConfigMaps("default").Get(context.Background(), "external-dns-registry", metav1.GetOptions{})
+	assert.True(t, k8serrors.IsNotFound(err), "unexpected ConfigMap: %v", err)
+}
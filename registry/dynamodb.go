@@ -20,6 +20,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -60,6 +62,9 @@ type DynamoDBRegistry struct {
 	// cache the dynamodb records owned by us.
 	labels         map[endpoint.EndpointKey]endpoint.Labels
 	orphanedLabels sets.Set[endpoint.EndpointKey]
+	// versions of the dynamodb records owned by us, which the updates are conditional on.
+	// Records written before the versioning have no version, which is 0.
+	versions map[endpoint.EndpointKey]int64
 
 	// cache the records in memory and update on an interval instead.
 	recordsCache            []*endpoint.Endpoint
@@ -72,6 +77,10 @@ const dynamodbAttributeMigrate = "dynamodb/needs-migration"
 // DynamoDB allows a maximum batch size of 25 items.
 var dynamodbMaxBatchSize uint8 = 25
 
+// dynamodbMaxConflictRetries is the number of times an update failing because the record has been changed
+// since it was read is retried with the current version of the record.
+const dynamodbMaxConflictRetries = 3
+
 // NewDynamoDBRegistry returns a new DynamoDBRegistry object.
 func NewDynamoDBRegistry(provider provider.Provider, ownerID string, dynamodbAPI DynamoDBAPI, table string, txtPrefix, txtSuffix, txtWildcardReplacement string, managedRecordTypes, excludeRecordTypes []string, txtEncryptAESKey []byte, cacheInterval time.Duration) (*DynamoDBRegistry, error) {
 	if ownerID == "" {
@@ -91,6 +100,9 @@ func NewDynamoDBRegistry(provider provider.Provider, ownerID string, dynamodbAPI
 	}
 
 	mapper := newaffixNameMapper(txtPrefix, txtSuffix, txtWildcardReplacement)
+	if mapper.zoneInAffix() {
+		mapper = mapper.withZones(provider.GetDomainFilter())
+	}
 
 	return &DynamoDBRegistry{
 		provider:            provider,
@@ -114,14 +126,23 @@ func (im *DynamoDBRegistry) OwnerID() string {
 	return im.ownerID
 }
 
+// OrphanedEntries returns the keys of the owned entries whose records didn't exist on the last read.
+func (im *DynamoDBRegistry) OrphanedEntries() []endpoint.EndpointKey {
+	return sortedKeys(im.orphanedLabels)
+}
+
 // Records returns the current records from the registry.
 func (im *DynamoDBRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
 	// If we have the zones cached AND we have refreshed the cache since the
 	// last given interval, then just use the cached results.
 	if im.recordsCache != nil && time.Since(im.recordsCacheRefreshTime) < im.cacheInterval {
 		log.Debug("Using cached records.")
+		countCacheLookup("dynamodb", true)
 		return im.recordsCache, nil
 	}
+	if im.cacheInterval > 0 {
+		countCacheLookup("dynamodb", false)
+	}
 
 	if im.labels == nil {
 		if err := im.readLabels(ctx); err != nil {
@@ -148,7 +169,7 @@ func (im *DynamoDBRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint,
 
 			if record.RecordType == endpoint.RecordTypeTXT {
 				// We simply assume that TXT records for the TXT registry will always have only one target.
-				if labels, err := endpoint.NewLabelsFromString(record.Targets[0], im.txtEncryptAESKey); err == nil {
+				if labels, err := endpoint.NewLabelsFromString(endpoint.JoinTXTStrings(record.Targets[0]), im.txtEncryptAESKey); err == nil {
 					endpointName, recordType := im.mapper.toEndpointName(record.DNSName)
 					key := endpoint.EndpointKey{
 						DNSName:       endpointName,
@@ -219,8 +240,11 @@ func (im *DynamoDBRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint,
 
 // ApplyChanges updates the DNS provider and DynamoDB table with the changes.
 func (im *DynamoDBRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	timer := startLabelPersistenceTimer("dynamodb")
+	defer timer.observe()
+
 	filteredChanges := &plan.Changes{
-		Create:    changes.Create,
+		Create:    slices.Clone(changes.Create),
 		UpdateNew: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateNew),
 		UpdateOld: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateOld),
 		Delete:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete),
@@ -250,6 +274,7 @@ func (im *DynamoDBRegistry) ApplyChanges(ctx context.Context, changes *plan.Chan
 
 	for _, r := range filteredChanges.Delete {
 		delete(im.labels, r.Key())
+		delete(im.versions, r.Key())
 		if im.cacheInterval > 0 {
 			im.removeFromCache(r)
 		}
@@ -287,6 +312,7 @@ func (im *DynamoDBRegistry) ApplyChanges(ctx context.Context, changes *plan.Chan
 		}
 	}
 
+	var conflicts []endpoint.EndpointKey
 	err := im.executeStatements(ctx, statements, func(request dynamodbtypes.BatchStatementRequest, response dynamodbtypes.BatchStatementResponse) error {
 		var context string
 		if strings.HasPrefix(*request.Statement, "INSERT") {
@@ -303,6 +329,7 @@ func (im *DynamoDBRegistry) ApplyChanges(ctx context.Context, changes *plan.Chan
 						// The dynamodb insertion failed; remove from our cache.
 						im.removeFromCache(endpoint)
 						delete(im.labels, key)
+						delete(im.versions, key)
 						return nil
 					}
 				}
@@ -313,14 +340,26 @@ func (im *DynamoDBRegistry) ApplyChanges(ctx context.Context, changes *plan.Chan
 			}
 			context = fmt.Sprintf("inserting dynamodb record %q", record)
 		} else {
+			if response.Error.Code == dynamodbtypes.BatchStatementErrorCodeEnumConditionalCheckFailed {
+				// The record has been changed since we read it, by another owner or another instance with our owner.
+				key, err := fromDynamoKey(request.Parameters[2])
+				if err != nil {
+					return err
+				}
+				conflicts = append(conflicts, key)
+				return nil
+			}
 			var record string
-			if err := attributevalue.Unmarshal(request.Parameters[1], &record); err != nil {
-				return fmt.Errorf("inserting dynamodb record: %w", err)
+			if err := attributevalue.Unmarshal(request.Parameters[2], &record); err != nil {
+				return fmt.Errorf("updating dynamodb record: %w", err)
 			}
 			context = fmt.Sprintf("updating dynamodb record %q", record)
 		}
 		return fmt.Errorf("%s: %s: %s", context, response.Error.Code, *response.Error.Message)
 	})
+	if err == nil && len(conflicts) > 0 {
+		err = im.resolveConflicts(ctx, conflicts, filteredChanges)
+	}
 	if err != nil {
 		im.recordsCache = nil
 		im.labels = nil
@@ -331,7 +370,7 @@ func (im *DynamoDBRegistry) ApplyChanges(ctx context.Context, changes *plan.Chan
 	if im.cacheInterval > 0 {
 		ctx = context.WithValue(ctx, provider.RecordsContextKey, nil)
 	}
-	err = im.provider.ApplyChanges(ctx, filteredChanges)
+	err = timer.applyChanges(ctx, im.provider, filteredChanges)
 	if err != nil {
 		im.recordsCache = nil
 		im.labels = nil
@@ -345,18 +384,125 @@ func (im *DynamoDBRegistry) ApplyChanges(ctx context.Context, changes *plan.Chan
 	for r := range im.orphanedLabels {
 		statements = im.appendDelete(statements, r)
 		delete(im.labels, r)
+		delete(im.versions, r)
 	}
 	im.orphanedLabels = nil
 	return im.executeStatements(ctx, statements, func(request dynamodbtypes.BatchStatementRequest, response dynamodbtypes.BatchStatementResponse) error {
-		im.labels = nil
 		record, err := fromDynamoKey(request.Parameters[0])
 		if err != nil {
+			im.labels = nil
 			return fmt.Errorf("deleting dynamodb record: %w", err)
 		}
+		if response.Error.Code == dynamodbtypes.BatchStatementErrorCodeEnumConditionalCheckFailed {
+			log.Infof("Skipping deletion of dynamodb record %q because it has been deleted or its owner does not match", record)
+			return nil
+		}
+		im.labels = nil
 		return fmt.Errorf("deleting dynamodb record %q: %s: %s", record, response.Error.Code, *response.Error.Message)
 	})
 }
 
+// resolveConflicts retries the updates of the records which have been changed since they were read, with their
+// current versions. Records which have been taken over by another owner are skipped along with their changes.
+func (im *DynamoDBRegistry) resolveConflicts(ctx context.Context, conflicts []endpoint.EndpointKey, changes *plan.Changes) error {
+	for retry := 0; len(conflicts) > 0; retry++ {
+		if retry == dynamodbMaxConflictRetries {
+			return fmt.Errorf("updating dynamodb records %v: still conflicting after %d retries", conflicts, retry)
+		}
+		items, err := im.readItems(ctx, conflicts)
+		if err != nil {
+			return err
+		}
+
+		statements := make([]dynamodbtypes.BatchStatementRequest, 0, len(conflicts))
+		for _, key := range conflicts {
+			item, exists := items[key]
+			if !exists || item.owner != im.ownerID {
+				log.Infof("Skipping endpoint %v because owner does not match", key)
+				isKey := func(ep *endpoint.Endpoint) bool { return ep.Key() == key }
+				changes.Create = slices.DeleteFunc(changes.Create, isKey)
+				changes.UpdateOld = slices.DeleteFunc(changes.UpdateOld, isKey)
+				changes.UpdateNew = slices.DeleteFunc(changes.UpdateNew, isKey)
+				delete(im.labels, key)
+				delete(im.versions, key)
+				im.recordsCache = nil
+				continue
+			}
+			im.versions[key] = item.version
+			statements = im.appendUpdate(statements, key, item.labels, im.labels[key])
+		}
+
+		conflicts = nil
+		err = im.executeStatements(ctx, statements, func(request dynamodbtypes.BatchStatementRequest, response dynamodbtypes.BatchStatementResponse) error {
+			key, err := fromDynamoKey(request.Parameters[2])
+			if err != nil {
+				return err
+			}
+			if response.Error.Code == dynamodbtypes.BatchStatementErrorCodeEnumConditionalCheckFailed {
+				conflicts = append(conflicts, key)
+				return nil
+			}
+			return fmt.Errorf("updating dynamodb record %q: %s: %s", toDynamoKeyString(key), response.Error.Code, *response.Error.Message)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dynamodbItem is a record of the table as read by readItems.
+type dynamodbItem struct {
+	owner   string
+	labels  endpoint.Labels
+	version int64
+}
+
+// readItems reads the current owners, labels and versions of the records with the given keys. Records which
+// don't exist are missing from the result.
+func (im *DynamoDBRegistry) readItems(ctx context.Context, keys []endpoint.EndpointKey) (map[endpoint.EndpointKey]dynamodbItem, error) {
+	items := make(map[endpoint.EndpointKey]dynamodbItem, len(keys))
+	for chunk := range slices.Chunk(keys, int(dynamodbMaxBatchSize)) {
+		statements := make([]dynamodbtypes.BatchStatementRequest, 0, len(chunk))
+		for _, key := range chunk {
+			statements = append(statements, dynamodbtypes.BatchStatementRequest{
+				Statement:      aws.String(fmt.Sprintf("SELECT \"o\",\"l\",\"v\" FROM %q WHERE \"k\"=?", im.table)),
+				ConsistentRead: aws.Bool(true),
+				Parameters:     []dynamodbtypes.AttributeValue{toDynamoKey(key)},
+			})
+		}
+		output, err := im.dynamodbAPI.BatchExecuteStatement(ctx, &dynamodb.BatchExecuteStatementInput{
+			Statements: statements,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for i, response := range output.Responses {
+			key := chunk[i]
+			if response.Error != nil {
+				return nil, fmt.Errorf("reading dynamodb record %q: %s: %s", toDynamoKeyString(key), response.Error.Code, *response.Error.Message)
+			}
+			if len(response.Item) == 0 {
+				continue
+			}
+			var owner string
+			if err := attributevalue.Unmarshal(response.Item["o"], &owner); err != nil {
+				return nil, fmt.Errorf("reading dynamodb record %q: %w", toDynamoKeyString(key), err)
+			}
+			labels, err := fromDynamoLabels(response.Item["l"], owner)
+			if err != nil {
+				return nil, fmt.Errorf("reading dynamodb record %q: %w", toDynamoKeyString(key), err)
+			}
+			version, err := fromDynamoVersion(response.Item["v"])
+			if err != nil {
+				return nil, fmt.Errorf("reading dynamodb record %q: %w", toDynamoKeyString(key), err)
+			}
+			items[key] = dynamodbItem{owner: owner, labels: labels, version: version}
+		}
+	}
+	return items, nil
+}
+
 // AdjustEndpoints modifies the endpoints as needed by the specific provider.
 func (im *DynamoDBRegistry) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
 	return im.provider.AdjustEndpoints(endpoints)
@@ -391,13 +537,14 @@ func (im *DynamoDBRegistry) readLabels(ctx context.Context) error {
 	}
 
 	labels := map[endpoint.EndpointKey]endpoint.Labels{}
+	versions := map[endpoint.EndpointKey]int64{}
 	scanPaginator := dynamodb.NewScanPaginator(im.dynamodbAPI, &dynamodb.ScanInput{
 		TableName:        aws.String(im.table),
 		FilterExpression: aws.String("o = :ownerval"),
 		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
 			":ownerval": &dynamodbtypes.AttributeValueMemberS{Value: im.ownerID},
 		},
-		ProjectionExpression: aws.String("k,l"),
+		ProjectionExpression: aws.String("k,l,v"),
 		ConsistentRead:       aws.Bool(true),
 	})
 	for scanPaginator.HasMorePages() {
@@ -414,12 +561,18 @@ func (im *DynamoDBRegistry) readLabels(ctx context.Context) error {
 			if err != nil {
 				return fmt.Errorf("querying dynamodb for labels: %w", err)
 			}
+			v, err := fromDynamoVersion(item["v"])
+			if err != nil {
+				return fmt.Errorf("querying dynamodb for version: %w", err)
+			}
 
 			labels[k] = l
+			versions[k] = v
 		}
 	}
 
 	im.labels = labels
+	im.versions = versions
 	return nil
 }
 
@@ -438,8 +591,28 @@ func fromDynamoKey(key dynamodbtypes.AttributeValue) (endpoint.EndpointKey, erro
 
 func toDynamoKey(key endpoint.EndpointKey) dynamodbtypes.AttributeValue {
 	return &dynamodbtypes.AttributeValueMemberS{
-		Value: fmt.Sprintf("%s#%s#%s", key.DNSName, key.RecordType, key.SetIdentifier),
+		Value: toDynamoKeyString(key),
+	}
+}
+
+func toDynamoKeyString(key endpoint.EndpointKey) string {
+	return fmt.Sprintf("%s#%s#%s", key.DNSName, key.RecordType, key.SetIdentifier)
+}
+
+// fromDynamoVersion returns the version of a record, which is 0 for the records written before the versioning.
+func fromDynamoVersion(version dynamodbtypes.AttributeValue) (int64, error) {
+	if version == nil {
+		return 0, nil
+	}
+	var v int64
+	if err := attributevalue.Unmarshal(version, &v); err != nil {
+		return 0, fmt.Errorf("unmarshalling version: %w", err)
 	}
+	return v, nil
+}
+
+func toDynamoVersion(version int64) dynamodbtypes.AttributeValue {
+	return &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatInt(version, 10)}
 }
 
 func fromDynamoLabels(label dynamodbtypes.AttributeValue, owner string) (endpoint.Labels, error) {
@@ -462,9 +635,11 @@ func toDynamoLabels(labels endpoint.Labels) dynamodbtypes.AttributeValue {
 	return &dynamodbtypes.AttributeValueMemberM{Value: labelMap}
 }
 
+// appendInsert appends the insertion of a record, which fails if the record exists, whatever its owner.
 func (im *DynamoDBRegistry) appendInsert(statements []dynamodbtypes.BatchStatementRequest, key endpoint.EndpointKey, new endpoint.Labels) []dynamodbtypes.BatchStatementRequest {
+	im.versions[key] = 1
 	return append(statements, dynamodbtypes.BatchStatementRequest{
-		Statement:      aws.String(fmt.Sprintf("INSERT INTO %q VALUE {'k':?, 'o':?, 'l':?}", im.table)),
+		Statement:      aws.String(fmt.Sprintf("INSERT INTO %q VALUE {'k':?, 'o':?, 'l':?, 'v':?}", im.table)),
 		ConsistentRead: aws.Bool(true),
 		Parameters: []dynamodbtypes.AttributeValue{
 			toDynamoKey(key),
@@ -472,10 +647,13 @@ func (im *DynamoDBRegistry) appendInsert(statements []dynamodbtypes.BatchStateme
 				Value: im.ownerID,
 			},
 			toDynamoLabels(new),
+			toDynamoVersion(1),
 		},
 	})
 }
 
+// appendUpdate appends the update of the labels of a record unless they are unchanged. The update is conditional
+// on the record being owned by us and still having the version it was read with, which it increments.
 func (im *DynamoDBRegistry) appendUpdate(statements []dynamodbtypes.BatchStatementRequest, key endpoint.EndpointKey, old endpoint.Labels, new endpoint.Labels) []dynamodbtypes.BatchStatementRequest {
 	if len(old) == len(new) {
 		equal := true
@@ -490,12 +668,23 @@ func (im *DynamoDBRegistry) appendUpdate(statements []dynamodbtypes.BatchStateme
 		}
 	}
 
+	version := im.versions[key]
+	im.versions[key] = version + 1
+	parameters := []dynamodbtypes.AttributeValue{
+		toDynamoLabels(new),
+		toDynamoVersion(version + 1),
+		toDynamoKey(key),
+		&dynamodbtypes.AttributeValueMemberS{Value: im.ownerID},
+	}
+	if version == 0 {
+		return append(statements, dynamodbtypes.BatchStatementRequest{
+			Statement:  aws.String(fmt.Sprintf("UPDATE %q SET \"l\"=? SET \"v\"=? WHERE \"k\"=? AND \"o\"=? AND \"v\" IS MISSING", im.table)),
+			Parameters: parameters,
+		})
+	}
 	return append(statements, dynamodbtypes.BatchStatementRequest{
-		Statement: aws.String(fmt.Sprintf("UPDATE %q SET \"l\"=? WHERE \"k\"=?", im.table)),
-		Parameters: []dynamodbtypes.AttributeValue{
-			toDynamoLabels(new),
-			toDynamoKey(key),
-		},
+		Statement:  aws.String(fmt.Sprintf("UPDATE %q SET \"l\"=? SET \"v\"=? WHERE \"k\"=? AND \"o\"=? AND \"v\"=?", im.table)),
+		Parameters: append(parameters, toDynamoVersion(version)),
 	})
 }
 
@@ -533,7 +722,7 @@ func (im *DynamoDBRegistry) executeStatements(ctx context.Context, statements []
 				op, _, _ := strings.Cut(*request.Statement, " ")
 				var key string
 				if op == "UPDATE" {
-					if err := attributevalue.Unmarshal(request.Parameters[1], &key); err != nil {
+					if err := attributevalue.Unmarshal(request.Parameters[2], &key); err != nil {
 						return err
 					}
 				} else {
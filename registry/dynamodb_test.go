@@ -18,6 +18,8 @@ package registry
 
 import (
 	"context"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -544,6 +546,66 @@ func TestDynamoDBRegistryApplyChanges(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "create orphaned taken over",
+			changes: plan.Changes{
+				Create: []*endpoint.Endpoint{
+					{
+						DNSName:       "quux.test-zone.example.org",
+						Targets:       endpoint.Targets{"5.5.5.5"},
+						RecordType:    endpoint.RecordTypeA,
+						SetIdentifier: "set-2",
+						Labels: map[string]string{
+							endpoint.ResourceLabelKey: "ingress/default/new-ingress",
+						},
+					},
+				},
+			},
+			stubConfig: DynamoDBStubConfig{
+				ChangedItems: map[string]DynamoDBStubItem{
+					"quux.test-zone.example.org#A#set-2": {Owner: "other-owner", Version: 1},
+				},
+			},
+			expectedRecords: []*endpoint.Endpoint{
+				{
+					DNSName:    "foo.test-zone.example.org",
+					Targets:    endpoint.Targets{"foo.loadbalancer.com"},
+					RecordType: endpoint.RecordTypeCNAME,
+					Labels: map[string]string{
+						endpoint.OwnerLabelKey: "",
+					},
+				},
+				{
+					DNSName:    "bar.test-zone.example.org",
+					Targets:    endpoint.Targets{"my-domain.com"},
+					RecordType: endpoint.RecordTypeCNAME,
+					Labels: map[string]string{
+						endpoint.OwnerLabelKey:    "test-owner",
+						endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+					},
+				},
+				{
+					DNSName:       "baz.test-zone.example.org",
+					Targets:       endpoint.Targets{"1.1.1.1"},
+					RecordType:    endpoint.RecordTypeA,
+					SetIdentifier: "set-1",
+					Labels: map[string]string{
+						endpoint.OwnerLabelKey:    "test-owner",
+						endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+					},
+				},
+				{
+					DNSName:       "baz.test-zone.example.org",
+					Targets:       endpoint.Targets{"2.2.2.2"},
+					RecordType:    endpoint.RecordTypeA,
+					SetIdentifier: "set-2",
+					Labels: map[string]string{
+						endpoint.OwnerLabelKey:    "test-owner",
+						endpoint.ResourceLabelKey: "ingress/default/other-ingress",
+					},
+				},
+			},
+		},
 		{
 			name: "create duplicate",
 			changes: plan.Changes{
@@ -807,6 +869,152 @@ func TestDynamoDBRegistryApplyChanges(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "update conflict",
+			changes: plan.Changes{
+				UpdateOld: []*endpoint.Endpoint{
+					{
+						DNSName:    "bar.test-zone.example.org",
+						Targets:    endpoint.Targets{"my-domain.com"},
+						RecordType: endpoint.RecordTypeCNAME,
+						Labels: map[string]string{
+							endpoint.OwnerLabelKey:    "test-owner",
+							endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+						},
+					},
+				},
+				UpdateNew: []*endpoint.Endpoint{
+					{
+						DNSName:    "bar.test-zone.example.org",
+						Targets:    endpoint.Targets{"new-domain.com"},
+						RecordType: endpoint.RecordTypeCNAME,
+						Labels: map[string]string{
+							endpoint.OwnerLabelKey:    "test-owner",
+							endpoint.ResourceLabelKey: "ingress/default/new-ingress",
+						},
+					},
+				},
+			},
+			stubConfig: DynamoDBStubConfig{
+				ExpectDelete: sets.New("quux.test-zone.example.org#A#set-2"),
+				ChangedItems: map[string]DynamoDBStubItem{
+					"bar.test-zone.example.org#CNAME#": {Owner: "test-owner", Version: 4},
+				},
+				ExpectUpdate: map[string]map[string]string{
+					"bar.test-zone.example.org#CNAME#": {endpoint.ResourceLabelKey: "ingress/default/new-ingress"},
+				},
+			},
+			expectedRecords: []*endpoint.Endpoint{
+				{
+					DNSName:    "foo.test-zone.example.org",
+					Targets:    endpoint.Targets{"foo.loadbalancer.com"},
+					RecordType: endpoint.RecordTypeCNAME,
+					Labels: map[string]string{
+						endpoint.OwnerLabelKey: "",
+					},
+				},
+				{
+					DNSName:    "bar.test-zone.example.org",
+					Targets:    endpoint.Targets{"new-domain.com"},
+					RecordType: endpoint.RecordTypeCNAME,
+					Labels: map[string]string{
+						endpoint.OwnerLabelKey:    "test-owner",
+						endpoint.ResourceLabelKey: "ingress/default/new-ingress",
+					},
+				},
+				{
+					DNSName:       "baz.test-zone.example.org",
+					Targets:       endpoint.Targets{"1.1.1.1"},
+					RecordType:    endpoint.RecordTypeA,
+					SetIdentifier: "set-1",
+					Labels: map[string]string{
+						endpoint.OwnerLabelKey:    "test-owner",
+						endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+					},
+				},
+				{
+					DNSName:       "baz.test-zone.example.org",
+					Targets:       endpoint.Targets{"2.2.2.2"},
+					RecordType:    endpoint.RecordTypeA,
+					SetIdentifier: "set-2",
+					Labels: map[string]string{
+						endpoint.OwnerLabelKey:    "test-owner",
+						endpoint.ResourceLabelKey: "ingress/default/other-ingress",
+					},
+				},
+			},
+		},
+		{
+			name: "update taken over",
+			changes: plan.Changes{
+				UpdateOld: []*endpoint.Endpoint{
+					{
+						DNSName:    "bar.test-zone.example.org",
+						Targets:    endpoint.Targets{"my-domain.com"},
+						RecordType: endpoint.RecordTypeCNAME,
+						Labels: map[string]string{
+							endpoint.OwnerLabelKey:    "test-owner",
+							endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+						},
+					},
+				},
+				UpdateNew: []*endpoint.Endpoint{
+					{
+						DNSName:    "bar.test-zone.example.org",
+						Targets:    endpoint.Targets{"new-domain.com"},
+						RecordType: endpoint.RecordTypeCNAME,
+						Labels: map[string]string{
+							endpoint.OwnerLabelKey:    "test-owner",
+							endpoint.ResourceLabelKey: "ingress/default/new-ingress",
+						},
+					},
+				},
+			},
+			stubConfig: DynamoDBStubConfig{
+				ExpectDelete: sets.New("quux.test-zone.example.org#A#set-2"),
+				ChangedItems: map[string]DynamoDBStubItem{
+					"bar.test-zone.example.org#CNAME#": {Owner: "other-owner", Version: 1},
+				},
+			},
+			expectedRecords: []*endpoint.Endpoint{
+				{
+					DNSName:    "foo.test-zone.example.org",
+					Targets:    endpoint.Targets{"foo.loadbalancer.com"},
+					RecordType: endpoint.RecordTypeCNAME,
+					Labels: map[string]string{
+						endpoint.OwnerLabelKey: "",
+					},
+				},
+				{
+					DNSName:    "bar.test-zone.example.org",
+					Targets:    endpoint.Targets{"my-domain.com"},
+					RecordType: endpoint.RecordTypeCNAME,
+					Labels: map[string]string{
+						endpoint.OwnerLabelKey: "",
+					},
+				},
+				{
+					DNSName:       "baz.test-zone.example.org",
+					Targets:       endpoint.Targets{"1.1.1.1"},
+					RecordType:    endpoint.RecordTypeA,
+					SetIdentifier: "set-1",
+					Labels: map[string]string{
+						endpoint.OwnerLabelKey:    "test-owner",
+						endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+					},
+				},
+				{
+					DNSName:       "baz.test-zone.example.org",
+					Targets:       endpoint.Targets{"2.2.2.2"},
+					RecordType:    endpoint.RecordTypeA,
+					SetIdentifier: "set-2",
+					Labels: map[string]string{
+						endpoint.OwnerLabelKey:    "test-owner",
+						endpoint.ResourceLabelKey: "ingress/default/other-ingress",
+					},
+				},
+			},
+		},
 		{
 			name: "update migrate",
 			addRecords: []*endpoint.Endpoint{
@@ -1023,6 +1231,58 @@ func TestDynamoDBRegistryApplyChanges(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "delete taken over",
+			changes: plan.Changes{
+				Delete: []*endpoint.Endpoint{
+					{
+						DNSName:    "bar.test-zone.example.org",
+						Targets:    endpoint.Targets{"my-domain.com"},
+						RecordType: endpoint.RecordTypeCNAME,
+						Labels: map[string]string{
+							endpoint.OwnerLabelKey:    "test-owner",
+							endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+						},
+					},
+				},
+			},
+			stubConfig: DynamoDBStubConfig{
+				ExpectDelete: sets.New("bar.test-zone.example.org#CNAME#", "quux.test-zone.example.org#A#set-2"),
+				ChangedItems: map[string]DynamoDBStubItem{
+					"quux.test-zone.example.org#A#set-2": {Owner: "other-owner", Version: 3},
+				},
+			},
+			expectedRecords: []*endpoint.Endpoint{
+				{
+					DNSName:    "foo.test-zone.example.org",
+					Targets:    endpoint.Targets{"foo.loadbalancer.com"},
+					RecordType: endpoint.RecordTypeCNAME,
+					Labels: map[string]string{
+						endpoint.OwnerLabelKey: "",
+					},
+				},
+				{
+					DNSName:       "baz.test-zone.example.org",
+					Targets:       endpoint.Targets{"1.1.1.1"},
+					RecordType:    endpoint.RecordTypeA,
+					SetIdentifier: "set-1",
+					Labels: map[string]string{
+						endpoint.OwnerLabelKey:    "test-owner",
+						endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+					},
+				},
+				{
+					DNSName:       "baz.test-zone.example.org",
+					Targets:       endpoint.Targets{"2.2.2.2"},
+					RecordType:    endpoint.RecordTypeA,
+					SetIdentifier: "set-2",
+					Labels: map[string]string{
+						endpoint.OwnerLabelKey:    "test-owner",
+						endpoint.ResourceLabelKey: "ingress/default/other-ingress",
+					},
+				},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			originalMaxBatchSize := dynamodbMaxBatchSize
@@ -1043,7 +1303,10 @@ func TestDynamoDBRegistryApplyChanges(t *testing.T) {
 			_, err := r.Records(ctx)
 			require.Nil(t, err)
 
+			// the registry must not modify the changes of the caller, which keeps reporting them
+			creates := slices.Clone(tc.changes.Create)
 			err = r.ApplyChanges(ctx, &tc.changes)
+			assert.Equal(t, creates, tc.changes.Create)
 			if tc.expectedError == "" {
 				assert.Nil(t, err)
 			} else {
@@ -1051,6 +1314,7 @@ func TestDynamoDBRegistryApplyChanges(t *testing.T) {
 			}
 
 			assert.Empty(t, tc.stubConfig.ExpectInsert, "all expected inserts made")
+			assert.Empty(t, tc.stubConfig.ExpectUpdate, "all expected updates made")
 			assert.Empty(t, tc.stubConfig.ExpectDelete, "all expected deletions made")
 
 			records, err := r.Records(ctx)
@@ -1076,6 +1340,13 @@ type DynamoDBStub struct {
 	stubConfig       *DynamoDBStubConfig
 	tableDescription dynamodbtypes.TableDescription
 	changesApplied   bool
+	// items are the owners and versions of the records in the table
+	items map[string]DynamoDBStubItem
+}
+
+type DynamoDBStubItem struct {
+	Owner   string
+	Version int64
 }
 
 type DynamoDBStubConfig struct {
@@ -1084,6 +1355,8 @@ type DynamoDBStubConfig struct {
 	ExpectUpdate      map[string]map[string]string
 	ExpectUpdateError map[string]dynamodbtypes.BatchStatementErrorCodeEnum
 	ExpectDelete      sets.Set[string]
+	// ChangedItems are the records changed by another writer after they have been read
+	ChangedItems map[string]DynamoDBStubItem
 }
 
 type wrappedProvider struct {
@@ -1115,6 +1388,17 @@ func newDynamoDBAPIStub(t *testing.T, stubConfig *DynamoDBStubConfig) (*DynamoDB
 				},
 			},
 		},
+		items: map[string]DynamoDBStubItem{
+			"bar.test-zone.example.org#CNAME#":   {Owner: "test-owner"},
+			"baz.test-zone.example.org#A#set-1":  {Owner: "test-owner"},
+			"baz.test-zone.example.org#A#set-2":  {Owner: "test-owner"},
+			"quux.test-zone.example.org#A#set-2": {Owner: "test-owner", Version: 2},
+		},
+	}
+	if stubConfig != nil {
+		for key, item := range stubConfig.ChangedItems {
+			stub.items[key] = item
+		}
 	}
 	p := inmemory.NewInMemoryProvider()
 	_ = p.CreateZone(testZone)
@@ -1148,7 +1432,7 @@ func (r *DynamoDBStub) Scan(ctx context.Context, input *dynamodb.ScanInput, opts
 	var owner string
 	assert.Nil(r.t, attributevalue.Unmarshal(input.ExpressionAttributeValues[":ownerval"], &owner))
 	assert.Equal(r.t, "test-owner", owner)
-	assert.Equal(r.t, "k,l", *input.ProjectionExpression)
+	assert.Equal(r.t, "k,l,v", *input.ProjectionExpression)
 	assert.True(r.t, *input.ConsistentRead)
 	return &dynamodb.ScanOutput{
 		Items: []map[string]dynamodbtypes.AttributeValue{
@@ -1175,6 +1459,7 @@ func (r *DynamoDBStub) Scan(ctx context.Context, input *dynamodb.ScanInput, opts
 				"l": &dynamodbtypes.AttributeValueMemberM{Value: map[string]dynamodbtypes.AttributeValue{
 					endpoint.ResourceLabelKey: &dynamodbtypes.AttributeValueMemberS{Value: "ingress/default/quux-ingress"},
 				}},
+				"v": &dynamodbtypes.AttributeValueMemberN{Value: "2"},
 			},
 		},
 	}, nil
@@ -1202,9 +1487,19 @@ func (r *DynamoDBStub) BatchExecuteStatement(context context.Context, input *dyn
 			assert.Nil(r.t, attributevalue.Unmarshal(statement.Parameters[1], &testOwner))
 			assert.Equal(r.t, "test-owner", testOwner)
 
+			if item := r.items[key]; item.Owner != testOwner {
+				responses = append(responses, dynamodbtypes.BatchStatementResponse{
+					Error: &dynamodbtypes.BatchStatementError{
+						Code:    dynamodbtypes.BatchStatementErrorCodeEnumConditionalCheckFailed,
+						Message: aws.String("testing conflict"),
+					},
+				})
+				break
+			}
+			delete(r.items, key)
 			responses = append(responses, dynamodbtypes.BatchStatementResponse{})
 
-		case "INSERT INTO \"test-table\" VALUE {'k':?, 'o':?, 'l':?}":
+		case "INSERT INTO \"test-table\" VALUE {'k':?, 'o':?, 'l':?, 'v':?}":
 			assert.False(r.t, r.changesApplied, "unexpected insert after provider changes")
 
 			var key string
@@ -1243,13 +1538,39 @@ func (r *DynamoDBStub) BatchExecuteStatement(context context.Context, input *dyn
 				r.t.Errorf("insert for key %q did not get expected label %q", key, label)
 			}
 
+			var version int64
+			assert.Nil(r.t, attributevalue.Unmarshal(statement.Parameters[3], &version))
+			assert.Equal(r.t, int64(1), version)
+			r.items[key] = DynamoDBStubItem{Owner: testOwner, Version: version}
+
 			responses = append(responses, dynamodbtypes.BatchStatementResponse{})
 
-		case "UPDATE \"test-table\" SET \"l\"=? WHERE \"k\"=?":
+		case "UPDATE \"test-table\" SET \"l\"=? SET \"v\"=? WHERE \"k\"=? AND \"o\"=? AND \"v\"=?",
+			"UPDATE \"test-table\" SET \"l\"=? SET \"v\"=? WHERE \"k\"=? AND \"o\"=? AND \"v\" IS MISSING":
 			assert.False(r.t, r.changesApplied, "unexpected update after provider changes")
 
-			var key string
-			assert.Nil(r.t, attributevalue.Unmarshal(statement.Parameters[1], &key))
+			var key, owner string
+			var version, newVersion int64
+			assert.Nil(r.t, attributevalue.Unmarshal(statement.Parameters[2], &key))
+			assert.Nil(r.t, attributevalue.Unmarshal(statement.Parameters[3], &owner))
+			assert.Equal(r.t, "test-owner", owner)
+			if len(statement.Parameters) > 4 {
+				assert.Nil(r.t, attributevalue.Unmarshal(statement.Parameters[4], &version))
+				assert.Positive(r.t, version, "update for key %q conditional on version 0", key)
+			}
+			assert.Nil(r.t, attributevalue.Unmarshal(statement.Parameters[1], &newVersion))
+			assert.Equal(r.t, version+1, newVersion, "update for key %q new version", key)
+			if item := r.items[key]; item.Owner != owner || item.Version != version {
+				responses = append(responses, dynamodbtypes.BatchStatementResponse{
+					Error: &dynamodbtypes.BatchStatementError{
+						Code:    dynamodbtypes.BatchStatementErrorCodeEnumConditionalCheckFailed,
+						Message: aws.String("testing conflict"),
+					},
+				})
+				break
+			}
+			r.items[key] = DynamoDBStubItem{Owner: owner, Version: newVersion}
+
 			if code, exists := r.stubConfig.ExpectUpdateError[key]; exists {
 				delete(r.stubConfig.ExpectInsertError, key)
 				responses = append(responses, dynamodbtypes.BatchStatementResponse{
@@ -1281,6 +1602,25 @@ func (r *DynamoDBStub) BatchExecuteStatement(context context.Context, input *dyn
 
 			responses = append(responses, dynamodbtypes.BatchStatementResponse{})
 
+		case "SELECT \"o\",\"l\",\"v\" FROM \"test-table\" WHERE \"k\"=?":
+			assert.False(r.t, r.changesApplied, "unexpected select after provider changes")
+			assert.True(r.t, *statement.ConsistentRead)
+
+			var key string
+			assert.Nil(r.t, attributevalue.Unmarshal(statement.Parameters[0], &key))
+			item, exists := r.items[key]
+			if !exists {
+				responses = append(responses, dynamodbtypes.BatchStatementResponse{})
+				break
+			}
+			responses = append(responses, dynamodbtypes.BatchStatementResponse{
+				Item: map[string]dynamodbtypes.AttributeValue{
+					"o": &dynamodbtypes.AttributeValueMemberS{Value: item.Owner},
+					"l": &dynamodbtypes.AttributeValueMemberM{Value: map[string]dynamodbtypes.AttributeValue{}},
+					"v": &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatInt(item.Version, 10)},
+				},
+			})
+
 		default:
 			r.t.Errorf("unexpected statement: %s", *statement.Statement)
 		}
@@ -0,0 +1,437 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	etcdcv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/tlsutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+const (
+	etcdTimeout = 5 * time.Second
+	// etcdLockKey is the key below the prefix the lock serializing the changes is held at
+	etcdLockKey = "lock"
+	// etcdRecordsKey is the key below the prefix the ownership entries are stored at
+	etcdRecordsKey = "records"
+)
+
+// etcdRecord is the ownership of a single DNS record as stored in an etcd key.
+type etcdRecord struct {
+	DNSName       string          `json:"dnsName"`
+	RecordType    string          `json:"recordType"`
+	SetIdentifier string          `json:"setIdentifier,omitempty"`
+	Labels        endpoint.Labels `json:"labels"`
+}
+
+// etcdRegistryClient is the part of etcd used by the EtcdRegistry.
+type etcdRegistryClient interface {
+	// List returns the values of the keys below the prefix.
+	List(ctx context.Context, prefix string) (map[string][]byte, error)
+	Put(ctx context.Context, key string, value []byte) error
+	Delete(ctx context.Context, key string) error
+	// Lock acquires the lock at the key and returns the function releasing it.
+	Lock(ctx context.Context, key string) (func(context.Context) error, error)
+}
+
+// EtcdRegistry implements registry interface with ownership implemented via etcd keys. The changes
+// of all replicas and owners sharing the prefix are serialized by a lock bound to a lease, so the
+// lock is released even if the replica holding it dies.
+type EtcdRegistry struct {
+	provider provider.Provider
+	ownerID  string // refers to the owner id of the current instance
+
+	client etcdRegistryClient
+	prefix string
+
+	// cache the labels of all owners as last read
+	labels         map[endpoint.EndpointKey]endpoint.Labels
+	orphanedLabels map[endpoint.EndpointKey]bool
+
+	// cache the records in memory and update on an interval instead.
+	recordsCache            []*endpoint.Endpoint
+	recordsCacheRefreshTime time.Time
+	cacheInterval           time.Duration
+}
+
+// NewEtcdRegistry returns a new EtcdRegistry object connecting to the given etcd endpoints. The lock is
+// bound to a lease with the given TTL.
+func NewEtcdRegistry(provider provider.Provider, ownerID string, endpoints []string, username, password, prefix string, lockTTL, cacheInterval time.Duration) (*EtcdRegistry, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("etcd endpoints cannot be empty")
+	}
+	cfg := etcdcv3.Config{
+		Endpoints:   endpoints,
+		Username:    username,
+		Password:    password,
+		DialTimeout: etcdTimeout,
+	}
+	for _, e := range endpoints {
+		u, err := url.Parse(e)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("etcd endpoint %q must start with either http:// or https://", e)
+		}
+		if u.Scheme == "https" && cfg.TLS == nil {
+			if cfg.TLS, err = tlsutils.CreateTLSConfig("ETCD"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	client, err := etcdcv3.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to etcd: %w", err)
+	}
+	return newEtcdRegistry(provider, ownerID, &etcdLockingClient{client: client, lockTTL: lockTTL}, prefix, cacheInterval)
+}
+
+func newEtcdRegistry(provider provider.Provider, ownerID string, client etcdRegistryClient, prefix string, cacheInterval time.Duration) (*EtcdRegistry, error) {
+	if ownerID == "" {
+		return nil, errors.New("owner id cannot be empty")
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return nil, errors.New("etcd prefix cannot be empty")
+	}
+
+	return &EtcdRegistry{
+		provider:      provider,
+		ownerID:       ownerID,
+		client:        client,
+		prefix:        prefix,
+		cacheInterval: cacheInterval,
+	}, nil
+}
+
+func (im *EtcdRegistry) GetDomainFilter() endpoint.DomainFilterInterface {
+	return im.provider.GetDomainFilter()
+}
+
+func (im *EtcdRegistry) OwnerID() string {
+	return im.ownerID
+}
+
+// OrphanedEntries returns the keys of the owned entries whose records didn't exist on the last read.
+func (im *EtcdRegistry) OrphanedEntries() []endpoint.EndpointKey {
+	return sortedKeys(im.orphanedLabels)
+}
+
+// Records returns the current records from the registry.
+func (im *EtcdRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	// If we have the zones cached AND we have refreshed the cache since the
+	// last given interval, then just use the cached results.
+	if im.recordsCache != nil && time.Since(im.recordsCacheRefreshTime) < im.cacheInterval {
+		log.Debug("Using cached records.")
+		countCacheLookup("etcd", true)
+		return im.recordsCache, nil
+	}
+	if im.cacheInterval > 0 {
+		countCacheLookup("etcd", false)
+	}
+
+	labels, err := im.readLabels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	im.labels = labels
+
+	records, err := im.provider.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orphanedLabels := map[endpoint.EndpointKey]bool{}
+	for key, l := range im.labels {
+		if l[endpoint.OwnerLabelKey] == im.ownerID {
+			orphanedLabels[key] = true
+		}
+	}
+	for _, record := range records {
+		key := record.Key()
+		if l, ok := im.labels[key]; ok {
+			record.Labels = maps.Clone(l)
+			delete(orphanedLabels, key)
+		} else {
+			record.Labels = endpoint.NewLabels()
+		}
+	}
+	im.orphanedLabels = orphanedLabels
+
+	// Update the cache.
+	if im.cacheInterval > 0 {
+		im.recordsCache = records
+		im.recordsCacheRefreshTime = time.Now()
+	}
+
+	return records, nil
+}
+
+// ApplyChanges records the ownership of created and updated records in etcd, updates the DNS
+// provider and then releases the ownership of deleted records, all while holding the lock.
+func (im *EtcdRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	timer := startLabelPersistenceTimer("etcd")
+	defer timer.observe()
+
+	unlock, err := im.client.Lock(ctx, im.prefix+"/"+etcdLockKey)
+	if err != nil {
+		return provider.NewSoftError(fmt.Errorf("acquiring etcd lock: %w", err))
+	}
+	defer func() {
+		if err := unlock(context.Background()); err != nil {
+			log.Warnf("Failed to release etcd lock: %v", err)
+		}
+	}()
+
+	// another replica may have changed the ownership since the records were read
+	labels, err := im.readLabels(ctx)
+	if err != nil {
+		return err
+	}
+	im.labels = labels
+
+	filteredChanges := &plan.Changes{
+		Create:    make([]*endpoint.Endpoint, 0, len(changes.Create)),
+		UpdateNew: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateNew),
+		UpdateOld: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateOld),
+		Delete:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete),
+	}
+
+	for _, r := range changes.Create {
+		key := r.Key()
+		if owner := im.labels[key][endpoint.OwnerLabelKey]; owner != "" && owner != im.ownerID {
+			// We lost a race with a different owner or another owner has an orphaned ownership record.
+			log.Infof("Skipping endpoint %v because owner does not match", r)
+			continue
+		}
+		if r.Labels == nil {
+			r.Labels = make(map[string]string)
+		}
+		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
+		if err := im.writeLabels(ctx, key, r.Labels); err != nil {
+			return err
+		}
+		filteredChanges.Create = append(filteredChanges.Create, r)
+		delete(im.orphanedLabels, key)
+		if im.cacheInterval > 0 {
+			im.addToCache(r)
+		}
+	}
+
+	for _, r := range filteredChanges.UpdateOld {
+		// remove old version of record from cache
+		if im.cacheInterval > 0 {
+			im.removeFromCache(r)
+		}
+	}
+
+	for _, r := range filteredChanges.UpdateNew {
+		key := r.Key()
+		if owner := im.labels[key][endpoint.OwnerLabelKey]; owner != "" && owner != im.ownerID {
+			im.recordsCache = nil
+			return provider.NewSoftError(fmt.Errorf("ownership of %s %s has been taken over by owner %s", key.RecordType, key.DNSName, owner))
+		}
+		if err := im.writeLabels(ctx, key, r.Labels); err != nil {
+			return err
+		}
+		// add new version of record to caches
+		if im.cacheInterval > 0 {
+			im.addToCache(r)
+		}
+	}
+
+	// When caching is enabled, disable the provider from using the cache.
+	if im.cacheInterval > 0 {
+		ctx = context.WithValue(ctx, provider.RecordsContextKey, nil)
+	}
+	if err := timer.applyChanges(ctx, im.provider, filteredChanges); err != nil {
+		im.recordsCache = nil
+		return err
+	}
+
+	releases := map[endpoint.EndpointKey]bool{}
+	for _, r := range filteredChanges.Delete {
+		releases[r.Key()] = true
+		if im.cacheInterval > 0 {
+			im.removeFromCache(r)
+		}
+	}
+	maps.Copy(releases, im.orphanedLabels)
+	im.orphanedLabels = nil
+	for key := range releases {
+		if im.labels[key][endpoint.OwnerLabelKey] != im.ownerID {
+			continue
+		}
+		if err := im.client.Delete(ctx, im.entryKey(key)); err != nil {
+			return provider.NewSoftError(fmt.Errorf("deleting etcd key %s: %w", im.entryKey(key), err))
+		}
+		delete(im.labels, key)
+	}
+	return nil
+}
+
+// AdjustEndpoints modifies the endpoints as needed by the specific provider.
+func (im *EtcdRegistry) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	return im.provider.AdjustEndpoints(endpoints)
+}
+
+// entryKey returns the key of the ownership of a record, <prefix>/records/<dns name>/<record type>[/<set identifier>].
+func (im *EtcdRegistry) entryKey(key endpoint.EndpointKey) string {
+	entryKey := im.prefix + "/" + etcdRecordsKey + "/" + url.PathEscape(key.DNSName) + "/" + url.PathEscape(key.RecordType)
+	if key.SetIdentifier != "" {
+		entryKey += "/" + url.PathEscape(key.SetIdentifier)
+	}
+	return entryKey
+}
+
+func (im *EtcdRegistry) readLabels(ctx context.Context) (map[endpoint.EndpointKey]endpoint.Labels, error) {
+	values, err := im.client.List(ctx, im.prefix+"/"+etcdRecordsKey+"/")
+	if err != nil {
+		return nil, provider.NewSoftError(fmt.Errorf("reading etcd keys below %s: %w", im.prefix, err))
+	}
+
+	labels := make(map[endpoint.EndpointKey]endpoint.Labels, len(values))
+	for k, value := range values {
+		var r etcdRecord
+		if err := json.Unmarshal(value, &r); err != nil {
+			log.Warnf("Skipping etcd key %s with invalid value: %v", k, err)
+			continue
+		}
+		if r.Labels == nil {
+			r.Labels = endpoint.NewLabels()
+		}
+		labels[endpoint.EndpointKey{DNSName: r.DNSName, RecordType: r.RecordType, SetIdentifier: r.SetIdentifier}] = r.Labels
+	}
+	return labels, nil
+}
+
+// writeLabels stores the labels of a record unless they are stored already.
+func (im *EtcdRegistry) writeLabels(ctx context.Context, key endpoint.EndpointKey, labels endpoint.Labels) error {
+	if old, ok := im.labels[key]; ok && maps.Equal(old, labels) {
+		return nil
+	}
+	value, err := json.Marshal(etcdRecord{
+		DNSName:       key.DNSName,
+		RecordType:    key.RecordType,
+		SetIdentifier: key.SetIdentifier,
+		Labels:        labels,
+	})
+	if err != nil {
+		return fmt.Errorf("marshalling etcd key %s: %w", im.entryKey(key), err)
+	}
+	if err := im.client.Put(ctx, im.entryKey(key), value); err != nil {
+		im.recordsCache = nil
+		return provider.NewSoftError(fmt.Errorf("writing etcd key %s: %w", im.entryKey(key), err))
+	}
+	im.labels[key] = maps.Clone(labels)
+	return nil
+}
+
+func (im *EtcdRegistry) addToCache(ep *endpoint.Endpoint) {
+	if im.recordsCache != nil {
+		im.recordsCache = append(im.recordsCache, ep)
+	}
+}
+
+func (im *EtcdRegistry) removeFromCache(ep *endpoint.Endpoint) {
+	if im.recordsCache == nil || ep == nil {
+		return
+	}
+
+	for i, e := range im.recordsCache {
+		if e.DNSName == ep.DNSName && e.RecordType == ep.RecordType && e.SetIdentifier == ep.SetIdentifier && e.Targets.Same(ep.Targets) {
+			// We found a match; delete the endpoint from the cache.
+			im.recordsCache = append(im.recordsCache[:i], im.recordsCache[i+1:]...)
+			return
+		}
+	}
+}
+
+// etcdLockingClient implements the etcdRegistryClient with an etcd client. The lock is held in a
+// session whose lease is kept alive while the process runs.
+type etcdLockingClient struct {
+	client  *etcdcv3.Client
+	lockTTL time.Duration
+	session *concurrency.Session
+}
+
+func (c *etcdLockingClient) List(ctx context.Context, prefix string) (map[string][]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	defer cancel()
+
+	resp, err := c.client.Get(ctx, prefix, etcdcv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string][]byte, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		values[string(kv.Key)] = kv.Value
+	}
+	return values, nil
+}
+
+func (c *etcdLockingClient) Put(ctx context.Context, key string, value []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	defer cancel()
+
+	_, err := c.client.Put(ctx, key, string(value))
+	return err
+}
+
+func (c *etcdLockingClient) Delete(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, etcdTimeout)
+	defer cancel()
+
+	_, err := c.client.Delete(ctx, key)
+	return err
+}
+
+func (c *etcdLockingClient) Lock(ctx context.Context, key string) (func(context.Context) error, error) {
+	if c.session != nil {
+		select {
+		case <-c.session.Done():
+			// the lease expired, e.g. because etcd was unreachable for longer than the TTL
+			c.session = nil
+		default:
+		}
+	}
+	if c.session == nil {
+		session, err := concurrency.NewSession(c.client, concurrency.WithTTL(int(c.lockTTL.Seconds())), concurrency.WithContext(context.Background()))
+		if err != nil {
+			return nil, err
+		}
+		c.session = session
+	}
+
+	mutex := concurrency.NewMutex(c.session, key)
+	if err := mutex.Lock(ctx); err != nil {
+		return nil, err
+	}
+	return mutex.Unlock, nil
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// fakeEtcdClient keeps the keys in memory. The lock is a mutex, hooks run while it is held.
+type fakeEtcdClient struct {
+	lock     sync.Mutex
+	locked   bool
+	onLocked func()
+	lockErr  error
+
+	values map[string][]byte
+}
+
+func newFakeEtcdClient() *fakeEtcdClient {
+	return &fakeEtcdClient{values: map[string][]byte{}}
+}
+
+func (c *fakeEtcdClient) List(_ context.Context, prefix string) (map[string][]byte, error) {
+	values := map[string][]byte{}
+	for k, v := range c.values {
+		if strings.HasPrefix(k, prefix) {
+			values[k] = v
+		}
+	}
+	return values, nil
+}
+
+func (c *fakeEtcdClient) Put(_ context.Context, key string, value []byte) error {
+	c.values[key] = value
+	return nil
+}
+
+func (c *fakeEtcdClient) Delete(_ context.Context, key string) error {
+	delete(c.values, key)
+	return nil
+}
+
+func (c *fakeEtcdClient) Lock(_ context.Context, _ string) (func(context.Context) error, error) {
+	if c.lockErr != nil {
+		return nil, c.lockErr
+	}
+	c.lock.Lock()
+	c.locked = true
+	if c.onLocked != nil {
+		c.onLocked()
+	}
+	return func(context.Context) error {
+		c.locked = false
+		c.lock.Unlock()
+		return nil
+	}, nil
+}
+
+func (c *fakeEtcdClient) set(t *testing.T, key string, record etcdRecord) {
+	value, err := json.Marshal(record)
+	require.NoError(t, err)
+	c.values[key] = value
+}
+
+func (c *fakeEtcdClient) records(t *testing.T) map[string]etcdRecord {
+	records := map[string]etcdRecord{}
+	for key, value := range c.values {
+		var record etcdRecord
+		require.NoError(t, json.Unmarshal(value, &record))
+		records[key] = record
+	}
+	return records
+}
+
+func TestEtcdRegistryNew(t *testing.T) {
+	p := newConfigMapRegistryProvider(t)
+
+	_, err := newEtcdRegistry(p, "test-owner", newFakeEtcdClient(), "/external-dns/registry", time.Hour)
+	require.NoError(t, err)
+
+	_, err = newEtcdRegistry(p, "", newFakeEtcdClient(), "/external-dns/registry", time.Hour)
+	require.EqualError(t, err, "owner id cannot be empty")
+
+	_, err = newEtcdRegistry(p, "test-owner", newFakeEtcdClient(), "/", time.Hour)
+	require.EqualError(t, err, "etcd prefix cannot be empty")
+
+	_, err = NewEtcdRegistry(p, "test-owner", []string{"localhost:2379"}, "", "", "/external-dns/registry", time.Minute, time.Hour)
+	require.EqualError(t, err, `etcd endpoint "localhost:2379" must start with either http:// or https://`)
+}
+
+func TestEtcdRegistryRecords(t *testing.T) {
+	client := newFakeEtcdClient()
+	client.set(t, "/external-dns/registry/records/bar.test-zone.example.org/CNAME", etcdRecord{
+		DNSName:    "bar.test-zone.example.org",
+		RecordType: endpoint.RecordTypeCNAME,
+		Labels: endpoint.Labels{
+			endpoint.OwnerLabelKey:    "test-owner",
+			endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+		},
+	})
+	client.values["/external-dns/registry/records/invalid"] = []byte("{")
+	r, err := newEtcdRegistry(newConfigMapRegistryProvider(t), "test-owner", client, "/external-dns/registry/", 0)
+	require.NoError(t, err)
+
+	records, err := r.Records(context.Background())
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		{
+			DNSName:    "foo.test-zone.example.org",
+			Targets:    endpoint.Targets{"foo.loadbalancer.com"},
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey: "",
+			},
+		},
+		{
+			DNSName:    "bar.test-zone.example.org",
+			Targets:    endpoint.Targets{"my-domain.com"},
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+			},
+		},
+	}), "actual: %v", records)
+}
+
+func TestEtcdRegistryApplyChanges(t *testing.T) {
+	client := newFakeEtcdClient()
+	client.set(t, "/external-dns/registry/records/bar.test-zone.example.org/CNAME", etcdRecord{
+		DNSName:    "bar.test-zone.example.org",
+		RecordType: endpoint.RecordTypeCNAME,
+		Labels: endpoint.Labels{
+			endpoint.OwnerLabelKey:    "test-owner",
+			endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+		},
+	})
+	// the record has been deleted from the zone
+	client.set(t, "/external-dns/registry/records/orphan.test-zone.example.org/A", etcdRecord{
+		DNSName:    "orphan.test-zone.example.org",
+		RecordType: endpoint.RecordTypeA,
+		Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "test-owner"},
+	})
+	p := newConfigMapRegistryProvider(t)
+	r, err := newEtcdRegistry(p, "test-owner", client, "/external-dns/registry", time.Hour)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+
+	var bar *endpoint.Endpoint
+	for _, record := range records {
+		if record.DNSName == "bar.test-zone.example.org" {
+			bar = record
+		}
+	}
+	require.NotNil(t, bar)
+	updated := bar.DeepCopy()
+	updated.Targets = endpoint.Targets{"other-domain.com"}
+	updated.Labels[endpoint.ResourceLabelKey] = "ingress/default/other-ingress"
+	qux := endpoint.NewEndpoint("qux.test-zone.example.org", endpoint.RecordTypeA, "3.3.3.3").WithSetIdentifier("set/1")
+	qux.Labels[endpoint.ResourceLabelKey] = "ingress/default/qux"
+
+	// another replica claims a record after the records have been read
+	client.onLocked = func() {
+		assert.True(t, client.locked)
+		client.set(t, "/external-dns/registry/records/new.test-zone.example.org/A", etcdRecord{
+			DNSName:    "new.test-zone.example.org",
+			RecordType: endpoint.RecordTypeA,
+			Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "other-owner"},
+		})
+	}
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			qux,
+			endpoint.NewEndpoint("new.test-zone.example.org", endpoint.RecordTypeA, "4.4.4.4"),
+		},
+		UpdateOld: []*endpoint.Endpoint{bar},
+		UpdateNew: []*endpoint.Endpoint{updated},
+	}))
+	assert.False(t, client.locked)
+
+	assert.Equal(t, map[string]etcdRecord{
+		"/external-dns/registry/records/bar.test-zone.example.org/CNAME": {
+			DNSName:    "bar.test-zone.example.org",
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/other-ingress",
+			},
+		},
+		"/external-dns/registry/records/new.test-zone.example.org/A": {
+			DNSName:    "new.test-zone.example.org",
+			RecordType: endpoint.RecordTypeA,
+			Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "other-owner"},
+		},
+		"/external-dns/registry/records/qux.test-zone.example.org/A/set%2F1": {
+			DNSName:       "qux.test-zone.example.org",
+			RecordType:    endpoint.RecordTypeA,
+			SetIdentifier: "set/1",
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/qux",
+			},
+		},
+	}, client.records(t))
+
+	zoneRecords, err := p.Records(ctx)
+	require.NoError(t, err)
+	names := []string{}
+	for _, record := range zoneRecords {
+		names = append(names, record.DNSName)
+	}
+	assert.Contains(t, names, "qux.test-zone.example.org")
+	assert.NotContains(t, names, "new.test-zone.example.org")
+
+	// the cache contains the applied changes
+	client.onLocked = nil
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	// deletions release the ownership
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Delete: []*endpoint.Endpoint{qux},
+	}))
+	assert.Len(t, client.records(t), 2)
+	assert.NotContains(t, client.records(t), "/external-dns/registry/records/qux.test-zone.example.org/A/set%2F1")
+}
+
+func TestEtcdRegistryApplyChangesTakenOver(t *testing.T) {
+	client := newFakeEtcdClient()
+	client.set(t, "/external-dns/registry/records/bar.test-zone.example.org/CNAME", etcdRecord{
+		DNSName:    "bar.test-zone.example.org",
+		RecordType: endpoint.RecordTypeCNAME,
+		Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "test-owner"},
+	})
+	r, err := newEtcdRegistry(newConfigMapRegistryProvider(t), "test-owner", client, "/external-dns/registry", 0)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	var bar *endpoint.Endpoint
+	for _, record := range records {
+		if record.DNSName == "bar.test-zone.example.org" {
+			bar = record
+		}
+	}
+	require.NotNil(t, bar)
+
+	client.set(t, "/external-dns/registry/records/bar.test-zone.example.org/CNAME", etcdRecord{
+		DNSName:    "bar.test-zone.example.org",
+		RecordType: endpoint.RecordTypeCNAME,
+		Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "other-owner"},
+	})
+	updated := bar.DeepCopy()
+	updated.Labels[endpoint.ResourceLabelKey] = "ingress/default/other-ingress"
+	err = r.ApplyChanges(ctx, &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{bar},
+		UpdateNew: []*endpoint.Endpoint{updated},
+	})
+	require.ErrorIs(t, err, provider.SoftError)
+	assert.Equal(t, "other-owner", client.records(t)["/external-dns/registry/records/bar.test-zone.example.org/CNAME"].Labels[endpoint.OwnerLabelKey])
+
+	client.lockErr = errors.New("etcd unavailable")
+	require.ErrorIs(t, r.ApplyChanges(ctx, &plan.Changes{}), provider.SoftError)
+}
@@ -0,0 +1,328 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+const (
+	heartbeatOwnerKey   = "external-dns/heartbeat-owner"
+	heartbeatTimeKey    = "external-dns/heartbeat"
+	heartbeatVersionKey = "external-dns/heartbeat-version"
+)
+
+var staleOwnerRecords = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "external_dns",
+		Subsystem: "registry",
+		Name:      "stale_owner_records",
+		Help:      "Number of records owned by an owner whose last heartbeat is older than the freshness window.",
+	},
+	[]string{"owner"},
+)
+
+func init() {
+	prometheus.MustRegister(staleOwnerRecords)
+}
+
+// HeartbeatRegistry wraps a TXTRegistry to find the records of abandoned owners, e.g. of clusters
+// which have been deleted without cleaning up their records. Every owner publishes a heartbeat
+// as TXT record <owner id>.<domain> for each heartbeat domain, usually one per zone. The heartbeat
+// holds the owner ID, the version of ExternalDNS and the time of the last synchronization, so it
+// also tells operators which instances manage a zone.
+//
+// Records owned by an owner whose latest heartbeat is older than the freshness window are reported and,
+// if cleanup is enabled, deleted along with their ownership records. Owners which never published a
+// heartbeat are left alone. Both the heartbeats and the cleanup are maintenance changes, see Maintainer,
+// so reading the records never writes to the zone.
+type HeartbeatRegistry struct {
+	*TXTRegistry
+	domains   []string
+	version   string
+	interval  time.Duration
+	freshness time.Duration
+	cleanup   bool
+	now       func() time.Time
+
+	// lastPublished holds the time of the last heartbeat published by this instance per domain
+	lastPublished map[string]time.Time
+	// the heartbeats and the other records found by the last call of Records
+	seen heartbeats
+}
+
+// heartbeat is a heartbeat record as found in the zone.
+type heartbeat struct {
+	record *endpoint.Endpoint
+	owner  string
+	domain string
+	time   time.Time
+}
+
+// heartbeats are the heartbeats found in the zone along with the other records.
+type heartbeats struct {
+	// latest is the latest heartbeat of every owner
+	latest map[string]heartbeat
+	// own are the heartbeats of this instance by domain
+	own map[string]heartbeat
+	// all are all heartbeats of every owner, for the cleanup
+	all map[string][]heartbeat
+	// records are the records other than heartbeats
+	records []*endpoint.Endpoint
+}
+
+// NewHeartbeatRegistry returns a HeartbeatRegistry publishing a heartbeat under each of the given domains.
+// The heartbeats are renewed after the given interval and are stale once they are older than freshness.
+func NewHeartbeatRegistry(registry *TXTRegistry, domains []string, version string, interval, freshness time.Duration, cleanup bool) (*HeartbeatRegistry, error) {
+	if len(domains) == 0 {
+		return nil, errors.New("heartbeat domains cannot be empty")
+	}
+	trimmed := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.Trim(domain, ".")
+		if domain == "" {
+			return nil, errors.New("heartbeat domain cannot be empty")
+		}
+		trimmed = append(trimmed, domain)
+	}
+	if interval <= 0 || freshness <= interval {
+		return nil, errors.New("heartbeat interval must be positive and shorter than the freshness")
+	}
+	return &HeartbeatRegistry{
+		TXTRegistry:   registry,
+		domains:       trimmed,
+		version:       version,
+		interval:      interval,
+		freshness:     freshness,
+		cleanup:       cleanup,
+		now:           time.Now,
+		lastPublished: map[string]time.Time{},
+	}, nil
+}
+
+// Records returns the records of the wrapped registry without the heartbeat records.
+func (r *HeartbeatRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	records, err := r.TXTRegistry.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := heartbeats{
+		latest:  map[string]heartbeat{},
+		own:     map[string]heartbeat{},
+		all:     map[string][]heartbeat{},
+		records: make([]*endpoint.Endpoint, 0, len(records)),
+	}
+	for _, record := range records {
+		hb, ok := r.parseHeartbeat(record)
+		if !ok {
+			seen.records = append(seen.records, record)
+			continue
+		}
+		seen.all[hb.owner] = append(seen.all[hb.owner], hb)
+		if current, exists := seen.latest[hb.owner]; !exists || hb.time.After(current.time) {
+			seen.latest[hb.owner] = hb
+		}
+		if hb.owner == r.ownerID && hb.record.DNSName == r.heartbeatName(hb.domain) {
+			seen.own[hb.domain] = hb
+		}
+	}
+	r.seen = seen
+	return seen.records, nil
+}
+
+// MaintenanceChanges returns the heartbeats of this instance which are due and, if cleanup is enabled, the
+// deletion of the records and the heartbeats of stale owners. The stale owners are reported either way.
+func (r *HeartbeatRegistry) MaintenanceChanges() *plan.Changes {
+	changes := &plan.Changes{}
+	for _, domain := range r.domains {
+		r.heartbeatChanges(changes, domain, r.seen.own[domain])
+	}
+	r.staleOwnerChanges(changes)
+	return changes
+}
+
+// ApplyChanges applies the heartbeats and the deletion of the records of stale owners, which the wrapped
+// registry would leave alone as they aren't owned by this instance, along with the other changes. The
+// heartbeats of stale owners go last, so a failed cleanup is retried on the next synchronization.
+func (r *HeartbeatRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	heartbeatChanges := &plan.Changes{}
+	var stale []*endpoint.Endpoint
+	filtered := &plan.Changes{}
+	for _, ep := range changes.Create {
+		if _, ok := r.parseHeartbeat(ep); ok {
+			heartbeatChanges.Create = append(heartbeatChanges.Create, ep)
+		} else {
+			filtered.Create = append(filtered.Create, ep)
+		}
+	}
+	for i := range min(len(changes.UpdateOld), len(changes.UpdateNew)) {
+		if _, ok := r.parseHeartbeat(changes.UpdateNew[i]); ok {
+			heartbeatChanges.UpdateOld = append(heartbeatChanges.UpdateOld, changes.UpdateOld[i])
+			heartbeatChanges.UpdateNew = append(heartbeatChanges.UpdateNew, changes.UpdateNew[i])
+		} else {
+			filtered.UpdateOld = append(filtered.UpdateOld, changes.UpdateOld[i])
+			filtered.UpdateNew = append(filtered.UpdateNew, changes.UpdateNew[i])
+		}
+	}
+	for _, ep := range changes.Delete {
+		if _, ok := r.parseHeartbeat(ep); ok {
+			heartbeatChanges.Delete = append(heartbeatChanges.Delete, ep)
+		} else if r.isStale(ep) {
+			stale = append(stale, ep)
+		} else {
+			filtered.Delete = append(filtered.Delete, ep)
+		}
+	}
+
+	if err := r.TXTRegistry.ApplyChanges(ctx, filtered); err != nil {
+		return err
+	}
+	if len(stale) > 0 {
+		if err := r.DeleteRecords(ctx, stale); err != nil {
+			return fmt.Errorf("deleting the records of stale owners: %w", err)
+		}
+	}
+	if !heartbeatChanges.HasChanges() {
+		return nil
+	}
+	if err := r.provider.ApplyChanges(ctx, heartbeatChanges); err != nil {
+		return fmt.Errorf("applying heartbeats: %w", err)
+	}
+	now := r.now()
+	for _, ep := range slices.Concat(heartbeatChanges.Create, heartbeatChanges.UpdateNew) {
+		if hb, ok := r.parseHeartbeat(ep); ok && hb.owner == r.ownerID {
+			r.lastPublished[hb.domain] = now
+		}
+	}
+	return nil
+}
+
+func (r *HeartbeatRegistry) heartbeatName(domain string) string {
+	return r.ownerID + "." + domain
+}
+
+// parseHeartbeat returns the heartbeat of a heartbeat record.
+func (r *HeartbeatRegistry) parseHeartbeat(record *endpoint.Endpoint) (heartbeat, bool) {
+	if record.RecordType != endpoint.RecordTypeTXT || len(record.Targets) != 1 {
+		return heartbeat{}, false
+	}
+	hb := heartbeat{record: record}
+	for _, domain := range r.domains {
+		if strings.HasSuffix(record.DNSName, "."+domain) {
+			hb.domain = domain
+			break
+		}
+	}
+	if hb.domain == "" {
+		return heartbeat{}, false
+	}
+
+	var timestamp string
+	for _, token := range strings.Split(strings.Trim(record.Targets[0], "\""), ",") {
+		key, value, _ := strings.Cut(token, "=")
+		switch key {
+		case heartbeatOwnerKey:
+			hb.owner = value
+		case heartbeatTimeKey:
+			timestamp = value
+		}
+	}
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if hb.owner == "" || err != nil {
+		return heartbeat{}, false
+	}
+	hb.time = t
+	return hb, true
+}
+
+// heartbeatChanges adds the creation or the update of the heartbeat of this instance in the given domain
+// to the changes once the current one is older than the heartbeat interval.
+func (r *HeartbeatRegistry) heartbeatChanges(changes *plan.Changes, domain string, current heartbeat) {
+	now := r.now()
+	if now.Sub(current.time) < r.interval || now.Sub(r.lastPublished[domain]) < r.interval {
+		return
+	}
+
+	value := fmt.Sprintf("\"%s=%s,%s=%s,%s=%s\"",
+		heartbeatOwnerKey, r.ownerID,
+		heartbeatTimeKey, now.UTC().Format(time.RFC3339),
+		heartbeatVersionKey, r.version)
+	desired := endpoint.NewEndpoint(r.heartbeatName(domain), endpoint.RecordTypeTXT, value)
+	if current.record == nil {
+		changes.Create = append(changes.Create, desired)
+		return
+	}
+	desired.SetIdentifier = current.record.SetIdentifier
+	changes.UpdateOld = append(changes.UpdateOld, current.record)
+	changes.UpdateNew = append(changes.UpdateNew, desired)
+}
+
+// isStale returns true if the record is owned by another owner whose latest heartbeat is stale.
+func (r *HeartbeatRegistry) isStale(record *endpoint.Endpoint) bool {
+	owner := record.Labels[endpoint.OwnerLabelKey]
+	hb, ok := r.seen.latest[owner]
+	return owner != "" && owner != r.ownerID && ok && r.now().Sub(hb.time) > r.freshness
+}
+
+// staleOwnerChanges reports the records of owners whose heartbeat is stale and, if cleanup is enabled,
+// adds the deletion of the records and the heartbeats of these owners to the changes.
+func (r *HeartbeatRegistry) staleOwnerChanges(changes *plan.Changes) {
+	stale := map[string][]*endpoint.Endpoint{}
+	for _, record := range r.seen.records {
+		if r.isStale(record) {
+			owner := record.Labels[endpoint.OwnerLabelKey]
+			stale[owner] = append(stale[owner], record)
+		}
+	}
+
+	owners := make([]string, 0, len(stale))
+	for owner := range stale {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+
+	staleOwnerRecords.Reset()
+	for _, owner := range owners {
+		ownerRecords := stale[owner]
+		lastSeen := r.seen.latest[owner].time
+		staleOwnerRecords.WithLabelValues(owner).Set(float64(len(ownerRecords)))
+
+		if !r.cleanup {
+			log.Warnf("Owner %q has %d records but sent its last heartbeat at %s", owner, len(ownerRecords), lastSeen.UTC().Format(time.RFC3339))
+			continue
+		}
+
+		log.Warnf("Deleting %d records of owner %q which sent its last heartbeat at %s", len(ownerRecords), owner, lastSeen.UTC().Format(time.RFC3339))
+		changes.Delete = append(changes.Delete, ownerRecords...)
+		for _, hb := range r.seen.all[owner] {
+			changes.Delete = append(changes.Delete, hb.record)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+const (
+	heartbeatDomain      = "heartbeat." + testZone
+	otherHeartbeatDomain = "heartbeat.other." + testZone
+)
+
+func newHeartbeatRecord(owner string, t time.Time) *endpoint.Endpoint {
+	return endpoint.NewEndpoint(owner+"."+heartbeatDomain, endpoint.RecordTypeTXT, fmt.Sprintf("\"%s=%s,%s=%s\"", heartbeatOwnerKey, owner, heartbeatTimeKey, t.UTC().Format(time.RFC3339)))
+}
+
+// newHeartbeatTestProvider sets up a zone with records of the owners "owner", "gone" and "never". "gone"
+// sent its last heartbeat a day before now, "never" didn't send any.
+func newHeartbeatTestProvider(t *testing.T, now time.Time) *inmemory.InMemoryProvider {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+
+	for owner, name := range map[string]string{"owner": "own", "gone": "gone", "never": "never"} {
+		r, err := NewTXTRegistry(p, "", "", owner, 0, "", []string{endpoint.RecordTypeA}, nil, false, nil)
+		require.NoError(t, err)
+		require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+			Create: []*endpoint.Endpoint{endpoint.NewEndpoint(name+"."+testZone, endpoint.RecordTypeA, "1.2.3.4")},
+		}))
+	}
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{newHeartbeatRecord("gone", now.Add(-24*time.Hour))},
+	}))
+	return p
+}
+
+func newTestHeartbeatRegistry(t *testing.T, p *inmemory.InMemoryProvider, cleanup bool, now time.Time) *HeartbeatRegistry {
+	txt, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA}, nil, false, nil)
+	require.NoError(t, err)
+	r, err := NewHeartbeatRegistry(txt, []string{heartbeatDomain + "."}, "v0.15.0", 15*time.Minute, time.Hour, cleanup)
+	require.NoError(t, err)
+	r.now = func() time.Time { return now }
+	return r
+}
+
+func recordNames(records []*endpoint.Endpoint) []string {
+	names := make([]string, 0, len(records))
+	for _, r := range records {
+		names = append(names, r.DNSName+" "+r.RecordType)
+	}
+	return names
+}
+
+func TestNewHeartbeatRegistry(t *testing.T) {
+	txt, err := NewTXTRegistry(inmemory.NewInMemoryProvider(), "", "", "owner", 0, "", nil, nil, false, nil)
+	require.NoError(t, err)
+
+	_, err = NewHeartbeatRegistry(txt, nil, "", time.Minute, time.Hour, false)
+	assert.Error(t, err)
+	_, err = NewHeartbeatRegistry(txt, []string{heartbeatDomain, "."}, "", time.Minute, time.Hour, false)
+	assert.Error(t, err)
+	_, err = NewHeartbeatRegistry(txt, []string{heartbeatDomain}, "", 0, time.Hour, false)
+	assert.Error(t, err)
+	_, err = NewHeartbeatRegistry(txt, []string{heartbeatDomain}, "", time.Hour, time.Hour, false)
+	assert.Error(t, err)
+}
+
+// syncHeartbeats reads the records and applies the maintenance changes of the registry, as a synchronization does.
+func syncHeartbeats(t *testing.T, r *HeartbeatRegistry) []*endpoint.Endpoint {
+	ctx := context.Background()
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(ctx, r.MaintenanceChanges()))
+	return records
+}
+
+func TestHeartbeatRegistryRecordsReadOnly(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1717430400, 0).UTC()
+	p := newHeartbeatTestProvider(t, now)
+	r := newTestHeartbeatRegistry(t, p, true, now)
+
+	before, err := p.Records(ctx)
+	require.NoError(t, err)
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"own." + testZone + " A",
+		"gone." + testZone + " A",
+		"never." + testZone + " A",
+	}, recordNames(records))
+
+	after, err := p.Records(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, recordNames(before), recordNames(after))
+}
+
+func TestHeartbeatRegistryReportsStaleOwners(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1717430400, 0).UTC()
+	p := newHeartbeatTestProvider(t, now)
+	r := newTestHeartbeatRegistry(t, p, false, now)
+
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	changes := r.MaintenanceChanges()
+	assert.Empty(t, changes.Delete)
+	require.Len(t, changes.Create, 1)
+	assert.Equal(t, "owner."+heartbeatDomain, changes.Create[0].DNSName)
+	assert.Equal(t, 1.0, testutil.ToFloat64(staleOwnerRecords.WithLabelValues("gone")))
+	require.NoError(t, r.ApplyChanges(ctx, changes))
+	assert.ElementsMatch(t, []string{
+		"own." + testZone + " A",
+		"gone." + testZone + " A",
+		"never." + testZone + " A",
+	}, recordNames(records))
+
+	// the heartbeat of this instance has been published
+	zone, err := p.Records(ctx)
+	require.NoError(t, err)
+	// without an ownership record of its own
+	var heartbeatNames []string
+	for _, name := range recordNames(zone) {
+		if strings.Contains(name, heartbeatDomain) {
+			heartbeatNames = append(heartbeatNames, name)
+		}
+	}
+	assert.ElementsMatch(t, []string{"gone." + heartbeatDomain + " TXT", "owner." + heartbeatDomain + " TXT"}, heartbeatNames)
+	hb, ok := r.parseHeartbeat(findRecord(zone, "owner."+heartbeatDomain))
+	require.True(t, ok)
+	assert.Equal(t, "owner", hb.owner)
+	assert.Equal(t, now, hb.time)
+	assert.Contains(t, hb.record.Targets[0], heartbeatVersionKey+"=v0.15.0")
+
+	// the heartbeat is not renewed while it is fresh
+	r.now = func() time.Time { return now.Add(10 * time.Minute) }
+	syncHeartbeats(t, r)
+	zone, err = p.Records(ctx)
+	require.NoError(t, err)
+	hb, _ = r.parseHeartbeat(findRecord(zone, "owner."+heartbeatDomain))
+	assert.Equal(t, now, hb.time)
+
+	// but after the heartbeat interval
+	r.now = func() time.Time { return now.Add(20 * time.Minute) }
+	syncHeartbeats(t, r)
+	zone, err = p.Records(ctx)
+	require.NoError(t, err)
+	hb, _ = r.parseHeartbeat(findRecord(zone, "owner."+heartbeatDomain))
+	assert.Equal(t, now.Add(20*time.Minute), hb.time)
+}
+
+func TestHeartbeatRegistryCleansUpStaleOwners(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1717430400, 0).UTC()
+	p := newHeartbeatTestProvider(t, now)
+	r := newTestHeartbeatRegistry(t, p, true, now)
+
+	_, err := r.Records(ctx)
+	require.NoError(t, err)
+	changes := r.MaintenanceChanges()
+	assert.ElementsMatch(t, []string{
+		"gone." + testZone + " A",
+		"gone." + heartbeatDomain + " TXT",
+	}, recordNames(changes.Delete))
+	require.NoError(t, r.ApplyChanges(ctx, changes))
+
+	zone, err := p.Records(ctx)
+	require.NoError(t, err)
+	for _, record := range zone {
+		assert.NotContains(t, record.DNSName, "gone", "record %s of the stale owner is left", record.DNSName)
+	}
+	assert.Contains(t, recordNames(zone), "never."+testZone+" A")
+	assert.Contains(t, recordNames(zone), "a-never."+testZone+" TXT")
+	assert.Contains(t, recordNames(zone), "owner."+heartbeatDomain+" TXT")
+}
+
+func TestHeartbeatRegistryCleanupSubjectToPolicy(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1717430400, 0).UTC()
+	p := newHeartbeatTestProvider(t, now)
+	r := newTestHeartbeatRegistry(t, p, true, now)
+
+	_, err := r.Records(ctx)
+	require.NoError(t, err)
+	changes := (&plan.UpsertOnlyPolicy{}).Apply(r.MaintenanceChanges())
+	require.NoError(t, r.ApplyChanges(ctx, changes))
+
+	zone, err := p.Records(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, recordNames(zone), "gone."+testZone+" A")
+	assert.Contains(t, recordNames(zone), "gone."+heartbeatDomain+" TXT")
+}
+
+func TestHeartbeatRegistryMultipleDomains(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1717430400, 0).UTC()
+	p := newHeartbeatTestProvider(t, now)
+	// "gone" is still alive in another domain
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("gone."+otherHeartbeatDomain, endpoint.RecordTypeTXT,
+			fmt.Sprintf("\"%s=gone,%s=%s\"", heartbeatOwnerKey, heartbeatTimeKey, now.UTC().Format(time.RFC3339)))},
+	}))
+
+	txt, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA}, nil, false, nil)
+	require.NoError(t, err)
+	r, err := NewHeartbeatRegistry(txt, []string{heartbeatDomain, otherHeartbeatDomain}, "v0.15.0", 15*time.Minute, time.Hour, true)
+	require.NoError(t, err)
+	r.now = func() time.Time { return now }
+
+	records := syncHeartbeats(t, r)
+	assert.Contains(t, recordNames(records), "gone."+testZone+" A")
+
+	zone, err := p.Records(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, recordNames(zone), "gone."+testZone+" A")
+	assert.Contains(t, recordNames(zone), "owner."+heartbeatDomain+" TXT")
+	assert.Contains(t, recordNames(zone), "owner."+otherHeartbeatDomain+" TXT")
+}
+
+func findRecord(records []*endpoint.Endpoint, dnsName string) *endpoint.Endpoint {
+	for _, r := range records {
+		if r.DNSName == dnsName {
+			return r
+		}
+	}
+	return &endpoint.Endpoint{}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// MetadataRegistry implements registry interface with ownership stored in the provider-native metadata of the
+// records themselves, e.g. Cloudflare record comments or Azure record set metadata, instead of TXT records.
+// It requires a provider supporting record metadata, see provider.RecordMetadataProvider.
+type MetadataRegistry struct {
+	provider provider.Provider
+	ownerID  string // refers to the owner id of the current instance
+}
+
+// NewMetadataRegistry returns a new MetadataRegistry object.
+func NewMetadataRegistry(p provider.Provider, ownerID string) (*MetadataRegistry, error) {
+	if ownerID == "" {
+		return nil, errors.New("owner id cannot be empty")
+	}
+	if !provider.SupportsRecordMetadata(p) {
+		return nil, errors.New("provider doesn't support record metadata")
+	}
+
+	return &MetadataRegistry{
+		provider: p,
+		ownerID:  ownerID,
+	}, nil
+}
+
+func (im *MetadataRegistry) GetDomainFilter() endpoint.DomainFilterInterface {
+	return im.provider.GetDomainFilter()
+}
+
+func (im *MetadataRegistry) OwnerID() string {
+	return im.ownerID
+}
+
+// Records returns the current records from the dns provider with the labels read from their metadata.
+// Records whose metadata wasn't written by ExternalDNS, e.g. a comment added by hand, have no owner.
+func (im *MetadataRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	records, err := im.provider.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		metadata, ok := record.Labels[endpoint.RecordMetadataLabelKey]
+		if !ok {
+			continue
+		}
+		delete(record.Labels, endpoint.RecordMetadataLabelKey)
+		labels, err := endpoint.NewLabelsFromStringPlain(metadata)
+		if err != nil {
+			continue
+		}
+		// providers storing the labels in comments report them as description, too
+		if record.Labels[endpoint.DescriptionLabelKey] == metadata {
+			delete(record.Labels, endpoint.DescriptionLabelKey)
+		}
+		maps.Copy(record.Labels, labels)
+	}
+
+	return records, nil
+}
+
+// ApplyChanges stores the labels of created and updated records in their metadata and propagates the changes
+// to the dns provider. Only records owned by this instance are updated or deleted. If the metadata of a record
+// exceeds the length supported by the provider, no change is applied, as the provider would reject the batch.
+func (im *MetadataRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	filteredChanges := &plan.Changes{
+		Create:    changes.Create,
+		UpdateNew: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateNew),
+		UpdateOld: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateOld),
+		Delete:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete),
+	}
+
+	for _, r := range filteredChanges.Create {
+		if r.Labels == nil {
+			r.Labels = make(map[string]string)
+		}
+		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
+		setRecordMetadata(r)
+	}
+	for _, r := range filteredChanges.UpdateNew {
+		setRecordMetadata(r)
+	}
+	if err := im.checkMetadataLength(filteredChanges); err != nil {
+		return err
+	}
+
+	return im.provider.ApplyChanges(ctx, filteredChanges)
+}
+
+// AdjustEndpoints modifies the endpoints as needed by the specific provider.
+func (im *MetadataRegistry) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	return im.provider.AdjustEndpoints(endpoints)
+}
+
+// checkMetadataLength returns an error for every created or updated record whose metadata is longer than the
+// provider supports.
+func (im *MetadataRegistry) checkMetadataLength(changes *plan.Changes) error {
+	limit := provider.MaxRecordMetadataLength(im.provider)
+	if limit <= 0 {
+		return nil
+	}
+	var errs []error
+	for _, r := range slices.Concat(changes.Create, changes.UpdateNew) {
+		if length := len(r.Labels[endpoint.RecordMetadataLabelKey]); length > limit {
+			errs = append(errs, fmt.Errorf("metadata of %s record %s has %d characters, more than the %d supported by the provider; shorten its labels, e.g. the description, or use the txt registry", r.RecordType, r.DNSName, length, limit))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setRecordMetadata serializes the labels of the record into the label stored by the provider.
+func setRecordMetadata(r *endpoint.Endpoint) {
+	labels := maps.Clone(r.Labels)
+	delete(labels, endpoint.RecordMetadataLabelKey)
+	r.Labels[endpoint.RecordMetadataLabelKey] = labels.SerializePlain(false)
+}
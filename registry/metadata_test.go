@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+// metadataProvider is an in-memory provider storing the labels of the metadata registry with the records.
+type metadataProvider struct {
+	*inmemory.InMemoryProvider
+}
+
+func (p *metadataProvider) SupportsRecordMetadata() bool {
+	return true
+}
+
+// limitedMetadataProvider limits the length of the metadata of a record like Cloudflare comments.
+type limitedMetadataProvider struct {
+	metadataProvider
+	limit int
+}
+
+func (p *limitedMetadataProvider) MaxRecordMetadataLength() int {
+	return p.limit
+}
+
+func TestMetadataRegistryNew(t *testing.T) {
+	p := inmemory.NewInMemoryProvider()
+
+	_, err := NewMetadataRegistry(&metadataProvider{p}, "owner")
+	require.NoError(t, err)
+
+	_, err = NewMetadataRegistry(&metadataProvider{p}, "")
+	require.EqualError(t, err, "owner id cannot be empty")
+
+	_, err = NewMetadataRegistry(p, "owner")
+	require.EqualError(t, err, "provider doesn't support record metadata")
+}
+
+func TestMetadataRegistry(t *testing.T) {
+	ctx := context.Background()
+	p := &metadataProvider{inmemory.NewInMemoryProvider()}
+	require.NoError(t, p.CreateZone(testZone))
+
+	commented := endpoint.NewEndpoint("commented.test-zone.example.org", endpoint.RecordTypeA, "9.9.9.9")
+	commented.Labels[endpoint.DescriptionLabelKey] = "added by hand"
+	commented.Labels[endpoint.RecordMetadataLabelKey] = "added by hand"
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{commented}}))
+
+	r, err := NewMetadataRegistry(p, "owner")
+	require.NoError(t, err)
+
+	foo := endpoint.NewEndpoint("foo.test-zone.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	foo.Labels[endpoint.ResourceLabelKey] = "ingress/default/foo"
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{foo}}))
+
+	stored, err := p.Records(ctx)
+	require.NoError(t, err)
+	for _, record := range stored {
+		if record.DNSName == foo.DNSName {
+			assert.Equal(t, "heritage=external-dns,external-dns/owner=owner,external-dns/resource=ingress/default/foo", record.Labels[endpoint.RecordMetadataLabelKey])
+		}
+	}
+
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	labels := map[string]endpoint.Labels{}
+	for _, record := range records {
+		labels[record.DNSName] = record.Labels
+	}
+	assert.Equal(t, map[string]endpoint.Labels{
+		"commented.test-zone.example.org": {endpoint.DescriptionLabelKey: "added by hand"},
+		"foo.test-zone.example.org":       {endpoint.OwnerLabelKey: "owner", endpoint.ResourceLabelKey: "ingress/default/foo"},
+	}, labels)
+
+	// records of other owners are neither updated nor deleted
+	other := endpoint.NewEndpoint("foo.test-zone.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	other.Labels[endpoint.OwnerLabelKey] = "other"
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Delete: []*endpoint.Endpoint{other}}))
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+}
+
+func TestMetadataRegistryMetadataTooLong(t *testing.T) {
+	ctx := context.Background()
+	p := &limitedMetadataProvider{metadataProvider{inmemory.NewInMemoryProvider()}, 100}
+	require.NoError(t, p.CreateZone(testZone))
+
+	r, err := NewMetadataRegistry(p, "owner")
+	require.NoError(t, err)
+
+	short := endpoint.NewEndpoint("short.test-zone.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	long := endpoint.NewEndpoint("long.test-zone.example.org", endpoint.RecordTypeA, "1.2.3.5")
+	long.Labels[endpoint.ResourceLabelKey] = "ingress/a-long-namespace-name/a-long-ingress-name"
+	long.Labels[endpoint.DescriptionLabelKey] = "team a"
+	err = r.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{short, long}})
+	require.ErrorContains(t, err, "metadata of A record long.test-zone.example.org has")
+	assert.NotContains(t, err.Error(), "short.test-zone.example.org")
+
+	// nothing is applied
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{short}}))
+}
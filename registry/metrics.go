@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+var (
+	cacheLookupsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "registry",
+			Name:      "cache_lookups_total",
+			Help:      "Number of reads of the records by the registry, by whether they were served from the records cache.",
+		},
+		[]string{"registry", "result"},
+	)
+	txtRecordsReadTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "registry",
+			Name:      "txt_records_read_total",
+			Help:      "Number of ownership TXT records read from the provider by the TXT registry.",
+		},
+	)
+	txtRecordsWrittenTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "registry",
+			Name:      "txt_records_written_total",
+			Help:      "Number of ownership TXT records created, updated or deleted by the TXT registry.",
+		},
+		[]string{"operation"},
+	)
+	txtEncryptionFailuresTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "registry",
+			Name:      "txt_encryption_failures_total",
+			Help:      "Number of encrypted TXT records the TXT registry couldn't decrypt with any of its keys.",
+		},
+	)
+	labelPersistenceDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "external_dns",
+			Subsystem: "registry",
+			Name:      "label_persistence_duration_seconds",
+			Help:      "Time spent by one ApplyChanges of the registry persisting the labels of the records, apart from the provider.",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+		},
+		[]string{"registry"},
+	)
+	dualSecondaryMissingRecords = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "registry",
+			Name:      "dual_secondary_missing_records",
+			Help:      "Number of records owned in the primary registry whose ownership was missing in the secondary registry on the last read.",
+		},
+	)
+	dualSecondaryWriteFailuresTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "registry",
+			Name:      "dual_secondary_write_failures_total",
+			Help:      "Number of writes of the secondary registry which failed in dual registry mode.",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(cacheLookupsTotal)
+	prometheus.MustRegister(txtRecordsReadTotal)
+	prometheus.MustRegister(txtRecordsWrittenTotal)
+	prometheus.MustRegister(txtEncryptionFailuresTotal)
+	prometheus.MustRegister(labelPersistenceDuration)
+	prometheus.MustRegister(dualSecondaryMissingRecords)
+	prometheus.MustRegister(dualSecondaryWriteFailuresTotal)
+}
+
+// countCacheLookup counts a read of the records by a registry caching them.
+func countCacheLookup(registry string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookupsTotal.WithLabelValues(registry, result).Inc()
+}
+
+// labelPersistenceTimer measures the time one ApplyChanges of a registry storing the labels apart from the
+// records spends on the labels, which is all of the time but the time spent applying the records.
+type labelPersistenceTimer struct {
+	registry string
+	start    time.Time
+	provider time.Duration
+}
+
+func startLabelPersistenceTimer(registry string) *labelPersistenceTimer {
+	return &labelPersistenceTimer{registry: registry, start: time.Now()}
+}
+
+// applyChanges applies the changes of the records with the provider, excluding the time from the persistence.
+func (t *labelPersistenceTimer) applyChanges(ctx context.Context, p provider.Provider, changes *plan.Changes) error {
+	start := time.Now()
+	defer func() {
+		t.provider += time.Since(start)
+	}()
+	return p.ApplyChanges(ctx, changes)
+}
+
+// observe records the time spent on the labels since the timer was started.
+func (t *labelPersistenceTimer) observe() {
+	labelPersistenceDuration.WithLabelValues(t.registry).Observe((time.Since(t.start) - t.provider).Seconds())
+}
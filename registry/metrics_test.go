@@ -0,0 +1,62 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+type slowProvider struct {
+	provider.Provider
+	delay time.Duration
+}
+
+func (p *slowProvider) ApplyChanges(_ context.Context, _ *plan.Changes) error {
+	time.Sleep(p.delay)
+	return nil
+}
+
+func TestCountCacheLookup(t *testing.T) {
+	hits := testutil.ToFloat64(cacheLookupsTotal.WithLabelValues("test", "hit"))
+	misses := testutil.ToFloat64(cacheLookupsTotal.WithLabelValues("test", "miss"))
+
+	countCacheLookup("test", true)
+	countCacheLookup("test", false)
+	countCacheLookup("test", false)
+
+	assert.Equal(t, hits+1, testutil.ToFloat64(cacheLookupsTotal.WithLabelValues("test", "hit")))
+	assert.Equal(t, misses+2, testutil.ToFloat64(cacheLookupsTotal.WithLabelValues("test", "miss")))
+}
+
+func TestLabelPersistenceTimer(t *testing.T) {
+	timer := startLabelPersistenceTimer("test")
+	require.NoError(t, timer.applyChanges(context.Background(), &slowProvider{Provider: inmemory.NewInMemoryProvider(), delay: 10 * time.Millisecond}, &plan.Changes{}))
+	assert.GreaterOrEqual(t, timer.provider, 10*time.Millisecond)
+
+	timer.observe()
+	assert.GreaterOrEqual(t, testutil.CollectAndCount(labelPersistenceDuration), 1)
+}
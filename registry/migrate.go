@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// ownershipProvider passes only the changes of registry TXT records on to the provider, so a registry
+// can store the ownership of existing records without creating or deleting the records themselves.
+type ownershipProvider struct {
+	provider.Provider
+}
+
+// NewOwnershipProvider returns a provider for the target registry of MigrateRegistry, which drops all
+// changes but those of the TXT records of the TXT registry.
+func NewOwnershipProvider(p provider.Provider) provider.Provider {
+	return &ownershipProvider{Provider: p}
+}
+
+// SupportsMultiStringTXT reports whether the wrapped provider supports TXT records made of several strings.
+func (p *ownershipProvider) SupportsMultiStringTXT() bool {
+	return provider.SupportsMultiStringTXT(p.Provider)
+}
+
+func (p *ownershipProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	filtered := &plan.Changes{
+		Create:    ownershipRecords(changes.Create),
+		UpdateOld: ownershipRecords(changes.UpdateOld),
+		UpdateNew: ownershipRecords(changes.UpdateNew),
+		Delete:    ownershipRecords(changes.Delete),
+	}
+	if !filtered.HasChanges() {
+		return nil
+	}
+	return p.Provider.ApplyChanges(ctx, filtered)
+}
+
+// ownershipRecords returns the TXT records generated by the TXT registry of the given records.
+func ownershipRecords(records []*endpoint.Endpoint) []*endpoint.Endpoint {
+	var filtered []*endpoint.Endpoint
+	for _, r := range records {
+		if r.RecordType == endpoint.RecordTypeTXT && r.Labels[endpoint.OwnedRecordLabelKey] != "" {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// MigrateRegistry copies the labels of the records owned by the owner of the source registry to the target
+// registry and verifies that the target registry reports the same owner and resource afterwards. The target
+// registry has to use a provider returned by NewOwnershipProvider, so the records themselves are left alone.
+// Records which already have an owner in the target registry are not copied, migrating again is safe. The
+// source registry is left as it is, so the ownership can still be found there until the migration is verified.
+func MigrateRegistry(ctx context.Context, from, to Registry) error {
+	ownerID := from.OwnerID()
+	if ownerID != to.OwnerID() {
+		return fmt.Errorf("owner %q of the source registry differs from owner %q of the target registry", ownerID, to.OwnerID())
+	}
+
+	records, err := from.Records(ctx)
+	if err != nil {
+		return fmt.Errorf("reading the source registry: %w", err)
+	}
+	existing, err := to.Records(ctx)
+	if err != nil {
+		return fmt.Errorf("reading the target registry: %w", err)
+	}
+	changes, owned := ownershipChanges(records, existing, ownerID)
+
+	log.Infof("Migrating the ownership of %d of %d records owned by %q", len(changes.Create), len(owned), ownerID)
+	if changes.HasChanges() {
+		if err := to.ApplyChanges(ctx, changes); err != nil {
+			return fmt.Errorf("writing the target registry: %w", err)
+		}
+	}
+
+	// verify the ownership as read back from the target registry
+	migrated, err := to.Records(ctx)
+	if err != nil {
+		return fmt.Errorf("reading the target registry: %w", err)
+	}
+	inconsistent := 0
+	for _, r := range migrated {
+		source, ok := owned[r.Key()]
+		if !ok {
+			continue
+		}
+		delete(owned, r.Key())
+		if !isOwned(r, ownerID) || r.Labels[endpoint.ResourceLabelKey] != source.Labels[endpoint.ResourceLabelKey] {
+			log.Warnf("Ownership of %s %s differs in the target registry: owner %q, resource %q", r.RecordType, r.DNSName, r.Labels[endpoint.OwnerLabelKey], r.Labels[endpoint.ResourceLabelKey])
+			inconsistent++
+		}
+	}
+	for _, r := range owned {
+		log.Warnf("Record %s %s is missing in the target registry", r.RecordType, r.DNSName)
+		inconsistent++
+	}
+	if inconsistent > 0 {
+		return fmt.Errorf("ownership of %d records is inconsistent after the migration", inconsistent)
+	}
+	log.Infof("Verified the ownership of the records owned by %q in the target registry", ownerID)
+	return nil
+}
+
+// ownershipChanges returns the creates copying the ownership of the records owned by the owner to the target
+// registry whose records are given, along with all records owned by the owner. Records which already have an
+// owner in the target registry are skipped.
+func ownershipChanges(records, existing []*endpoint.Endpoint, ownerID string) (*plan.Changes, map[endpoint.EndpointKey]*endpoint.Endpoint) {
+	targets := make(map[endpoint.EndpointKey]*endpoint.Endpoint, len(existing))
+	for _, r := range existing {
+		targets[r.Key()] = r
+	}
+
+	owned := map[endpoint.EndpointKey]*endpoint.Endpoint{}
+	changes := &plan.Changes{}
+	for _, r := range records {
+		if !isOwned(r, ownerID) {
+			continue
+		}
+		key := r.Key()
+		owned[key] = r
+		var owner string
+		if target, ok := targets[key]; ok {
+			owner = target.Labels[endpoint.OwnerLabelKey]
+		}
+		switch {
+		case owner == ownerID:
+			log.Debugf("Skipping %s %s which is already owned in the target registry", r.RecordType, r.DNSName)
+		case owner != "":
+			log.Warnf("Cannot migrate %s %s which is owned by %q in the target registry", r.RecordType, r.DNSName, owner)
+		default:
+			migrated := &endpoint.Endpoint{
+				DNSName:          r.DNSName,
+				Targets:          r.Targets,
+				RecordType:       r.RecordType,
+				SetIdentifier:    r.SetIdentifier,
+				RecordTTL:        r.RecordTTL,
+				Labels:           r.Labels.WithoutTXTMetadata(),
+				ProviderSpecific: r.ProviderSpecific,
+			}
+			changes.Create = append(changes.Create, migrated)
+		}
+	}
+	return changes, owned
+}
+
+// isOwned reports whether the record is owned by the owner, alone or shared with others.
+func isOwned(r *endpoint.Endpoint, ownerID string) bool {
+	return r.Labels[endpoint.OwnerLabelKey] == ownerID || slices.Contains(r.Labels.Owners(), ownerID)
+}
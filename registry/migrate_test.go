@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+func TestMigrateRegistryTXTToConfigMap(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+
+	from, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, nil, false, nil)
+	require.NoError(t, err)
+	foo := newEndpointWithOwner("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner")
+	foo.Labels[endpoint.ResourceLabelKey] = "ingress/default/foo"
+	require.NoError(t, from.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			foo,
+			newEndpointWithOwner("bar.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, "owner"),
+		},
+	}))
+	other, err := NewTXTRegistry(p, "", "", "other", 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, nil, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, other.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{newEndpointWithOwner("other.test-zone.example.org", "9.9.9.9", endpoint.RecordTypeA, "other")},
+	}))
+	before, err := p.Records(ctx)
+	require.NoError(t, err)
+
+	client := fake.NewSimpleClientset()
+	to, err := NewConfigMapRegistry(NewOwnershipProvider(p), "owner", client, "default", "external-dns-registry", 0)
+	require.NoError(t, err)
+	require.NoError(t, MigrateRegistry(ctx, from, to))
+
+	assert.ElementsMatch(t, []configMapRecord{
+		{
+			DNSName:    "foo.test-zone.example.org",
+			RecordType: endpoint.RecordTypeA,
+			Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "owner", endpoint.ResourceLabelKey: "ingress/default/foo"},
+		},
+		{
+			DNSName:    "bar.test-zone.example.org",
+			RecordType: endpoint.RecordTypeA,
+			Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "owner"},
+		},
+	}, readConfigMapRecords(t, client))
+
+	after, err := p.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, after, len(before))
+
+	// migrating again changes nothing
+	require.NoError(t, MigrateRegistry(ctx, from, to))
+	assert.Len(t, readConfigMapRecords(t, client), 2)
+}
+
+func TestMigrateRegistryConfigMapToTXT(t *testing.T) {
+	ctx := context.Background()
+	p := newConfigMapRegistryProvider(t)
+	client := newConfigMapRegistryClient(t, []configMapRecord{
+		{
+			DNSName:    "foo.test-zone.example.org",
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "owner", endpoint.ResourceLabelKey: "ingress/default/foo"},
+		},
+	})
+	from, err := NewConfigMapRegistry(p, "owner", client, "default", "external-dns-registry", 0)
+	require.NoError(t, err)
+	to, err := NewTXTRegistry(NewOwnershipProvider(p), "", "", "owner", 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, nil, false, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, MigrateRegistry(ctx, from, to))
+
+	records, err := to.Records(ctx)
+	require.NoError(t, err)
+	owners := map[string]string{}
+	for _, r := range records {
+		owners[r.DNSName] = r.Labels[endpoint.OwnerLabelKey] + " " + r.Labels[endpoint.ResourceLabelKey]
+	}
+	assert.Equal(t, map[string]string{
+		"foo.test-zone.example.org": "owner ingress/default/foo",
+		"bar.test-zone.example.org": " ",
+	}, owners)
+}
+
+func TestMigrateRegistryDifferentOwners(t *testing.T) {
+	p := newConfigMapRegistryProvider(t)
+	from, err := NewConfigMapRegistry(p, "owner", fake.NewSimpleClientset(), "default", "external-dns-registry", 0)
+	require.NoError(t, err)
+	to, err := NewTXTRegistry(NewOwnershipProvider(p), "", "", "other", 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, nil, false, nil)
+	require.NoError(t, err)
+
+	assert.Error(t, MigrateRegistry(context.Background(), from, to))
+}
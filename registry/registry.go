@@ -17,7 +17,9 @@ limitations under the License.
 package registry
 
 import (
+	"cmp"
 	"context"
+	"slices"
 
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/plan"
@@ -34,3 +36,63 @@ type Registry interface {
 	GetDomainFilter() endpoint.DomainFilterInterface
 	OwnerID() string
 }
+
+// OrphanReporter is implemented by registries which can report their ownership entries without a record, e.g.
+// left behind by records deleted by hand.
+type OrphanReporter interface {
+	// OrphanedEntries returns the keys of the ownership entries of this owner whose records didn't exist when the
+	// records were last read from the provider.
+	OrphanedEntries() []endpoint.EndpointKey
+}
+
+// orphanedEntries returns the orphaned entries of the registry, if it reports them.
+func orphanedEntries(r Registry) []endpoint.EndpointKey {
+	if reporter, ok := r.(OrphanReporter); ok {
+		return reporter.OrphanedEntries()
+	}
+	return nil
+}
+
+// Maintainer is implemented by registries which keep records of their own up to date, e.g. heartbeats. The
+// controller applies their changes with ApplyChanges along with the changes of the plan, so the policy, the
+// deletion thresholds and the dry run apply to them like to any other change.
+type Maintainer interface {
+	// MaintenanceChanges returns the changes maintaining the registry, based on the records last read with Records.
+	MaintenanceChanges() *plan.Changes
+}
+
+// maintenanceChanges returns the maintenance changes of the registry, if it maintains records of its own.
+func maintenanceChanges(r Registry) *plan.Changes {
+	if maintainer, ok := r.(Maintainer); ok {
+		return maintainer.MaintenanceChanges()
+	}
+	return &plan.Changes{}
+}
+
+// ChangeFilter is implemented by registries which hold back some changes of a plan, e.g. damping. The controller
+// filters the changes of a plan with FilterChanges before applying them, so the changes held back are neither
+// applied nor reported as applied.
+type ChangeFilter interface {
+	// FilterChanges returns the changes the registry allows to apply.
+	FilterChanges(changes *plan.Changes) *plan.Changes
+}
+
+// filterChanges returns the changes the registry allows to apply, if it holds back any.
+func filterChanges(r Registry, changes *plan.Changes) *plan.Changes {
+	if filter, ok := r.(ChangeFilter); ok {
+		return filter.FilterChanges(changes)
+	}
+	return changes
+}
+
+// sortedKeys returns the keys of the map of ownership entries, sorted by name, type and set identifier.
+func sortedKeys[V any](entries map[endpoint.EndpointKey]V) []endpoint.EndpointKey {
+	keys := make([]endpoint.EndpointKey, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b endpoint.EndpointKey) int {
+		return cmp.Or(cmp.Compare(a.DNSName, b.DNSName), cmp.Compare(a.RecordType, b.RecordType), cmp.Compare(a.SetIdentifier, b.SetIdentifier))
+	})
+	return keys
+}
@@ -0,0 +1,479 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// sqlMigrations return the statements upgrading the schema of the table. The version of the schema
+// is the number of applied migrations, so new migrations are appended and existing ones are never
+// changed.
+var sqlMigrations = []func(db *sqlPgxDB) string{
+	func(db *sqlPgxDB) string {
+		return `CREATE TABLE ` + db.table.Sanitize() + ` (
+			dns_name text NOT NULL,
+			record_type text NOT NULL,
+			set_identifier text NOT NULL DEFAULT '',
+			labels jsonb NOT NULL,
+			updated_at timestamptz NOT NULL DEFAULT now(),
+			PRIMARY KEY (dns_name, record_type, set_identifier)
+		)`
+	},
+	func(db *sqlPgxDB) string {
+		return `CREATE INDEX ` + db.name("_owner") + ` ON ` + db.table.Sanitize() + ` ((labels->>'owner'))`
+	},
+}
+
+// sqlRegistryDB is the part of the database used by the SQLRegistry.
+type sqlRegistryDB interface {
+	// List returns the labels of all records.
+	List(ctx context.Context) (map[endpoint.EndpointKey]endpoint.Labels, error)
+	// Begin starts a transaction, transactions of all replicas sharing the table are serialized.
+	Begin(ctx context.Context) (sqlRegistryTx, error)
+}
+
+// sqlRegistryTx is a transaction of the sqlRegistryDB.
+type sqlRegistryTx interface {
+	List(ctx context.Context) (map[endpoint.EndpointKey]endpoint.Labels, error)
+	Put(ctx context.Context, key endpoint.EndpointKey, labels endpoint.Labels) error
+	Delete(ctx context.Context, key endpoint.EndpointKey) error
+	Commit(ctx context.Context) error
+	// Rollback aborts the transaction unless it has been committed.
+	Rollback(ctx context.Context) error
+}
+
+// SQLRegistry implements registry interface with ownership implemented via rows of a PostgreSQL
+// table. Changes are applied in a transaction serialized with the ones of other replicas and
+// owners sharing the table.
+type SQLRegistry struct {
+	provider provider.Provider
+	ownerID  string // refers to the owner id of the current instance
+
+	db sqlRegistryDB
+
+	// cache the labels of all owners as last read
+	labels         map[endpoint.EndpointKey]endpoint.Labels
+	orphanedLabels map[endpoint.EndpointKey]bool
+
+	// cache the records in memory and update on an interval instead.
+	recordsCache            []*endpoint.Endpoint
+	recordsCacheRefreshTime time.Time
+	cacheInterval           time.Duration
+}
+
+// NewSQLRegistry returns a new SQLRegistry object connecting to the PostgreSQL database with the
+// given connection string. The schema of the table is created or upgraded as needed.
+func NewSQLRegistry(ctx context.Context, provider provider.Provider, ownerID, dsn, table string, cacheInterval time.Duration) (*SQLRegistry, error) {
+	if table == "" {
+		return nil, errors.New("sql table cannot be empty")
+	}
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to the sql registry database: %w", err)
+	}
+	db := &sqlPgxDB{pool: pool, table: pgx.Identifier(strings.Split(table, "."))}
+	if err := db.Migrate(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return newSQLRegistry(provider, ownerID, db, cacheInterval)
+}
+
+func newSQLRegistry(provider provider.Provider, ownerID string, db sqlRegistryDB, cacheInterval time.Duration) (*SQLRegistry, error) {
+	if ownerID == "" {
+		return nil, errors.New("owner id cannot be empty")
+	}
+
+	return &SQLRegistry{
+		provider:      provider,
+		ownerID:       ownerID,
+		db:            db,
+		cacheInterval: cacheInterval,
+	}, nil
+}
+
+func (im *SQLRegistry) GetDomainFilter() endpoint.DomainFilterInterface {
+	return im.provider.GetDomainFilter()
+}
+
+func (im *SQLRegistry) OwnerID() string {
+	return im.ownerID
+}
+
+// OrphanedEntries returns the keys of the owned entries whose records didn't exist on the last read.
+func (im *SQLRegistry) OrphanedEntries() []endpoint.EndpointKey {
+	return sortedKeys(im.orphanedLabels)
+}
+
+// Records returns the current records from the registry.
+func (im *SQLRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	// If we have the zones cached AND we have refreshed the cache since the
+	// last given interval, then just use the cached results.
+	if im.recordsCache != nil && time.Since(im.recordsCacheRefreshTime) < im.cacheInterval {
+		log.Debug("Using cached records.")
+		countCacheLookup("sql", true)
+		return im.recordsCache, nil
+	}
+	if im.cacheInterval > 0 {
+		countCacheLookup("sql", false)
+	}
+
+	labels, err := im.db.List(ctx)
+	if err != nil {
+		return nil, provider.NewSoftError(fmt.Errorf("reading sql registry: %w", err))
+	}
+	im.labels = labels
+
+	records, err := im.provider.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orphanedLabels := map[endpoint.EndpointKey]bool{}
+	for key, l := range im.labels {
+		if l[endpoint.OwnerLabelKey] == im.ownerID {
+			orphanedLabels[key] = true
+		}
+	}
+	for _, record := range records {
+		key := record.Key()
+		if l, ok := im.labels[key]; ok {
+			record.Labels = maps.Clone(l)
+			delete(orphanedLabels, key)
+		} else {
+			record.Labels = endpoint.NewLabels()
+		}
+	}
+	im.orphanedLabels = orphanedLabels
+
+	// Update the cache.
+	if im.cacheInterval > 0 {
+		im.recordsCache = records
+		im.recordsCacheRefreshTime = time.Now()
+	}
+
+	return records, nil
+}
+
+// ApplyChanges records the ownership of created and updated records, updates the DNS provider and
+// then releases the ownership of deleted records in a single transaction. The ownership is
+// committed even if the provider fails, as some of the records may have been created.
+func (im *SQLRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	timer := startLabelPersistenceTimer("sql")
+	defer timer.observe()
+
+	tx, err := im.db.Begin(ctx)
+	if err != nil {
+		return provider.NewSoftError(fmt.Errorf("starting sql transaction: %w", err))
+	}
+	defer func() {
+		if err := tx.Rollback(context.Background()); err != nil {
+			log.Warnf("Failed to roll back sql transaction: %v", err)
+		}
+	}()
+
+	// another replica may have changed the ownership since the records were read
+	labels, err := tx.List(ctx)
+	if err != nil {
+		return provider.NewSoftError(fmt.Errorf("reading sql registry: %w", err))
+	}
+	im.labels = labels
+
+	filteredChanges := &plan.Changes{
+		Create:    make([]*endpoint.Endpoint, 0, len(changes.Create)),
+		UpdateNew: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateNew),
+		UpdateOld: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateOld),
+		Delete:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete),
+	}
+
+	for _, r := range changes.Create {
+		key := r.Key()
+		if owner := im.labels[key][endpoint.OwnerLabelKey]; owner != "" && owner != im.ownerID {
+			// We lost a race with a different owner or another owner has an orphaned ownership record.
+			log.Infof("Skipping endpoint %v because owner does not match", r)
+			continue
+		}
+		if r.Labels == nil {
+			r.Labels = make(map[string]string)
+		}
+		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
+		if err := im.writeLabels(ctx, tx, key, r.Labels); err != nil {
+			return err
+		}
+		filteredChanges.Create = append(filteredChanges.Create, r)
+		delete(im.orphanedLabels, key)
+		if im.cacheInterval > 0 {
+			im.addToCache(r)
+		}
+	}
+
+	for _, r := range filteredChanges.UpdateOld {
+		// remove old version of record from cache
+		if im.cacheInterval > 0 {
+			im.removeFromCache(r)
+		}
+	}
+
+	for _, r := range filteredChanges.UpdateNew {
+		key := r.Key()
+		if owner := im.labels[key][endpoint.OwnerLabelKey]; owner != "" && owner != im.ownerID {
+			im.recordsCache = nil
+			return provider.NewSoftError(fmt.Errorf("ownership of %s %s has been taken over by owner %s", key.RecordType, key.DNSName, owner))
+		}
+		if err := im.writeLabels(ctx, tx, key, r.Labels); err != nil {
+			return err
+		}
+		// add new version of record to caches
+		if im.cacheInterval > 0 {
+			im.addToCache(r)
+		}
+	}
+
+	// When caching is enabled, disable the provider from using the cache.
+	if im.cacheInterval > 0 {
+		ctx = context.WithValue(ctx, provider.RecordsContextKey, nil)
+	}
+	if err := timer.applyChanges(ctx, im.provider, filteredChanges); err != nil {
+		im.recordsCache = nil
+		if commitErr := tx.Commit(ctx); commitErr != nil {
+			log.Warnf("Failed to commit sql transaction: %v", commitErr)
+			im.labels = nil
+		}
+		return err
+	}
+
+	releases := map[endpoint.EndpointKey]bool{}
+	for _, r := range filteredChanges.Delete {
+		releases[r.Key()] = true
+		if im.cacheInterval > 0 {
+			im.removeFromCache(r)
+		}
+	}
+	maps.Copy(releases, im.orphanedLabels)
+	im.orphanedLabels = nil
+	for key := range releases {
+		if im.labels[key][endpoint.OwnerLabelKey] != im.ownerID {
+			continue
+		}
+		if err := tx.Delete(ctx, key); err != nil {
+			return provider.NewSoftError(fmt.Errorf("deleting ownership of %s %s: %w", key.RecordType, key.DNSName, err))
+		}
+		delete(im.labels, key)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		im.labels = nil
+		im.recordsCache = nil
+		return provider.NewSoftError(fmt.Errorf("committing sql transaction: %w", err))
+	}
+	return nil
+}
+
+// AdjustEndpoints modifies the endpoints as needed by the specific provider.
+func (im *SQLRegistry) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	return im.provider.AdjustEndpoints(endpoints)
+}
+
+// writeLabels stores the labels of a record unless they are stored already.
+func (im *SQLRegistry) writeLabels(ctx context.Context, tx sqlRegistryTx, key endpoint.EndpointKey, labels endpoint.Labels) error {
+	if old, ok := im.labels[key]; ok && maps.Equal(old, labels) {
+		return nil
+	}
+	if err := tx.Put(ctx, key, labels); err != nil {
+		im.recordsCache = nil
+		return provider.NewSoftError(fmt.Errorf("writing ownership of %s %s: %w", key.RecordType, key.DNSName, err))
+	}
+	im.labels[key] = maps.Clone(labels)
+	return nil
+}
+
+func (im *SQLRegistry) addToCache(ep *endpoint.Endpoint) {
+	if im.recordsCache != nil {
+		im.recordsCache = append(im.recordsCache, ep)
+	}
+}
+
+func (im *SQLRegistry) removeFromCache(ep *endpoint.Endpoint) {
+	if im.recordsCache == nil || ep == nil {
+		return
+	}
+
+	for i, e := range im.recordsCache {
+		if e.DNSName == ep.DNSName && e.RecordType == ep.RecordType && e.SetIdentifier == ep.SetIdentifier && e.Targets.Same(ep.Targets) {
+			// We found a match; delete the endpoint from the cache.
+			im.recordsCache = append(im.recordsCache[:i], im.recordsCache[i+1:]...)
+			return
+		}
+	}
+}
+
+// sqlPgxDB implements the sqlRegistryDB with a pgx connection pool. Transactions are serialized
+// with an advisory lock derived from the name of the table.
+type sqlPgxDB struct {
+	pool  *pgxpool.Pool
+	table pgx.Identifier
+}
+
+// queryer is implemented by both the pool and the transactions.
+type queryer interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func (db *sqlPgxDB) List(ctx context.Context) (map[endpoint.EndpointKey]endpoint.Labels, error) {
+	return db.list(ctx, db.pool)
+}
+
+func (db *sqlPgxDB) Begin(ctx context.Context) (sqlRegistryTx, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", db.lockID()); err != nil {
+		_ = tx.Rollback(ctx)
+		return nil, err
+	}
+	return &sqlPgxTx{db: db, tx: tx}, nil
+}
+
+// Migrate applies the migrations which haven't been applied yet.
+func (db *sqlPgxDB) Migrate(ctx context.Context) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("starting sql migration: %w", err)
+	}
+	defer func() { _ = tx.Rollback(context.Background()) }()
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", db.lockID()); err != nil {
+		return fmt.Errorf("locking sql migration: %w", err)
+	}
+	migrations := db.identifier("_migrations")
+	if _, err := tx.Exec(ctx, "CREATE TABLE IF NOT EXISTS "+migrations+" (version integer PRIMARY KEY, applied_at timestamptz NOT NULL DEFAULT now())"); err != nil {
+		return fmt.Errorf("creating sql migrations table: %w", err)
+	}
+	var version int
+	if err := tx.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM "+migrations).Scan(&version); err != nil {
+		return fmt.Errorf("reading sql schema version: %w", err)
+	}
+	if version > len(sqlMigrations) {
+		return fmt.Errorf("sql schema version %d is newer than the supported version %d", version, len(sqlMigrations))
+	}
+	for i := version; i < len(sqlMigrations); i++ {
+		log.Infof("Applying sql registry migration %d to %s", i+1, db.table.Sanitize())
+		if _, err := tx.Exec(ctx, sqlMigrations[i](db)); err != nil {
+			return fmt.Errorf("applying sql migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(ctx, "INSERT INTO "+migrations+" (version) VALUES ($1)", i+1); err != nil {
+			return fmt.Errorf("recording sql migration %d: %w", i+1, err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (db *sqlPgxDB) list(ctx context.Context, q queryer) (map[endpoint.EndpointKey]endpoint.Labels, error) {
+	rows, err := q.Query(ctx, "SELECT dns_name, record_type, set_identifier, labels FROM "+db.table.Sanitize())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := map[endpoint.EndpointKey]endpoint.Labels{}
+	for rows.Next() {
+		var key endpoint.EndpointKey
+		var value []byte
+		if err := rows.Scan(&key.DNSName, &key.RecordType, &key.SetIdentifier, &value); err != nil {
+			return nil, err
+		}
+		l := endpoint.NewLabels()
+		if err := json.Unmarshal(value, &l); err != nil {
+			log.Warnf("Skipping sql registry row of %s %s with invalid labels: %v", key.RecordType, key.DNSName, err)
+			continue
+		}
+		labels[key] = l
+	}
+	return labels, rows.Err()
+}
+
+// identifier returns the quoted name of the table with the given suffix, in the schema of the table.
+func (db *sqlPgxDB) identifier(suffix string) string {
+	id := append(pgx.Identifier{}, db.table...)
+	id[len(id)-1] += suffix
+	return id.Sanitize()
+}
+
+// name returns the quoted name of the table with the given suffix, without the schema.
+func (db *sqlPgxDB) name(suffix string) string {
+	return pgx.Identifier{db.table[len(db.table)-1] + suffix}.Sanitize()
+}
+
+func (db *sqlPgxDB) lockID() int64 {
+	h := fnv.New64a()
+	h.Write([]byte(db.table.Sanitize()))
+	return int64(h.Sum64())
+}
+
+type sqlPgxTx struct {
+	db *sqlPgxDB
+	tx pgx.Tx
+}
+
+func (t *sqlPgxTx) List(ctx context.Context) (map[endpoint.EndpointKey]endpoint.Labels, error) {
+	return t.db.list(ctx, t.tx)
+}
+
+func (t *sqlPgxTx) Put(ctx context.Context, key endpoint.EndpointKey, labels endpoint.Labels) error {
+	value, err := json.Marshal(labels)
+	if err != nil {
+		return err
+	}
+	_, err = t.tx.Exec(ctx, "INSERT INTO "+t.db.table.Sanitize()+" (dns_name, record_type, set_identifier, labels) VALUES ($1, $2, $3, $4) "+
+		"ON CONFLICT (dns_name, record_type, set_identifier) DO UPDATE SET labels = EXCLUDED.labels, updated_at = now()",
+		key.DNSName, key.RecordType, key.SetIdentifier, string(value))
+	return err
+}
+
+func (t *sqlPgxTx) Delete(ctx context.Context, key endpoint.EndpointKey) error {
+	_, err := t.tx.Exec(ctx, "DELETE FROM "+t.db.table.Sanitize()+" WHERE dns_name = $1 AND record_type = $2 AND set_identifier = $3",
+		key.DNSName, key.RecordType, key.SetIdentifier)
+	return err
+}
+
+func (t *sqlPgxTx) Commit(ctx context.Context) error {
+	return t.tx.Commit(ctx)
+}
+
+func (t *sqlPgxTx) Rollback(ctx context.Context) error {
+	if err := t.tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// fakeSQLDB keeps the rows in memory. Transactions work on a copy of the rows which replaces them
+// on commit, hooks run once a transaction has been started.
+type fakeSQLDB struct {
+	rows     map[endpoint.EndpointKey]endpoint.Labels
+	inTx     bool
+	onBegin  func()
+	beginErr error
+}
+
+func newFakeSQLDB() *fakeSQLDB {
+	return &fakeSQLDB{rows: map[endpoint.EndpointKey]endpoint.Labels{}}
+}
+
+func (db *fakeSQLDB) List(context.Context) (map[endpoint.EndpointKey]endpoint.Labels, error) {
+	return cloneSQLRows(db.rows), nil
+}
+
+func (db *fakeSQLDB) Begin(context.Context) (sqlRegistryTx, error) {
+	if db.beginErr != nil {
+		return nil, db.beginErr
+	}
+	if db.inTx {
+		return nil, errors.New("transaction already in progress")
+	}
+	db.inTx = true
+	if db.onBegin != nil {
+		db.onBegin()
+	}
+	return &fakeSQLTx{db: db, rows: cloneSQLRows(db.rows)}, nil
+}
+
+type fakeSQLTx struct {
+	db   *fakeSQLDB
+	rows map[endpoint.EndpointKey]endpoint.Labels
+	done bool
+}
+
+func (tx *fakeSQLTx) List(context.Context) (map[endpoint.EndpointKey]endpoint.Labels, error) {
+	return cloneSQLRows(tx.rows), nil
+}
+
+func (tx *fakeSQLTx) Put(_ context.Context, key endpoint.EndpointKey, labels endpoint.Labels) error {
+	tx.rows[key] = maps.Clone(labels)
+	return nil
+}
+
+func (tx *fakeSQLTx) Delete(_ context.Context, key endpoint.EndpointKey) error {
+	delete(tx.rows, key)
+	return nil
+}
+
+func (tx *fakeSQLTx) Commit(context.Context) error {
+	tx.db.rows = tx.rows
+	tx.done = true
+	tx.db.inTx = false
+	return nil
+}
+
+func (tx *fakeSQLTx) Rollback(context.Context) error {
+	if !tx.done {
+		tx.done = true
+		tx.db.inTx = false
+	}
+	return nil
+}
+
+func cloneSQLRows(rows map[endpoint.EndpointKey]endpoint.Labels) map[endpoint.EndpointKey]endpoint.Labels {
+	clone := make(map[endpoint.EndpointKey]endpoint.Labels, len(rows))
+	for key, labels := range rows {
+		clone[key] = maps.Clone(labels)
+	}
+	return clone
+}
+
+// failingApplyProvider fails to apply the changes after applying them.
+type failingApplyProvider struct {
+	provider.Provider
+}
+
+func (p failingApplyProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	if err := p.Provider.ApplyChanges(ctx, changes); err != nil {
+		return err
+	}
+	return errors.New("provider failure")
+}
+
+func TestSQLRegistryNew(t *testing.T) {
+	p := newConfigMapRegistryProvider(t)
+
+	_, err := newSQLRegistry(p, "test-owner", newFakeSQLDB(), time.Hour)
+	require.NoError(t, err)
+
+	_, err = newSQLRegistry(p, "", newFakeSQLDB(), time.Hour)
+	require.EqualError(t, err, "owner id cannot be empty")
+
+	_, err = NewSQLRegistry(context.Background(), p, "test-owner", "postgres://localhost/externaldns", "", time.Hour)
+	require.EqualError(t, err, "sql table cannot be empty")
+}
+
+func TestSQLRegistryRecords(t *testing.T) {
+	db := newFakeSQLDB()
+	db.rows[endpoint.EndpointKey{DNSName: "bar.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}] = endpoint.Labels{
+		endpoint.OwnerLabelKey:    "test-owner",
+		endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+	}
+	r, err := newSQLRegistry(newConfigMapRegistryProvider(t), "test-owner", db, 0)
+	require.NoError(t, err)
+
+	records, err := r.Records(context.Background())
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		{
+			DNSName:    "foo.test-zone.example.org",
+			Targets:    endpoint.Targets{"foo.loadbalancer.com"},
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey: "",
+			},
+		},
+		{
+			DNSName:    "bar.test-zone.example.org",
+			Targets:    endpoint.Targets{"my-domain.com"},
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+			},
+		},
+	}), "actual: %v", records)
+}
+
+func TestSQLRegistryApplyChanges(t *testing.T) {
+	barKey := endpoint.EndpointKey{DNSName: "bar.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}
+	orphanKey := endpoint.EndpointKey{DNSName: "orphan.test-zone.example.org", RecordType: endpoint.RecordTypeA}
+	newKey := endpoint.EndpointKey{DNSName: "new.test-zone.example.org", RecordType: endpoint.RecordTypeA}
+	quxKey := endpoint.EndpointKey{DNSName: "qux.test-zone.example.org", RecordType: endpoint.RecordTypeA, SetIdentifier: "set-1"}
+
+	db := newFakeSQLDB()
+	db.rows[barKey] = endpoint.Labels{
+		endpoint.OwnerLabelKey:    "test-owner",
+		endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+	}
+	// the record has been deleted from the zone
+	db.rows[orphanKey] = endpoint.Labels{endpoint.OwnerLabelKey: "test-owner"}
+	p := newConfigMapRegistryProvider(t)
+	r, err := newSQLRegistry(p, "test-owner", db, time.Hour)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+
+	var bar *endpoint.Endpoint
+	for _, record := range records {
+		if record.DNSName == "bar.test-zone.example.org" {
+			bar = record
+		}
+	}
+	require.NotNil(t, bar)
+	updated := bar.DeepCopy()
+	updated.Targets = endpoint.Targets{"other-domain.com"}
+	updated.Labels[endpoint.ResourceLabelKey] = "ingress/default/other-ingress"
+	qux := endpoint.NewEndpoint("qux.test-zone.example.org", endpoint.RecordTypeA, "3.3.3.3").WithSetIdentifier("set-1")
+	qux.Labels[endpoint.ResourceLabelKey] = "ingress/default/qux"
+
+	// another replica claims a record after the records have been read
+	db.onBegin = func() {
+		db.rows[newKey] = endpoint.Labels{endpoint.OwnerLabelKey: "other-owner"}
+	}
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			qux,
+			endpoint.NewEndpoint("new.test-zone.example.org", endpoint.RecordTypeA, "4.4.4.4"),
+		},
+		UpdateOld: []*endpoint.Endpoint{bar},
+		UpdateNew: []*endpoint.Endpoint{updated},
+	}))
+	assert.False(t, db.inTx)
+
+	assert.Equal(t, map[endpoint.EndpointKey]endpoint.Labels{
+		barKey: {
+			endpoint.OwnerLabelKey:    "test-owner",
+			endpoint.ResourceLabelKey: "ingress/default/other-ingress",
+		},
+		newKey: {endpoint.OwnerLabelKey: "other-owner"},
+		quxKey: {
+			endpoint.OwnerLabelKey:    "test-owner",
+			endpoint.ResourceLabelKey: "ingress/default/qux",
+		},
+	}, db.rows)
+
+	zoneRecords, err := p.Records(ctx)
+	require.NoError(t, err)
+	names := []string{}
+	for _, record := range zoneRecords {
+		names = append(names, record.DNSName)
+	}
+	assert.Contains(t, names, "qux.test-zone.example.org")
+	assert.NotContains(t, names, "new.test-zone.example.org")
+
+	// the cache contains the applied changes
+	db.onBegin = nil
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	// deletions release the ownership
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Delete: []*endpoint.Endpoint{qux},
+	}))
+	assert.Len(t, db.rows, 2)
+	assert.NotContains(t, db.rows, quxKey)
+}
+
+func TestSQLRegistryApplyChangesProviderFailure(t *testing.T) {
+	db := newFakeSQLDB()
+	db.rows[endpoint.EndpointKey{DNSName: "bar.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}] = endpoint.Labels{
+		endpoint.OwnerLabelKey: "test-owner",
+	}
+	r, err := newSQLRegistry(failingApplyProvider{newConfigMapRegistryProvider(t)}, "test-owner", db, 0)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	var bar *endpoint.Endpoint
+	for _, record := range records {
+		if record.DNSName == "bar.test-zone.example.org" {
+			bar = record
+		}
+	}
+	require.NotNil(t, bar)
+
+	// the ownership of created records is kept, the ownership of deleted ones isn't released
+	err = r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("qux.test-zone.example.org", endpoint.RecordTypeA, "3.3.3.3")},
+		Delete: []*endpoint.Endpoint{bar},
+	})
+	require.EqualError(t, err, "provider failure")
+	assert.False(t, db.inTx)
+	assert.Equal(t, map[endpoint.EndpointKey]endpoint.Labels{
+		{DNSName: "bar.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {endpoint.OwnerLabelKey: "test-owner"},
+		{DNSName: "qux.test-zone.example.org", RecordType: endpoint.RecordTypeA}:     {endpoint.OwnerLabelKey: "test-owner"},
+	}, db.rows)
+}
+
+func TestSQLRegistryApplyChangesTakenOver(t *testing.T) {
+	barKey := endpoint.EndpointKey{DNSName: "bar.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}
+	db := newFakeSQLDB()
+	db.rows[barKey] = endpoint.Labels{endpoint.OwnerLabelKey: "test-owner"}
+	r, err := newSQLRegistry(newConfigMapRegistryProvider(t), "test-owner", db, 0)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	var bar *endpoint.Endpoint
+	for _, record := range records {
+		if record.DNSName == "bar.test-zone.example.org" {
+			bar = record
+		}
+	}
+	require.NotNil(t, bar)
+
+	db.rows[barKey] = endpoint.Labels{endpoint.OwnerLabelKey: "other-owner"}
+	updated := bar.DeepCopy()
+	updated.Labels[endpoint.ResourceLabelKey] = "ingress/default/other-ingress"
+	err = r.ApplyChanges(ctx, &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{bar},
+		UpdateNew: []*endpoint.Endpoint{updated},
+	})
+	require.ErrorIs(t, err, provider.SoftError)
+	assert.False(t, db.inTx)
+	assert.Equal(t, "other-owner", db.rows[barKey][endpoint.OwnerLabelKey])
+
+	db.beginErr = errors.New("database unavailable")
+	require.ErrorIs(t, r.ApplyChanges(ctx, &plan.Changes{}), provider.SoftError)
+}
+
+func TestSQLMigrations(t *testing.T) {
+	db := &sqlPgxDB{table: pgx.Identifier{"dns", "records"}}
+
+	assert.Equal(t, `"dns"."records_migrations"`, db.identifier("_migrations"))
+	assert.Contains(t, sqlMigrations[0](db), `CREATE TABLE "dns"."records" (`)
+	assert.Equal(t, `CREATE INDEX "records_owner" ON "dns"."records" ((labels->>'owner'))`, sqlMigrations[1](db))
+}
@@ -17,9 +17,15 @@ limitations under the License.
 package registry
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -31,7 +37,15 @@ import (
 
 const (
 	recordTemplate              = "%{record_type}"
+	zoneTemplate                = "%{zone}"
+	hashTemplate                = "%{hash}"
 	providerSpecificForceUpdate = "txt/force-update"
+	// expiredOwnerLabelKey holds the owner of a record whose ownership lease has expired
+	expiredOwnerLabelKey = "txt/expired-owner"
+	// ownerlessLabelKey marks records of an ownerless record type without TXT records
+	ownerlessLabelKey = "txt/ownerless"
+	// unmappedOwnerLabelKey marks records in a zone with a mapped owner ID whose TXT records still name the owner ID
+	unmappedOwnerLabelKey = "txt/unmapped-owner"
 )
 
 // TXTRegistry implements registry interface with ownership implemented via associated TXT records
@@ -44,6 +58,8 @@ type TXTRegistry struct {
 	recordsCache            []*endpoint.Endpoint
 	recordsCacheRefreshTime time.Time
 	cacheInterval           time.Duration
+	// cacheInvalidated is set by InvalidateCache, which may be called concurrently with the other methods.
+	cacheInvalidated atomic.Bool
 
 	// optional string to use to replace the asterisk in wildcard entries - without using this,
 	// registry TXT records corresponding to wildcard records will be invalid (and rejected by most providers), due to
@@ -56,6 +72,29 @@ type TXTRegistry struct {
 	// encrypt text records
 	txtEncryptEnabled bool
 	txtEncryptAESKey  []byte
+	// additional keys for decrypting text records encrypted with a previous key
+	txtDecryptAESKeys [][]byte
+
+	// format of the payload of created and updated TXT records
+	txtFormat string
+	// compress the payload of created and updated TXT records
+	txtCompress bool
+
+	// duration of the ownership leases, 0 if the ownership doesn't expire
+	leaseTTL time.Duration
+	now      func() time.Time
+
+	// record types managed without TXT records, which are owned by this instance regardless of their owner
+	ownerlessRecordTypes []string
+
+	// owner IDs of the records in the domains, which are reported with the owner ID of this instance
+	ownerIDMap map[string]string
+
+	// zone the TXT records are written to instead of the zones of the records, if any
+	registryZone string
+
+	// owned TXT records without a record as of the last read
+	orphanedEntries map[endpoint.EndpointKey]struct{}
 }
 
 // NewTXTRegistry returns new TXTRegistry object
@@ -77,6 +116,9 @@ func NewTXTRegistry(provider provider.Provider, txtPrefix, txtSuffix, ownerID st
 	}
 
 	mapper := newaffixNameMapper(txtPrefix, txtSuffix, txtWildcardReplacement)
+	if mapper.zoneInAffix() {
+		mapper = mapper.withZones(provider.GetDomainFilter())
+	}
 
 	return &TXTRegistry{
 		provider:            provider,
@@ -88,9 +130,132 @@ func NewTXTRegistry(provider provider.Provider, txtPrefix, txtSuffix, ownerID st
 		excludeRecordTypes:  excludeRecordTypes,
 		txtEncryptEnabled:   txtEncryptEnabled,
 		txtEncryptAESKey:    txtEncryptAESKey,
+		txtFormat:           endpoint.TXTFormatV2,
+		now:                 time.Now,
 	}, nil
 }
 
+// SetFormat sets the format of the payload of created and updated TXT records. TXT records are
+// read in any format, and owned TXT records in another format are migrated by updating them.
+func (im *TXTRegistry) SetFormat(format string) error {
+	switch format {
+	case endpoint.TXTFormatV2, endpoint.TXTFormatV3:
+		im.txtFormat = format
+		return nil
+	}
+	return fmt.Errorf("unknown TXT format %q", format)
+}
+
+// SetCompression enables the compression of the payload of created and updated TXT records, for records
+// with many labels. Owned TXT records compressed otherwise are migrated by updating them. Encrypted
+// payloads are compressed regardless.
+func (im *TXTRegistry) SetCompression(enabled bool) {
+	im.txtCompress = enabled
+}
+
+// SetDecryptionKeys sets additional keys for decrypting TXT records, so the encryption key can be
+// rotated. Owned TXT records decrypted with one of these keys are migrated to the encryption key by
+// updating them.
+func (im *TXTRegistry) SetDecryptionKeys(keys [][]byte) error {
+	for _, key := range keys {
+		if len(key) != 32 {
+			return errors.New("the AES Decryption keys must have a length of 32 bytes")
+		}
+	}
+	im.txtDecryptAESKeys = keys
+	return nil
+}
+
+// SetOwnershipLease enables leases of the ownership of records with the given duration. Leases of
+// owned records are renewed once half of the duration has passed, records of other owners whose
+// lease has expired are reported without an owner, so they can be adopted.
+func (im *TXTRegistry) SetOwnershipLease(ttl time.Duration) error {
+	if ttl < 0 {
+		return errors.New("the ownership lease cannot be negative")
+	}
+	im.leaseTTL = ttl
+	return nil
+}
+
+// SetOwnerlessRecordTypes makes the registry manage records of the given types without TXT records.
+// All records of these types are considered owned by this instance, including the records created
+// by hand or by other owners. Existing TXT records of these records are removed on their next update.
+func (im *TXTRegistry) SetOwnerlessRecordTypes(recordTypes []string) {
+	im.ownerlessRecordTypes = recordTypes
+}
+
+// isOwnerless returns true if the record is of a type managed without TXT records.
+func (im *TXTRegistry) isOwnerless(r *endpoint.Endpoint) bool {
+	return slices.Contains(im.ownerlessRecordTypes, r.RecordType)
+}
+
+// SetOwnerIDMap makes the TXT records of the records in the given domains name the mapped owner ID
+// instead of the owner ID of this instance, so the ownership is partitioned by domain. The records
+// are still reported with the owner ID of this instance. TXT records in a mapped domain naming the
+// owner ID of this instance are migrated to the mapped owner ID.
+func (im *TXTRegistry) SetOwnerIDMap(ownerIDs map[string]string) error {
+	im.ownerIDMap = make(map[string]string, len(ownerIDs))
+	for domain, ownerID := range ownerIDs {
+		if ownerID == "" {
+			return fmt.Errorf("the owner id of domain %s cannot be empty", domain)
+		}
+		im.ownerIDMap[strings.ToLower(strings.Trim(domain, "."))] = ownerID
+	}
+	return nil
+}
+
+// OrphanedEntries returns the keys of the owned TXT records whose records didn't exist on the last read. The
+// record type is empty for TXT records in the old format.
+func (im *TXTRegistry) OrphanedEntries() []endpoint.EndpointKey {
+	return sortedKeys(im.orphanedEntries)
+}
+
+// SetRegistryZone makes the registry write the TXT records to the given zone instead of the zones of the
+// records, with the zone appended to their names, so the zones of the records only contain the records.
+// TXT records next to the records are still read, so switching to a registry zone keeps the ownership.
+// The missing TXT records in the registry zone are created by updating the records.
+func (im *TXTRegistry) SetRegistryZone(zone string) {
+	zone = strings.ToLower(strings.Trim(zone, "."))
+	if zone == "" {
+		return
+	}
+	im.registryZone = zone
+	im.mapper = registryZoneNameMapper{nameMapper: im.mapper, zone: zone}
+}
+
+// ownerIDFor returns the owner ID named in the TXT records of a record, which is the owner ID mapped
+// to the longest domain of the record, or the owner ID of this instance.
+func (im *TXTRegistry) ownerIDFor(dnsName string) string {
+	ownerID, matched := im.ownerID, ""
+	name := strings.ToLower(strings.TrimSuffix(dnsName, "."))
+	for domain, mapped := range im.ownerIDMap {
+		if (name == domain || strings.HasSuffix(name, "."+domain)) && len(domain) > len(matched) {
+			ownerID, matched = mapped, domain
+		}
+	}
+	return ownerID
+}
+
+// replaceOwnerID replaces the owner ID in the owner labels of the labels.
+func replaceOwnerID(labels endpoint.Labels, from, to string) {
+	if labels[endpoint.OwnerLabelKey] == from {
+		labels[endpoint.OwnerLabelKey] = to
+	}
+	if owners := labels.Owners(); slices.Contains(owners, from) {
+		for i := range owners {
+			if owners[i] == from {
+				owners[i] = to
+			}
+		}
+		labels.SetOwners(owners)
+	}
+}
+
+// aesKeys returns the encryption key followed by the decryption keys.
+func (im *TXTRegistry) aesKeys() [][]byte {
+	return append([][]byte{im.txtEncryptAESKey}, im.txtDecryptAESKeys...)
+}
+
 func getSupportedTypes() []string {
 	return []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME, endpoint.RecordTypeNS}
 }
@@ -109,10 +274,15 @@ func (im *TXTRegistry) OwnerID() string {
 func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
 	// If we have the zones cached AND we have refreshed the cache since the
 	// last given interval, then just use the cached results.
-	if im.recordsCache != nil && time.Since(im.recordsCacheRefreshTime) < im.cacheInterval {
+	invalidated := im.cacheInvalidated.Swap(false)
+	if im.recordsCache != nil && !invalidated && time.Since(im.recordsCacheRefreshTime) < im.cacheInterval {
 		log.Debug("Using cached records.")
+		countCacheLookup("txt", true)
 		return im.recordsCache, nil
 	}
+	if im.cacheInterval > 0 {
+		countCacheLookup("txt", false)
+	}
 
 	records, err := im.provider.Records(ctx)
 	if err != nil {
@@ -121,8 +291,10 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 
 	endpoints := []*endpoint.Endpoint{}
 
+	aesKeys := im.aesKeys()
 	labelMap := map[endpoint.EndpointKey]endpoint.Labels{}
-	txtRecordsMap := map[string]struct{}{}
+	// the TXT records of the record sets with set identifiers have the same name, they are told apart by the set identifier
+	txtRecordsMap := map[endpoint.EndpointKey]struct{}{}
 
 	for _, record := range records {
 		if record.RecordType != endpoint.RecordTypeTXT {
@@ -130,17 +302,24 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 			continue
 		}
 		// We simply assume that TXT records for the registry will always have only one target.
-		labels, err := endpoint.NewLabelsFromString(record.Targets[0], im.txtEncryptAESKey)
+		// Long payloads are split into several strings of the target.
+		payload := endpoint.JoinTXTStrings(record.Targets[0])
+		labels, err := endpoint.NewLabelsFromStringWithKeys(payload, aesKeys)
 		if err == endpoint.ErrInvalidHeritage {
 			// if no heritage is found or it is invalid
 			// case when value of txt record cannot be identified
 			// record will not be removed as it will have empty owner
+			if im.txtEncryptAESKey != nil && looksEncrypted(payload) {
+				log.Debugf("Failed to decrypt the TXT record %s with any of the keys", record.DNSName)
+				txtEncryptionFailuresTotal.Inc()
+			}
 			endpoints = append(endpoints, record)
 			continue
 		}
 		if err != nil {
 			return nil, err
 		}
+		txtRecordsReadTotal.Inc()
 
 		endpointName, recordType := im.mapper.toEndpointName(record.DNSName)
 		key := endpoint.EndpointKey{
@@ -149,7 +328,14 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 			SetIdentifier: record.SetIdentifier,
 		}
 		labelMap[key] = labels
-		txtRecordsMap[record.DNSName] = struct{}{}
+		txtRecordsMap[record.Key()] = struct{}{}
+	}
+
+	orphanedEntries := map[endpoint.EndpointKey]struct{}{}
+	for key, labels := range labelMap {
+		if owner := labels[endpoint.OwnerLabelKey]; owner == im.ownerID || owner == im.ownerIDFor(key.DNSName) {
+			orphanedEntries[key] = struct{}{}
+		}
 	}
 
 	for _, ep := range endpoints {
@@ -183,6 +369,33 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 			for k, v := range labels {
 				ep.Labels[k] = v
 			}
+			// the TXT record in the old format belongs to the record as well
+			delete(orphanedEntries, key)
+			delete(orphanedEntries, endpoint.EndpointKey{DNSName: key.DNSName, SetIdentifier: key.SetIdentifier})
+		}
+
+		// Records in domains with a mapped owner ID are reported with the owner ID of this instance.
+		if zoneOwnerID := im.ownerIDFor(ep.DNSName); zoneOwnerID != im.ownerID {
+			if ep.Labels[endpoint.OwnerLabelKey] == im.ownerID {
+				// Handle the migration of TXT records created before the owner ID was mapped.
+				ep.Labels[unmappedOwnerLabelKey] = "true"
+				ep.WithProviderSpecific(providerSpecificForceUpdate, "true")
+			}
+			replaceOwnerID(ep.Labels, zoneOwnerID, im.ownerID)
+		}
+
+		// Records whose ownership is shared are owned by each of their owners.
+		if slices.Contains(ep.Labels.Owners(), im.ownerID) {
+			ep.Labels[endpoint.OwnerLabelKey] = im.ownerID
+		}
+
+		// Records of ownerless types are owned by this instance, TXT records are only kept for existing ones.
+		if im.isOwnerless(ep) {
+			if !labelsExist {
+				ep.Labels[ownerlessLabelKey] = "true"
+			}
+			ep.Labels[endpoint.OwnerLabelKey] = im.ownerID
+			continue
 		}
 
 		// Handle the migration of TXT records created before the new format (introduced in v0.12.0).
@@ -192,14 +405,41 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 				// Get desired TXT records and detect the missing ones
 				desiredTXTs := im.generateTXTRecord(ep)
 				for _, desiredTXT := range desiredTXTs {
-					if _, exists := txtRecordsMap[desiredTXT.DNSName]; !exists {
+					if _, exists := txtRecordsMap[desiredTXT.Key()]; !exists {
 						ep.WithProviderSpecific(providerSpecificForceUpdate, "true")
 					}
 				}
+				// Handle the migration of TXT records with a payload in another format.
+				if labelsExist && labels.TXTFormat() != im.txtFormat {
+					ep.WithProviderSpecific(providerSpecificForceUpdate, "true")
+				}
+				// Handle the migration of TXT records compressed otherwise.
+				if !im.txtEncryptEnabled && labelsExist && labels.TXTCompressed() != im.txtCompress {
+					ep.WithProviderSpecific(providerSpecificForceUpdate, "true")
+				}
+				// Handle the migration of TXT records encrypted with a previous key.
+				if im.txtEncryptEnabled && labelsExist && !bytes.Equal(labels.DecryptionKey(aesKeys), im.txtEncryptAESKey) {
+					ep.WithProviderSpecific(providerSpecificForceUpdate, "true")
+				}
+				// Handle the renewal of ownership leases.
+				if expiry, ok := leaseExpiry(labels); im.leaseTTL > 0 && labelsExist && (!ok || expiry.Sub(im.now()) < im.leaseTTL/2) {
+					ep.WithProviderSpecific(providerSpecificForceUpdate, "true")
+				}
+			}
+		}
+
+		// Handle the expiry of ownership leases of other owners, e.g. of decommissioned clusters.
+		if owner := ep.Labels[endpoint.OwnerLabelKey]; im.leaseTTL > 0 && owner != "" && owner != im.ownerID {
+			if expiry, ok := leaseExpiry(labels); ok && !im.now().Before(expiry) {
+				log.Infof("Ownership lease of %s %s by owner %q expired at %s", ep.RecordType, ep.DNSName, owner, labels[endpoint.LeaseLabelKey])
+				ep.Labels[expiredOwnerLabelKey] = owner
+				ep.Labels[endpoint.OwnerLabelKey] = ""
 			}
 		}
 	}
 
+	im.orphanedEntries = orphanedEntries
+
 	// Update the cache.
 	if im.cacheInterval > 0 {
 		im.recordsCache = endpoints
@@ -212,15 +452,67 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 // generateTXTRecord generates both "old" and "new" TXT records.
 // Once we decide to drop old format we need to drop toTXTName() and rename toNewTXTName
 func (im *TXTRegistry) generateTXTRecord(r *endpoint.Endpoint) []*endpoint.Endpoint {
+	if im.isOwnerless(r) {
+		return nil
+	}
+	return im.generateTXTRecordInFormat(r, im.txtFormat, im.txtEncryptAESKey, im.txtCompress)
+}
+
+// generateExistingTXTRecord generates the TXT records of an existing record with the payload in
+// the format, with the key and compressed as they have been read in, so they match the TXT records in the zone.
+func (im *TXTRegistry) generateExistingTXTRecord(r *endpoint.Endpoint) []*endpoint.Endpoint {
+	if _, ok := r.Labels[ownerlessLabelKey]; ok {
+		return nil
+	}
+	if owner, ok := r.Labels[expiredOwnerLabelKey]; ok {
+		// the TXT records still name the owner whose lease has expired
+		r = r.DeepCopy()
+		r.Labels[endpoint.OwnerLabelKey] = owner
+		delete(r.Labels, expiredOwnerLabelKey)
+	}
+	return im.generateTXTRecordInFormat(r, r.Labels.TXTFormat(), r.Labels.DecryptionKey(im.aesKeys()), r.Labels.TXTCompressed())
+}
+
+func (im *TXTRegistry) generateTXTRecordInFormat(r *endpoint.Endpoint, format string, aesKey []byte, compress bool) []*endpoint.Endpoint {
+	if _, unmapped := r.Labels[unmappedOwnerLabelKey]; unmapped {
+		// the TXT records still name the owner ID of this instance
+		r = r.DeepCopy()
+		delete(r.Labels, unmappedOwnerLabelKey)
+	} else if zoneOwnerID := im.ownerIDFor(r.DNSName); zoneOwnerID != im.ownerID {
+		r = r.DeepCopy()
+		replaceOwnerID(r.Labels, im.ownerID, zoneOwnerID)
+	}
+	if owners := r.Labels.Owners(); len(owners) > 0 && r.Labels[endpoint.OwnerLabelKey] != owners[0] {
+		// the TXT records of records with shared ownership name the first of the owners, so every owner generates
+		// the same TXT records
+		r = r.DeepCopy()
+		r.Labels[endpoint.OwnerLabelKey] = owners[0]
+	}
 	endpoints := make([]*endpoint.Endpoint, 0)
+	var payload string
+	switch {
+	case compress && !im.txtEncryptEnabled:
+		payload = r.Labels.SerializeCompressed(format, true)
+	case format == endpoint.TXTFormatV3:
+		payload = r.Labels.SerializeJSON(true, im.txtEncryptEnabled, aesKey)
+	default:
+		payload = r.Labels.Serialize(true, im.txtEncryptEnabled, aesKey)
+	}
+	// payloads exceeding the length of a TXT string are split into several strings, if the provider supports them
+	if provider.SupportsMultiStringTXT(im.provider) {
+		payload = endpoint.SplitTXTStrings(payload)
+	}
 
-	if !im.txtEncryptEnabled && !im.mapper.recordTypeInAffix() && r.RecordType != endpoint.RecordTypeAAAA {
+	if !im.txtEncryptEnabled && !im.mapper.templated() && r.RecordType != endpoint.RecordTypeAAAA {
 		// old TXT record format
-		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), endpoint.RecordTypeTXT, r.Labels.Serialize(true, im.txtEncryptEnabled, im.txtEncryptAESKey))
+		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), endpoint.RecordTypeTXT, payload)
 		if txt != nil {
 			txt.WithSetIdentifier(r.SetIdentifier)
 			txt.Labels[endpoint.OwnedRecordLabelKey] = r.DNSName
 			txt.ProviderSpecific = r.ProviderSpecific
+			if zoneID, ok := r.Labels[endpoint.ZoneIDLabelKey]; ok && im.registryZone == "" {
+				txt.Labels[endpoint.ZoneIDLabelKey] = zoneID
+			}
 			endpoints = append(endpoints, txt)
 		}
 	}
@@ -230,11 +522,15 @@ func (im *TXTRegistry) generateTXTRecord(r *endpoint.Endpoint) []*endpoint.Endpo
 	if isAlias, found := r.GetProviderSpecificProperty("alias"); found && isAlias == "true" && recordType == endpoint.RecordTypeA {
 		recordType = endpoint.RecordTypeCNAME
 	}
-	txtNew := endpoint.NewEndpoint(im.mapper.toNewTXTName(r.DNSName, recordType), endpoint.RecordTypeTXT, r.Labels.Serialize(true, im.txtEncryptEnabled, im.txtEncryptAESKey))
+	txtNew := endpoint.NewEndpoint(im.mapper.toNewTXTName(r.DNSName, recordType), endpoint.RecordTypeTXT, payload)
 	if txtNew != nil {
 		txtNew.WithSetIdentifier(r.SetIdentifier)
 		txtNew.Labels[endpoint.OwnedRecordLabelKey] = r.DNSName
 		txtNew.ProviderSpecific = r.ProviderSpecific
+		// the TXT records go to the same zone as the record, unless there is a registry zone
+		if zoneID, ok := r.Labels[endpoint.ZoneIDLabelKey]; ok && im.registryZone == "" {
+			txtNew.Labels[endpoint.ZoneIDLabelKey] = zoneID
+		}
 		endpoints = append(endpoints, txtNew)
 	}
 
@@ -244,10 +540,11 @@ func (im *TXTRegistry) generateTXTRecord(r *endpoint.Endpoint) []*endpoint.Endpo
 // ApplyChanges updates dns provider with the changes
 // for each created/deleted record it will also take into account TXT records for creation/deletion
 func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	updateOld, updateNew, adoptedOld, adoptedNew := im.splitAdoptions(changes.UpdateOld, changes.UpdateNew)
 	filteredChanges := &plan.Changes{
 		Create:    changes.Create,
-		UpdateNew: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateNew),
-		UpdateOld: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateOld),
+		UpdateNew: endpoint.FilterEndpointsByOwnerID(im.ownerID, updateNew),
+		UpdateOld: endpoint.FilterEndpointsByOwnerID(im.ownerID, updateOld),
 		Delete:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete),
 	}
 	for _, r := range filteredChanges.Create {
@@ -255,6 +552,8 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 			r.Labels = make(map[string]string)
 		}
 		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
+		im.renewLease(r)
+		im.markOwnerless(r)
 
 		filteredChanges.Create = append(filteredChanges.Create, im.generateTXTRecord(r)...)
 
@@ -263,11 +562,15 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 		}
 	}
 
+	// records with shared ownership are only deleted by the last owner, the others just release them
+	var released []*endpoint.Endpoint
+	filteredChanges.Delete, released = im.splitReleases(filteredChanges.Delete)
+
 	for _, r := range filteredChanges.Delete {
 		// when we delete TXT records for which value has changed (due to new label) this would still work because
 		// !!! TXT record value is uniquely generated from the Labels of the endpoint. Hence old TXT record can be uniquely reconstructed
 		// !!! After migration to the new TXT registry format we can drop records in old format here!!!
-		filteredChanges.Delete = append(filteredChanges.Delete, im.generateTXTRecord(r)...)
+		filteredChanges.Delete = append(filteredChanges.Delete, im.generateExistingTXTRecord(r)...)
 
 		if im.cacheInterval > 0 {
 			im.removeFromCache(r)
@@ -278,7 +581,7 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 	for _, r := range filteredChanges.UpdateOld {
 		// when we updateOld TXT records for which value has changed (due to new label) this would still work because
 		// !!! TXT record value is uniquely generated from the Labels of the endpoint. Hence old TXT record can be uniquely reconstructed
-		filteredChanges.UpdateOld = append(filteredChanges.UpdateOld, im.generateTXTRecord(r)...)
+		filteredChanges.UpdateOld = append(filteredChanges.UpdateOld, im.generateExistingTXTRecord(r)...)
 		// remove old version of record from cache
 		if im.cacheInterval > 0 {
 			im.removeFromCache(r)
@@ -287,6 +590,8 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 
 	// make sure TXT records are consistently updated as well
 	for _, r := range filteredChanges.UpdateNew {
+		im.renewLease(r)
+		im.markOwnerless(r)
 		filteredChanges.UpdateNew = append(filteredChanges.UpdateNew, im.generateTXTRecord(r)...)
 		// add new version of record to cache
 		if im.cacheInterval > 0 {
@@ -294,11 +599,169 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 		}
 	}
 
+	for _, r := range released {
+		release := r.DeepCopy()
+		owners := slices.DeleteFunc(r.Labels.Owners(), func(owner string) bool { return owner == im.ownerID })
+		release.Labels[endpoint.OwnerLabelKey] = owners[0]
+		release.Labels.SetOwners(owners)
+		log.Infof("Releasing the shared ownership of %s %s to %v", r.RecordType, r.DNSName, owners)
+		filteredChanges.UpdateOld = append(filteredChanges.UpdateOld, im.generateExistingTXTRecord(r)...)
+		filteredChanges.UpdateNew = append(filteredChanges.UpdateNew, im.generateTXTRecord(release)...)
+
+		if im.cacheInterval > 0 {
+			im.removeFromCache(r)
+			im.addToCache(release)
+		}
+	}
+
+	// adopted records have no TXT records yet, so these are created instead of updated, unless
+	// the ownership of another owner has expired. Records with shared ownership only get their
+	// TXT records updated.
+	for i, r := range adoptedNew {
+		im.renewLease(r)
+		_, expired := adoptedOld[i].Labels[expiredOwnerLabelKey]
+		joined := !expired && adoptedOld[i].Labels[endpoint.OwnerLabelKey] != ""
+		if !joined {
+			filteredChanges.UpdateOld = append(filteredChanges.UpdateOld, adoptedOld[i])
+			filteredChanges.UpdateNew = append(filteredChanges.UpdateNew, r)
+		}
+		if expired || joined {
+			filteredChanges.UpdateOld = append(filteredChanges.UpdateOld, im.generateExistingTXTRecord(adoptedOld[i])...)
+			filteredChanges.UpdateNew = append(filteredChanges.UpdateNew, im.generateTXTRecord(r)...)
+		} else {
+			filteredChanges.Create = append(filteredChanges.Create, im.generateTXTRecord(r)...)
+		}
+
+		if im.cacheInterval > 0 {
+			im.removeFromCache(adoptedOld[i])
+			im.addToCache(r)
+		}
+	}
+
 	// when caching is enabled, disable the provider from using the cache
 	if im.cacheInterval > 0 {
 		ctx = context.WithValue(ctx, provider.RecordsContextKey, nil)
 	}
-	return im.provider.ApplyChanges(ctx, filteredChanges)
+	// the TXT records are persisted along with the records they belong to
+	start := time.Now()
+	err := im.provider.ApplyChanges(ctx, filteredChanges)
+	labelPersistenceDuration.WithLabelValues("txt").Observe(time.Since(start).Seconds())
+	if err != nil {
+		// the changes may have been applied partially, so the cache doesn't reflect the records anymore
+		im.recordsCache = nil
+		return err
+	}
+	txtRecordsWrittenTotal.WithLabelValues("create").Add(float64(countTXTRecords(filteredChanges.Create)))
+	txtRecordsWrittenTotal.WithLabelValues("update").Add(float64(countTXTRecords(filteredChanges.UpdateNew)))
+	txtRecordsWrittenTotal.WithLabelValues("delete").Add(float64(countTXTRecords(filteredChanges.Delete)))
+	return nil
+}
+
+// countTXTRecords returns the number of ownership TXT records generated by the registry among the records.
+func countTXTRecords(records []*endpoint.Endpoint) int {
+	count := 0
+	for _, r := range records {
+		if _, ok := r.Labels[endpoint.OwnedRecordLabelKey]; ok && r.RecordType == endpoint.RecordTypeTXT {
+			count++
+		}
+	}
+	return count
+}
+
+// looksEncrypted reports whether the value of a TXT record has the form of an encrypted payload, base64 encoded
+// ciphertext, as opposed to the TXT records of other tools.
+func looksEncrypted(value string) bool {
+	data, err := base64.StdEncoding.DecodeString(strings.Trim(value, "\""))
+	// the ciphertext follows the 12 bytes of the GCM nonce
+	return err == nil && len(data) > 12
+}
+
+// renewLease sets the expiry of the ownership lease of a created or updated record.
+func (im *TXTRegistry) renewLease(r *endpoint.Endpoint) {
+	if im.leaseTTL <= 0 {
+		return
+	}
+	if r.Labels == nil {
+		r.Labels = endpoint.NewLabels()
+	}
+	r.Labels[endpoint.LeaseLabelKey] = im.now().Add(im.leaseTTL).UTC().Format(time.RFC3339)
+}
+
+// markOwnerless marks a created or updated record of an ownerless type as having no TXT records.
+func (im *TXTRegistry) markOwnerless(r *endpoint.Endpoint) {
+	if !im.isOwnerless(r) {
+		return
+	}
+	if r.Labels == nil {
+		r.Labels = endpoint.NewLabels()
+	}
+	r.Labels[ownerlessLabelKey] = "true"
+}
+
+// leaseExpiry returns the expiry of the ownership lease in the labels, if any.
+func leaseExpiry(labels endpoint.Labels) (time.Time, bool) {
+	expiry, err := time.Parse(time.RFC3339, labels[endpoint.LeaseLabelKey])
+	return expiry, err == nil
+}
+
+// splitReleases separates the records with shared ownership which are still owned by other owners
+// from the records to delete.
+func (im *TXTRegistry) splitReleases(records []*endpoint.Endpoint) (deleted, released []*endpoint.Endpoint) {
+	for _, r := range records {
+		if owners := r.Labels.Owners(); len(owners) > 1 && slices.Contains(owners, im.ownerID) {
+			released = append(released, r)
+			continue
+		}
+		deleted = append(deleted, r)
+	}
+	return deleted, released
+}
+
+// splitAdoptions separates the updates which adopt a record without an owner, as planned with
+// adoption of existing records enabled, or join the owners of a record owned by another owner,
+// as planned with shared ownership enabled, from the other updates.
+func (im *TXTRegistry) splitAdoptions(updateOld, updateNew []*endpoint.Endpoint) (otherOld, otherNew, adoptedOld, adoptedNew []*endpoint.Endpoint) {
+	if len(updateOld) != len(updateNew) {
+		return updateOld, updateNew, nil, nil
+	}
+	for i := range updateOld {
+		if updateOld[i].Labels[endpoint.OwnerLabelKey] != im.ownerID && updateNew[i].Labels[endpoint.OwnerLabelKey] == im.ownerID {
+			adoptedOld = append(adoptedOld, updateOld[i])
+			adoptedNew = append(adoptedNew, updateNew[i])
+			continue
+		}
+		otherOld = append(otherOld, updateOld[i])
+		otherNew = append(otherNew, updateNew[i])
+	}
+	return otherOld, otherNew, adoptedOld, adoptedNew
+}
+
+// DeleteRecords deletes the given records along with their ownership TXT records regardless of their owner.
+// It is meant for cleaning up the records of abandoned owners, ApplyChanges only deletes owned records.
+func (im *TXTRegistry) DeleteRecords(ctx context.Context, records []*endpoint.Endpoint) error {
+	changes := &plan.Changes{}
+	for _, r := range records {
+		changes.Delete = append(changes.Delete, r)
+		changes.Delete = append(changes.Delete, im.generateExistingTXTRecord(r)...)
+
+		if im.cacheInterval > 0 {
+			im.removeFromCache(r)
+		}
+	}
+
+	if im.cacheInterval > 0 {
+		ctx = context.WithValue(ctx, provider.RecordsContextKey, nil)
+	}
+	if err := im.provider.ApplyChanges(ctx, changes); err != nil {
+		im.recordsCache = nil
+		return err
+	}
+	return nil
+}
+
+// InvalidateCache makes the next call of Records read the records from the provider instead of the cache.
+func (im *TXTRegistry) InvalidateCache() {
+	im.cacheInvalidated.Store(true)
 }
 
 // AdjustEndpoints modifies the endpoints as needed by the specific provider
@@ -316,12 +779,15 @@ type nameMapper interface {
 	toTXTName(string) string
 	toNewTXTName(string, string) string
 	recordTypeInAffix() bool
+	templated() bool
 }
 
 type affixNameMapper struct {
 	prefix              string
 	suffix              string
 	wildcardReplacement string
+	// zones are the domains %{zone} is replaced with, the longest one containing the record
+	zones []string
 }
 
 var _ nameMapper = affixNameMapper{}
@@ -330,6 +796,29 @@ func newaffixNameMapper(prefix, suffix, wildcardReplacement string) affixNameMap
 	return affixNameMapper{prefix: strings.ToLower(prefix), suffix: strings.ToLower(suffix), wildcardReplacement: strings.ToLower(wildcardReplacement)}
 }
 
+// registryZoneNameMapper maps the records to TXT records in a registry zone by appending the zone to the names
+// of the TXT records of the wrapped mapper. TXT records outside of the registry zone are mapped by the wrapped
+// mapper, so the TXT records written before the registry zone was set are still found.
+type registryZoneNameMapper struct {
+	nameMapper
+	zone string
+}
+
+func (pr registryZoneNameMapper) toEndpointName(txtDNSName string) (endpointName string, recordType string) {
+	if name, found := strings.CutSuffix(strings.ToLower(txtDNSName), "."+pr.zone); found {
+		return pr.nameMapper.toEndpointName(name)
+	}
+	return pr.nameMapper.toEndpointName(txtDNSName)
+}
+
+func (pr registryZoneNameMapper) toTXTName(endpointDNSName string) string {
+	return pr.nameMapper.toTXTName(endpointDNSName) + "." + pr.zone
+}
+
+func (pr registryZoneNameMapper) toNewTXTName(endpointDNSName, recordType string) string {
+	return pr.nameMapper.toNewTXTName(endpointDNSName, recordType) + "." + pr.zone
+}
+
 // extractRecordTypeDefaultPosition extracts record type from the default position
 // when not using '%{record_type}' in the prefix/suffix
 func extractRecordTypeDefaultPosition(name string) (baseName, recordType string) {
@@ -394,6 +883,10 @@ func (pr affixNameMapper) isSuffix() bool {
 func (pr affixNameMapper) toEndpointName(txtDNSName string) (endpointName string, recordType string) {
 	lowerDNSName := strings.ToLower(txtDNSName)
 
+	if pr.zoneInAffix() || pr.hashInAffix() {
+		return pr.matchTemplatedName(lowerDNSName)
+	}
+
 	// drop prefix
 	if pr.isPrefix() {
 		return pr.dropAffixExtractType(lowerDNSName)
@@ -437,26 +930,161 @@ func (pr affixNameMapper) recordTypeInAffix() bool {
 	return false
 }
 
-func (pr affixNameMapper) normalizeAffixTemplate(afix, recordType string) string {
-	if strings.Contains(afix, recordTemplate) {
-		return strings.ReplaceAll(afix, recordTemplate, recordType)
+func (pr affixNameMapper) zoneInAffix() bool {
+	return strings.Contains(pr.prefix, zoneTemplate) || strings.Contains(pr.suffix, zoneTemplate)
+}
+
+func (pr affixNameMapper) hashInAffix() bool {
+	return strings.Contains(pr.prefix, hashTemplate) || strings.Contains(pr.suffix, hashTemplate)
+}
+
+// templated reports whether the prefix or suffix contains any template, in which case only TXT records
+// in the new format are written.
+func (pr affixNameMapper) templated() bool {
+	return pr.recordTypeInAffix() || pr.zoneInAffix() || pr.hashInAffix()
+}
+
+// withZones returns the mapper replacing %{zone} with the domains of the domain filter.
+func (pr affixNameMapper) withZones(domainFilter endpoint.DomainFilterInterface) affixNameMapper {
+	var filters []string
+	switch df := domainFilter.(type) {
+	case endpoint.DomainFilter:
+		filters = df.Filters
+	case *endpoint.DomainFilter:
+		filters = df.Filters
+	}
+	pr.zones = nil
+	for _, f := range filters {
+		if f = strings.ToLower(strings.Trim(f, ".")); f != "" {
+			pr.zones = append(pr.zones, f)
+		}
+	}
+	return pr
+}
+
+// zoneOf returns the longest zone containing the name or, if there is none, its parent domain.
+func (pr affixNameMapper) zoneOf(name string) string {
+	name = strings.ToLower(name)
+	zone := ""
+	for _, z := range pr.zones {
+		if (name == z || strings.HasSuffix(name, "."+z)) && len(z) > len(zone) {
+			zone = z
+		}
+	}
+	if zone != "" {
+		return zone
+	}
+	if _, parent, found := strings.Cut(name, "."); found {
+		return parent
+	}
+	return name
+}
+
+// hashOf returns a hash of the name which keeps the TXT records of similar names apart.
+func hashOf(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(name)))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// expandAffixTemplate replaces the templates in the prefix or suffix for the record with the given name,
+// whose leading asterisk has already been replaced.
+func (pr affixNameMapper) expandAffixTemplate(afix, recordType, name string) string {
+	afix = strings.ReplaceAll(afix, recordTemplate, recordType)
+	if strings.Contains(afix, zoneTemplate) {
+		afix = strings.ReplaceAll(afix, zoneTemplate, pr.zoneOf(name))
+	}
+	if strings.Contains(afix, hashTemplate) {
+		afix = strings.ReplaceAll(afix, hashTemplate, hashOf(name))
 	}
 	return afix
 }
 
+// matchTemplatedName finds the name and the record type of the record a TXT record with a %{zone} or %{hash}
+// template in its prefix or suffix belongs to. The templates can't be stripped like the record type, so every
+// possible name of the record is checked by generating its TXT record name again.
+func (pr affixNameMapper) matchTemplatedName(txtDNSName string) (endpointName string, recordType string) {
+	recordTypes := []string{""}
+	if pr.recordTypeInAffix() {
+		recordTypes = getSupportedTypes()
+	}
+	for _, t := range recordTypes {
+		afix := pr.prefix
+		if pr.isSuffix() {
+			afix = pr.suffix
+		}
+		// the text before the first and after the last template narrows down the possible names
+		afix = strings.ReplaceAll(afix, recordTemplate, strings.ToLower(t))
+		head, tail := afix, ""
+		if i := strings.Index(afix, "%{"); i >= 0 {
+			head = afix[:i]
+			tail = afix[strings.LastIndex(afix, "}")+1:]
+		}
+
+		for _, candidate := range pr.candidateNames(txtDNSName, head, tail) {
+			name, recordType := candidate, t
+			if recordType == "" {
+				name, recordType = extractRecordTypeDefaultPosition(name)
+			}
+			if recordType != "" && pr.toNewTXTName(name, recordType) == txtDNSName {
+				return name, recordType
+			}
+		}
+	}
+	return "", ""
+}
+
+// candidateNames returns the names of records which may have a TXT record with the given name, if the
+// prefix or suffix starts with head and ends with tail.
+func (pr affixNameMapper) candidateNames(txtDNSName, head, tail string) []string {
+	var candidates []string
+	if pr.isPrefix() {
+		for i := len(head + tail); i < len(txtDNSName); i++ {
+			if affix := txtDNSName[:i]; strings.HasPrefix(affix, head) && strings.HasSuffix(affix, tail) {
+				candidates = append(candidates, txtDNSName[i:])
+			}
+		}
+		return candidates
+	}
+
+	// the suffix is between the first label of the record and its parent domain
+	firstDot := strings.Index(txtDNSName, ".")
+	if firstDot < 0 {
+		firstDot = len(txtDNSName)
+	}
+	for i := 1; i <= firstDot; i++ {
+		for j := i + len(head+tail); j <= len(txtDNSName); j++ {
+			if j < len(txtDNSName) && txtDNSName[j] != '.' {
+				continue
+			}
+			affix := txtDNSName[i:j]
+			if !strings.HasPrefix(affix, head) || !strings.HasSuffix(affix, tail) {
+				continue
+			}
+			if j == len(txtDNSName) {
+				candidates = append(candidates, txtDNSName[:i])
+			} else {
+				candidates = append(candidates, txtDNSName[:i]+txtDNSName[j:])
+			}
+		}
+	}
+	return candidates
+}
+
 func (pr affixNameMapper) toNewTXTName(endpointDNSName, recordType string) string {
 	DNSName := strings.SplitN(endpointDNSName, ".", 2)
 	recordType = strings.ToLower(recordType)
 	recordT := recordType + "-"
 
-	prefix := pr.normalizeAffixTemplate(pr.prefix, recordType)
-	suffix := pr.normalizeAffixTemplate(pr.suffix, recordType)
-
 	// If specified, replace a leading asterisk in the generated txt record name with some other string
 	if pr.wildcardReplacement != "" && DNSName[0] == "*" {
 		DNSName[0] = pr.wildcardReplacement
 	}
 
+	name := strings.Join(DNSName, ".")
+	prefix := pr.expandAffixTemplate(pr.prefix, recordType, name)
+	suffix := pr.expandAffixTemplate(pr.suffix, recordType, name)
+
 	if !pr.recordTypeInAffix() {
 		DNSName[0] = recordT + DNSName[0]
 	}
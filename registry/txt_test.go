@@ -18,11 +18,13 @@ package registry
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -1404,6 +1406,129 @@ func TestToEndpointNameNewTXT(t *testing.T) {
 	}
 }
 
+func TestToEndpointNameTemplatedTXT(t *testing.T) {
+	zones := endpoint.NewDomainFilter([]string{"example.com", "team.example.com"})
+	tests := []struct {
+		name       string
+		mapper     affixNameMapper
+		domain     string
+		recordType string
+		txtDomain  string
+	}{
+		{
+			name:       "hash prefix",
+			mapper:     newaffixNameMapper("%{hash}-", "", ""),
+			domain:     "www.example.com",
+			recordType: "A",
+			txtDomain:  "88469fcb-a-www.example.com",
+		},
+		{
+			name:       "hash and record type prefix",
+			mapper:     newaffixNameMapper("%{record_type}-%{hash}.", "", ""),
+			domain:     "www.example.com",
+			recordType: "CNAME",
+			txtDomain:  "cname-88469fcb.www.example.com",
+		},
+		{
+			name:       "hash suffix",
+			mapper:     newaffixNameMapper("", "-%{record_type}-%{hash}", ""),
+			domain:     "www.example.com",
+			recordType: "AAAA",
+			txtDomain:  "www-aaaa-88469fcb.example.com",
+		},
+		{
+			name:       "zone prefix",
+			mapper:     newaffixNameMapper("%{record_type}.%{zone}.", "", "").withZones(zones),
+			domain:     "api.team.example.com",
+			recordType: "A",
+			txtDomain:  "a.team.example.com.api.team.example.com",
+		},
+		{
+			name:       "zone suffix",
+			mapper:     newaffixNameMapper("", "-%{record_type}.%{zone}", "").withZones(zones),
+			domain:     "www.example.com",
+			recordType: "AAAA",
+			txtDomain:  "www-aaaa.example.com.example.com",
+		},
+		{
+			name:       "zone of the apex",
+			mapper:     newaffixNameMapper("%{record_type}-%{zone}-", "", "").withZones(zones),
+			domain:     "example.com",
+			recordType: "NS",
+			txtDomain:  "ns-example.com-example.com",
+		},
+		{
+			name:       "zone without domain filter",
+			mapper:     newaffixNameMapper("%{zone}.", "", ""),
+			domain:     "api.team.example.com",
+			recordType: "A",
+			txtDomain:  "team.example.com.a-api.team.example.com",
+		},
+		{
+			name:       "wildcard",
+			mapper:     newaffixNameMapper("%{hash}-%{record_type}-", "", "_wild"),
+			domain:     "*.example.com",
+			recordType: "A",
+			txtDomain:  "ddbf5b1d-a-_wild.example.com",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			txtDomain := tc.mapper.toNewTXTName(tc.domain, tc.recordType)
+			assert.Equal(t, tc.txtDomain, txtDomain)
+
+			domain, recordType := tc.mapper.toEndpointName(txtDomain)
+			assert.Equal(t, strings.Replace(tc.domain, "*", "_wild", 1), domain)
+			assert.Equal(t, tc.recordType, recordType)
+		})
+	}
+
+	// TXT records whose hash doesn't match the name aren't ownership records of the name
+	mapper := newaffixNameMapper("%{hash}-", "", "")
+	domain, recordType := mapper.toEndpointName("26544ddc-a-www.example.com")
+	assert.Empty(t, domain)
+	assert.Empty(t, recordType)
+}
+
+func TestTXTRegistryTemplatedNames(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider(inmemory.InMemoryWithDomain(endpoint.NewDomainFilter([]string{testZone})))
+	require.NoError(t, p.CreateZone(testZone))
+	r, err := NewTXTRegistry(p, "%{record_type}-%{hash}.%{zone}.", "", "owner", 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, nil, false, nil)
+	require.NoError(t, err)
+
+	records := []*endpoint.Endpoint{
+		newEndpointWithOwnerResource("app.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "", "service/default/app"),
+		newEndpointWithOwnerResource("app-1.test-zone.example.org", "1.2.3.5", endpoint.RecordTypeA, "", "service/default/app-1"),
+		newEndpointWithOwnerResource("app.test-zone.example.org", "lb.example.com", endpoint.RecordTypeCNAME, "", "service/default/app-cname"),
+	}
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Create: records}))
+
+	txtNames := map[string]bool{}
+	all, err := p.Records(ctx)
+	require.NoError(t, err)
+	for _, record := range all {
+		if record.RecordType == endpoint.RecordTypeTXT {
+			assert.Contains(t, record.DNSName, ".test-zone.example.org.app")
+			txtNames[record.DNSName] = true
+		}
+	}
+	assert.Len(t, txtNames, 3, "only TXT records in the new format are written")
+
+	read, err := r.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, read, 3)
+	for _, record := range read {
+		assert.Equal(t, "owner", record.Labels[endpoint.OwnerLabelKey], record.DNSName)
+		expected := "service/default/" + strings.TrimSuffix(record.DNSName, ".test-zone.example.org")
+		if record.RecordType == endpoint.RecordTypeCNAME {
+			expected += "-cname"
+		}
+		assert.Equal(t, expected, record.Labels[endpoint.ResourceLabelKey], record.DNSName)
+	}
+}
+
 func TestNewTXTScheme(t *testing.T) {
 	p := inmemory.NewInMemoryProvider()
 	p.CreateZone(testZone)
@@ -1507,6 +1632,35 @@ func TestGenerateTXT(t *testing.T) {
 	assert.Equal(t, expectedTXT, gotTXT)
 }
 
+func TestGenerateTXTWithZoneID(t *testing.T) {
+	record := newEndpointWithOwnerAndLabels("foo.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner", endpoint.Labels{endpoint.ZoneIDLabelKey: "Z0123456789"})
+	expectedTXT := []*endpoint.Endpoint{
+		{
+			DNSName:    "foo.test-zone.example.org",
+			Targets:    endpoint.Targets{"\"heritage=external-dns,external-dns/owner=owner,external-dns/zone-id=Z0123456789\""},
+			RecordType: endpoint.RecordTypeTXT,
+			Labels: map[string]string{
+				endpoint.OwnedRecordLabelKey: "foo.test-zone.example.org",
+				endpoint.ZoneIDLabelKey:      "Z0123456789",
+			},
+		},
+		{
+			DNSName:    "a-foo.test-zone.example.org",
+			Targets:    endpoint.Targets{"\"heritage=external-dns,external-dns/owner=owner,external-dns/zone-id=Z0123456789\""},
+			RecordType: endpoint.RecordTypeTXT,
+			Labels: map[string]string{
+				endpoint.OwnedRecordLabelKey: "foo.test-zone.example.org",
+				endpoint.ZoneIDLabelKey:      "Z0123456789",
+			},
+		},
+	}
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	gotTXT := r.generateTXTRecord(record)
+	assert.Equal(t, expectedTXT, gotTXT)
+}
+
 func TestGenerateTXTForAAAA(t *testing.T) {
 	record := newEndpointWithOwner("foo.test-zone.example.org", "2001:DB8::1", endpoint.RecordTypeAAAA, "owner")
 	expectedTXT := []*endpoint.Endpoint{
@@ -1646,6 +1800,718 @@ func TestMultiClusterDifferentRecordTypeOwnership(t *testing.T) {
 	}
 }
 
+func TestTXTRegistryAdoptExistingRecords(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("adopted.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("different.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}))
+
+	r, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA}, nil, false, nil)
+	require.NoError(t, err)
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+
+	pl := &plan.Plan{
+		Policies: []plan.Policy{&plan.SyncPolicy{}},
+		Current:  records,
+		Desired: []*endpoint.Endpoint{
+			newEndpointWithOwner("adopted.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("different.test-zone.example.org", "8.8.8.8", endpoint.RecordTypeA, ""),
+		},
+		ManagedRecords: []string{endpoint.RecordTypeA},
+		OwnerID:        r.OwnerID(),
+		AdoptExisting:  true,
+	}
+	require.NoError(t, r.ApplyChanges(ctx, pl.Calculate().Changes))
+
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	owners := map[string]string{}
+	for _, record := range records {
+		owners[record.DNSName] = record.Labels[endpoint.OwnerLabelKey]
+	}
+	assert.Equal(t, map[string]string{
+		"adopted.test-zone.example.org":   "owner",
+		"different.test-zone.example.org": "",
+	}, owners)
+}
+
+func TestTXTRegistrySharedOwnership(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+
+	newRegistry := func(ownerID string) *TXTRegistry {
+		r, err := NewTXTRegistry(p, "", "", ownerID, 0, "", []string{endpoint.RecordTypeA}, nil, false, nil)
+		require.NoError(t, err)
+		return r
+	}
+	clusterA, clusterB := newRegistry("cluster-a"), newRegistry("cluster-b")
+	require.NoError(t, clusterA.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("shared.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}))
+
+	sync := func(r *TXTRegistry, desired ...*endpoint.Endpoint) *plan.Changes {
+		records, err := r.Records(ctx)
+		require.NoError(t, err)
+		pl := &plan.Plan{
+			Policies:        []plan.Policy{&plan.SyncPolicy{}},
+			Current:         records,
+			Desired:         desired,
+			ManagedRecords:  []string{endpoint.RecordTypeA},
+			OwnerID:         r.OwnerID(),
+			SharedOwnership: true,
+		}
+		changes := pl.Calculate().Changes
+		require.NoError(t, r.ApplyChanges(ctx, changes))
+		return changes
+	}
+	owners := func() map[string]string {
+		records, err := p.Records(ctx)
+		require.NoError(t, err)
+		owners := map[string]string{}
+		for _, record := range records {
+			if record.RecordType != endpoint.RecordTypeTXT {
+				continue
+			}
+			labels, err := endpoint.NewLabelsFromStringPlain(record.Targets[0])
+			require.NoError(t, err)
+			owners[record.DNSName] = labels[endpoint.OwnerLabelKey] + " " + labels[endpoint.OwnersLabelKey]
+		}
+		return owners
+	}
+	desired := newEndpointWithOwner("shared.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "")
+
+	// cluster-b joins the owners, only the TXT records are updated
+	changes := sync(clusterB, desired)
+	assert.Len(t, changes.UpdateNew, 1)
+	assert.Equal(t, map[string]string{
+		"shared.test-zone.example.org":   "cluster-a cluster-a;cluster-b",
+		"a-shared.test-zone.example.org": "cluster-a cluster-a;cluster-b",
+	}, owners())
+	assert.False(t, sync(clusterB, desired).HasChanges())
+	assert.False(t, sync(clusterA, desired).HasChanges())
+
+	// cluster-a releases the record, cluster-b keeps it
+	assert.Len(t, sync(clusterA).Delete, 1)
+	assert.Equal(t, map[string]string{
+		"shared.test-zone.example.org":   "cluster-b ",
+		"a-shared.test-zone.example.org": "cluster-b ",
+	}, owners())
+	records, err := clusterA.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "cluster-b", records[0].Labels[endpoint.OwnerLabelKey])
+
+	// the last owner deletes the record
+	assert.Len(t, sync(clusterB).Delete, 1)
+	records, err = p.Records(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestTXTRegistryMigrateFormat(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+
+	v2, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA}, nil, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, v2.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwnerResource("migrated.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "", "ingress/default/my-ingress"),
+		},
+	}))
+
+	v3, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA}, nil, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, v3.SetFormat(endpoint.TXTFormatV3))
+	require.Error(t, v3.SetFormat("v4"))
+
+	sync := func() *plan.Changes {
+		records, err := v3.Records(ctx)
+		require.NoError(t, err)
+		pl := &plan.Plan{
+			Policies: []plan.Policy{&plan.SyncPolicy{}},
+			Current:  records,
+			Desired: []*endpoint.Endpoint{
+				newEndpointWithOwnerResource("migrated.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "", "ingress/default/my-ingress"),
+			},
+			ManagedRecords: []string{endpoint.RecordTypeA},
+			OwnerID:        v3.OwnerID(),
+		}
+		changes := pl.Calculate().Changes
+		require.NoError(t, v3.ApplyChanges(ctx, changes))
+		return changes
+	}
+
+	// the TXT records in the v2 format are updated to the v3 format
+	assert.Len(t, sync().UpdateNew, 1)
+	records, err := p.Records(ctx)
+	require.NoError(t, err)
+	txts := 0
+	for _, record := range records {
+		if record.RecordType != endpoint.RecordTypeTXT {
+			continue
+		}
+		txts++
+		labels, err := endpoint.NewLabelsFromStringPlain(record.Targets[0])
+		require.NoError(t, err)
+		assert.Equal(t, endpoint.TXTFormatV3, labels.TXTFormat(), record.DNSName)
+		assert.Equal(t, "owner", labels[endpoint.OwnerLabelKey])
+		assert.Equal(t, "ingress/default/my-ingress", labels[endpoint.ResourceLabelKey])
+	}
+	assert.Equal(t, 2, txts)
+
+	// migrated records are left alone
+	assert.False(t, sync().HasChanges())
+}
+
+func TestTXTRegistryCompression(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+	newRecord := func() *endpoint.Endpoint {
+		ep := newEndpointWithOwnerResource("long.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "", "ingress/default/my-ingress")
+		ep.Labels[endpoint.DescriptionLabelKey] = strings.Repeat("a long description ", 20)
+		return ep
+	}
+	txtTargets := func() []string {
+		records, err := p.Records(ctx)
+		require.NoError(t, err)
+		var targets []string
+		for _, record := range records {
+			if record.RecordType == endpoint.RecordTypeTXT {
+				targets = append(targets, record.Targets[0])
+			}
+		}
+		return targets
+	}
+
+	// the payload exceeding the length of a TXT string is split, since the provider supports it
+	plain, err := NewTXTRegistry(&multiStringTXTProvider{Provider: p}, "", "", "owner", 0, "", []string{endpoint.RecordTypeA}, nil, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, plain.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{newRecord()}}))
+	for _, target := range txtTargets() {
+		assert.Contains(t, target, `" "`)
+	}
+	records, err := plain.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "owner", records[0].Labels[endpoint.OwnerLabelKey])
+	assert.Equal(t, newRecord().Labels[endpoint.DescriptionLabelKey], records[0].Labels[endpoint.DescriptionLabelKey])
+
+	compressed, err := NewTXTRegistry(&multiStringTXTProvider{Provider: p}, "", "", "owner", 0, "", []string{endpoint.RecordTypeA}, nil, false, nil)
+	require.NoError(t, err)
+	compressed.SetCompression(true)
+	sync := func() *plan.Changes {
+		records, err := compressed.Records(ctx)
+		require.NoError(t, err)
+		pl := &plan.Plan{
+			Policies:       []plan.Policy{&plan.SyncPolicy{}},
+			Current:        records,
+			Desired:        []*endpoint.Endpoint{newRecord()},
+			ManagedRecords: []string{endpoint.RecordTypeA},
+			OwnerID:        compressed.OwnerID(),
+		}
+		changes := pl.Calculate().Changes
+		require.NoError(t, compressed.ApplyChanges(ctx, changes))
+		return changes
+	}
+
+	// the split TXT records are migrated to compressed ones
+	assert.Len(t, sync().UpdateNew, 1)
+	targets := txtTargets()
+	assert.Len(t, targets, 2)
+	for _, target := range targets {
+		assert.True(t, strings.HasPrefix(target, `"gzip:`), target)
+		assert.NotContains(t, target, `" "`)
+		labels, err := endpoint.NewLabelsFromStringPlain(target)
+		require.NoError(t, err)
+		assert.True(t, labels.TXTCompressed())
+		assert.Equal(t, "owner", labels[endpoint.OwnerLabelKey])
+	}
+
+	// migrated records are left alone
+	assert.False(t, sync().HasChanges())
+}
+
+// multiStringTXTProvider is a provider supporting TXT records made of several strings.
+type multiStringTXTProvider struct {
+	provider.Provider
+}
+
+func (p *multiStringTXTProvider) SupportsMultiStringTXT() bool {
+	return true
+}
+
+func TestTXTRegistrySplitsOnlyForMultiStringProviders(t *testing.T) {
+	ctx := context.Background()
+	newRecord := func() *endpoint.Endpoint {
+		ep := newEndpointWithOwnerResource("long.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "", "ingress/default/my-ingress")
+		ep.Labels[endpoint.DescriptionLabelKey] = strings.Repeat("a long description ", 20)
+		return ep
+	}
+	for _, tc := range []struct {
+		title       string
+		multiString bool
+	}{
+		{title: "multi-string provider", multiString: true},
+		{title: "single-string provider"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			inMemory := inmemory.NewInMemoryProvider()
+			require.NoError(t, inMemory.CreateZone(testZone))
+			var p provider.Provider = inMemory
+			if tc.multiString {
+				p = &multiStringTXTProvider{Provider: inMemory}
+			}
+			r, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA}, nil, false, nil)
+			require.NoError(t, err)
+			require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{newRecord()}}))
+
+			all, err := inMemory.Records(ctx)
+			require.NoError(t, err)
+			for _, record := range all {
+				if record.RecordType != endpoint.RecordTypeTXT {
+					continue
+				}
+				require.Len(t, record.Targets, 1)
+				assert.Equal(t, tc.multiString, strings.Contains(record.Targets[0], `" "`), record.Targets[0])
+			}
+
+			// the ownership is read back either way
+			records, err := r.Records(ctx)
+			require.NoError(t, err)
+			require.Len(t, records, 1)
+			assert.Equal(t, "owner", records[0].Labels[endpoint.OwnerLabelKey])
+		})
+	}
+}
+
+func TestTXTRegistryRotateEncryptionKey(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+	oldKey := []byte("0123456789abcdef0123456789abcdef")
+	newKey := []byte("abcdef0123456789abcdef0123456789")
+
+	old, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA}, nil, true, oldKey)
+	require.NoError(t, err)
+	require.NoError(t, old.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwnerResource("rotated.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "", "ingress/default/my-ingress"),
+		},
+	}))
+
+	rotated, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA}, nil, true, newKey)
+	require.NoError(t, err)
+	require.Error(t, rotated.SetDecryptionKeys([][]byte{[]byte("short")}))
+	require.NoError(t, rotated.SetDecryptionKeys([][]byte{oldKey}))
+
+	sync := func() *plan.Changes {
+		records, err := rotated.Records(ctx)
+		require.NoError(t, err)
+		for _, record := range records {
+			assert.Equal(t, "owner", record.Labels[endpoint.OwnerLabelKey], "records should stay owned")
+		}
+		pl := &plan.Plan{
+			Policies: []plan.Policy{&plan.SyncPolicy{}},
+			Current:  records,
+			Desired: []*endpoint.Endpoint{
+				newEndpointWithOwnerResource("rotated.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "", "ingress/default/my-ingress"),
+			},
+			ManagedRecords: []string{endpoint.RecordTypeA},
+			OwnerID:        rotated.OwnerID(),
+		}
+		changes := pl.Calculate().Changes
+		require.NoError(t, rotated.ApplyChanges(ctx, changes))
+		return changes
+	}
+
+	// the TXT records encrypted with the old key are updated to the new key
+	assert.Len(t, sync().UpdateNew, 1)
+	records, err := p.Records(ctx)
+	require.NoError(t, err)
+	txts := 0
+	for _, record := range records {
+		if record.RecordType != endpoint.RecordTypeTXT {
+			continue
+		}
+		txts++
+		labels, err := endpoint.NewLabelsFromString(record.Targets[0], newKey)
+		require.NoError(t, err, record.DNSName)
+		assert.Equal(t, "owner", labels[endpoint.OwnerLabelKey])
+	}
+	assert.Equal(t, 1, txts)
+
+	// migrated records are left alone
+	assert.False(t, sync().HasChanges())
+}
+
+func TestTXTRegistryOwnershipLease(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	decommissioned, err := NewTXTRegistry(p, "", "", "decommissioned", 0, "", []string{endpoint.RecordTypeA}, nil, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, decommissioned.SetOwnershipLease(time.Hour))
+	decommissioned.now = func() time.Time { return now }
+	require.NoError(t, decommissioned.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("expired.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}))
+
+	r, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA}, nil, false, nil)
+	require.NoError(t, err)
+	require.Error(t, r.SetOwnershipLease(-time.Hour))
+	require.NoError(t, r.SetOwnershipLease(time.Hour))
+	r.now = func() time.Time { return now }
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("owned.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, ""),
+		},
+	}))
+
+	sync := func() *plan.Changes {
+		records, err := r.Records(ctx)
+		require.NoError(t, err)
+		pl := &plan.Plan{
+			Policies: []plan.Policy{&plan.SyncPolicy{}},
+			Current:  records,
+			Desired: []*endpoint.Endpoint{
+				newEndpointWithOwner("expired.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+				newEndpointWithOwner("owned.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, ""),
+			},
+			ManagedRecords: []string{endpoint.RecordTypeA},
+			OwnerID:        r.OwnerID(),
+			AdoptExisting:  true,
+		}
+		changes := pl.Calculate().Changes
+		require.NoError(t, r.ApplyChanges(ctx, changes))
+		return changes
+	}
+	leases := func() map[string]string {
+		records, err := p.Records(ctx)
+		require.NoError(t, err)
+		leases := map[string]string{}
+		for _, record := range records {
+			if record.RecordType != endpoint.RecordTypeTXT {
+				continue
+			}
+			labels, err := endpoint.NewLabelsFromStringPlain(record.Targets[0])
+			require.NoError(t, err)
+			leases[record.DNSName] = labels[endpoint.OwnerLabelKey] + " " + labels[endpoint.LeaseLabelKey]
+		}
+		return leases
+	}
+
+	// leases are neither expired nor due for renewal
+	assert.False(t, sync().HasChanges())
+
+	// the lease of the owned record is renewed once half of it has passed, the other
+	// owner's lease is still valid
+	now = now.Add(40 * time.Minute)
+	assert.Len(t, sync().UpdateNew, 1)
+	assert.Equal(t, map[string]string{
+		"expired.test-zone.example.org":   "decommissioned 2024-01-01T13:00:00Z",
+		"a-expired.test-zone.example.org": "decommissioned 2024-01-01T13:00:00Z",
+		"owned.test-zone.example.org":     "owner 2024-01-01T13:40:00Z",
+		"a-owned.test-zone.example.org":   "owner 2024-01-01T13:40:00Z",
+	}, leases())
+
+	// the expired record is adopted along with its TXT records
+	now = now.Add(30 * time.Minute)
+	assert.Len(t, sync().UpdateNew, 1)
+	assert.Equal(t, map[string]string{
+		"expired.test-zone.example.org":   "owner 2024-01-01T14:10:00Z",
+		"a-expired.test-zone.example.org": "owner 2024-01-01T14:10:00Z",
+		"owned.test-zone.example.org":     "owner 2024-01-01T13:40:00Z",
+		"a-owned.test-zone.example.org":   "owner 2024-01-01T13:40:00Z",
+	}, leases())
+	assert.False(t, sync().HasChanges())
+}
+
+// rejectingProvider fails to apply any changes while reject is set.
+type rejectingProvider struct {
+	provider.Provider
+	reject bool
+}
+
+func (p *rejectingProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	if p.reject {
+		return errors.New("provider failure")
+	}
+	return p.Provider.ApplyChanges(ctx, changes)
+}
+
+func TestTXTRegistryCacheInvalidatedOnFailure(t *testing.T) {
+	ctx := context.Background()
+	inmem := inmemory.NewInMemoryProvider()
+	require.NoError(t, inmem.CreateZone(testZone))
+	p := &rejectingProvider{Provider: inmem}
+	r, err := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	require.NoError(t, err)
+
+	_, err = r.Records(ctx)
+	require.NoError(t, err)
+
+	p.reject = true
+	require.Error(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{newEndpointWithOwner("new-record.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "")},
+	}))
+	require.Error(t, r.DeleteRecords(ctx, []*endpoint.Endpoint{newEndpointWithOwner("old-record.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "owner")}))
+
+	// the failed create must not be reported from the cache, else it would never be retried
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestTXTRegistryInvalidateCache(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+	r, err := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	require.NoError(t, err)
+
+	_, err = r.Records(ctx)
+	require.NoError(t, err)
+
+	// records changed outside of the registry are only seen once the cache is invalidated
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{newEndpointWithOwner("manual.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "")},
+	}))
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	r.InvalidateCache()
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+func TestTXTRegistryOwnerlessRecordTypes(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("manual.test-zone.example.org", "\"challenge\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("other.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+		},
+	}))
+	r, err := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeTXT}, []string{}, false, nil)
+	require.NoError(t, err)
+	r.SetOwnerlessRecordTypes([]string{endpoint.RecordTypeTXT})
+
+	// records of ownerless types are owned by this instance without TXT records
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	owners := map[string]string{}
+	for _, record := range records {
+		owners[record.DNSName] = record.Labels[endpoint.OwnerLabelKey]
+	}
+	assert.Equal(t, map[string]string{
+		"manual.test-zone.example.org": "owner",
+		"other.test-zone.example.org":  "",
+	}, owners)
+
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("created.test-zone.example.org", "\"challenge\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("owned.test-zone.example.org", "5.6.7.8", endpoint.RecordTypeA, ""),
+		},
+	}))
+	providerRecords, err := p.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, providerRecords, 6, "only the created A record gets TXT records")
+
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	var deletes []*endpoint.Endpoint
+	for _, record := range records {
+		if record.RecordType == endpoint.RecordTypeTXT {
+			deletes = append(deletes, record)
+		}
+	}
+	require.Len(t, deletes, 2)
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Delete: deletes}))
+
+	providerRecords, err = p.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, providerRecords, 4)
+}
+
+func TestTXTRegistrySetIdentifierOwnership(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+
+	registries := map[string]*TXTRegistry{}
+	for _, owner := range []string{"cluster-a", "cluster-b"} {
+		r, err := NewTXTRegistry(p, "", "", owner, 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, []string{}, false, nil)
+		require.NoError(t, err)
+		require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+			Create: []*endpoint.Endpoint{newEndpointWithOwner("api.test-zone.example.org", "1.1.1.1", endpoint.RecordTypeA, "").WithSetIdentifier(owner)},
+		}))
+		registries[owner] = r
+	}
+
+	// each cluster owns its record set
+	records, err := registries["cluster-a"].Records(ctx)
+	require.NoError(t, err)
+	owners := map[string]string{}
+	for _, record := range records {
+		owners[record.SetIdentifier] = record.Labels[endpoint.OwnerLabelKey]
+	}
+	assert.Equal(t, map[string]string{"cluster-a": "cluster-a", "cluster-b": "cluster-b"}, owners)
+
+	// the missing TXT record of one record set is detected even though the other record set has a TXT record
+	// with the same name
+	var lost []*endpoint.Endpoint
+	all, err := p.Records(ctx)
+	require.NoError(t, err)
+	for _, record := range all {
+		if record.DNSName == "a-api.test-zone.example.org" && record.SetIdentifier == "cluster-a" {
+			lost = append(lost, record)
+		}
+	}
+	require.Len(t, lost, 1)
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{Delete: lost}))
+
+	records, err = registries["cluster-a"].Records(ctx)
+	require.NoError(t, err)
+	for _, record := range records {
+		if record.RecordType != endpoint.RecordTypeA {
+			continue
+		}
+		_, forced := record.GetProviderSpecificProperty(providerSpecificForceUpdate)
+		assert.Equal(t, record.SetIdentifier == "cluster-a", forced, record.SetIdentifier)
+	}
+}
+
+func TestTXTRegistryRegistryZone(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+	require.NoError(t, p.CreateZone("registry.internal"))
+
+	// the ownership of records created before the registry zone was set is kept
+	legacy, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, []string{}, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, legacy.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{newEndpointWithOwner("legacy.test-zone.example.org", "1.1.1.1", endpoint.RecordTypeA, "")},
+	}))
+
+	r, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, []string{}, false, nil)
+	require.NoError(t, err)
+	r.SetRegistryZone("Registry.Internal.")
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{newEndpointWithOwner("new.test-zone.example.org", "2.2.2.2", endpoint.RecordTypeA, "")},
+	}))
+
+	all, err := p.Records(ctx)
+	require.NoError(t, err)
+	var names []string
+	for _, record := range all {
+		names = append(names, record.DNSName+" "+record.RecordType)
+	}
+	assert.ElementsMatch(t, []string{
+		"legacy.test-zone.example.org A",
+		"legacy.test-zone.example.org TXT",
+		"a-legacy.test-zone.example.org TXT",
+		"new.test-zone.example.org A",
+		"new.test-zone.example.org.registry.internal TXT",
+		"a-new.test-zone.example.org.registry.internal TXT",
+	}, names)
+
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	for _, record := range records {
+		assert.Equal(t, "owner", record.Labels[endpoint.OwnerLabelKey], record.DNSName)
+		// the TXT records of the legacy record are missing in the registry zone
+		_, forced := record.GetProviderSpecificProperty(providerSpecificForceUpdate)
+		assert.Equal(t, record.DNSName == "legacy.test-zone.example.org", forced, record.DNSName)
+	}
+}
+
+func TestTXTRegistryOwnerIDMap(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+	legacy, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{}, []string{}, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, legacy.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{newEndpointWithOwner("legacy.prod.test-zone.example.org", "1.1.1.1", endpoint.RecordTypeA, "")},
+	}))
+
+	r, err := NewTXTRegistry(p, "", "", "owner", 0, "", []string{}, []string{}, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, r.SetOwnerIDMap(map[string]string{"prod.test-zone.example.org.": "prod"}))
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("new.prod.test-zone.example.org", "2.2.2.2", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("other.test-zone.example.org", "3.3.3.3", endpoint.RecordTypeA, ""),
+		},
+	}))
+
+	txtOwners := func() map[string]string {
+		records, err := p.Records(ctx)
+		require.NoError(t, err)
+		owners := map[string]string{}
+		for _, record := range records {
+			if record.RecordType != endpoint.RecordTypeTXT || !strings.HasPrefix(record.DNSName, "a-") {
+				continue
+			}
+			labels, err := endpoint.NewLabelsFromString(record.Targets[0], nil)
+			require.NoError(t, err)
+			owners[strings.TrimPrefix(record.DNSName, "a-")] = labels[endpoint.OwnerLabelKey]
+		}
+		return owners
+	}
+	assert.Equal(t, map[string]string{
+		"legacy.prod.test-zone.example.org": "owner",
+		"new.prod.test-zone.example.org":    "prod",
+		"other.test-zone.example.org":       "owner",
+	}, txtOwners())
+
+	// the records are reported with the owner id of the instance, the legacy record is migrated to the mapped owner id
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	var migrated *endpoint.Endpoint
+	for _, record := range records {
+		assert.Equal(t, "owner", record.Labels[endpoint.OwnerLabelKey], record.DNSName)
+		_, forced := record.GetProviderSpecificProperty(providerSpecificForceUpdate)
+		assert.Equal(t, record.DNSName == "legacy.prod.test-zone.example.org", forced, record.DNSName)
+		if forced {
+			migrated = record
+		}
+	}
+	require.NotNil(t, migrated)
+
+	desired := newEndpointWithOwner(migrated.DNSName, "1.1.1.1", endpoint.RecordTypeA, "owner")
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{migrated},
+		UpdateNew: []*endpoint.Endpoint{desired},
+	}))
+	assert.Equal(t, "prod", txtOwners()["legacy.prod.test-zone.example.org"])
+
+	require.EqualError(t, r.SetOwnerIDMap(map[string]string{"prod.test-zone.example.org": ""}), "the owner id of domain prod.test-zone.example.org cannot be empty")
+}
+
 /**
 
 helper methods
@@ -1675,3 +2541,55 @@ func newEndpointWithOwnerResource(dnsName, target, recordType, ownerID, resource
 	e.Labels[endpoint.ResourceLabelKey] = resource
 	return e
 }
+
+func TestTXTRegistryMetrics(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+	r, err := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	require.NoError(t, err)
+
+	misses := testutil.ToFloat64(cacheLookupsTotal.WithLabelValues("txt", "miss"))
+	hits := testutil.ToFloat64(cacheLookupsTotal.WithLabelValues("txt", "hit"))
+	created := testutil.ToFloat64(txtRecordsWrittenTotal.WithLabelValues("create"))
+	read := testutil.ToFloat64(txtRecordsReadTotal)
+
+	_, err = r.Records(ctx)
+	require.NoError(t, err)
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{newEndpointWithOwner("new-record.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, "")},
+	}))
+	_, err = r.Records(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, misses+1, testutil.ToFloat64(cacheLookupsTotal.WithLabelValues("txt", "miss")))
+	assert.Equal(t, hits+1, testutil.ToFloat64(cacheLookupsTotal.WithLabelValues("txt", "hit")))
+	// the TXT records in the old and in the new format
+	assert.Equal(t, created+2, testutil.ToFloat64(txtRecordsWrittenTotal.WithLabelValues("create")))
+
+	r.InvalidateCache()
+	_, err = r.Records(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, read+2, testutil.ToFloat64(txtRecordsReadTotal))
+}
+
+func TestTXTRegistryEncryptionFailureMetric(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+	otherKey := []byte("abcdefghijklmnopqrstuvwxyz012345")
+	payload := endpoint.Labels{endpoint.OwnerLabelKey: "owner"}.Serialize(true, true, otherKey)
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("a-record.test-zone.example.org", payload, endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("spf.test-zone.example.org", "v=spf1 -all", endpoint.RecordTypeTXT, ""),
+		},
+	}))
+	r, err := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, true, []byte("12345678901234567890123456789012"))
+	require.NoError(t, err)
+
+	failures := testutil.ToFloat64(txtEncryptionFailuresTotal)
+	_, err = r.Records(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, failures+1, testutil.ToFloat64(txtEncryptionFailuresTotal))
+}
@@ -0,0 +1,367 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+const (
+	// WebhookRegistryMediaType is the media type of the requests and responses of the webhook registry protocol.
+	WebhookRegistryMediaType = "application/external.dns.registry+json;version=1"
+
+	webhookRegistryRecordsPath = "/records"
+)
+
+// WebhookRegistryRecord is the ownership of a single DNS record as exchanged with the webhook.
+type WebhookRegistryRecord struct {
+	DNSName       string          `json:"dnsName"`
+	RecordType    string          `json:"recordType"`
+	SetIdentifier string          `json:"setIdentifier,omitempty"`
+	Labels        endpoint.Labels `json:"labels,omitempty"`
+}
+
+// WebhookRegistryChanges is the body of the POST request changing the ownership stored by the webhook.
+type WebhookRegistryChanges struct {
+	// Owner is the owner id of the instance sending the changes. The webhook should reject the changes with
+	// 409 Conflict if any of the records is owned by another owner.
+	Owner string `json:"owner"`
+	// Put are the records whose labels are created or replaced.
+	Put []WebhookRegistryRecord `json:"put,omitempty"`
+	// Delete are the records whose labels are removed, the labels of the records are not set.
+	Delete []WebhookRegistryRecord `json:"delete,omitempty"`
+}
+
+// WebhookRegistry implements registry interface with ownership stored by an external HTTP service, e.g.
+// an adapter to a CMDB or an IPAM. The service may be shared by many owners.
+type WebhookRegistry struct {
+	provider provider.Provider
+	ownerID  string // refers to the owner id of the current instance
+
+	client *http.Client
+	url    string
+
+	// cache the labels of all owners as last read
+	labels         map[endpoint.EndpointKey]endpoint.Labels
+	orphanedLabels map[endpoint.EndpointKey]bool
+
+	// cache the records in memory and update on an interval instead.
+	recordsCache            []*endpoint.Endpoint
+	recordsCacheRefreshTime time.Time
+	cacheInterval           time.Duration
+}
+
+// NewWebhookRegistry returns a new WebhookRegistry object storing the ownership with the webhook at the given URL.
+func NewWebhookRegistry(provider provider.Provider, ownerID, url string, timeout, cacheInterval time.Duration) (*WebhookRegistry, error) {
+	if ownerID == "" {
+		return nil, errors.New("owner id cannot be empty")
+	}
+	if url == "" {
+		return nil, errors.New("webhook registry url cannot be empty")
+	}
+
+	return &WebhookRegistry{
+		provider:      provider,
+		ownerID:       ownerID,
+		client:        &http.Client{Timeout: timeout},
+		url:           strings.TrimSuffix(url, "/"),
+		cacheInterval: cacheInterval,
+	}, nil
+}
+
+func (im *WebhookRegistry) GetDomainFilter() endpoint.DomainFilterInterface {
+	return im.provider.GetDomainFilter()
+}
+
+func (im *WebhookRegistry) OwnerID() string {
+	return im.ownerID
+}
+
+// OrphanedEntries returns the keys of the owned entries whose records didn't exist on the last read.
+func (im *WebhookRegistry) OrphanedEntries() []endpoint.EndpointKey {
+	return sortedKeys(im.orphanedLabels)
+}
+
+// Records returns the current records from the registry.
+func (im *WebhookRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	// If we have the zones cached AND we have refreshed the cache since the
+	// last given interval, then just use the cached results.
+	if im.recordsCache != nil && time.Since(im.recordsCacheRefreshTime) < im.cacheInterval {
+		log.Debug("Using cached records.")
+		countCacheLookup("webhook", true)
+		return im.recordsCache, nil
+	}
+	if im.cacheInterval > 0 {
+		countCacheLookup("webhook", false)
+	}
+
+	if err := im.readLabels(ctx); err != nil {
+		return nil, err
+	}
+
+	records, err := im.provider.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orphanedLabels := map[endpoint.EndpointKey]bool{}
+	for key, l := range im.labels {
+		if l[endpoint.OwnerLabelKey] == im.ownerID {
+			orphanedLabels[key] = true
+		}
+	}
+	for _, record := range records {
+		key := record.Key()
+		if l, ok := im.labels[key]; ok {
+			record.Labels = maps.Clone(l)
+			delete(orphanedLabels, key)
+		} else {
+			record.Labels = endpoint.NewLabels()
+		}
+	}
+	im.orphanedLabels = orphanedLabels
+
+	// Update the cache.
+	if im.cacheInterval > 0 {
+		im.recordsCache = records
+		im.recordsCacheRefreshTime = time.Now()
+	}
+
+	return records, nil
+}
+
+// ApplyChanges records the ownership of created and updated records with the webhook, updates the DNS
+// provider and then releases the ownership of deleted records.
+func (im *WebhookRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	timer := startLabelPersistenceTimer("webhook")
+	defer timer.observe()
+
+	if im.labels == nil {
+		if err := im.readLabels(ctx); err != nil {
+			return err
+		}
+	}
+
+	filteredChanges := &plan.Changes{
+		Create:    make([]*endpoint.Endpoint, 0, len(changes.Create)),
+		UpdateNew: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateNew),
+		UpdateOld: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateOld),
+		Delete:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete),
+	}
+
+	claims := &WebhookRegistryChanges{Owner: im.ownerID}
+	for _, r := range changes.Create {
+		key := r.Key()
+		if owner := im.labels[key][endpoint.OwnerLabelKey]; owner != "" && owner != im.ownerID {
+			// We lost a race with a different owner or another owner has an orphaned ownership record.
+			log.Infof("Skipping endpoint %v because owner does not match", r)
+			continue
+		}
+		if r.Labels == nil {
+			r.Labels = make(map[string]string)
+		}
+		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
+		filteredChanges.Create = append(filteredChanges.Create, r)
+
+		delete(im.orphanedLabels, key)
+		if !maps.Equal(im.labels[key], r.Labels) {
+			claims.Put = append(claims.Put, newWebhookRegistryRecord(key, r.Labels))
+		}
+		if im.cacheInterval > 0 {
+			im.addToCache(r)
+		}
+	}
+
+	for _, r := range filteredChanges.UpdateOld {
+		// remove old version of record from cache
+		if im.cacheInterval > 0 {
+			im.removeFromCache(r)
+		}
+	}
+
+	for _, r := range filteredChanges.UpdateNew {
+		key := r.Key()
+		if l, ok := im.labels[key]; !ok || !maps.Equal(l, r.Labels) {
+			claims.Put = append(claims.Put, newWebhookRegistryRecord(key, r.Labels))
+		}
+		// add new version of record to caches
+		if im.cacheInterval > 0 {
+			im.addToCache(r)
+		}
+	}
+
+	if err := im.writeLabels(ctx, claims); err != nil {
+		return err
+	}
+
+	// When caching is enabled, disable the provider from using the cache.
+	if im.cacheInterval > 0 {
+		ctx = context.WithValue(ctx, provider.RecordsContextKey, nil)
+	}
+	if err := timer.applyChanges(ctx, im.provider, filteredChanges); err != nil {
+		im.recordsCache = nil
+		return err
+	}
+
+	releases := &WebhookRegistryChanges{Owner: im.ownerID}
+	for _, r := range filteredChanges.Delete {
+		if _, ok := im.labels[r.Key()]; ok {
+			releases.Delete = append(releases.Delete, newWebhookRegistryRecord(r.Key(), nil))
+		}
+		if im.cacheInterval > 0 {
+			im.removeFromCache(r)
+		}
+	}
+	for _, key := range sortedKeys(im.orphanedLabels) {
+		releases.Delete = append(releases.Delete, newWebhookRegistryRecord(key, nil))
+	}
+	im.orphanedLabels = nil
+	return im.writeLabels(ctx, releases)
+}
+
+// AdjustEndpoints modifies the endpoints as needed by the specific provider.
+func (im *WebhookRegistry) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	return im.provider.AdjustEndpoints(endpoints)
+}
+
+func newWebhookRegistryRecord(key endpoint.EndpointKey, labels endpoint.Labels) WebhookRegistryRecord {
+	return WebhookRegistryRecord{
+		DNSName:       key.DNSName,
+		RecordType:    key.RecordType,
+		SetIdentifier: key.SetIdentifier,
+		Labels:        maps.Clone(labels),
+	}
+}
+
+// readLabels reads the labels of all owners from the webhook.
+func (im *WebhookRegistry) readLabels(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, im.url+webhookRegistryRecordsPath, nil)
+	if err != nil {
+		return fmt.Errorf("creating webhook registry request: %w", err)
+	}
+	req.Header.Set("Accept", WebhookRegistryMediaType)
+
+	body, err := im.do(req)
+	if err != nil {
+		return err
+	}
+	var records []WebhookRegistryRecord
+	if err := json.Unmarshal(body, &records); err != nil {
+		return fmt.Errorf("unmarshalling webhook registry records: %w", err)
+	}
+
+	labels := make(map[endpoint.EndpointKey]endpoint.Labels, len(records))
+	for _, r := range records {
+		if r.Labels == nil {
+			r.Labels = endpoint.NewLabels()
+		}
+		labels[endpoint.EndpointKey{DNSName: r.DNSName, RecordType: r.RecordType, SetIdentifier: r.SetIdentifier}] = r.Labels
+	}
+	im.labels = labels
+	return nil
+}
+
+// writeLabels sends the changes of the ownership to the webhook and updates the labels read before
+// accordingly. Nothing is sent without changes.
+func (im *WebhookRegistry) writeLabels(ctx context.Context, changes *WebhookRegistryChanges) error {
+	if len(changes.Put) == 0 && len(changes.Delete) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("marshalling webhook registry changes: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, im.url+webhookRegistryRecordsPath, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("creating webhook registry request: %w", err)
+	}
+	req.Header.Set("Content-Type", WebhookRegistryMediaType)
+
+	if _, err := im.do(req); err != nil {
+		// the ownership is read again on the next synchronization
+		im.labels = nil
+		im.recordsCache = nil
+		return err
+	}
+	for _, r := range changes.Put {
+		im.labels[endpoint.EndpointKey{DNSName: r.DNSName, RecordType: r.RecordType, SetIdentifier: r.SetIdentifier}] = r.Labels
+	}
+	for _, r := range changes.Delete {
+		delete(im.labels, endpoint.EndpointKey{DNSName: r.DNSName, RecordType: r.RecordType, SetIdentifier: r.SetIdentifier})
+	}
+	log.Infof("Updated ownership of %d and released ownership of %d records in webhook registry", len(changes.Put), len(changes.Delete))
+	return nil
+}
+
+// do sends the request and returns the body of a successful response. Failures which may go away by
+// retrying on the next synchronization are returned as soft errors.
+func (im *WebhookRegistry) do(req *http.Request) ([]byte, error) {
+	resp, err := im.client.Do(req)
+	if err != nil {
+		return nil, provider.NewSoftError(fmt.Errorf("webhook registry request %s %s: %w", req.Method, req.URL.Path, err))
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, provider.NewSoftError(fmt.Errorf("reading webhook registry response: %w", err))
+	}
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return body, nil
+	}
+
+	err = fmt.Errorf("webhook registry request %s %s failed with status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+	if resp.StatusCode == http.StatusConflict || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		return nil, provider.NewSoftError(err)
+	}
+	return nil, err
+}
+
+func (im *WebhookRegistry) addToCache(ep *endpoint.Endpoint) {
+	if im.recordsCache != nil {
+		im.recordsCache = append(im.recordsCache, ep)
+	}
+}
+
+func (im *WebhookRegistry) removeFromCache(ep *endpoint.Endpoint) {
+	if im.recordsCache == nil || ep == nil {
+		return
+	}
+
+	for i, e := range im.recordsCache {
+		if e.DNSName == ep.DNSName && e.RecordType == ep.RecordType && e.SetIdentifier == ep.SetIdentifier && e.Targets.Same(ep.Targets) {
+			// We found a match; delete the endpoint from the cache.
+			im.recordsCache = append(im.recordsCache[:i], im.recordsCache[i+1:]...)
+			return
+		}
+	}
+}
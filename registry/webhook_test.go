@@ -0,0 +1,292 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+// fakeWebhookRegistry is a webhook storing the ownership in memory.
+type fakeWebhookRegistry struct {
+	sync.Mutex
+	records map[endpoint.EndpointKey]WebhookRegistryRecord
+	posts   int
+	status  int
+}
+
+func newFakeWebhookRegistry(t *testing.T, records ...WebhookRegistryRecord) (*fakeWebhookRegistry, string) {
+	f := &fakeWebhookRegistry{records: map[endpoint.EndpointKey]WebhookRegistryRecord{}}
+	for _, r := range records {
+		f.records[webhookRegistryRecordKey(r)] = r
+	}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, server.URL
+}
+
+func webhookRegistryRecordKey(r WebhookRegistryRecord) endpoint.EndpointKey {
+	return endpoint.EndpointKey{DNSName: r.DNSName, RecordType: r.RecordType, SetIdentifier: r.SetIdentifier}
+}
+
+func (f *fakeWebhookRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	if f.status != 0 {
+		http.Error(w, "failure", f.status)
+		return
+	}
+	if req.URL.Path != "/records" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		if req.Header.Get("Accept") != WebhookRegistryMediaType {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		w.Header().Set("Content-Type", WebhookRegistryMediaType)
+		_ = json.NewEncoder(w).Encode(f.list())
+	case http.MethodPost:
+		f.posts++
+		var changes WebhookRegistryChanges
+		if err := json.NewDecoder(req.Body).Decode(&changes); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, r := range append(changes.Put, changes.Delete...) {
+			if owner := f.records[webhookRegistryRecordKey(r)].Labels[endpoint.OwnerLabelKey]; owner != "" && owner != changes.Owner {
+				http.Error(w, "owned by "+owner, http.StatusConflict)
+				return
+			}
+		}
+		for _, r := range changes.Put {
+			f.records[webhookRegistryRecordKey(r)] = r
+		}
+		for _, r := range changes.Delete {
+			delete(f.records, webhookRegistryRecordKey(r))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeWebhookRegistry) list() []WebhookRegistryRecord {
+	records := make([]WebhookRegistryRecord, 0, len(f.records))
+	for _, r := range f.records {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].DNSName < records[j].DNSName
+	})
+	return records
+}
+
+func TestWebhookRegistryNew(t *testing.T) {
+	p := inmemory.NewInMemoryProvider()
+
+	_, err := NewWebhookRegistry(p, "test-owner", "http://localhost:8080", time.Second, time.Hour)
+	require.NoError(t, err)
+
+	_, err = NewWebhookRegistry(p, "", "http://localhost:8080", time.Second, time.Hour)
+	require.EqualError(t, err, "owner id cannot be empty")
+
+	_, err = NewWebhookRegistry(p, "test-owner", "", time.Second, time.Hour)
+	require.EqualError(t, err, "webhook registry url cannot be empty")
+}
+
+func TestWebhookRegistryRecords(t *testing.T) {
+	_, url := newFakeWebhookRegistry(t,
+		WebhookRegistryRecord{
+			DNSName:    "bar.test-zone.example.org",
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+			},
+		},
+		WebhookRegistryRecord{
+			// the record has been deleted from the zone
+			DNSName:    "gone.test-zone.example.org",
+			RecordType: endpoint.RecordTypeA,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey: "test-owner",
+			},
+		},
+	)
+	r, err := NewWebhookRegistry(newConfigMapRegistryProvider(t), "test-owner", url+"/", time.Second, 0)
+	require.NoError(t, err)
+
+	records, err := r.Records(context.Background())
+	require.NoError(t, err)
+	assert.True(t, testutils.SameEndpoints(records, []*endpoint.Endpoint{
+		{
+			DNSName:    "foo.test-zone.example.org",
+			Targets:    endpoint.Targets{"foo.loadbalancer.com"},
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey: "",
+			},
+		},
+		{
+			DNSName:    "bar.test-zone.example.org",
+			Targets:    endpoint.Targets{"my-domain.com"},
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: map[string]string{
+				endpoint.OwnerLabelKey:    "test-owner",
+				endpoint.ResourceLabelKey: "ingress/default/my-ingress",
+			},
+		},
+	}), "actual: %v", records)
+	assert.Equal(t, []endpoint.EndpointKey{{DNSName: "gone.test-zone.example.org", RecordType: endpoint.RecordTypeA}}, r.OrphanedEntries())
+}
+
+func TestWebhookRegistryApplyChanges(t *testing.T) {
+	ctx := context.Background()
+	webhook, url := newFakeWebhookRegistry(t,
+		WebhookRegistryRecord{
+			DNSName:    "bar.test-zone.example.org",
+			RecordType: endpoint.RecordTypeCNAME,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey: "test-owner",
+			},
+		},
+		WebhookRegistryRecord{
+			DNSName:    "new.test-zone.example.org",
+			RecordType: endpoint.RecordTypeA,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey: "other-owner",
+			},
+		},
+		WebhookRegistryRecord{
+			// the record has been deleted from the zone
+			DNSName:    "gone.test-zone.example.org",
+			RecordType: endpoint.RecordTypeA,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey: "test-owner",
+			},
+		},
+	)
+	p := newConfigMapRegistryProvider(t)
+	r, err := NewWebhookRegistry(p, "test-owner", url, time.Second, 0)
+	require.NoError(t, err)
+
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	var bar *endpoint.Endpoint
+	for _, record := range records {
+		if record.DNSName == "bar.test-zone.example.org" {
+			bar = record
+		}
+	}
+	require.NotNil(t, bar)
+
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("baz.test-zone.example.org", endpoint.RecordTypeA, "1.2.3.4").WithSetIdentifier("set-1"),
+			// owned by another owner
+			endpoint.NewEndpoint("new.test-zone.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		},
+		Delete: []*endpoint.Endpoint{bar},
+	}))
+	assert.Equal(t, 2, webhook.posts)
+	assert.Equal(t, []WebhookRegistryRecord{
+		{
+			DNSName:       "baz.test-zone.example.org",
+			RecordType:    endpoint.RecordTypeA,
+			SetIdentifier: "set-1",
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey: "test-owner",
+			},
+		},
+		{
+			DNSName:    "new.test-zone.example.org",
+			RecordType: endpoint.RecordTypeA,
+			Labels: endpoint.Labels{
+				endpoint.OwnerLabelKey: "other-owner",
+			},
+		},
+	}, webhook.list())
+
+	zoneRecords, err := p.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, zoneRecords, 2)
+
+	// nothing is sent without changes
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{}))
+	assert.Equal(t, 2, webhook.posts)
+}
+
+func TestWebhookRegistryApplyChangesConflict(t *testing.T) {
+	ctx := context.Background()
+	webhook, url := newFakeWebhookRegistry(t)
+	p := newConfigMapRegistryProvider(t)
+	r, err := NewWebhookRegistry(p, "test-owner", url, time.Second, 0)
+	require.NoError(t, err)
+	_, err = r.Records(ctx)
+	require.NoError(t, err)
+
+	// another owner claims the record after it has been read
+	webhook.records[endpoint.EndpointKey{DNSName: "new.test-zone.example.org", RecordType: endpoint.RecordTypeA}] = WebhookRegistryRecord{
+		DNSName:    "new.test-zone.example.org",
+		RecordType: endpoint.RecordTypeA,
+		Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "other-owner"},
+	}
+	err = r.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("new.test-zone.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+	})
+	require.ErrorIs(t, err, provider.SoftError)
+	assert.ErrorContains(t, err, "failed with status 409: owned by other-owner")
+
+	// the provider isn't changed
+	zoneRecords, err := p.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, zoneRecords, 2)
+}
+
+func TestWebhookRegistryErrors(t *testing.T) {
+	webhook, url := newFakeWebhookRegistry(t)
+	r, err := NewWebhookRegistry(newConfigMapRegistryProvider(t), "test-owner", url, time.Second, 0)
+	require.NoError(t, err)
+
+	webhook.status = http.StatusServiceUnavailable
+	_, err = r.Records(context.Background())
+	require.ErrorIs(t, err, provider.SoftError)
+
+	webhook.status = http.StatusUnauthorized
+	_, err = r.Records(context.Background())
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, provider.SoftError)
+}
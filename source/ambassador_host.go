@@ -38,6 +38,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
 )
 
 // ambHostAnnotation is the annotation in the Host that maps to a Service
@@ -143,6 +144,10 @@ func (sc *ambassadorHostSource) Endpoints(ctx context.Context) ([]*endpoint.Endp
 	endpoints := []*endpoint.Endpoint{}
 
 	for _, host := range ambassadorHosts {
+		if isExcluded(host.Annotations, "host", host.Namespace, host.Name) {
+			continue
+		}
+
 		fullname := fmt.Sprintf("%s/%s", host.Namespace, host.Name)
 
 		// look for the "exernal-dns.ambassador-service" annotation. If it is not there then just ignore this `Host`
@@ -152,7 +157,7 @@ func (sc *ambassadorHostSource) Endpoints(ctx context.Context) ([]*endpoint.Endp
 			continue
 		}
 
-		targets := getTargetsFromTargetAnnotation(host.Annotations)
+		targets := annotations.TargetsFromTargetAnnotation(host.Annotations)
 		if len(targets) == 0 {
 			targets, err = sc.targetsFromAmbassadorLoadBalancer(ctx, service)
 			if err != nil {
@@ -185,11 +190,11 @@ func (sc *ambassadorHostSource) Endpoints(ctx context.Context) ([]*endpoint.Endp
 // endpointsFromHost extracts the endpoints from a Host object
 func (sc *ambassadorHostSource) endpointsFromHost(host *ambassador.Host, targets endpoint.Targets) ([]*endpoint.Endpoint, error) {
 	var endpoints []*endpoint.Endpoint
-	annotations := host.Annotations
+	annots := host.Annotations
 
 	resource := fmt.Sprintf("host/%s/%s", host.Namespace, host.Name)
-	providerSpecific, setIdentifier := getProviderSpecificAnnotations(annotations)
-	ttl := getTTLFromAnnotations(annotations, resource)
+	providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(annots)
+	ttl := annotations.TTLFromAnnotations(annots, resource)
 
 	if host.Spec != nil {
 		hostname := host.Spec.Hostname
@@ -264,6 +269,11 @@ func parseAmbLoadBalancerService(service string) (namespace, name string, err er
 }
 
 func (sc *ambassadorHostSource) AddEventHandler(ctx context.Context, handler func()) {
+	log.Debug("Adding event handler for Ambassador Host")
+
+	// Right now there is no way to remove event handler from informer, see:
+	// https://github.com/kubernetes/kubernetes/issues/79610
+	sc.ambassadorHostInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
 }
 
 // unstructuredConverter handles conversions between unstructured.Unstructured and Ambassador types
@@ -0,0 +1,379 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Types of the values of annotations. Annotation values are always strings, the type describes how they are parsed.
+const (
+	AnnotationTypeString   = "string"
+	AnnotationTypeBoolean  = "boolean"
+	AnnotationTypeEnum     = "enum"
+	AnnotationTypeList     = "list"
+	AnnotationTypeDuration = "duration"
+)
+
+// AnnotationSpec describes an annotation supported by the sources.
+type AnnotationSpec struct {
+	// Name is the key of the annotation, or the prefix of the keys of a family of annotations if Prefix is set
+	Name   string `json:"name"`
+	Prefix bool   `json:"prefix,omitempty"`
+	// Type is one of the AnnotationType constants
+	Type          string   `json:"type"`
+	AllowedValues []string `json:"allowedValues,omitempty"`
+	Description   string   `json:"description"`
+	// Sources are the names of the sources supporting the annotation, as given to --source
+	Sources []string `json:"sources"`
+}
+
+var (
+	gatewaySources = []string{"gateway-httproute", "gateway-grpcroute", "gateway-tlsroute", "gateway-tcproute", "gateway-udproute"}
+	istioSources   = []string{"istio-gateway", "istio-virtualservice"}
+
+	// sources reading Kubernetes resources, which all support the exclude annotation
+	kubernetesSources = joinSources([]string{"ambassador-host", "argo-rollout", "capi-machine", "contour-httpproxy", "crd", "f5-transportserver", "f5-virtualserver"}, gatewaySources,
+		[]string{"gloo-proxy", "ingress"}, istioSources, []string{"kong-tcpingress", "kubevirt-vmi", "node", "openshift-route", "pod", "service",
+			"skipper-routegroup", "traefik-proxy"})
+	controllerSources = joinSources([]string{"capi-machine", "contour-httpproxy"}, gatewaySources, []string{"ingress"}, istioSources,
+		[]string{"kubevirt-vmi", "node", "openshift-route", "service", "skipper-routegroup"})
+	hostnameSources = joinSources([]string{"argo-rollout", "contour-httpproxy", "f5-transportserver"}, gatewaySources, []string{"ingress"}, istioSources,
+		[]string{"kong-tcpingress", "openshift-route", "pod", "service", "skipper-routegroup", "traefik-proxy"})
+	targetSources = joinSources([]string{"ambassador-host", "argo-rollout", "capi-machine", "contour-httpproxy", "f5-transportserver", "f5-virtualserver"}, gatewaySources,
+		[]string{"gloo-proxy", "ingress"}, istioSources, []string{"kong-tcpingress", "kubevirt-vmi", "node", "openshift-route", "pod", "service",
+			"skipper-routegroup", "traefik-proxy"})
+	ttlSources = slices.DeleteFunc(slices.Clone(targetSources), func(name string) bool { return name == "pod" })
+	// sources supporting the provider-specific annotations
+	providerSpecificSources = joinSources([]string{"ambassador-host", "argo-rollout", "capi-machine", "contour-httpproxy", "f5-transportserver",
+		"f5-virtualserver"}, gatewaySources, []string{"gloo-proxy", "ingress"}, istioSources, []string{"kong-tcpingress", "kubevirt-vmi", "node",
+		"openshift-route", "service", "skipper-routegroup", "traefik-proxy"})
+	labelSources = []string{"ingress", "service"}
+	// sources publishing the addresses of a load balancer
+	ipFamiliesSources = joinSources(gatewaySources, []string{"ingress"}, istioSources, []string{"service"})
+)
+
+// annotationCatalog lists every annotation the sources support. Annotations added to the sources have to be added
+// here, too, so they are part of the schema served to policy engines and editors.
+var annotationCatalog = []AnnotationSpec{
+	{
+		Name: accessAnnotationKey, Type: AnnotationTypeEnum, AllowedValues: []string{"public", "private"},
+		Description: "Which set of node IP addresses to use for a Service of type NodePort.",
+		Sources:     []string{"service"},
+	},
+	{
+		Name: aliasAnnotationKey, Type: AnnotationTypeBoolean, AllowedValues: []string{"true", "false"},
+		Description: "Publishes the CNAME records of the resource as alias records, AWS only.",
+		Sources:     providerSpecificSources,
+	},
+	{
+		Name: CloudflareProxiedKey, Type: AnnotationTypeBoolean, AllowedValues: []string{"true", "false"},
+		Description: "Whether the traffic to the records goes through the Cloudflare proxy.",
+		Sources:     providerSpecificSources,
+	},
+	{
+		Name: controllerAnnotationKey, Type: AnnotationTypeString,
+		Description: "The resource is ignored if the value is anything but " + controllerAnnotationValue + ".",
+		Sources:     controllerSources,
+	},
+	{
+		Name: dependsOnAnnotationKey, Type: AnnotationTypeList,
+		Description: "Comma separated DNS names of the records which have to exist before the records are created.",
+		Sources:     []string{"crd", "ingress", "service"},
+	},
+	{
+		Name: descriptionAnnotationKey, Type: AnnotationTypeString,
+		Description: "Human-readable description of the records, published as record comment by supporting providers.",
+		Sources:     labelSources,
+	},
+	{
+		Name: gatewayAPIDualstackAnnotationKey, Type: AnnotationTypeBoolean, AllowedValues: []string{"true", "false"},
+		Description: "Publishes the records of the route for both IPv4 and IPv6.",
+		Sources:     gatewaySources,
+	},
+	{
+		Name: endpointsTypeAnnotationKey, Type: AnnotationTypeEnum, AllowedValues: []string{EndpointsTypeNodeExternalIP, EndpointsTypeHostIP},
+		Description: "Which set of addresses to use for a headless Service.",
+		Sources:     []string{"service"},
+	},
+	{
+		Name: excludeAnnotationKey, Type: AnnotationTypeBoolean, AllowedValues: []string{"true", "false"},
+		Description: "The resource is ignored if the value is true.",
+		Sources:     kubernetesSources,
+	},
+	{
+		Name: handoffAnnotationKey, Type: AnnotationTypeString,
+		Description: "Resource label <kind>/<namespace>/<name> of the resource the records are taken over from.",
+		Sources:     []string{"crd", "ingress", "service"},
+	},
+	{
+		Name: hostnameTemplateKey, Type: AnnotationTypeString,
+		Description: "Go template of comma separated DNS names of the records of a Pod pointing to the Pod IP, executed with the Pod.",
+		Sources:     []string{"pod"},
+	},
+	{
+		Name: hostnameAnnotationKey, Type: AnnotationTypeList,
+		Description: "Comma separated DNS names of the records of the resource.",
+		Sources:     hostnameSources,
+	},
+	{
+		Name: IstioGatewayIngressSource, Type: AnnotationTypeString,
+		Description: "Ingress <namespace>/<name> whose load balancer is used as the target of the records.",
+		Sources:     istioSources,
+	},
+	{
+		Name: ingressHostnameSourceKey, Type: AnnotationTypeEnum, AllowedValues: []string{"defined-hosts-only", "annotation-only"},
+		Description: "Where to get the DNS names of an Ingress from.",
+		Sources:     []string{"ingress"},
+	},
+	{
+		Name: ipFamiliesAnnotationKey, Type: AnnotationTypeList,
+		Description: "Comma separated IP families, ipv4 and ipv6, of the addresses published when the targets have both.",
+		Sources:     ipFamiliesSources,
+	},
+	{
+		Name: internalHostnameAnnotationKey, Type: AnnotationTypeList,
+		Description: "Comma separated DNS names of the records for use from internal networks.",
+		Sources:     []string{"pod", "service"},
+	},
+	{
+		Name: srvAnnotationKey, Type: AnnotationTypeString,
+		Description: "SRV records published under the DNS names of the Service prefixed with _<service>._<protocol>, one _<service>._<protocol> <priority> <weight> <port> <target> per line; requires SRV in --managed-record-types.",
+		Sources:     []string{"service"},
+	},
+	{
+		Name: srvServiceAnnotationKey, Type: AnnotationTypeList,
+		Description: "Comma separated service names of the SRV records of the node ports of a Service, as <service> for all ports or <port>=<service> per port name or number.",
+		Sources:     []string{"service"},
+	},
+	{
+		Name: loadBalancerHostnameKey, Type: AnnotationTypeList,
+		Description: "Comma separated DNS names of the CNAME records to the hostname of the load balancer, while the other names of the Service get the records of its IPs.",
+		Sources:     []string{"service"},
+	},
+	{
+		Name: mxAnnotationKey, Type: AnnotationTypeString,
+		Description: "Values of the MX records published under the DNS names of the Service, one <preference> <host> per line; requires MX in --managed-record-types.",
+		Sources:     []string{"service"},
+	},
+	{
+		Name: naptrAnnotationKey, Type: AnnotationTypeString,
+		Description: "Values of the NAPTR records published under the DNS names of the Service, one per line; requires NAPTR in --managed-record-types.",
+		Sources:     []string{"service"},
+	},
+	{
+		Name: ptrRecordsAnnotationKey, Type: AnnotationTypeBoolean, AllowedValues: []string{"true", "false"},
+		Description: "Also publishes a PTR record for each IP address of the Pods of a headless Service with a hostname.",
+		Sources:     []string{"service"},
+	},
+	{
+		Name: resyncAnnotationKey, Type: AnnotationTypeString,
+		Description: "Any value; the records are updated again whenever it changes.",
+		Sources:     labelSources,
+	},
+	{
+		Name: rolloutPreviewHostnameKey, Type: AnnotationTypeList,
+		Description: "Comma separated DNS names of the records of the preview or canary Service of a Rollout.",
+		Sources:     []string{"argo-rollout"},
+	},
+	{
+		Name: rolloutPreviewPrefixKey, Type: AnnotationTypeString,
+		Description: "Label prefixed to the hostnames of a Rollout to get the DNS names of its preview or canary Service; empty disables them.",
+		Sources:     []string{"argo-rollout"},
+	},
+	{
+		Name: SetIdentifierKey, Type: AnnotationTypeString,
+		Description: "Set identifier of the records, differentiating record sets with the same name and type.",
+		Sources:     providerSpecificSources,
+	},
+	{
+		Name: WeightKey, Type: AnnotationTypeString,
+		Description: "Weight of the records among the resources publishing the same hostname, for providers with weighted routing.",
+		Sources:     providerSpecificSources,
+	},
+	{
+		Name: GeolocationKey, Type: AnnotationTypeString,
+		Description: "Location of the clients answered with the records, continent:<code>, country:<code> or country:<code>-<subdivision>, for providers with geolocation routing.",
+		Sources:     providerSpecificSources,
+	},
+	{
+		Name: LatencyRegionKey, Type: AnnotationTypeString,
+		Description: "Cloud region of the targets of the records, for providers with latency-based routing.",
+		Sources:     providerSpecificSources,
+	},
+	{
+		Name: FailoverKey, Type: AnnotationTypeEnum, AllowedValues: []string{"PRIMARY", "SECONDARY"},
+		Description: "Failover role of the records, for providers with failover routing.",
+		Sources:     providerSpecificSources,
+	},
+	{
+		Name: targetAnnotationKey, Type: AnnotationTypeList,
+		Description: "Comma separated targets overriding the targets of the records.",
+		Sources:     targetSources,
+	},
+	{
+		Name: ttlAnnotationKey, Type: AnnotationTypeDuration,
+		Description: "TTL of the records, as a duration or a number of seconds.",
+		Sources:     ttlSources,
+	},
+	{
+		Name: txtAnnotationKey, Type: AnnotationTypeString,
+		Description: "Values of the TXT records published under the DNS names of the resource, one per line; requires TXT in --managed-record-types.",
+		Sources:     []string{"ingress", "service"},
+	},
+	{
+		Name: visibilityAnnotationKey, Type: AnnotationTypeEnum, AllowedValues: []string{"public", "private"},
+		Description: "Classifies the records as intended for public or private zones only.",
+		Sources:     labelSources,
+	},
+	{
+		Name: zonalRecordsAnnotationKey, Type: AnnotationTypeBoolean, AllowedValues: []string{"true", "false"},
+		Description: "Also publishes the records of a NodePort or headless Service per topology zone of the nodes, as <zone>.<hostname>.",
+		Sources:     []string{"service"},
+	},
+	{
+		Name: zoneIDAnnotationKey, Type: AnnotationTypeString,
+		Description: "ID of the hosted zone the records are pinned to.",
+		Sources:     labelSources,
+	},
+	{
+		Name: "external-dns.alpha.kubernetes.io/aws-", Prefix: true, Type: AnnotationTypeString,
+		Description: "AWS-specific properties of the records, e.g. aws-weight.",
+		Sources:     providerSpecificSources,
+	},
+	{
+		Name: "external-dns.alpha.kubernetes.io/ibmcloud-", Prefix: true, Type: AnnotationTypeString,
+		Description: "IBM Cloud-specific properties of the records.",
+		Sources:     providerSpecificSources,
+	},
+	{
+		Name: "external-dns.alpha.kubernetes.io/label-", Prefix: true, Type: AnnotationTypeString,
+		Description: "Custom labels of the records persisted in the registry, for the label names allowed with --registry-label.",
+		Sources:     labelSources,
+	},
+	{
+		Name: "external-dns.alpha.kubernetes.io/provider-specific-", Prefix: true, Type: AnnotationTypeString,
+		Description: "Provider-specific properties of the records, named by the rest of the key and passed to any provider.",
+		Sources:     providerSpecificSources,
+	},
+	{
+		Name: "external-dns.alpha.kubernetes.io/scw-", Prefix: true, Type: AnnotationTypeString,
+		Description: "Scaleway-specific properties of the records.",
+		Sources:     providerSpecificSources,
+	},
+	{
+		Name: "external-dns.alpha.kubernetes.io/webhook-", Prefix: true, Type: AnnotationTypeString,
+		Description: "Properties of the records passed to webhook providers.",
+		Sources:     providerSpecificSources,
+	},
+}
+
+// durationPattern matches the values of annotations of type AnnotationTypeDuration.
+const durationPattern = `^([0-9]+|([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$`
+
+// Annotations returns the catalog of the annotations supported by the sources.
+func Annotations() []AnnotationSpec {
+	specs := make([]AnnotationSpec, 0, len(annotationCatalog))
+	for _, spec := range annotationCatalog {
+		spec.AllowedValues = slices.Clone(spec.AllowedValues)
+		spec.Sources = slices.Clone(spec.Sources)
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// annotationSchema is a JSON schema of the annotations of a Kubernetes object.
+type annotationSchema struct {
+	Schema               string                              `json:"$schema"`
+	Title                string                              `json:"title"`
+	Type                 string                              `json:"type"`
+	Properties           map[string]annotationPropertySchema `json:"properties"`
+	PatternProperties    map[string]annotationPropertySchema `json:"patternProperties"`
+	AdditionalProperties bool                                `json:"additionalProperties"`
+}
+
+type annotationPropertySchema struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description"`
+	Enum        []string `json:"enum,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"`
+	// the extensions keep the information of the catalog which has no equivalent in JSON schema
+	AnnotationType string   `json:"x-external-dns-type"`
+	Sources        []string `json:"x-external-dns-sources"`
+}
+
+// AnnotationSchema returns a JSON schema validating the annotations of Kubernetes objects against the catalog
+// of supported annotations. Annotations not related to ExternalDNS are allowed.
+func AnnotationSchema() ([]byte, error) {
+	schema := annotationSchema{
+		Schema:               "https://json-schema.org/draft/2020-12/schema",
+		Title:                "ExternalDNS annotations",
+		Type:                 "object",
+		Properties:           map[string]annotationPropertySchema{},
+		PatternProperties:    map[string]annotationPropertySchema{},
+		AdditionalProperties: true,
+	}
+	for _, spec := range annotationCatalog {
+		property := annotationPropertySchema{
+			Type:           "string",
+			Description:    spec.Description,
+			Enum:           spec.AllowedValues,
+			AnnotationType: spec.Type,
+			Sources:        spec.Sources,
+		}
+		if spec.Type == AnnotationTypeDuration {
+			property.Pattern = durationPattern
+		}
+		if spec.Prefix {
+			schema.PatternProperties["^"+regexp.QuoteMeta(spec.Name)] = property
+		} else {
+			schema.Properties[spec.Name] = property
+		}
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ServeAnnotationSchema is an http.HandlerFunc serving the JSON schema of the supported annotations.
+func ServeAnnotationSchema(w http.ResponseWriter, _ *http.Request) {
+	schema, err := AnnotationSchema()
+	if err != nil {
+		log.Errorf("Failed to generate the annotation schema: %v", err)
+		http.Error(w, "failed to generate the annotation schema", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	if _, err := w.Write(schema); err != nil {
+		log.Errorf("Failed to write the annotation schema: %v", err)
+	}
+}
+
+func joinSources(sources ...[]string) []string {
+	return slices.Concat(sources...)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const annotationSchemaFile = "../docs/annotations/schema.json"
+
+var updateAnnotationSchema = flag.Bool("update-annotation-schema", false, "update "+annotationSchemaFile)
+
+func TestAnnotationsAreUnique(t *testing.T) {
+	names := map[string]bool{}
+	for _, spec := range Annotations() {
+		assert.False(t, names[spec.Name], "duplicate annotation %s", spec.Name)
+		names[spec.Name] = true
+		assert.NotEmpty(t, spec.Description, spec.Name)
+		assert.NotEmpty(t, spec.Sources, spec.Name)
+		if spec.Type == AnnotationTypeEnum || spec.Type == AnnotationTypeBoolean {
+			assert.NotEmpty(t, spec.AllowedValues, spec.Name)
+		}
+	}
+}
+
+func TestAnnotationsAreCopies(t *testing.T) {
+	specs := Annotations()
+	specs[0].Sources[0] = "modified"
+	assert.NotEqual(t, "modified", Annotations()[0].Sources[0])
+}
+
+// TestAnnotationsAreDocumented checks that the annotations documented in annotations.md are part of the catalog.
+func TestAnnotationsAreDocumented(t *testing.T) {
+	file, err := os.Open("../docs/annotations/annotations.md")
+	require.NoError(t, err)
+	defer file.Close()
+
+	names := map[string]bool{}
+	for _, spec := range Annotations() {
+		names[spec.Name] = true
+	}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		heading := strings.TrimLeft(scanner.Text(), "#")
+		if heading == scanner.Text() {
+			continue
+		}
+		// the name of a prefix ends with a placeholder, e.g. label-&lt;name&gt;
+		name, _, _ := strings.Cut(strings.TrimSpace(heading), "&lt;")
+		if strings.HasPrefix(name, "external-dns.alpha.kubernetes.io/") {
+			assert.True(t, names[name], "annotation %s is missing from the catalog", name)
+		}
+	}
+	require.NoError(t, scanner.Err())
+}
+
+func TestAnnotationSchema(t *testing.T) {
+	data, err := AnnotationSchema()
+	require.NoError(t, err)
+
+	var schema annotationSchema
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	ttl := schema.Properties[ttlAnnotationKey]
+	assert.Equal(t, durationPattern, ttl.Pattern)
+	assert.Equal(t, AnnotationTypeDuration, ttl.AnnotationType)
+	assert.NotContains(t, ttl.Sources, "pod")
+
+	assert.Equal(t, []string{"true", "false"}, schema.Properties[excludeAnnotationKey].Enum)
+	assert.Contains(t, schema.PatternProperties, `^external-dns\.alpha\.kubernetes\.io/aws-`)
+	assert.NotContains(t, schema.Properties, "external-dns.alpha.kubernetes.io/aws-")
+	assert.True(t, schema.AdditionalProperties)
+}
+
+// TestAnnotationSchemaFile checks that the checked in schema is up to date. Run make annotation-schema to update it.
+func TestAnnotationSchemaFile(t *testing.T) {
+	data, err := AnnotationSchema()
+	require.NoError(t, err)
+
+	if *updateAnnotationSchema {
+		require.NoError(t, os.WriteFile(annotationSchemaFile, data, 0o644))
+	}
+	expected, err := os.ReadFile(annotationSchemaFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(data), "%s is outdated, run make annotation-schema", annotationSchemaFile)
+}
+
+func TestServeAnnotationSchema(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ServeAnnotationSchema(rec, httptest.NewRequest(http.MethodGet, "/schema", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/schema+json", rec.Header().Get("Content-Type"))
+	expected, err := AnnotationSchema()
+	require.NoError(t, err)
+	assert.Equal(t, expected, rec.Body.Bytes())
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var deprecatedAnnotationsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "external_dns",
+		Subsystem: "source",
+		Name:      "deprecated_annotations_total",
+		Help:      "Number of times a legacy annotation was read in place of the annotation it's an alias of.",
+	},
+	[]string{"annotation"},
+)
+
+func init() {
+	prometheus.MustRegister(deprecatedAnnotationsTotal)
+}
+
+var (
+	annotationAliasesMutex sync.RWMutex
+	// annotationAliases maps legacy annotation keys to the current ones
+	annotationAliases map[string]string
+	// annotationPrefixAliases are the legacy annotation prefixes with the current ones, the longest first
+	annotationPrefixAliases []prefixAlias
+	// warnedAliases holds the legacy annotations whose deprecation has been logged already
+	warnedAliases sync.Map
+)
+
+// prefixAlias is a legacy annotation prefix with the current prefix it stands for.
+type prefixAlias struct {
+	legacy  string
+	current string
+}
+
+// SetAliases configures legacy annotations which are recognized in place of the current
+// ones, e.g. during the migration from a fork using another annotation prefix. Keys are the legacy
+// annotations and values the annotations they stand for. A key ending with "/" is a prefix alias and
+// translates every annotation starting with it, e.g. "example.com/" to "external-dns.alpha.kubernetes.io/".
+// The longest matching prefix alias applies. A current annotation always takes precedence over its legacy alias.
+func SetAliases(aliases map[string]string) {
+	keys := map[string]string{}
+	var prefixes []prefixAlias
+	for legacy, current := range aliases {
+		if strings.HasSuffix(legacy, "/") {
+			prefixes = append(prefixes, prefixAlias{legacy: legacy, current: current})
+		} else {
+			keys[legacy] = current
+		}
+	}
+	slices.SortFunc(prefixes, func(a, b prefixAlias) int {
+		if n := cmp.Compare(len(b.legacy), len(a.legacy)); n != 0 {
+			return n
+		}
+		return strings.Compare(a.legacy, b.legacy)
+	})
+
+	annotationAliasesMutex.Lock()
+	defer annotationAliasesMutex.Unlock()
+	annotationAliases = keys
+	annotationPrefixAliases = prefixes
+}
+
+// annotationValue returns the value of the annotation with the given key or, if it's missing, of a legacy
+// annotation which is an alias of it. Only the legacy annotation actually read is reported as deprecated.
+func annotationValue(annotations map[string]string, key string) (string, bool) {
+	if value, exists := annotations[key]; exists {
+		return value, true
+	}
+
+	annotationAliasesMutex.RLock()
+	defer annotationAliasesMutex.RUnlock()
+	if len(annotationAliases) == 0 && len(annotationPrefixAliases) == 0 {
+		return "", false
+	}
+	// the legacy annotations are sorted, so the same one wins when several are an alias of the key
+	for _, legacy := range slices.Sorted(maps.Keys(annotations)) {
+		if current, ok := currentAnnotationKey(legacy); ok && current == key {
+			reportDeprecated(legacy, current)
+			return annotations[legacy], true
+		}
+	}
+	return "", false
+}
+
+// resolveAliases returns the annotations with the legacy keys translated to the current ones, along with the
+// legacy key of each translated annotation. The legacy annotations are not reported as deprecated, since the
+// caller may not read them, see reportDeprecated. Without any configured aliases the annotations are returned as
+// they are.
+func resolveAliases(annotations map[string]string) (map[string]string, map[string]string) {
+	annotationAliasesMutex.RLock()
+	defer annotationAliasesMutex.RUnlock()
+	if len(annotationAliases) == 0 && len(annotationPrefixAliases) == 0 {
+		return annotations, nil
+	}
+
+	resolved := make(map[string]string, len(annotations))
+	legacy := map[string]string{}
+	var legacyKeys []string
+	for key, value := range annotations {
+		if _, ok := currentAnnotationKey(key); ok {
+			legacyKeys = append(legacyKeys, key)
+			continue
+		}
+		resolved[key] = value
+	}
+	// the legacy annotations are sorted, so the same one wins when several are an alias of the same annotation
+	slices.Sort(legacyKeys)
+	for _, key := range legacyKeys {
+		current, _ := currentAnnotationKey(key)
+		if _, exists := resolved[current]; exists {
+			continue
+		}
+		resolved[current] = annotations[key]
+		legacy[current] = key
+	}
+	return resolved, legacy
+}
+
+// reportDeprecated counts a read of the legacy annotation and logs its deprecation once.
+func reportDeprecated(legacy, current string) {
+	deprecatedAnnotationsTotal.WithLabelValues(legacy).Inc()
+	if _, warned := warnedAliases.LoadOrStore(legacy, true); !warned {
+		log.Warnf("The annotation %s is deprecated, use %s instead", legacy, current)
+	}
+}
+
+// currentAnnotationKey returns the annotation the legacy key is an alias of, if any.
+func currentAnnotationKey(key string) (string, bool) {
+	if current, ok := annotationAliases[key]; ok {
+		return current, true
+	}
+	for _, prefix := range annotationPrefixAliases {
+		if strings.HasPrefix(key, prefix.legacy) {
+			return prefix.current + strings.TrimPrefix(key, prefix.legacy), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestAnnotationAliases(t *testing.T) {
+	SetAliases(map[string]string{
+		"legacy.example.com/dns-name": HostnameKey,
+		"fork.example.com/":           "external-dns.alpha.kubernetes.io/",
+	})
+	defer SetAliases(nil)
+
+	before := testutil.ToFloat64(deprecatedAnnotationsTotal.WithLabelValues("legacy.example.com/dns-name"))
+	beforeTTL := testutil.ToFloat64(deprecatedAnnotationsTotal.WithLabelValues("fork.example.com/ttl"))
+
+	annotations := map[string]string{
+		"legacy.example.com/dns-name":  "foo.example.org",
+		"fork.example.com/ttl":         "60",
+		"fork.example.com/aws-weight":  "10",
+		"fork.example.com/target":      "10.0.0.1",
+		TargetKey:                      "10.0.0.2",
+		"unrelated.example.com/target": "10.0.0.3",
+	}
+
+	assert.Equal(t, []string{"foo.example.org"}, HostnamesFromAnnotations(annotations))
+	assert.Equal(t, endpoint.TTL(60), TTLFromAnnotations(annotations, "test"))
+	// the current annotation takes precedence over the legacy one
+	assert.Equal(t, endpoint.Targets{"10.0.0.2"}, TargetsFromTargetAnnotation(annotations))
+
+	providerSpecific, _ := ProviderSpecificAnnotations(annotations)
+	assert.Equal(t, endpoint.ProviderSpecific{{Name: "aws/weight", Value: "10"}}, providerSpecific)
+
+	// only the legacy annotations actually read are counted, once per read
+	assert.Equal(t, before+1, testutil.ToFloat64(deprecatedAnnotationsTotal.WithLabelValues("legacy.example.com/dns-name")))
+	assert.Equal(t, beforeTTL+1, testutil.ToFloat64(deprecatedAnnotationsTotal.WithLabelValues("fork.example.com/ttl")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(deprecatedAnnotationsTotal.WithLabelValues("fork.example.com/target")))
+	// the annotations of the resource are left untouched
+	assert.Len(t, annotations, 6)
+}
+
+func TestAnnotationAliasesDisabled(t *testing.T) {
+	annotations := map[string]string{"fork.example.com/ttl": "60"}
+	assert.Equal(t, endpoint.TTL(0), TTLFromAnnotations(annotations, "test"))
+}
+
+func TestAnnotationAliasesPrecedence(t *testing.T) {
+	SetAliases(map[string]string{
+		"legacy-b.example.com/hostname": HostnameKey,
+		"legacy-a.example.com/hostname": HostnameKey,
+		"fork/":                         "unrelated.example.com/",
+		"fork/v2/":                      "external-dns.alpha.kubernetes.io/",
+	})
+	defer SetAliases(nil)
+
+	annotations := map[string]string{
+		"legacy-a.example.com/hostname": "a.example.org",
+		"legacy-b.example.com/hostname": "b.example.org",
+		"fork/v2/ttl":                   "60",
+	}
+	for range 10 {
+		// the same legacy annotation wins every time
+		assert.Equal(t, []string{"a.example.org"}, HostnamesFromAnnotations(annotations))
+		resolved, _ := resolveAliases(annotations)
+		assert.Equal(t, "a.example.org", resolved[HostnameKey])
+		// the longest prefix alias applies
+		assert.Equal(t, endpoint.TTL(60), TTLFromAnnotations(annotations, "test"))
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package annotations defines the annotations ExternalDNS reads from Kubernetes resources and parses
+// them, so that an annotation behaves the same whichever source reads it.
+package annotations
+
+const (
+	// The annotation used for figuring out which controller is responsible
+	ControllerKey = "external-dns.alpha.kubernetes.io/controller"
+	// The value of the controller annotation so that we feel responsible
+	ControllerValue = "dns-controller"
+	// The annotation used for opting a resource out of ExternalDNS, whatever the source
+	ExcludeKey = "external-dns.alpha.kubernetes.io/exclude"
+	// The annotation used for defining the desired hostname
+	HostnameKey = "external-dns.alpha.kubernetes.io/hostname"
+	// The annotation used for defining the desired hostname for use from internal networks
+	InternalHostnameKey = "external-dns.alpha.kubernetes.io/internal-hostname"
+	// The annotation used for specifying whether the public or private interface address is used
+	AccessKey = "external-dns.alpha.kubernetes.io/access"
+	// The annotation used for specifying the type of endpoints to use for headless services
+	EndpointsTypeKey = "external-dns.alpha.kubernetes.io/endpoints-type"
+	// The annotation used for publishing per-zone records of the nodes of NodePort and headless services
+	ZonalRecordsKey = "external-dns.alpha.kubernetes.io/zonal-records"
+	// The annotation used for publishing PTR records of the pods of headless services
+	PTRRecordsKey = "external-dns.alpha.kubernetes.io/ptr-records"
+	// The annotation used for choosing the IP families published when the targets have both IPv4 and IPv6 addresses
+	IPFamiliesKey = "external-dns.alpha.kubernetes.io/ip-families"
+	// The annotation used for publishing additional TXT records under the hostnames of the resource
+	TXTKey = "external-dns.alpha.kubernetes.io/txt"
+	// The annotation used for publishing additional MX records under the hostnames of the resource
+	MXKey = "external-dns.alpha.kubernetes.io/mx"
+	// The annotation used for publishing additional SRV records under the hostnames of the resource
+	SRVKey = "external-dns.alpha.kubernetes.io/srv"
+	// The annotation used for publishing additional NAPTR records under the hostnames of the resource
+	NAPTRKey = "external-dns.alpha.kubernetes.io/naptr"
+	// The annotation used for defining the desired ingress/service target
+	TargetKey = "external-dns.alpha.kubernetes.io/target"
+	// The annotation used for defining the desired DNS record TTL
+	TTLKey = "external-dns.alpha.kubernetes.io/ttl"
+	// The annotation used for attaching a human-readable description to the DNS records
+	DescriptionKey = "external-dns.alpha.kubernetes.io/description"
+	// The annotation used for forcing the DNS records to be updated again whenever its value changes
+	ResyncKey = "external-dns.alpha.kubernetes.io/resync"
+	// The annotation used for pinning the DNS records to the hosted zone with the given ID
+	ZoneIDKey = "external-dns.alpha.kubernetes.io/zone-id"
+	// The annotation used for handing the DNS records of another resource over to this one
+	HandoffKey = "external-dns.alpha.kubernetes.io/handoff-from"
+	// The annotation used for deferring the creation of the DNS records until the records with the given names exist
+	DependsOnKey = "external-dns.alpha.kubernetes.io/depends-on"
+	// The annotation used for classifying the DNS records as intended for public or private zones only
+	VisibilityKey = "external-dns.alpha.kubernetes.io/visibility"
+	// The annotation used for switching to the alias record types e. g. AWS Alias records instead of a normal CNAME
+	AliasKey = "external-dns.alpha.kubernetes.io/alias"
+	// The annotation used to determine the source of hostnames for ingresses.  This is an optional field - all
+	// available hostname sources are used if not specified.
+	IngressHostnameSourceKey = "external-dns.alpha.kubernetes.io/ingress-hostname-source"
+	// The annotation used for defining the hostnames of the preview or canary Service of an Argo Rollout
+	RolloutPreviewHostnameKey = "external-dns.alpha.kubernetes.io/rollout-preview-hostname"
+	// The annotation used for defining the label prepended to the hostnames of an Argo Rollout for its preview or canary Service
+	RolloutPreviewPrefixKey = "external-dns.alpha.kubernetes.io/rollout-preview-prefix"
+	// The annotation used for defining a template of the hostnames of a Pod, executed with the Pod
+	HostnameTemplateKey = "external-dns.alpha.kubernetes.io/hostname-template"
+	// The annotation used for defining the hostnames of the CNAME records to the hostname of the load balancer of a
+	// Service, when the IPs of the load balancer are published under the other hostnames of the Service
+	LoadBalancerHostnameKey = "external-dns.alpha.kubernetes.io/load-balancer-hostname"
+	// The annotation used for defining the service names of the SRV records of the node ports of a Service
+	SRVServiceKey = "external-dns.alpha.kubernetes.io/srv-service"
+	// The prefix of the annotations used for setting the custom labels allowed with --registry-label
+	LabelPrefix = "external-dns.alpha.kubernetes.io/label-"
+)
+
+// Provider-specific annotations
+const (
+	// The annotation used for determining if traffic will go through Cloudflare
+	CloudflareProxiedKey = "external-dns.alpha.kubernetes.io/cloudflare-proxied"
+
+	SetIdentifierKey = "external-dns.alpha.kubernetes.io/set-identifier"
+
+	// The annotation used for defining the weight of the records of a resource, for weighted routing between the
+	// resources publishing the same hostname
+	WeightKey = "external-dns.alpha.kubernetes.io/weight"
+	// The annotation used for defining the location of the clients answered with the records of a resource, for
+	// geolocation routing, e.g. continent:EU or country:US-CA
+	GeolocationKey = "external-dns.alpha.kubernetes.io/geolocation"
+	// The annotation used for defining the cloud region of the targets of a resource, for latency-based routing
+	LatencyRegionKey = "external-dns.alpha.kubernetes.io/latency-region"
+	// The annotation used for defining whether the records of a resource are the primary or the secondary records of
+	// failover routing
+	FailoverKey = "external-dns.alpha.kubernetes.io/failover"
+
+	// Prefixes of the annotations passed to the providers as provider-specific properties
+	AWSPrefix      = "external-dns.alpha.kubernetes.io/aws-"
+	SCWPrefix      = "external-dns.alpha.kubernetes.io/scw-"
+	IBMCloudPrefix = "external-dns.alpha.kubernetes.io/ibmcloud-"
+	WebhookPrefix  = "external-dns.alpha.kubernetes.io/webhook-"
+	// ProviderSpecificPrefix is the prefix of the annotations passed as they are, named by the rest of the key
+	ProviderSpecificPrefix = "external-dns.alpha.kubernetes.io/provider-specific-"
+)
@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"slices"
+	"sync"
+)
+
+var (
+	customLabelsMutex sync.RWMutex
+	// customLabels are the names of the labels which may be set with the label annotations
+	customLabels []string
+)
+
+// SetCustomLabels configures the names of the custom labels sources set from the annotations with
+// LabelPrefix, e.g. "team" for external-dns.alpha.kubernetes.io/label-team. Annotations of other
+// labels are ignored, so only the labels persisted by the registry are set.
+func SetCustomLabels(names []string) {
+	customLabelsMutex.Lock()
+	defer customLabelsMutex.Unlock()
+	customLabels = slices.Clone(names)
+}
+
+// CustomLabelsFromAnnotations returns the custom labels set with the label annotations, sanitized like
+// the labels of LabelFromAnnotations.
+func CustomLabelsFromAnnotations(annotations map[string]string) map[string]string {
+	customLabelsMutex.RLock()
+	defer customLabelsMutex.RUnlock()
+	labels := map[string]string{}
+	for _, name := range customLabels {
+		if value, ok := LabelFromAnnotations(annotations, LabelPrefix+name); ok {
+			labels[name] = value
+		}
+	}
+	return labels
+}
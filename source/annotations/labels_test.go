@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomLabelsFromAnnotations(t *testing.T) {
+	annotations := map[string]string{
+		LabelPrefix + "team":        "payments",
+		LabelPrefix + "environment": "prod,eu",
+		LabelPrefix + "cost-center": "42",
+		LabelPrefix + "empty":       " ",
+	}
+	assert.Empty(t, CustomLabelsFromAnnotations(annotations))
+
+	SetCustomLabels([]string{"team", "environment", "empty", "missing"})
+	defer SetCustomLabels(nil)
+	assert.Equal(t, map[string]string{
+		"team":        "payments",
+		"environment": "prod eu",
+	}, CustomLabelsFromAnnotations(annotations))
+}
+
+func TestCustomLabelsFromAliasedAnnotations(t *testing.T) {
+	SetCustomLabels([]string{"team"})
+	defer SetCustomLabels(nil)
+	SetAliases(map[string]string{"example.com/": "external-dns.alpha.kubernetes.io/"})
+	defer SetAliases(nil)
+
+	assert.Equal(t, map[string]string{"team": "payments"}, CustomLabelsFromAnnotations(map[string]string{"example.com/label-team": "payments"}))
+}
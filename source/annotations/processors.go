@@ -0,0 +1,452 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	ttlMinimum = 1
+	ttlMaximum = math.MaxInt32
+)
+
+// TTLFromAnnotations returns the TTL set with the TTL annotation. Missing, invalid or out of range values
+// are logged and result in a TTL of 0, which leaves the TTL to the provider.
+func TTLFromAnnotations(annotations map[string]string, resource string) endpoint.TTL {
+	ttlNotConfigured := endpoint.TTL(0)
+	ttlAnnotation, exists := annotationValue(annotations, TTLKey)
+	if !exists {
+		return ttlNotConfigured
+	}
+	ttlValue, err := ParseTTL(ttlAnnotation)
+	if err != nil {
+		log.Warnf("%s: \"%v\" is not a valid TTL value: %v", resource, ttlAnnotation, err)
+		return ttlNotConfigured
+	}
+	if ttlValue < ttlMinimum || ttlValue > ttlMaximum {
+		log.Warnf("%s: TTL value %d must be between [%d, %d]", resource, ttlValue, ttlMinimum, ttlMaximum)
+		return ttlNotConfigured
+	}
+	return endpoint.TTL(ttlValue)
+}
+
+// ParseTTL parses TTL from string, returning duration in seconds.
+// ParseTTL supports both integers like "600" and durations based
+// on Go Duration like "10m", hence "600" and "10m" represent the same value.
+//
+// Note: for durations like "1.5s" the fraction is omitted (resulting in 1 second
+// for the example).
+func ParseTTL(s string) (ttlSeconds int64, err error) {
+	ttlDuration, errDuration := time.ParseDuration(s)
+	if errDuration != nil {
+		ttlInt, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, errDuration
+		}
+		return ttlInt, nil
+	}
+
+	return int64(ttlDuration.Seconds()), nil
+}
+
+// HostnamesFromAnnotations returns the hostnames set with the hostname annotation.
+func HostnamesFromAnnotations(annotations map[string]string) []string {
+	hostnameAnnotation, exists := annotationValue(annotations, HostnameKey)
+	if !exists {
+		return nil
+	}
+	return SplitHostnameAnnotation(hostnameAnnotation)
+}
+
+// InternalHostnamesFromAnnotations returns the hostnames set with the internal hostname annotation.
+func InternalHostnamesFromAnnotations(annotations map[string]string) []string {
+	internalHostnameAnnotation, exists := annotationValue(annotations, InternalHostnameKey)
+	if !exists {
+		return nil
+	}
+	return SplitHostnameAnnotation(internalHostnameAnnotation)
+}
+
+// LoadBalancerHostnamesFromAnnotations returns the hostnames of the CNAME records to the hostname of a load balancer.
+func LoadBalancerHostnamesFromAnnotations(annotations map[string]string) []string {
+	loadBalancerHostnameAnnotation, exists := annotations[LoadBalancerHostnameKey]
+	if !exists {
+		return nil
+	}
+	return SplitHostnameAnnotation(loadBalancerHostnameAnnotation)
+}
+
+// SRVServicesFromAnnotations returns the service names of the SRV records of the ports of a Service. The annotation
+// is a comma separated list of `<service>`, for all ports, or `<port>=<service>`, with the name or number of a port.
+// The default service name is stored under the empty key. The second return value is false without the annotation.
+func SRVServicesFromAnnotations(annotations map[string]string) (map[string]string, bool) {
+	srvServiceAnnotation, exists := annotations[SRVServiceKey]
+	if !exists {
+		return nil, false
+	}
+	services := map[string]string{}
+	for _, entry := range strings.Split(srvServiceAnnotation, ",") {
+		port, service, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			port, service = "", port
+		}
+		service = strings.TrimPrefix(strings.TrimSpace(service), "_")
+		if service != "" {
+			services[strings.TrimSpace(port)] = service
+		}
+	}
+	return services, true
+}
+
+// SplitHostnameAnnotation splits the comma separated value of a hostname annotation. Blanks are removed
+// and empty entries are skipped.
+func SplitHostnameAnnotation(annotation string) []string {
+	var hostnames []string
+	for _, hostname := range strings.Split(strings.ReplaceAll(annotation, " ", ""), ",") {
+		if hostname != "" {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	return hostnames
+}
+
+// TargetsFromTargetAnnotation returns the targets set with the target annotation, without trailing periods.
+// Returns empty targets if the annotation is missing or empty.
+func TargetsFromTargetAnnotation(annotations map[string]string) endpoint.Targets {
+	value, _ := annotationValue(annotations, TargetKey)
+	var targets endpoint.Targets
+	for _, target := range SplitHostnameAnnotation(value) {
+		targets = append(targets, strings.TrimSuffix(target, "."))
+	}
+	return targets
+}
+
+// TXTRecordsFromAnnotations returns the values of the TXT records set with the txt annotation, one per line.
+// Surrounding blanks are removed and empty lines are skipped.
+func TXTRecordsFromAnnotations(annotations map[string]string) []string {
+	return annotationLines(annotations, TXTKey)
+}
+
+// MXRecordsFromAnnotations returns the targets of the MX records set with the mx annotation, one
+// "<preference> <host>" per line.
+func MXRecordsFromAnnotations(annotations map[string]string) []string {
+	return annotationLines(annotations, MXKey)
+}
+
+// NAPTRRecordsFromAnnotations returns the targets of the NAPTR records set with the naptr annotation, one
+// "<order> <preference> "<flags>" "<service>" "<regexp>" <replacement>" per line.
+func NAPTRRecordsFromAnnotations(annotations map[string]string) []string {
+	return annotationLines(annotations, NAPTRKey)
+}
+
+// SRVRecordsFromAnnotations returns the targets of the SRV records set with the srv annotation by the
+// "_<service>._<protocol>" prefix of their names, one "_<service>._<protocol> <priority> <weight> <port> <target>"
+// per line. Lines with an invalid prefix are logged and ignored.
+func SRVRecordsFromAnnotations(annotations map[string]string) map[string][]string {
+	records := map[string][]string{}
+	for _, line := range annotationLines(annotations, SRVKey) {
+		fields := strings.Fields(line)
+		prefix := fields[0]
+		service, protocol, ok := strings.Cut(prefix, ".")
+		if !ok || len(service) < 2 || len(protocol) < 2 || service[0] != '_' || protocol[0] != '_' || strings.Contains(protocol, ".") {
+			log.Warnf("Ignoring SRV record %q of annotation %s: the name must have the format _<service>._<protocol>", line, SRVKey)
+			continue
+		}
+		records[prefix] = append(records[prefix], strings.Join(fields[1:], " "))
+	}
+	return records
+}
+
+// annotationLines returns the lines of the value of an annotation without surrounding blanks, skipping empty lines.
+func annotationLines(annotations map[string]string, key string) []string {
+	annotation, _ := annotationValue(annotations, key)
+	var values []string
+	for _, value := range strings.Split(annotation, "\n") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// TargetsOfIPFamilies returns the targets of the IP families of the ip-families annotation, "ipv4", "ipv6" or
+// both separated by a comma. The annotation only applies to targets with addresses of both families, so a resource
+// is still published when its load balancer has only one. Hostnames are kept. Invalid values are logged and ignored.
+func TargetsOfIPFamilies(annotations map[string]string, targets endpoint.Targets) endpoint.Targets {
+	value, _ := annotationValue(annotations, IPFamiliesKey)
+	var ipv4, ipv6 bool
+	for _, family := range SplitHostnameAnnotation(strings.ToLower(value)) {
+		switch family {
+		case "ipv4":
+			ipv4 = true
+		case "ipv6":
+			ipv6 = true
+		default:
+			log.Warnf("Ignoring invalid value %q of the annotation %s", value, IPFamiliesKey)
+			return targets
+		}
+	}
+	if !ipv4 && !ipv6 {
+		return targets
+	}
+
+	isIPv6 := func(target string) (bool, bool) {
+		addr, err := netip.ParseAddr(target)
+		return addr.Is6(), err == nil
+	}
+	var hasIPv4, hasIPv6 bool
+	for _, target := range targets {
+		if v6, isIP := isIPv6(target); isIP {
+			hasIPv4 = hasIPv4 || !v6
+			hasIPv6 = hasIPv6 || v6
+		}
+	}
+	if !hasIPv4 || !hasIPv6 {
+		return targets
+	}
+
+	return slices.DeleteFunc(slices.Clone(targets), func(target string) bool {
+		v6, isIP := isIPv6(target)
+		return isIP && ((v6 && !ipv6) || (!v6 && !ipv4))
+	})
+}
+
+// AccessFromAnnotations returns the value of the access annotation, if any.
+func AccessFromAnnotations(annotations map[string]string) string {
+	value, _ := annotationValue(annotations, AccessKey)
+	return value
+}
+
+// EndpointsTypeFromAnnotations returns the value of the endpoints type annotation, if any.
+func EndpointsTypeFromAnnotations(annotations map[string]string) string {
+	value, _ := annotationValue(annotations, EndpointsTypeKey)
+	return value
+}
+
+// BoolFromAnnotations parses the boolean annotation with the given key. The value is accepted in any of
+// the forms of strconv.ParseBool, e.g. "true" or "1". A missing annotation is false and not an error.
+func BoolFromAnnotations(annotations map[string]string, key string) (bool, error) {
+	value, exists := annotations[key]
+	return parseBoolAnnotation(key, value, exists)
+}
+
+// parseBoolAnnotation parses the value of a boolean annotation, which is false if the annotation doesn't exist.
+func parseBoolAnnotation(key, value string, exists bool) (bool, error) {
+	if !exists {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid value %q of the annotation %s", value, key)
+	}
+	return parsed, nil
+}
+
+// AliasFromAnnotations returns whether the alias annotation is set. Invalid values are logged and ignored.
+func AliasFromAnnotations(annotations map[string]string) bool {
+	value, exists := annotationValue(annotations, AliasKey)
+	alias, err := parseBoolAnnotation(AliasKey, value, exists)
+	if err != nil {
+		log.Warnf("Ignoring %v", err)
+	}
+	return alias
+}
+
+// ZonalRecordsFromAnnotations returns whether the zonal-records annotation is set. Invalid values are logged and
+// ignored.
+func ZonalRecordsFromAnnotations(annotations map[string]string) bool {
+	zonal, err := BoolFromAnnotations(annotations, ZonalRecordsKey)
+	if err != nil {
+		log.Warnf("Ignoring %v", err)
+	}
+	return zonal
+}
+
+// PTRRecordsFromAnnotations returns whether the ptr-records annotation is set. Invalid values are logged and ignored.
+func PTRRecordsFromAnnotations(annotations map[string]string) bool {
+	ptr, err := BoolFromAnnotations(annotations, PTRRecordsKey)
+	if err != nil {
+		log.Warnf("Ignoring %v", err)
+	}
+	return ptr
+}
+
+// ForeignController returns the value of the controller annotation and whether it names another controller
+// than ExternalDNS, in which case the resource has to be skipped.
+func ForeignController(annotations map[string]string) (string, bool) {
+	controller, exists := annotations[ControllerKey]
+	return controller, exists && controller != ControllerValue
+}
+
+// LabelFromAnnotations returns the value of an annotation which is copied to a label of the endpoints.
+// The characters separating the labels in the registry are replaced by spaces, blank values are ignored.
+func LabelFromAnnotations(annotations map[string]string, key string) (string, bool) {
+	value, exists := annotationValue(annotations, key)
+	if !exists {
+		return "", false
+	}
+	value = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r == ',' || r == '=' || r == '"' {
+			return ' '
+		}
+		return r
+	}, value))
+	return value, value != ""
+}
+
+// routingPolicyAnnotations are the annotations of the routing policies supported by all the providers with routing
+// policies, with the provider-specific property they are translated to.
+var routingPolicyAnnotations = []struct {
+	key       string
+	property  string
+	normalize func(string) string
+}{
+	{WeightKey, endpoint.ProviderSpecificWeight, strings.TrimSpace},
+	{GeolocationKey, endpoint.ProviderSpecificGeolocation, strings.TrimSpace},
+	{LatencyRegionKey, endpoint.ProviderSpecificLatencyRegion, strings.TrimSpace},
+	{FailoverKey, endpoint.ProviderSpecificFailover, func(v string) string { return strings.ToUpper(strings.TrimSpace(v)) }},
+}
+
+// providerSpecificPrefixes are the prefixes of the annotations which are translated to provider-specific properties.
+var providerSpecificPrefixes = []string{AWSPrefix, SCWPrefix, IBMCloudPrefix, WebhookPrefix, ProviderSpecificPrefix}
+
+// ProviderSpecificAnnotations returns the provider-specific properties and the set identifier set with
+// the provider-specific annotations.
+func ProviderSpecificAnnotations(annotations map[string]string) (endpoint.ProviderSpecific, string) {
+	providerSpecificAnnotations := endpoint.ProviderSpecific{}
+
+	v, exists := annotationValue(annotations, CloudflareProxiedKey)
+	if exists {
+		providerSpecificAnnotations = append(providerSpecificAnnotations, endpoint.ProviderSpecificProperty{
+			Name:  CloudflareProxiedKey,
+			Value: v,
+		})
+	}
+	if AliasFromAnnotations(annotations) {
+		providerSpecificAnnotations = append(providerSpecificAnnotations, endpoint.ProviderSpecificProperty{
+			Name:  "alias",
+			Value: "true",
+		})
+	}
+	for _, policy := range routingPolicyAnnotations {
+		if v, exists := annotationValue(annotations, policy.key); exists {
+			providerSpecificAnnotations = append(providerSpecificAnnotations, endpoint.ProviderSpecificProperty{
+				Name:  policy.property,
+				Value: policy.normalize(v),
+			})
+		}
+	}
+	setIdentifier := ""
+	resolved, legacy := resolveAliases(annotations)
+	for k, v := range resolved {
+		if k != SetIdentifierKey && !slices.ContainsFunc(providerSpecificPrefixes, func(prefix string) bool {
+			return strings.HasPrefix(k, prefix)
+		}) {
+			continue
+		}
+		if l, ok := legacy[k]; ok {
+			reportDeprecated(l, k)
+		}
+		if k == SetIdentifierKey {
+			setIdentifier = v
+		} else if strings.HasPrefix(k, AWSPrefix) {
+			attr := strings.TrimPrefix(k, AWSPrefix)
+			providerSpecificAnnotations = append(providerSpecificAnnotations, endpoint.ProviderSpecificProperty{
+				Name:  fmt.Sprintf("aws/%s", attr),
+				Value: v,
+			})
+		} else if strings.HasPrefix(k, SCWPrefix) {
+			attr := strings.TrimPrefix(k, SCWPrefix)
+			providerSpecificAnnotations = append(providerSpecificAnnotations, endpoint.ProviderSpecificProperty{
+				Name:  fmt.Sprintf("scw/%s", attr),
+				Value: v,
+			})
+		} else if strings.HasPrefix(k, IBMCloudPrefix) {
+			attr := strings.TrimPrefix(k, IBMCloudPrefix)
+			providerSpecificAnnotations = append(providerSpecificAnnotations, endpoint.ProviderSpecificProperty{
+				Name:  fmt.Sprintf("ibmcloud-%s", attr),
+				Value: v,
+			})
+		} else if strings.HasPrefix(k, ProviderSpecificPrefix) {
+			// Passed through without a provider prefix, for the providers defining their own property names
+			attr := strings.TrimPrefix(k, ProviderSpecificPrefix)
+			providerSpecificAnnotations = append(providerSpecificAnnotations, endpoint.ProviderSpecificProperty{
+				Name:  attr,
+				Value: v,
+			})
+		} else if strings.HasPrefix(k, WebhookPrefix) {
+			// Support for wildcard annotations for webhook providers
+			attr := strings.TrimPrefix(k, WebhookPrefix)
+			providerSpecificAnnotations = append(providerSpecificAnnotations, endpoint.ProviderSpecificProperty{
+				Name:  fmt.Sprintf("webhook/%s", attr),
+				Value: v,
+			})
+		}
+	}
+	return providerSpecificAnnotations, setIdentifier
+}
+
+// namespaceDefaultKeys are the annotations which can be set on a Namespace as defaults of its resources.
+var namespaceDefaultKeys = []string{TTLKey, TargetKey, CloudflareProxiedKey, AliasKey}
+
+// namespaceDefaultPrefixes are the prefixes of the provider-specific annotations which can be set on a Namespace
+// as defaults of its resources.
+var namespaceDefaultPrefixes = providerSpecificPrefixes
+
+// WithNamespaceDefaults returns the annotations of a resource completed with the TTL, target and provider-specific
+// annotations of its Namespace which the resource doesn't set itself, and whether any default was added. The
+// annotations of the resource are not modified.
+func WithNamespaceDefaults(annotations, namespaceAnnotations map[string]string) (map[string]string, bool) {
+	resolved, _ := resolveAliases(annotations)
+	defaults, legacy := resolveAliases(namespaceAnnotations)
+	var merged map[string]string
+	for key, value := range defaults {
+		if _, exists := resolved[key]; exists {
+			continue
+		}
+		if !slices.Contains(namespaceDefaultKeys, key) && !slices.ContainsFunc(namespaceDefaultPrefixes, func(prefix string) bool {
+			return strings.HasPrefix(key, prefix)
+		}) {
+			continue
+		}
+		if merged == nil {
+			// the annotations of the resource are kept as they are, so their legacy keys are reported when read
+			merged = make(map[string]string, len(annotations)+1)
+			maps.Copy(merged, annotations)
+		}
+		merged[key] = value
+		if l, ok := legacy[key]; ok {
+			reportDeprecated(l, key)
+		}
+	}
+	if merged == nil {
+		return annotations, false
+	}
+	return merged, true
+}
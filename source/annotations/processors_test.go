@@ -0,0 +1,249 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestTTLFromAnnotations(t *testing.T) {
+	for _, tc := range []struct {
+		title       string
+		annotations map[string]string
+		expectedTTL endpoint.TTL
+	}{
+		{
+			title:       "TTL annotation not present",
+			annotations: map[string]string{"foo": "bar"},
+			expectedTTL: endpoint.TTL(0),
+		},
+		{
+			title:       "TTL annotation value is not a number",
+			annotations: map[string]string{TTLKey: "foo"},
+			expectedTTL: endpoint.TTL(0),
+		},
+		{
+			title:       "TTL annotation value is empty",
+			annotations: map[string]string{TTLKey: ""},
+			expectedTTL: endpoint.TTL(0),
+		},
+		{
+			title:       "TTL annotation value is negative number",
+			annotations: map[string]string{TTLKey: "-1"},
+			expectedTTL: endpoint.TTL(0),
+		},
+		{
+			title:       "TTL annotation value is too high",
+			annotations: map[string]string{TTLKey: fmt.Sprintf("%d", 1<<32)},
+			expectedTTL: endpoint.TTL(0),
+		},
+		{
+			title:       "TTL annotation value is set correctly using integer",
+			annotations: map[string]string{TTLKey: "60"},
+			expectedTTL: endpoint.TTL(60),
+		},
+		{
+			title:       "TTL annotation value is set correctly using duration (whole)",
+			annotations: map[string]string{TTLKey: "10m"},
+			expectedTTL: endpoint.TTL(600),
+		},
+		{
+			title:       "TTL annotation value is set correctly using duration (fractional)",
+			annotations: map[string]string{TTLKey: "20.5s"},
+			expectedTTL: endpoint.TTL(20),
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			ttl := TTLFromAnnotations(tc.annotations, "resource/test")
+			assert.Equal(t, tc.expectedTTL, ttl)
+		})
+	}
+}
+
+func TestHostnamesFromAnnotations(t *testing.T) {
+	assert.Nil(t, HostnamesFromAnnotations(map[string]string{"foo": "bar"}))
+	assert.Nil(t, HostnamesFromAnnotations(map[string]string{HostnameKey: ""}))
+	assert.Equal(t, []string{"foo.example.org", "bar.example.org"},
+		HostnamesFromAnnotations(map[string]string{HostnameKey: " foo.example.org, ,bar.example.org,"}))
+	assert.Equal(t, []string{"internal.example.org"},
+		InternalHostnamesFromAnnotations(map[string]string{InternalHostnameKey: "internal.example.org"}))
+}
+
+func TestTargetsFromTargetAnnotation(t *testing.T) {
+	assert.Empty(t, TargetsFromTargetAnnotation(map[string]string{"foo": "bar"}))
+	assert.Empty(t, TargetsFromTargetAnnotation(map[string]string{TargetKey: ""}))
+	assert.Equal(t, endpoint.Targets{"lb.example.org", "10.0.0.1"},
+		TargetsFromTargetAnnotation(map[string]string{TargetKey: "lb.example.org., 10.0.0.1"}))
+}
+
+func TestTXTRecordsFromAnnotations(t *testing.T) {
+	assert.Empty(t, TXTRecordsFromAnnotations(map[string]string{"foo": "bar"}))
+	assert.Empty(t, TXTRecordsFromAnnotations(map[string]string{TXTKey: " \n"}))
+	assert.Equal(t, []string{"v=spf1 include:_spf.example.org -all", "site-verification=abc"},
+		TXTRecordsFromAnnotations(map[string]string{TXTKey: "v=spf1 include:_spf.example.org -all\n\n  site-verification=abc\n"}))
+}
+
+func TestSRVRecordsFromAnnotations(t *testing.T) {
+	assert.Empty(t, SRVRecordsFromAnnotations(map[string]string{"foo": "bar"}))
+	assert.Equal(t, map[string][]string{
+		"_sip._udp":  {"10 50 5060 sip.example.org", "20 50 5060 backup.example.org"},
+		"_sips._tcp": {"10 50 5061 sip.example.org"},
+	}, SRVRecordsFromAnnotations(map[string]string{SRVKey: "_sip._udp 10 50 5060 sip.example.org\n" +
+		"_sips._tcp\t10 50 5061 sip.example.org\n" +
+		"_sip._udp 20  50 5060 backup.example.org\n" +
+		"sip._udp 10 50 5060 sip.example.org\n" +
+		"_sip 10 50 5060 sip.example.org\n" +
+		"_sip._udp.example.org 10 50 5060 sip.example.org\n" +
+		"_._udp 10 50 5060 sip.example.org"}))
+}
+
+func TestTargetsOfIPFamilies(t *testing.T) {
+	dualStack := endpoint.Targets{"10.0.0.1", "2001:db8::1", "lb.example.org"}
+	for _, tc := range []struct {
+		value    string
+		targets  endpoint.Targets
+		expected endpoint.Targets
+	}{
+		{value: "", targets: dualStack, expected: dualStack},
+		{value: "ipv4", targets: dualStack, expected: endpoint.Targets{"10.0.0.1", "lb.example.org"}},
+		{value: "IPv6", targets: dualStack, expected: endpoint.Targets{"2001:db8::1", "lb.example.org"}},
+		{value: "ipv4, ipv6", targets: dualStack, expected: dualStack},
+		{value: "ipv6", targets: endpoint.Targets{"10.0.0.1"}, expected: endpoint.Targets{"10.0.0.1"}},
+		{value: "ipv5", targets: dualStack, expected: dualStack},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			assert.Equal(t, tc.expected, TargetsOfIPFamilies(map[string]string{IPFamiliesKey: tc.value}, tc.targets))
+		})
+	}
+	assert.Equal(t, dualStack, TargetsOfIPFamilies(nil, dualStack))
+}
+
+func TestBoolFromAnnotations(t *testing.T) {
+	for _, tc := range []struct {
+		value         string
+		expected      bool
+		expectedError bool
+	}{
+		{value: "true", expected: true},
+		{value: "True", expected: true},
+		{value: "1", expected: true},
+		{value: "false"},
+		{value: "yes", expectedError: true},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			value, err := BoolFromAnnotations(map[string]string{AliasKey: tc.value}, AliasKey)
+			if tc.expectedError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.expected, value)
+		})
+	}
+
+	value, err := BoolFromAnnotations(map[string]string{}, AliasKey)
+	require.NoError(t, err)
+	assert.False(t, value)
+}
+
+func TestForeignController(t *testing.T) {
+	_, foreign := ForeignController(map[string]string{})
+	assert.False(t, foreign)
+	_, foreign = ForeignController(map[string]string{ControllerKey: ControllerValue})
+	assert.False(t, foreign)
+	controller, foreign := ForeignController(map[string]string{ControllerKey: "other"})
+	assert.True(t, foreign)
+	assert.Equal(t, "other", controller)
+}
+
+func TestLabelFromAnnotations(t *testing.T) {
+	_, ok := LabelFromAnnotations(map[string]string{DescriptionKey: "  "}, DescriptionKey)
+	assert.False(t, ok)
+	value, ok := LabelFromAnnotations(map[string]string{DescriptionKey: `owner=team-a,"shop"`}, DescriptionKey)
+	assert.True(t, ok)
+	assert.Equal(t, "owner team-a  shop", value)
+}
+
+func TestProviderSpecificAnnotations(t *testing.T) {
+	providerSpecific, setIdentifier := ProviderSpecificAnnotations(map[string]string{
+		AliasKey:             "True",
+		SetIdentifierKey:     "eu",
+		AWSPrefix + "weight": "10",
+		ProviderSpecificPrefix + "example.com/tier": "gold",
+		WeightKey:        "20",
+		GeolocationKey:   "country:US-CA",
+		LatencyRegionKey: "eu-west-1",
+		FailoverKey:      " secondary",
+	})
+	assert.Equal(t, "eu", setIdentifier)
+	assert.ElementsMatch(t, endpoint.ProviderSpecific{
+		{Name: "alias", Value: "true"},
+		{Name: "aws/weight", Value: "10"},
+		{Name: "example.com/tier", Value: "gold"},
+		{Name: "weight", Value: "20"},
+		{Name: "geolocation", Value: "country:US-CA"},
+		{Name: "latency-region", Value: "eu-west-1"},
+		{Name: "failover", Value: "SECONDARY"},
+	}, providerSpecific)
+}
+
+func TestSRVServicesFromAnnotations(t *testing.T) {
+	services, ok := SRVServicesFromAnnotations(map[string]string{})
+	assert.False(t, ok)
+	assert.Nil(t, services)
+
+	services, ok = SRVServicesFromAnnotations(map[string]string{SRVServiceKey: "_sip, sips=_sips ,5222=xmpp-client,=,"})
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"": "sip", "sips": "sips", "5222": "xmpp-client"}, services)
+}
+
+func TestWithNamespaceDefaults(t *testing.T) {
+	resource := map[string]string{TTLKey: "60", HostnameKey: "app.example.org"}
+	namespace := map[string]string{
+		TTLKey:                 "300",
+		TargetKey:              "lb.example.org",
+		CloudflareProxiedKey:   "true",
+		AWSPrefix + "weight":   "10",
+		HostnameKey:            "other.example.org",
+		SetIdentifierKey:       "eu",
+		"example.com/internal": "true",
+	}
+
+	merged, ok := WithNamespaceDefaults(resource, namespace)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{
+		TTLKey:               "60",
+		HostnameKey:          "app.example.org",
+		TargetKey:            "lb.example.org",
+		CloudflareProxiedKey: "true",
+		AWSPrefix + "weight": "10",
+	}, merged)
+	assert.Equal(t, map[string]string{TTLKey: "60", HostnameKey: "app.example.org"}, resource, "the annotations of the resource are not modified")
+
+	merged, ok = WithNamespaceDefaults(nil, map[string]string{TTLKey: "300"})
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{TTLKey: "300"}, merged)
+
+	_, ok = WithNamespaceDefaults(resource, map[string]string{TTLKey: "300", HostnameKey: "other.example.org"})
+	assert.False(t, ok)
+}
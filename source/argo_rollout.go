@@ -0,0 +1,297 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
+)
+
+var argoRolloutGVR = schema.GroupVersionResource{
+	Group:    "argoproj.io",
+	Version:  "v1alpha1",
+	Resource: "rollouts",
+}
+
+// argoRolloutSource is an implementation of Source for Argo Rollouts. The DNS names of a Rollout point to its
+// active Service of the blue-green strategy or its stable Service of the canary strategy, the preview DNS names
+// to the preview or canary Service. Argo Rollouts switches the selectors of the Services, so the records follow
+// the rollout without changes.
+type argoRolloutSource struct {
+	rolloutInformer  informers.GenericInformer
+	serviceInformer  coreinformers.ServiceInformer
+	namespace        string
+	annotationFilter string
+}
+
+// The parts of the Rollout read by the source, see https://argoproj.github.io/argo-rollouts/features/specification/
+type argoRollout struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              argoRolloutSpec `json:"spec,omitempty"`
+}
+
+type argoRolloutSpec struct {
+	Strategy argoRolloutStrategy `json:"strategy,omitempty"`
+}
+
+type argoRolloutStrategy struct {
+	BlueGreen *argoRolloutBlueGreenStrategy `json:"blueGreen,omitempty"`
+	Canary    *argoRolloutCanaryStrategy    `json:"canary,omitempty"`
+}
+
+type argoRolloutBlueGreenStrategy struct {
+	ActiveService  string `json:"activeService,omitempty"`
+	PreviewService string `json:"previewService,omitempty"`
+}
+
+type argoRolloutCanaryStrategy struct {
+	StableService string `json:"stableService,omitempty"`
+	CanaryService string `json:"canaryService,omitempty"`
+}
+
+// NewArgoRolloutSource creates a new argoRolloutSource with the given config.
+func NewArgoRolloutSource(ctx context.Context, dynamicKubeClient dynamic.Interface, kubeClient kubernetes.Interface, namespace, annotationFilter string) (Source, error) {
+	// Use shared informers to listen for add/update/delete of Rollouts and Services in the specified namespace.
+	// Set resync period to 0, to prevent processing when nothing has changed.
+	dynamicInformerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicKubeClient, 0, namespace, nil)
+	rolloutInformer := dynamicInformerFactory.ForResource(argoRolloutGVR)
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(kubeClient, 0, informers.WithNamespace(namespace))
+	serviceInformer := informerFactory.Core().V1().Services()
+
+	// Add default resource event handlers to properly initialize informers.
+	rolloutInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+			},
+		},
+	)
+	serviceInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+			},
+		},
+	)
+
+	dynamicInformerFactory.Start(ctx.Done())
+	informerFactory.Start(ctx.Done())
+
+	// wait for the local caches to be populated.
+	if err := waitForDynamicCacheSync(context.Background(), dynamicInformerFactory); err != nil {
+		return nil, err
+	}
+	if err := waitForCacheSync(context.Background(), informerFactory); err != nil {
+		return nil, err
+	}
+
+	return &argoRolloutSource{
+		rolloutInformer:  rolloutInformer,
+		serviceInformer:  serviceInformer,
+		namespace:        namespace,
+		annotationFilter: annotationFilter,
+	}, nil
+}
+
+// Endpoints returns endpoint objects for each host-target combination that should be processed.
+// Retrieves all Rollouts in the source's namespace(s).
+func (sc *argoRolloutSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	objects, err := sc.rolloutInformer.Lister().ByNamespace(sc.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var rollouts []*argoRollout
+	for _, obj := range objects {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, errors.New("could not convert")
+		}
+		rollout := &argoRollout{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, rollout); err != nil {
+			return nil, err
+		}
+		rollouts = append(rollouts, rollout)
+	}
+
+	rollouts, err = sc.filterByAnnotations(rollouts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to filter Rollouts")
+	}
+
+	var endpoints []*endpoint.Endpoint
+	for _, rollout := range rollouts {
+		if isExcluded(rollout.Annotations, "rollout", rollout.Namespace, rollout.Name) {
+			continue
+		}
+		rolloutEndpoints, err := sc.endpointsFromRollout(rollout)
+		if err != nil {
+			return nil, err
+		}
+		if len(rolloutEndpoints) == 0 {
+			log.Debugf("No endpoints could be generated from Rollout %s/%s", rollout.Namespace, rollout.Name)
+			continue
+		}
+		log.Debugf("Endpoints generated from Rollout: %s/%s: %v", rollout.Namespace, rollout.Name, rolloutEndpoints)
+		endpoints = append(endpoints, rolloutEndpoints...)
+	}
+
+	for _, ep := range endpoints {
+		sort.Sort(ep.Targets)
+	}
+
+	return endpoints, nil
+}
+
+// endpointsFromRollout extracts the endpoints of the Services of a Rollout.
+func (sc *argoRolloutSource) endpointsFromRollout(rollout *argoRollout) ([]*endpoint.Endpoint, error) {
+	var activeService, previewService, previewPrefix string
+	switch strategy := rollout.Spec.Strategy; {
+	case strategy.BlueGreen != nil:
+		activeService, previewService, previewPrefix = strategy.BlueGreen.ActiveService, strategy.BlueGreen.PreviewService, "preview"
+	case strategy.Canary != nil:
+		activeService, previewService, previewPrefix = strategy.Canary.StableService, strategy.Canary.CanaryService, "canary"
+	}
+	if activeService == "" {
+		log.Debugf("Rollout %s/%s has no active or stable Service", rollout.Namespace, rollout.Name)
+		return nil, nil
+	}
+	if prefix, ok := rollout.Annotations[rolloutPreviewPrefixKey]; ok {
+		previewPrefix = prefix
+	}
+
+	hostnames := annotations.HostnamesFromAnnotations(rollout.Annotations)
+	var previewHostnames []string
+	if value, ok := rollout.Annotations[rolloutPreviewHostnameKey]; ok {
+		previewHostnames = annotations.SplitHostnameAnnotation(value)
+	} else if previewPrefix != "" {
+		for _, hostname := range hostnames {
+			previewHostnames = append(previewHostnames, previewPrefix+"."+strings.TrimPrefix(hostname, "*."))
+		}
+	}
+
+	resource := fmt.Sprintf("rollout/%s/%s", rollout.Namespace, rollout.Name)
+	ttl := annotations.TTLFromAnnotations(rollout.Annotations, resource)
+	providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(rollout.Annotations)
+
+	var endpoints []*endpoint.Endpoint
+	targets, err := sc.serviceTargets(rollout, activeService)
+	if err != nil {
+		return nil, err
+	}
+	for _, hostname := range hostnames {
+		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, resource)...)
+	}
+	if previewService != "" && len(previewHostnames) > 0 {
+		targets, err := sc.serviceTargets(rollout, previewService)
+		if err != nil {
+			return nil, err
+		}
+		for _, hostname := range previewHostnames {
+			endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, resource)...)
+		}
+	}
+	return endpoints, nil
+}
+
+// serviceTargets returns the targets of a Service of a Rollout: the target annotation of the Rollout, the load
+// balancer of the Service or, for Services without load balancer, its cluster IP.
+func (sc *argoRolloutSource) serviceTargets(rollout *argoRollout, name string) (endpoint.Targets, error) {
+	if targets := annotations.TargetsFromTargetAnnotation(rollout.Annotations); len(targets) > 0 {
+		return targets, nil
+	}
+	svc, err := sc.serviceInformer.Lister().Services(rollout.Namespace).Get(name)
+	if k8serrors.IsNotFound(err) {
+		log.Debugf("Service %s/%s of Rollout %s does not exist", rollout.Namespace, name, rollout.Name)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var targets endpoint.Targets
+	for _, lb := range svc.Status.LoadBalancer.Ingress {
+		if lb.IP != "" {
+			targets = append(targets, lb.IP)
+		}
+		if lb.Hostname != "" {
+			targets = append(targets, lb.Hostname)
+		}
+	}
+	if len(targets) == 0 && svc.Spec.Type == corev1.ServiceTypeClusterIP && svc.Spec.ClusterIP != "" && svc.Spec.ClusterIP != corev1.ClusterIPNone {
+		targets = append(targets, svc.Spec.ClusterIP)
+	}
+	return targets, nil
+}
+
+// filterByAnnotations filters a list of Rollouts by a given annotation selector.
+func (sc *argoRolloutSource) filterByAnnotations(rollouts []*argoRollout) ([]*argoRollout, error) {
+	labelSelector, err := metav1.ParseToLabelSelector(sc.annotationFilter)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	// empty filter returns original list
+	if selector.Empty() {
+		return rollouts, nil
+	}
+
+	var filteredList []*argoRollout
+	for _, rollout := range rollouts {
+		// include Rollout if its annotations match the selector
+		if selector.Matches(labels.Set(rollout.Annotations)) {
+			filteredList = append(filteredList, rollout)
+		}
+	}
+	return filteredList, nil
+}
+
+func (sc *argoRolloutSource) resourceVersion() (string, bool) {
+	return informersVersion(sc.rolloutInformer.Informer(), sc.serviceInformer.Informer())
+}
+
+func (sc *argoRolloutSource) AddEventHandler(ctx context.Context, handler func()) {
+	log.Debug("Adding event handler for Rollout")
+
+	// Right now there is no way to remove event handler from informer, see:
+	// https://github.com/kubernetes/kubernetes/issues/79610
+	sc.rolloutInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+	sc.serviceInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakeDynamic "k8s.io/client-go/dynamic/fake"
+	fakeKube "k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// This is a compile-time validation that argoRolloutSource is a Source.
+var _ Source = &argoRolloutSource{}
+
+func newTestArgoRollout(name string, annotations map[string]string, strategy map[string]interface{}) *unstructured.Unstructured {
+	rollout := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": argoRolloutGVR.GroupVersion().String(),
+		"kind":       "Rollout",
+		"spec": map[string]interface{}{
+			"strategy": strategy,
+		},
+	}}
+	rollout.SetName(name)
+	rollout.SetNamespace("default")
+	rollout.SetAnnotations(annotations)
+	return rollout
+}
+
+func TestArgoRolloutEndpoints(t *testing.T) {
+	t.Parallel()
+
+	blueGreen := map[string]interface{}{
+		"blueGreen": map[string]interface{}{"activeService": "active", "previewService": "preview"},
+	}
+	canary := map[string]interface{}{
+		"canary": map[string]interface{}{"stableService": "active", "canaryService": "preview"},
+	}
+	services := []*corev1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "active", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "preview", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, ClusterIP: "10.0.0.1"},
+		},
+	}
+
+	for _, tt := range []struct {
+		title            string
+		annotations      map[string]string
+		strategy         map[string]interface{}
+		annotationFilter string
+		expected         []*endpoint.Endpoint
+	}{
+		{
+			title:       "blue-green rollout publishes the active and preview services",
+			annotations: map[string]string{hostnameAnnotationKey: "app.example.org"},
+			strategy:    blueGreen,
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("app.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+				endpoint.NewEndpoint("preview.app.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+			},
+		},
+		{
+			title:       "canary rollout publishes the stable and canary services",
+			annotations: map[string]string{hostnameAnnotationKey: "app.example.org"},
+			strategy:    canary,
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("app.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+				endpoint.NewEndpoint("canary.app.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+			},
+		},
+		{
+			title: "preview prefix annotation",
+			annotations: map[string]string{
+				hostnameAnnotationKey:   "app.example.org",
+				rolloutPreviewPrefixKey: "next",
+			},
+			strategy: blueGreen,
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("app.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+				endpoint.NewEndpoint("next.app.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+			},
+		},
+		{
+			title: "empty preview prefix disables the preview hostnames",
+			annotations: map[string]string{
+				hostnameAnnotationKey:   "app.example.org",
+				rolloutPreviewPrefixKey: "",
+			},
+			strategy: blueGreen,
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("app.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+			},
+		},
+		{
+			title: "preview hostname annotation",
+			annotations: map[string]string{
+				hostnameAnnotationKey:     "app.example.org",
+				rolloutPreviewHostnameKey: "staging.example.org",
+			},
+			strategy: blueGreen,
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("app.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+				endpoint.NewEndpoint("staging.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+			},
+		},
+		{
+			title: "target annotation",
+			annotations: map[string]string{
+				hostnameAnnotationKey: "app.example.org",
+				targetAnnotationKey:   "lb.example.org",
+			},
+			strategy: canary,
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("app.example.org", endpoint.RecordTypeCNAME, "lb.example.org"),
+				endpoint.NewEndpoint("canary.app.example.org", endpoint.RecordTypeCNAME, "lb.example.org"),
+			},
+		},
+		{
+			title:       "missing preview service",
+			annotations: map[string]string{hostnameAnnotationKey: "app.example.org"},
+			strategy: map[string]interface{}{
+				"blueGreen": map[string]interface{}{"activeService": "active", "previewService": "missing"},
+			},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("app.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+			},
+		},
+		{
+			title:       "rollout without hostname annotation",
+			annotations: map[string]string{},
+			strategy:    blueGreen,
+		},
+		{
+			title: "excluded rollout",
+			annotations: map[string]string{
+				hostnameAnnotationKey: "app.example.org",
+				excludeAnnotationKey:  "true",
+			},
+			strategy: blueGreen,
+		},
+		{
+			title:            "annotation filter",
+			annotations:      map[string]string{hostnameAnnotationKey: "app.example.org"},
+			strategy:         blueGreen,
+			annotationFilter: "team=dns",
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			t.Parallel()
+
+			kubeClient := fakeKube.NewSimpleClientset()
+			for _, svc := range services {
+				_, err := kubeClient.CoreV1().Services(svc.Namespace).Create(context.Background(), svc, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+			dynamicClient := fakeDynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{argoRolloutGVR: "RolloutList"})
+			_, err := dynamicClient.Resource(argoRolloutGVR).Namespace("default").Create(context.Background(),
+				newTestArgoRollout("app", tt.annotations, tt.strategy), metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			source, err := NewArgoRolloutSource(context.Background(), dynamicClient, kubeClient, "default", tt.annotationFilter)
+			require.NoError(t, err)
+
+			endpoints, err := source.Endpoints(context.Background())
+			require.NoError(t, err)
+			for _, ep := range tt.expected {
+				ep.Labels[endpoint.ResourceLabelKey] = "rollout/default/app"
+			}
+			validateEndpoints(t, endpoints, tt.expected)
+		})
+	}
+}
+
+func TestArgoRolloutSourceResourceVersion(t *testing.T) {
+	dynamicClient := fakeDynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{argoRolloutGVR: "RolloutList"})
+	source, err := NewArgoRolloutSource(context.Background(), dynamicClient, fakeKube.NewSimpleClientset(), "default", "")
+	require.NoError(t, err)
+
+	_, ok := source.(*argoRolloutSource).resourceVersion()
+	assert.True(t, ok)
+}
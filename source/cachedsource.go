@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// resourceVersioner is implemented by sources whose endpoints only depend on the objects in their informer caches.
+type resourceVersioner interface {
+	// resourceVersion returns the aggregate resource version of the objects the endpoints are generated from.
+	// It returns false if the version can't be determined, e.g. if the endpoints depend on DNS lookups.
+	resourceVersion() (string, bool)
+}
+
+// cachedSource is a Source that returns the endpoints generated by its wrapped source before, as long as the
+// aggregate resource version of the objects in the informer caches of the wrapped source didn't change.
+type cachedSource struct {
+	source    Source
+	versioner resourceVersioner
+
+	mu        sync.Mutex
+	version   string
+	endpoints []*endpoint.Endpoint
+}
+
+// NewCachedSource creates a new cachedSource wrapping the provided Source. Sources whose endpoints don't only
+// depend on the objects in their informer caches are returned as they are.
+func NewCachedSource(source Source) Source {
+	versioner, ok := source.(resourceVersioner)
+	if !ok {
+		return source
+	}
+	return &cachedSource{source: source, versioner: versioner}
+}
+
+// Endpoints returns copies of the cached endpoints if the objects didn't change, else the endpoints generated by
+// the wrapped source. The endpoints are copied since later stages of a synchronization modify them.
+func (s *cachedSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	version, ok := s.versioner.resourceVersion()
+	if ok && s.endpoints != nil && version == s.version {
+		log.Debugf("Using cached endpoints of %T at resource version %s", s.source, version)
+		return copyEndpoints(s.endpoints), nil
+	}
+
+	endpoints, err := s.source.Endpoints(ctx)
+	if err != nil {
+		s.endpoints = nil
+		return nil, err
+	}
+	if ok {
+		s.version = version
+		s.endpoints = copyEndpoints(endpoints)
+	} else {
+		s.endpoints = nil
+	}
+	return endpoints, nil
+}
+
+func (s *cachedSource) AddEventHandler(ctx context.Context, handler func()) {
+	s.source.AddEventHandler(ctx, handler)
+}
+
+func copyEndpoints(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	copies := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		copies = append(copies, ep.DeepCopy())
+	}
+	return copies
+}
+
+// informersVersion returns the aggregate resource version of the objects in the caches of the informers, which
+// changes whenever an object is added, updated or deleted. It returns false if an object has no resource version.
+func informersVersion(informers ...cache.SharedIndexInformer) (string, bool) {
+	var sum uint64
+	count := 0
+	for _, informer := range informers {
+		for _, obj := range informer.GetStore().List() {
+			m, err := meta.Accessor(obj)
+			if err != nil || m.GetResourceVersion() == "" {
+				return "", false
+			}
+			// objects are listed in random order, so the hashes of the objects are summed up
+			h := fnv.New64a()
+			_, _ = h.Write([]byte(m.GetNamespace() + "/" + m.GetName() + "/" + string(m.GetUID()) + "/" + m.GetResourceVersion()))
+			sum += h.Sum64()
+			count++
+		}
+	}
+	return fmt.Sprintf("%d-%x", count, sum), true
+}
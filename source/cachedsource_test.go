@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+// versionedSource is a mock source whose endpoints are generated from objects at the given resource version.
+type versionedSource struct {
+	testutils.MockSource
+	version string
+	ok      bool
+}
+
+func (s *versionedSource) resourceVersion() (string, bool) {
+	return s.version, s.ok
+}
+
+func TestCachedSource(t *testing.T) {
+	foo := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	src := &versionedSource{version: "1", ok: true}
+	src.On("Endpoints").Return([]*endpoint.Endpoint{foo}, nil).Twice()
+
+	cached := NewCachedSource(src)
+
+	endpoints, err := cached.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{foo})
+
+	// the endpoints are modified by later stages of the synchronization
+	endpoints[0].Targets = endpoint.Targets{"5.6.7.8"}
+
+	endpoints, err = cached.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")})
+
+	src.version = "2"
+	_, err = cached.Endpoints(context.Background())
+	require.NoError(t, err)
+
+	// without a version the endpoints are generated every time
+	src.ok = false
+	src.On("Endpoints").Return([]*endpoint.Endpoint{foo}, nil).Twice()
+	_, err = cached.Endpoints(context.Background())
+	require.NoError(t, err)
+	_, err = cached.Endpoints(context.Background())
+	require.NoError(t, err)
+
+	src.AssertNumberOfCalls(t, "Endpoints", 4)
+}
+
+func TestNewCachedSourceWithoutVersion(t *testing.T) {
+	src := new(testutils.MockSource)
+	assert.Same(t, src, NewCachedSource(src))
+}
+
+func TestInformersVersion(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1", ResourceVersion: "1"},
+	})
+	src, err := NewNodeSource(ctx, client, "", "", labels.Everything(), false, nil)
+	require.NoError(t, err)
+	versioner := src.(resourceVersioner)
+
+	initial, ok := versioner.resourceVersion()
+	require.True(t, ok)
+
+	_, err = client.CoreV1().Nodes().Update(ctx, &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1", ResourceVersion: "2"},
+	}, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		version, ok := versioner.resourceVersion()
+		return ok && version != initial
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err = client.CoreV1().Nodes().Create(ctx, &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node2"},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		_, ok := versioner.resourceVersion()
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+}
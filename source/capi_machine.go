@@ -0,0 +1,272 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"text/template"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
+)
+
+var capiMachineGVR = schema.GroupVersionResource{
+	Group:    "cluster.x-k8s.io",
+	Version:  "v1beta1",
+	Resource: "machines",
+}
+
+// capiMachineSource is an implementation of Source for Cluster API Machines. It publishes the addresses the
+// infrastructure provider reports for a Machine, which are known before the kubelet registers the Node.
+type capiMachineSource struct {
+	machineInformer  informers.GenericInformer
+	namespace        string
+	annotationFilter string
+	fqdnTemplate     *template.Template
+	labelSelector    labels.Selector
+}
+
+// The parts of the Machine read by the source, see https://cluster-api.sigs.k8s.io/developer/providers/contracts/infra-machine
+type capiMachine struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              capiMachineSpec   `json:"spec,omitempty"`
+	Status            capiMachineStatus `json:"status,omitempty"`
+}
+
+type capiMachineSpec struct {
+	ClusterName string `json:"clusterName"`
+	ProviderID  string `json:"providerID,omitempty"`
+}
+
+type capiMachineStatus struct {
+	Addresses []capiMachineAddress `json:"addresses,omitempty"`
+}
+
+type capiMachineAddress struct {
+	Type    string `json:"type"`
+	Address string `json:"address"`
+}
+
+const (
+	capiMachineExternalIP = "ExternalIP"
+	capiMachineInternalIP = "InternalIP"
+)
+
+// DeepCopyObject makes the Machine a runtime.Object, as required by the FQDN template.
+func (in *capiMachine) DeepCopyObject() runtime.Object {
+	out := *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Status.Addresses = slices.Clone(in.Status.Addresses)
+	return &out
+}
+
+// NewCAPIMachineSource creates a new capiMachineSource with the given config.
+func NewCAPIMachineSource(ctx context.Context, dynamicKubeClient dynamic.Interface, namespace, annotationFilter, fqdnTemplate string, labelSelector labels.Selector) (Source, error) {
+	tmpl, err := parseTemplate(fqdnTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use shared informer to listen for add/update/delete of Machines in the specified namespace.
+	// Set resync period to 0, to prevent processing when nothing has changed.
+	informerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicKubeClient, 0, namespace, nil)
+	machineInformer := informerFactory.ForResource(capiMachineGVR)
+
+	// Add default resource event handlers to properly initialize informer.
+	machineInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+			},
+		},
+	)
+
+	informerFactory.Start(ctx.Done())
+
+	// wait for the local cache to be populated.
+	if err := waitForDynamicCacheSync(context.Background(), informerFactory); err != nil {
+		return nil, err
+	}
+
+	return &capiMachineSource{
+		machineInformer:  machineInformer,
+		namespace:        namespace,
+		annotationFilter: annotationFilter,
+		fqdnTemplate:     tmpl,
+		labelSelector:    labelSelector,
+	}, nil
+}
+
+// Endpoints returns an A and an AAAA endpoint for each Machine with addresses.
+func (sc *capiMachineSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	objects, err := sc.machineInformer.Lister().ByNamespace(sc.namespace).List(sc.labelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	var machines []*capiMachine
+	for _, obj := range objects {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, errors.New("could not convert")
+		}
+		machine := &capiMachine{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, machine); err != nil {
+			return nil, err
+		}
+		machines = append(machines, machine)
+	}
+
+	machines, err = sc.filterByAnnotations(machines)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to filter Machines")
+	}
+
+	var endpoints []*endpoint.Endpoint
+	for _, machine := range machines {
+		if isExcluded(machine.Annotations, "machine", machine.Namespace, machine.Name) {
+			continue
+		}
+
+		// Check controller annotation to see if we are responsible.
+		if controller, foreign := annotations.ForeignController(machine.Annotations); foreign {
+			log.Debugf("Skipping Machine %s/%s because controller value does not match, found: %s, required: %s",
+				machine.Namespace, machine.Name, controller, controllerAnnotationValue)
+			continue
+		}
+
+		if machine.DeletionTimestamp != nil {
+			log.Debugf("Skipping Machine %s/%s because it is being deleted", machine.Namespace, machine.Name)
+			continue
+		}
+
+		machineEndpoints, err := sc.endpointsFromMachine(machine)
+		if err != nil {
+			return nil, err
+		}
+		if len(machineEndpoints) == 0 {
+			log.Debugf("No endpoints could be generated from Machine %s/%s", machine.Namespace, machine.Name)
+			continue
+		}
+		log.Debugf("Endpoints generated from Machine: %s/%s: %v", machine.Namespace, machine.Name, machineEndpoints)
+		endpoints = append(endpoints, machineEndpoints...)
+	}
+
+	for _, ep := range endpoints {
+		sort.Sort(ep.Targets)
+	}
+
+	return endpoints, nil
+}
+
+func (sc *capiMachineSource) endpointsFromMachine(machine *capiMachine) ([]*endpoint.Endpoint, error) {
+	hostname := machine.Name
+	if sc.fqdnTemplate != nil {
+		hostnames, err := execTemplate(sc.fqdnTemplate, machine)
+		if err != nil {
+			return nil, err
+		}
+		if len(hostnames) == 0 || hostnames[0] == "" {
+			return nil, nil
+		}
+		hostname = hostnames[0]
+	}
+
+	targets := annotations.TargetsFromTargetAnnotation(machine.Annotations)
+	if len(targets) == 0 {
+		targets = capiMachineAddresses(machine)
+	}
+
+	resource := fmt.Sprintf("machine/%s/%s", machine.Namespace, machine.Name)
+	ttl := annotations.TTLFromAnnotations(machine.Annotations, resource)
+	providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(machine.Annotations)
+	return endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, resource), nil
+}
+
+// capiMachineAddresses returns the external IPs of the Machine and, if there are none, its internal IPs.
+// Like for Nodes, internal IPv6 addresses are published together with the external IPs.
+func capiMachineAddresses(machine *capiMachine) endpoint.Targets {
+	var external, internal, ipv6 endpoint.Targets
+	for _, addr := range machine.Status.Addresses {
+		switch addr.Type {
+		case capiMachineExternalIP:
+			external = append(external, addr.Address)
+		case capiMachineInternalIP:
+			internal = append(internal, addr.Address)
+			if suitableType(addr.Address) == endpoint.RecordTypeAAAA {
+				ipv6 = append(ipv6, addr.Address)
+			}
+		}
+	}
+	if len(external) > 0 {
+		return append(external, ipv6...)
+	}
+	return internal
+}
+
+// filterByAnnotations filters a list of Machines by a given annotation selector.
+func (sc *capiMachineSource) filterByAnnotations(machines []*capiMachine) ([]*capiMachine, error) {
+	labelSelector, err := metav1.ParseToLabelSelector(sc.annotationFilter)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	// empty filter returns original list
+	if selector.Empty() {
+		return machines, nil
+	}
+
+	var filteredList []*capiMachine
+	for _, machine := range machines {
+		// include Machine if its annotations match the selector
+		if selector.Matches(labels.Set(machine.Annotations)) {
+			filteredList = append(filteredList, machine)
+		}
+	}
+	return filteredList, nil
+}
+
+func (sc *capiMachineSource) resourceVersion() (string, bool) {
+	return informersVersion(sc.machineInformer.Informer())
+}
+
+func (sc *capiMachineSource) AddEventHandler(ctx context.Context, handler func()) {
+	log.Debug("Adding event handler for Machine")
+
+	// Right now there is no way to remove event handler from informer, see:
+	// https://github.com/kubernetes/kubernetes/issues/79610
+	sc.machineInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+}
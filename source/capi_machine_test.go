@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakeDynamic "k8s.io/client-go/dynamic/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// This is a compile-time validation that capiMachineSource is a Source.
+var _ Source = &capiMachineSource{}
+
+func newTestCAPIMachine(name string, machineLabels, annotations map[string]string, addresses ...capiMachineAddress) *unstructured.Unstructured {
+	var addrs []interface{}
+	for _, addr := range addresses {
+		addrs = append(addrs, map[string]interface{}{"type": addr.Type, "address": addr.Address})
+	}
+	machine := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": capiMachineGVR.GroupVersion().String(),
+		"kind":       "Machine",
+		"spec": map[string]interface{}{
+			"clusterName": "prod",
+		},
+		"status": map[string]interface{}{
+			"addresses": addrs,
+		},
+	}}
+	machine.SetName(name)
+	machine.SetNamespace("default")
+	machine.SetLabels(machineLabels)
+	machine.SetAnnotations(annotations)
+	return machine
+}
+
+func TestCAPIMachineEndpoints(t *testing.T) {
+	t.Parallel()
+
+	external := capiMachineAddress{Type: capiMachineExternalIP, Address: "1.2.3.4"}
+	internal := capiMachineAddress{Type: capiMachineInternalIP, Address: "10.0.0.1"}
+	internalIPv6 := capiMachineAddress{Type: capiMachineInternalIP, Address: "2001:db8::1"}
+	hostname := capiMachineAddress{Type: "InternalDNS", Address: "ip-10-0-0-1.ec2.internal"}
+
+	for _, tt := range []struct {
+		title         string
+		machine       *unstructured.Unstructured
+		fqdnTemplate  string
+		labelSelector labels.Selector
+		expected      []*endpoint.Endpoint
+	}{
+		{
+			title:        "external and internal IPv6 addresses",
+			machine:      newTestCAPIMachine("cp-0", nil, nil, external, internal, internalIPv6, hostname),
+			fqdnTemplate: "{{.Name}}.{{.Spec.ClusterName}}.example.org",
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("cp-0.prod.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+				endpoint.NewEndpoint("cp-0.prod.example.org", endpoint.RecordTypeAAAA, "2001:db8::1"),
+			},
+		},
+		{
+			title:        "internal addresses without external address",
+			machine:      newTestCAPIMachine("cp-0", nil, nil, internal, hostname),
+			fqdnTemplate: "{{.Name}}.{{.Spec.ClusterName}}.example.org",
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("cp-0.prod.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+			},
+		},
+		{
+			title:   "machine name without template",
+			machine: newTestCAPIMachine("cp-0.example.org", nil, nil, external),
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("cp-0.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+			},
+		},
+		{
+			title:        "target annotation",
+			machine:      newTestCAPIMachine("cp-0", nil, map[string]string{targetAnnotationKey: "5.6.7.8"}, external),
+			fqdnTemplate: "{{.Name}}.example.org",
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("cp-0.example.org", endpoint.RecordTypeA, "5.6.7.8"),
+			},
+		},
+		{
+			title:        "machine without addresses",
+			machine:      newTestCAPIMachine("cp-0", nil, nil),
+			fqdnTemplate: "{{.Name}}.example.org",
+		},
+		{
+			title:        "template rendering an empty name",
+			machine:      newTestCAPIMachine("cp-0", nil, nil, external),
+			fqdnTemplate: `{{if eq .Name "other"}}{{.Name}}.example.org{{end}}`,
+		},
+		{
+			title:        "excluded machine",
+			machine:      newTestCAPIMachine("cp-0", nil, map[string]string{excludeAnnotationKey: "true"}, external),
+			fqdnTemplate: "{{.Name}}.example.org",
+		},
+		{
+			title:         "label filter",
+			machine:       newTestCAPIMachine("cp-0", map[string]string{"role": "worker"}, nil, external),
+			fqdnTemplate:  "{{.Name}}.example.org",
+			labelSelector: labels.SelectorFromSet(labels.Set{"role": "control-plane"}),
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			t.Parallel()
+
+			dynamicClient := fakeDynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{capiMachineGVR: "MachineList"})
+			_, err := dynamicClient.Resource(capiMachineGVR).Namespace("default").Create(context.Background(), tt.machine, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			labelSelector := tt.labelSelector
+			if labelSelector == nil {
+				labelSelector = labels.Everything()
+			}
+			source, err := NewCAPIMachineSource(context.Background(), dynamicClient, "", "", tt.fqdnTemplate, labelSelector)
+			require.NoError(t, err)
+
+			endpoints, err := source.Endpoints(context.Background())
+			require.NoError(t, err)
+			for _, ep := range tt.expected {
+				ep.Labels[endpoint.ResourceLabelKey] = "machine/default/" + tt.machine.GetName()
+			}
+			validateEndpoints(t, endpoints, tt.expected)
+		})
+	}
+}
@@ -17,12 +17,11 @@ limitations under the License.
 package source
 
 import (
-	"strings"
-
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
 )
 
 const (
@@ -89,7 +88,7 @@ func legacyEndpointsFromMoleculeService(svc *v1.Service) []*endpoint.Endpoint {
 		return nil
 	}
 
-	hostnameList := strings.Split(strings.Replace(hostnameAnnotation, " ", "", -1), ",")
+	hostnameList := annotations.SplitHostnameAnnotation(hostnameAnnotation)
 
 	for _, hostname := range hostnameList {
 		// Create a corresponding endpoint for each configured external entrypoint.
@@ -145,9 +144,9 @@ func legacyEndpointsFromDNSControllerNodePortService(svc *v1.Service, sc *servic
 
 	var hostnameList []string
 	if isExternal {
-		hostnameList = strings.Split(strings.Replace(hostnameAnnotation, " ", "", -1), ",")
+		hostnameList = annotations.SplitHostnameAnnotation(hostnameAnnotation)
 	} else {
-		hostnameList = strings.Split(strings.Replace(internalHostnameAnnotation, " ", "", -1), ",")
+		hostnameList = annotations.SplitHostnameAnnotation(internalHostnameAnnotation)
 	}
 
 	for _, hostname := range hostnameList {
@@ -186,10 +185,10 @@ func legacyEndpointsFromDNSControllerLoadBalancerService(svc *v1.Service) []*end
 
 	var hostnameList []string
 	if hasExternal {
-		hostnameList = append(hostnameList, strings.Split(strings.Replace(hostnameAnnotation, " ", "", -1), ",")...)
+		hostnameList = append(hostnameList, annotations.SplitHostnameAnnotation(hostnameAnnotation)...)
 	}
 	if hasInternal {
-		hostnameList = append(hostnameList, strings.Split(strings.Replace(internalHostnameAnnotation, " ", "", -1), ",")...)
+		hostnameList = append(hostnameList, annotations.SplitHostnameAnnotation(internalHostnameAnnotation)...)
 	}
 
 	for _, hostname := range hostnameList {
@@ -34,6 +34,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
 )
 
 // HTTPProxySource is an implementation of Source for ProjectContour HTTPProxy objects.
@@ -126,6 +127,9 @@ func (sc *httpProxySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint,
 		httpProxies = append(httpProxies, hpConverted)
 	}
 
+	// the delegation is resolved before filtering, the included proxies don't need to match the filter
+	delegates, included := resolveHTTPProxyIncludes(httpProxies)
+
 	httpProxies, err = sc.filterByAnnotations(httpProxies)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to filter HTTPProxies")
@@ -134,15 +138,23 @@ func (sc *httpProxySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint,
 	endpoints := []*endpoint.Endpoint{}
 
 	for _, hp := range httpProxies {
+		if hp.Spec.VirtualHost == nil && included[httpProxyKey(hp.Namespace, hp.Name)] {
+			log.Debugf("Skipping HTTPProxy %s/%s because it is included by a root HTTPProxy", hp.Namespace, hp.Name)
+			continue
+		}
+
+		if isExcluded(hp.Annotations, "httpproxy", hp.Namespace, hp.Name) {
+			continue
+		}
+
 		// Check controller annotation to see if we are responsible.
-		controller, ok := hp.Annotations[controllerAnnotationKey]
-		if ok && controller != controllerAnnotationValue {
+		if controller, foreign := annotations.ForeignController(hp.Annotations); foreign {
 			log.Debugf("Skipping HTTPProxy %s/%s because controller value does not match, found: %s, required: %s",
 				hp.Namespace, hp.Name, controller, controllerAnnotationValue)
 			continue
 		}
 
-		hpEndpoints, err := sc.endpointsFromHTTPProxy(hp)
+		hpEndpoints, err := sc.endpointsFromHTTPProxy(hp, delegates[httpProxyKey(hp.Namespace, hp.Name)]...)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to get endpoints from HTTPProxy")
 		}
@@ -185,9 +197,9 @@ func (sc *httpProxySource) endpointsFromTemplate(httpProxy *projectcontour.HTTPP
 
 	resource := fmt.Sprintf("HTTPProxy/%s/%s", httpProxy.Namespace, httpProxy.Name)
 
-	ttl := getTTLFromAnnotations(httpProxy.Annotations, resource)
+	ttl := annotations.TTLFromAnnotations(httpProxy.Annotations, resource)
 
-	targets := getTargetsFromTargetAnnotation(httpProxy.Annotations)
+	targets := annotations.TargetsFromTargetAnnotation(httpProxy.Annotations)
 	if len(targets) == 0 {
 		for _, lb := range httpProxy.Status.LoadBalancer.Ingress {
 			if lb.IP != "" {
@@ -199,7 +211,7 @@ func (sc *httpProxySource) endpointsFromTemplate(httpProxy *projectcontour.HTTPP
 		}
 	}
 
-	providerSpecific, setIdentifier := getProviderSpecificAnnotations(httpProxy.Annotations)
+	providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(httpProxy.Annotations)
 
 	var endpoints []*endpoint.Endpoint
 	for _, hostname := range hostnames {
@@ -239,13 +251,15 @@ func (sc *httpProxySource) filterByAnnotations(httpProxies []*projectcontour.HTT
 	return filteredList, nil
 }
 
-// endpointsFromHTTPProxyConfig extracts the endpoints from a Contour HTTPProxy object
-func (sc *httpProxySource) endpointsFromHTTPProxy(httpProxy *projectcontour.HTTPProxy) ([]*endpoint.Endpoint, error) {
+// endpointsFromHTTPProxyConfig extracts the endpoints from a Contour HTTPProxy object. The hostname annotations of
+// the delegates, the proxies included by the root proxy directly or transitively, are published with the targets of
+// the root proxy, which owns all records of the tree. Each hostname is published once.
+func (sc *httpProxySource) endpointsFromHTTPProxy(httpProxy *projectcontour.HTTPProxy, delegates ...*projectcontour.HTTPProxy) ([]*endpoint.Endpoint, error) {
 	resource := fmt.Sprintf("HTTPProxy/%s/%s", httpProxy.Namespace, httpProxy.Name)
 
-	ttl := getTTLFromAnnotations(httpProxy.Annotations, resource)
+	ttl := annotations.TTLFromAnnotations(httpProxy.Annotations, resource)
 
-	targets := getTargetsFromTargetAnnotation(httpProxy.Annotations)
+	targets := annotations.TargetsFromTargetAnnotation(httpProxy.Annotations)
 
 	if len(targets) == 0 {
 		for _, lb := range httpProxy.Status.LoadBalancer.Ingress {
@@ -258,27 +272,87 @@ func (sc *httpProxySource) endpointsFromHTTPProxy(httpProxy *projectcontour.HTTP
 		}
 	}
 
-	providerSpecific, setIdentifier := getProviderSpecificAnnotations(httpProxy.Annotations)
-
-	var endpoints []*endpoint.Endpoint
+	providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(httpProxy.Annotations)
 
+	var hostnames []string
 	if virtualHost := httpProxy.Spec.VirtualHost; virtualHost != nil {
 		if fqdn := virtualHost.Fqdn; fqdn != "" {
-			endpoints = append(endpoints, endpointsForHostname(fqdn, targets, ttl, providerSpecific, setIdentifier, resource)...)
+			hostnames = append(hostnames, fqdn)
 		}
 	}
 
 	// Skip endpoints if we do not want entries from annotations
 	if !sc.ignoreHostnameAnnotation {
-		hostnameList := getHostnamesFromAnnotations(httpProxy.Annotations)
-		for _, hostname := range hostnameList {
-			endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, resource)...)
+		hostnames = append(hostnames, annotations.HostnamesFromAnnotations(httpProxy.Annotations)...)
+		for _, delegate := range delegates {
+			hostnames = append(hostnames, annotations.HostnamesFromAnnotations(delegate.Annotations)...)
+		}
+	}
+
+	var endpoints []*endpoint.Endpoint
+	seen := map[string]bool{}
+	for _, hostname := range hostnames {
+		if seen[hostname] {
+			continue
 		}
+		seen[hostname] = true
+		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, resource)...)
 	}
 
 	return endpoints, nil
 }
 
+func httpProxyKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// resolveHTTPProxyIncludes walks the includes of the root proxies, the proxies with a virtual host. It returns the
+// delegates of each root proxy by its key and the keys of all included proxies. Like Contour, it ignores includes
+// of other root proxies, missing proxies and cycles. The delegates which are excluded or belong to another
+// controller are skipped along with the proxies they include.
+func resolveHTTPProxyIncludes(httpProxies []*projectcontour.HTTPProxy) (map[string][]*projectcontour.HTTPProxy, map[string]bool) {
+	proxies := make(map[string]*projectcontour.HTTPProxy, len(httpProxies))
+	for _, hp := range httpProxies {
+		proxies[httpProxyKey(hp.Namespace, hp.Name)] = hp
+	}
+
+	delegates := map[string][]*projectcontour.HTTPProxy{}
+	included := map[string]bool{}
+	for _, root := range httpProxies {
+		if root.Spec.VirtualHost == nil {
+			continue
+		}
+		rootKey := httpProxyKey(root.Namespace, root.Name)
+		visited := map[string]bool{rootKey: true}
+		var walk func(hp *projectcontour.HTTPProxy)
+		walk = func(hp *projectcontour.HTTPProxy) {
+			for _, include := range hp.Spec.Includes {
+				namespace := include.Namespace
+				if namespace == "" {
+					namespace = hp.Namespace
+				}
+				key := httpProxyKey(namespace, include.Name)
+				child, ok := proxies[key]
+				if !ok || visited[key] || child.Spec.VirtualHost != nil {
+					continue
+				}
+				visited[key] = true
+				included[key] = true
+				if excluded, _ := annotations.BoolFromAnnotations(child.Annotations, excludeAnnotationKey); excluded {
+					continue
+				}
+				if _, foreign := annotations.ForeignController(child.Annotations); foreign {
+					continue
+				}
+				delegates[rootKey] = append(delegates[rootKey], child)
+				walk(child)
+			}
+		}
+		walk(root)
+	}
+	return delegates, included
+}
+
 func (sc *httpProxySource) AddEventHandler(ctx context.Context, handler func()) {
 	log.Debug("Adding event handler for httpproxy")
 
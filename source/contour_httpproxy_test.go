@@ -1033,6 +1033,114 @@ func testHTTPProxyEndpoints(t *testing.T) {
 			},
 			ignoreHostnameAnnotation: true,
 		},
+		{
+			title: "hostname annotations of included httpproxys across namespaces are published by the root",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			httpProxyItems: []fakeHTTPProxy{
+				{
+					name:      "root",
+					namespace: "root",
+					host:      "example.org",
+					includes:  []projectcontour.Include{{Name: "blog", Namespace: "marketing"}},
+				},
+				{
+					name:        "blog",
+					namespace:   "marketing",
+					delegate:    true,
+					annotations: map[string]string{hostnameAnnotationKey: "blog.example.org"},
+					includes:    []projectcontour.Include{{Name: "comments"}},
+				},
+				{
+					name:        "comments",
+					namespace:   "marketing",
+					delegate:    true,
+					annotations: map[string]string{hostnameAnnotationKey: "comments.example.org,blog.example.org"},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"8.8.8.8"},
+					Labels:     endpoint.Labels{endpoint.ResourceLabelKey: "HTTPProxy/root/root"},
+				},
+				{
+					DNSName:    "blog.example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"8.8.8.8"},
+					Labels:     endpoint.Labels{endpoint.ResourceLabelKey: "HTTPProxy/root/root"},
+				},
+				{
+					DNSName:    "comments.example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"8.8.8.8"},
+					Labels:     endpoint.Labels{endpoint.ResourceLabelKey: "HTTPProxy/root/root"},
+				},
+			},
+		},
+		{
+			title: "include cycles, excluded delegates and included roots are ignored",
+			loadBalancer: fakeLoadBalancerService{
+				ips: []string{"8.8.8.8"},
+			},
+			httpProxyItems: []fakeHTTPProxy{
+				{
+					name:      "root",
+					namespace: namespace,
+					host:      "example.org",
+					includes:  []projectcontour.Include{{Name: "a"}, {Name: "excluded"}, {Name: "other-root"}, {Name: "missing"}},
+				},
+				{
+					name:        "a",
+					namespace:   namespace,
+					delegate:    true,
+					annotations: map[string]string{hostnameAnnotationKey: "a.example.org"},
+					includes:    []projectcontour.Include{{Name: "b"}},
+				},
+				{
+					name:        "b",
+					namespace:   namespace,
+					delegate:    true,
+					annotations: map[string]string{hostnameAnnotationKey: "b.example.org"},
+					includes:    []projectcontour.Include{{Name: "a"}, {Name: "root"}},
+				},
+				{
+					name:        "excluded",
+					namespace:   namespace,
+					delegate:    true,
+					annotations: map[string]string{hostnameAnnotationKey: "excluded.example.org", excludeAnnotationKey: "true"},
+				},
+				{
+					name:      "other-root",
+					namespace: namespace,
+					host:      "new.org",
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"8.8.8.8"},
+				},
+				{
+					DNSName:    "a.example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"8.8.8.8"},
+				},
+				{
+					DNSName:    "b.example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"8.8.8.8"},
+				},
+				{
+					DNSName:    "new.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"8.8.8.8"},
+				},
+			},
+		},
 	} {
 		ti := ti
 		t.Run(ti.title, func(t *testing.T) {
@@ -1107,6 +1215,7 @@ type fakeHTTPProxy struct {
 
 	host         string
 	delegate     bool
+	includes     []projectcontour.Include
 	loadBalancer fakeLoadBalancerService
 }
 
@@ -1121,6 +1230,7 @@ func (ir fakeHTTPProxy) HTTPProxy() *projectcontour.HTTPProxy {
 			},
 		}
 	}
+	spec.Includes = ir.includes
 
 	lb := v1.LoadBalancerStatus{
 		Ingress: []v1.LoadBalancerIngress{},
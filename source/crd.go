@@ -19,8 +19,11 @@ package source
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
@@ -48,7 +51,8 @@ type crdSource struct {
 	codec            runtime.ParameterCodec
 	annotationFilter string
 	labelSelector    labels.Selector
-	informer         *cache.SharedInformer
+	informer         cache.SharedIndexInformer
+	now              func() time.Time
 }
 
 func addKnownTypes(scheme *runtime.Scheme, groupVersion schema.GroupVersion) error {
@@ -107,7 +111,9 @@ func NewCRDClientForAPIVersionKind(client kubernetes.Interface, kubeConfig, apiS
 	return crdClient, scheme, nil
 }
 
-// NewCRDSource creates a new crdSource with the given config.
+// NewCRDSource creates a new crdSource with the given config. With startInformer, the DNSEndpoints matching the
+// namespace and the label selector are watched by a shared informer, so the source reads them from its cache
+// instead of listing them from the API server on every synchronization.
 func NewCRDSource(crdClient rest.Interface, namespace, kind string, annotationFilter string, labelSelector labels.Selector, scheme *runtime.Scheme, startInformer bool) (Source, error) {
 	sourceCrd := crdSource{
 		crdResource:      strings.ToLower(kind) + "s",
@@ -116,47 +122,99 @@ func NewCRDSource(crdClient rest.Interface, namespace, kind string, annotationFi
 		labelSelector:    labelSelector,
 		crdClient:        crdClient,
 		codec:            runtime.NewParameterCodec(scheme),
+		now:              time.Now,
 	}
 	if startInformer {
 		// external-dns already runs its sync-handler periodically (controlled by `--interval` flag) to ensure any
 		// missed or dropped events are handled.  specify a resync period 0 to avoid unnecessary sync handler invocations.
-		informer := cache.NewSharedInformer(
-			&cache.ListWatch{
-				ListFunc: func(lo metav1.ListOptions) (result runtime.Object, err error) {
-					return sourceCrd.List(context.TODO(), &lo)
-				},
-				WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
-					return sourceCrd.watch(context.TODO(), &lo)
-				},
-			},
-			&endpoint.DNSEndpoint{},
-			0)
-		sourceCrd.informer = &informer
+		informer := cache.NewSharedIndexInformer(sourceCrd.listWatch(), &endpoint.DNSEndpoint{}, 0, cache.Indexers{})
+		sourceCrd.informer = informer
 		go informer.Run(wait.NeverStop)
+
+		// wait for the local cache to be populated.
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+		if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+			return nil, fmt.Errorf("failed to sync %s: %v", sourceCrd.crdResource, ctx.Err())
+		}
 	}
 	return &sourceCrd, nil
 }
 
+// listWatch lists and watches the DNSEndpoints in the namespace of the source which match its label selector.
+func (cs *crdSource) listWatch() *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
+			lo.LabelSelector = cs.labelSelector.String()
+			return cs.List(context.TODO(), &lo)
+		},
+		WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
+			lo.LabelSelector = cs.labelSelector.String()
+			return cs.watch(context.TODO(), &lo)
+		},
+	}
+}
+
 func (cs *crdSource) AddEventHandler(ctx context.Context, handler func()) {
 	if cs.informer != nil {
 		log.Debug("Adding event handler for CRD")
 		// Right now there is no way to remove event handler from informer, see:
 		// https://github.com/kubernetes/kubernetes/issues/79610
-		informer := *cs.informer
-		informer.AddEventHandler(
-			cache.ResourceEventHandlerFuncs{
-				AddFunc: func(obj interface{}) {
-					handler()
-				},
-				UpdateFunc: func(old interface{}, new interface{}) {
-					handler()
-				},
-				DeleteFunc: func(obj interface{}) {
-					handler()
-				},
-			},
-		)
+		cs.informer.AddEventHandler(eventHandlerFunc(handler))
+	}
+}
+
+// resourceVersion returns the version of the DNSEndpoints along with their active schedules, whose windows
+// change the endpoints without changing the DNSEndpoints.
+func (cs *crdSource) resourceVersion() (string, bool) {
+	if cs.informer == nil {
+		return "", false
+	}
+	version, ok := informersVersion(cs.informer)
+	if !ok {
+		return "", false
+	}
+
+	now := cs.now()
+	var active []string
+	for _, obj := range cs.informer.GetStore().List() {
+		dnsEndpoint, ok := obj.(*endpoint.DNSEndpoint)
+		if !ok {
+			return "", false
+		}
+		for i, schedule := range dnsEndpoint.Spec.Schedules {
+			if schedule == nil {
+				continue
+			}
+			if on, err := scheduleActive(schedule, now); err == nil && on {
+				active = append(active, fmt.Sprintf("%s/%s/%d", dnsEndpoint.Namespace, dnsEndpoint.Name, i))
+			}
+		}
+	}
+	if len(active) == 0 {
+		return version, true
 	}
+	sort.Strings(active)
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(strings.Join(active, ",")))
+	return fmt.Sprintf("%s-%x", version, h.Sum64()), true
+}
+
+// dnsEndpoints returns the DNSEndpoints from the cache of the informer, or lists them if there is no informer.
+// The cached objects are copied since the endpoints are modified.
+func (cs *crdSource) dnsEndpoints(ctx context.Context) (*endpoint.DNSEndpointList, error) {
+	if cs.informer == nil {
+		return cs.List(ctx, &metav1.ListOptions{LabelSelector: cs.labelSelector.String()})
+	}
+	result := &endpoint.DNSEndpointList{}
+	for _, obj := range cs.informer.GetStore().List() {
+		dnsEndpoint, ok := obj.(*endpoint.DNSEndpoint)
+		if !ok {
+			return nil, fmt.Errorf("could not convert %T to DNSEndpoint", obj)
+		}
+		result.Items = append(result.Items, *dnsEndpoint.DeepCopy())
+	}
+	return result, nil
 }
 
 // Endpoints returns endpoint objects.
@@ -168,7 +226,7 @@ func (cs *crdSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error
 		err    error
 	)
 
-	result, err = cs.List(ctx, &metav1.ListOptions{LabelSelector: cs.labelSelector.String()})
+	result, err = cs.dnsEndpoints(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -180,6 +238,12 @@ func (cs *crdSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error
 	}
 
 	for _, dnsEndpoint := range result.Items {
+		if isExcluded(dnsEndpoint.Annotations, "dnsendpoint", dnsEndpoint.Namespace, dnsEndpoint.Name) {
+			continue
+		}
+
+		applyTargetSchedules(&dnsEndpoint, dnsEndpoint.Spec.Endpoints, cs.now())
+
 		// Make sure that all endpoints have targets for A or CNAME type
 		crdEndpoints := []*endpoint.Endpoint{}
 		for _, ep := range dnsEndpoint.Spec.Endpoints {
@@ -212,6 +276,8 @@ func (cs *crdSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error
 		}
 
 		cs.setResourceLabel(&dnsEndpoint, crdEndpoints)
+		setHandoffLabel(dnsEndpoint.Annotations, crdEndpoints)
+		setDependsOnLabel(dnsEndpoint.Annotations, crdEndpoints)
 		endpoints = append(endpoints, crdEndpoints...)
 
 		if dnsEndpoint.Status.ObservedGeneration == dnsEndpoint.Generation {
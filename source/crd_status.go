@@ -0,0 +1,196 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
+)
+
+const (
+	// DNSEndpointSyncedCondition is the type of the condition telling whether the records of a
+	// DNSEndpoint reached the provider in the last synchronization.
+	DNSEndpointSyncedCondition = "Synced"
+
+	dnsEndpointSyncedReason  = "Synced"
+	dnsEndpointSkippedReason = "EndpointsNotApplied"
+	dnsEndpointErrorReason   = "Error"
+)
+
+// CRDStatusReporter writes the outcome of each synchronization to the status of the DNSEndpoints,
+// so their users can tell whether their records actually reached the provider.
+type CRDStatusReporter struct {
+	source *crdSource
+}
+
+// NewCRDStatusReporter creates a CRDStatusReporter for the DNSEndpoints read by the CRD source with the same config.
+func NewCRDStatusReporter(crdClient rest.Interface, namespace, kind string, annotationFilter string, labelSelector labels.Selector, scheme *runtime.Scheme) *CRDStatusReporter {
+	return &CRDStatusReporter{
+		source: &crdSource{
+			crdResource:      strings.ToLower(kind) + "s",
+			namespace:        namespace,
+			annotationFilter: annotationFilter,
+			labelSelector:    labelSelector,
+			crdClient:        crdClient,
+			codec:            runtime.NewParameterCodec(scheme),
+			now:              time.Now,
+		},
+	}
+}
+
+// BuildCRDStatusReporter creates a CRDStatusReporter for the DNSEndpoints read by the CRD source built from the shared config.
+func BuildCRDStatusReporter(p ClientGenerator, cfg *Config) (*CRDStatusReporter, error) {
+	client, err := p.KubeClient()
+	if err != nil {
+		return nil, err
+	}
+	crdClient, scheme, err := NewCRDClientForAPIVersionKind(client, cfg.KubeConfig, cfg.APIServerURL, cfg.CRDSourceAPIVersion, cfg.CRDSourceKind)
+	if err != nil {
+		return nil, err
+	}
+	return NewCRDStatusReporter(crdClient, cfg.Namespace, cfg.CRDSourceKind, cfg.AnnotationFilter, cfg.crdLabelSelector(), scheme), nil
+}
+
+// ReportSync updates the Synced condition and the state of the endpoints of every DNSEndpoint.
+// The status is only written when it changed. Failures are logged, they don't fail the synchronization.
+func (r *CRDStatusReporter) ReportSync(ctx context.Context, desired, skipped []*endpoint.Endpoint, syncErr error) {
+	result, err := r.source.List(ctx, &metav1.ListOptions{LabelSelector: r.source.labelSelector.String()})
+	if err != nil {
+		log.Warnf("Could not list the DNSEndpoints to report the synchronization: %v", err)
+		return
+	}
+	result, err = r.source.filterByAnnotations(result)
+	if err != nil {
+		log.Warnf("Could not filter the DNSEndpoints to report the synchronization: %v", err)
+		return
+	}
+
+	desiredKeys := statusKeysByResource(desired)
+	skippedKeys := statusKeysByResource(skipped)
+
+	for i := range result.Items {
+		dnsEndpoint := &result.Items[i]
+		if excluded, _ := annotations.BoolFromAnnotations(dnsEndpoint.Annotations, excludeAnnotationKey); excluded {
+			continue
+		}
+
+		resource := fmt.Sprintf("crd/%s/%s", dnsEndpoint.Namespace, dnsEndpoint.Name)
+		status := dnsEndpointSyncStatus(dnsEndpoint, desiredKeys[resource], skippedKeys[resource], syncErr)
+		if equality.Semantic.DeepEqual(status, dnsEndpoint.Status) {
+			continue
+		}
+
+		dnsEndpoint.Status = status
+		if _, err := r.source.UpdateStatus(ctx, dnsEndpoint); err != nil {
+			log.Warnf("Could not update the status of DNSEndpoint %s/%s: %v", dnsEndpoint.Namespace, dnsEndpoint.Name, err)
+		}
+	}
+}
+
+// dnsEndpointSyncStatus returns the status of the DNSEndpoint after a synchronization. Endpoints keep the
+// generation they were last applied with when they weren't applied, e.g. since the synchronization failed.
+func dnsEndpointSyncStatus(dnsEndpoint *endpoint.DNSEndpoint, desired, skipped map[endpoint.EndpointKey]bool, syncErr error) endpoint.DNSEndpointStatus {
+	status := *dnsEndpoint.Status.DeepCopy()
+
+	applied := make(map[endpoint.EndpointKey]int64, len(status.Endpoints))
+	for _, s := range status.Endpoints {
+		applied[statusKey(s.DNSName, s.RecordType, s.SetIdentifier)] = s.AppliedGeneration
+	}
+
+	status.Endpoints = nil
+	notApplied := 0
+	for _, ep := range dnsEndpoint.Spec.Endpoints {
+		key := statusKey(ep.DNSName, ep.RecordType, ep.SetIdentifier)
+		epStatus := endpoint.EndpointStatus{
+			DNSName:           ep.DNSName,
+			RecordType:        ep.RecordType,
+			SetIdentifier:     ep.SetIdentifier,
+			AppliedGeneration: applied[key],
+		}
+		switch {
+		case syncErr != nil:
+			epStatus.Message = "The synchronization failed"
+		case !desired[key]:
+			epStatus.Message = "The endpoint is not valid"
+		case skipped[key]:
+			epStatus.Message = "The change of the endpoint was skipped"
+		default:
+			epStatus.AppliedGeneration = dnsEndpoint.Generation
+		}
+		if epStatus.Message != "" {
+			notApplied++
+		}
+		status.Endpoints = append(status.Endpoints, epStatus)
+	}
+
+	condition := metav1.Condition{
+		Type:               DNSEndpointSyncedCondition,
+		ObservedGeneration: dnsEndpoint.Generation,
+	}
+	switch {
+	case syncErr != nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = dnsEndpointErrorReason
+		condition.Message = syncErr.Error()
+	case notApplied > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = dnsEndpointSkippedReason
+		condition.Message = fmt.Sprintf("%d of %d endpoints were not applied", notApplied, len(dnsEndpoint.Spec.Endpoints))
+	default:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = dnsEndpointSyncedReason
+		condition.Message = "All endpoints were applied"
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	return status
+}
+
+// statusKeysByResource indexes the keys of the endpoints by their resource label.
+func statusKeysByResource(endpoints []*endpoint.Endpoint) map[string]map[endpoint.EndpointKey]bool {
+	keys := make(map[string]map[endpoint.EndpointKey]bool)
+	for _, ep := range endpoints {
+		resource := ep.Labels[endpoint.ResourceLabelKey]
+		if keys[resource] == nil {
+			keys[resource] = make(map[endpoint.EndpointKey]bool)
+		}
+		keys[resource][statusKey(ep.DNSName, ep.RecordType, ep.SetIdentifier)] = true
+	}
+	return keys
+}
+
+// statusKey returns the key of an endpoint ignoring the normalization of its DNS name by the registry.
+func statusKey(dnsName, recordType, setIdentifier string) endpoint.EndpointKey {
+	return endpoint.EndpointKey{
+		DNSName:       strings.TrimSuffix(strings.ToLower(dnsName), "."),
+		RecordType:    recordType,
+		SetIdentifier: setIdentifier,
+	}
+}
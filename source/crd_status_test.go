@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestCRDStatusReporter(t *testing.T) {
+	const apiVersion, kind, namespace, name = "test.k8s.io/v1alpha1", "DNSEndpoint", "foo", "test"
+
+	web := endpoint.NewEndpoint("web.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	api := endpoint.NewEndpoint("api.example.org", endpoint.RecordTypeCNAME, "web.example.org")
+	client := fakeRESTClient([]*endpoint.Endpoint{web, api}, apiVersion, kind, namespace, name, nil, nil, t)
+
+	groupVersion, _ := schema.ParseGroupVersion(apiVersion)
+	scheme := runtime.NewScheme()
+	require.NoError(t, addKnownTypes(scheme, groupVersion))
+	reporter := NewCRDStatusReporter(client, namespace, kind, "", labels.Everything(), scheme)
+
+	desired := func(endpoints ...*endpoint.Endpoint) []*endpoint.Endpoint {
+		var result []*endpoint.Endpoint
+		for _, ep := range endpoints {
+			ep = ep.DeepCopy()
+			ep.Labels = endpoint.Labels{endpoint.ResourceLabelKey: "crd/foo/test"}
+			result = append(result, ep)
+		}
+		return result
+	}
+	status := func() endpoint.DNSEndpointStatus {
+		result, err := reporter.source.List(context.Background(), &metav1.ListOptions{})
+		require.NoError(t, err)
+		require.Len(t, result.Items, 1)
+		return result.Items[0].Status
+	}
+
+	t.Run("synced", func(t *testing.T) {
+		reporter.ReportSync(context.Background(), desired(web, api), nil, nil)
+
+		s := status()
+		condition := meta.FindStatusCondition(s.Conditions, DNSEndpointSyncedCondition)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, dnsEndpointSyncedReason, condition.Reason)
+		assert.Equal(t, int64(1), condition.ObservedGeneration)
+		assert.Equal(t, []endpoint.EndpointStatus{
+			{DNSName: "web.example.org", RecordType: endpoint.RecordTypeA, AppliedGeneration: 1},
+			{DNSName: "api.example.org", RecordType: endpoint.RecordTypeCNAME, AppliedGeneration: 1},
+		}, s.Endpoints)
+	})
+
+	t.Run("skipped and invalid endpoints", func(t *testing.T) {
+		reporter.ReportSync(context.Background(), desired(web), desired(web), nil)
+
+		s := status()
+		condition := meta.FindStatusCondition(s.Conditions, DNSEndpointSyncedCondition)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, dnsEndpointSkippedReason, condition.Reason)
+		assert.Equal(t, "2 of 2 endpoints were not applied", condition.Message)
+		assert.Equal(t, []endpoint.EndpointStatus{
+			{DNSName: "web.example.org", RecordType: endpoint.RecordTypeA, AppliedGeneration: 1, Message: "The change of the endpoint was skipped"},
+			{DNSName: "api.example.org", RecordType: endpoint.RecordTypeCNAME, AppliedGeneration: 1, Message: "The endpoint is not valid"},
+		}, s.Endpoints)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		reporter.ReportSync(context.Background(), nil, nil, errors.New("provider unavailable"))
+
+		s := status()
+		condition := meta.FindStatusCondition(s.Conditions, DNSEndpointSyncedCondition)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, dnsEndpointErrorReason, condition.Reason)
+		assert.Equal(t, "provider unavailable", condition.Message)
+		for _, epStatus := range s.Endpoints {
+			assert.Equal(t, int64(1), epStatus.AppliedGeneration)
+			assert.Equal(t, "The synchronization failed", epStatus.Message)
+		}
+	})
+}
+
+func TestDNSEndpointSyncStatusKeepsTransitionTime(t *testing.T) {
+	dnsEndpoint := &endpoint.DNSEndpoint{
+		ObjectMeta: metav1.ObjectMeta{Generation: 2},
+		Spec: endpoint.DNSEndpointSpec{
+			Endpoints: []*endpoint.Endpoint{endpoint.NewEndpoint("web.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+		},
+	}
+	desired := map[endpoint.EndpointKey]bool{statusKey("Web.example.org.", endpoint.RecordTypeA, ""): true}
+
+	dnsEndpoint.Status = dnsEndpointSyncStatus(dnsEndpoint, desired, nil, nil)
+	assert.Equal(t, int64(2), dnsEndpoint.Status.Endpoints[0].AppliedGeneration)
+
+	// an unchanged outcome results in an unchanged status, which isn't written again
+	assert.Equal(t, dnsEndpoint.Status, dnsEndpointSyncStatus(dnsEndpoint, desired, nil, nil))
+}
@@ -25,7 +25,9 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -35,6 +37,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/rest/fake"
+	"k8s.io/client-go/tools/cache"
 
 	"sigs.k8s.io/external-dns/endpoint"
 )
@@ -103,7 +106,7 @@ func fakeRESTClient(endpoints []*endpoint.Endpoint, apiVersion, kind, namespace,
 
 				var body endpoint.DNSEndpoint
 				decoder.Decode(&body)
-				dnsEndpoint.Status.ObservedGeneration = body.Status.ObservedGeneration
+				dnsEndpoint.Status = body.Status
 				return &http.Response{StatusCode: http.StatusOK, Header: defaultHeader(), Body: objBody(codec, dnsEndpoint)}, nil
 			default:
 				return nil, fmt.Errorf("unexpected request: %#v\n%#v", req.URL, req)
@@ -526,3 +529,91 @@ func validateCRDResource(t *testing.T, src Source, expectError bool) {
 		}
 	}
 }
+
+func TestCRDSourceEndpointsFromInformer(t *testing.T) {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &endpoint.DNSEndpoint{}, 0, cache.Indexers{})
+	cached := &endpoint.DNSEndpoint{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "foo", Generation: 1},
+		Spec: endpoint.DNSEndpointSpec{
+			Endpoints: []*endpoint.Endpoint{endpoint.NewEndpoint("web.example.org", endpoint.RecordTypeA, "1.2.3.4")},
+		},
+		Status: endpoint.DNSEndpointStatus{ObservedGeneration: 1},
+	}
+	require.NoError(t, informer.GetStore().Add(cached))
+
+	cs := &crdSource{informer: informer, labelSelector: labels.Everything(), now: time.Now}
+	endpoints, err := cs.Endpoints(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, "web.example.org", endpoints[0].DNSName)
+	assert.Equal(t, "crd/foo/test", endpoints[0].Labels[endpoint.ResourceLabelKey])
+	// the cached object is left unchanged
+	assert.Empty(t, cached.Spec.Endpoints[0].Labels)
+}
+
+func TestCRDSourceResourceVersionWithSchedules(t *testing.T) {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &endpoint.DNSEndpoint{}, 0, cache.Indexers{})
+	require.NoError(t, informer.GetStore().Add(&endpoint.DNSEndpoint{
+		ObjectMeta: metav1.ObjectMeta{Name: "maintenance", Namespace: "foo", ResourceVersion: "1"},
+		Spec: endpoint.DNSEndpointSpec{
+			Endpoints: []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.org", endpoint.RecordTypeA, "10.0.0.1")},
+			Schedules: []*endpoint.TargetSchedule{{
+				DNSName:    "www.example.org",
+				RecordType: endpoint.RecordTypeA,
+				Schedule:   "0 22 * * *",
+				Duration:   metav1.Duration{Duration: 8 * time.Hour},
+				Targets:    endpoint.Targets{"10.0.0.2"},
+			}},
+		},
+	}))
+
+	now := time.Date(2024, time.June, 3, 12, 0, 0, 0, time.UTC)
+	cs := &crdSource{informer: informer, labelSelector: labels.Everything(), now: func() time.Time { return now }}
+	outside, ok := cs.resourceVersion()
+	require.True(t, ok)
+
+	// the version changes when the window starts, although the DNSEndpoint doesn't
+	now = time.Date(2024, time.June, 3, 23, 0, 0, 0, time.UTC)
+	inside, ok := cs.resourceVersion()
+	require.True(t, ok)
+	assert.NotEqual(t, outside, inside)
+
+	now = time.Date(2024, time.June, 4, 5, 0, 0, 0, time.UTC)
+	stillInside, _ := cs.resourceVersion()
+	assert.Equal(t, inside, stillInside)
+
+	now = time.Date(2024, time.June, 4, 12, 0, 0, 0, time.UTC)
+	after, _ := cs.resourceVersion()
+	assert.Equal(t, outside, after)
+}
+
+func TestCRDSourceListWatchLabelSelector(t *testing.T) {
+	groupVersion := schema.GroupVersion{Group: "test.k8s.io", Version: "v1alpha1"}
+	scheme := runtime.NewScheme()
+	require.NoError(t, addKnownTypes(scheme, groupVersion))
+	codecFactory := serializer.WithoutConversionCodecFactory{CodecFactory: serializer.NewCodecFactory(scheme)}
+
+	var selectors []string
+	client := &fake.RESTClient{
+		GroupVersion:         groupVersion,
+		VersionedAPIPath:     "/apis/" + groupVersion.String(),
+		NegotiatedSerializer: codecFactory,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			selectors = append(selectors, req.URL.Query().Get("labelSelector"))
+			codec := codecFactory.LegacyCodec(groupVersion)
+			return &http.Response{StatusCode: http.StatusOK, Header: defaultHeader(), Body: objBody(codec, &endpoint.DNSEndpointList{})}, nil
+		}),
+	}
+
+	cs := &crdSource{
+		crdClient:     client,
+		namespace:     "foo",
+		crdResource:   "dnsendpoints",
+		codec:         runtime.NewParameterCodec(scheme),
+		labelSelector: labels.SelectorFromSet(labels.Set{"tier": "dns"}),
+	}
+	_, err := cs.listWatch().List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tier=dns"}, selectors)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
+)
+
+// The annotation used for opting a resource out of ExternalDNS, whatever the source
+const excludeAnnotationKey = annotations.ExcludeKey
+
+var excludedObjectsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "external_dns",
+		Subsystem: "source",
+		Name:      "excluded_objects_total",
+		Help:      "Number of resources skipped because of the exclude annotation.",
+	},
+	[]string{"kind"},
+)
+
+func init() {
+	prometheus.MustRegister(excludedObjectsTotal)
+}
+
+// isExcluded returns whether the object of the given kind has opted out of ExternalDNS with the
+// exclude annotation. Invalid values are logged and don't exclude the object.
+func isExcluded(annots map[string]string, kind, namespace, name string) bool {
+	excluded, err := annotations.BoolFromAnnotations(annots, excludeAnnotationKey)
+	if err != nil {
+		log.Warnf("Ignoring %v on %s %s", err, kind, objectName(namespace, name))
+		return false
+	}
+	if !excluded {
+		return false
+	}
+	log.Debugf("Skipping %s %s because of the exclude annotation", kind, objectName(namespace, name))
+	excludedObjectsTotal.WithLabelValues(kind).Inc()
+	return true
+}
+
+// withoutExcludedEndpoints drops the endpoints of the sources not reading Kubernetes resources, like the http and
+// webhook sources, whose labels set the exclude annotation to true, and removes the label from the others, so it
+// isn't stored along with the records.
+func withoutExcludedEndpoints(endpoints []*endpoint.Endpoint, kind string) []*endpoint.Endpoint {
+	return slices.DeleteFunc(endpoints, func(ep *endpoint.Endpoint) bool {
+		if _, ok := ep.Labels[excludeAnnotationKey]; !ok {
+			return false
+		}
+		excluded := isExcluded(ep.Labels, kind, "", ep.DNSName)
+		delete(ep.Labels, excludeAnnotationKey)
+		return excluded
+	})
+}
+
+// objectName returns the name of an object prefixed by its namespace, if any.
+func objectName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakeDynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestIsExcluded(t *testing.T) {
+	for _, tc := range []struct {
+		title       string
+		annotations map[string]string
+		expected    bool
+	}{
+		{
+			title:    "no annotation",
+			expected: false,
+		},
+		{
+			title:       "excluded",
+			annotations: map[string]string{excludeAnnotationKey: "true"},
+			expected:    true,
+		},
+		{
+			title:       "not excluded",
+			annotations: map[string]string{excludeAnnotationKey: "false"},
+			expected:    false,
+		},
+		{
+			title:       "invalid value",
+			annotations: map[string]string{excludeAnnotationKey: "yes please"},
+			expected:    false,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isExcluded(tc.annotations, "test", "default", "foo"))
+		})
+	}
+}
+
+func TestIngressSourceExclude(t *testing.T) {
+	fakeClient := fake.NewSimpleClientset()
+	for _, ing := range []fakeIngress{
+		{
+			name:      "included",
+			namespace: "default",
+			dnsnames:  []string{"included.example.org"},
+			ips:       []string{"8.8.8.8"},
+		},
+		{
+			name:        "excluded",
+			namespace:   "default",
+			dnsnames:    []string{"excluded.example.org"},
+			ips:         []string{"8.8.4.4"},
+			annotations: map[string]string{excludeAnnotationKey: "true"},
+		},
+	} {
+		_, err := fakeClient.NetworkingV1().Ingresses(ing.namespace).Create(context.Background(), ing.Ingress(), metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	before := testutil.ToFloat64(excludedObjectsTotal.WithLabelValues("ingress"))
+
+	sc, err := NewIngressSource(context.Background(), fakeClient, "", "", "", false, false, false, false, labels.Everything(), []string{}, false)
+	require.NoError(t, err)
+	endpoints, err := sc.Endpoints(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, "included.example.org", endpoints[0].DNSName)
+	assert.Equal(t, before+1, testutil.ToFloat64(excludedObjectsTotal.WithLabelValues("ingress")))
+}
+
+func TestDynamicSourcesExclude(t *testing.T) {
+	excluded := map[string]string{excludeAnnotationKey: "true"}
+	blueGreen := map[string]interface{}{
+		"blueGreen": map[string]interface{}{"activeService": "active"},
+	}
+	newTransportServer := func(name, host string, annotations map[string]string) *unstructured.Unstructured {
+		ts := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": f5TransportServerGVR.GroupVersion().String(),
+			"kind":       "TransportServer",
+			"spec": map[string]interface{}{
+				"host":                 host,
+				"virtualServerAddress": "192.0.2.1",
+			},
+		}}
+		ts.SetName(name)
+		ts.SetNamespace("default")
+		ts.SetAnnotations(annotations)
+		return ts
+	}
+	external := capiMachineAddress{Type: capiMachineExternalIP, Address: "192.0.2.1"}
+	bridge := kubevirtVMIInterface{Name: "default", IP: "192.0.2.1"}
+
+	for _, tc := range []struct {
+		title     string
+		kind      string
+		gvr       schema.GroupVersionResource
+		listKind  string
+		included  *unstructured.Unstructured
+		excluded  *unstructured.Unstructured
+		newSource func(ctx context.Context, dynamicClient *fakeDynamic.FakeDynamicClient) (Source, error)
+	}{
+		{
+			title:    "cluster api machine",
+			kind:     "machine",
+			gvr:      capiMachineGVR,
+			listKind: "MachineList",
+			included: newTestCAPIMachine("included", nil, nil, external),
+			excluded: newTestCAPIMachine("excluded", nil, excluded, external),
+			newSource: func(ctx context.Context, dynamicClient *fakeDynamic.FakeDynamicClient) (Source, error) {
+				return NewCAPIMachineSource(ctx, dynamicClient, "", "", "{{.Name}}.example.org", labels.Everything())
+			},
+		},
+		{
+			title:    "argo rollout",
+			kind:     "rollout",
+			gvr:      argoRolloutGVR,
+			listKind: "RolloutList",
+			included: newTestArgoRollout("included", map[string]string{hostnameAnnotationKey: "included.example.org"}, blueGreen),
+			excluded: newTestArgoRollout("excluded", map[string]string{hostnameAnnotationKey: "excluded.example.org", excludeAnnotationKey: "true"}, blueGreen),
+			newSource: func(ctx context.Context, dynamicClient *fakeDynamic.FakeDynamicClient) (Source, error) {
+				kubeClient := fake.NewSimpleClientset(&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Name: "active", Namespace: "default"},
+					Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, ClusterIP: "10.0.0.1"},
+				})
+				return NewArgoRolloutSource(ctx, dynamicClient, kubeClient, "default", "")
+			},
+		},
+		{
+			title:    "f5 transportserver",
+			kind:     "transportserver",
+			gvr:      f5TransportServerGVR,
+			listKind: "TransportServerList",
+			included: newTransportServer("included", "included.example.org", nil),
+			excluded: newTransportServer("excluded", "excluded.example.org", excluded),
+			newSource: func(ctx context.Context, dynamicClient *fakeDynamic.FakeDynamicClient) (Source, error) {
+				return NewF5TransportServerSource(ctx, dynamicClient, fake.NewSimpleClientset(), "default", "", false)
+			},
+		},
+		{
+			title:    "kubevirt virtualmachineinstance",
+			kind:     "virtualmachineinstance",
+			gvr:      kubevirtVMIGVR,
+			listKind: "VirtualMachineInstanceList",
+			included: newTestKubeVirtVMI("included", kubevirtVMIRunning, nil, nil, bridge),
+			excluded: newTestKubeVirtVMI("excluded", kubevirtVMIRunning, nil, excluded, bridge),
+			newSource: func(ctx context.Context, dynamicClient *fakeDynamic.FakeDynamicClient) (Source, error) {
+				return NewKubeVirtVMISource(ctx, dynamicClient, "", "", "{{.Name}}.example.org", labels.Everything())
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			ctx := context.Background()
+			dynamicClient := fakeDynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{tc.gvr: tc.listKind})
+			for _, obj := range []*unstructured.Unstructured{tc.included, tc.excluded} {
+				_, err := dynamicClient.Resource(tc.gvr).Namespace("default").Create(ctx, obj, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+			before := testutil.ToFloat64(excludedObjectsTotal.WithLabelValues(tc.kind))
+
+			source, err := tc.newSource(ctx, dynamicClient)
+			require.NoError(t, err)
+			endpoints, err := source.Endpoints(ctx)
+			require.NoError(t, err)
+
+			require.Len(t, endpoints, 1)
+			assert.Equal(t, "included.example.org", endpoints[0].DNSName)
+			assert.Equal(t, before+1, testutil.ToFloat64(excludedObjectsTotal.WithLabelValues(tc.kind)))
+		})
+	}
+}
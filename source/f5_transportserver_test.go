@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeDynamic "k8s.io/client-go/dynamic/fake"
+	fakeKube "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
+
+	f5 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
+)
+
+const defaultF5TransportServerNamespace = "transportserver"
+
+func TestF5TransportServerEndpoints(t *testing.T) {
+	t.Parallel()
+
+	newTransportServer := func(annotations map[string]string, spec f5.TransportServerSpec, status f5.TransportServerStatus) f5.TransportServer {
+		return f5.TransportServer{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: f5TransportServerGVR.GroupVersion().String(),
+				Kind:       "TransportServer",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test-ts",
+				Namespace:   defaultF5TransportServerNamespace,
+				Annotations: annotations,
+			},
+			Spec:   spec,
+			Status: status,
+		}
+	}
+	newEndpoint := func(dnsName string, ttl endpoint.TTL, targets ...string) *endpoint.Endpoint {
+		return &endpoint.Endpoint{
+			DNSName:          dnsName,
+			Targets:          targets,
+			RecordType:       endpoint.RecordTypeA,
+			RecordTTL:        ttl,
+			ProviderSpecific: endpoint.ProviderSpecific{},
+			Labels: endpoint.Labels{
+				"resource": "f5-transportserver/transportserver/test-ts",
+			},
+		}
+	}
+
+	tests := []struct {
+		name                     string
+		annotationFilter         string
+		ignoreHostnameAnnotation bool
+		transportServer          f5.TransportServer
+		expected                 []*endpoint.Endpoint
+	}{
+		{
+			name: "F5 TransportServer with host and virtualServerAddress set",
+			transportServer: newTransportServer(nil,
+				f5.TransportServerSpec{Host: "tcp.example.com", VirtualServerAddress: "192.168.1.100"},
+				f5.TransportServerStatus{VSAddress: "192.168.1.200"}),
+			expected: []*endpoint.Endpoint{newEndpoint("tcp.example.com", 0, "192.168.1.100")},
+		},
+		{
+			name: "F5 TransportServer with IP address from the status field",
+			transportServer: newTransportServer(nil,
+				f5.TransportServerSpec{Host: "tcp.example.com"},
+				f5.TransportServerStatus{VSAddress: "192.168.1.200"}),
+			expected: []*endpoint.Endpoint{newEndpoint("tcp.example.com", 0, "192.168.1.200")},
+		},
+		{
+			name: "F5 TransportServer with target and ttl annotations",
+			transportServer: newTransportServer(map[string]string{targetAnnotationKey: "192.168.1.150", ttlAnnotationKey: "600"},
+				f5.TransportServerSpec{Host: "tcp.example.com", VirtualServerAddress: "192.168.1.100"},
+				f5.TransportServerStatus{}),
+			expected: []*endpoint.Endpoint{newEndpoint("tcp.example.com", 600, "192.168.1.150")},
+		},
+		{
+			name: "F5 TransportServer with hostname annotation and without host",
+			transportServer: newTransportServer(map[string]string{hostnameAnnotationKey: "db.example.com"},
+				f5.TransportServerSpec{VirtualServerAddress: "192.168.1.100"},
+				f5.TransportServerStatus{}),
+			expected: []*endpoint.Endpoint{newEndpoint("db.example.com", 0, "192.168.1.100")},
+		},
+		{
+			name: "F5 TransportServer with provider-specific annotations",
+			transportServer: newTransportServer(map[string]string{annotations.ProviderSpecificPrefix + "tier": "gold", annotations.SetIdentifierKey: "eu"},
+				f5.TransportServerSpec{Host: "tcp.example.com", VirtualServerAddress: "192.168.1.100"},
+				f5.TransportServerStatus{}),
+			expected: []*endpoint.Endpoint{func() *endpoint.Endpoint {
+				ep := newEndpoint("tcp.example.com", 0, "192.168.1.100")
+				ep.ProviderSpecific = endpoint.ProviderSpecific{{Name: "tier", Value: "gold"}}
+				ep.SetIdentifier = "eu"
+				return ep
+			}()},
+		},
+		{
+			name:                     "F5 TransportServer with ignored hostname annotation",
+			ignoreHostnameAnnotation: true,
+			transportServer: newTransportServer(map[string]string{hostnameAnnotationKey: "db.example.com"},
+				f5.TransportServerSpec{VirtualServerAddress: "192.168.1.100"},
+				f5.TransportServerStatus{}),
+		},
+		{
+			name: "F5 TransportServer with no IP address set",
+			transportServer: newTransportServer(nil,
+				f5.TransportServerSpec{Host: "tcp.example.com"},
+				f5.TransportServerStatus{}),
+		},
+		{
+			name:             "F5 TransportServer with non-matching annotation filter",
+			annotationFilter: "foo=bar",
+			transportServer: newTransportServer(map[string]string{"bar": "foo"},
+				f5.TransportServerSpec{Host: "tcp.example.com", VirtualServerAddress: "192.168.1.100"},
+				f5.TransportServerStatus{}),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeKubernetesClient := fakeKube.NewSimpleClientset()
+			scheme := runtime.NewScheme()
+			scheme.AddKnownTypes(f5TransportServerGVR.GroupVersion(), &f5.TransportServer{}, &f5.TransportServerList{})
+			fakeDynamicClient := fakeDynamic.NewSimpleDynamicClient(scheme)
+
+			transportServer := unstructured.Unstructured{}
+
+			transportServerJSON, err := json.Marshal(tc.transportServer)
+			require.NoError(t, err)
+			assert.NoError(t, transportServer.UnmarshalJSON(transportServerJSON))
+
+			// Create TransportServer resources
+			_, err = fakeDynamicClient.Resource(f5TransportServerGVR).Namespace(defaultF5TransportServerNamespace).Create(context.Background(), &transportServer, metav1.CreateOptions{})
+			assert.NoError(t, err)
+
+			source, err := NewF5TransportServerSource(context.TODO(), fakeDynamicClient, fakeKubernetesClient, defaultF5TransportServerNamespace, tc.annotationFilter, tc.ignoreHostnameAnnotation)
+			require.NoError(t, err)
+			assert.NotNil(t, source)
+
+			endpoints, err := source.Endpoints(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, endpoints)
+		})
+	}
+}
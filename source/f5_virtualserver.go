@@ -38,6 +38,7 @@ import (
 	f5 "github.com/F5Networks/k8s-bigip-ctlr/v2/config/apis/cis/v1"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
 )
 
 var f5VirtualServerGVR = schema.GroupVersionResource{
@@ -147,11 +148,16 @@ func (vs *f5VirtualServerSource) endpointsFromVirtualServers(virtualServers []*f
 	var endpoints []*endpoint.Endpoint
 
 	for _, virtualServer := range virtualServers {
+		if isExcluded(virtualServer.Annotations, "virtualserver", virtualServer.Namespace, virtualServer.Name) {
+			continue
+		}
+
 		resource := fmt.Sprintf("f5-virtualserver/%s/%s", virtualServer.Namespace, virtualServer.Name)
 
-		ttl := getTTLFromAnnotations(virtualServer.Annotations, resource)
+		ttl := annotations.TTLFromAnnotations(virtualServer.Annotations, resource)
+		providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(virtualServer.Annotations)
 
-		targets := getTargetsFromTargetAnnotation(virtualServer.Annotations)
+		targets := annotations.TargetsFromTargetAnnotation(virtualServer.Annotations)
 		if len(targets) == 0 && virtualServer.Spec.VirtualServerAddress != "" {
 			targets = append(targets, virtualServer.Spec.VirtualServerAddress)
 		}
@@ -159,7 +165,7 @@ func (vs *f5VirtualServerSource) endpointsFromVirtualServers(virtualServers []*f
 			targets = append(targets, virtualServer.Status.VSAddress)
 		}
 
-		endpoints = append(endpoints, endpointsForHostname(virtualServer.Spec.Host, targets, ttl, nil, "", resource)...)
+		endpoints = append(endpoints, endpointsForHostname(virtualServer.Spec.Host, targets, ttl, providerSpecific, setIdentifier, resource)...)
 	}
 
 	return endpoints, nil
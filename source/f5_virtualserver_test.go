@@ -69,10 +69,11 @@ func TestF5VirtualServerEndpoints(t *testing.T) {
 			},
 			expected: []*endpoint.Endpoint{
 				{
-					DNSName:    "www.example.com",
-					Targets:    []string{"192.168.1.150"},
-					RecordType: endpoint.RecordTypeA,
-					RecordTTL:  0,
+					DNSName:          "www.example.com",
+					Targets:          []string{"192.168.1.150"},
+					RecordType:       endpoint.RecordTypeA,
+					RecordTTL:        0,
+					ProviderSpecific: endpoint.ProviderSpecific{},
 					Labels: endpoint.Labels{
 						"resource": "f5-virtualserver/virtualserver/test-vs",
 					},
@@ -101,10 +102,11 @@ func TestF5VirtualServerEndpoints(t *testing.T) {
 			},
 			expected: []*endpoint.Endpoint{
 				{
-					DNSName:    "www.example.com",
-					Targets:    []string{"192.168.1.100"},
-					RecordType: endpoint.RecordTypeA,
-					RecordTTL:  0,
+					DNSName:          "www.example.com",
+					Targets:          []string{"192.168.1.100"},
+					RecordType:       endpoint.RecordTypeA,
+					RecordTTL:        0,
+					ProviderSpecific: endpoint.ProviderSpecific{},
 					Labels: endpoint.Labels{
 						"resource": "f5-virtualserver/virtualserver/test-vs",
 					},
@@ -132,10 +134,11 @@ func TestF5VirtualServerEndpoints(t *testing.T) {
 			},
 			expected: []*endpoint.Endpoint{
 				{
-					DNSName:    "www.example.com",
-					Targets:    []string{"192.168.1.100"},
-					RecordType: endpoint.RecordTypeA,
-					RecordTTL:  0,
+					DNSName:          "www.example.com",
+					Targets:          []string{"192.168.1.100"},
+					RecordType:       endpoint.RecordTypeA,
+					RecordTTL:        0,
+					ProviderSpecific: endpoint.ProviderSpecific{},
 					Labels: endpoint.Labels{
 						"resource": "f5-virtualserver/virtualserver/test-vs",
 					},
@@ -185,10 +188,11 @@ func TestF5VirtualServerEndpoints(t *testing.T) {
 			},
 			expected: []*endpoint.Endpoint{
 				{
-					DNSName:    "www.example.com",
-					Targets:    []string{"192.168.1.100"},
-					RecordType: endpoint.RecordTypeA,
-					RecordTTL:  0,
+					DNSName:          "www.example.com",
+					Targets:          []string{"192.168.1.100"},
+					RecordType:       endpoint.RecordTypeA,
+					RecordTTL:        0,
+					ProviderSpecific: endpoint.ProviderSpecific{},
 					Labels: endpoint.Labels{
 						"resource": "f5-virtualserver/virtualserver/test-vs",
 					},
@@ -238,10 +242,11 @@ func TestF5VirtualServerEndpoints(t *testing.T) {
 			},
 			expected: []*endpoint.Endpoint{
 				{
-					DNSName:    "www.example.com",
-					Targets:    []string{"192.168.1.100"},
-					RecordType: endpoint.RecordTypeA,
-					RecordTTL:  600,
+					DNSName:          "www.example.com",
+					Targets:          []string{"192.168.1.100"},
+					RecordType:       endpoint.RecordTypeA,
+					RecordTTL:        600,
+					ProviderSpecific: endpoint.ProviderSpecific{},
 					Labels: endpoint.Labels{
 						"resource": "f5-virtualserver/virtualserver/test-vs",
 					},
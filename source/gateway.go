@@ -19,7 +19,9 @@ package source
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net/netip"
+	"slices"
 	"sort"
 	"strings"
 	"text/template"
@@ -40,6 +42,7 @@ import (
 	informers_v1beta1 "sigs.k8s.io/gateway-api/pkg/client/informers/externalversions/apis/v1beta1"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
 )
 
 const (
@@ -97,10 +100,13 @@ type gatewayRouteSource struct {
 	rtInformer    gatewayRouteInformer
 
 	nsInformer coreinformers.NamespaceInformer
+	nsDefaults *namespaceDefaults
 
 	fqdnTemplate             *template.Template
 	combineFQDNAnnotation    bool
 	ignoreHostnameAnnotation bool
+	listenerSRVRecords       bool
+	listenerHTTPSRecords     bool
 }
 
 func newGatewayRouteSource(clients ClientGenerator, config *Config, kind string, newInformerFn newGatewayRouteInformerFunc) (Source, error) {
@@ -164,6 +170,12 @@ func newGatewayRouteSource(clients ClientGenerator, config *Config, kind string,
 		return nil, err
 	}
 
+	// The Namespace informer is shared with the defaults of the Routes.
+	var nsDefaults *namespaceDefaults
+	if config.NamespaceDefaultAnnotations {
+		nsDefaults = &namespaceDefaults{nsInformer: nsInformer}
+	}
+
 	src := &gatewayRouteSource{
 		gwNamespace: config.GatewayNamespace,
 		gwLabels:    gwLabels,
@@ -176,10 +188,13 @@ func newGatewayRouteSource(clients ClientGenerator, config *Config, kind string,
 		rtInformer:    rtInformer,
 
 		nsInformer: nsInformer,
+		nsDefaults: nsDefaults,
 
 		fqdnTemplate:             tmpl,
 		combineFQDNAnnotation:    config.CombineFQDNAndAnnotation,
 		ignoreHostnameAnnotation: config.IgnoreHostnameAnnotation,
+		listenerSRVRecords:       slices.Contains(config.GatewayListenerPortRecords, endpoint.RecordTypeSRV),
+		listenerHTTPSRecords:     slices.Contains(config.GatewayListenerPortRecords, endpoint.RecordTypeHTTPS),
 	}
 	return src, nil
 }
@@ -215,16 +230,22 @@ func (src *gatewayRouteSource) Endpoints(ctx context.Context) ([]*endpoint.Endpo
 		if !src.rtAnnotations.Matches(labels.Set(annots)) {
 			continue
 		}
+		if isExcluded(annots, kind, meta.Namespace, meta.Name) {
+			continue
+		}
 
 		// Check controller annotation to see if we are responsible.
-		if v, ok := annots[controllerAnnotationKey]; ok && v != controllerAnnotationValue {
+		if v, foreign := annotations.ForeignController(annots); foreign {
 			log.Debugf("Skipping %s %s/%s because controller value does not match, found: %s, required: %s",
 				src.rtKind, meta.Namespace, meta.Name, v, controllerAnnotationValue)
 			continue
 		}
+		if merged, ok := src.nsDefaults.annotations(meta.Namespace, annots); ok {
+			annots = merged
+		}
 
 		// Get Route hostnames and their targets.
-		hostTargets, err := resolver.resolve(rt)
+		hostTargets, hostListeners, err := resolver.resolve(rt)
 		if err != nil {
 			return nil, err
 		}
@@ -235,10 +256,13 @@ func (src *gatewayRouteSource) Endpoints(ctx context.Context) ([]*endpoint.Endpo
 
 		// Create endpoints from hostnames and targets.
 		resource := fmt.Sprintf("%s/%s/%s", kind, meta.Namespace, meta.Name)
-		providerSpecific, setIdentifier := getProviderSpecificAnnotations(annots)
-		ttl := getTTLFromAnnotations(annots, resource)
+		providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(annots)
+		ttl := annotations.TTLFromAnnotations(annots, resource)
 		for host, targets := range hostTargets {
-			endpoints = append(endpoints, endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier, resource)...)
+			targets = annotations.TargetsOfIPFamilies(annots, targets)
+			hostEndpoints := endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier, resource)
+			endpoints = append(endpoints, hostEndpoints...)
+			endpoints = append(endpoints, src.listenerPortEndpoints(host, hostEndpoints, hostListeners[host], ttl, resource)...)
 		}
 		setDualstackLabel(rt, endpoints)
 		log.Debugf("Endpoints generated from %s %s/%s: %v", src.rtKind, meta.Namespace, meta.Name, endpoints)
@@ -287,12 +311,14 @@ func newGatewayRouteResolver(src *gatewayRouteSource, gateways []*v1beta1.Gatewa
 	}
 }
 
-func (c *gatewayRouteResolver) resolve(rt gatewayRoute) (map[string]endpoint.Targets, error) {
+// resolve returns the targets of the hostnames of the Route, along with the Listeners each hostname is attached to.
+func (c *gatewayRouteResolver) resolve(rt gatewayRoute) (map[string]endpoint.Targets, map[string][]v1.Listener, error) {
 	rtHosts, err := c.hosts(rt)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	hostTargets := make(map[string]endpoint.Targets)
+	hostListeners := make(map[string][]v1.Listener)
 
 	meta := rt.Metadata()
 	for _, rps := range rt.RouteStatus().Parents {
@@ -352,13 +378,14 @@ func (c *gatewayRouteResolver) resolve(rt gatewayRoute) (map[string]endpoint.Tar
 				if !ok {
 					continue
 				}
-				override := getTargetsFromTargetAnnotation(gw.gateway.Annotations)
+				override := annotations.TargetsFromTargetAnnotation(gw.gateway.Annotations)
 				hostTargets[host] = append(hostTargets[host], override...)
 				if len(override) == 0 {
 					for _, addr := range gw.gateway.Status.Addresses {
 						hostTargets[host] = append(hostTargets[host], addr.Value)
 					}
 				}
+				hostListeners[host] = append(hostListeners[host], *lis)
 				match = true
 			}
 		}
@@ -371,7 +398,84 @@ func (c *gatewayRouteResolver) resolve(rt gatewayRoute) (map[string]endpoint.Tar
 	for host, targets := range hostTargets {
 		hostTargets[host] = uniqueTargets(targets)
 	}
-	return hostTargets, nil
+	return hostTargets, hostListeners, nil
+}
+
+// listenerPortEndpoints returns SRV and HTTPS records carrying the ports of the Listeners with non-standard
+// ports, so clients can discover them. HTTPS records are only published for HTTPS Listeners, next to A and
+// AAAA records, since they can't coexist with a CNAME record.
+func (src *gatewayRouteSource) listenerPortEndpoints(host string, hostEndpoints []*endpoint.Endpoint, listeners []v1.Listener, ttl endpoint.TTL, resource string) []*endpoint.Endpoint {
+	if !src.listenerSRVRecords && !src.listenerHTTPSRecords {
+		return nil
+	}
+
+	addressRecords := false
+	for _, ep := range hostEndpoints {
+		if ep.RecordType == endpoint.RecordTypeA || ep.RecordType == endpoint.RecordTypeAAAA {
+			addressRecords = true
+		}
+	}
+
+	srvTargets := make(map[string]endpoint.Targets)
+	var httpsTargets endpoint.Targets
+	for _, lis := range listeners {
+		if gwStandardPort(lis) {
+			continue
+		}
+		// following the RFC 2782, the name of an SRV record is _service._proto.name, wildcards can't be used
+		if src.listenerSRVRecords && !strings.HasPrefix(host, "*") {
+			recordName := fmt.Sprintf("_%s._%s.%s", gwListenerService(lis), gwListenerTransport(lis), host)
+			srvTargets[recordName] = append(srvTargets[recordName], fmt.Sprintf("0 50 %d %s", lis.Port, host))
+		}
+		// a ServiceMode record for the name itself, see RFC 9460
+		if src.listenerHTTPSRecords && addressRecords && lis.Protocol == v1.HTTPSProtocolType {
+			httpsTargets = append(httpsTargets, fmt.Sprintf("1 . port=%d", lis.Port))
+		}
+	}
+
+	var endpoints []*endpoint.Endpoint
+	for _, recordName := range slices.Sorted(maps.Keys(srvTargets)) {
+		endpoints = append(endpoints, endpoint.NewEndpointWithTTL(recordName, endpoint.RecordTypeSRV, ttl, uniqueTargets(srvTargets[recordName])...))
+	}
+	if len(httpsTargets) > 0 {
+		endpoints = append(endpoints, endpoint.NewEndpointWithTTL(host, endpoint.RecordTypeHTTPS, ttl, uniqueTargets(httpsTargets)...))
+	}
+	for _, ep := range endpoints {
+		ep.Labels[endpoint.ResourceLabelKey] = resource
+	}
+	return endpoints
+}
+
+// gwStandardPort returns whether the Listener uses the default port of its protocol,
+// which clients use without discovering it. TCP and UDP have no default port.
+func gwStandardPort(lis v1.Listener) bool {
+	switch lis.Protocol {
+	case v1.HTTPProtocolType:
+		return lis.Port == 80
+	case v1.HTTPSProtocolType, v1.TLSProtocolType:
+		return lis.Port == 443
+	}
+	return false
+}
+
+// gwListenerService returns the service name of the SRV record of the Listener: http and https for
+// HTTP and HTTPS Listeners, and the name of the Listener otherwise.
+func gwListenerService(lis v1.Listener) string {
+	switch lis.Protocol {
+	case v1.HTTPProtocolType:
+		return "http"
+	case v1.HTTPSProtocolType:
+		return "https"
+	}
+	return string(lis.Name)
+}
+
+// gwListenerTransport returns the protocol label of the SRV record of the Listener.
+func gwListenerTransport(lis v1.Listener) string {
+	if lis.Protocol == v1.UDPProtocolType {
+		return "udp"
+	}
+	return "tcp"
 }
 
 func (c *gatewayRouteResolver) hosts(rt gatewayRoute) ([]string, error) {
@@ -382,7 +486,7 @@ func (c *gatewayRouteResolver) hosts(rt gatewayRoute) ([]string, error) {
 	// TODO: The ignore-hostname-annotation flag help says "valid only when using fqdn-template"
 	// but other sources don't check if fqdn-template is set. Which should it be?
 	if !c.src.ignoreHostnameAnnotation {
-		hostnames = append(hostnames, getHostnamesFromAnnotations(rt.Metadata().Annotations)...)
+		hostnames = append(hostnames, annotations.HostnamesFromAnnotations(rt.Metadata().Annotations)...)
 	}
 	// TODO: The combine-fqdn-annotation flag is similarly vague.
 	if c.src.fqdnTemplate != nil && (len(hostnames) == 0 || c.src.combineFQDNAnnotation) {
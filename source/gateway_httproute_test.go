@@ -1119,6 +1119,64 @@ func TestGatewayHTTPRouteSourceEndpoints(t *testing.T) {
 				newTestEndpoint("test.example.internal", "A", "4.3.2.1", "2.3.4.5"),
 			},
 		},
+		{
+			title: "ListenerPortRecords",
+			config: Config{
+				GatewayListenerPortRecords: []string{endpoint.RecordTypeSRV, endpoint.RecordTypeHTTPS},
+			},
+			namespaces: namespaces("default"),
+			gateways: []*v1beta1.Gateway{{
+				ObjectMeta: objectMeta("default", "test"),
+				Spec: v1.GatewaySpec{
+					Listeners: []v1.Listener{
+						{Name: "http", Protocol: v1.HTTPProtocolType, Port: 80},
+						{Name: "https-alt", Protocol: v1.HTTPSProtocolType, Port: 8443},
+						{Name: "http-alt", Protocol: v1.HTTPProtocolType, Port: 8080},
+					},
+				},
+				Status: gatewayStatus("1.2.3.4"),
+			}},
+			routes: []*v1beta1.HTTPRoute{{
+				ObjectMeta: objectMeta("default", "test"),
+				Spec: v1.HTTPRouteSpec{
+					Hostnames: hostnames("test.example.internal", "*.wildcard.example.internal"),
+				},
+				Status: httpRouteStatus(gwParentRef("default", "test")),
+			}},
+			endpoints: []*endpoint.Endpoint{
+				newTestEndpoint("test.example.internal", "A", "1.2.3.4"),
+				newTestEndpoint("_http._tcp.test.example.internal", "SRV", "0 50 8080 test.example.internal"),
+				newTestEndpoint("_https._tcp.test.example.internal", "SRV", "0 50 8443 test.example.internal"),
+				newTestEndpoint("test.example.internal", "HTTPS", "1 . port=8443"),
+				newTestEndpoint("*.wildcard.example.internal", "A", "1.2.3.4"),
+				newTestEndpoint("*.wildcard.example.internal", "HTTPS", "1 . port=8443"),
+			},
+		},
+		{
+			title: "ListenerPortRecordsWithHostnameTarget",
+			config: Config{
+				GatewayListenerPortRecords: []string{endpoint.RecordTypeSRV, endpoint.RecordTypeHTTPS},
+			},
+			namespaces: namespaces("default"),
+			gateways: []*v1beta1.Gateway{{
+				ObjectMeta: objectMeta("default", "test"),
+				Spec: v1.GatewaySpec{
+					Listeners: []v1.Listener{{Name: "https-alt", Protocol: v1.HTTPSProtocolType, Port: 8443}},
+				},
+				Status: gatewayStatus("lb.example.com"),
+			}},
+			routes: []*v1beta1.HTTPRoute{{
+				ObjectMeta: objectMeta("default", "test"),
+				Spec: v1.HTTPRouteSpec{
+					Hostnames: hostnames("test.example.internal"),
+				},
+				Status: httpRouteStatus(gwParentRef("default", "test")),
+			}},
+			endpoints: []*endpoint.Endpoint{
+				newTestEndpoint("test.example.internal", "CNAME", "lb.example.com"),
+				newTestEndpoint("_https._tcp.test.example.internal", "SRV", "0 50 8443 test.example.internal"),
+			},
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -91,6 +91,38 @@ func TestGatewayMatchingHost(t *testing.T) {
 			b:    "test.example.net",
 			ok:   false,
 		},
+		{
+			desc: "wildcard-matches-label-boundary",
+			a:    "*.example.net",
+			b:    "testexample.net",
+			ok:   false,
+		},
+		{
+			desc: "wildcard-matches-longer-wildcard",
+			a:    "*.example.net",
+			b:    "*.test.example.net",
+			host: "*.test.example.net",
+			ok:   true,
+		},
+		{
+			desc: "wildcard-matches-wildcard",
+			a:    "*.example.net",
+			b:    "*.example.net",
+			host: "*.example.net",
+			ok:   true,
+		},
+		{
+			desc: "wildcard-doesnt-match-other-wildcard",
+			a:    "*.example.net",
+			b:    "*.example.org",
+			ok:   false,
+		},
+		{
+			desc: "different-hosts-dont-match",
+			a:    "test.example.net",
+			b:    "other.example.net",
+			ok:   false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
@@ -162,6 +194,57 @@ func TestGatewayMatchingProtocol(t *testing.T) {
 	}
 }
 
+func TestGatewayListenerSRVRecord(t *testing.T) {
+	tests := []struct {
+		desc      string
+		lis       v1.Listener
+		standard  bool
+		service   string
+		transport string
+	}{
+		{
+			desc:      "http-default-port",
+			lis:       v1.Listener{Name: "web", Protocol: v1.HTTPProtocolType, Port: 80},
+			standard:  true,
+			service:   "http",
+			transport: "tcp",
+		},
+		{
+			desc:      "https-custom-port",
+			lis:       v1.Listener{Name: "web", Protocol: v1.HTTPSProtocolType, Port: 8443},
+			service:   "https",
+			transport: "tcp",
+		},
+		{
+			desc:      "tls-default-port",
+			lis:       v1.Listener{Name: "imaps", Protocol: v1.TLSProtocolType, Port: 443},
+			standard:  true,
+			service:   "imaps",
+			transport: "tcp",
+		},
+		{
+			desc:      "udp",
+			lis:       v1.Listener{Name: "dns", Protocol: v1.UDPProtocolType, Port: 53},
+			service:   "dns",
+			transport: "udp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if standard := gwStandardPort(tt.lis); standard != tt.standard {
+				t.Errorf("gwStandardPort(%v); got: %v; want: %v", tt.lis, standard, tt.standard)
+			}
+			if service := gwListenerService(tt.lis); service != tt.service {
+				t.Errorf("gwListenerService(%v); got: %q; want: %q", tt.lis, service, tt.service)
+			}
+			if transport := gwListenerTransport(tt.lis); transport != tt.transport {
+				t.Errorf("gwListenerTransport(%v); got: %q; want: %q", tt.lis, transport, tt.transport)
+			}
+		})
+	}
+}
+
 func TestIsDNS1123Domain(t *testing.T) {
 	tests := []struct {
 		desc string
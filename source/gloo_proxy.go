@@ -20,16 +20,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
 )
 
 var (
@@ -45,6 +49,9 @@ var (
 	}
 )
 
+// glooProxyIDLabel is the label Gloo sets on the Service of a gateway proxy, with the name of its Proxy as value.
+const glooProxyIDLabel = "gateway-proxy-id"
+
 // Basic redefinition of "Proxy" CRD : https://github.com/solo-io/gloo/blob/v1.4.6/projects/gloo/pkg/api/v1/proxy.pb.go
 type proxy struct {
 	metav1.TypeMeta `json:",inline"`
@@ -133,8 +140,11 @@ func (gs *glooSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, erro
 				return nil, err
 			}
 			log.Debugf("Gloo: Find %s proxy", proxy.Metadata.Name)
+			if isExcluded(proxy.Metadata.Annotations, "proxy", proxy.Metadata.Namespace, proxy.Metadata.Name) {
+				continue
+			}
 
-			proxyTargets := getTargetsFromTargetAnnotation(proxy.Metadata.Annotations)
+			proxyTargets := annotations.TargetsFromTargetAnnotation(proxy.Metadata.Annotations)
 			if len(proxyTargets) == 0 {
 				proxyTargets, err = gs.proxyTargets(ctx, proxy.Metadata.Name, ns)
 				if err != nil {
@@ -161,32 +171,47 @@ func (gs *glooSource) generateEndpointsFromProxy(ctx context.Context, proxy *pro
 
 	for _, listener := range proxy.Spec.Listeners {
 		for _, virtualHost := range listener.HTTPListener.VirtualHosts {
-			annotations, err := gs.annotationsFromProxySource(ctx, virtualHost)
+			annots, sniDomains, err := gs.proxySourceMetadata(ctx, virtualHost)
 			if err != nil {
 				return nil, err
 			}
-			ttl := getTTLFromAnnotations(annotations, resource)
-			providerSpecific, setIdentifier := getProviderSpecificAnnotations(annotations)
-			for _, domain := range virtualHost.Domains {
-				endpoints = append(endpoints, endpointsForHostname(strings.TrimSuffix(domain, "."), targets, ttl, providerSpecific, setIdentifier, "")...)
+			ttl := annotations.TTLFromAnnotations(annots, resource)
+			providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(annots)
+			var hostnames []string
+			for _, domain := range append(slices.Clone(virtualHost.Domains), sniDomains...) {
+				// the catch-all domain of a virtual service has no DNS name
+				if domain = strings.TrimSuffix(domain, "."); domain != "*" && !slices.Contains(hostnames, domain) {
+					hostnames = append(hostnames, domain)
+				}
+			}
+			for _, hostname := range hostnames {
+				endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, resource)...)
 			}
 		}
 	}
 	return endpoints, nil
 }
 
-func (gs *glooSource) annotationsFromProxySource(ctx context.Context, virtualHost proxyVirtualHost) (map[string]string, error) {
+// proxySourceMetadata returns the annotations of the sources of a virtual host, e.g. its VirtualService, and the
+// SNI domains of their SSL config, which name the virtual host when its domain is the catch-all "*".
+func (gs *glooSource) proxySourceMetadata(ctx context.Context, virtualHost proxyVirtualHost) (map[string]string, []string, error) {
 	annotations := map[string]string{}
+	var sniDomains []string
+	addSource := func(source *unstructured.Unstructured) {
+		for key, value := range source.GetAnnotations() {
+			annotations[key] = value
+		}
+		domains, _, _ := unstructured.NestedStringSlice(source.Object, "spec", "sslConfig", "sniDomains")
+		sniDomains = append(sniDomains, domains...)
+	}
 	for _, src := range virtualHost.Metadata.Source {
 		kind := sourceKind(src.Kind)
 		if kind != nil {
 			source, err := gs.dynamicKubeClient.Resource(*kind).Namespace(src.Namespace).Get(ctx, src.Name, metav1.GetOptions{})
 			if err != nil {
-				return nil, err
-			}
-			for key, value := range source.GetAnnotations() {
-				annotations[key] = value
+				return nil, nil, err
 			}
+			addSource(source)
 		}
 	}
 	for _, src := range virtualHost.MetadataStatic.Source {
@@ -194,18 +219,16 @@ func (gs *glooSource) annotationsFromProxySource(ctx context.Context, virtualHos
 		if kind != nil {
 			source, err := gs.dynamicKubeClient.Resource(*kind).Namespace(src.ResourceRef.Namespace).Get(ctx, src.ResourceRef.Name, metav1.GetOptions{})
 			if err != nil {
-				return nil, err
-			}
-			for key, value := range source.GetAnnotations() {
-				annotations[key] = value
+				return nil, nil, err
 			}
+			addSource(source)
 		}
 	}
-	return annotations, nil
+	return annotations, sniDomains, nil
 }
 
 func (gs *glooSource) proxyTargets(ctx context.Context, name string, namespace string) (endpoint.Targets, error) {
-	svc, err := gs.kubeClient.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	svc, err := gs.proxyService(ctx, name, namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -227,6 +250,29 @@ func (gs *glooSource) proxyTargets(ctx context.Context, name string, namespace s
 	return targets, nil
 }
 
+// proxyService returns the Service of the gateway proxy of a Proxy. With several gateway proxies, they may be deployed
+// in other namespaces than the Proxy, so the Service is looked up by its gateway-proxy-id label in all namespaces,
+// preferring the namespace of the Proxy. Services without the label are found by the name of the Proxy.
+func (gs *glooSource) proxyService(ctx context.Context, name string, namespace string) (*corev1.Service, error) {
+	services, err := gs.kubeClient.CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.Set{glooProxyIDLabel: name}.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(services.Items) == 0 {
+		return gs.kubeClient.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+	if i := slices.IndexFunc(services.Items, func(svc corev1.Service) bool { return svc.Namespace == namespace }); i >= 0 {
+		return &services.Items[i], nil
+	}
+	if len(services.Items) > 1 {
+		log.Warnf("Gloo[%s]: Found %d gateway proxy services in other namespaces, using %s/%s", name, len(services.Items),
+			services.Items[0].Namespace, services.Items[0].Name)
+	}
+	return &services.Items[0], nil
+}
+
 func sourceKind(kind string) *schema.GroupVersionResource {
 	switch kind {
 	case "*v1.VirtualService":
@@ -22,6 +22,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -488,7 +489,7 @@ func TestGlooSource(t *testing.T) {
 			Targets:          []string{internalProxySvc.Status.LoadBalancer.Ingress[0].IP, internalProxySvc.Status.LoadBalancer.Ingress[1].IP, internalProxySvc.Status.LoadBalancer.Ingress[2].IP},
 			RecordType:       endpoint.RecordTypeA,
 			RecordTTL:        0,
-			Labels:           endpoint.Labels{},
+			Labels:           endpoint.Labels{endpoint.ResourceLabelKey: "proxy/gloo-system/internal"},
 			ProviderSpecific: endpoint.ProviderSpecific{},
 		},
 		{
@@ -496,7 +497,7 @@ func TestGlooSource(t *testing.T) {
 			Targets:          []string{internalProxySvc.Status.LoadBalancer.Ingress[0].IP, internalProxySvc.Status.LoadBalancer.Ingress[1].IP, internalProxySvc.Status.LoadBalancer.Ingress[2].IP},
 			RecordType:       endpoint.RecordTypeA,
 			RecordTTL:        0,
-			Labels:           endpoint.Labels{},
+			Labels:           endpoint.Labels{endpoint.ResourceLabelKey: "proxy/gloo-system/internal"},
 			ProviderSpecific: endpoint.ProviderSpecific{},
 		},
 		{
@@ -505,7 +506,7 @@ func TestGlooSource(t *testing.T) {
 			RecordType:    endpoint.RecordTypeA,
 			SetIdentifier: "identifier",
 			RecordTTL:     42,
-			Labels:        endpoint.Labels{},
+			Labels:        endpoint.Labels{endpoint.ResourceLabelKey: "proxy/gloo-system/internal"},
 			ProviderSpecific: endpoint.ProviderSpecific{
 				endpoint.ProviderSpecificProperty{
 					Name:  "aws/geolocation-country-code",
@@ -518,7 +519,7 @@ func TestGlooSource(t *testing.T) {
 			Targets:          []string{externalProxySvc.Status.LoadBalancer.Ingress[0].Hostname, externalProxySvc.Status.LoadBalancer.Ingress[1].Hostname, externalProxySvc.Status.LoadBalancer.Ingress[2].Hostname},
 			RecordType:       endpoint.RecordTypeCNAME,
 			RecordTTL:        0,
-			Labels:           endpoint.Labels{},
+			Labels:           endpoint.Labels{endpoint.ResourceLabelKey: "proxy/gloo-system/external"},
 			ProviderSpecific: endpoint.ProviderSpecific{},
 		},
 		{
@@ -527,7 +528,7 @@ func TestGlooSource(t *testing.T) {
 			RecordType:    endpoint.RecordTypeCNAME,
 			SetIdentifier: "identifier-external",
 			RecordTTL:     24,
-			Labels:        endpoint.Labels{},
+			Labels:        endpoint.Labels{endpoint.ResourceLabelKey: "proxy/gloo-system/external"},
 			ProviderSpecific: endpoint.ProviderSpecific{
 				endpoint.ProviderSpecificProperty{
 					Name:  "aws/geolocation-country-code",
@@ -540,7 +541,7 @@ func TestGlooSource(t *testing.T) {
 			Targets:          []string{proxyMetadataStaticSvc.Status.LoadBalancer.Ingress[0].IP, proxyMetadataStaticSvc.Status.LoadBalancer.Ingress[1].IP, proxyMetadataStaticSvc.Status.LoadBalancer.Ingress[2].IP},
 			RecordType:       endpoint.RecordTypeA,
 			RecordTTL:        0,
-			Labels:           endpoint.Labels{},
+			Labels:           endpoint.Labels{endpoint.ResourceLabelKey: "proxy/gloo-system/internal-static"},
 			ProviderSpecific: endpoint.ProviderSpecific{},
 		},
 		{
@@ -548,7 +549,7 @@ func TestGlooSource(t *testing.T) {
 			Targets:          []string{proxyMetadataStaticSvc.Status.LoadBalancer.Ingress[0].IP, proxyMetadataStaticSvc.Status.LoadBalancer.Ingress[1].IP, proxyMetadataStaticSvc.Status.LoadBalancer.Ingress[2].IP},
 			RecordType:       endpoint.RecordTypeA,
 			RecordTTL:        0,
-			Labels:           endpoint.Labels{},
+			Labels:           endpoint.Labels{endpoint.ResourceLabelKey: "proxy/gloo-system/internal-static"},
 			ProviderSpecific: endpoint.ProviderSpecific{},
 		},
 		{
@@ -557,7 +558,7 @@ func TestGlooSource(t *testing.T) {
 			RecordType:    endpoint.RecordTypeA,
 			SetIdentifier: "identifier",
 			RecordTTL:     420,
-			Labels:        endpoint.Labels{},
+			Labels:        endpoint.Labels{endpoint.ResourceLabelKey: "proxy/gloo-system/internal-static"},
 			ProviderSpecific: endpoint.ProviderSpecific{
 				endpoint.ProviderSpecificProperty{
 					Name:  "aws/geolocation-country-code",
@@ -569,7 +570,7 @@ func TestGlooSource(t *testing.T) {
 			DNSName:          "i.test",
 			Targets:          []string{"203.2.45.7"},
 			RecordType:       endpoint.RecordTypeA,
-			Labels:           endpoint.Labels{},
+			Labels:           endpoint.Labels{endpoint.ResourceLabelKey: "proxy/gloo-system/target-ann"},
 			ProviderSpecific: endpoint.ProviderSpecific{},
 		},
 		{
@@ -578,7 +579,7 @@ func TestGlooSource(t *testing.T) {
 			RecordType:    endpoint.RecordTypeA,
 			SetIdentifier: "identifier-annotated",
 			RecordTTL:     460,
-			Labels:        endpoint.Labels{},
+			Labels:        endpoint.Labels{endpoint.ResourceLabelKey: "proxy/gloo-system/target-ann"},
 			ProviderSpecific: endpoint.ProviderSpecific{
 				endpoint.ProviderSpecificProperty{
 					Name:  "aws/geolocation-country-code",
@@ -588,3 +589,106 @@ func TestGlooSource(t *testing.T) {
 		},
 	})
 }
+
+func TestGlooSourceProxyInOtherNamespace(t *testing.T) {
+	t.Parallel()
+
+	fakeKubernetesClient := fakeKube.NewSimpleClientset()
+	fakeDynamicClient := fakeDynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			proxyGVR: "ProxyList",
+		})
+
+	publicProxy := proxy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: proxyGVR.GroupVersion().String(),
+			Kind:       "Proxy",
+		},
+		Metadata: metav1.ObjectMeta{
+			Name:      "public-gw",
+			Namespace: defaultGlooNamespace,
+		},
+		Spec: proxySpec{
+			Listeners: []proxySpecListener{
+				{
+					HTTPListener: proxySpecHTTPListener{
+						VirtualHosts: []proxyVirtualHost{
+							{
+								Domains: []string{"*"},
+								Metadata: proxyVirtualHostMetadata{
+									Source: []proxyVirtualHostMetadataSource{
+										{
+											Kind:      "*v1.VirtualService",
+											Name:      "public-svc",
+											Namespace: "public",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	publicProxyAsJSON, err := json.Marshal(publicProxy)
+	require.NoError(t, err)
+	publicProxyUnstructured := unstructured.Unstructured{}
+	require.NoError(t, publicProxyUnstructured.UnmarshalJSON(publicProxyAsJSON))
+	_, err = fakeDynamicClient.Resource(proxyGVR).Namespace(defaultGlooNamespace).Create(context.Background(), &publicProxyUnstructured, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	virtualService := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": virtualServiceGVR.GroupVersion().String(),
+		"kind":       "VirtualService",
+		"metadata": map[string]interface{}{
+			"name":      "public-svc",
+			"namespace": "public",
+		},
+		"spec": map[string]interface{}{
+			"sslConfig": map[string]interface{}{
+				"sniDomains": []interface{}{"k.test", "l.test"},
+			},
+		},
+	}}
+	_, err = fakeDynamicClient.Resource(virtualServiceGVR).Namespace("public").Create(context.Background(), &virtualService, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// the gateway proxy is deployed in another namespace than the Proxy
+	_, err = fakeKubernetesClient.CoreV1().Services("gloo-public").Create(context.Background(), &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "public-gw",
+			Namespace: "gloo-public",
+			Labels:    map[string]string{glooProxyIDLabel: "public-gw"},
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeLoadBalancer,
+		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}},
+			},
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	source, err := NewGlooSource(fakeDynamicClient, fakeKubernetesClient, []string{defaultGlooNamespace})
+	require.NoError(t, err)
+
+	endpoints, err := source.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{
+			DNSName:    "k.test",
+			Targets:    endpoint.Targets{"203.0.113.10"},
+			RecordType: endpoint.RecordTypeA,
+			Labels:     endpoint.Labels{endpoint.ResourceLabelKey: "proxy/gloo-system/public-gw"},
+		},
+		{
+			DNSName:    "l.test",
+			Targets:    endpoint.Targets{"203.0.113.10"},
+			RecordType: endpoint.RecordTypeA,
+			Labels:     endpoint.Labels{endpoint.ResourceLabelKey: "proxy/gloo-system/public-gw"},
+		},
+	})
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// httpSourceRecordTypes are the record types accepted from the http source.
+var httpSourceRecordTypes = []string{
+	endpoint.RecordTypeA,
+	endpoint.RecordTypeAAAA,
+	endpoint.RecordTypeCNAME,
+	endpoint.RecordTypeTXT,
+	endpoint.RecordTypeSRV,
+	endpoint.RecordTypeNS,
+	endpoint.RecordTypePTR,
+	endpoint.RecordTypeMX,
+	endpoint.RecordTypeNAPTR,
+	endpoint.RecordTypeHTTPS,
+}
+
+// httpSource is an implementation of Source that polls a list of endpoints in JSON from a URL,
+// for example a service registry.
+type httpSource struct {
+	client  *http.Client
+	url     string
+	headers http.Header
+}
+
+// NewHTTPSource creates a new httpSource fetching the endpoints from the given URL. The headers,
+// e.g. an Authorization header, have the format "Name: value" and are sent with every request.
+func NewHTTPSource(u string, headers []string, requestTimeout time.Duration) (Source, error) {
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("invalid http source URL %q: the scheme must be http or https", u)
+	}
+
+	httpHeaders := http.Header{}
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, errors.New("invalid http source header: the format must be 'Name: value'")
+		}
+		httpHeaders.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	return &httpSource{
+		client:  &http.Client{Timeout: requestTimeout},
+		url:     parsedURL.String(),
+		headers: httpHeaders,
+	}, nil
+}
+
+// Endpoints fetches the endpoints from the URL. If the document is not valid, it returns an error instead
+// of the valid endpoints, so that the records of the invalid endpoints are not deleted.
+func (hs *httpSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, hs.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range hs.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := hs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch endpoints from %s: %w", req.URL.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch endpoints from %s: unexpected status code %d", req.URL.Redacted(), resp.StatusCode)
+	}
+
+	var endpoints []*endpoint.Endpoint
+	decoder := json.NewDecoder(resp.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&endpoints); err != nil {
+		return nil, fmt.Errorf("failed to decode endpoints from %s: %w", req.URL.Redacted(), err)
+	}
+
+	if err := validateHTTPSourceEndpoints(endpoints); err != nil {
+		return nil, fmt.Errorf("invalid endpoints from %s: %w", req.URL.Redacted(), err)
+	}
+
+	for _, ep := range endpoints {
+		if ep.Labels == nil {
+			ep.Labels = endpoint.NewLabels()
+		}
+		if _, ok := ep.Labels[endpoint.ResourceLabelKey]; !ok {
+			ep.Labels[endpoint.ResourceLabelKey] = "http/" + ep.DNSName
+		}
+	}
+	endpoints = withoutExcludedEndpoints(endpoints, "http")
+
+	log.Debugf("Endpoints fetched from %s: %v", req.URL.Redacted(), endpoints)
+
+	return endpoints, nil
+}
+
+// validateHTTPSourceEndpoints checks the DNS name, record type, TTL and targets of every endpoint.
+func validateHTTPSourceEndpoints(endpoints []*endpoint.Endpoint) error {
+	var errs []error
+	for i, ep := range endpoints {
+		invalid := func(err error) {
+			errs = append(errs, fmt.Errorf("endpoint %d: %w", i, err))
+		}
+		if ep == nil {
+			invalid(errors.New("endpoint is null"))
+			continue
+		}
+		if ep.DNSName == "" {
+			invalid(errors.New("dnsName is required"))
+			continue
+		}
+		if !slices.Contains(httpSourceRecordTypes, ep.RecordType) {
+			invalid(fmt.Errorf("record type %q of %q is not supported", ep.RecordType, ep.DNSName))
+			continue
+		}
+		if ep.RecordTTL < 0 {
+			invalid(fmt.Errorf("recordTTL of %q is negative", ep.DNSName))
+		}
+		if len(ep.Targets) == 0 {
+			invalid(fmt.Errorf("%s record %q has no targets", ep.RecordType, ep.DNSName))
+			continue
+		}
+		if _, err := endpoint.NewValidatedEndpoint(ep.DNSName, ep.RecordType, ep.RecordTTL, ep.Targets...); err != nil {
+			invalid(err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AddEventHandler does nothing, the URL is polled at every synchronization.
+func (hs *httpSource) AddEventHandler(ctx context.Context, handler func()) {
+}
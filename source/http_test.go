@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// This is a compile-time validation that httpSource is a Source.
+var _ Source = &httpSource{}
+
+func TestHTTPSourceEndpoints(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		title       string
+		status      int
+		body        string
+		expected    []*endpoint.Endpoint
+		expectError string
+	}{
+		{
+			title:  "endpoints",
+			status: http.StatusOK,
+			body:   `[{"dnsName":"vm-1.example.org","targets":["10.0.0.1"],"recordType":"A","recordTTL":300},{"dnsName":"app.example.org","targets":["vm-1.example.org"],"recordType":"CNAME","labels":{"resource":"registry/app"}}]`,
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "vm-1.example.org",
+					Targets:    endpoint.Targets{"10.0.0.1"},
+					RecordType: endpoint.RecordTypeA,
+					RecordTTL:  300,
+					Labels:     endpoint.Labels{endpoint.ResourceLabelKey: "http/vm-1.example.org"},
+				},
+				{
+					DNSName:    "app.example.org",
+					Targets:    endpoint.Targets{"vm-1.example.org"},
+					RecordType: endpoint.RecordTypeCNAME,
+					Labels:     endpoint.Labels{endpoint.ResourceLabelKey: "registry/app"},
+				},
+			},
+		},
+		{
+			title:  "excluded endpoint",
+			status: http.StatusOK,
+			body:   `[{"dnsName":"vm-1.example.org","targets":["10.0.0.1"],"recordType":"A","labels":{"external-dns.alpha.kubernetes.io/exclude":"true"}},{"dnsName":"vm-2.example.org","targets":["10.0.0.2"],"recordType":"A","labels":{"external-dns.alpha.kubernetes.io/exclude":"false"}}]`,
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "vm-2.example.org",
+					Targets:    endpoint.Targets{"10.0.0.2"},
+					RecordType: endpoint.RecordTypeA,
+					Labels:     endpoint.Labels{endpoint.ResourceLabelKey: "http/vm-2.example.org"},
+				},
+			},
+		},
+		{
+			title:    "no endpoints",
+			status:   http.StatusOK,
+			body:     `[]`,
+			expected: []*endpoint.Endpoint{},
+		},
+		{
+			title:       "server error",
+			status:      http.StatusInternalServerError,
+			expectError: "unexpected status code 500",
+		},
+		{
+			title:       "unknown field",
+			status:      http.StatusOK,
+			body:        `[{"dnsName":"vm-1.example.org","target":["10.0.0.1"],"recordType":"A"}]`,
+			expectError: `unknown field "target"`,
+		},
+		{
+			title:       "missing DNS name",
+			status:      http.StatusOK,
+			body:        `[{"targets":["10.0.0.1"],"recordType":"A"}]`,
+			expectError: "endpoint 0: dnsName is required",
+		},
+		{
+			title:       "unsupported record type",
+			status:      http.StatusOK,
+			body:        `[{"dnsName":"vm-1.example.org","targets":["10.0.0.1"],"recordType":"SOA"}]`,
+			expectError: `record type "SOA" of "vm-1.example.org" is not supported`,
+		},
+		{
+			title:       "negative TTL",
+			status:      http.StatusOK,
+			body:        `[{"dnsName":"vm-1.example.org","targets":["10.0.0.1"],"recordType":"A","recordTTL":-1}]`,
+			expectError: `recordTTL of "vm-1.example.org" is negative`,
+		},
+		{
+			title:       "no targets",
+			status:      http.StatusOK,
+			body:        `[{"dnsName":"vm-1.example.org","recordType":"A"}]`,
+			expectError: `A record "vm-1.example.org" has no targets`,
+		},
+		{
+			title:       "invalid target",
+			status:      http.StatusOK,
+			body:        `[{"dnsName":"ok.example.org","targets":["10.0.0.1"],"recordType":"A"},{"dnsName":"vm-1.example.org","targets":["vm-1"],"recordType":"A"}]`,
+			expectError: "endpoint 1: target \"vm-1\"",
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v1/endpoints", r.URL.Path)
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+				assert.Equal(t, []string{"a", "b"}, r.Header.Values("X-Tenant"))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			source, err := NewHTTPSource(server.URL+"/v1/endpoints", []string{"Authorization: Bearer token", "X-Tenant: a", "X-Tenant:b"}, time.Second)
+			require.NoError(t, err)
+
+			endpoints, err := source.Endpoints(context.Background())
+			if tt.expectError != "" {
+				require.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, tt.expected)
+			for _, ep := range endpoints {
+				assert.NotContains(t, ep.Labels, excludeAnnotationKey)
+			}
+		})
+	}
+}
+
+func TestNewHTTPSourceInvalidConfig(t *testing.T) {
+	_, err := NewHTTPSource("registry.example.org", nil, time.Second)
+	require.Error(t, err)
+
+	_, err = NewHTTPSource("https://registry.example.org", []string{"Bearer token"}, time.Second)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "token")
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"text/template"
@@ -27,13 +28,13 @@ import (
 	log "github.com/sirupsen/logrus"
 	networkv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/selection"
 	kubeinformers "k8s.io/client-go/informers"
 	netinformers "k8s.io/client-go/informers/networking/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
 )
 
 const (
@@ -58,6 +59,7 @@ type ingressSource struct {
 	namespace                string
 	annotationFilter         string
 	ingressClassNames        []string
+	excludedIngressClasses   []string
 	fqdnTemplate             *template.Template
 	combineFQDNAnnotation    bool
 	ignoreHostnameAnnotation bool
@@ -65,15 +67,21 @@ type ingressSource struct {
 	ignoreIngressTLSSpec     bool
 	ignoreIngressRulesSpec   bool
 	labelSelector            labels.Selector
+	nsDefaults               *namespaceDefaults
 }
 
 // NewIngressSource creates a new ingressSource with the given config.
-func NewIngressSource(ctx context.Context, kubeClient kubernetes.Interface, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool, ignoreHostnameAnnotation bool, ignoreIngressTLSSpec bool, ignoreIngressRulesSpec bool, labelSelector labels.Selector, ingressClassNames []string) (Source, error) {
+func NewIngressSource(ctx context.Context, kubeClient kubernetes.Interface, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool, ignoreHostnameAnnotation bool, ignoreIngressTLSSpec bool, ignoreIngressRulesSpec bool, labelSelector labels.Selector, ingressClassNames []string, namespaceDefaultAnnotations bool) (Source, error) {
 	tmpl, err := parseTemplate(fqdnTemplate)
 	if err != nil {
 		return nil, err
 	}
 
+	includedIngressClasses, excludedIngressClasses, err := parseIngressClasses(ingressClassNames)
+	if err != nil {
+		return nil, err
+	}
+
 	// ensure that ingress class is only set in either the ingressClassNames or
 	// annotationFilter but not both
 	if ingressClassNames != nil && annotationFilter != "" {
@@ -109,11 +117,17 @@ func NewIngressSource(ctx context.Context, kubeClient kubernetes.Interface, name
 		return nil, err
 	}
 
+	nsDefaults, err := newNamespaceDefaults(ctx, kubeClient, namespaceDefaultAnnotations)
+	if err != nil {
+		return nil, err
+	}
+
 	sc := &ingressSource{
 		client:                   kubeClient,
 		namespace:                namespace,
 		annotationFilter:         annotationFilter,
-		ingressClassNames:        ingressClassNames,
+		ingressClassNames:        includedIngressClasses,
+		excludedIngressClasses:   excludedIngressClasses,
 		fqdnTemplate:             tmpl,
 		combineFQDNAnnotation:    combineFqdnAnnotation,
 		ignoreHostnameAnnotation: ignoreHostnameAnnotation,
@@ -121,6 +135,7 @@ func NewIngressSource(ctx context.Context, kubeClient kubernetes.Interface, name
 		ignoreIngressTLSSpec:     ignoreIngressTLSSpec,
 		ignoreIngressRulesSpec:   ignoreIngressRulesSpec,
 		labelSelector:            labelSelector,
+		nsDefaults:               nsDefaults,
 	}
 	return sc, nil
 }
@@ -137,22 +152,27 @@ func (sc *ingressSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 		return nil, err
 	}
 
-	ingresses, err = sc.filterByIngressClass(ingresses)
-	if err != nil {
-		return nil, err
-	}
+	ingresses = sc.filterByIngressClass(ingresses)
 
 	endpoints := []*endpoint.Endpoint{}
 
 	for _, ing := range ingresses {
+		if isExcluded(ing.Annotations, "ingress", ing.Namespace, ing.Name) {
+			continue
+		}
+
 		// Check controller annotation to see if we are responsible.
-		controller, ok := ing.Annotations[controllerAnnotationKey]
-		if ok && controller != controllerAnnotationValue {
+		if controller, foreign := annotations.ForeignController(ing.Annotations); foreign {
 			log.Debugf("Skipping ingress %s/%s because controller value does not match, found: %s, required: %s",
 				ing.Namespace, ing.Name, controller, controllerAnnotationValue)
 			continue
 		}
 
+		if annots, ok := sc.nsDefaults.annotations(ing.Namespace, ing.Annotations); ok {
+			ing = ing.DeepCopy()
+			ing.Annotations = annots
+		}
+
 		ingEndpoints := endpointsFromIngress(ing, sc.ignoreHostnameAnnotation, sc.ignoreIngressTLSSpec, sc.ignoreIngressRulesSpec)
 
 		// apply template if host is missing on ingress
@@ -171,7 +191,15 @@ func (sc *ingressSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 		}
 
 		log.Debugf("Endpoints generated from ingress: %s/%s: %v", ing.Namespace, ing.Name, ingEndpoints)
+		ingEndpoints = append(ingEndpoints, txtEndpoints(ing.Annotations, ingEndpoints)...)
 		sc.setDualstackLabel(ing, ingEndpoints)
+		setDescriptionLabel(ing.Annotations, ingEndpoints)
+		setResyncLabel(ing.Annotations, ingEndpoints)
+		setZoneIDLabel(ing.Annotations, ingEndpoints)
+		setVisibilityLabel(ing.Annotations, ingEndpoints)
+		setHandoffLabel(ing.Annotations, ingEndpoints)
+		setDependsOnLabel(ing.Annotations, ingEndpoints)
+		setCustomLabels(ing.Annotations, ingEndpoints)
 		endpoints = append(endpoints, ingEndpoints...)
 	}
 
@@ -190,14 +218,15 @@ func (sc *ingressSource) endpointsFromTemplate(ing *networkv1.Ingress) ([]*endpo
 
 	resource := fmt.Sprintf("ingress/%s/%s", ing.Namespace, ing.Name)
 
-	ttl := getTTLFromAnnotations(ing.Annotations, resource)
+	ttl := annotations.TTLFromAnnotations(ing.Annotations, resource)
 
-	targets := getTargetsFromTargetAnnotation(ing.Annotations)
+	targets := annotations.TargetsFromTargetAnnotation(ing.Annotations)
 	if len(targets) == 0 {
 		targets = targetsFromIngressStatus(ing.Status)
 	}
+	targets = annotations.TargetsOfIPFamilies(ing.Annotations, targets)
 
-	providerSpecific, setIdentifier := getProviderSpecificAnnotations(ing.Annotations)
+	providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(ing.Annotations)
 
 	var endpoints []*endpoint.Endpoint
 	for _, hostname := range hostnames {
@@ -230,48 +259,61 @@ func (sc *ingressSource) filterByAnnotations(ingresses []*networkv1.Ingress) ([]
 	return filteredList, nil
 }
 
-// filterByIngressClass filters a list of ingresses based on a required ingress
-// class
-func (sc *ingressSource) filterByIngressClass(ingresses []*networkv1.Ingress) ([]*networkv1.Ingress, error) {
-	// if no class filter is specified then there's nothing to do
-	if len(sc.ingressClassNames) == 0 {
-		return ingresses, nil
+// parseIngressClasses splits the values of --ingress-class, which may be comma separated lists, into the
+// required ingress classes and the ingress classes excluded with a leading '!', e.g. "nginx,haproxy,!internal".
+func parseIngressClasses(values []string) ([]string, []string, error) {
+	var included, excluded []string
+	for _, value := range values {
+		for _, class := range strings.Split(value, ",") {
+			class = strings.TrimSpace(class)
+			negated := strings.HasPrefix(class, "!")
+			class = strings.TrimSpace(strings.TrimPrefix(class, "!"))
+			if class == "" {
+				return nil, nil, fmt.Errorf("invalid ingress class %q: empty class name", value)
+			}
+			if negated {
+				excluded = append(excluded, class)
+			} else {
+				included = append(included, class)
+			}
+		}
 	}
+	return included, excluded, nil
+}
 
-	classNameReq, err := labels.NewRequirement(IngressClassAnnotationKey, selection.In, sc.ingressClassNames)
-	if err != nil {
-		return nil, err
+// ingressClass returns the class of an ingress, from the spec.ingressClassName field or else from the legacy
+// kubernetes.io/ingress.class annotation.
+func ingressClass(ingress *networkv1.Ingress) string {
+	if ingress.Spec.IngressClassName != nil && len(*ingress.Spec.IngressClassName) > 0 {
+		return *ingress.Spec.IngressClassName
 	}
+	return ingress.Annotations[IngressClassAnnotationKey]
+}
 
-	selector := labels.NewSelector()
-	selector = selector.Add(*classNameReq)
+// filterByIngressClass filters a list of ingresses based on the required and excluded ingress
+// classes. With required classes, ingresses without a class are discarded.
+func (sc *ingressSource) filterByIngressClass(ingresses []*networkv1.Ingress) []*networkv1.Ingress {
+	// if no class filter is specified then there's nothing to do
+	if len(sc.ingressClassNames) == 0 && len(sc.excludedIngressClasses) == 0 {
+		return ingresses
+	}
 
 	filteredList := []*networkv1.Ingress{}
 
 	for _, ingress := range ingresses {
-		var matched = false
-
-		for _, nameFilter := range sc.ingressClassNames {
-			if ingress.Spec.IngressClassName != nil && len(*ingress.Spec.IngressClassName) > 0 {
-				if nameFilter == *ingress.Spec.IngressClassName {
-					matched = true
-				}
-			} else if matchLabelSelector(selector, ingress.Annotations) {
-				matched = true
-			}
-
-			if matched {
-				filteredList = append(filteredList, ingress)
-				break
-			}
+		class := ingressClass(ingress)
+		if class != "" && slices.Contains(sc.excludedIngressClasses, class) {
+			log.Debugf("Discarding ingress %s/%s because its ingress class %s is excluded", ingress.Namespace, ingress.Name, class)
+			continue
 		}
-
-		if !matched {
+		if len(sc.ingressClassNames) > 0 && !slices.Contains(sc.ingressClassNames, class) {
 			log.Debugf("Discarding ingress %s/%s because it does not match required ingress classes %v", ingress.Namespace, ingress.Name, sc.ingressClassNames)
+			continue
 		}
+		filteredList = append(filteredList, ingress)
 	}
 
-	return filteredList, nil
+	return filteredList
 }
 
 func (sc *ingressSource) setDualstackLabel(ingress *networkv1.Ingress, endpoints []*endpoint.Endpoint) {
@@ -288,15 +330,16 @@ func (sc *ingressSource) setDualstackLabel(ingress *networkv1.Ingress, endpoints
 func endpointsFromIngress(ing *networkv1.Ingress, ignoreHostnameAnnotation bool, ignoreIngressTLSSpec bool, ignoreIngressRulesSpec bool) []*endpoint.Endpoint {
 	resource := fmt.Sprintf("ingress/%s/%s", ing.Namespace, ing.Name)
 
-	ttl := getTTLFromAnnotations(ing.Annotations, resource)
+	ttl := annotations.TTLFromAnnotations(ing.Annotations, resource)
 
-	targets := getTargetsFromTargetAnnotation(ing.Annotations)
+	targets := annotations.TargetsFromTargetAnnotation(ing.Annotations)
 
 	if len(targets) == 0 {
 		targets = targetsFromIngressStatus(ing.Status)
 	}
+	targets = annotations.TargetsOfIPFamilies(ing.Annotations, targets)
 
-	providerSpecific, setIdentifier := getProviderSpecificAnnotations(ing.Annotations)
+	providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(ing.Annotations)
 
 	// Gather endpoints defined on hosts sections of the ingress
 	var definedHostsEndpoints []*endpoint.Endpoint
@@ -325,7 +368,7 @@ func endpointsFromIngress(ing *networkv1.Ingress, ignoreHostnameAnnotation bool,
 	// Gather endpoints defined on annotations in the ingress
 	var annotationEndpoints []*endpoint.Endpoint
 	if !ignoreHostnameAnnotation {
-		for _, hostname := range getHostnamesFromAnnotations(ing.Annotations) {
+		for _, hostname := range annotations.HostnamesFromAnnotations(ing.Annotations) {
 			annotationEndpoints = append(annotationEndpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, resource)...)
 		}
 	}
@@ -362,10 +405,15 @@ func targetsFromIngressStatus(status networkv1.IngressStatus) endpoint.Targets {
 	return targets
 }
 
+func (sc *ingressSource) resourceVersion() (string, bool) {
+	return informersVersion(sc.nsDefaults.withInformer(sc.ingressInformer.Informer())...)
+}
+
 func (sc *ingressSource) AddEventHandler(ctx context.Context, handler func()) {
 	log.Debug("Adding event handler for ingress")
 
 	// Right now there is no way to remove event handler from informer, see:
 	// https://github.com/kubernetes/kubernetes/issues/79610
 	sc.ingressInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+	sc.nsDefaults.addEventHandler(handler)
 }
@@ -66,6 +66,7 @@ func (suite *IngressSuite) SetupTest() {
 		false,
 		labels.Everything(),
 		[]string{},
+		false,
 	)
 	suite.NoError(err, "should initialize ingress source")
 }
@@ -139,6 +140,11 @@ func TestNewIngressSource(t *testing.T) {
 			expectError:       false,
 			ingressClassNames: []string{"internal", "external"},
 		},
+		{
+			title:             "invalid ingress class name list",
+			expectError:       true,
+			ingressClassNames: []string{"internal,!"},
+		},
 		{
 			title:             "ingress class name and annotation filter jointly specified",
 			expectError:       true,
@@ -162,6 +168,7 @@ func TestNewIngressSource(t *testing.T) {
 				false,
 				labels.Everything(),
 				ti.ingressClassNames,
+				false,
 			)
 			if ti.expectError {
 				assert.Error(t, err)
@@ -231,6 +238,21 @@ func testEndpointsFromIngress(t *testing.T) {
 				},
 			},
 		},
+		{
+			title: "dual-stack lb.IP with ip-families annotation",
+			ingress: fakeIngress{
+				dnsnames:    []string{"foo.bar"},
+				ips:         []string{"8.8.8.8", "2001:db8::1"},
+				annotations: map[string]string{ipFamiliesAnnotationKey: "ipv6"},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "foo.bar",
+					RecordType: endpoint.RecordTypeAAAA,
+					Targets:    endpoint.Targets{"2001:db8::1"},
+				},
+			},
+		},
 		{
 			title: "no rule.host",
 			ingress: fakeIngress{
@@ -431,6 +453,31 @@ func testIngressEndpoints(t *testing.T) {
 				},
 			},
 		},
+		{
+			title:           "ingress with txt annotation",
+			targetNamespace: "",
+			ingressItems: []fakeIngress{
+				{
+					name:        "fake1",
+					namespace:   namespace,
+					dnsnames:    []string{"example.org"},
+					hostnames:   []string{"lb.com"},
+					annotations: map[string]string{txtAnnotationKey: "v=spf1 -all\nsite-verification=abc"},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "example.org",
+					RecordType: endpoint.RecordTypeCNAME,
+					Targets:    endpoint.Targets{"lb.com"},
+				},
+				{
+					DNSName:    "example.org",
+					RecordType: endpoint.RecordTypeTXT,
+					Targets:    endpoint.Targets{"v=spf1 -all", "site-verification=abc"},
+				},
+			},
+		},
 		{
 			title:           "ipv6 ingress",
 			targetNamespace: "",
@@ -1352,6 +1399,89 @@ func testIngressEndpoints(t *testing.T) {
 				},
 			},
 		},
+		{
+			title:             "ingressClassName filtering with a list and an excluded class",
+			targetNamespace:   "",
+			ingressClassNames: []string{"public, dmz", "!internal"},
+			ingressItems: []fakeIngress{
+				{
+					name:             "fake-public",
+					namespace:        namespace,
+					tlsdnsnames:      [][]string{{"example.org"}},
+					ips:              []string{"1.2.3.4"},
+					ingressClassName: "public", // match
+				},
+				{
+					name:        "annotated-dmz",
+					namespace:   namespace,
+					tlsdnsnames: [][]string{{"annodmz.example.org"}},
+					ips:         []string{"4.5.6.7"},
+					annotations: map[string]string{
+						"kubernetes.io/ingress.class": "dmz", // match
+					},
+				},
+				{
+					name:             "fake-internal",
+					namespace:        namespace,
+					tlsdnsnames:      [][]string{{"int.example.org"}},
+					ips:              []string{"2.3.4.5"},
+					ingressClassName: "internal",
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"1.2.3.4"},
+				},
+				{
+					DNSName:    "annodmz.example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"4.5.6.7"},
+				},
+			},
+		},
+		{
+			title:             "ingressClassName filtering with excluded classes only",
+			targetNamespace:   "",
+			ingressClassNames: []string{"!internal"},
+			ingressItems: []fakeIngress{
+				{
+					name:        "none",
+					namespace:   namespace,
+					tlsdnsnames: [][]string{{"none.example.org"}},
+					ips:         []string{"1.0.0.0"},
+				},
+				{
+					name:             "fake-public",
+					namespace:        namespace,
+					tlsdnsnames:      [][]string{{"example.org"}},
+					ips:              []string{"1.2.3.4"},
+					ingressClassName: "public",
+				},
+				{
+					name:        "annotated-internal",
+					namespace:   namespace,
+					tlsdnsnames: [][]string{{"int.example.org"}},
+					ips:         []string{"2.3.4.5"},
+					annotations: map[string]string{
+						"kubernetes.io/ingress.class": "internal",
+					},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "none.example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"1.0.0.0"},
+				},
+				{
+					DNSName:    "example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"1.2.3.4"},
+				},
+			},
+		},
 		{
 			ingressLabelSelector: labels.SelectorFromSet(labels.Set{"app": "web-external"}),
 			title:                "ingress with matching labels",
@@ -1416,6 +1546,7 @@ func testIngressEndpoints(t *testing.T) {
 				ti.ignoreIngressRulesSpec,
 				ti.ingressLabelSelector,
 				ti.ingressClassNames,
+				false,
 			)
 			// Informer cache has all of the ingresses. Retrieve and validate their endpoints.
 			res, err := source.Endpoints(context.Background())
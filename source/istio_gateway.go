@@ -36,6 +36,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
 )
 
 // IstioGatewayIngressSource is the annotation used to determine if the gateway is implemented by an Ingress object
@@ -53,6 +54,7 @@ type gatewaySource struct {
 	fqdnTemplate             *template.Template
 	combineFQDNAnnotation    bool
 	ignoreHostnameAnnotation bool
+	labelSelector            labels.Selector
 	serviceInformer          coreinformers.ServiceInformer
 	gatewayInformer          networkingv1alpha3informer.GatewayInformer
 }
@@ -67,6 +69,7 @@ func NewIstioGatewaySource(
 	fqdnTemplate string,
 	combineFQDNAnnotation bool,
 	ignoreHostnameAnnotation bool,
+	labelSelector labels.Selector,
 ) (Source, error) {
 	tmpl, err := parseTemplate(fqdnTemplate)
 	if err != nil {
@@ -116,6 +119,7 @@ func NewIstioGatewaySource(
 		fqdnTemplate:             tmpl,
 		combineFQDNAnnotation:    combineFQDNAnnotation,
 		ignoreHostnameAnnotation: ignoreHostnameAnnotation,
+		labelSelector:            labelSelector,
 		serviceInformer:          serviceInformer,
 		gatewayInformer:          gatewayInformer,
 	}, nil
@@ -124,7 +128,7 @@ func NewIstioGatewaySource(
 // Endpoints returns endpoint objects for each host-target combination that should be processed.
 // Retrieves all gateway resources in the source's namespace(s).
 func (sc *gatewaySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
-	gwList, err := sc.istioClient.NetworkingV1alpha3().Gateways(sc.namespace).List(ctx, metav1.ListOptions{})
+	gwList, err := sc.istioClient.NetworkingV1alpha3().Gateways(sc.namespace).List(ctx, metav1.ListOptions{LabelSelector: sc.labelSelector.String()})
 	if err != nil {
 		return nil, err
 	}
@@ -138,9 +142,12 @@ func (sc *gatewaySource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 	var endpoints []*endpoint.Endpoint
 
 	for _, gateway := range gateways {
+		if isExcluded(gateway.Annotations, "gateway", gateway.Namespace, gateway.Name) {
+			continue
+		}
+
 		// Check controller annotation to see if we are responsible.
-		controller, ok := gateway.Annotations[controllerAnnotationKey]
-		if ok && controller != controllerAnnotationValue {
+		if controller, foreign := annotations.ForeignController(gateway.Annotations); foreign {
 			log.Debugf("Skipping gateway %s/%s because controller value does not match, found: %s, required: %s",
 				gateway.Namespace, gateway.Name, controller, controllerAnnotationValue)
 			continue
@@ -196,6 +203,8 @@ func (sc *gatewaySource) AddEventHandler(ctx context.Context, handler func()) {
 	log.Debug("Adding event handler for Istio Gateway")
 
 	sc.gatewayInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+	// The targets come from the load balancers of the ingress gateway Services.
+	sc.serviceInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
 }
 
 // filterByAnnotations filters a list of configs by a given annotation selector.
@@ -267,7 +276,7 @@ func (sc *gatewaySource) targetsFromIngress(ctx context.Context, ingressStr stri
 }
 
 func (sc *gatewaySource) targetsFromGateway(ctx context.Context, gateway *networkingv1alpha3.Gateway) (targets endpoint.Targets, err error) {
-	targets = getTargetsFromTargetAnnotation(gateway.Annotations)
+	targets = annotations.TargetsFromTargetAnnotation(gateway.Annotations)
 	if len(targets) > 0 {
 		return
 	}
@@ -313,18 +322,19 @@ func (sc *gatewaySource) endpointsFromGateway(ctx context.Context, hostnames []s
 
 	resource := fmt.Sprintf("gateway/%s/%s", gateway.Namespace, gateway.Name)
 
-	annotations := gateway.Annotations
-	ttl := getTTLFromAnnotations(annotations, resource)
+	annots := gateway.Annotations
+	ttl := annotations.TTLFromAnnotations(annots, resource)
 
-	targets := getTargetsFromTargetAnnotation(annotations)
+	targets := annotations.TargetsFromTargetAnnotation(annots)
 	if len(targets) == 0 {
 		targets, err = sc.targetsFromGateway(ctx, gateway)
 		if err != nil {
 			return nil, err
 		}
 	}
+	targets = annotations.TargetsOfIPFamilies(annots, targets)
 
-	providerSpecific, setIdentifier := getProviderSpecificAnnotations(annotations)
+	providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(annots)
 
 	for _, host := range hostnames {
 		endpoints = append(endpoints, endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier, resource)...)
@@ -356,7 +366,7 @@ func (sc *gatewaySource) hostNamesFromGateway(gateway *networkingv1alpha3.Gatewa
 	}
 
 	if !sc.ignoreHostnameAnnotation {
-		hostnames = append(hostnames, getHostnamesFromAnnotations(gateway.Annotations)...)
+		hostnames = append(hostnames, annotations.HostnamesFromAnnotations(gateway.Annotations)...)
 	}
 
 	return hostnames, nil
@@ -30,6 +30,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	networkv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes/fake"
 
 	"sigs.k8s.io/external-dns/endpoint"
@@ -99,6 +100,7 @@ func (suite *GatewaySuite) SetupTest() {
 		"{{.Name}}",
 		false,
 		false,
+		labels.Everything(),
 	)
 	suite.NoError(err, "should initialize gateway source")
 	suite.NoError(err, "should succeed")
@@ -173,6 +175,7 @@ func TestNewIstioGatewaySource(t *testing.T) {
 				ti.fqdnTemplate,
 				ti.combineFQDNAndAnnotation,
 				false,
+				labels.Everything(),
 			)
 			if ti.expectError {
 				assert.Error(t, err)
@@ -517,6 +520,7 @@ func testGatewayEndpoints(t *testing.T) {
 		title                    string
 		targetNamespace          string
 		annotationFilter         string
+		labelFilter              string
 		lbServices               []fakeIngressGatewayService
 		ingresses                []fakeIngress
 		configItems              []fakeGatewayConfig
@@ -574,6 +578,39 @@ func testGatewayEndpoints(t *testing.T) {
 				},
 			},
 		},
+		{
+			title:       "gateways filtered by a set-based label selector",
+			labelFilter: "team in (a,b),!legacy",
+			lbServices: []fakeIngressGatewayService{
+				{
+					ips: []string{"8.8.8.8"},
+				},
+			},
+			configItems: []fakeGatewayConfig{
+				{
+					name:     "fake1",
+					labels:   map[string]string{"team": "a"},
+					dnsnames: [][]string{{"example.org"}},
+				},
+				{
+					name:     "fake2",
+					labels:   map[string]string{"team": "c"},
+					dnsnames: [][]string{{"new.org"}},
+				},
+				{
+					name:     "fake3",
+					labels:   map[string]string{"team": "b", "legacy": "true"},
+					dnsnames: [][]string{{"legacy.org"}},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"8.8.8.8"},
+				},
+			},
+		},
 		{
 			title:           "two simple gateways on different namespaces, one ingressgateway loadbalancer service",
 			targetNamespace: "",
@@ -1507,6 +1544,7 @@ func testGatewayEndpoints(t *testing.T) {
 				ti.fqdnTemplate,
 				ti.combineFQDNAndAnnotation,
 				ti.ignoreHostnameAnnotation,
+				mustGetLabelSelector(ti.labelFilter),
 			)
 			require.NoError(t, err)
 
@@ -1551,6 +1589,7 @@ func newTestGatewaySource(loadBalancerList []fakeIngressGatewayService, ingressL
 		"{{.Name}}",
 		false,
 		false,
+		labels.Everything(),
 	)
 	if err != nil {
 		return nil, err
@@ -1608,6 +1647,7 @@ type fakeGatewayConfig struct {
 	namespace   string
 	name        string
 	annotations map[string]string
+	labels      map[string]string
 	dnsnames    [][]string
 	selector    map[string]string
 }
@@ -1618,6 +1658,7 @@ func (c fakeGatewayConfig) Config() *networkingv1alpha3.Gateway {
 			Name:        c.name,
 			Namespace:   c.namespace,
 			Annotations: c.annotations,
+			Labels:      c.labels,
 		},
 		Spec: networkingv1alpha3api.Gateway{
 			Servers:  nil,
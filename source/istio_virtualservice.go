@@ -37,6 +37,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
 )
 
 // IstioMeshGateway is the built in gateway for all sidecars
@@ -53,6 +54,7 @@ type virtualServiceSource struct {
 	fqdnTemplate             *template.Template
 	combineFQDNAnnotation    bool
 	ignoreHostnameAnnotation bool
+	labelSelector            labels.Selector
 	serviceInformer          coreinformers.ServiceInformer
 	virtualserviceInformer   networkingv1alpha3informer.VirtualServiceInformer
 }
@@ -67,15 +69,17 @@ func NewIstioVirtualServiceSource(
 	fqdnTemplate string,
 	combineFQDNAnnotation bool,
 	ignoreHostnameAnnotation bool,
+	labelSelector labels.Selector,
 ) (Source, error) {
 	tmpl, err := parseTemplate(fqdnTemplate)
 	if err != nil {
 		return nil, err
 	}
 
-	// Use shared informers to listen for add/update/delete of services/pods/nodes in the specified namespace.
+	// Use shared informers to listen for add/update/delete of services in all namespaces, since the selector
+	// of a gateway matches the ingress gateway pods in any namespace, and of virtual services in the specified namespace.
 	// Set resync period to 0, to prevent processing when nothing has changed
-	informerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 0, kubeinformers.WithNamespace(namespace))
+	informerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 0)
 	serviceInformer := informerFactory.Core().V1().Services()
 	istioInformerFactory := istioinformers.NewSharedInformerFactoryWithOptions(istioClient, 0, istioinformers.WithNamespace(namespace))
 	virtualServiceInformer := istioInformerFactory.Networking().V1alpha3().VirtualServices()
@@ -116,6 +120,7 @@ func NewIstioVirtualServiceSource(
 		fqdnTemplate:             tmpl,
 		combineFQDNAnnotation:    combineFQDNAnnotation,
 		ignoreHostnameAnnotation: ignoreHostnameAnnotation,
+		labelSelector:            labelSelector,
 		serviceInformer:          serviceInformer,
 		virtualserviceInformer:   virtualServiceInformer,
 	}, nil
@@ -124,7 +129,7 @@ func NewIstioVirtualServiceSource(
 // Endpoints returns endpoint objects for each host-target combination that should be processed.
 // Retrieves all VirtualService resources in the source's namespace(s).
 func (sc *virtualServiceSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
-	virtualServices, err := sc.virtualserviceInformer.Lister().VirtualServices(sc.namespace).List(labels.Everything())
+	virtualServices, err := sc.virtualserviceInformer.Lister().VirtualServices(sc.namespace).List(sc.labelSelector)
 	if err != nil {
 		return nil, err
 	}
@@ -136,14 +141,24 @@ func (sc *virtualServiceSource) Endpoints(ctx context.Context) ([]*endpoint.Endp
 	var endpoints []*endpoint.Endpoint
 
 	for _, virtualService := range virtualServices {
+		if isExcluded(virtualService.Annotations, "virtualservice", virtualService.Namespace, virtualService.Name) {
+			continue
+		}
+
 		// Check controller annotation to see if we are responsible.
-		controller, ok := virtualService.Annotations[controllerAnnotationKey]
-		if ok && controller != controllerAnnotationValue {
+		if controller, foreign := annotations.ForeignController(virtualService.Annotations); foreign {
 			log.Debugf("Skipping VirtualService %s/%s because controller value does not match, found: %s, required: %s",
 				virtualService.Namespace, virtualService.Name, controller, controllerAnnotationValue)
 			continue
 		}
 
+		// VirtualServices only bound to the sidecars are internal to the mesh, even with a target annotation.
+		if !virtualServiceExposedByGateway(virtualService) {
+			log.Debugf("Skipping VirtualService %s/%s because it is not exported to a gateway other than %s",
+				virtualService.Namespace, virtualService.Name, IstioMeshGateway)
+			continue
+		}
+
 		gwEndpoints, err := sc.endpointsFromVirtualService(ctx, virtualService)
 		if err != nil {
 			return nil, err
@@ -184,6 +199,8 @@ func (sc *virtualServiceSource) AddEventHandler(ctx context.Context, handler fun
 	log.Debug("Adding event handler for Istio VirtualService")
 
 	sc.virtualserviceInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+	// The targets come from the load balancers of the ingress gateway Services.
+	sc.serviceInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
 }
 
 func (sc *virtualServiceSource) getGateway(ctx context.Context, gatewayStr string, virtualService *networkingv1alpha3.VirtualService) (*networkingv1alpha3.Gateway, error) {
@@ -224,9 +241,9 @@ func (sc *virtualServiceSource) endpointsFromTemplate(ctx context.Context, virtu
 
 	resource := fmt.Sprintf("virtualservice/%s/%s", virtualService.Namespace, virtualService.Name)
 
-	ttl := getTTLFromAnnotations(virtualService.Annotations, resource)
+	ttl := annotations.TTLFromAnnotations(virtualService.Annotations, resource)
 
-	providerSpecific, setIdentifier := getProviderSpecificAnnotations(virtualService.Annotations)
+	providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(virtualService.Annotations)
 
 	var endpoints []*endpoint.Endpoint
 	for _, hostname := range hostnames {
@@ -234,6 +251,7 @@ func (sc *virtualServiceSource) endpointsFromTemplate(ctx context.Context, virtu
 		if err != nil {
 			return endpoints, err
 		}
+		targets = annotations.TargetsOfIPFamilies(virtualService.Annotations, targets)
 		endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, resource)...)
 	}
 	return endpoints, nil
@@ -313,11 +331,11 @@ func (sc *virtualServiceSource) endpointsFromVirtualService(ctx context.Context,
 
 	resource := fmt.Sprintf("virtualservice/%s/%s", virtualservice.Namespace, virtualservice.Name)
 
-	ttl := getTTLFromAnnotations(virtualservice.Annotations, resource)
+	ttl := annotations.TTLFromAnnotations(virtualservice.Annotations, resource)
 
-	targetsFromAnnotation := getTargetsFromTargetAnnotation(virtualservice.Annotations)
+	targetsFromAnnotation := annotations.TargetsFromTargetAnnotation(virtualservice.Annotations)
 
-	providerSpecific, setIdentifier := getProviderSpecificAnnotations(virtualservice.Annotations)
+	providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(virtualservice.Annotations)
 
 	for _, host := range virtualservice.Spec.Hosts {
 		if host == "" || host == "*" {
@@ -339,13 +357,14 @@ func (sc *virtualServiceSource) endpointsFromVirtualService(ctx context.Context,
 				return endpoints, err
 			}
 		}
+		targets = annotations.TargetsOfIPFamilies(virtualservice.Annotations, targets)
 
 		endpoints = append(endpoints, endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier, resource)...)
 	}
 
 	// Skip endpoints if we do not want entries from annotations
 	if !sc.ignoreHostnameAnnotation {
-		hostnameList := getHostnamesFromAnnotations(virtualservice.Annotations)
+		hostnameList := annotations.HostnamesFromAnnotations(virtualservice.Annotations)
 		for _, hostname := range hostnameList {
 			targets := targetsFromAnnotation
 			if len(targets) == 0 {
@@ -354,6 +373,7 @@ func (sc *virtualServiceSource) endpointsFromVirtualService(ctx context.Context,
 					return endpoints, err
 				}
 			}
+			targets = annotations.TargetsOfIPFamilies(virtualservice.Annotations, targets)
 			endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, resource)...)
 		}
 	}
@@ -361,20 +381,46 @@ func (sc *virtualServiceSource) endpointsFromVirtualService(ctx context.Context,
 	return endpoints, nil
 }
 
-// checks if the given VirtualService should actually bind to the given gateway
-// see requirements here: https://istio.io/docs/reference/config/networking/gateway/#Server
-func virtualServiceBindsToGateway(virtualService *networkingv1alpha3.VirtualService, gateway *networkingv1alpha3.Gateway, vsHost string) bool {
-	isValid := false
+// virtualServiceExposedByGateway returns whether the VirtualService is bound to a gateway other than the mesh
+// and exported to its namespace. A VirtualService without gateways only applies to the sidecars.
+func virtualServiceExposedByGateway(virtualService *networkingv1alpha3.VirtualService) bool {
+	for _, gateway := range virtualService.Spec.Gateways {
+		if gateway == "" || gateway == IstioMeshGateway {
+			continue
+		}
+		namespace, _, err := parseGateway(gateway)
+		if err != nil {
+			continue
+		}
+		if namespace == "" {
+			namespace = virtualService.Namespace
+		}
+		if virtualServiceExportedTo(virtualService, namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// virtualServiceExportedTo returns whether the VirtualService is visible in the namespace of a gateway,
+// see https://istio.io/latest/docs/reference/config/networking/virtual-service/#VirtualService
+func virtualServiceExportedTo(virtualService *networkingv1alpha3.VirtualService, namespace string) bool {
 	if len(virtualService.Spec.ExportTo) == 0 {
-		isValid = true
-	} else {
-		for _, ns := range virtualService.Spec.ExportTo {
-			if ns == "*" || ns == gateway.Namespace || (ns == "." && gateway.Namespace == virtualService.Namespace) {
-				isValid = true
-			}
+		return true
+	}
+	for _, ns := range virtualService.Spec.ExportTo {
+		// "~" exports the VirtualService to no namespace at all
+		if ns == "*" || ns == namespace || (ns == "." && namespace == virtualService.Namespace) {
+			return true
 		}
 	}
-	if !isValid {
+	return false
+}
+
+// checks if the given VirtualService should actually bind to the given gateway
+// see requirements here: https://istio.io/docs/reference/config/networking/gateway/#Server
+func virtualServiceBindsToGateway(virtualService *networkingv1alpha3.VirtualService, gateway *networkingv1alpha3.Gateway, vsHost string) bool {
+	if !virtualServiceExportedTo(virtualService, gateway.Namespace) {
 		return false
 	}
 
@@ -448,7 +494,7 @@ func (sc *virtualServiceSource) targetsFromIngress(ctx context.Context, ingressS
 }
 
 func (sc *virtualServiceSource) targetsFromGateway(ctx context.Context, gateway *networkingv1alpha3.Gateway) (targets endpoint.Targets, err error) {
-	targets = getTargetsFromTargetAnnotation(gateway.Annotations)
+	targets = annotations.TargetsFromTargetAnnotation(gateway.Annotations)
 	if len(targets) > 0 {
 		return
 	}
@@ -459,7 +505,7 @@ func (sc *virtualServiceSource) targetsFromGateway(ctx context.Context, gateway
 		return
 	}
 
-	services, err := sc.serviceInformer.Lister().Services(sc.namespace).List(labels.Everything())
+	services, err := sc.serviceInformer.Lister().List(labels.Everything())
 	if err != nil {
 		log.Error(err)
 		return
@@ -33,6 +33,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	networkv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 	k8sclienttesting "k8s.io/client-go/testing"
@@ -123,6 +124,7 @@ func (suite *VirtualServiceSuite) SetupTest() {
 		"{{.Name}}",
 		false,
 		false,
+		labels.Everything(),
 	)
 	suite.NoError(err, "should initialize virtualservice source")
 }
@@ -200,6 +202,7 @@ func TestNewIstioVirtualServiceSource(t *testing.T) {
 				ti.fqdnTemplate,
 				ti.combineFQDNAndAnnotation,
 				false,
+				labels.Everything(),
 			)
 			if ti.expectError {
 				assert.Error(t, err)
@@ -384,6 +387,19 @@ func testVirtualServiceBindsToGateway(t *testing.T) {
 			vsHost:   "foo.bar",
 			expected: false,
 		},
+		{
+			title: "not exported ~",
+			gwconfig: fakeGatewayConfig{
+				namespace: "istio-system",
+				dnsnames:  [][]string{{"*"}},
+			},
+			vsconfig: fakeVirtualServiceConfig{
+				namespace: "istio-system",
+				exportTo:  "~",
+			},
+			vsHost:   "foo.bar",
+			expected: false,
+		},
 	} {
 		t.Run(ti.title, func(t *testing.T) {
 			vsconfig := ti.vsconfig.Config()
@@ -719,6 +735,7 @@ func testVirtualServiceEndpoints(t *testing.T) {
 		title                    string
 		targetNamespace          string
 		annotationFilter         string
+		labelFilter              string
 		lbServices               []fakeIngressGatewayService
 		ingresses                []fakeIngress
 		gwConfigs                []fakeGatewayConfig
@@ -787,6 +804,47 @@ func testVirtualServiceEndpoints(t *testing.T) {
 				},
 			},
 		},
+		{
+			title:       "virtualservices filtered by a set-based label selector",
+			labelFilter: "team notin (b)",
+			lbServices: []fakeIngressGatewayService{
+				{
+					namespace: namespace,
+					ips:       []string{"8.8.8.8"},
+				},
+			},
+			gwConfigs: []fakeGatewayConfig{
+				{
+					name:      "fake1",
+					namespace: namespace,
+					labels:    map[string]string{"team": "b"},
+					dnsnames:  [][]string{{"*"}},
+				},
+			},
+			vsConfigs: []fakeVirtualServiceConfig{
+				{
+					name:      "vs1",
+					namespace: namespace,
+					labels:    map[string]string{"team": "a"},
+					gateways:  []string{"fake1"},
+					dnsnames:  []string{"example.org"},
+				},
+				{
+					name:      "vs2",
+					namespace: namespace,
+					labels:    map[string]string{"team": "b"},
+					gateways:  []string{"fake1"},
+					dnsnames:  []string{"new.org"},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"8.8.8.8"},
+				},
+			},
+		},
 		{
 			title: "two simple virtualservices with one gateway each, one ingress",
 			lbServices: []fakeIngressGatewayService{
@@ -1933,6 +1991,101 @@ func testVirtualServiceEndpoints(t *testing.T) {
 			},
 			fqdnTemplate: "{{.Name}}.ext-dns.test.com",
 		},
+		{
+			title: "virtualservices only bound to the mesh are skipped, even with a target annotation",
+			vsConfigs: []fakeVirtualServiceConfig{
+				{
+					name:        "vs-mesh",
+					namespace:   namespace,
+					gateways:    []string{IstioMeshGateway},
+					dnsnames:    []string{"mesh.example.org"},
+					annotations: map[string]string{targetAnnotationKey: "1.2.3.4"},
+				},
+				{
+					name:        "vs-no-gateways",
+					namespace:   namespace,
+					dnsnames:    []string{"sidecars.example.org"},
+					annotations: map[string]string{targetAnnotationKey: "1.2.3.4"},
+				},
+				{
+					name:        "vs-public",
+					namespace:   namespace,
+					gateways:    []string{IstioMeshGateway, "istio-system/public"},
+					dnsnames:    []string{"public.example.org"},
+					annotations: map[string]string{targetAnnotationKey: "1.2.3.4"},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "public.example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"1.2.3.4"},
+				},
+			},
+		},
+		{
+			title: "virtualservices not exported to the namespace of their gateway are skipped, even with a target annotation",
+			vsConfigs: []fakeVirtualServiceConfig{
+				{
+					name:        "vs-local",
+					namespace:   namespace,
+					gateways:    []string{"istio-system/public"},
+					dnsnames:    []string{"local.example.org"},
+					annotations: map[string]string{targetAnnotationKey: "1.2.3.4"},
+					exportTo:    ".",
+				},
+				{
+					name:        "vs-exported",
+					namespace:   namespace,
+					gateways:    []string{"istio-system/public"},
+					dnsnames:    []string{"exported.example.org"},
+					annotations: map[string]string{targetAnnotationKey: "1.2.3.4"},
+					exportTo:    "istio-system",
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "exported.example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"1.2.3.4"},
+				},
+			},
+		},
+		{
+			title:           "gateway selector matching an ingress gateway service outside of the namespace of the source",
+			targetNamespace: namespace,
+			lbServices: []fakeIngressGatewayService{
+				{
+					namespace: "istio-system",
+					name:      "istio-ingressgateway",
+					ips:       []string{"8.8.8.8"},
+					selector:  map[string]string{"istio": "ingressgateway"},
+				},
+			},
+			gwConfigs: []fakeGatewayConfig{
+				{
+					name:      "public",
+					namespace: namespace,
+					dnsnames:  [][]string{{"example.org"}},
+					selector:  map[string]string{"istio": "ingressgateway"},
+				},
+			},
+			vsConfigs: []fakeVirtualServiceConfig{
+				{
+					name:      "vs",
+					namespace: namespace,
+					gateways:  []string{"public"},
+					dnsnames:  []string{"example.org"},
+				},
+			},
+			expected: []*endpoint.Endpoint{
+				{
+					DNSName:    "example.org",
+					RecordType: endpoint.RecordTypeA,
+					Targets:    endpoint.Targets{"8.8.8.8"},
+				},
+			},
+		},
 	} {
 		ti := ti
 		t.Run(ti.title, func(t *testing.T) {
@@ -1983,6 +2136,7 @@ func testVirtualServiceEndpoints(t *testing.T) {
 				ti.fqdnTemplate,
 				ti.combineFQDNAndAnnotation,
 				ti.ignoreHostnameAnnotation,
+				mustGetLabelSelector(ti.labelFilter),
 			)
 			require.NoError(t, err)
 
@@ -2069,6 +2223,7 @@ func newTestVirtualServiceSource(loadBalancerList []fakeIngressGatewayService, i
 		"{{.Name}}",
 		false,
 		false,
+		labels.Everything(),
 	)
 	if err != nil {
 		return nil, err
@@ -2087,6 +2242,7 @@ type fakeVirtualServiceConfig struct {
 	name        string
 	gateways    []string
 	annotations map[string]string
+	labels      map[string]string
 	dnsnames    []string
 	exportTo    string
 }
@@ -2105,6 +2261,7 @@ func (c fakeVirtualServiceConfig) Config() *networkingv1alpha3.VirtualService {
 			Name:        c.name,
 			Namespace:   c.namespace,
 			Annotations: c.annotations,
+			Labels:      c.labels,
 		},
 		Spec: *vs.DeepCopy(),
 	}
@@ -2197,6 +2354,7 @@ func TestVirtualServiceSourceGetGateway(t *testing.T) {
 					"{{.Name}}",
 					false,
 					false,
+					labels.Everything(),
 				)
 				return vs.(*virtualServiceSource)
 			}(),
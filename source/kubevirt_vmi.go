@@ -0,0 +1,272 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"sort"
+	"text/template"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
+)
+
+var kubevirtVMIGVR = schema.GroupVersionResource{
+	Group:    "kubevirt.io",
+	Version:  "v1",
+	Resource: "virtualmachineinstances",
+}
+
+// kubevirtVMISource is an implementation of Source for KubeVirt VirtualMachineInstances. It publishes the
+// addresses of the interfaces of the running virtual machines, like the pod source does for pods.
+type kubevirtVMISource struct {
+	vmiInformer      informers.GenericInformer
+	namespace        string
+	annotationFilter string
+	fqdnTemplate     *template.Template
+	labelSelector    labels.Selector
+}
+
+// The parts of the VirtualMachineInstance read by the source, see https://kubevirt.io/api-reference/
+type kubevirtVMI struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Status            kubevirtVMIStatus `json:"status,omitempty"`
+}
+
+type kubevirtVMIStatus struct {
+	Phase      string                 `json:"phase,omitempty"`
+	NodeName   string                 `json:"nodeName,omitempty"`
+	Interfaces []kubevirtVMIInterface `json:"interfaces,omitempty"`
+}
+
+type kubevirtVMIInterface struct {
+	Name string   `json:"name,omitempty"`
+	IP   string   `json:"ipAddress,omitempty"`
+	IPs  []string `json:"ipAddresses,omitempty"`
+}
+
+const kubevirtVMIRunning = "Running"
+
+// DeepCopyObject makes the VirtualMachineInstance a runtime.Object, as required by the FQDN template.
+func (in *kubevirtVMI) DeepCopyObject() runtime.Object {
+	out := *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Status.Interfaces = make([]kubevirtVMIInterface, len(in.Status.Interfaces))
+	for i, iface := range in.Status.Interfaces {
+		iface.IPs = slices.Clone(iface.IPs)
+		out.Status.Interfaces[i] = iface
+	}
+	return &out
+}
+
+// NewKubeVirtVMISource creates a new kubevirtVMISource with the given config.
+func NewKubeVirtVMISource(ctx context.Context, dynamicKubeClient dynamic.Interface, namespace, annotationFilter, fqdnTemplate string, labelSelector labels.Selector) (Source, error) {
+	tmpl, err := parseTemplate(fqdnTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use shared informer to listen for add/update/delete of VirtualMachineInstances in the specified namespace.
+	// Set resync period to 0, to prevent processing when nothing has changed.
+	informerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicKubeClient, 0, namespace, nil)
+	vmiInformer := informerFactory.ForResource(kubevirtVMIGVR)
+
+	// Add default resource event handlers to properly initialize informer.
+	vmiInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+			},
+		},
+	)
+
+	informerFactory.Start(ctx.Done())
+
+	// wait for the local cache to be populated.
+	if err := waitForDynamicCacheSync(context.Background(), informerFactory); err != nil {
+		return nil, err
+	}
+
+	return &kubevirtVMISource{
+		vmiInformer:      vmiInformer,
+		namespace:        namespace,
+		annotationFilter: annotationFilter,
+		fqdnTemplate:     tmpl,
+		labelSelector:    labelSelector,
+	}, nil
+}
+
+// Endpoints returns an A and an AAAA endpoint for each running VirtualMachineInstance with addresses.
+func (sc *kubevirtVMISource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	objects, err := sc.vmiInformer.Lister().ByNamespace(sc.namespace).List(sc.labelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	var vmis []*kubevirtVMI
+	for _, obj := range objects {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, errors.New("could not convert")
+		}
+		vmi := &kubevirtVMI{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, vmi); err != nil {
+			return nil, err
+		}
+		vmis = append(vmis, vmi)
+	}
+
+	vmis, err = sc.filterByAnnotations(vmis)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to filter VirtualMachineInstances")
+	}
+
+	var endpoints []*endpoint.Endpoint
+	for _, vmi := range vmis {
+		if isExcluded(vmi.Annotations, "virtualmachineinstance", vmi.Namespace, vmi.Name) {
+			continue
+		}
+
+		// Check controller annotation to see if we are responsible.
+		if controller, foreign := annotations.ForeignController(vmi.Annotations); foreign {
+			log.Debugf("Skipping VirtualMachineInstance %s/%s because controller value does not match, found: %s, required: %s",
+				vmi.Namespace, vmi.Name, controller, controllerAnnotationValue)
+			continue
+		}
+
+		if vmi.DeletionTimestamp != nil || vmi.Status.Phase != kubevirtVMIRunning {
+			log.Debugf("Skipping VirtualMachineInstance %s/%s because it is not running", vmi.Namespace, vmi.Name)
+			continue
+		}
+
+		vmiEndpoints, err := sc.endpointsFromVMI(vmi)
+		if err != nil {
+			return nil, err
+		}
+		if len(vmiEndpoints) == 0 {
+			log.Debugf("No endpoints could be generated from VirtualMachineInstance %s/%s", vmi.Namespace, vmi.Name)
+			continue
+		}
+		log.Debugf("Endpoints generated from VirtualMachineInstance: %s/%s: %v", vmi.Namespace, vmi.Name, vmiEndpoints)
+		endpoints = append(endpoints, vmiEndpoints...)
+	}
+
+	for _, ep := range endpoints {
+		sort.Sort(ep.Targets)
+	}
+
+	return endpoints, nil
+}
+
+func (sc *kubevirtVMISource) endpointsFromVMI(vmi *kubevirtVMI) ([]*endpoint.Endpoint, error) {
+	hostname := vmi.Name
+	if sc.fqdnTemplate != nil {
+		hostnames, err := execTemplate(sc.fqdnTemplate, vmi)
+		if err != nil {
+			return nil, err
+		}
+		if len(hostnames) == 0 || hostnames[0] == "" {
+			return nil, nil
+		}
+		hostname = hostnames[0]
+	}
+
+	targets := annotations.TargetsFromTargetAnnotation(vmi.Annotations)
+	if len(targets) == 0 {
+		targets = kubevirtVMIAddresses(vmi)
+	}
+
+	resource := fmt.Sprintf("virtualmachineinstance/%s/%s", vmi.Namespace, vmi.Name)
+	ttl := annotations.TTLFromAnnotations(vmi.Annotations, resource)
+	providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(vmi.Annotations)
+	return endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, resource), nil
+}
+
+// kubevirtVMIAddresses returns the unique addresses of the interfaces of the VirtualMachineInstance. The
+// link-local addresses reported by the guest agent are skipped, they can't be reached from outside the VM.
+func kubevirtVMIAddresses(vmi *kubevirtVMI) endpoint.Targets {
+	var targets endpoint.Targets
+	for _, iface := range vmi.Status.Interfaces {
+		ips := iface.IPs
+		if len(ips) == 0 && iface.IP != "" {
+			ips = []string{iface.IP}
+		}
+		for _, ip := range ips {
+			addr, err := netip.ParseAddr(ip)
+			if err != nil || addr.IsLinkLocalUnicast() || addr.IsLoopback() {
+				continue
+			}
+			if !slices.Contains(targets, addr.String()) {
+				targets = append(targets, addr.String())
+			}
+		}
+	}
+	return targets
+}
+
+// filterByAnnotations filters a list of VirtualMachineInstances by a given annotation selector.
+func (sc *kubevirtVMISource) filterByAnnotations(vmis []*kubevirtVMI) ([]*kubevirtVMI, error) {
+	labelSelector, err := metav1.ParseToLabelSelector(sc.annotationFilter)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	// empty filter returns original list
+	if selector.Empty() {
+		return vmis, nil
+	}
+
+	var filteredList []*kubevirtVMI
+	for _, vmi := range vmis {
+		// include VirtualMachineInstance if its annotations match the selector
+		if selector.Matches(labels.Set(vmi.Annotations)) {
+			filteredList = append(filteredList, vmi)
+		}
+	}
+	return filteredList, nil
+}
+
+func (sc *kubevirtVMISource) resourceVersion() (string, bool) {
+	return informersVersion(sc.vmiInformer.Informer())
+}
+
+func (sc *kubevirtVMISource) AddEventHandler(ctx context.Context, handler func()) {
+	log.Debug("Adding event handler for VirtualMachineInstance")
+
+	// Right now there is no way to remove event handler from informer, see:
+	// https://github.com/kubernetes/kubernetes/issues/79610
+	sc.vmiInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+}
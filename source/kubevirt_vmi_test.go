@@ -0,0 +1,143 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakeDynamic "k8s.io/client-go/dynamic/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// This is a compile-time validation that kubevirtVMISource is a Source.
+var _ Source = &kubevirtVMISource{}
+
+func newTestKubeVirtVMI(name, phase string, vmiLabels, annotations map[string]string, interfaces ...kubevirtVMIInterface) *unstructured.Unstructured {
+	var ifaces []interface{}
+	for _, iface := range interfaces {
+		ips := make([]interface{}, 0, len(iface.IPs))
+		for _, ip := range iface.IPs {
+			ips = append(ips, ip)
+		}
+		ifaces = append(ifaces, map[string]interface{}{"name": iface.Name, "ipAddress": iface.IP, "ipAddresses": ips})
+	}
+	vmi := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": kubevirtVMIGVR.GroupVersion().String(),
+		"kind":       "VirtualMachineInstance",
+		"status": map[string]interface{}{
+			"phase":      phase,
+			"interfaces": ifaces,
+		},
+	}}
+	vmi.SetName(name)
+	vmi.SetNamespace("default")
+	vmi.SetLabels(vmiLabels)
+	vmi.SetAnnotations(annotations)
+	return vmi
+}
+
+func TestKubeVirtVMIEndpoints(t *testing.T) {
+	t.Parallel()
+
+	pod := kubevirtVMIInterface{Name: "default", IP: "10.244.0.10", IPs: []string{"10.244.0.10", "fd00:10:244::a", "fe80::1"}}
+	bridge := kubevirtVMIInterface{Name: "lan", IP: "192.168.1.10"}
+
+	for _, tt := range []struct {
+		title         string
+		vmi           *unstructured.Unstructured
+		fqdnTemplate  string
+		labelSelector labels.Selector
+		expected      []*endpoint.Endpoint
+	}{
+		{
+			title:        "addresses of all interfaces",
+			vmi:          newTestKubeVirtVMI("vm-0", kubevirtVMIRunning, nil, nil, pod, bridge),
+			fqdnTemplate: "{{.Name}}.{{.Namespace}}.example.org",
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("vm-0.default.example.org", endpoint.RecordTypeA, "10.244.0.10", "192.168.1.10"),
+				endpoint.NewEndpoint("vm-0.default.example.org", endpoint.RecordTypeAAAA, "fd00:10:244::a"),
+			},
+		},
+		{
+			title: "vmi name without template",
+			vmi:   newTestKubeVirtVMI("vm-0.example.org", kubevirtVMIRunning, nil, nil, bridge),
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("vm-0.example.org", endpoint.RecordTypeA, "192.168.1.10"),
+			},
+		},
+		{
+			title:        "target annotation",
+			vmi:          newTestKubeVirtVMI("vm-0", kubevirtVMIRunning, nil, map[string]string{targetAnnotationKey: "5.6.7.8"}, bridge),
+			fqdnTemplate: "{{.Name}}.example.org",
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("vm-0.example.org", endpoint.RecordTypeA, "5.6.7.8"),
+			},
+		},
+		{
+			title:        "vmi not running",
+			vmi:          newTestKubeVirtVMI("vm-0", "Scheduling", nil, nil, bridge),
+			fqdnTemplate: "{{.Name}}.example.org",
+		},
+		{
+			title:        "vmi without addresses",
+			vmi:          newTestKubeVirtVMI("vm-0", kubevirtVMIRunning, nil, nil),
+			fqdnTemplate: "{{.Name}}.example.org",
+		},
+		{
+			title:        "excluded vmi",
+			vmi:          newTestKubeVirtVMI("vm-0", kubevirtVMIRunning, nil, map[string]string{excludeAnnotationKey: "true"}, bridge),
+			fqdnTemplate: "{{.Name}}.example.org",
+		},
+		{
+			title:         "label filter",
+			vmi:           newTestKubeVirtVMI("vm-0", kubevirtVMIRunning, map[string]string{"app": "db"}, nil, bridge),
+			fqdnTemplate:  "{{.Name}}.example.org",
+			labelSelector: labels.SelectorFromSet(labels.Set{"app": "web"}),
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			t.Parallel()
+
+			dynamicClient := fakeDynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{kubevirtVMIGVR: "VirtualMachineInstanceList"})
+			_, err := dynamicClient.Resource(kubevirtVMIGVR).Namespace("default").Create(context.Background(), tt.vmi, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			labelSelector := tt.labelSelector
+			if labelSelector == nil {
+				labelSelector = labels.Everything()
+			}
+			source, err := NewKubeVirtVMISource(context.Background(), dynamicClient, "", "", tt.fqdnTemplate, labelSelector)
+			require.NoError(t, err)
+
+			endpoints, err := source.Endpoints(context.Background())
+			require.NoError(t, err)
+			for _, ep := range tt.expected {
+				ep.Labels[endpoint.ResourceLabelKey] = "virtualmachineinstance/default/" + tt.vmi.GetName()
+			}
+			validateEndpoints(t, endpoints, tt.expected)
+		})
+	}
+}
@@ -18,24 +18,73 @@ package source
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/pkg/crash"
+)
+
+const (
+	// SourceFailurePolicyFail aborts the synchronization when a source fails.
+	SourceFailurePolicyFail = "fail"
+	// SourceFailurePolicySkipDeletes continues the synchronization with the endpoints of the other
+	// sources when a source fails, but without deleting or updating any records, see FetchReport.
+	SourceFailurePolicySkipDeletes = "skip-deletes"
 )
 
 // multiSource is a Source that merges the endpoints of its nested Sources.
 type multiSource struct {
 	children       []Source
+	priorities     []int
 	defaultTargets []string
+	timeout        time.Duration
+	failurePolicy  string
 }
 
-// Endpoints collects endpoints of all nested Sources and returns them in a single slice.
+// Endpoints collects endpoints of all nested Sources concurrently and returns them in a single slice.
 func (ms *multiSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
-	result := []*endpoint.Endpoint{}
+	results := make([][]*endpoint.Endpoint, len(ms.children))
+	errs := make([]error, len(ms.children))
 
-	for _, s := range ms.children {
-		endpoints, err := s.Endpoints(ctx)
-		if err != nil {
-			return nil, err
+	var wg sync.WaitGroup
+	for i, s := range ms.children {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = ms.childEndpoints(ctx, s)
+		}()
+	}
+	wg.Wait()
+
+	var winners map[string]int
+	if ms.priorities != nil {
+		winners = priorityWinners(results, errs, ms.priorities)
+	}
+
+	result := []*endpoint.Endpoint{}
+	for n, endpoints := range results {
+		if err := errs[n]; err != nil {
+			if ms.failurePolicy != SourceFailurePolicySkipDeletes {
+				return nil, err
+			}
+			log.Errorf("Continuing without the endpoints of a failed source: %v", err)
+			reportFailedSource(ctx)
+			continue
+		}
+		if winners != nil {
+			endpoints = slices.DeleteFunc(slices.Clone(endpoints), func(ep *endpoint.Endpoint) bool {
+				if ms.priorities[n] == winners[priorityKey(ep)] {
+					return false
+				}
+				log.Infof("Ignoring endpoint %s of %s, the hostname is published by a source with a higher priority", ep, ep.Labels[endpoint.ResourceLabelKey])
+				return true
+			})
 		}
 		if len(ms.defaultTargets) > 0 {
 			for i := range endpoints {
@@ -53,13 +102,126 @@ func (ms *multiSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, err
 	return result, nil
 }
 
+// childEndpoints returns the endpoints of the nested source, giving up after the timeout. A source which
+// doesn't return is left behind, so it can't block the synchronization, a panic of the source is returned as
+// a *crash.Error.
+func (ms *multiSource) childEndpoints(ctx context.Context, s Source) ([]*endpoint.Endpoint, error) {
+	if ms.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ms.timeout)
+		defer cancel()
+	}
+
+	type result struct {
+		endpoints []*endpoint.Endpoint
+		err       error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		defer func() { done <- r }()
+		defer crash.Recover(fmt.Sprintf("source %T", s), &r.err)
+		r.endpoints, r.err = s.Endpoints(ctx)
+	}()
+
+	select {
+	case r := <-done:
+		return r.endpoints, r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("fetching endpoints from %T: %w", s, ctx.Err())
+	}
+}
+
 func (ms *multiSource) AddEventHandler(ctx context.Context, handler func()) {
 	for _, s := range ms.children {
 		s.AddEventHandler(ctx, handler)
 	}
 }
 
-// NewMultiSource creates a new multiSource.
-func NewMultiSource(children []Source, defaultTargets []string) Source {
-	return &multiSource{children: children, defaultTargets: defaultTargets}
+// NewMultiSource creates a new multiSource. The endpoints of each nested source are fetched with the
+// timeout, 0 disables it. The failure policy decides what happens when a nested source fails.
+//
+// The priorities, if not nil, rank the nested sources, the lowest value first: when several sources publish
+// the same hostname, only the endpoints of the source with the highest priority are kept, see SourcePriorities.
+func NewMultiSource(children []Source, priorities []int, defaultTargets []string, timeout time.Duration, failurePolicy string) Source {
+	return &multiSource{children: children, priorities: priorities, defaultTargets: defaultTargets, timeout: timeout, failurePolicy: failurePolicy}
+}
+
+// SourcePriorities returns the priorities of the named sources for NewMultiSource: the position of the source
+// in the priority list, or the length of the list for the sources which are not in it. It returns nil for an
+// empty priority list, which leaves the sources unranked.
+func SourcePriorities(names, priority []string) []int {
+	if len(priority) == 0 {
+		return nil
+	}
+	priorities := make([]int, 0, len(names))
+	for _, name := range names {
+		rank := slices.Index(priority, name)
+		if rank < 0 {
+			rank = len(priority)
+		}
+		priorities = append(priorities, rank)
+	}
+	return priorities
+}
+
+// priorityKey identifies the records which conflict between sources: the records with the same name and set
+// identifier, the A, AAAA and CNAME records being a single group since a CNAME excludes the other two. Names are
+// compared case-insensitively and regardless of a trailing dot, as in the plan.
+func priorityKey(ep *endpoint.Endpoint) string {
+	recordType := ep.RecordType
+	switch recordType {
+	case endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME:
+		recordType = "address"
+	}
+	dnsName := strings.TrimSuffix(strings.TrimSpace(strings.ToLower(ep.DNSName)), ".")
+	return dnsName + " / " + ep.SetIdentifier + " / " + recordType
+}
+
+// priorityWinners returns the highest priority of the sources publishing each group of records.
+func priorityWinners(results [][]*endpoint.Endpoint, errs []error, priorities []int) map[string]int {
+	winners := map[string]int{}
+	for n, endpoints := range results {
+		if errs[n] != nil {
+			continue
+		}
+		for _, ep := range endpoints {
+			key := priorityKey(ep)
+			if rank, ok := winners[key]; !ok || priorities[n] < rank {
+				winners[key] = priorities[n]
+			}
+		}
+	}
+	return winners
+}
+
+// fetchReportKey is the context key of the FetchReport.
+type fetchReportKey struct{}
+
+// FetchReport tells whether the endpoints returned by a Source are incomplete because nested
+// sources failed under the skip-deletes failure policy.
+type FetchReport struct {
+	mu     sync.Mutex
+	failed int
+}
+
+// WithFetchReport returns a context for fetching endpoints which records failed sources in the returned report.
+func WithFetchReport(ctx context.Context) (context.Context, *FetchReport) {
+	report := &FetchReport{}
+	return context.WithValue(ctx, fetchReportKey{}, report), report
+}
+
+// FailedSources returns the number of sources whose endpoints are missing.
+func (r *FetchReport) FailedSources() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failed
+}
+
+func reportFailedSource(ctx context.Context) {
+	if report, ok := ctx.Value(fetchReportKey{}).(*FetchReport); ok {
+		report.mu.Lock()
+		report.failed++
+		report.mu.Unlock()
+	}
 }
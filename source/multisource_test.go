@@ -20,12 +20,14 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/pkg/crash"
 )
 
 func TestMultiSource(t *testing.T) {
@@ -35,6 +37,10 @@ func TestMultiSource(t *testing.T) {
 	t.Run("Endpoints", testMultiSourceEndpoints)
 	t.Run("EndpointsWithError", testMultiSourceEndpointsWithError)
 	t.Run("EndpointsDefaultTargets", testMultiSourceEndpointsDefaultTargets)
+	t.Run("EndpointsTimeout", testMultiSourceEndpointsTimeout)
+	t.Run("EndpointsSkipDeletes", testMultiSourceEndpointsSkipDeletes)
+	t.Run("EndpointsPanic", testMultiSourceEndpointsPanic)
+	t.Run("EndpointsPriority", testMultiSourceEndpointsPriority)
 }
 
 // testMultiSourceImplementsSource tests that multiSource is a valid Source.
@@ -89,7 +95,7 @@ func testMultiSourceEndpoints(t *testing.T) {
 			}
 
 			// Create our object under test and get the endpoints.
-			source := NewMultiSource(sources, nil)
+			source := NewMultiSource(sources, nil, nil, 0, SourceFailurePolicyFail)
 
 			// Get endpoints from the source.
 			endpoints, err := source.Endpoints(context.Background())
@@ -116,7 +122,7 @@ func testMultiSourceEndpointsWithError(t *testing.T) {
 	src.On("Endpoints").Return(nil, errSomeError)
 
 	// Create our object under test and get the endpoints.
-	source := NewMultiSource([]Source{src}, nil)
+	source := NewMultiSource([]Source{src}, nil, nil, 0, SourceFailurePolicyFail)
 
 	// Get endpoints from our source.
 	_, err := source.Endpoints(context.Background())
@@ -156,7 +162,7 @@ func testMultiSourceEndpointsDefaultTargets(t *testing.T) {
 	src.On("Endpoints").Return(sourceEndpoints, nil)
 
 	// Create our object under test with non-empty defaultTargets and get the endpoints.
-	source := NewMultiSource([]Source{src}, defaultTargets)
+	source := NewMultiSource([]Source{src}, nil, defaultTargets, 0, SourceFailurePolicyFail)
 
 	// Get endpoints from our source.
 	endpoints, err := source.Endpoints(context.Background())
@@ -168,3 +174,82 @@ func testMultiSourceEndpointsDefaultTargets(t *testing.T) {
 	// Validate that the nested sources were called.
 	src.AssertExpectations(t)
 }
+
+// testMultiSourceEndpointsTimeout tests that a nested source which doesn't return in time fails.
+func testMultiSourceEndpointsTimeout(t *testing.T) {
+	block := make(chan time.Time)
+	defer close(block)
+
+	src := new(testutils.MockSource)
+	src.On("Endpoints").Return([]*endpoint.Endpoint{}, nil).WaitUntil(block)
+
+	source := NewMultiSource([]Source{src}, nil, nil, 10*time.Millisecond, SourceFailurePolicyFail)
+
+	_, err := source.Endpoints(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// testMultiSourceEndpointsSkipDeletes tests that the endpoints of the other nested sources are returned
+// and the failed source is reported with the skip-deletes policy.
+func testMultiSourceEndpointsSkipDeletes(t *testing.T) {
+	foo := &endpoint.Endpoint{DNSName: "foo", Targets: endpoint.Targets{"8.8.8.8"}}
+	block := make(chan time.Time)
+	defer close(block)
+
+	failing := new(testutils.MockSource)
+	failing.On("Endpoints").Return(nil, errors.New("some error"))
+	slow := new(testutils.MockSource)
+	slow.On("Endpoints").Return([]*endpoint.Endpoint{}, nil).WaitUntil(block)
+	working := new(testutils.MockSource)
+	working.On("Endpoints").Return([]*endpoint.Endpoint{foo}, nil)
+
+	source := NewMultiSource([]Source{failing, slow, working}, nil, nil, 10*time.Millisecond, SourceFailurePolicySkipDeletes)
+
+	ctx, report := WithFetchReport(context.Background())
+	endpoints, err := source.Endpoints(ctx)
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{foo})
+	assert.Equal(t, 2, report.FailedSources())
+}
+
+// testMultiSourceEndpointsPanic tests that a panic of a nested source is returned as an error.
+func testMultiSourceEndpointsPanic(t *testing.T) {
+	src := new(testutils.MockSource)
+	src.On("Endpoints").Panic("source bug")
+
+	source := NewMultiSource([]Source{src}, nil, nil, 0, SourceFailurePolicyFail)
+
+	_, err := source.Endpoints(context.Background())
+	var crashErr *crash.Error
+	require.ErrorAs(t, err, &crashErr)
+	assert.Equal(t, "source *testutils.MockSource", crashErr.Component)
+	assert.Equal(t, "source bug", crashErr.Value)
+}
+
+// testMultiSourceEndpointsPriority tests that only the source with the highest priority publishes a hostname.
+func testMultiSourceEndpointsPriority(t *testing.T) {
+	ingressFoo := &endpoint.Endpoint{DNSName: "foo", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"lb.example.org"}}
+	ingressBar := &endpoint.Endpoint{DNSName: "bar", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"8.8.4.4"}}
+	crdFoo := &endpoint.Endpoint{DNSName: "foo", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"8.8.8.8"}, RecordTTL: 60}
+	crdFooTXT := &endpoint.Endpoint{DNSName: "foo", RecordType: endpoint.RecordTypeTXT, Targets: endpoint.Targets{"v=spf1 -all"}}
+	serviceFoo := &endpoint.Endpoint{DNSName: "foo", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}}
+	serviceBar := &endpoint.Endpoint{DNSName: "BAR.", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}}
+
+	ingress := new(testutils.MockSource)
+	ingress.On("Endpoints").Return([]*endpoint.Endpoint{ingressFoo, ingressBar}, nil)
+	crd := new(testutils.MockSource)
+	crd.On("Endpoints").Return([]*endpoint.Endpoint{crdFoo, crdFooTXT}, nil)
+	service := new(testutils.MockSource)
+	service.On("Endpoints").Return([]*endpoint.Endpoint{serviceFoo, serviceBar}, nil)
+
+	priorities := SourcePriorities([]string{"ingress", "service", "crd"}, []string{"crd", "ingress"})
+	assert.Equal(t, []int{1, 2, 0}, priorities)
+
+	source := NewMultiSource([]Source{ingress, service, crd}, priorities, nil, 0, SourceFailurePolicyFail)
+
+	endpoints, err := source.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{ingressBar, crdFoo, crdFooTXT})
+
+	assert.Nil(t, SourcePriorities([]string{"ingress", "crd"}, nil))
+}
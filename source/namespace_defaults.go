@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+
+	kubeinformers "k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/external-dns/source/annotations"
+)
+
+// namespaceDefaults completes the annotations of the resources with the default annotations set on their
+// Namespace, see annotations.WithNamespaceDefaults. A nil namespaceDefaults leaves the annotations as they are.
+type namespaceDefaults struct {
+	nsInformer coreinformers.NamespaceInformer
+}
+
+// newNamespaceDefaults creates a namespaceDefaults watching the Namespaces if enabled, or returns nil.
+func newNamespaceDefaults(ctx context.Context, kubeClient kubernetes.Interface, enabled bool) (*namespaceDefaults, error) {
+	if !enabled {
+		return nil, nil
+	}
+
+	informerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 0)
+	nsInformer := informerFactory.Core().V1().Namespaces()
+	nsInformer.Informer() // Register with factory before starting.
+
+	informerFactory.Start(ctx.Done())
+
+	// wait for the local cache to be populated.
+	if err := waitForCacheSync(context.Background(), informerFactory); err != nil {
+		return nil, err
+	}
+	return &namespaceDefaults{nsInformer: nsInformer}, nil
+}
+
+// annotations returns the annotations of a resource of the given namespace completed with the defaults of the
+// namespace, and whether any default was added.
+func (nd *namespaceDefaults) annotations(namespace string, annots map[string]string) (map[string]string, bool) {
+	if nd == nil {
+		return annots, false
+	}
+	ns, err := nd.nsInformer.Lister().Get(namespace)
+	if err != nil {
+		return annots, false
+	}
+	return annotations.WithNamespaceDefaults(annots, ns.Annotations)
+}
+
+// addEventHandler triggers the handler when a Namespace changes, since its defaults may have changed.
+func (nd *namespaceDefaults) addEventHandler(handler func()) {
+	if nd == nil {
+		return
+	}
+	nd.nsInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+}
+
+// withInformer appends the Namespace informer to the given informers, so that the version of a cached source
+// changes along with the defaults of the namespaces.
+func (nd *namespaceDefaults) withInformer(informers ...cache.SharedIndexInformer) []cache.SharedIndexInformer {
+	if nd == nil {
+		return informers
+	}
+	return append(informers, nd.nsInformer.Informer())
+}
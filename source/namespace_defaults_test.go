@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestIngressSourceNamespaceDefaults(t *testing.T) {
+	fakeClient := fake.NewSimpleClientset(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: "tenant-a",
+			Annotations: map[string]string{
+				ttlAnnotationKey:      "300",
+				CloudflareProxiedKey:  "true",
+				hostnameAnnotationKey: "ignored.example.org",
+			},
+		}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b"}},
+	)
+	for _, ing := range []fakeIngress{
+		{
+			name:      "defaults",
+			namespace: "tenant-a",
+			dnsnames:  []string{"defaults.example.org"},
+			ips:       []string{"8.8.8.8"},
+		},
+		{
+			name:        "explicit",
+			namespace:   "tenant-a",
+			dnsnames:    []string{"explicit.example.org"},
+			ips:         []string{"8.8.8.8"},
+			annotations: map[string]string{ttlAnnotationKey: "60"},
+		},
+		{
+			name:      "no-defaults",
+			namespace: "tenant-b",
+			dnsnames:  []string{"no-defaults.example.org"},
+			ips:       []string{"8.8.4.4"},
+		},
+	} {
+		_, err := fakeClient.NetworkingV1().Ingresses(ing.namespace).Create(context.Background(), ing.Ingress(), metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	proxied := endpoint.ProviderSpecific{{Name: CloudflareProxiedKey, Value: "true"}}
+
+	for _, tc := range []struct {
+		title    string
+		enabled  bool
+		expected []*endpoint.Endpoint
+	}{
+		{
+			title:   "enabled",
+			enabled: true,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "defaults.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"8.8.8.8"}, RecordTTL: 300, ProviderSpecific: proxied},
+				{DNSName: "explicit.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"8.8.8.8"}, RecordTTL: 60, ProviderSpecific: proxied},
+				{DNSName: "no-defaults.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"8.8.4.4"}},
+			},
+		},
+		{
+			title: "disabled",
+			expected: []*endpoint.Endpoint{
+				{DNSName: "defaults.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"8.8.8.8"}},
+				{DNSName: "explicit.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"8.8.8.8"}, RecordTTL: 60},
+				{DNSName: "no-defaults.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"8.8.4.4"}},
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			sc, err := NewIngressSource(context.Background(), fakeClient, "", "", "", false, false, false, false, labels.Everything(), []string{}, tc.enabled)
+			require.NoError(t, err)
+			endpoints, err := sc.Endpoints(context.Background())
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, tc.expected)
+		})
+	}
+}
+
+func TestServiceSourceNamespaceDefaults(t *testing.T) {
+	fakeClient := fake.NewSimpleClientset(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "tenant-a",
+			Annotations: map[string]string{targetAnnotationKey: "lb.example.org", ttlAnnotationKey: "300"},
+		}},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "app",
+				Namespace:   "tenant-a",
+				Annotations: map[string]string{hostnameAnnotationKey: "app.example.org"},
+			},
+			Spec: v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+			Status: v1.ServiceStatus{LoadBalancer: v1.LoadBalancerStatus{
+				Ingress: []v1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+			}},
+		},
+	)
+
+	sc, err := NewServiceSource(context.Background(), fakeClient, "", "", "", false, "", false, false, false, []string{}, false, labels.Everything(), false, false, false, true)
+	require.NoError(t, err)
+	endpoints, err := sc.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		{DNSName: "app.example.org", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"lb.example.org"}, RecordTTL: 300},
+	})
+}
+
+func TestIngressSourceNamespaceDefaultsResourceVersion(t *testing.T) {
+	fakeClient := fake.NewSimpleClientset(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", ResourceVersion: "1"}})
+	ing := fakeIngress{
+		name:      "defaults",
+		namespace: "tenant-a",
+		dnsnames:  []string{"defaults.example.org"},
+		ips:       []string{"8.8.8.8"},
+	}.Ingress()
+	ing.ResourceVersion = "1"
+	_, err := fakeClient.NetworkingV1().Ingresses("tenant-a").Create(context.Background(), ing, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	sc, err := NewIngressSource(context.Background(), fakeClient, "", "", "", false, false, false, false, labels.Everything(), []string{}, true)
+	require.NoError(t, err)
+	version, ok := sc.(*ingressSource).resourceVersion()
+	require.True(t, ok)
+
+	// the defaults of the namespace change the endpoints, so they must change the version too
+	_, err = fakeClient.CoreV1().Namespaces().Update(context.Background(), &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:            "tenant-a",
+		ResourceVersion: "2",
+		Annotations:     map[string]string{ttlAnnotationKey: "300"},
+	}}, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		updated, ok := sc.(*ingressSource).resourceVersion()
+		return ok && updated != version
+	}, 5*time.Second, 10*time.Millisecond)
+}
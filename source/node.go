@@ -31,6 +31,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
 )
 
 type nodeSource struct {
@@ -39,10 +40,13 @@ type nodeSource struct {
 	fqdnTemplate     *template.Template
 	nodeInformer     coreinformers.NodeInformer
 	labelSelector    labels.Selector
+	excludeNotReady  bool
+	addressTypes     []v1.NodeAddressType
 }
 
-// NewNodeSource creates a new nodeSource with the given config.
-func NewNodeSource(ctx context.Context, kubeClient kubernetes.Interface, annotationFilter, fqdnTemplate string, labelSelector labels.Selector) (Source, error) {
+// NewNodeSource creates a new nodeSource with the given config. The addresses of the first of the addressTypes
+// a node has are published; without addressTypes the external IPs are preferred over the internal IPs.
+func NewNodeSource(ctx context.Context, kubeClient kubernetes.Interface, annotationFilter, fqdnTemplate string, labelSelector labels.Selector, excludeNotReady bool, addressTypes []string) (Source, error) {
 	tmpl, err := parseTemplate(fqdnTemplate)
 	if err != nil {
 		return nil, err
@@ -75,6 +79,8 @@ func NewNodeSource(ctx context.Context, kubeClient kubernetes.Interface, annotat
 		fqdnTemplate:     tmpl,
 		nodeInformer:     nodeInformer,
 		labelSelector:    labelSelector,
+		excludeNotReady:  excludeNotReady,
+		addressTypes:     nodeAddressTypes(addressTypes),
 	}, nil
 }
 
@@ -94,9 +100,12 @@ func (ns *nodeSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, erro
 
 	// create endpoints for all nodes
 	for _, node := range nodes {
+		if isExcluded(node.Annotations, "node", "", node.Name) {
+			continue
+		}
+
 		// Check controller annotation to see if we are responsible.
-		controller, ok := node.Annotations[controllerAnnotationKey]
-		if ok && controller != controllerAnnotationValue {
+		if controller, foreign := annotations.ForeignController(node.Annotations); foreign {
 			log.Debugf("Skipping node %s because controller value does not match, found: %s, required: %s",
 				node.Name, controller, controllerAnnotationValue)
 			continue
@@ -107,32 +116,28 @@ func (ns *nodeSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, erro
 			continue
 		}
 
+		if ns.excludeNotReady && !isNodeReady(node) {
+			log.Debugf("Skipping node %s because it is not ready", node.Name)
+			continue
+		}
+
 		log.Debugf("creating endpoint for node %s", node.Name)
 
-		ttl := getTTLFromAnnotations(node.Annotations, fmt.Sprintf("node/%s", node.Name))
-
-		// create new endpoint with the information we already have
-		ep := &endpoint.Endpoint{
-			RecordTTL: ttl,
-		}
+		ttl := annotations.TTLFromAnnotations(node.Annotations, fmt.Sprintf("node/%s", node.Name))
+		providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(node.Annotations)
 
+		hostnames := []string{node.Name}
 		if ns.fqdnTemplate != nil {
-			hostnames, err := execTemplate(ns.fqdnTemplate, node)
+			hostnames, err = execTemplate(ns.fqdnTemplate, node)
 			if err != nil {
 				return nil, err
 			}
-			hostname := ""
-			if len(hostnames) > 0 {
-				hostname = hostnames[0]
-			}
-			ep.DNSName = hostname
-			log.Debugf("applied template for %s, converting to %s", node.Name, ep.DNSName)
+			log.Debugf("applied template for %s, converting to %v", node.Name, hostnames)
 		} else {
-			ep.DNSName = node.Name
 			log.Debugf("not applying template for %s", node.Name)
 		}
 
-		addrs := getTargetsFromTargetAnnotation(node.Annotations)
+		addrs := annotations.TargetsFromTargetAnnotation(node.Annotations)
 		if len(addrs) == 0 {
 			addrs, err = ns.nodeAddresses(node)
 			if err != nil {
@@ -140,19 +145,30 @@ func (ns *nodeSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, erro
 			}
 		}
 
-		ep.Labels = endpoint.NewLabels()
-		for _, addr := range addrs {
-			log.Debugf("adding endpoint %s target %s", ep, addr)
-			key := endpoint.EndpointKey{
-				DNSName:    ep.DNSName,
-				RecordType: suitableType(addr),
+		for _, hostname := range hostnames {
+			if hostname == "" {
+				continue
 			}
-			if _, ok := endpoints[key]; !ok {
-				epCopy := *ep
-				epCopy.RecordType = key.RecordType
-				endpoints[key] = &epCopy
+			for _, addr := range addrs {
+				log.Debugf("adding endpoint %s target %s", hostname, addr)
+				key := endpoint.EndpointKey{
+					DNSName:    hostname,
+					RecordType: suitableType(addr),
+				}
+				if _, ok := endpoints[key]; !ok {
+					ep := &endpoint.Endpoint{
+						DNSName:          hostname,
+						RecordType:       key.RecordType,
+						RecordTTL:        ttl,
+						Labels:           endpoint.NewLabels(),
+						ProviderSpecific: providerSpecific,
+						SetIdentifier:    setIdentifier,
+					}
+					ep.Labels[endpoint.ResourceLabelKey] = fmt.Sprintf("node/%s", node.Name)
+					endpoints[key] = ep
+				}
+				endpoints[key].Targets = append(endpoints[key].Targets, addr)
 			}
-			endpoints[key].Targets = append(endpoints[key].Targets, addr)
 		}
 	}
 
@@ -164,12 +180,36 @@ func (ns *nodeSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, erro
 	return endpointsSlice, nil
 }
 
+func (ns *nodeSource) resourceVersion() (string, bool) {
+	return informersVersion(ns.nodeInformer.Informer())
+}
+
 func (ns *nodeSource) AddEventHandler(ctx context.Context, handler func()) {
+	log.Debug("Adding event handler for node")
+
+	// Right now there is no way to remove event handler from informer, see:
+	// https://github.com/kubernetes/kubernetes/issues/79610
+	ns.nodeInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
 }
 
 // nodeAddress returns node's externalIP and if that's not found, node's internalIP
 // basically what k8s.io/kubernetes/pkg/util/node.GetPreferredNodeAddress does
 func (ns *nodeSource) nodeAddresses(node *v1.Node) ([]string, error) {
+	for _, addressType := range ns.addressTypes {
+		var addresses []string
+		for _, addr := range node.Status.Addresses {
+			if addr.Type == addressType {
+				addresses = append(addresses, addr.Address)
+			}
+		}
+		if len(addresses) > 0 {
+			return addresses, nil
+		}
+	}
+	if len(ns.addressTypes) > 0 {
+		return nil, fmt.Errorf("could not find node address of types %v for %s", ns.addressTypes, node.Name)
+	}
+
 	addresses := map[v1.NodeAddressType][]string{
 		v1.NodeExternalIP: {},
 		v1.NodeInternalIP: {},
@@ -225,3 +265,21 @@ func (ns *nodeSource) filterByAnnotations(nodes []*v1.Node) ([]*v1.Node, error)
 
 	return filteredList, nil
 }
+
+// isNodeReady returns whether the Ready condition of the node is true.
+func isNodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func nodeAddressTypes(addressTypes []string) []v1.NodeAddressType {
+	var types []v1.NodeAddressType
+	for _, addressType := range addressTypes {
+		types = append(types, v1.NodeAddressType(addressType))
+	}
+	return types
+}
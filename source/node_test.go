@@ -19,6 +19,7 @@ package source
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -35,6 +36,7 @@ func TestNodeSource(t *testing.T) {
 
 	t.Run("NewNodeSource", testNodeSourceNewNodeSource)
 	t.Run("Endpoints", testNodeSourceEndpoints)
+	t.Run("EventHandler", testNodeSourceEventHandler)
 }
 
 // testNodeSourceNewNodeSource tests that NewNodeService doesn't return an error.
@@ -77,6 +79,8 @@ func testNodeSourceNewNodeSource(t *testing.T) {
 				ti.annotationFilter,
 				ti.fqdnTemplate,
 				labels.Everything(),
+				false,
+				nil,
 			)
 
 			if ti.expectError {
@@ -102,6 +106,9 @@ func testNodeSourceEndpoints(t *testing.T) {
 		labels           map[string]string
 		annotations      map[string]string
 		unschedulable    bool // default to false
+		notReady         bool
+		excludeNotReady  bool
+		addressTypes     []string
 		expected         []*endpoint.Endpoint
 		expectError      bool
 	}{
@@ -329,6 +336,68 @@ func testNodeSourceEndpoints(t *testing.T) {
 			unschedulable: true,
 			expected:      []*endpoint.Endpoint{},
 		},
+		{
+			title:           "not ready node returns nothing if excluded",
+			nodeName:        "node1",
+			nodeAddresses:   []v1.NodeAddress{{Type: v1.NodeExternalIP, Address: "1.2.3.4"}},
+			notReady:        true,
+			excludeNotReady: true,
+			expected:        []*endpoint.Endpoint{},
+		},
+		{
+			title:         "not ready node returns one endpoint",
+			nodeName:      "node1",
+			nodeAddresses: []v1.NodeAddress{{Type: v1.NodeExternalIP, Address: "1.2.3.4"}},
+			notReady:      true,
+			expected: []*endpoint.Endpoint{
+				{RecordType: "A", DNSName: "node1", Targets: endpoint.Targets{"1.2.3.4"}},
+			},
+		},
+		{
+			title:           "ready node returns one endpoint if not ready nodes are excluded",
+			nodeName:        "node1",
+			nodeAddresses:   []v1.NodeAddress{{Type: v1.NodeExternalIP, Address: "1.2.3.4"}},
+			excludeNotReady: true,
+			expected: []*endpoint.Endpoint{
+				{RecordType: "A", DNSName: "node1", Targets: endpoint.Targets{"1.2.3.4"}},
+			},
+		},
+		{
+			title:         "internal IP address type",
+			nodeName:      "node1",
+			nodeAddresses: []v1.NodeAddress{{Type: v1.NodeExternalIP, Address: "1.2.3.4"}, {Type: v1.NodeInternalIP, Address: "10.0.0.1"}},
+			addressTypes:  []string{"InternalIP"},
+			expected: []*endpoint.Endpoint{
+				{RecordType: "A", DNSName: "node1", Targets: endpoint.Targets{"10.0.0.1"}},
+			},
+		},
+		{
+			title:         "hostname address type",
+			nodeName:      "node1",
+			nodeAddresses: []v1.NodeAddress{{Type: v1.NodeHostName, Address: "node1.internal.example.org"}, {Type: v1.NodeInternalIP, Address: "10.0.0.1"}},
+			addressTypes:  []string{"ExternalIP", "Hostname"},
+			expected: []*endpoint.Endpoint{
+				{RecordType: "CNAME", DNSName: "node1", Targets: endpoint.Targets{"node1.internal.example.org"}},
+			},
+		},
+		{
+			title:         "missing address type",
+			nodeName:      "node1",
+			nodeAddresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}},
+			addressTypes:  []string{"ExternalIP"},
+			expected:      []*endpoint.Endpoint{},
+			expectError:   true,
+		},
+		{
+			title:         "template with multiple names",
+			nodeName:      "node1",
+			nodeAddresses: []v1.NodeAddress{{Type: v1.NodeExternalIP, Address: "1.2.3.4"}},
+			fqdnTemplate:  "{{.Name}}.example.org,{{.Name}}.nodes.example.org",
+			expected: []*endpoint.Endpoint{
+				{RecordType: "A", DNSName: "node1.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+				{RecordType: "A", DNSName: "node1.nodes.example.org", Targets: endpoint.Targets{"1.2.3.4"}},
+			},
+		},
 	} {
 		tc := tc
 		t.Run(tc.title, func(t *testing.T) {
@@ -355,8 +424,15 @@ func testNodeSourceEndpoints(t *testing.T) {
 				},
 				Status: v1.NodeStatus{
 					Addresses: tc.nodeAddresses,
+					Conditions: []v1.NodeCondition{{
+						Type:   v1.NodeReady,
+						Status: v1.ConditionTrue,
+					}},
 				},
 			}
+			if tc.notReady {
+				node.Status.Conditions[0].Status = v1.ConditionFalse
+			}
 
 			_, err := kubernetes.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
 			require.NoError(t, err)
@@ -368,6 +444,8 @@ func testNodeSourceEndpoints(t *testing.T) {
 				tc.annotationFilter,
 				tc.fqdnTemplate,
 				labelSelector,
+				tc.excludeNotReady,
+				tc.addressTypes,
 			)
 			require.NoError(t, err)
 
@@ -380,6 +458,36 @@ func testNodeSourceEndpoints(t *testing.T) {
 
 			// Validate returned endpoints against desired endpoints.
 			validateEndpoints(t, endpoints, tc.expected)
+			for _, ep := range endpoints {
+				assert.Equal(t, "node/"+tc.nodeName, ep.Labels[endpoint.ResourceLabelKey])
+			}
 		})
 	}
 }
+
+// testNodeSourceEventHandler tests that a changed Node triggers the event handler.
+func testNodeSourceEventHandler(t *testing.T) {
+	t.Parallel()
+
+	kubernetes := fake.NewSimpleClientset()
+	client, err := NewNodeSource(context.TODO(), kubernetes, "", "", labels.Everything(), true, nil)
+	require.NoError(t, err)
+
+	events := make(chan struct{}, 1)
+	client.AddEventHandler(context.TODO(), func() {
+		select {
+		case events <- struct{}{}:
+		default:
+		}
+	})
+
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	_, err = kubernetes.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	select {
+	case <-events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+}
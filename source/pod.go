@@ -18,8 +18,12 @@ package source
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
 
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
@@ -31,15 +35,18 @@ import (
 )
 
 type podSource struct {
-	client        kubernetes.Interface
-	namespace     string
-	podInformer   coreinformers.PodInformer
-	nodeInformer  coreinformers.NodeInformer
-	compatibility string
+	client          kubernetes.Interface
+	namespace       string
+	podInformer     coreinformers.PodInformer
+	nodeInformer    coreinformers.NodeInformer
+	compatibility   string
+	excludeNotReady bool
+	srvRecords      bool
 }
 
-// NewPodSource creates a new podSource with the given config.
-func NewPodSource(ctx context.Context, kubeClient kubernetes.Interface, namespace string, compatibility string) (Source, error) {
+// NewPodSource creates a new podSource with the given config. If srvRecords is set, the named container ports of
+// the pods are published as SRV records of their templated hostnames.
+func NewPodSource(ctx context.Context, kubeClient kubernetes.Interface, namespace string, compatibility string, excludeNotReady, srvRecords bool) (Source, error) {
 	informerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 0, kubeinformers.WithNamespace(namespace))
 	podInformer := informerFactory.Core().V1().Pods()
 	nodeInformer := informerFactory.Core().V1().Nodes()
@@ -65,15 +72,27 @@ func NewPodSource(ctx context.Context, kubeClient kubernetes.Interface, namespac
 	}
 
 	return &podSource{
-		client:        kubeClient,
-		podInformer:   podInformer,
-		nodeInformer:  nodeInformer,
-		namespace:     namespace,
-		compatibility: compatibility,
+		client:          kubeClient,
+		podInformer:     podInformer,
+		nodeInformer:    nodeInformer,
+		namespace:       namespace,
+		compatibility:   compatibility,
+		excludeNotReady: excludeNotReady,
+		srvRecords:      srvRecords,
 	}, nil
 }
 
-func (*podSource) AddEventHandler(ctx context.Context, handler func()) {
+func (ps *podSource) resourceVersion() (string, bool) {
+	return informersVersion(ps.podInformer.Informer(), ps.nodeInformer.Informer())
+}
+
+func (ps *podSource) AddEventHandler(ctx context.Context, handler func()) {
+	log.Debug("Adding event handler for pod")
+
+	// Right now there is no way to remove event handler from informer, see:
+	// https://github.com/kubernetes/kubernetes/issues/79610
+	ps.podInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+	ps.nodeInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
 }
 
 func (ps *podSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
@@ -83,77 +102,162 @@ func (ps *podSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error
 	}
 
 	endpointMap := make(map[endpoint.EndpointKey][]string)
+	resources := make(map[endpoint.EndpointKey]string)
 	for _, pod := range pods {
-		if !pod.Spec.HostNetwork {
-			log.Debugf("skipping pod %s. hostNetwork=false", pod.Name)
+		if isExcluded(pod.Annotations, "pod", pod.Namespace, pod.Name) {
+			continue
+		}
+
+		if ps.excludeNotReady && !isPodReady(pod) {
+			log.Debugf("skipping pod %s. ready=false", pod.Name)
 			continue
 		}
 
-		targets := getTargetsFromTargetAnnotation(pod.Annotations)
+		podEndpointMap := make(map[endpoint.EndpointKey][]string)
+		targets := annotations.TargetsFromTargetAnnotation(pod.Annotations)
 
-		if domainAnnotation, ok := pod.Annotations[internalHostnameAnnotationKey]; ok {
-			domainList := splitHostnameAnnotation(domainAnnotation)
-			for _, domain := range domainList {
-				if len(targets) == 0 {
-					addToEndpointMap(endpointMap, domain, suitableType(pod.Status.PodIP), pod.Status.PodIP)
-				} else {
-					for _, target := range targets {
-						addToEndpointMap(endpointMap, domain, suitableType(target), target)
-					}
+		// the templated hostnames are published for every pod, they point to the pod itself
+		for _, domain := range ps.templateHostnames(pod) {
+			if len(targets) == 0 && pod.Status.PodIP != "" {
+				addToEndpointMap(podEndpointMap, domain, suitableType(pod.Status.PodIP), pod.Status.PodIP)
+			}
+			for _, target := range targets {
+				addToEndpointMap(podEndpointMap, domain, suitableType(target), target)
+			}
+			if ps.srvRecords {
+				addSRVToEndpointMap(podEndpointMap, pod, domain)
+			}
+		}
+
+		if !pod.Spec.HostNetwork {
+			if len(podEndpointMap) == 0 {
+				log.Debugf("skipping pod %s. hostNetwork=false", pod.Name)
+			}
+			addPodEndpoints(endpointMap, resources, pod, podEndpointMap)
+			continue
+		}
+
+		for _, domain := range annotations.InternalHostnamesFromAnnotations(pod.Annotations) {
+			if len(targets) == 0 {
+				addToEndpointMap(podEndpointMap, domain, suitableType(pod.Status.PodIP), pod.Status.PodIP)
+			} else {
+				for _, target := range targets {
+					addToEndpointMap(podEndpointMap, domain, suitableType(target), target)
 				}
 			}
 		}
 
-		if domainAnnotation, ok := pod.Annotations[hostnameAnnotationKey]; ok {
-			domainList := splitHostnameAnnotation(domainAnnotation)
-			for _, domain := range domainList {
-				if len(targets) == 0 {
-					node, _ := ps.nodeInformer.Lister().Get(pod.Spec.NodeName)
-					for _, address := range node.Status.Addresses {
-						recordType := suitableType(address.Address)
-						// IPv6 addresses are labeled as NodeInternalIP despite being usable externally as well.
-						if address.Type == corev1.NodeExternalIP || (address.Type == corev1.NodeInternalIP && recordType == endpoint.RecordTypeAAAA) {
-							addToEndpointMap(endpointMap, domain, recordType, address.Address)
-						}
-					}
-				} else {
-					for _, target := range targets {
-						addToEndpointMap(endpointMap, domain, suitableType(target), target)
+		for _, domain := range annotations.HostnamesFromAnnotations(pod.Annotations) {
+			if len(targets) == 0 {
+				node, _ := ps.nodeInformer.Lister().Get(pod.Spec.NodeName)
+				for _, address := range node.Status.Addresses {
+					recordType := suitableType(address.Address)
+					// IPv6 addresses are labeled as NodeInternalIP despite being usable externally as well.
+					if address.Type == corev1.NodeExternalIP || (address.Type == corev1.NodeInternalIP && recordType == endpoint.RecordTypeAAAA) {
+						addToEndpointMap(podEndpointMap, domain, recordType, address.Address)
 					}
 				}
+			} else {
+				for _, target := range targets {
+					addToEndpointMap(podEndpointMap, domain, suitableType(target), target)
+				}
 			}
 		}
 
 		if ps.compatibility == "kops-dns-controller" {
 			if domainAnnotation, ok := pod.Annotations[kopsDNSControllerInternalHostnameAnnotationKey]; ok {
-				domainList := splitHostnameAnnotation(domainAnnotation)
+				domainList := annotations.SplitHostnameAnnotation(domainAnnotation)
 				for _, domain := range domainList {
-					addToEndpointMap(endpointMap, domain, suitableType(pod.Status.PodIP), pod.Status.PodIP)
+					addToEndpointMap(podEndpointMap, domain, suitableType(pod.Status.PodIP), pod.Status.PodIP)
 				}
 			}
 
 			if domainAnnotation, ok := pod.Annotations[kopsDNSControllerHostnameAnnotationKey]; ok {
-				domainList := splitHostnameAnnotation(domainAnnotation)
+				domainList := annotations.SplitHostnameAnnotation(domainAnnotation)
 				for _, domain := range domainList {
 					node, _ := ps.nodeInformer.Lister().Get(pod.Spec.NodeName)
 					for _, address := range node.Status.Addresses {
 						recordType := suitableType(address.Address)
 						// IPv6 addresses are labeled as NodeInternalIP despite being usable externally as well.
 						if address.Type == corev1.NodeExternalIP || (address.Type == corev1.NodeInternalIP && recordType == endpoint.RecordTypeAAAA) {
-							addToEndpointMap(endpointMap, domain, recordType, address.Address)
+							addToEndpointMap(podEndpointMap, domain, recordType, address.Address)
 						}
 					}
 				}
 			}
 		}
+
+		addPodEndpoints(endpointMap, resources, pod, podEndpointMap)
 	}
 	endpoints := []*endpoint.Endpoint{}
 	for key, targets := range endpointMap {
-		endpoints = append(endpoints, endpoint.NewEndpoint(key.DNSName, key.RecordType, targets...))
+		ep := endpoint.NewEndpoint(key.DNSName, key.RecordType, targets...)
+		ep.Labels[endpoint.ResourceLabelKey] = resources[key]
+		endpoints = append(endpoints, ep)
 	}
 	return endpoints, nil
 }
 
+// addPodEndpoints adds the endpoints of a pod to the endpoints of all pods.
+func addPodEndpoints(endpointMap map[endpoint.EndpointKey][]string, resources map[endpoint.EndpointKey]string, pod *corev1.Pod, podEndpointMap map[endpoint.EndpointKey][]string) {
+	// several pods can share a record, the resource label names the alphabetically first one of them
+	resource := fmt.Sprintf("pod/%s/%s", pod.Namespace, pod.Name)
+	for key, addresses := range podEndpointMap {
+		endpointMap[key] = append(endpointMap[key], addresses...)
+		if current, ok := resources[key]; !ok || resource < current {
+			resources[key] = resource
+		}
+	}
+}
+
+// templateHostnames executes the hostname template annotation of the pod, e.g.
+// {{.Spec.Hostname}}.{{index .Labels "app"}}.example.org for the pods of a StatefulSet.
+func (ps *podSource) templateHostnames(pod *corev1.Pod) []string {
+	text, ok := pod.Annotations[hostnameTemplateKey]
+	if !ok {
+		return nil
+	}
+	tmpl, err := parseTemplate(text)
+	if err == nil && tmpl != nil {
+		var hostnames []string
+		hostnames, err = execTemplate(tmpl, pod)
+		if err == nil {
+			return slices.DeleteFunc(hostnames, func(hostname string) bool { return hostname == "" })
+		}
+	}
+	log.Warnf("Invalid hostname template of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	return nil
+}
+
+// addSRVToEndpointMap adds an SRV record for each named container port of the pod, following the RFC 2782 format
+// _service._proto.name with the port name as service.
+func addSRVToEndpointMap(endpointMap map[endpoint.EndpointKey][]string, pod *corev1.Pod, domain string) {
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == "" {
+				continue
+			}
+			protocol := strings.ToLower(string(port.Protocol))
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			recordName := fmt.Sprintf("_%s._%s.%s", port.Name, protocol, domain)
+			// a priority of 0 and a weight of 50, like the SRV records of NodePort services
+			addToEndpointMap(endpointMap, recordName, endpoint.RecordTypeSRV, fmt.Sprintf("0 50 %d %s", port.ContainerPort, domain))
+		}
+	}
+}
+
+// isPodReady returns whether the Ready condition of the pod is true.
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
 func addToEndpointMap(endpointMap map[endpoint.EndpointKey][]string, domain string, recordType string, address string) {
 	key := endpoint.EndpointKey{
 		DNSName:    domain,
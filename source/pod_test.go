@@ -20,6 +20,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -662,7 +663,7 @@ func TestPodSource(t *testing.T) {
 				}
 			}
 
-			client, err := NewPodSource(context.TODO(), kubernetes, tc.targetNamespace, tc.compatibility)
+			client, err := NewPodSource(context.TODO(), kubernetes, tc.targetNamespace, tc.compatibility, false, false)
 			require.NoError(t, err)
 
 			endpoints, err := client.Endpoints(ctx)
@@ -678,3 +679,131 @@ func TestPodSource(t *testing.T) {
 
 	}
 }
+
+func TestPodSourceResourceLabel(t *testing.T) {
+	kubernetes := fake.NewSimpleClientset()
+	ctx := context.Background()
+
+	for _, name := range []string{"my-pod2", "my-pod1"} {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "kube-system",
+				Annotations: map[string]string{
+					internalHostnameAnnotationKey: "internal.a.foo.example.org",
+				},
+			},
+			Spec: corev1.PodSpec{
+				HostNetwork: true,
+			},
+			Status: corev1.PodStatus{
+				PodIP: "10.0.1." + name[len(name)-1:],
+			},
+		}
+		_, err := kubernetes.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	client, err := NewPodSource(ctx, kubernetes, "", "", false, false)
+	require.NoError(t, err)
+
+	endpoints, err := client.Endpoints(ctx)
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+	assert.ElementsMatch(t, []string{"10.0.1.1", "10.0.1.2"}, endpoints[0].Targets)
+	// the record is shared, it's attributed to the first of the pods
+	assert.Equal(t, "pod/kube-system/my-pod1", endpoints[0].Labels[endpoint.ResourceLabelKey])
+}
+
+func TestPodSourceHostnameTemplate(t *testing.T) {
+	t.Parallel()
+
+	newPod := func(name string, ready bool, annotations map[string]string) *corev1.Pod {
+		status := corev1.ConditionTrue
+		if !ready {
+			status = corev1.ConditionFalse
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Labels:      map[string]string{"app": "db"},
+				Annotations: annotations,
+			},
+			Spec: corev1.PodSpec{
+				Hostname: name,
+				Containers: []corev1.Container{{
+					Ports: []corev1.ContainerPort{
+						{Name: "postgres", ContainerPort: 5432},
+						{ContainerPort: 9187},
+					},
+				}},
+			},
+			Status: corev1.PodStatus{
+				PodIP:      "10.0.1." + name[len(name)-1:],
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+			},
+		}
+	}
+	template := map[string]string{hostnameTemplateKey: `{{.Spec.Hostname}}.{{index .Labels "app"}}.example.org`}
+
+	for _, tc := range []struct {
+		title           string
+		pods            []*corev1.Pod
+		excludeNotReady bool
+		srvRecords      bool
+		expected        []*endpoint.Endpoint
+	}{
+		{
+			title: "templated hostnames of pods without host network",
+			pods:  []*corev1.Pod{newPod("db-0", true, template), newPod("db-1", false, template)},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "db-0.db.example.org", Targets: endpoint.Targets{"10.0.1.0"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "db-1.db.example.org", Targets: endpoint.Targets{"10.0.1.1"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:           "not ready pods are skipped",
+			pods:            []*corev1.Pod{newPod("db-0", true, template), newPod("db-1", false, template)},
+			excludeNotReady: true,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "db-0.db.example.org", Targets: endpoint.Targets{"10.0.1.0"}, RecordType: endpoint.RecordTypeA},
+			},
+		},
+		{
+			title:      "SRV records of the named container ports",
+			pods:       []*corev1.Pod{newPod("db-0", true, template)},
+			srvRecords: true,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "db-0.db.example.org", Targets: endpoint.Targets{"10.0.1.0"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "_postgres._tcp.db-0.db.example.org", Targets: endpoint.Targets{"0 50 5432 db-0.db.example.org"}, RecordType: endpoint.RecordTypeSRV},
+			},
+		},
+		{
+			title: "invalid template",
+			pods:  []*corev1.Pod{newPod("db-0", true, map[string]string{hostnameTemplateKey: "{{.Spec.Hostname"})},
+		},
+		{
+			title: "pods without template nor host network",
+			pods:  []*corev1.Pod{newPod("db-0", true, nil)},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			kubernetes := fake.NewSimpleClientset()
+			ctx := context.Background()
+			for _, pod := range tc.pods {
+				_, err := kubernetes.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			client, err := NewPodSource(ctx, kubernetes, "", "", tc.excludeNotReady, tc.srvRecords)
+			require.NoError(t, err)
+
+			endpoints, err := client.Endpoints(ctx)
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	// scheduledRecordMaxTTL caps the TTL of records with a schedule, so resolvers pick up
+	// the swapped targets shortly after a window starts or ends.
+	scheduledRecordMaxTTL endpoint.TTL = 300
+	// maxScheduleDuration bounds the length of a window, as its start is searched minute by minute.
+	maxScheduleDuration = 7 * 24 * time.Hour
+)
+
+// cronSchedule is a parsed cron expression. Each field holds the allowed values as a bitset.
+type cronSchedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek uint64
+	// anyDayOfMonth and anyDayOfWeek are set if the field is "*", see matches
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	// both 0 and 7 are sunday
+	{"day of week", 0, 7},
+}
+
+// parseCronSchedule parses a cron expression with the five fields minute, hour, day of month,
+// month and day of week. Each field is "*" or a comma separated list of values and ranges,
+// optionally followed by a step like "*/15" or "1-5/2".
+func parseCronSchedule(spec string) (*cronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("schedule %q must have %d fields, got %d", spec, len(cronFields), len(fields))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		b, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in schedule %q: %w", cronFields[i].name, spec, err)
+		}
+		bits[i] = b
+	}
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return &cronSchedule{
+		minute:        bits[0],
+		hour:          bits[1],
+		dayOfMonth:    bits[2],
+		month:         bits[3],
+		dayOfWeek:     bits[4],
+		anyDayOfMonth: fields[2] == "*",
+		anyDayOfWeek:  fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		values, stepValue, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepValue)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepValue)
+			}
+		}
+
+		first, last := min, max
+		if values != "*" {
+			from, to, isRange := strings.Cut(values, "-")
+			var err error
+			if first, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", from)
+			}
+			switch {
+			case isRange:
+				if last, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid value %q", to)
+				}
+			case !hasStep:
+				last = first
+			}
+		}
+		if first < min || last > max || first > last {
+			return 0, fmt.Errorf("%q is out of the range %d-%d", part, min, max)
+		}
+
+		for v := first; v <= last; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// matches reports whether the schedule fires at the minute of t.
+func (c *cronSchedule) matches(t time.Time) bool {
+	if c.minute&(1<<uint(t.Minute())) == 0 || c.hour&(1<<uint(t.Hour())) == 0 || c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	dayOfMonth := c.dayOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := c.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	// like cron, a day matches either field if both of them are restricted
+	if c.anyDayOfMonth || c.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+// active reports whether now lies within a window of the given duration started by the schedule.
+func (c *cronSchedule) active(now time.Time, duration time.Duration) bool {
+	for t := now.Truncate(time.Minute); now.Sub(t) < duration; t = t.Add(-time.Minute) {
+		if c.matches(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleActive validates the schedule and reports whether its window is active at now.
+func scheduleActive(schedule *endpoint.TargetSchedule, now time.Time) (bool, error) {
+	if len(schedule.Targets) == 0 {
+		return false, errors.New("no targets")
+	}
+	duration := schedule.Duration.Duration
+	if duration <= 0 || duration > maxScheduleDuration {
+		return false, fmt.Errorf("duration %s must be positive and at most %s", duration, maxScheduleDuration)
+	}
+	cron, err := parseCronSchedule(schedule.Schedule)
+	if err != nil {
+		return false, err
+	}
+	location, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		return false, fmt.Errorf("invalid time zone %q: %w", schedule.TimeZone, err)
+	}
+	return cron.active(now.In(location), duration), nil
+}
+
+// applyTargetSchedules replaces the targets of the endpoints whose schedule is active at now.
+// The TTL of every endpoint with a valid schedule is capped, so the swap is visible soon.
+func applyTargetSchedules(crd *endpoint.DNSEndpoint, endpoints []*endpoint.Endpoint, now time.Time) {
+	for _, schedule := range crd.Spec.Schedules {
+		if schedule == nil {
+			continue
+		}
+		active, err := scheduleActive(schedule, now)
+		if err != nil {
+			log.Warnf("Ignoring schedule for %s record %s of %s/%s: %v", schedule.RecordType, schedule.DNSName, crd.Namespace, crd.Name, err)
+			continue
+		}
+
+		maxTTL := min(scheduledRecordMaxTTL, endpoint.TTL(schedule.Duration.Seconds()))
+		found := false
+		for _, ep := range endpoints {
+			if ep.DNSName != schedule.DNSName || ep.RecordType != schedule.RecordType || ep.SetIdentifier != schedule.SetIdentifier {
+				continue
+			}
+			found = true
+			if active {
+				log.Debugf("Schedule %q of %s/%s is active, %s record %s points to %v", schedule.Schedule, crd.Namespace, crd.Name, ep.RecordType, ep.DNSName, schedule.Targets)
+				ep.Targets = append(endpoint.Targets{}, schedule.Targets...)
+			}
+			if !ep.RecordTTL.IsConfigured() || ep.RecordTTL > maxTTL {
+				ep.RecordTTL = maxTTL
+			}
+		}
+		if !found {
+			log.Warnf("Schedule for %s record %s of %s/%s matches no endpoint", schedule.RecordType, schedule.DNSName, crd.Namespace, crd.Name)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestParseCronScheduleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-b * * * *",
+	} {
+		_, err := parseCronSchedule(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCronScheduleMatches(t *testing.T) {
+	// a Monday
+	monday := time.Date(2024, time.June, 3, 22, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		spec     string
+		time     time.Time
+		expected bool
+	}{
+		{"* * * * *", monday, true},
+		{"0 22 * * *", monday, true},
+		{"0 22 * * *", monday.Add(time.Minute), false},
+		{"*/15 * * * *", monday.Add(45 * time.Minute), true},
+		{"*/15 * * * *", monday.Add(50 * time.Minute), false},
+		{"5/15 * * * *", monday.Add(20 * time.Minute), true},
+		{"0,30 20-23 * * *", monday.Add(30 * time.Minute), true},
+		{"0 22 * * 1-5", monday, true},
+		{"0 22 * * 0,6", monday, false},
+		{"0 22 * * 7", monday.Add(6 * 24 * time.Hour), true},
+		{"0 22 * 6 *", monday, true},
+		{"0 22 * 7 *", monday, false},
+		// both days restricted, either one matches
+		{"0 22 3 * 5", monday, true},
+		{"0 22 4 * 1", monday, true},
+		{"0 22 4 * 5", monday, false},
+	} {
+		cron, err := parseCronSchedule(tc.spec)
+		require.NoError(t, err, tc.spec)
+		assert.Equal(t, tc.expected, cron.matches(tc.time), "%s at %s", tc.spec, tc.time)
+	}
+}
+
+func TestCronScheduleActive(t *testing.T) {
+	cron, err := parseCronSchedule("0 22 * * *")
+	require.NoError(t, err)
+	start := time.Date(2024, time.June, 3, 22, 0, 0, 0, time.UTC)
+
+	assert.False(t, cron.active(start.Add(-time.Second), 8*time.Hour))
+	assert.True(t, cron.active(start, 8*time.Hour))
+	assert.True(t, cron.active(start.Add(5*time.Hour+30*time.Second), 8*time.Hour))
+	assert.True(t, cron.active(start.Add(8*time.Hour-time.Second), 8*time.Hour))
+	assert.False(t, cron.active(start.Add(8*time.Hour), 8*time.Hour))
+}
+
+func TestScheduleActive(t *testing.T) {
+	schedule := &endpoint.TargetSchedule{
+		Schedule: "0 22 * * *",
+		Duration: metav1.Duration{Duration: 8 * time.Hour},
+		TimeZone: "Europe/Berlin",
+		Targets:  endpoint.Targets{"10.0.0.2"},
+	}
+	// 22:00 in Berlin is 20:00 UTC in summer
+	active, err := scheduleActive(schedule, time.Date(2024, time.June, 3, 20, 30, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, active)
+
+	active, err = scheduleActive(schedule, time.Date(2024, time.June, 3, 4, 30, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.False(t, active)
+
+	for _, invalid := range []func(s *endpoint.TargetSchedule){
+		func(s *endpoint.TargetSchedule) { s.Targets = nil },
+		func(s *endpoint.TargetSchedule) { s.Duration.Duration = 0 },
+		func(s *endpoint.TargetSchedule) { s.Duration.Duration = 8 * 24 * time.Hour },
+		func(s *endpoint.TargetSchedule) { s.Schedule = "0 22 * *" },
+		func(s *endpoint.TargetSchedule) { s.TimeZone = "Nowhere/Special" },
+	} {
+		s := *schedule
+		invalid(&s)
+		_, err := scheduleActive(&s, time.Now())
+		assert.Error(t, err)
+	}
+}
+
+func TestApplyTargetSchedules(t *testing.T) {
+	newCRD := func() (*endpoint.DNSEndpoint, []*endpoint.Endpoint) {
+		endpoints := []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("www.example.org", endpoint.RecordTypeA, 3600, "10.0.0.1"),
+			endpoint.NewEndpoint("api.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+		}
+		crd := &endpoint.DNSEndpoint{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "maintenance"},
+			Spec: endpoint.DNSEndpointSpec{
+				Endpoints: endpoints,
+				Schedules: []*endpoint.TargetSchedule{
+					{
+						DNSName:    "www.example.org",
+						RecordType: endpoint.RecordTypeA,
+						Schedule:   "0 22 * * *",
+						Duration:   metav1.Duration{Duration: 8 * time.Hour},
+						Targets:    endpoint.Targets{"10.0.0.2"},
+					},
+					{
+						DNSName:    "missing.example.org",
+						RecordType: endpoint.RecordTypeA,
+						Schedule:   "0 22 * * *",
+						Duration:   metav1.Duration{Duration: 8 * time.Hour},
+						Targets:    endpoint.Targets{"10.0.0.2"},
+					},
+					{
+						DNSName:    "api.example.org",
+						RecordType: endpoint.RecordTypeA,
+						Schedule:   "invalid",
+						Duration:   metav1.Duration{Duration: 8 * time.Hour},
+						Targets:    endpoint.Targets{"10.0.0.2"},
+					},
+				},
+			},
+		}
+		return crd, endpoints
+	}
+
+	crd, endpoints := newCRD()
+	applyTargetSchedules(crd, endpoints, time.Date(2024, time.June, 3, 23, 0, 0, 0, time.UTC))
+	assert.Equal(t, endpoint.Targets{"10.0.0.2"}, endpoints[0].Targets)
+	assert.Equal(t, scheduledRecordMaxTTL, endpoints[0].RecordTTL)
+	assert.Equal(t, endpoint.Targets{"10.0.0.1"}, endpoints[1].Targets)
+	assert.False(t, endpoints[1].RecordTTL.IsConfigured())
+
+	crd, endpoints = newCRD()
+	applyTargetSchedules(crd, endpoints, time.Date(2024, time.June, 3, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, endpoint.Targets{"10.0.0.1"}, endpoints[0].Targets)
+	assert.Equal(t, scheduledRecordMaxTTL, endpoints[0].RecordTTL)
+
+	// short windows cap the TTL to their duration
+	crd, endpoints = newCRD()
+	crd.Spec.Schedules[0].Duration.Duration = time.Minute
+	applyTargetSchedules(crd, endpoints, time.Date(2024, time.June, 3, 22, 0, 30, 0, time.UTC))
+	assert.Equal(t, endpoint.Targets{"10.0.0.2"}, endpoints[0].Targets)
+	assert.Equal(t, endpoint.TTL(60), endpoints[0].RecordTTL)
+}
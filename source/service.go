@@ -21,19 +21,24 @@ import (
 	"fmt"
 	"net"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
+	"github.com/miekg/dns"
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	kubeinformers "k8s.io/client-go/informers"
 	coreinformers "k8s.io/client-go/informers/core/v1"
+	discoveryinformers "k8s.io/client-go/informers/discovery/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
 )
 
 // serviceSource is an implementation of Source for Kubernetes service objects.
@@ -56,15 +61,18 @@ type serviceSource struct {
 	alwaysPublishNotReadyAddresses bool
 	resolveLoadBalancerHostname    bool
 	serviceInformer                coreinformers.ServiceInformer
-	endpointsInformer              coreinformers.EndpointsInformer
+	endpointSliceInformer          discoveryinformers.EndpointSliceInformer
 	podInformer                    coreinformers.PodInformer
 	nodeInformer                   coreinformers.NodeInformer
 	serviceTypeFilter              map[string]struct{}
 	labelSelector                  labels.Selector
+	flaggerAware                   bool
+	flaggerPublishCanary           bool
+	nsDefaults                     *namespaceDefaults
 }
 
 // NewServiceSource creates a new serviceSource with the given config.
-func NewServiceSource(ctx context.Context, kubeClient kubernetes.Interface, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool, compatibility string, publishInternal bool, publishHostIP bool, alwaysPublishNotReadyAddresses bool, serviceTypeFilter []string, ignoreHostnameAnnotation bool, labelSelector labels.Selector, resolveLoadBalancerHostname bool) (Source, error) {
+func NewServiceSource(ctx context.Context, kubeClient kubernetes.Interface, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool, compatibility string, publishInternal bool, publishHostIP bool, alwaysPublishNotReadyAddresses bool, serviceTypeFilter []string, ignoreHostnameAnnotation bool, labelSelector labels.Selector, resolveLoadBalancerHostname bool, flaggerAware bool, flaggerPublishCanary bool, namespaceDefaultAnnotations bool) (Source, error) {
 	tmpl, err := parseTemplate(fqdnTemplate)
 	if err != nil {
 		return nil, err
@@ -74,7 +82,7 @@ func NewServiceSource(ctx context.Context, kubeClient kubernetes.Interface, name
 	// Set resync period to 0, to prevent processing when nothing has changed
 	informerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 0, kubeinformers.WithNamespace(namespace))
 	serviceInformer := informerFactory.Core().V1().Services()
-	endpointsInformer := informerFactory.Core().V1().Endpoints()
+	endpointSliceInformer := informerFactory.Discovery().V1().EndpointSlices()
 	podInformer := informerFactory.Core().V1().Pods()
 	nodeInformer := informerFactory.Core().V1().Nodes()
 
@@ -85,7 +93,7 @@ func NewServiceSource(ctx context.Context, kubeClient kubernetes.Interface, name
 			},
 		},
 	)
-	endpointsInformer.Informer().AddEventHandler(
+	endpointSliceInformer.Informer().AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
 			},
@@ -111,6 +119,11 @@ func NewServiceSource(ctx context.Context, kubeClient kubernetes.Interface, name
 		return nil, err
 	}
 
+	nsDefaults, err := newNamespaceDefaults(ctx, kubeClient, namespaceDefaultAnnotations)
+	if err != nil {
+		return nil, err
+	}
+
 	// Transform the slice into a map so it will
 	// be way much easier and fast to filter later
 	serviceTypes := make(map[string]struct{})
@@ -130,12 +143,15 @@ func NewServiceSource(ctx context.Context, kubeClient kubernetes.Interface, name
 		publishHostIP:                  publishHostIP,
 		alwaysPublishNotReadyAddresses: alwaysPublishNotReadyAddresses,
 		serviceInformer:                serviceInformer,
-		endpointsInformer:              endpointsInformer,
+		endpointSliceInformer:          endpointSliceInformer,
 		podInformer:                    podInformer,
 		nodeInformer:                   nodeInformer,
 		serviceTypeFilter:              serviceTypes,
 		labelSelector:                  labelSelector,
 		resolveLoadBalancerHostname:    resolveLoadBalancerHostname,
+		flaggerAware:                   flaggerAware,
+		flaggerPublishCanary:           flaggerPublishCanary,
+		nsDefaults:                     nsDefaults,
 	}, nil
 }
 
@@ -158,14 +174,26 @@ func (sc *serviceSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 	endpoints := []*endpoint.Endpoint{}
 
 	for _, svc := range services {
+		if isExcluded(svc.Annotations, "service", svc.Namespace, svc.Name) {
+			continue
+		}
+
 		// Check controller annotation to see if we are responsible.
-		controller, ok := svc.Annotations[controllerAnnotationKey]
-		if ok && controller != controllerAnnotationValue {
+		if controller, foreign := annotations.ForeignController(svc.Annotations); foreign {
 			log.Debugf("Skipping service %s/%s because controller value does not match, found: %s, required: %s",
 				svc.Namespace, svc.Name, controller, controllerAnnotationValue)
 			continue
 		}
 
+		if annots, ok := sc.nsDefaults.annotations(svc.Namespace, svc.Annotations); ok {
+			svc = svc.DeepCopy()
+			svc.Annotations = annots
+		}
+
+		if sc.flaggerAware && !sc.publishFlaggerService(svc) {
+			continue
+		}
+
 		svcEndpoints := sc.endpoints(svc)
 
 		// process legacy annotations if no endpoints were returned and compatibility mode is enabled.
@@ -190,13 +218,24 @@ func (sc *serviceSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 			}
 		}
 
+		svcEndpoints = append(svcEndpoints, sc.loadBalancerHostnameEndpoints(svc)...)
+
 		if len(svcEndpoints) == 0 {
 			log.Debugf("No endpoints could be generated from service %s/%s", svc.Namespace, svc.Name)
 			continue
 		}
 
 		log.Debugf("Endpoints generated from service: %s/%s: %v", svc.Namespace, svc.Name, svcEndpoints)
+		svcEndpoints = append(svcEndpoints, txtEndpoints(svc.Annotations, svcEndpoints)...)
+		svcEndpoints = append(svcEndpoints, serviceRecordEndpoints(svc.Annotations, svcEndpoints)...)
 		sc.setResourceLabel(svc, svcEndpoints)
+		setDescriptionLabel(svc.Annotations, svcEndpoints)
+		setResyncLabel(svc.Annotations, svcEndpoints)
+		setZoneIDLabel(svc.Annotations, svcEndpoints)
+		setVisibilityLabel(svc.Annotations, svcEndpoints)
+		setHandoffLabel(svc.Annotations, svcEndpoints)
+		setDependsOnLabel(svc.Annotations, svcEndpoints)
+		setCustomLabels(svc.Annotations, svcEndpoints)
 		endpoints = append(endpoints, svcEndpoints...)
 	}
 
@@ -240,7 +279,41 @@ func (sc *serviceSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 	return endpoints, nil
 }
 
-// extractHeadlessEndpoints extracts endpoints from a headless service using the "Endpoints" Kubernetes API resource
+// publishFlaggerService reports whether the records of a Service generated by Flagger are published. Flagger
+// generates an apex, a -primary and a -canary Service for each Canary, all owned by it. Only the apex Service is
+// published, and the -canary Service if enabled, so that a template or copied annotations don't publish the
+// -primary Service. The Services which are not generated by Flagger are always published.
+func (sc *serviceSource) publishFlaggerService(svc *v1.Service) bool {
+	canary := flaggerCanaryName(svc)
+	if canary == "" {
+		return true
+	}
+	switch svc.Name {
+	case canary:
+		return true
+	case canary + "-canary":
+		if !sc.flaggerPublishCanary {
+			log.Debugf("Skipping service %s/%s because it is the canary service of Flagger canary %s", svc.Namespace, svc.Name, canary)
+		}
+		return sc.flaggerPublishCanary
+	default:
+		log.Debugf("Skipping service %s/%s because it is generated by Flagger canary %s", svc.Namespace, svc.Name, canary)
+		return false
+	}
+}
+
+// flaggerCanaryName returns the name of the Flagger Canary owning the Service, or an empty string.
+func flaggerCanaryName(svc *v1.Service) string {
+	for _, ref := range svc.OwnerReferences {
+		if ref.Kind == "Canary" && strings.HasPrefix(ref.APIVersion, "flagger.app/") {
+			return ref.Name
+		}
+	}
+	return ""
+}
+
+// extractHeadlessEndpoints extracts endpoints from a headless service using the EndpointSlices of the service, which
+// unlike the Endpoints resource are not capped at 1000 addresses and have an EndpointSlice per IP family.
 func (sc *serviceSource) extractHeadlessEndpoints(svc *v1.Service, hostname string, ttl endpoint.TTL) []*endpoint.Endpoint {
 	var endpoints []*endpoint.Endpoint
 
@@ -253,9 +326,10 @@ func (sc *serviceSource) extractHeadlessEndpoints(svc *v1.Service, hostname stri
 		return nil
 	}
 
-	endpointsObject, err := sc.endpointsInformer.Lister().Endpoints(svc.Namespace).Get(svc.GetName())
+	endpointSlices, err := sc.endpointSliceInformer.Lister().EndpointSlices(svc.Namespace).List(
+		labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: svc.GetName()}))
 	if err != nil {
-		log.Errorf("Get endpoints of service[%s] error:%v", svc.GetName(), err)
+		log.Errorf("List endpoint slices of service[%s] error:%v", svc.GetName(), err)
 		return endpoints
 	}
 
@@ -265,16 +339,25 @@ func (sc *serviceSource) extractHeadlessEndpoints(svc *v1.Service, hostname stri
 		return endpoints
 	}
 
-	endpointsType := getEndpointsTypeFromAnnotations(svc.Annotations)
+	endpointsType := annotations.EndpointsTypeFromAnnotations(svc.Annotations)
+	zonal := annotations.ZonalRecordsFromAnnotations(svc.Annotations)
+	ptrRecords := annotations.PTRRecordsFromAnnotations(svc.Annotations)
 
+	publishNotReadyAddresses := svc.Spec.PublishNotReadyAddresses || sc.alwaysPublishNotReadyAddresses
 	targetsByHeadlessDomainAndType := make(map[endpoint.EndpointKey]endpoint.Targets)
-	for _, subset := range endpointsObject.Subsets {
-		addresses := subset.Addresses
-		if svc.Spec.PublishNotReadyAddresses || sc.alwaysPublishNotReadyAddresses {
-			addresses = append(addresses, subset.NotReadyAddresses...)
+	for _, endpointSlice := range endpointSlices {
+		if endpointSlice.AddressType != discoveryv1.AddressTypeIPv4 && endpointSlice.AddressType != discoveryv1.AddressTypeIPv6 {
+			continue
 		}
 
-		for _, address := range addresses {
+		for _, address := range endpointSlice.Endpoints {
+			// an unknown readiness is interpreted as ready
+			if address.Conditions.Ready != nil && !*address.Conditions.Ready && !publishNotReadyAddresses {
+				continue
+			}
+			if len(address.Addresses) == 0 {
+				continue
+			}
 			// find pod for this address
 			if address.TargetRef == nil || address.TargetRef.APIVersion != "" || address.TargetRef.Kind != "Pod" {
 				log.Debugf("Skipping address because its target is not a pod: %v", address)
@@ -293,12 +376,19 @@ func (sc *serviceSource) extractHeadlessEndpoints(svc *v1.Service, hostname stri
 			}
 
 			headlessDomains := []string{hostname}
+			podDomain := ""
 			if pod.Spec.Hostname != "" {
-				headlessDomains = append(headlessDomains, fmt.Sprintf("%s.%s", pod.Spec.Hostname, hostname))
+				podDomain = fmt.Sprintf("%s.%s", pod.Spec.Hostname, hostname)
+				headlessDomains = append(headlessDomains, podDomain)
+			}
+			if zonal {
+				if zone := sc.nodeZone(pod.Spec.NodeName); zone != "" {
+					headlessDomains = append(headlessDomains, zonalHostname(zone, hostname))
+				}
 			}
 
 			for _, headlessDomain := range headlessDomains {
-				targets := getTargetsFromTargetAnnotation(pod.Annotations)
+				targets := annotations.TargetsFromTargetAnnotation(pod.Annotations)
 				if len(targets) == 0 {
 					if endpointsType == EndpointsTypeNodeExternalIP {
 						node, err := sc.nodeInformer.Lister().Get(pod.Spec.NodeName)
@@ -316,8 +406,24 @@ func (sc *serviceSource) extractHeadlessEndpoints(svc *v1.Service, hostname stri
 						targets = endpoint.Targets{pod.Status.HostIP}
 						log.Debugf("Generating matching endpoint %s with HostIP %s", headlessDomain, pod.Status.HostIP)
 					} else {
-						targets = endpoint.Targets{address.IP}
-						log.Debugf("Generating matching endpoint %s with EndpointAddress IP %s", headlessDomain, address.IP)
+						// the addresses are fungible, only the first one is used
+						targets = endpoint.Targets{address.Addresses[0]}
+						log.Debugf("Generating matching endpoint %s with EndpointSlice address %s", headlessDomain, targets)
+						// the reverse records of the pod IPs point to the hostname of the pod
+						if ptrRecords && headlessDomain == podDomain {
+							for _, target := range targets {
+								reverseAddress, err := dns.ReverseAddr(target)
+								if err != nil {
+									log.Warnf("Could not generate a PTR record for %s: %v", target, err)
+									continue
+								}
+								key := endpoint.EndpointKey{
+									DNSName:    strings.TrimSuffix(reverseAddress, "."),
+									RecordType: endpoint.RecordTypePTR,
+								}
+								targetsByHeadlessDomainAndType[key] = append(targetsByHeadlessDomainAndType[key], podDomain)
+							}
+						}
 					}
 				}
 				for _, target := range targets {
@@ -377,7 +483,7 @@ func (sc *serviceSource) endpointsFromTemplate(svc *v1.Service) ([]*endpoint.End
 		return nil, err
 	}
 
-	providerSpecific, setIdentifier := getProviderSpecificAnnotations(svc.Annotations)
+	providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(svc.Annotations)
 
 	var endpoints []*endpoint.Endpoint
 	for _, hostname := range hostnames {
@@ -392,16 +498,16 @@ func (sc *serviceSource) endpoints(svc *v1.Service) []*endpoint.Endpoint {
 	var endpoints []*endpoint.Endpoint
 	// Skip endpoints if we do not want entries from annotations
 	if !sc.ignoreHostnameAnnotation {
-		providerSpecific, setIdentifier := getProviderSpecificAnnotations(svc.Annotations)
+		providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(svc.Annotations)
 		var hostnameList []string
 		var internalHostnameList []string
 
-		hostnameList = getHostnamesFromAnnotations(svc.Annotations)
+		hostnameList = annotations.HostnamesFromAnnotations(svc.Annotations)
 		for _, hostname := range hostnameList {
 			endpoints = append(endpoints, sc.generateEndpoints(svc, hostname, providerSpecific, setIdentifier, false)...)
 		}
 
-		internalHostnameList = getInternalHostnamesFromAnnotations(svc.Annotations)
+		internalHostnameList = annotations.InternalHostnamesFromAnnotations(svc.Annotations)
 		for _, hostname := range internalHostnameList {
 			endpoints = append(endpoints, sc.generateEndpoints(svc, hostname, providerSpecific, setIdentifier, true)...)
 		}
@@ -464,9 +570,9 @@ func (sc *serviceSource) generateEndpoints(svc *v1.Service, hostname string, pro
 
 	resource := fmt.Sprintf("service/%s/%s", svc.Namespace, svc.Name)
 
-	ttl := getTTLFromAnnotations(svc.Annotations, resource)
+	ttl := annotations.TTLFromAnnotations(svc.Annotations, resource)
 
-	targets := getTargetsFromTargetAnnotation(svc.Annotations)
+	targets := annotations.TargetsFromTargetAnnotation(svc.Annotations)
 
 	if len(targets) == 0 {
 		switch svc.Spec.Type {
@@ -475,6 +581,9 @@ func (sc *serviceSource) generateEndpoints(svc *v1.Service, hostname string, pro
 				targets = extractServiceIps(svc)
 			} else {
 				targets = extractLoadBalancerTargets(svc, sc.resolveLoadBalancerHostname)
+				if len(sc.loadBalancerHostnames(svc)) > 0 {
+					targets = ipTargetsIfAny(targets)
+				}
 			}
 		case v1.ServiceTypeClusterIP:
 			if svc.Spec.ClusterIP == v1.ClusterIPNone {
@@ -491,6 +600,21 @@ func (sc *serviceSource) generateEndpoints(svc *v1.Service, hostname string, pro
 				return endpoints
 			}
 			endpoints = append(endpoints, sc.extractNodePortEndpoints(svc, hostname, ttl)...)
+			if annotations.ZonalRecordsFromAnnotations(svc.Annotations) {
+				targetsByZone, err := sc.extractNodePortTargetsByZone(svc)
+				if err != nil {
+					log.Errorf("Unable to extract zonal targets from service %s/%s error: %v", svc.Namespace, svc.Name, err)
+					return endpoints
+				}
+				zones := make([]string, 0, len(targetsByZone))
+				for zone := range targetsByZone {
+					zones = append(zones, zone)
+				}
+				sort.Strings(zones)
+				for _, zone := range zones {
+					endpoints = append(endpoints, endpointsForHostname(zonalHostname(zone, hostname), targetsByZone[zone], ttl, providerSpecific, setIdentifier, resource)...)
+				}
+			}
 		case v1.ServiceTypeExternalName:
 			targets = extractServiceExternalName(svc)
 		}
@@ -501,11 +625,66 @@ func (sc *serviceSource) generateEndpoints(svc *v1.Service, hostname string, pro
 		}
 	}
 
+	targets = annotations.TargetsOfIPFamilies(svc.Annotations, targets)
 	endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, resource)...)
 
 	return endpoints
 }
 
+// loadBalancerHostnames returns the hostnames of the CNAME records to the hostname of the load balancer of the Service.
+func (sc *serviceSource) loadBalancerHostnames(svc *v1.Service) []string {
+	if sc.ignoreHostnameAnnotation || svc.Spec.Type != v1.ServiceTypeLoadBalancer {
+		return nil
+	}
+	if len(annotations.TargetsFromTargetAnnotation(svc.Annotations)) > 0 || len(svc.Spec.ExternalIPs) > 0 {
+		return nil
+	}
+	return annotations.LoadBalancerHostnamesFromAnnotations(svc.Annotations)
+}
+
+// loadBalancerHostnameEndpoints returns the CNAME records to the hostname of the load balancer of the Service, for a
+// load balancer reporting both an IP and a hostname. The other hostnames of the Service get the records of the IPs.
+func (sc *serviceSource) loadBalancerHostnameEndpoints(svc *v1.Service) []*endpoint.Endpoint {
+	hostnames := sc.loadBalancerHostnames(svc)
+	if len(hostnames) == 0 {
+		return nil
+	}
+
+	var targets endpoint.Targets
+	for _, lb := range svc.Status.LoadBalancer.Ingress {
+		if lb.Hostname != "" {
+			targets = append(targets, lb.Hostname)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	resource := fmt.Sprintf("service/%s/%s", svc.Namespace, svc.Name)
+	ttl := annotations.TTLFromAnnotations(svc.Annotations, resource)
+	providerSpecific, setIdentifier := annotations.ProviderSpecificAnnotations(svc.Annotations)
+
+	var endpoints []*endpoint.Endpoint
+	for _, hostname := range hostnames {
+		endpoints = append(endpoints, endpointsForHostname(strings.TrimSuffix(hostname, "."), targets, ttl, providerSpecific, setIdentifier, resource)...)
+	}
+	return endpoints
+}
+
+// ipTargetsIfAny returns the IP addresses among the targets, or all targets if there are none.
+func ipTargetsIfAny(targets endpoint.Targets) endpoint.Targets {
+	var ips endpoint.Targets
+	for _, target := range targets {
+		if suitableType(target) != endpoint.RecordTypeCNAME {
+			ips = append(ips, target)
+		}
+	}
+	if len(ips) == 0 {
+		return targets
+	}
+	return ips
+}
+
 func extractServiceIps(svc *v1.Service) endpoint.Targets {
 	if svc.Spec.ClusterIP == v1.ClusterIPNone {
 		log.Debugf("Unable to associate %s headless service with a Cluster IP", svc.Name)
@@ -576,12 +755,66 @@ func getPodConditionFromList(conditions []v1.PodCondition, conditionType v1.PodC
 }
 
 func (sc *serviceSource) extractNodePortTargets(svc *v1.Service) (endpoint.Targets, error) {
+	nodes, err := sc.nodePortNodes(svc)
+	if err != nil {
+		return nil, err
+	}
+	return nodePortTargets(nodes, nodePortPublic(svc, nodes)), nil
+}
+
+// extractNodePortTargetsByZone returns the targets of the nodes of a NodePort service by the zone of the nodes, as
+// returned by zoneLabel. Nodes without a zone label are left out.
+func (sc *serviceSource) extractNodePortTargetsByZone(svc *v1.Service) (map[string]endpoint.Targets, error) {
+	nodes, err := sc.nodePortNodes(svc)
+	if err != nil {
+		return nil, err
+	}
+	public := nodePortPublic(svc, nodes)
+	nodesByZone := map[string][]*v1.Node{}
+	for _, node := range nodes {
+		if zone := zoneLabel(node); zone != "" {
+			nodesByZone[zone] = append(nodesByZone[zone], node)
+		}
+	}
+	targetsByZone := make(map[string]endpoint.Targets, len(nodesByZone))
+	for zone, zoneNodes := range nodesByZone {
+		targetsByZone[zone] = nodePortTargets(zoneNodes, public)
+	}
+	return targetsByZone, nil
+}
+
+// nodeZone returns the topology zone of the node, or an empty string if the node is unknown or has no zone label.
+func (sc *serviceSource) nodeZone(nodeName string) string {
+	node, err := sc.nodeInformer.Lister().Get(nodeName)
+	if err != nil {
+		log.Debugf("Get node[%s] error: %v; not adding any zonal endpoints", nodeName, err)
+		return ""
+	}
+	return zoneLabel(node)
+}
+
+// zoneLabel returns the topology zone of the node as a DNS label, e.g. eu-west-1a, or an empty string if the node
+// has no zone label.
+func zoneLabel(node *v1.Node) string {
+	label := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(node.Labels[v1.LabelTopologyZone]))
+	return strings.Trim(label, "-")
+}
+
+// zonalHostname returns the name of the records of the given zone, e.g. eu-west-1a.svc.example.org.
+func zonalHostname(zone, hostname string) string {
+	return fmt.Sprintf("%s.%s", zone, hostname)
+}
+
+// nodePortNodes returns the nodes whose addresses are the targets of a NodePort service.
+func (sc *serviceSource) nodePortNodes(svc *v1.Service) ([]*v1.Node, error) {
 	var (
-		internalIPs endpoint.Targets
-		externalIPs endpoint.Targets
-		ipv6IPs     endpoint.Targets
-		nodes       []*v1.Node
-		err         error
+		nodes []*v1.Node
+		err   error
 	)
 
 	switch svc.Spec.ExternalTrafficPolicy {
@@ -643,6 +876,36 @@ func (sc *serviceSource) extractNodePortTargets(svc *v1.Service) (endpoint.Targe
 		}
 	}
 
+	return nodes, nil
+}
+
+// nodePortPublic reports whether the public addresses of the nodes are the targets of a NodePort service, as set
+// with the access annotation or, without it, if any of the nodes has an external address.
+func nodePortPublic(svc *v1.Service, nodes []*v1.Node) bool {
+	switch annotations.AccessFromAnnotations(svc.Annotations) {
+	case "public":
+		return true
+	case "private":
+		return false
+	}
+	for _, node := range nodes {
+		for _, address := range node.Status.Addresses {
+			if address.Type == v1.NodeExternalIP {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nodePortTargets returns the public addresses of the nodes, which are the external addresses plus the internal
+// IPv6 addresses, or the internal addresses.
+func nodePortTargets(nodes []*v1.Node, public bool) endpoint.Targets {
+	var (
+		internalIPs endpoint.Targets
+		externalIPs endpoint.Targets
+		ipv6IPs     endpoint.Targets
+	)
 	for _, node := range nodes {
 		for _, address := range node.Status.Addresses {
 			switch address.Type {
@@ -656,23 +919,17 @@ func (sc *serviceSource) extractNodePortTargets(svc *v1.Service) (endpoint.Targe
 			}
 		}
 	}
-
-	access := getAccessFromAnnotations(svc.Annotations)
-	if access == "public" {
-		return append(externalIPs, ipv6IPs...), nil
-	}
-	if access == "private" {
-		return internalIPs, nil
+	if public {
+		return append(externalIPs, ipv6IPs...)
 	}
-	if len(externalIPs) > 0 {
-		return append(externalIPs, ipv6IPs...), nil
-	}
-	return internalIPs, nil
+	return internalIPs
 }
 
 func (sc *serviceSource) extractNodePortEndpoints(svc *v1.Service, hostname string, ttl endpoint.TTL) []*endpoint.Endpoint {
 	var endpoints []*endpoint.Endpoint
 
+	srvServices, customServices := annotations.SRVServicesFromAnnotations(svc.Annotations)
+
 	for _, port := range svc.Spec.Ports {
 		if port.NodePort > 0 {
 			// following the RFC 2782, SRV record must have a following format
@@ -686,6 +943,13 @@ func (sc *serviceSource) extractNodePortEndpoints(svc *v1.Service, hostname stri
 			// it is safe to use since it is DNS compatible
 			// see https://kubernetes.io/docs/concepts/overview/working-with-objects/names/#dns-label-names
 			serviceName := svc.ObjectMeta.Name
+			if customServices {
+				// well-known service names like sip or xmpp-client from the annotation, ports not listed are skipped
+				serviceName = srvServiceName(srvServices, port)
+				if serviceName == "" {
+					continue
+				}
+			}
 
 			// figure out the protocol
 			protocol := strings.ToLower(string(port.Protocol))
@@ -711,10 +975,34 @@ func (sc *serviceSource) extractNodePortEndpoints(svc *v1.Service, hostname stri
 	return endpoints
 }
 
+// srvServiceName returns the service name of the SRV record of the port from the srv-service annotation.
+func srvServiceName(srvServices map[string]string, port v1.ServicePort) string {
+	if port.Name != "" {
+		if service, ok := srvServices[port.Name]; ok {
+			return service
+		}
+	}
+	if service, ok := srvServices[strconv.Itoa(int(port.Port))]; ok {
+		return service
+	}
+	return srvServices[""]
+}
+
+func (sc *serviceSource) resourceVersion() (string, bool) {
+	if sc.resolveLoadBalancerHostname {
+		return "", false
+	}
+	return informersVersion(sc.nsDefaults.withInformer(sc.serviceInformer.Informer(), sc.endpointSliceInformer.Informer(), sc.podInformer.Informer(), sc.nodeInformer.Informer())...)
+}
+
 func (sc *serviceSource) AddEventHandler(ctx context.Context, handler func()) {
 	log.Debug("Adding event handler for service")
 
 	// Right now there is no way to remove event handler from informer, see:
 	// https://github.com/kubernetes/kubernetes/issues/79610
 	sc.serviceInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+	// The targets of headless and NodePort services follow their endpoints and nodes.
+	sc.endpointSliceInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+	sc.nodeInformer.Informer().AddEventHandler(eventHandlerFunc(handler))
+	sc.nsDefaults.addEventHandler(handler)
 }
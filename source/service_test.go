@@ -18,6 +18,7 @@ package source
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"sort"
 	"strings"
@@ -27,8 +28,10 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 
 	"sigs.k8s.io/external-dns/endpoint"
@@ -79,6 +82,9 @@ func (suite *ServiceSuite) SetupTest() {
 		false,
 		labels.Everything(),
 		false,
+		false,
+		false,
+		false,
 	)
 	suite.NoError(err, "should initialize service source")
 }
@@ -160,6 +166,9 @@ func testServiceSourceNewServiceSource(t *testing.T) {
 				false,
 				labels.Everything(),
 				false,
+				false,
+				false,
+				false,
 			)
 
 			if ti.expectError {
@@ -1131,6 +1140,9 @@ func testServiceSourceEndpoints(t *testing.T) {
 				tc.ignoreHostnameAnnotation,
 				sourceLabel,
 				tc.resolveLoadBalancerHostname,
+				false,
+				false,
+				false,
 			)
 
 			require.NoError(t, err)
@@ -1321,6 +1333,9 @@ func testMultipleServicesEndpoints(t *testing.T) {
 				tc.ignoreHostnameAnnotation,
 				labels.Everything(),
 				false,
+				false,
+				false,
+				false,
 			)
 			require.NoError(t, err)
 
@@ -1624,6 +1639,9 @@ func TestClusterIpServices(t *testing.T) {
 				tc.ignoreHostnameAnnotation,
 				labelSelector,
 				false,
+				false,
+				false,
+				false,
 			)
 			require.NoError(t, err)
 
@@ -1706,6 +1724,143 @@ func TestServiceSourceNodePortServices(t *testing.T) {
 				},
 			}},
 		},
+		{
+			title:            "srv-service annotated NodePort services use the service name of the annotation",
+			svcNamespace:     "testing",
+			svcName:          "foo",
+			svcType:          v1.ServiceTypeNodePort,
+			svcTrafficPolicy: v1.ServiceExternalTrafficPolicyTypeCluster,
+			annotations: map[string]string{
+				hostnameAnnotationKey:   "foo.example.org.",
+				srvServiceAnnotationKey: "_minecraft",
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "_minecraft._tcp.foo.example.org", Targets: endpoint.Targets{"0 50 30192 foo.example.org"}, RecordType: endpoint.RecordTypeSRV},
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"54.10.11.1"}, RecordType: endpoint.RecordTypeA},
+			},
+			nodes: []*v1.Node{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "node1",
+				},
+				Status: v1.NodeStatus{
+					Addresses: []v1.NodeAddress{
+						{Type: v1.NodeExternalIP, Address: "54.10.11.1"},
+					},
+				},
+			}},
+		},
+		{
+			title:            "srv-service annotated NodePort services skip the ports not listed",
+			svcNamespace:     "testing",
+			svcName:          "foo",
+			svcType:          v1.ServiceTypeNodePort,
+			svcTrafficPolicy: v1.ServiceExternalTrafficPolicyTypeCluster,
+			annotations: map[string]string{
+				hostnameAnnotationKey:   "foo.example.org.",
+				srvServiceAnnotationKey: "sip=sip,5060=sip",
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"54.10.11.1"}, RecordType: endpoint.RecordTypeA},
+			},
+			nodes: []*v1.Node{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "node1",
+				},
+				Status: v1.NodeStatus{
+					Addresses: []v1.NodeAddress{
+						{Type: v1.NodeExternalIP, Address: "54.10.11.1"},
+					},
+				},
+			}},
+		},
+		{
+			title:            "zonal-records annotated NodePort services return zonal endpoints plus the aggregate endpoint",
+			svcNamespace:     "testing",
+			svcName:          "foo",
+			svcType:          v1.ServiceTypeNodePort,
+			svcTrafficPolicy: v1.ServiceExternalTrafficPolicyTypeCluster,
+			annotations: map[string]string{
+				hostnameAnnotationKey:     "foo.example.org.",
+				zonalRecordsAnnotationKey: "true",
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "_foo._tcp.foo.example.org", Targets: endpoint.Targets{"0 50 30192 foo.example.org"}, RecordType: endpoint.RecordTypeSRV},
+				{DNSName: "eu-west-1a.foo.example.org", Targets: endpoint.Targets{"54.10.11.1"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "eu-west-1b.foo.example.org", Targets: endpoint.Targets{"54.10.11.2"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"54.10.11.1", "54.10.11.2", "54.10.11.3"}, RecordType: endpoint.RecordTypeA},
+			},
+			nodes: []*v1.Node{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "node1",
+					Labels: map[string]string{v1.LabelTopologyZone: "eu-west-1a"},
+				},
+				Status: v1.NodeStatus{
+					Addresses: []v1.NodeAddress{
+						{Type: v1.NodeExternalIP, Address: "54.10.11.1"},
+						{Type: v1.NodeInternalIP, Address: "10.0.1.1"},
+					},
+				},
+			}, {
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "node2",
+					Labels: map[string]string{v1.LabelTopologyZone: "EU-West-1b"},
+				},
+				Status: v1.NodeStatus{
+					Addresses: []v1.NodeAddress{
+						{Type: v1.NodeExternalIP, Address: "54.10.11.2"},
+						{Type: v1.NodeInternalIP, Address: "10.0.1.2"},
+					},
+				},
+			}, {
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "node3",
+				},
+				Status: v1.NodeStatus{
+					Addresses: []v1.NodeAddress{
+						{Type: v1.NodeExternalIP, Address: "54.10.11.3"},
+						{Type: v1.NodeInternalIP, Address: "10.0.1.3"},
+					},
+				},
+			}},
+		},
+		{
+			title:            "zonal-records annotated NodePort services use the same access for all zones",
+			svcNamespace:     "testing",
+			svcName:          "foo",
+			svcType:          v1.ServiceTypeNodePort,
+			svcTrafficPolicy: v1.ServiceExternalTrafficPolicyTypeCluster,
+			annotations: map[string]string{
+				hostnameAnnotationKey:     "foo.example.org.",
+				zonalRecordsAnnotationKey: "true",
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "_foo._tcp.foo.example.org", Targets: endpoint.Targets{"0 50 30192 foo.example.org"}, RecordType: endpoint.RecordTypeSRV},
+				{DNSName: "eu-west-1a.foo.example.org", Targets: endpoint.Targets{"54.10.11.1"}, RecordType: endpoint.RecordTypeA},
+				{DNSName: "foo.example.org", Targets: endpoint.Targets{"54.10.11.1"}, RecordType: endpoint.RecordTypeA},
+			},
+			nodes: []*v1.Node{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "node1",
+					Labels: map[string]string{v1.LabelTopologyZone: "eu-west-1a"},
+				},
+				Status: v1.NodeStatus{
+					Addresses: []v1.NodeAddress{
+						{Type: v1.NodeExternalIP, Address: "54.10.11.1"},
+						{Type: v1.NodeInternalIP, Address: "10.0.1.1"},
+					},
+				},
+			}, {
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "node2",
+					Labels: map[string]string{v1.LabelTopologyZone: "eu-west-1b"},
+				},
+				Status: v1.NodeStatus{
+					Addresses: []v1.NodeAddress{
+						{Type: v1.NodeInternalIP, Address: "10.0.1.2"},
+					},
+				},
+			}},
+		},
 		{
 			title:                    "hostname annotated NodePort services are ignored",
 			svcNamespace:             "testing",
@@ -2342,6 +2497,9 @@ func TestServiceSourceNodePortServices(t *testing.T) {
 				tc.ignoreHostnameAnnotation,
 				labels.Everything(),
 				false,
+				false,
+				false,
+				false,
 			)
 			require.NoError(t, err)
 
@@ -2817,6 +2975,47 @@ func TestHeadlessServices(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"zonal-records annotated Headless services return zonal endpoints for the zone of the node of each selected Pod",
+			"",
+			"testing",
+			"foo",
+			v1.ServiceTypeClusterIP,
+			"",
+			"",
+			false,
+			map[string]string{"component": "foo"},
+			map[string]string{
+				hostnameAnnotationKey:     "service.example.org",
+				zonalRecordsAnnotationKey: "true",
+			},
+			map[string]string{},
+			v1.ClusterIPNone,
+			[]string{"1.1.1.1", "1.1.1.2"},
+			[]string{"", ""},
+			map[string]string{
+				"component": "foo",
+			},
+			[]string{},
+			[]string{"foo-0", "foo-1"},
+			[]string{"foo-0", "foo-1"},
+			[]bool{true, true},
+			false,
+			[]v1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{v1.LabelTopologyZone: "eu-west-1a"},
+					},
+				},
+			},
+			[]*endpoint.Endpoint{
+				{DNSName: "eu-west-1a.service.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.1", "1.1.1.2"}},
+				{DNSName: "foo-0.service.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.1"}},
+				{DNSName: "foo-1.service.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.2"}},
+				{DNSName: "service.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.1", "1.1.1.2"}},
+			},
+			false,
+		},
 		{
 			"annotated Headless services return IPv6 targets from node external IP if endpoints-type annotation is set",
 			"",
@@ -2998,7 +3197,7 @@ func TestHeadlessServices(t *testing.T) {
 			_, err := kubernetes.CoreV1().Services(service.Namespace).Create(context.Background(), service, metav1.CreateOptions{})
 			require.NoError(t, err)
 
-			var addresses, notReadyAddresses []v1.EndpointAddress
+			var sliceEndpoints []discoveryv1.Endpoint
 			for i, podname := range tc.podnames {
 				pod := &v1.Pod{
 					Spec: v1.PodSpec{
@@ -3020,35 +3219,19 @@ func TestHeadlessServices(t *testing.T) {
 				_, err = kubernetes.CoreV1().Pods(tc.svcNamespace).Create(context.Background(), pod, metav1.CreateOptions{})
 				require.NoError(t, err)
 
-				address := v1.EndpointAddress{
-					IP: tc.podIPs[i],
+				sliceEndpoints = append(sliceEndpoints, discoveryv1.Endpoint{
+					Addresses: []string{tc.podIPs[i]},
+					Conditions: discoveryv1.EndpointConditions{
+						Ready: &tc.podsReady[i],
+					},
 					TargetRef: &v1.ObjectReference{
 						APIVersion: "",
 						Kind:       "Pod",
 						Name:       podname,
 					},
-				}
-				if tc.podsReady[i] {
-					addresses = append(addresses, address)
-				} else {
-					notReadyAddresses = append(notReadyAddresses, address)
-				}
-			}
-			endpointsObject := &v1.Endpoints{
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: tc.svcNamespace,
-					Name:      tc.svcName,
-					Labels:    tc.labels,
-				},
-				Subsets: []v1.EndpointSubset{
-					{
-						Addresses:         addresses,
-						NotReadyAddresses: notReadyAddresses,
-					},
-				},
+				})
 			}
-			_, err = kubernetes.CoreV1().Endpoints(tc.svcNamespace).Create(context.Background(), endpointsObject, metav1.CreateOptions{})
-			require.NoError(t, err)
+			createEndpointSlices(t, kubernetes, tc.svcNamespace, tc.svcName, sliceEndpoints)
 			for _, node := range tc.nodes {
 				_, err = kubernetes.CoreV1().Nodes().Create(context.Background(), &node, metav1.CreateOptions{})
 				require.NoError(t, err)
@@ -3070,6 +3253,9 @@ func TestHeadlessServices(t *testing.T) {
 				tc.ignoreHostnameAnnotation,
 				labels.Everything(),
 				false,
+				false,
+				false,
+				false,
 			)
 			require.NoError(t, err)
 
@@ -3086,6 +3272,122 @@ func TestHeadlessServices(t *testing.T) {
 	}
 }
 
+// TestHeadlessServicesDualStack tests that dual-stack headless services get A and AAAA records, and PTR records
+// if they are annotated.
+func TestHeadlessServicesDualStack(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		title                    string
+		ipFamilies               []v1.IPFamily
+		svcAnnotations           map[string]string
+		publishNotReadyAddresses bool
+		expected                 []*endpoint.Endpoint
+	}{
+		{
+			title:      "dual-stack service",
+			ipFamilies: []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo-0.service.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.1"}},
+				{DNSName: "foo-0.service.example.org", RecordType: endpoint.RecordTypeAAAA, Targets: endpoint.Targets{"2001:db8::1"}},
+				{DNSName: "service.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.1"}},
+				{DNSName: "service.example.org", RecordType: endpoint.RecordTypeAAAA, Targets: endpoint.Targets{"2001:db8::1"}},
+			},
+		},
+		{
+			title:      "single-stack service",
+			ipFamilies: []v1.IPFamily{v1.IPv4Protocol},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "foo-0.service.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.1"}},
+				{DNSName: "service.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.1"}},
+			},
+		},
+		{
+			title:          "PTR records of ready pods",
+			ipFamilies:     []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol},
+			svcAnnotations: map[string]string{ptrRecordsAnnotationKey: "true"},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "1.1.1.1.in-addr.arpa", RecordType: endpoint.RecordTypePTR, Targets: endpoint.Targets{"foo-0.service.example.org"}},
+				{DNSName: "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa", RecordType: endpoint.RecordTypePTR, Targets: endpoint.Targets{"foo-0.service.example.org"}},
+				{DNSName: "foo-0.service.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.1"}},
+				{DNSName: "foo-0.service.example.org", RecordType: endpoint.RecordTypeAAAA, Targets: endpoint.Targets{"2001:db8::1"}},
+				{DNSName: "service.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.1"}},
+				{DNSName: "service.example.org", RecordType: endpoint.RecordTypeAAAA, Targets: endpoint.Targets{"2001:db8::1"}},
+			},
+		},
+		{
+			title:                    "PTR records of not ready pods with publishNotReadyAddresses",
+			ipFamilies:               []v1.IPFamily{v1.IPv4Protocol},
+			svcAnnotations:           map[string]string{ptrRecordsAnnotationKey: "true"},
+			publishNotReadyAddresses: true,
+			expected: []*endpoint.Endpoint{
+				{DNSName: "1.1.1.1.in-addr.arpa", RecordType: endpoint.RecordTypePTR, Targets: endpoint.Targets{"foo-0.service.example.org"}},
+				{DNSName: "2.1.1.1.in-addr.arpa", RecordType: endpoint.RecordTypePTR, Targets: endpoint.Targets{"foo-1.service.example.org"}},
+				{DNSName: "foo-0.service.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.1"}},
+				{DNSName: "foo-1.service.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.2"}},
+				{DNSName: "service.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.1", "1.1.1.2"}},
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			kubernetes := fake.NewSimpleClientset()
+			selector := map[string]string{"component": "foo"}
+
+			svcAnnotations := map[string]string{hostnameAnnotationKey: "service.example.org"}
+			for k, v := range tc.svcAnnotations {
+				svcAnnotations[k] = v
+			}
+			service := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "testing", Name: "foo", Annotations: svcAnnotations},
+				Spec: v1.ServiceSpec{
+					Type:                     v1.ServiceTypeClusterIP,
+					ClusterIP:                v1.ClusterIPNone,
+					IPFamilies:               tc.ipFamilies,
+					Selector:                 selector,
+					PublishNotReadyAddresses: tc.publishNotReadyAddresses,
+				},
+			}
+			_, err := kubernetes.CoreV1().Services("testing").Create(context.Background(), service, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			var sliceEndpoints []discoveryv1.Endpoint
+			for i, podIPs := range [][]string{{"1.1.1.1", "2001:db8::1"}, {"1.1.1.2", "2001:db8::2"}} {
+				name := fmt.Sprintf("foo-%d", i)
+				pod := &v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "testing", Name: name, Labels: selector},
+					Spec:       v1.PodSpec{Hostname: name},
+					Status:     v1.PodStatus{PodIP: podIPs[0], PodIPs: []v1.PodIP{{IP: podIPs[0]}, {IP: podIPs[1]}}},
+				}
+				_, err = kubernetes.CoreV1().Pods("testing").Create(context.Background(), pod, metav1.CreateOptions{})
+				require.NoError(t, err)
+
+				ready := i == 0
+				for _, family := range tc.ipFamilies {
+					podIP := podIPs[0]
+					if family == v1.IPv6Protocol {
+						podIP = podIPs[1]
+					}
+					sliceEndpoints = append(sliceEndpoints, discoveryv1.Endpoint{
+						Addresses:  []string{podIP},
+						Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+						TargetRef:  &v1.ObjectReference{Kind: "Pod", Name: name},
+					})
+				}
+			}
+			createEndpointSlices(t, kubernetes, "testing", "foo", sliceEndpoints)
+
+			client, err := NewServiceSource(context.TODO(), kubernetes, "", "", "", false, "", true, false, false, []string{}, false, labels.Everything(), false, false, false, false)
+			require.NoError(t, err)
+
+			endpoints, err := client.Endpoints(context.Background())
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, tc.expected)
+		})
+	}
+}
+
 // TestHeadlessServices tests that headless services generate the correct endpoints.
 func TestHeadlessServicesHostIP(t *testing.T) {
 	t.Parallel()
@@ -3465,8 +3767,7 @@ func TestHeadlessServicesHostIP(t *testing.T) {
 			_, err := kubernetes.CoreV1().Services(service.Namespace).Create(context.Background(), service, metav1.CreateOptions{})
 			require.NoError(t, err)
 
-			var addresses []v1.EndpointAddress
-			var notReadyAddresses []v1.EndpointAddress
+			var sliceEndpoints []discoveryv1.Endpoint
 			for i, podname := range tc.podnames {
 				pod := &v1.Pod{
 					Spec: v1.PodSpec{
@@ -3487,31 +3788,15 @@ func TestHeadlessServicesHostIP(t *testing.T) {
 				_, err = kubernetes.CoreV1().Pods(tc.svcNamespace).Create(context.Background(), pod, metav1.CreateOptions{})
 				require.NoError(t, err)
 
-				address := v1.EndpointAddress{
-					IP:        "4.3.2.1",
-					TargetRef: tc.targetRefs[i],
-				}
-				if tc.podsReady[i] {
-					addresses = append(addresses, address)
-				} else {
-					notReadyAddresses = append(notReadyAddresses, address)
-				}
-			}
-			endpointsObject := &v1.Endpoints{
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: tc.svcNamespace,
-					Name:      tc.svcName,
-					Labels:    tc.labels,
-				},
-				Subsets: []v1.EndpointSubset{
-					{
-						Addresses:         addresses,
-						NotReadyAddresses: notReadyAddresses,
+				sliceEndpoints = append(sliceEndpoints, discoveryv1.Endpoint{
+					Addresses: []string{"4.3.2.1"},
+					Conditions: discoveryv1.EndpointConditions{
+						Ready: &tc.podsReady[i],
 					},
-				},
+					TargetRef: tc.targetRefs[i],
+				})
 			}
-			_, err = kubernetes.CoreV1().Endpoints(tc.svcNamespace).Create(context.Background(), endpointsObject, metav1.CreateOptions{})
-			require.NoError(t, err)
+			createEndpointSlices(t, kubernetes, tc.svcNamespace, tc.svcName, sliceEndpoints)
 
 			// Create our object under test and get the endpoints.
 			client, _ := NewServiceSource(
@@ -3529,6 +3814,9 @@ func TestHeadlessServicesHostIP(t *testing.T) {
 				tc.ignoreHostnameAnnotation,
 				labels.Everything(),
 				false,
+				false,
+				false,
+				false,
 			)
 			require.NoError(t, err)
 
@@ -3707,6 +3995,9 @@ func TestExternalServices(t *testing.T) {
 				tc.ignoreHostnameAnnotation,
 				labels.Everything(),
 				false,
+				false,
+				false,
+				false,
 			)
 			require.NoError(t, err)
 
@@ -3723,6 +4014,261 @@ func TestExternalServices(t *testing.T) {
 	}
 }
 
+func TestServiceSourceFlaggerCanary(t *testing.T) {
+	t.Parallel()
+
+	canaryOwner := []metav1.OwnerReference{{APIVersion: "flagger.app/v1beta1", Kind: "Canary", Name: "podinfo"}}
+	services := []*v1.Service{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "testing", Name: "podinfo", OwnerReferences: canaryOwner}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "testing", Name: "podinfo-primary", OwnerReferences: canaryOwner}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "testing", Name: "podinfo-canary", OwnerReferences: canaryOwner}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "testing", Name: "other-primary"}},
+	}
+
+	for _, tc := range []struct {
+		title                string
+		flaggerAware         bool
+		flaggerPublishCanary bool
+		expected             []string
+	}{
+		{
+			title:    "all services are published without flagger awareness",
+			expected: []string{"other-primary.example.org", "podinfo-canary.example.org", "podinfo-primary.example.org", "podinfo.example.org"},
+		},
+		{
+			title:        "only the apex service of a canary is published",
+			flaggerAware: true,
+			expected:     []string{"other-primary.example.org", "podinfo.example.org"},
+		},
+		{
+			title:                "the canary service is published when enabled",
+			flaggerAware:         true,
+			flaggerPublishCanary: true,
+			expected:             []string{"other-primary.example.org", "podinfo-canary.example.org", "podinfo.example.org"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			kubernetes := fake.NewSimpleClientset()
+			for _, svc := range services {
+				svc := svc.DeepCopy()
+				svc.Spec = v1.ServiceSpec{Type: v1.ServiceTypeClusterIP, ClusterIP: "1.2.3.4"}
+				_, err := kubernetes.CoreV1().Services(svc.Namespace).Create(context.Background(), svc, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			client, err := NewServiceSource(
+				context.TODO(),
+				kubernetes,
+				"",
+				"",
+				"{{.Name}}.example.org",
+				false,
+				"",
+				true,
+				false,
+				false,
+				[]string{},
+				false,
+				labels.Everything(),
+				false,
+				tc.flaggerAware,
+				tc.flaggerPublishCanary,
+				false,
+			)
+			require.NoError(t, err)
+
+			endpoints, err := client.Endpoints(context.Background())
+			require.NoError(t, err)
+
+			var dnsNames []string
+			for _, ep := range endpoints {
+				dnsNames = append(dnsNames, ep.DNSName)
+			}
+			assert.Equal(t, tc.expected, dnsNames)
+		})
+	}
+}
+
+func TestServiceSourceLoadBalancerHostname(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		title       string
+		annotations map[string]string
+		ingress     []v1.LoadBalancerIngress
+		expected    []*endpoint.Endpoint
+	}{
+		{
+			title:       "IP and hostname published under the same name without the annotation",
+			annotations: map[string]string{hostnameAnnotationKey: "svc.example.org"},
+			ingress:     []v1.LoadBalancerIngress{{IP: "1.2.3.4", Hostname: "lb.elb.com"}},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "svc.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}},
+				{DNSName: "svc.example.org", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"lb.elb.com"}},
+			},
+		},
+		{
+			title:       "hostname published under its own name",
+			annotations: map[string]string{hostnameAnnotationKey: "svc.example.org", loadBalancerHostnameKey: "lb.example.org"},
+			ingress:     []v1.LoadBalancerIngress{{IP: "1.2.3.4", Hostname: "lb.elb.com"}, {IP: "2001:db8::1"}},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "lb.example.org", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"lb.elb.com"}},
+				{DNSName: "svc.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}},
+				{DNSName: "svc.example.org", RecordType: endpoint.RecordTypeAAAA, Targets: endpoint.Targets{"2001:db8::1"}},
+			},
+		},
+		{
+			title:       "load balancer without IP",
+			annotations: map[string]string{hostnameAnnotationKey: "svc.example.org", loadBalancerHostnameKey: "lb.example.org"},
+			ingress:     []v1.LoadBalancerIngress{{Hostname: "lb.elb.com"}},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "lb.example.org", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"lb.elb.com"}},
+				{DNSName: "svc.example.org", RecordType: endpoint.RecordTypeCNAME, Targets: endpoint.Targets{"lb.elb.com"}},
+			},
+		},
+		{
+			title:       "load balancer without hostname",
+			annotations: map[string]string{hostnameAnnotationKey: "svc.example.org", loadBalancerHostnameKey: "lb.example.org"},
+			ingress:     []v1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "svc.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}},
+			},
+		},
+		{
+			title:       "target annotation",
+			annotations: map[string]string{hostnameAnnotationKey: "svc.example.org", loadBalancerHostnameKey: "lb.example.org", targetAnnotationKey: "5.6.7.8"},
+			ingress:     []v1.LoadBalancerIngress{{IP: "1.2.3.4", Hostname: "lb.elb.com"}},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "svc.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"5.6.7.8"}},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			kubernetes := fake.NewSimpleClientset()
+			service := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "testing", Name: "foo", Annotations: tc.annotations},
+				Spec:       v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+				Status:     v1.ServiceStatus{LoadBalancer: v1.LoadBalancerStatus{Ingress: tc.ingress}},
+			}
+			_, err := kubernetes.CoreV1().Services(service.Namespace).Create(context.Background(), service, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			client, err := NewServiceSource(
+				context.TODO(),
+				kubernetes,
+				"",
+				"",
+				"",
+				false,
+				"",
+				false,
+				false,
+				false,
+				[]string{},
+				false,
+				labels.Everything(),
+				false,
+				false,
+				false,
+				false,
+			)
+			require.NoError(t, err)
+
+			endpoints, err := client.Endpoints(context.Background())
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, tc.expected)
+		})
+	}
+}
+
+func TestServiceSourceRecordAnnotations(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		title       string
+		annotations map[string]string
+		expected    []*endpoint.Endpoint
+	}{
+		{
+			title: "mx, srv and naptr records",
+			annotations: map[string]string{
+				hostnameAnnotationKey: "sip.example.org",
+				ttlAnnotationKey:      "60",
+				mxAnnotationKey:       "10 mx1.example.org\n20 mx2.example.org",
+				srvAnnotationKey:      "_sips._tcp 10 50 5061 sip.example.org\n_sip._udp 10 50 5060 sip.example.org\n_sip._udp 20 50 5060 backup.example.org",
+				naptrAnnotationKey:    `10 50 "S" "SIP+D2U" "" _sip._udp.sip.example.org.`,
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "sip.example.org", RecordType: endpoint.RecordTypeA, RecordTTL: 60, Targets: endpoint.Targets{"1.2.3.4"}},
+				{DNSName: "sip.example.org", RecordType: endpoint.RecordTypeMX, RecordTTL: 60, Targets: endpoint.Targets{"10 mx1.example.org", "20 mx2.example.org"}},
+				{DNSName: "_sip._udp.sip.example.org", RecordType: endpoint.RecordTypeSRV, RecordTTL: 60, Targets: endpoint.Targets{"10 50 5060 sip.example.org", "20 50 5060 backup.example.org"}},
+				{DNSName: "_sips._tcp.sip.example.org", RecordType: endpoint.RecordTypeSRV, RecordTTL: 60, Targets: endpoint.Targets{"10 50 5061 sip.example.org"}},
+				{DNSName: "sip.example.org", RecordType: endpoint.RecordTypeNAPTR, RecordTTL: 60, Targets: endpoint.Targets{`10 50 "S" "SIP+D2U" "" _sip._udp.sip.example.org.`}},
+			},
+		},
+		{
+			title: "invalid records are skipped",
+			annotations: map[string]string{
+				hostnameAnnotationKey: "sip.example.org",
+				mxAnnotationKey:       "mx1.example.org",
+				srvAnnotationKey:      "_sip 10 50 5060 sip.example.org\n_sips._tcp 10 50 sip.example.org",
+				naptrAnnotationKey:    `10 50 S SIP+D2U "" _sip._udp.sip.example.org.`,
+			},
+			expected: []*endpoint.Endpoint{
+				{DNSName: "sip.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			kubernetes := fake.NewSimpleClientset()
+			service := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "testing", Name: "foo", Annotations: tc.annotations},
+				Spec:       v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+				Status:     v1.ServiceStatus{LoadBalancer: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "1.2.3.4"}}}},
+			}
+			_, err := kubernetes.CoreV1().Services(service.Namespace).Create(context.Background(), service, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			client, err := NewServiceSource(
+				context.TODO(),
+				kubernetes,
+				"",
+				"",
+				"",
+				false,
+				"",
+				false,
+				false,
+				false,
+				[]string{},
+				false,
+				labels.Everything(),
+				false,
+				false,
+				false,
+				false,
+			)
+			require.NoError(t, err)
+
+			endpoints, err := client.Endpoints(context.Background())
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, tc.expected)
+			for _, ep := range endpoints {
+				assert.Equal(t, "service/testing/foo", ep.Labels[endpoint.ResourceLabelKey])
+			}
+		})
+	}
+}
+
 func BenchmarkServiceEndpoints(b *testing.B) {
 	kubernetes := fake.NewSimpleClientset()
 
@@ -3762,6 +4308,9 @@ func BenchmarkServiceEndpoints(b *testing.B) {
 		false,
 		labels.Everything(),
 		false,
+		false,
+		false,
+		false,
 	)
 	require.NoError(b, err)
 
@@ -3770,3 +4319,31 @@ func BenchmarkServiceEndpoints(b *testing.B) {
 		require.NoError(b, err)
 	}
 }
+
+// createEndpointSlices creates the EndpointSlices of the service with the given endpoints, an EndpointSlice per IP
+// family as the EndpointSlice controller does.
+func createEndpointSlices(t *testing.T, client kubernetes.Interface, namespace, service string, endpoints []discoveryv1.Endpoint) {
+	t.Helper()
+
+	byAddressType := map[discoveryv1.AddressType][]discoveryv1.Endpoint{}
+	for _, ep := range endpoints {
+		addressType := discoveryv1.AddressTypeIPv4
+		if net.ParseIP(ep.Addresses[0]).To4() == nil {
+			addressType = discoveryv1.AddressTypeIPv6
+		}
+		byAddressType[addressType] = append(byAddressType[addressType], ep)
+	}
+	for addressType, endpoints := range byAddressType {
+		endpointSlice := &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      fmt.Sprintf("%s-%s", service, strings.ToLower(string(addressType))),
+				Labels:    map[string]string{discoveryv1.LabelServiceName: service},
+			},
+			AddressType: addressType,
+			Endpoints:   endpoints,
+		}
+		_, err := client.DiscoveryV1().EndpointSlices(namespace).Create(context.Background(), endpointSlice, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+}
@@ -20,11 +20,12 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"math"
+	"maps"
 	"net"
 	"reflect"
-	"strconv"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"text/template"
 	"time"
 	"unicode"
@@ -36,30 +37,39 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/source/annotations"
 )
 
+// Keys of the annotations read by the sources, defined by the annotations package
 const (
-	// The annotation used for figuring out which controller is responsible
-	controllerAnnotationKey = "external-dns.alpha.kubernetes.io/controller"
-	// The annotation used for defining the desired hostname
-	hostnameAnnotationKey = "external-dns.alpha.kubernetes.io/hostname"
-	// The annotation used for specifying whether the public or private interface address is used
-	accessAnnotationKey = "external-dns.alpha.kubernetes.io/access"
-	// The annotation used for specifying the type of endpoints to use for headless services
-	endpointsTypeAnnotationKey = "external-dns.alpha.kubernetes.io/endpoints-type"
-	// The annotation used for defining the desired ingress/service target
-	targetAnnotationKey = "external-dns.alpha.kubernetes.io/target"
-	// The annotation used for defining the desired DNS record TTL
-	ttlAnnotationKey = "external-dns.alpha.kubernetes.io/ttl"
-	// The annotation used for switching to the alias record types e. g. AWS Alias records instead of a normal CNAME
-	aliasAnnotationKey = "external-dns.alpha.kubernetes.io/alias"
-	// The annotation used to determine the source of hostnames for ingresses.  This is an optional field - all
-	// available hostname sources are used if not specified.
-	ingressHostnameSourceKey = "external-dns.alpha.kubernetes.io/ingress-hostname-source"
-	// The value of the controller annotation so that we feel responsible
-	controllerAnnotationValue = "dns-controller"
-	// The annotation used for defining the desired hostname
-	internalHostnameAnnotationKey = "external-dns.alpha.kubernetes.io/internal-hostname"
+	controllerAnnotationKey       = annotations.ControllerKey
+	controllerAnnotationValue     = annotations.ControllerValue
+	hostnameAnnotationKey         = annotations.HostnameKey
+	internalHostnameAnnotationKey = annotations.InternalHostnameKey
+	accessAnnotationKey           = annotations.AccessKey
+	endpointsTypeAnnotationKey    = annotations.EndpointsTypeKey
+	zonalRecordsAnnotationKey     = annotations.ZonalRecordsKey
+	ptrRecordsAnnotationKey       = annotations.PTRRecordsKey
+	ipFamiliesAnnotationKey       = annotations.IPFamiliesKey
+	txtAnnotationKey              = annotations.TXTKey
+	mxAnnotationKey               = annotations.MXKey
+	srvAnnotationKey              = annotations.SRVKey
+	naptrAnnotationKey            = annotations.NAPTRKey
+	targetAnnotationKey           = annotations.TargetKey
+	ttlAnnotationKey              = annotations.TTLKey
+	descriptionAnnotationKey      = annotations.DescriptionKey
+	resyncAnnotationKey           = annotations.ResyncKey
+	zoneIDAnnotationKey           = annotations.ZoneIDKey
+	handoffAnnotationKey          = annotations.HandoffKey
+	dependsOnAnnotationKey        = annotations.DependsOnKey
+	visibilityAnnotationKey       = annotations.VisibilityKey
+	aliasAnnotationKey            = annotations.AliasKey
+	ingressHostnameSourceKey      = annotations.IngressHostnameSourceKey
+	rolloutPreviewHostnameKey     = annotations.RolloutPreviewHostnameKey
+	rolloutPreviewPrefixKey       = annotations.RolloutPreviewPrefixKey
+	hostnameTemplateKey           = annotations.HostnameTemplateKey
+	loadBalancerHostnameKey       = annotations.LoadBalancerHostnameKey
+	srvServiceAnnotationKey       = annotations.SRVServiceKey
 )
 
 const (
@@ -70,14 +80,13 @@ const (
 // Provider-specific annotations
 const (
 	// The annotation used for determining if traffic will go through Cloudflare
-	CloudflareProxiedKey = "external-dns.alpha.kubernetes.io/cloudflare-proxied"
+	CloudflareProxiedKey = annotations.CloudflareProxiedKey
 
-	SetIdentifierKey = "external-dns.alpha.kubernetes.io/set-identifier"
-)
-
-const (
-	ttlMinimum = 1
-	ttlMaximum = math.MaxInt32
+	SetIdentifierKey = annotations.SetIdentifierKey
+	WeightKey        = annotations.WeightKey
+	GeolocationKey   = annotations.GeolocationKey
+	LatencyRegionKey = annotations.LatencyRegionKey
+	FailoverKey      = annotations.FailoverKey
 )
 
 // Source defines the interface Endpoint sources should implement.
@@ -87,171 +96,169 @@ type Source interface {
 	AddEventHandler(context.Context, func())
 }
 
-func getTTLFromAnnotations(annotations map[string]string, resource string) endpoint.TTL {
-	ttlNotConfigured := endpoint.TTL(0)
-	ttlAnnotation, exists := annotations[ttlAnnotationKey]
-	if !exists {
-		return ttlNotConfigured
-	}
-	ttlValue, err := parseTTL(ttlAnnotation)
-	if err != nil {
-		log.Warnf("%s: \"%v\" is not a valid TTL value: %v", resource, ttlAnnotation, err)
-		return ttlNotConfigured
-	}
-	if ttlValue < ttlMinimum || ttlValue > ttlMaximum {
-		log.Warnf("TTL value %q must be between [%d, %d]", ttlValue, ttlMinimum, ttlMaximum)
-		return ttlNotConfigured
-	}
-	return endpoint.TTL(ttlValue)
+// setDescriptionLabel attaches the description annotation, if any, to the endpoints. Characters
+// which would break the serialization of the labels in the TXT registry are replaced by spaces.
+func setDescriptionLabel(annotations map[string]string, endpoints []*endpoint.Endpoint) {
+	setLabelFromAnnotation(annotations, descriptionAnnotationKey, endpoint.DescriptionLabelKey, endpoints)
 }
 
-// parseTTL parses TTL from string, returning duration in seconds.
-// parseTTL supports both integers like "600" and durations based
-// on Go Duration like "10m", hence "600" and "10m" represent the same value.
-//
-// Note: for durations like "1.5s" the fraction is omitted (resulting in 1 second
-// for the example).
-func parseTTL(s string) (ttlSeconds int64, err error) {
-	ttlDuration, errDuration := time.ParseDuration(s)
-	if errDuration != nil {
-		ttlInt, err := strconv.ParseInt(s, 10, 64)
-		if err != nil {
-			return 0, errDuration
-		}
-		return ttlInt, nil
-	}
+func setResyncLabel(annotations map[string]string, endpoints []*endpoint.Endpoint) {
+	setLabelFromAnnotation(annotations, resyncAnnotationKey, endpoint.ResyncLabelKey, endpoints)
+}
 
-	return int64(ttlDuration.Seconds()), nil
+func setZoneIDLabel(annotations map[string]string, endpoints []*endpoint.Endpoint) {
+	setLabelFromAnnotation(annotations, zoneIDAnnotationKey, endpoint.ZoneIDLabelKey, endpoints)
 }
 
-type kubeObject interface {
-	runtime.Object
-	metav1.Object
+func setVisibilityLabel(annotations map[string]string, endpoints []*endpoint.Endpoint) {
+	setLabelFromAnnotation(annotations, visibilityAnnotationKey, endpoint.VisibilityLabelKey, endpoints)
 }
 
-func execTemplate(tmpl *template.Template, obj kubeObject) (hostnames []string, err error) {
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, obj); err != nil {
-		kind := obj.GetObjectKind().GroupVersionKind().Kind
-		return nil, fmt.Errorf("failed to apply template on %s %s/%s: %w", kind, obj.GetNamespace(), obj.GetName(), err)
-	}
-	for _, name := range strings.Split(buf.String(), ",") {
-		name = strings.TrimFunc(name, unicode.IsSpace)
-		name = strings.TrimSuffix(name, ".")
-		hostnames = append(hostnames, name)
+func setHandoffLabel(annotations map[string]string, endpoints []*endpoint.Endpoint) {
+	setLabelFromAnnotation(annotations, handoffAnnotationKey, endpoint.HandoffLabelKey, endpoints)
+}
+
+func setDependsOnLabel(annotations map[string]string, endpoints []*endpoint.Endpoint) {
+	setLabelFromAnnotation(annotations, dependsOnAnnotationKey, endpoint.DependsOnLabelKey, endpoints)
+}
+
+// setCustomLabels attaches the custom labels allowed with --registry-label which are set with annotations.
+func setCustomLabels(annots map[string]string, endpoints []*endpoint.Endpoint) {
+	for key, value := range annotations.CustomLabelsFromAnnotations(annots) {
+		for _, ep := range endpoints {
+			ep.Labels[key] = value
+		}
 	}
-	return hostnames, nil
 }
 
-func parseTemplate(fqdnTemplate string) (tmpl *template.Template, err error) {
-	if fqdnTemplate == "" {
-		return nil, nil
+// txtEndpoints returns the TXT records of the txt annotation for each DNS name of the A, AAAA and CNAME endpoints
+// of a resource, see supplementaryEndpoint.
+func txtEndpoints(annots map[string]string, endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	values := annotations.TXTRecordsFromAnnotations(annots)
+	if len(values) == 0 {
+		return nil
 	}
-	funcs := template.FuncMap{
-		"trimPrefix": strings.TrimPrefix,
+
+	var txtEndpoints []*endpoint.Endpoint
+	for _, ep := range addressEndpoints(endpoints) {
+		if txt := supplementaryEndpoint(ep, ep.DNSName, endpoint.RecordTypeTXT, values); txt != nil {
+			txtEndpoints = append(txtEndpoints, txt)
+		}
 	}
-	return template.New("endpoint").Funcs(funcs).Parse(fqdnTemplate)
+	return txtEndpoints
 }
 
-func getHostnamesFromAnnotations(annotations map[string]string) []string {
-	hostnameAnnotation, exists := annotations[hostnameAnnotationKey]
-	if !exists {
+// serviceRecordEndpoints returns the MX and NAPTR records of the mx and naptr annotations for each DNS name of the
+// A, AAAA and CNAME endpoints of a resource, and the SRV records of the srv annotation under the DNS names prefixed
+// with _<service>._<protocol>, see supplementaryEndpoint.
+func serviceRecordEndpoints(annots map[string]string, endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	mx := annotations.MXRecordsFromAnnotations(annots)
+	srv := annotations.SRVRecordsFromAnnotations(annots)
+	naptr := annotations.NAPTRRecordsFromAnnotations(annots)
+	if len(mx) == 0 && len(srv) == 0 && len(naptr) == 0 {
 		return nil
 	}
-	return splitHostnameAnnotation(hostnameAnnotation)
-}
+	srvPrefixes := slices.Sorted(maps.Keys(srv))
 
-func getAccessFromAnnotations(annotations map[string]string) string {
-	return annotations[accessAnnotationKey]
+	var recordEndpoints []*endpoint.Endpoint
+	add := func(ep *endpoint.Endpoint, dnsName, recordType string, targets []string) {
+		if len(targets) == 0 {
+			return
+		}
+		if record := supplementaryEndpoint(ep, dnsName, recordType, targets); record != nil {
+			recordEndpoints = append(recordEndpoints, record)
+		}
+	}
+	for _, ep := range addressEndpoints(endpoints) {
+		add(ep, ep.DNSName, endpoint.RecordTypeMX, mx)
+		for _, prefix := range srvPrefixes {
+			add(ep, prefix+"."+ep.DNSName, endpoint.RecordTypeSRV, srv[prefix])
+		}
+		add(ep, ep.DNSName, endpoint.RecordTypeNAPTR, naptr)
+	}
+	return recordEndpoints
 }
 
-func getEndpointsTypeFromAnnotations(annotations map[string]string) string {
-	return annotations[endpointsTypeAnnotationKey]
+// addressEndpoints returns the first A, AAAA or CNAME endpoint of each DNS name and set identifier of a resource,
+// which the records set with annotations like txt are published along with.
+func addressEndpoints(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	var addressEndpoints []*endpoint.Endpoint
+	seen := make(map[endpoint.EndpointKey]bool)
+	for _, ep := range endpoints {
+		switch ep.RecordType {
+		case endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME:
+		default:
+			continue
+		}
+		key := endpoint.EndpointKey{DNSName: ep.DNSName, SetIdentifier: ep.SetIdentifier}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		addressEndpoints = append(addressEndpoints, ep)
+	}
+	return addressEndpoints
 }
 
-func getInternalHostnamesFromAnnotations(annotations map[string]string) []string {
-	internalHostnameAnnotation, exists := annotations[internalHostnameAnnotationKey]
-	if !exists {
+// supplementaryEndpoint returns the record of the given name, type and targets published along with an address
+// endpoint, with its TTL, set identifier, routing policy properties and resource label. Other provider-specific
+// properties, e.g. alias, don't apply to these records. Invalid records are logged and nil is returned.
+func supplementaryEndpoint(ep *endpoint.Endpoint, dnsName, recordType string, targets []string) *endpoint.Endpoint {
+	resource := ep.Labels[endpoint.ResourceLabelKey]
+	record, err := endpoint.NewValidatedEndpoint(dnsName, recordType, ep.RecordTTL, targets...)
+	if err != nil {
+		logInvalidEndpoint(resource, err)
 		return nil
 	}
-	return splitHostnameAnnotation(internalHostnameAnnotation)
+	record.SetIdentifier = ep.SetIdentifier
+	record.ProviderSpecific = slices.DeleteFunc(slices.Clone(ep.ProviderSpecific), func(p endpoint.ProviderSpecificProperty) bool {
+		return !endpoint.IsRoutingPolicyProperty(p.Name)
+	})
+	if resource != "" {
+		record.Labels[endpoint.ResourceLabelKey] = resource
+	}
+	return record
 }
 
-func splitHostnameAnnotation(annotation string) []string {
-	return strings.Split(strings.Replace(annotation, " ", "", -1), ",")
+// setLabelFromAnnotation copies the value of an annotation to a label of the endpoints, see
+// annotations.LabelFromAnnotations.
+func setLabelFromAnnotation(annots map[string]string, annotationKey, labelKey string, endpoints []*endpoint.Endpoint) {
+	value, ok := annotations.LabelFromAnnotations(annots, annotationKey)
+	if !ok {
+		return
+	}
+	for _, ep := range endpoints {
+		ep.Labels[labelKey] = value
+	}
 }
 
-func getAliasFromAnnotations(annotations map[string]string) bool {
-	aliasAnnotation, exists := annotations[aliasAnnotationKey]
-	return exists && aliasAnnotation == "true"
+type kubeObject interface {
+	runtime.Object
+	metav1.Object
 }
 
-func getProviderSpecificAnnotations(annotations map[string]string) (endpoint.ProviderSpecific, string) {
-	providerSpecificAnnotations := endpoint.ProviderSpecific{}
-
-	v, exists := annotations[CloudflareProxiedKey]
-	if exists {
-		providerSpecificAnnotations = append(providerSpecificAnnotations, endpoint.ProviderSpecificProperty{
-			Name:  CloudflareProxiedKey,
-			Value: v,
-		})
-	}
-	if getAliasFromAnnotations(annotations) {
-		providerSpecificAnnotations = append(providerSpecificAnnotations, endpoint.ProviderSpecificProperty{
-			Name:  "alias",
-			Value: "true",
-		})
+// execTemplate executes the template on the object. The output is a list of hostnames separated by commas or
+// whitespace, so that a template can emit several hostnames, e.g. with a range over the labels or with join.
+func execTemplate(tmpl *template.Template, obj kubeObject) (hostnames []string, err error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, obj); err != nil {
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		return nil, fmt.Errorf("failed to apply template on %s %s/%s: %w", kind, obj.GetNamespace(), obj.GetName(), err)
 	}
-	setIdentifier := ""
-	for k, v := range annotations {
-		if k == SetIdentifierKey {
-			setIdentifier = v
-		} else if strings.HasPrefix(k, "external-dns.alpha.kubernetes.io/aws-") {
-			attr := strings.TrimPrefix(k, "external-dns.alpha.kubernetes.io/aws-")
-			providerSpecificAnnotations = append(providerSpecificAnnotations, endpoint.ProviderSpecificProperty{
-				Name:  fmt.Sprintf("aws/%s", attr),
-				Value: v,
-			})
-		} else if strings.HasPrefix(k, "external-dns.alpha.kubernetes.io/scw-") {
-			attr := strings.TrimPrefix(k, "external-dns.alpha.kubernetes.io/scw-")
-			providerSpecificAnnotations = append(providerSpecificAnnotations, endpoint.ProviderSpecificProperty{
-				Name:  fmt.Sprintf("scw/%s", attr),
-				Value: v,
-			})
-		} else if strings.HasPrefix(k, "external-dns.alpha.kubernetes.io/ibmcloud-") {
-			attr := strings.TrimPrefix(k, "external-dns.alpha.kubernetes.io/ibmcloud-")
-			providerSpecificAnnotations = append(providerSpecificAnnotations, endpoint.ProviderSpecificProperty{
-				Name:  fmt.Sprintf("ibmcloud-%s", attr),
-				Value: v,
-			})
-		} else if strings.HasPrefix(k, "external-dns.alpha.kubernetes.io/webhook-") {
-			// Support for wildcard annotations for webhook providers
-			attr := strings.TrimPrefix(k, "external-dns.alpha.kubernetes.io/webhook-")
-			providerSpecificAnnotations = append(providerSpecificAnnotations, endpoint.ProviderSpecificProperty{
-				Name:  fmt.Sprintf("webhook/%s", attr),
-				Value: v,
-			})
-		}
+	names := strings.FieldsFunc(buf.String(), func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	for _, name := range names {
+		hostnames = append(hostnames, strings.TrimSuffix(name, "."))
 	}
-	return providerSpecificAnnotations, setIdentifier
+	return hostnames, nil
 }
 
-// getTargetsFromTargetAnnotation gets endpoints from optional "target" annotation.
-// Returns empty endpoints array if none are found.
-func getTargetsFromTargetAnnotation(annotations map[string]string) endpoint.Targets {
-	var targets endpoint.Targets
-
-	// Get the desired hostname of the ingress from the annotation.
-	targetAnnotation, exists := annotations[targetAnnotationKey]
-	if exists && targetAnnotation != "" {
-		// splits the hostname annotation and removes the trailing periods
-		targetsList := strings.Split(strings.Replace(targetAnnotation, " ", "", -1), ",")
-		for _, targetHostname := range targetsList {
-			targetHostname = strings.TrimSuffix(targetHostname, ".")
-			targets = append(targets, targetHostname)
-		}
+func parseTemplate(fqdnTemplate string) (tmpl *template.Template, err error) {
+	if fqdnTemplate == "" {
+		return nil, nil
 	}
-	return targets
+	return template.New("endpoint").Funcs(templateFuncs()).Parse(fqdnTemplate)
 }
 
 // suitableType returns the DNS resource record type suitable for the target.
@@ -265,10 +272,18 @@ func suitableType(target string) string {
 	return endpoint.RecordTypeCNAME
 }
 
-// endpointsForHostname returns the endpoint objects for each host-target combination.
+// endpointsForHostname returns the endpoint objects for each host-target combination. Records with a routing policy but
+// without a set identifier are identified by their resource, so the records of all resources publishing the hostname
+// are kept.
 func endpointsForHostname(hostname string, targets endpoint.Targets, ttl endpoint.TTL, providerSpecific endpoint.ProviderSpecific, setIdentifier string, resource string) []*endpoint.Endpoint {
 	var endpoints []*endpoint.Endpoint
 
+	if setIdentifier == "" && slices.ContainsFunc(providerSpecific, func(p endpoint.ProviderSpecificProperty) bool {
+		return endpoint.IsRoutingPolicyProperty(p.Name)
+	}) {
+		setIdentifier = resource
+	}
+
 	var aTargets endpoint.Targets
 	var aaaaTargets endpoint.Targets
 	var cnameTargets endpoint.Targets
@@ -291,8 +306,10 @@ func endpointsForHostname(hostname string, targets endpoint.Targets, ttl endpoin
 	}
 
 	if len(aTargets) > 0 {
-		epA := endpoint.NewEndpointWithTTL(hostname, endpoint.RecordTypeA, ttl, aTargets...)
-		if epA != nil {
+		epA, err := endpoint.NewValidatedEndpoint(hostname, endpoint.RecordTypeA, ttl, aTargets...)
+		if err != nil {
+			logInvalidEndpoint(resource, err)
+		} else {
 			epA.ProviderSpecific = providerSpecific
 			epA.SetIdentifier = setIdentifier
 			if resource != "" {
@@ -303,8 +320,10 @@ func endpointsForHostname(hostname string, targets endpoint.Targets, ttl endpoin
 	}
 
 	if len(aaaaTargets) > 0 {
-		epAAAA := endpoint.NewEndpointWithTTL(hostname, endpoint.RecordTypeAAAA, ttl, aaaaTargets...)
-		if epAAAA != nil {
+		epAAAA, err := endpoint.NewValidatedEndpoint(hostname, endpoint.RecordTypeAAAA, ttl, aaaaTargets...)
+		if err != nil {
+			logInvalidEndpoint(resource, err)
+		} else {
 			epAAAA.ProviderSpecific = providerSpecific
 			epAAAA.SetIdentifier = setIdentifier
 			if resource != "" {
@@ -315,8 +334,10 @@ func endpointsForHostname(hostname string, targets endpoint.Targets, ttl endpoin
 	}
 
 	if len(cnameTargets) > 0 {
-		epCNAME := endpoint.NewEndpointWithTTL(hostname, endpoint.RecordTypeCNAME, ttl, cnameTargets...)
-		if epCNAME != nil {
+		epCNAME, err := endpoint.NewValidatedEndpoint(hostname, endpoint.RecordTypeCNAME, ttl, cnameTargets...)
+		if err != nil {
+			logInvalidEndpoint(resource, err)
+		} else {
 			epCNAME.ProviderSpecific = providerSpecific
 			epCNAME.SetIdentifier = setIdentifier
 			if resource != "" {
@@ -329,6 +350,36 @@ func endpointsForHostname(hostname string, targets endpoint.Targets, ttl endpoin
 	return endpoints
 }
 
+// InvalidEndpointHandler is called with the originating resource, empty if unknown, and the validation error
+// of every endpoint skipped because it is invalid.
+type InvalidEndpointHandler func(resource string, err error)
+
+// invalidEndpointHandler is the handler set with SetInvalidEndpointHandler.
+var invalidEndpointHandler atomic.Pointer[InvalidEndpointHandler]
+
+// SetInvalidEndpointHandler sets the handler of the invalid endpoints skipped by the sources, e.g. to record
+// them as events on their resources. They are logged in any case. nil removes the handler.
+func SetInvalidEndpointHandler(handler InvalidEndpointHandler) {
+	if handler == nil {
+		invalidEndpointHandler.Store(nil)
+		return
+	}
+	invalidEndpointHandler.Store(&handler)
+}
+
+// logInvalidEndpoint reports an endpoint which could not be created, naming the originating resource if known,
+// and passes it to the InvalidEndpointHandler.
+func logInvalidEndpoint(resource string, err error) {
+	if handler := invalidEndpointHandler.Load(); handler != nil {
+		(*handler)(resource, err)
+	}
+	if resource == "" {
+		log.Warnf("Skipping invalid endpoint: %v", err)
+		return
+	}
+	log.Warnf("Skipping invalid endpoint of %s: %v", resource, err)
+}
+
 func getLabelSelector(annotationFilter string) (labels.Selector, error) {
 	labelSelector, err := metav1.ParseToLabelSelector(annotationFilter)
 	if err != nil {
@@ -17,68 +17,102 @@ limitations under the License.
 package source
 
 import (
-	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/source/annotations"
 )
 
-func TestGetTTLFromAnnotations(t *testing.T) {
+func TestSetDescriptionLabel(t *testing.T) {
 	for _, tc := range []struct {
-		title       string
-		annotations map[string]string
-		expectedTTL endpoint.TTL
+		title               string
+		annotations         map[string]string
+		expectedDescription string
+		expectedExists      bool
 	}{
 		{
-			title:       "TTL annotation not present",
+			title:       "description annotation not present",
 			annotations: map[string]string{"foo": "bar"},
-			expectedTTL: endpoint.TTL(0),
 		},
 		{
-			title:       "TTL annotation value is not a number",
-			annotations: map[string]string{ttlAnnotationKey: "foo"},
-			expectedTTL: endpoint.TTL(0),
+			title:       "description annotation value is blank",
+			annotations: map[string]string{descriptionAnnotationKey: "  "},
 		},
 		{
-			title:       "TTL annotation value is empty",
-			annotations: map[string]string{ttlAnnotationKey: ""},
-			expectedTTL: endpoint.TTL(0),
+			title:               "description annotation value is set",
+			annotations:         map[string]string{descriptionAnnotationKey: "frontend of the shop"},
+			expectedDescription: "frontend of the shop",
+			expectedExists:      true,
 		},
 		{
-			title:       "TTL annotation value is negative number",
-			annotations: map[string]string{ttlAnnotationKey: "-1"},
-			expectedTTL: endpoint.TTL(0),
-		},
-		{
-			title:       "TTL annotation value is too high",
-			annotations: map[string]string{ttlAnnotationKey: fmt.Sprintf("%d", 1<<32)},
-			expectedTTL: endpoint.TTL(0),
-		},
-		{
-			title:       "TTL annotation value is set correctly using integer",
-			annotations: map[string]string{ttlAnnotationKey: "60"},
-			expectedTTL: endpoint.TTL(60),
-		},
-		{
-			title:       "TTL annotation value is set correctly using duration (whole)",
-			annotations: map[string]string{ttlAnnotationKey: "10m"},
-			expectedTTL: endpoint.TTL(600),
-		},
-		{
-			title:       "TTL annotation value is set correctly using duration (fractional)",
-			annotations: map[string]string{ttlAnnotationKey: "20.5s"},
-			expectedTTL: endpoint.TTL(20),
+			title:               "description annotation value contains label separators",
+			annotations:         map[string]string{descriptionAnnotationKey: `owner=team-a,"shop"`},
+			expectedDescription: "owner team-a  shop",
+			expectedExists:      true,
 		},
 	} {
 		t.Run(tc.title, func(t *testing.T) {
-			ttl := getTTLFromAnnotations(tc.annotations, "resource/test")
-			assert.Equal(t, tc.expectedTTL, ttl)
+			endpoints := []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")}
+			setDescriptionLabel(tc.annotations, endpoints)
+			description, exists := endpoints[0].Labels[endpoint.DescriptionLabelKey]
+			assert.Equal(t, tc.expectedExists, exists)
+			assert.Equal(t, tc.expectedDescription, description)
 		})
 	}
 }
 
+func TestSetResyncLabel(t *testing.T) {
+	endpoints := []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")}
+	setResyncLabel(map[string]string{resyncAnnotationKey: "2024-06-03"}, endpoints)
+	assert.Equal(t, "2024-06-03", endpoints[0].Labels[endpoint.ResyncLabelKey])
+
+	endpoints = []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")}
+	setResyncLabel(map[string]string{"foo": "bar"}, endpoints)
+	assert.NotContains(t, endpoints[0].Labels, endpoint.ResyncLabelKey)
+}
+
+func TestSetCustomLabels(t *testing.T) {
+	annotations.SetCustomLabels([]string{"team"})
+	defer annotations.SetCustomLabels(nil)
+
+	endpoints := []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")}
+	setCustomLabels(map[string]string{annotations.LabelPrefix + "team": "payments", annotations.LabelPrefix + "environment": "prod"}, endpoints)
+	assert.Equal(t, "payments", endpoints[0].Labels["team"])
+	assert.NotContains(t, endpoints[0].Labels, "environment")
+}
+
+func TestSetZoneIDLabel(t *testing.T) {
+	endpoints := []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")}
+	setZoneIDLabel(map[string]string{zoneIDAnnotationKey: " Z0123456789 "}, endpoints)
+	assert.Equal(t, "Z0123456789", endpoints[0].Labels[endpoint.ZoneIDLabelKey])
+
+	endpoints = []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.2.3.4")}
+	setZoneIDLabel(map[string]string{"foo": "bar"}, endpoints)
+	assert.NotContains(t, endpoints[0].Labels, endpoint.ZoneIDLabelKey)
+}
+
+func TestSetVisibilityLabel(t *testing.T) {
+	endpoints := []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "10.0.0.1")}
+	setVisibilityLabel(map[string]string{visibilityAnnotationKey: "private"}, endpoints)
+	assert.Equal(t, endpoint.VisibilityPrivate, endpoints[0].Labels[endpoint.VisibilityLabelKey])
+}
+
+func TestSetHandoffLabel(t *testing.T) {
+	endpoints := []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "10.0.0.1")}
+	setHandoffLabel(map[string]string{handoffAnnotationKey: "ingress/default/foo"}, endpoints)
+	assert.Equal(t, "ingress/default/foo", endpoints[0].Labels[endpoint.HandoffLabelKey])
+}
+
+func TestSetDependsOnLabel(t *testing.T) {
+	endpoints := []*endpoint.Endpoint{endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "bar.example.org")}
+	setDependsOnLabel(map[string]string{dependsOnAnnotationKey: "bar.example.org,baz.example.org"}, endpoints)
+	assert.Equal(t, []string{"bar.example.org", "baz.example.org"}, endpoints[0].Labels.DependsOn())
+}
+
 func TestSuitableType(t *testing.T) {
 	for _, tc := range []struct {
 		target, recordType, expected string
@@ -96,3 +130,72 @@ func TestSuitableType(t *testing.T) {
 		}
 	}
 }
+
+func TestEndpointsIPv4MappedKept(t *testing.T) {
+	// records published with IPv4-mapped IPv6 addresses before targets were validated must not be deleted,
+	// the A records are updated to the unmapped addresses instead
+	ownedRecord := func(recordType, target string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint("example.org", recordType, target)
+		ep.Labels[endpoint.OwnerLabelKey] = "default"
+		return ep
+	}
+	current := []*endpoint.Endpoint{
+		ownedRecord(endpoint.RecordTypeA, "::ffff:10.0.0.1"),
+		ownedRecord(endpoint.RecordTypeAAAA, "::ffff:10.0.0.2"),
+	}
+
+	desired := endpointsForHostname("example.org", endpoint.Targets{"::ffff:10.0.0.1"}, 0, nil, "", "service/default/foo")
+	require.Len(t, desired, 1)
+	assert.Equal(t, endpoint.RecordTypeA, desired[0].RecordType)
+	assert.Equal(t, endpoint.Targets{"10.0.0.1"}, desired[0].Targets)
+	aaaa := supplementaryEndpoint(desired[0], "example.org", endpoint.RecordTypeAAAA, []string{"::ffff:10.0.0.2"})
+	require.NotNil(t, aaaa)
+	desired = append(desired, aaaa)
+
+	p := (&plan.Plan{
+		Current:        current,
+		Desired:        desired,
+		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA},
+		OwnerID:        "default",
+	}).Calculate()
+	assert.Empty(t, p.Changes.Create)
+	assert.Empty(t, p.Changes.Delete)
+	require.Len(t, p.Changes.UpdateNew, 1)
+	assert.Equal(t, endpoint.RecordTypeA, p.Changes.UpdateNew[0].RecordType)
+	assert.Equal(t, endpoint.Targets{"10.0.0.1"}, p.Changes.UpdateNew[0].Targets)
+}
+
+func TestInvalidEndpointHandler(t *testing.T) {
+	var resources []string
+	SetInvalidEndpointHandler(func(resource string, err error) {
+		var targetErr *endpoint.InvalidTargetError
+		assert.ErrorAs(t, err, &targetErr)
+		resources = append(resources, resource)
+	})
+	t.Cleanup(func() { SetInvalidEndpointHandler(nil) })
+
+	ep := endpointsForHostname("example.org", endpoint.Targets{"10.0.0.1"}, 0, nil, "", "service/default/foo")[0]
+	assert.Nil(t, supplementaryEndpoint(ep, "example.org", endpoint.RecordTypeMX, []string{"mail.example.org"}))
+	assert.Equal(t, []string{"service/default/foo"}, resources)
+}
+
+func TestEndpointsForHostnameWeighted(t *testing.T) {
+	weighted := endpoint.ProviderSpecific{{Name: endpoint.ProviderSpecificWeight, Value: "20"}}
+
+	endpoints := endpointsForHostname("example.org", endpoint.Targets{"1.2.3.4"}, 0, weighted, "", "ingress/default/blue")
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, "ingress/default/blue", endpoints[0].SetIdentifier)
+
+	endpoints = endpointsForHostname("example.org", endpoint.Targets{"1.2.3.4"}, 0, weighted, "eu", "ingress/default/blue")
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, "eu", endpoints[0].SetIdentifier)
+
+	endpoints = endpointsForHostname("example.org", endpoint.Targets{"1.2.3.4"}, 0, nil, "", "ingress/default/blue")
+	require.Len(t, endpoints, 1)
+	assert.Empty(t, endpoints[0].SetIdentifier)
+
+	failover := endpoint.ProviderSpecific{{Name: endpoint.ProviderSpecificFailover, Value: endpoint.FailoverPrimary}}
+	endpoints = endpointsForHostname("example.org", endpoint.Targets{"1.2.3.4"}, 0, failover, "", "service/default/primary")
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, "service/default/primary", endpoints[0].SetIdentifier)
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// staticSource is a Source that returns a fixed set of endpoints, for a handful of records which
+// don't belong to any Kubernetes resource, e.g. TXT records verifying the ownership of a domain.
+type staticSource struct {
+	endpoints []*endpoint.Endpoint
+}
+
+// staticEndpoint is an endpoint as defined in a static endpoints file.
+type staticEndpoint struct {
+	DNSName       string   `yaml:"dnsName"`
+	RecordType    string   `yaml:"recordType"`
+	Targets       []string `yaml:"targets"`
+	RecordTTL     int64    `yaml:"recordTTL,omitempty"`
+	SetIdentifier string   `yaml:"setIdentifier,omitempty"`
+}
+
+// NewStaticSource creates a new staticSource returning the given endpoints. Endpoints with the
+// same DNS name, record type and set identifier are merged into one.
+func NewStaticSource(endpoints []*endpoint.Endpoint) Source {
+	merged := []*endpoint.Endpoint{}
+	byKey := map[string]*endpoint.Endpoint{}
+	for _, ep := range endpoints {
+		key := ep.DNSName + " / " + ep.RecordType + " / " + ep.SetIdentifier
+		if existing, ok := byKey[key]; ok {
+			existing.Targets = append(existing.Targets, ep.Targets...)
+			if ep.RecordTTL.IsConfigured() {
+				existing.RecordTTL = ep.RecordTTL
+			}
+			continue
+		}
+		ep = ep.DeepCopy()
+		byKey[key] = ep
+		merged = append(merged, ep)
+	}
+	return &staticSource{endpoints: merged}
+}
+
+// ParseStaticEndpoints parses endpoints of the form "<dns name> <record type> <target>". The target
+// is the rest of the value, so TXT targets may contain spaces.
+func ParseStaticEndpoints(values []string) ([]*endpoint.Endpoint, error) {
+	endpoints := make([]*endpoint.Endpoint, 0, len(values))
+	for _, value := range values {
+		fields := strings.Fields(value)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("invalid static endpoint %q, expected \"<dns name> <record type> <target>\"", value)
+		}
+		// strip the name and the record type only, so the spacing of the target is kept
+		target := strings.TrimSpace(value)
+		for _, field := range fields[:2] {
+			target = strings.TrimSpace(strings.TrimPrefix(target, field))
+		}
+		ep, err := endpoint.NewBuilder(fields[0], strings.ToUpper(fields[1])).WithTargets(target).Build()
+		if err != nil {
+			return nil, fmt.Errorf("invalid static endpoint %q: %w", value, err)
+		}
+		endpoints = append(endpoints, ep)
+	}
+	return endpoints, nil
+}
+
+// LoadStaticEndpoints reads static endpoints from a YAML file of the form
+//
+//	endpoints:
+//	- dnsName: example.org
+//	  recordType: TXT
+//	  targets:
+//	  - google-site-verification=abc
+//	  recordTTL: 3600
+func LoadStaticEndpoints(path string) ([]*endpoint.Endpoint, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read static endpoints file '%s': %w", path, err)
+	}
+	config := struct {
+		Endpoints []staticEndpoint `yaml:"endpoints"`
+	}{}
+	if err := yaml.UnmarshalStrict(contents, &config); err != nil {
+		return nil, fmt.Errorf("failed to read static endpoints file '%s': %w", path, err)
+	}
+
+	endpoints := make([]*endpoint.Endpoint, 0, len(config.Endpoints))
+	for _, e := range config.Endpoints {
+		ep, err := endpoint.NewBuilder(e.DNSName, strings.ToUpper(e.RecordType)).
+			WithTargets(e.Targets...).
+			WithTTL(endpoint.TTL(e.RecordTTL)).
+			WithSetIdentifier(e.SetIdentifier).
+			Build()
+		if err != nil {
+			return nil, fmt.Errorf("invalid static endpoint %q in '%s': %w", e.DNSName, path, err)
+		}
+		endpoints = append(endpoints, ep)
+	}
+	return endpoints, nil
+}
+
+// AddEventHandler does nothing, the endpoints never change.
+func (s *staticSource) AddEventHandler(ctx context.Context, handler func()) {
+}
+
+// Endpoints returns copies of the static endpoints, so later stages can adjust them freely.
+func (s *staticSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	endpoints := make([]*endpoint.Endpoint, 0, len(s.endpoints))
+	for _, ep := range s.endpoints {
+		endpoints = append(endpoints, ep.DeepCopy())
+	}
+	return endpoints, nil
+}
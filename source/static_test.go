@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// Validates that staticSource is a Source
+var _ Source = &staticSource{}
+
+func TestParseStaticEndpoints(t *testing.T) {
+	endpoints, err := ParseStaticEndpoints([]string{
+		"example.org TXT v=spf1 include:_spf.example.com ~all",
+		"www.example.org cname example.org.",
+	})
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("example.org", endpoint.RecordTypeTXT, "v=spf1 include:_spf.example.com ~all"),
+		endpoint.NewEndpoint("www.example.org", endpoint.RecordTypeCNAME, "example.org"),
+	})
+
+	for _, value := range []string{
+		"example.org TXT",
+		"example.org A not-an-ip",
+	} {
+		_, err := ParseStaticEndpoints([]string{value})
+		assert.Error(t, err, value)
+	}
+}
+
+func TestLoadStaticEndpoints(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+		return path
+	}
+
+	endpoints, err := LoadStaticEndpoints(write("valid.yaml", `
+endpoints:
+- dnsName: example.org
+  recordType: TXT
+  targets:
+  - google-site-verification=abc
+  recordTTL: 3600
+- dnsName: mail.example.org
+  recordType: A
+  targets:
+  - 192.0.2.1
+  - 192.0.2.2
+`))
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("example.org", endpoint.RecordTypeTXT, 3600, "google-site-verification=abc"),
+		endpoint.NewEndpoint("mail.example.org", endpoint.RecordTypeA, "192.0.2.1", "192.0.2.2"),
+	})
+
+	_, err = LoadStaticEndpoints(write("invalid.yaml", "endpoints:\n- dnsName: example.org\n  recordType: A\n  targets: [example.com]\n"))
+	assert.Error(t, err)
+	_, err = LoadStaticEndpoints(write("unknown.yaml", "records: []\n"))
+	assert.Error(t, err)
+	_, err = LoadStaticEndpoints(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestStaticSource(t *testing.T) {
+	source := NewStaticSource([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("example.org", endpoint.RecordTypeTXT, "google-site-verification=abc"),
+		endpoint.NewEndpoint("www.example.org", endpoint.RecordTypeCNAME, "example.org"),
+		endpoint.NewEndpointWithTTL("example.org", endpoint.RecordTypeTXT, 3600, "v=spf1 -all"),
+	})
+
+	endpoints, err := source.Endpoints(context.Background())
+	require.NoError(t, err)
+	validateEndpoints(t, endpoints, []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("example.org", endpoint.RecordTypeTXT, 3600, "google-site-verification=abc", "v=spf1 -all"),
+		endpoint.NewEndpoint("www.example.org", endpoint.RecordTypeCNAME, "example.org"),
+	})
+
+	// changes to the returned endpoints don't affect the next synchronization
+	endpoints[0].Targets = endpoint.Targets{"changed"}
+	endpoints, err = source.Endpoints(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, endpoints[0].Targets, 2)
+}
@@ -54,13 +54,18 @@ type Config struct {
 	IgnoreIngressRulesSpec         bool
 	GatewayNamespace               string
 	GatewayLabelFilter             string
+	GatewayListenerPortRecords     []string
 	Compatibility                  string
 	PublishInternal                bool
 	PublishHostIP                  bool
 	AlwaysPublishNotReadyAddresses bool
 	ConnectorServer                string
+	WebhookSourceURL               string
+	HTTPSourceURL                  string
+	HTTPSourceHeaders              []string
 	CRDSourceAPIVersion            string
 	CRDSourceKind                  string
+	CRDSourceLabelFilter           labels.Selector
 	KubeConfig                     string
 	APIServerURL                   string
 	ServiceTypeFilter              []string
@@ -76,6 +81,16 @@ type Config struct {
 	ResolveLoadBalancerHostname    bool
 	TraefikDisableLegacy           bool
 	TraefikDisableNew              bool
+	CacheEndpoints                 bool
+	NodeLabelFilter                labels.Selector
+	NodeFQDNTemplate               string
+	NodeExcludeNotReady            bool
+	NodeAddressTypes               []string
+	PodExcludeNotReady             bool
+	PodSRVRecords                  bool
+	ServiceFlaggerAware            bool
+	ServiceFlaggerPublishCanary    bool
+	NamespaceDefaultAnnotations    bool
 }
 
 // ClientGenerator provides clients
@@ -198,12 +213,30 @@ func ByNames(ctx context.Context, p ClientGenerator, names []string, cfg *Config
 		if err != nil {
 			return nil, err
 		}
+		if cfg.CacheEndpoints {
+			source = NewCachedSource(source)
+		}
 		sources = append(sources, source)
 	}
 
 	return sources, nil
 }
 
+// crdLabelSelector returns the selector of the DNSEndpoints, which have to match both the label filter
+// and the label filter of the CRD source.
+func (cfg *Config) crdLabelSelector() labels.Selector {
+	labelSelector := cfg.LabelFilter
+	if labelSelector == nil {
+		labelSelector = labels.Everything()
+	}
+	if cfg.CRDSourceLabelFilter != nil {
+		if requirements, selectable := cfg.CRDSourceLabelFilter.Requirements(); selectable {
+			labelSelector = labelSelector.Add(requirements...)
+		}
+	}
+	return labelSelector
+}
+
 // BuildWithConfig allows to generate a Source implementation from the shared config
 func BuildWithConfig(ctx context.Context, source string, p ClientGenerator, cfg *Config) (Source, error) {
 	switch source {
@@ -212,25 +245,39 @@ func BuildWithConfig(ctx context.Context, source string, p ClientGenerator, cfg
 		if err != nil {
 			return nil, err
 		}
-		return NewNodeSource(ctx, client, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.LabelFilter)
+		labelSelector := cfg.LabelFilter
+		if cfg.NodeLabelFilter != nil {
+			// the nodes have to match both selectors
+			if requirements, selectable := cfg.NodeLabelFilter.Requirements(); selectable {
+				if labelSelector == nil {
+					labelSelector = labels.Everything()
+				}
+				labelSelector = labelSelector.Add(requirements...)
+			}
+		}
+		fqdnTemplate := cfg.FQDNTemplate
+		if cfg.NodeFQDNTemplate != "" {
+			fqdnTemplate = cfg.NodeFQDNTemplate
+		}
+		return NewNodeSource(ctx, client, cfg.AnnotationFilter, fqdnTemplate, labelSelector, cfg.NodeExcludeNotReady, cfg.NodeAddressTypes)
 	case "service":
 		client, err := p.KubeClient()
 		if err != nil {
 			return nil, err
 		}
-		return NewServiceSource(ctx, client, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.Compatibility, cfg.PublishInternal, cfg.PublishHostIP, cfg.AlwaysPublishNotReadyAddresses, cfg.ServiceTypeFilter, cfg.IgnoreHostnameAnnotation, cfg.LabelFilter, cfg.ResolveLoadBalancerHostname)
+		return NewServiceSource(ctx, client, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.Compatibility, cfg.PublishInternal, cfg.PublishHostIP, cfg.AlwaysPublishNotReadyAddresses, cfg.ServiceTypeFilter, cfg.IgnoreHostnameAnnotation, cfg.LabelFilter, cfg.ResolveLoadBalancerHostname, cfg.ServiceFlaggerAware, cfg.ServiceFlaggerPublishCanary, cfg.NamespaceDefaultAnnotations)
 	case "ingress":
 		client, err := p.KubeClient()
 		if err != nil {
 			return nil, err
 		}
-		return NewIngressSource(ctx, client, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation, cfg.IgnoreIngressTLSSpec, cfg.IgnoreIngressRulesSpec, cfg.LabelFilter, cfg.IngressClassNames)
+		return NewIngressSource(ctx, client, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation, cfg.IgnoreIngressTLSSpec, cfg.IgnoreIngressRulesSpec, cfg.LabelFilter, cfg.IngressClassNames, cfg.NamespaceDefaultAnnotations)
 	case "pod":
 		client, err := p.KubeClient()
 		if err != nil {
 			return nil, err
 		}
-		return NewPodSource(ctx, client, cfg.Namespace, cfg.Compatibility)
+		return NewPodSource(ctx, client, cfg.Namespace, cfg.Compatibility, cfg.PodExcludeNotReady, cfg.PodSRVRecords)
 	case "gateway-httproute":
 		return NewGatewayHTTPRouteSource(p, cfg)
 	case "gateway-grpcroute":
@@ -250,7 +297,7 @@ func BuildWithConfig(ctx context.Context, source string, p ClientGenerator, cfg
 		if err != nil {
 			return nil, err
 		}
-		return NewIstioGatewaySource(ctx, kubernetesClient, istioClient, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation)
+		return NewIstioGatewaySource(ctx, kubernetesClient, istioClient, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation, cfg.LabelFilter)
 	case "istio-virtualservice":
 		kubernetesClient, err := p.KubeClient()
 		if err != nil {
@@ -260,7 +307,7 @@ func BuildWithConfig(ctx context.Context, source string, p ClientGenerator, cfg
 		if err != nil {
 			return nil, err
 		}
-		return NewIstioVirtualServiceSource(ctx, kubernetesClient, istioClient, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation)
+		return NewIstioVirtualServiceSource(ctx, kubernetesClient, istioClient, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.CombineFQDNAndAnnotation, cfg.IgnoreHostnameAnnotation, cfg.LabelFilter)
 	case "cloudfoundry":
 		cfClient, err := p.CloudFoundryClient(cfg.CFAPIEndpoint, cfg.CFUsername, cfg.CFPassword)
 		if err != nil {
@@ -313,6 +360,10 @@ func BuildWithConfig(ctx context.Context, source string, p ClientGenerator, cfg
 		return NewFakeSource(cfg.FQDNTemplate)
 	case "connector":
 		return NewConnectorSource(cfg.ConnectorServer)
+	case "webhook":
+		return NewWebhookSource(cfg.WebhookSourceURL, cfg.RequestTimeout)
+	case "http":
+		return NewHTTPSource(cfg.HTTPSourceURL, cfg.HTTPSourceHeaders, cfg.RequestTimeout)
 	case "crd":
 		client, err := p.KubeClient()
 		if err != nil {
@@ -322,7 +373,7 @@ func BuildWithConfig(ctx context.Context, source string, p ClientGenerator, cfg
 		if err != nil {
 			return nil, err
 		}
-		return NewCRDSource(crdClient, cfg.Namespace, cfg.CRDSourceKind, cfg.AnnotationFilter, cfg.LabelFilter, scheme, cfg.UpdateEvents)
+		return NewCRDSource(crdClient, cfg.Namespace, cfg.CRDSourceKind, cfg.AnnotationFilter, cfg.crdLabelSelector(), scheme, true)
 	case "skipper-routegroup":
 		apiServerURL := cfg.APIServerURL
 		tokenPath := ""
@@ -354,6 +405,38 @@ func BuildWithConfig(ctx context.Context, source string, p ClientGenerator, cfg
 			return nil, err
 		}
 		return NewF5VirtualServerSource(ctx, dynamicClient, kubernetesClient, cfg.Namespace, cfg.AnnotationFilter)
+	case "f5-transportserver":
+		kubernetesClient, err := p.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+		dynamicClient, err := p.DynamicKubernetesClient()
+		if err != nil {
+			return nil, err
+		}
+		return NewF5TransportServerSource(ctx, dynamicClient, kubernetesClient, cfg.Namespace, cfg.AnnotationFilter, cfg.IgnoreHostnameAnnotation)
+	case "argo-rollout":
+		kubernetesClient, err := p.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+		dynamicClient, err := p.DynamicKubernetesClient()
+		if err != nil {
+			return nil, err
+		}
+		return NewArgoRolloutSource(ctx, dynamicClient, kubernetesClient, cfg.Namespace, cfg.AnnotationFilter)
+	case "capi-machine":
+		dynamicClient, err := p.DynamicKubernetesClient()
+		if err != nil {
+			return nil, err
+		}
+		return NewCAPIMachineSource(ctx, dynamicClient, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.LabelFilter)
+	case "kubevirt-vmi":
+		dynamicClient, err := p.DynamicKubernetesClient()
+		if err != nil {
+			return nil, err
+		}
+		return NewKubeVirtVMISource(ctx, dynamicClient, cfg.Namespace, cfg.AnnotationFilter, cfg.FQDNTemplate, cfg.LabelFilter)
 	}
 
 	return nil, ErrSourceNotFound
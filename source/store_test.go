@@ -23,10 +23,13 @@ import (
 
 	cfclient "github.com/cloudfoundry-community/go-cfclient"
 	openshift "github.com/openshift/client-go/route/clientset/versioned"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
 	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
@@ -219,3 +222,27 @@ func (suite *ByNamesTestSuite) TestIstioClientFails() {
 func TestByNames(t *testing.T) {
 	suite.Run(t, new(ByNamesTestSuite))
 }
+
+func TestConfigCRDLabelSelector(t *testing.T) {
+	for _, tt := range []struct {
+		title                string
+		labelFilter          string
+		crdSourceLabelFilter string
+		expected             string
+	}{
+		{title: "no filters", expected: ""},
+		{title: "label filter", labelFilter: "app=web", expected: "app=web"},
+		{title: "crd source label filter", crdSourceLabelFilter: "tier=dns", expected: "tier=dns"},
+		{title: "both filters", labelFilter: "app=web", crdSourceLabelFilter: "tier=dns", expected: "app=web,tier=dns"},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			labelFilter, err := labels.Parse(tt.labelFilter)
+			require.NoError(t, err)
+			crdSourceLabelFilter, err := labels.Parse(tt.crdSourceLabelFilter)
+			require.NoError(t, err)
+
+			cfg := &Config{LabelFilter: labelFilter, CRDSourceLabelFilter: crdSourceLabelFilter}
+			assert.Equal(t, tt.expected, cfg.crdLabelSelector().String())
+		})
+	}
+}
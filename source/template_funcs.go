@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the functions available in the FQDN templates. Apart from trimPrefix, which predates them and
+// keeps its argument order, they behave like the Sprig functions of the same name, so that the value is the last
+// argument and can be piped.
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"trimPrefix": strings.TrimPrefix,
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"trim":       strings.TrimSpace,
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"trunc":      trunc,
+		"sha1sum":    func(s string) string { sum := sha1.Sum([]byte(s)); return hex.EncodeToString(sum[:]) },
+		"sha256sum":  func(s string) string { sum := sha256.Sum256([]byte(s)); return hex.EncodeToString(sum[:]) },
+		"default":    defaultValue,
+		"list":       func(values ...interface{}) []interface{} { return values },
+		"join":       join,
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+	}
+}
+
+// trunc returns the first length characters of s, or the last ones if length is negative.
+func trunc(length int, s string) string {
+	if length < 0 && len(s)+length > 0 {
+		return s[len(s)+length:]
+	}
+	if length >= 0 && len(s) > length {
+		return s[:length]
+	}
+	return s
+}
+
+// defaultValue returns the given value, or def if the value is missing or empty.
+func defaultValue(def interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || given[0] == nil {
+		return def
+	}
+	v := reflect.ValueOf(given[0])
+	if v.IsZero() || ((v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0) {
+		return def
+	}
+	return given[0]
+}
+
+// join joins the elements of a list, e.g. the output of list or split, with sep.
+func join(sep string, values interface{}) string {
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fmt.Sprint(values)
+	}
+	elems := make([]string, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elems = append(elems, fmt.Sprint(v.Index(i).Interface()))
+	}
+	return strings.Join(elems, sep)
+}
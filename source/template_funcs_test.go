@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExecTemplate(t *testing.T) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "My-Service",
+			Labels:      map[string]string{"team": "payments"},
+			Annotations: map[string]string{"aliases": "api,web"},
+		},
+	}
+
+	for _, tt := range []struct {
+		title    string
+		template string
+		expected []string
+	}{
+		{
+			title:    "comma separated hostnames",
+			template: "{{.Name}}.example.org, {{.Namespace}}.example.org.",
+			expected: []string{"My-Service.example.org", "default.example.org"},
+		},
+		{
+			title:    "labels and lower",
+			template: `{{index .Labels "team"}}-{{.Name | lower}}.example.org`,
+			expected: []string{"payments-my-service.example.org"},
+		},
+		{
+			title:    "default for a missing label",
+			template: `{{index .Labels "owner" | default "shared"}}.example.org`,
+			expected: []string{"shared.example.org"},
+		},
+		{
+			title:    "trunc and sha1sum",
+			template: `{{.Name | lower | sha1sum | trunc 8}}.example.org`,
+			expected: []string{"e9ead9b1.example.org"},
+		},
+		{
+			title:    "range over a split annotation",
+			template: `{{range split "," (index .Annotations "aliases")}}{{.}}.example.org {{end}}`,
+			expected: []string{"api.example.org", "web.example.org"},
+		},
+		{
+			title:    "joined list",
+			template: `{{list "a" "b" | join ".example.org," }}.example.org`,
+			expected: []string{"a.example.org", "b.example.org"},
+		},
+		{
+			title:    "trimPrefix keeps its argument order",
+			template: `{{trimPrefix .Name "My-"}}.example.org`,
+			expected: []string{"Service.example.org"},
+		},
+		{
+			title:    "empty output",
+			template: `{{if eq .Name "other"}}{{.Name}}.example.org{{end}}`,
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			tmpl, err := parseTemplate(tt.template)
+			require.NoError(t, err)
+
+			hostnames, err := execTemplate(tmpl, svc)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, hostnames)
+		})
+	}
+}
+
+func TestTrunc(t *testing.T) {
+	assert.Equal(t, "abc", trunc(3, "abcdef"))
+	assert.Equal(t, "def", trunc(-3, "abcdef"))
+	assert.Equal(t, "abc", trunc(5, "abc"))
+	assert.Equal(t, "abc", trunc(-5, "abc"))
+}
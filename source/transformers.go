@@ -0,0 +1,278 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// TransformerStage is a named stage of the transformer pipeline with its options.
+type TransformerStage struct {
+	Name    string            `yaml:"name"`
+	Options map[string]string `yaml:"options,omitempty"`
+}
+
+// TransformerConfig holds the settings of the stages which are configured by flags. The options
+// of a stage take precedence over them.
+type TransformerConfig struct {
+	NAT64Networks []string
+	TargetFilter  endpoint.TargetFilterInterface
+}
+
+// TransformerFactory wraps a source with the transformer of a stage, which adjusts or filters the
+// endpoints of the source.
+type TransformerFactory func(source Source, cfg *TransformerConfig, options map[string]string) (Source, error)
+
+// Transformers is a registry of available transformers. Plan mutators can be used as stages, too.
+var Transformers = map[string]TransformerFactory{
+	"dedup":         newDedupTransformer,
+	"nat64":         newNAT64Transformer,
+	"target-filter": newTargetFilterTransformer,
+	"ttl-clamp":     newTTLClampTransformer,
+}
+
+// DefaultTransformerStages are the stages run when no pipeline is configured.
+var DefaultTransformerStages = []TransformerStage{
+	{Name: "dedup"},
+	{Name: "nat64"},
+	{Name: "target-filter"},
+}
+
+// RegisterTransformer makes a transformer available under the given name. It panics if the name
+// is already taken, so it's meant to be called from init functions.
+func RegisterTransformer(name string, factory TransformerFactory) {
+	if _, exists := Transformers[name]; exists {
+		panic(fmt.Sprintf("endpoint transformer %q is already registered", name))
+	}
+	Transformers[name] = factory
+}
+
+// LoadTransformerStages reads the stages of the transformer pipeline from a YAML file of the form
+//
+//	stages:
+//	- name: dedup
+//	- name: ttl-clamp
+//	  options:
+//	    min: 1m
+func LoadTransformerStages(path string) ([]TransformerStage, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transformer config file '%s': %w", path, err)
+	}
+	config := struct {
+		Stages []TransformerStage `yaml:"stages"`
+	}{}
+	if err := yaml.UnmarshalStrict(contents, &config); err != nil {
+		return nil, fmt.Errorf("failed to read transformer config file '%s': %w", path, err)
+	}
+	if len(config.Stages) == 0 {
+		return nil, fmt.Errorf("transformer config file '%s' has no stages", path)
+	}
+	return config.Stages, nil
+}
+
+// NewTransformerPipeline wraps the source with the transformers of the given stages, so the
+// endpoints of the source are passed through the stages in order.
+func NewTransformerPipeline(source Source, stages []TransformerStage, cfg *TransformerConfig) (Source, error) {
+	for _, stage := range stages {
+		factory, ok := Transformers[stage.Name]
+		if !ok {
+			mutator, ok := plan.Mutators[stage.Name]
+			if !ok {
+				return nil, fmt.Errorf("unknown endpoint transformer %q", stage.Name)
+			}
+			factory = newMutatorTransformer(mutator)
+		}
+		wrapped, err := factory(source, cfg, stage.Options)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint transformer %q: %w", stage.Name, err)
+		}
+		source = wrapped
+	}
+	return source, nil
+}
+
+// TransformerStageNames returns the names of the stages in order.
+func TransformerStageNames(stages []TransformerStage) []string {
+	names := make([]string, 0, len(stages))
+	for _, stage := range stages {
+		names = append(names, stage.Name)
+	}
+	return names
+}
+
+// checkOptions returns an error for options which are not in allowed.
+func checkOptions(options map[string]string, allowed ...string) error {
+	unknown := []string{}
+	for name := range options {
+		if !slices.Contains(allowed, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown options %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// splitOption splits a comma separated option.
+func splitOption(value string) []string {
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func newDedupTransformer(source Source, _ *TransformerConfig, options map[string]string) (Source, error) {
+	if err := checkOptions(options); err != nil {
+		return nil, err
+	}
+	return NewDedupSource(source), nil
+}
+
+func newNAT64Transformer(source Source, cfg *TransformerConfig, options map[string]string) (Source, error) {
+	if err := checkOptions(options, "networks"); err != nil {
+		return nil, err
+	}
+	networks := cfg.NAT64Networks
+	if value, ok := options["networks"]; ok {
+		networks = splitOption(value)
+	}
+	return NewNAT64Source(source, networks), nil
+}
+
+func newTargetFilterTransformer(source Source, cfg *TransformerConfig, options map[string]string) (Source, error) {
+	if err := checkOptions(options, "include", "exclude"); err != nil {
+		return nil, err
+	}
+	targetFilter := cfg.TargetFilter
+	include, hasInclude := options["include"]
+	exclude, hasExclude := options["exclude"]
+	if hasInclude || hasExclude {
+		targetFilter = endpoint.NewTargetNetFilterWithExclusions(splitOption(include), splitOption(exclude))
+	}
+	if targetFilter == nil {
+		return nil, errors.New("no target filter configured")
+	}
+	return NewTargetFilterSource(source, targetFilter), nil
+}
+
+// ttlClampSource is a Source that limits the configured TTLs of the endpoints of its wrapped source
+// to a range. Endpoints without a configured TTL keep the default TTL of the provider.
+type ttlClampSource struct {
+	source Source
+	min    endpoint.TTL
+	max    endpoint.TTL
+}
+
+func newTTLClampTransformer(source Source, _ *TransformerConfig, options map[string]string) (Source, error) {
+	if err := checkOptions(options, "min", "max"); err != nil {
+		return nil, err
+	}
+	s := &ttlClampSource{source: source}
+	for name, ttl := range map[string]*endpoint.TTL{"min": &s.min, "max": &s.max} {
+		value, ok := options[name]
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("invalid %s TTL %q", name, value)
+		}
+		*ttl = endpoint.TTL(d.Seconds())
+	}
+	if s.min == 0 && s.max == 0 {
+		return nil, errors.New("requires the option min or max")
+	}
+	if s.max != 0 && s.min > s.max {
+		return nil, fmt.Errorf("min TTL %ds is greater than max TTL %ds", s.min, s.max)
+	}
+	return s, nil
+}
+
+// Endpoints collects endpoints from its wrapped source and returns them with clamped TTLs.
+func (s *ttlClampSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	endpoints, err := s.source.Endpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ep := range endpoints {
+		if !ep.RecordTTL.IsConfigured() {
+			continue
+		}
+		ttl := max(ep.RecordTTL, s.min)
+		if s.max != 0 {
+			ttl = min(ttl, s.max)
+		}
+		if ttl != ep.RecordTTL {
+			log.Debugf("Clamping TTL of endpoint %s from %ds to %ds", ep, ep.RecordTTL, ttl)
+			ep.RecordTTL = ttl
+		}
+	}
+
+	return endpoints, nil
+}
+
+func (s *ttlClampSource) AddEventHandler(ctx context.Context, handler func()) {
+	s.source.AddEventHandler(ctx, handler)
+}
+
+// mutatorSource is a Source that runs a plan mutator on the endpoints of its wrapped source.
+type mutatorSource struct {
+	source  Source
+	mutator plan.Mutator
+}
+
+func newMutatorTransformer(mutator plan.Mutator) TransformerFactory {
+	return func(source Source, _ *TransformerConfig, options map[string]string) (Source, error) {
+		if err := checkOptions(options); err != nil {
+			return nil, err
+		}
+		return &mutatorSource{source: source, mutator: mutator}, nil
+	}
+}
+
+// Endpoints collects endpoints from its wrapped source and returns them as mutated.
+func (s *mutatorSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	endpoints, err := s.source.Endpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.mutator.Mutate(endpoints), nil
+}
+
+func (s *mutatorSource) AddEventHandler(ctx context.Context, handler func()) {
+	s.source.AddEventHandler(ctx, handler)
+}
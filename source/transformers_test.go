@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+)
+
+// Validates that ttlClampSource and mutatorSource are Sources
+var (
+	_ Source = &ttlClampSource{}
+	_ Source = &mutatorSource{}
+)
+
+func TestLoadTransformerStages(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+		return path
+	}
+
+	stages, err := LoadTransformerStages(write("valid.yaml", `
+stages:
+- name: ttl-clamp
+  options:
+    min: 1m
+- name: dedup
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []TransformerStage{
+		{Name: "ttl-clamp", Options: map[string]string{"min": "1m"}},
+		{Name: "dedup"},
+	}, stages)
+
+	_, err = LoadTransformerStages(write("empty.yaml", "stages: []\n"))
+	assert.Error(t, err)
+	_, err = LoadTransformerStages(write("unknown.yaml", "stage:\n- name: dedup\n"))
+	assert.Error(t, err)
+	_, err = LoadTransformerStages(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestTransformerPipeline(t *testing.T) {
+	for _, tc := range []struct {
+		title     string
+		stages    []TransformerStage
+		endpoints []*endpoint.Endpoint
+		expected  []*endpoint.Endpoint
+	}{
+		{
+			"default stages",
+			DefaultTransformerStages,
+			[]*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeAAAA, "2001:db8::192.0.2.42"),
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeAAAA, "2001:db8::192.0.2.42"),
+				endpoint.NewEndpoint("bar.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+			},
+			[]*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeAAAA, "2001:db8::192.0.2.42"),
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "192.0.2.42"),
+			},
+		},
+		{
+			"stage options override the flags",
+			[]TransformerStage{{Name: "target-filter", Options: map[string]string{"exclude": "192.0.2.0/24"}}},
+			[]*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "192.0.2.42", "10.0.0.1"),
+			},
+			[]*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "10.0.0.1"),
+			},
+		},
+		{
+			"ttl clamp",
+			[]TransformerStage{{Name: "ttl-clamp", Options: map[string]string{"min": "1m", "max": "1h"}}},
+			[]*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("low.example.org", endpoint.RecordTypeA, 10, "192.0.2.1"),
+				endpoint.NewEndpointWithTTL("high.example.org", endpoint.RecordTypeA, 86400, "192.0.2.1"),
+				endpoint.NewEndpointWithTTL("fine.example.org", endpoint.RecordTypeA, 300, "192.0.2.1"),
+				endpoint.NewEndpoint("default.example.org", endpoint.RecordTypeA, "192.0.2.1"),
+			},
+			[]*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("low.example.org", endpoint.RecordTypeA, 60, "192.0.2.1"),
+				endpoint.NewEndpointWithTTL("high.example.org", endpoint.RecordTypeA, 3600, "192.0.2.1"),
+				endpoint.NewEndpointWithTTL("fine.example.org", endpoint.RecordTypeA, 300, "192.0.2.1"),
+				endpoint.NewEndpoint("default.example.org", endpoint.RecordTypeA, "192.0.2.1"),
+			},
+		},
+		{
+			"plan mutator as stage",
+			[]TransformerStage{{Name: "lowercase-names"}, {Name: "dedup"}},
+			[]*endpoint.Endpoint{
+				endpoint.NewEndpoint("Foo.example.org", endpoint.RecordTypeA, "192.0.2.1"),
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "192.0.2.1"),
+			},
+			[]*endpoint.Endpoint{
+				endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "192.0.2.1"),
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockSource := new(testutils.MockSource)
+			mockSource.On("Endpoints").Return(tc.endpoints, nil)
+
+			source, err := NewTransformerPipeline(mockSource, tc.stages, &TransformerConfig{
+				NAT64Networks: []string{"2001:db8::/96"},
+				TargetFilter:  endpoint.NewTargetNetFilterWithExclusions([]string{"2001:db8::/32", "192.0.2.0/24"}, nil),
+			})
+			require.NoError(t, err)
+
+			endpoints, err := source.Endpoints(context.Background())
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, tc.expected)
+			mockSource.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTransformerPipelineErrors(t *testing.T) {
+	for _, tc := range []struct {
+		title  string
+		stages []TransformerStage
+	}{
+		{"unknown stage", []TransformerStage{{Name: "unknown"}}},
+		{"unknown option", []TransformerStage{{Name: "dedup", Options: map[string]string{"keep": "last"}}}},
+		{"ttl clamp without range", []TransformerStage{{Name: "ttl-clamp"}}},
+		{"ttl clamp with invalid duration", []TransformerStage{{Name: "ttl-clamp", Options: map[string]string{"min": "soon"}}}},
+		{"ttl clamp with inverted range", []TransformerStage{{Name: "ttl-clamp", Options: map[string]string{"min": "1h", "max": "1m"}}}},
+		{"target filter without filter", []TransformerStage{{Name: "target-filter"}}},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			_, err := NewTransformerPipeline(new(testutils.MockSource), tc.stages, &TransformerConfig{})
+			assert.Error(t, err)
+		})
+	}
+}
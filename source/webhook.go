@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	backoff "github.com/cenkalti/backoff/v4"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+const (
+	// WebhookSourceMediaType is the media type of the requests and responses of the webhook source protocol.
+	WebhookSourceMediaType = "application/external.dns.source+json;version=1"
+
+	webhookSourceEndpointsPath = "/endpoints"
+	webhookSourceEventsPath    = "/events"
+)
+
+// webhookSource is an implementation of Source that reads the endpoints from an external
+// process over HTTP, see docs/sources/webhook.md for the protocol.
+type webhookSource struct {
+	client          *http.Client
+	remoteServerURL *url.URL
+}
+
+// NewWebhookSource creates a new webhookSource reading the endpoints from the server at the given URL.
+func NewWebhookSource(u string, requestTimeout time.Duration) (Source, error) {
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("invalid webhook source URL %q: the scheme must be http or https", u)
+	}
+
+	return &webhookSource{
+		client:          &http.Client{Timeout: requestTimeout},
+		remoteServerURL: parsedURL,
+	}, nil
+}
+
+// Endpoints returns the endpoints served by the webhook.
+func (ws *webhookSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ws.remoteServerURL.JoinPath(webhookSourceEndpointsPath).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", WebhookSourceMediaType)
+
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get endpoints from webhook source: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get endpoints from webhook source: unexpected status code %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != WebhookSourceMediaType {
+		return nil, fmt.Errorf("failed to get endpoints from webhook source: wrong content type %q, expected %q", contentType, WebhookSourceMediaType)
+	}
+
+	var endpoints []*endpoint.Endpoint
+	if err := json.NewDecoder(resp.Body).Decode(&endpoints); err != nil {
+		return nil, fmt.Errorf("failed to decode endpoints from webhook source: %w", err)
+	}
+
+	for _, ep := range endpoints {
+		if ep.Labels == nil {
+			ep.Labels = endpoint.NewLabels()
+		}
+	}
+	endpoints = withoutExcludedEndpoints(endpoints, "webhook")
+
+	log.Debugf("Received endpoints from webhook source: %v", endpoints)
+
+	return endpoints, nil
+}
+
+// AddEventHandler watches the event stream of the webhook and calls the handler for every event.
+// The stream is reopened with an exponential backoff until the context is done.
+func (ws *webhookSource) AddEventHandler(ctx context.Context, handler func()) {
+	log.Debug("Adding event handler for webhook source")
+
+	go func() {
+		retry := backoff.NewExponentialBackOff()
+		retry.MaxElapsedTime = 0
+		for {
+			connected, err := ws.watchEvents(ctx, handler)
+			if ctx.Err() != nil {
+				return
+			}
+			if errors.Is(err, errWebhookSourceEventsUnsupported) {
+				log.Info("Webhook source does not serve events, relying on the synchronization interval")
+				return
+			}
+			if connected {
+				retry.Reset()
+			}
+			log.Debugf("Webhook source event stream closed: %v", err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retry.NextBackOff()):
+			}
+		}
+	}()
+}
+
+var errWebhookSourceEventsUnsupported = errors.New("webhook source does not serve events")
+
+// watchEvents reads the event stream until it ends. It reports whether the stream was opened.
+func (ws *webhookSource) watchEvents(ctx context.Context, handler func()) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ws.remoteServerURL.JoinPath(webhookSourceEventsPath).String(), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", WebhookSourceMediaType)
+
+	// The request timeout does not apply to the stream, which stays open between events.
+	client := *ws.client
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusNotImplemented:
+		return false, errWebhookSourceEventsUnsupported
+	default:
+		return false, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	// Every line is an event, its content is reserved for future use.
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		handler()
+	}
+	return true, scanner.Err()
+}
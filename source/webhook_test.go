@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// This is a compile-time validation that webhookSource is a Source.
+var _ Source = &webhookSource{}
+
+func TestWebhookSourceEndpoints(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		title       string
+		status      int
+		contentType string
+		body        string
+		expected    []*endpoint.Endpoint
+		expectError bool
+	}{
+		{
+			title:       "endpoints",
+			status:      http.StatusOK,
+			contentType: WebhookSourceMediaType,
+			body:        `[{"dnsName":"vm-1.example.org","targets":["10.0.0.1"],"recordType":"A","recordTTL":300},{"dnsName":"app.example.org","targets":["vm-1.example.org"],"recordType":"CNAME","labels":{"resource":"cmdb/app"}}]`,
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpointWithTTL("vm-1.example.org", endpoint.RecordTypeA, 300, "10.0.0.1"),
+				{
+					DNSName:    "app.example.org",
+					Targets:    endpoint.Targets{"vm-1.example.org"},
+					RecordType: endpoint.RecordTypeCNAME,
+					Labels:     endpoint.Labels{endpoint.ResourceLabelKey: "cmdb/app"},
+				},
+			},
+		},
+		{
+			title:       "excluded endpoint",
+			status:      http.StatusOK,
+			contentType: WebhookSourceMediaType,
+			body:        `[{"dnsName":"vm-1.example.org","targets":["10.0.0.1"],"recordType":"A","labels":{"external-dns.alpha.kubernetes.io/exclude":"true"}},{"dnsName":"vm-2.example.org","targets":["10.0.0.2"],"recordType":"A","labels":{"external-dns.alpha.kubernetes.io/exclude":"false"}}]`,
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("vm-2.example.org", endpoint.RecordTypeA, "10.0.0.2"),
+			},
+		},
+		{
+			title:       "no endpoints",
+			status:      http.StatusOK,
+			contentType: WebhookSourceMediaType,
+			body:        `[]`,
+			expected:    []*endpoint.Endpoint{},
+		},
+		{
+			title:       "server error",
+			status:      http.StatusInternalServerError,
+			contentType: WebhookSourceMediaType,
+			expectError: true,
+		},
+		{
+			title:       "wrong content type",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `[]`,
+			expectError: true,
+		},
+		{
+			title:       "invalid body",
+			status:      http.StatusOK,
+			contentType: WebhookSourceMediaType,
+			body:        `{`,
+			expectError: true,
+		},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, webhookSourceEndpointsPath, r.URL.Path)
+				assert.Equal(t, WebhookSourceMediaType, r.Header.Get("Accept"))
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			source, err := NewWebhookSource(server.URL, time.Second)
+			require.NoError(t, err)
+
+			endpoints, err := source.Endpoints(context.Background())
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			validateEndpoints(t, endpoints, tt.expected)
+			for _, ep := range endpoints {
+				assert.NotNil(t, ep.Labels)
+				assert.NotContains(t, ep.Labels, excludeAnnotationKey)
+			}
+		})
+	}
+}
+
+func TestWebhookSourceInvalidURL(t *testing.T) {
+	_, err := NewWebhookSource("localhost:8889", time.Second)
+	require.Error(t, err)
+}
+
+func TestWebhookSourceEventHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, webhookSourceEventsPath, r.URL.Path)
+		w.Header().Set("Content-Type", WebhookSourceMediaType)
+		for i := 0; i < 2; i++ {
+			fmt.Fprintln(w, `{}`)
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	source, err := NewWebhookSource(server.URL, time.Second)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan struct{}, 2)
+	source.AddEventHandler(ctx, func() { events <- struct{}{} })
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-events:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an event")
+		}
+	}
+}
+
+func TestWebhookSourceEventsUnsupported(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	source, err := NewWebhookSource(server.URL, time.Second)
+	require.NoError(t, err)
+
+	ws := source.(*webhookSource)
+	connected, err := ws.watchEvents(context.Background(), func() {})
+	assert.False(t, connected)
+	assert.ErrorIs(t, err, errWebhookSourceEventsUnsupported)
+}